@@ -5,14 +5,18 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os/exec"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/require"
 
 	"github.com/osbuild/image-builder/internal/common"
@@ -100,7 +104,7 @@ func tearDown(t *testing.T) {
 
 func testInsertCompose(t *testing.T) {
 	ctx := context.Background()
-	d, err := db.InitDBConnectionPool(connStr(t))
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
 	require.NoError(t, err)
 
 	imageName := "MyImageName"
@@ -110,33 +114,58 @@ func testInsertCompose(t *testing.T) {
 
 	migrateTern(t)
 
-	err = d.InsertBlueprint(ctx, blueprintId, versionId, ORGID1, ANR1, "blueprint", "blueprint desc", []byte("{}"), []byte("{}"))
+	err = d.InsertBlueprint(ctx, blueprintId, versionId, ORGID1, ANR1, "blueprint", "blueprint desc", []byte("{}"), []byte("{}"), db.BlueprintOptions{})
 	require.NoError(t, err)
 
 	// test
-	err = d.InsertCompose(ctx, uuid.New(), "", "", ORGID1, &imageName, []byte("{}"), &clientId, &versionId)
+	err = d.InsertCompose(ctx, uuid.New(), "", "", ORGID1, &imageName, []byte("{}"), &clientId, &versionId, nil, nil)
 	require.NoError(t, err)
-	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, &imageName, []byte("{}"), &clientId, nil)
+	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, &imageName, []byte("{}"), &clientId, nil, nil, nil)
 	require.NoError(t, err)
-	err = d.InsertCompose(ctx, uuid.New(), "", "", ORGID1, &imageName, []byte("{}"), &clientId, nil)
+	err = d.InsertCompose(ctx, uuid.New(), "", "", ORGID1, &imageName, []byte("{}"), &clientId, nil, nil, nil)
 	require.NoError(t, err)
 }
 
+// the modes safe behind pgbouncer in transaction mode don't rely on prepared
+// statements, queries have to work without them too
+func testStatementCacheModes(t *testing.T) {
+	ctx := context.Background()
+	for _, mode := range []string{"describe", "none"} {
+		d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{MaxConns: 2, StatementCacheMode: mode})
+		require.NoError(t, err)
+
+		blueprintId := uuid.New()
+		versionId := uuid.New()
+		err = d.InsertBlueprint(ctx, blueprintId, versionId, ORGID1, ANR1, "blueprint "+mode, "blueprint desc", []byte("{}"), []byte("{}"), db.BlueprintOptions{})
+		require.NoError(t, err, mode)
+		blueprint, err := d.GetBlueprint(ctx, blueprintId, ORGID1, nil)
+		require.NoError(t, err, mode)
+		require.Equal(t, versionId, blueprint.VersionId)
+
+		composeId := uuid.New()
+		err = d.InsertCompose(ctx, composeId, ANR1, EMAIL1, ORGID1, nil, []byte(`{"image_requests": []}`), nil, &versionId, nil, nil)
+		require.NoError(t, err, mode)
+		compose, err := d.GetCompose(ctx, composeId, ORGID1)
+		require.NoError(t, err, mode)
+		require.Equal(t, versionId, *compose.BlueprintVersionId)
+	}
+}
+
 func testGetCompose(t *testing.T) {
 	ctx := context.Background()
-	d, err := db.InitDBConnectionPool(connStr(t))
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
 	require.NoError(t, err)
 
 	imageName := "MyImageName"
 	clientId := "ui"
 
-	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, &imageName, []byte("{}"), &clientId, nil)
+	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, &imageName, []byte("{}"), &clientId, nil, nil, nil)
 	require.NoError(t, err)
-	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, &imageName, []byte("{}"), &clientId, nil)
+	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, &imageName, []byte("{}"), &clientId, nil, nil, nil)
 	require.NoError(t, err)
-	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, &imageName, []byte("{}"), &clientId, nil)
+	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, &imageName, []byte("{}"), &clientId, nil, nil, nil)
 	require.NoError(t, err)
-	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, &imageName, []byte("{}"), &clientId, nil)
+	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, &imageName, []byte("{}"), &clientId, nil, nil, nil)
 	require.NoError(t, err)
 
 	// test
@@ -157,16 +186,227 @@ func testGetCompose(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, composes[0].Id, compose.Id)
 
+	require.Nil(t, compose.ParentComposeId)
+
 	// cross-account compose access not allowed
 	compose, err = d.GetCompose(ctx, composes[0].Id, ORGID2)
 	require.Equal(t, db.ComposeNotFoundError, err)
 	require.Nil(t, compose)
 
+	// retries link to the compose they retried
+	retryId := uuid.New()
+	err = d.InsertCompose(ctx, retryId, ANR1, EMAIL1, ORGID1, &imageName, []byte("{}"), &clientId, nil, nil, &composes[0].Id)
+	require.NoError(t, err)
+	compose, err = d.GetCompose(ctx, retryId, ORGID1)
+	require.NoError(t, err)
+	require.Equal(t, composes[0].Id, *compose.ParentComposeId)
+}
+
+func testComposeGroups(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	groupId := uuid.New()
+	require.NoError(t, d.InsertComposeGroup(ctx, groupId, ORGID1))
+
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+	for _, id := range ids {
+		require.NoError(t, d.InsertCompose(ctx, id, ANR1, EMAIL1, ORGID1, nil, []byte("{}"), nil, nil, nil, nil))
+		require.NoError(t, d.AddComposeToGroup(ctx, groupId, id))
+	}
+	require.ErrorIs(t, d.AddComposeToGroup(ctx, groupId, uuid.New()), db.ComposeNotFoundError)
+
+	group, err := d.GetComposeGroup(ctx, groupId, ORGID1)
+	require.NoError(t, err)
+	require.Len(t, group, 2)
+	for i, compose := range group {
+		require.Equal(t, ids[i], compose.Id)
+		require.Equal(t, groupId, *compose.GroupId)
+	}
+	compose, err := d.GetCompose(ctx, ids[0], ORGID1)
+	require.NoError(t, err)
+	require.Equal(t, groupId, *compose.GroupId)
+
+	// groups are per org and leave out deleted composes
+	group, err = d.GetComposeGroup(ctx, groupId, ORGID2)
+	require.NoError(t, err)
+	require.Empty(t, group)
+	require.NoError(t, d.DeleteCompose(ctx, ids[1], ORGID1))
+	group, err = d.GetComposeGroup(ctx, groupId, ORGID1)
+	require.NoError(t, err)
+	require.Len(t, group, 1)
+}
+
+func testComposeQueryPlans(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, nil, []byte(`{"image_requests": [{"image_type": "aws"}]}`), nil, nil, nil, nil)
+		require.NoError(t, err)
+	}
+
+	plans, err := d.ExplainComposeQueries(ctx, ORGID1)
+	require.NoError(t, err)
+	require.NotEmpty(t, plans)
+	for name, plan := range plans {
+		require.NotContains(t, plan.SeqScans(), "composes", name)
+		require.Contains(t, plan.Indexes(), "composes_org_listing_idx", name)
+	}
+}
+
+func testGetComposesAfter(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, nil, []byte("{}"), nil, nil, nil, nil)
+		require.NoError(t, err)
+	}
+
+	all, _, err := d.GetComposes(ctx, ORGID1, fortnight, 100, 0, []string{})
+	require.NoError(t, err)
+	require.Len(t, all, 4)
+
+	first, count, err := d.GetComposes(ctx, ORGID1, fortnight, 2, 0, []string{})
+	require.NoError(t, err)
+	require.Equal(t, 4, count)
+	require.Len(t, first, 2)
+
+	// a compose created mid-iteration doesn't shift the next page
+	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, nil, []byte("{}"), nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	second, count, err := d.GetComposesAfter(ctx, ORGID1, fortnight, 2, db.ComposeCursor{
+		CreatedAt: first[1].CreatedAt,
+		Id:        first[1].Id,
+	}, []string{})
+	require.NoError(t, err)
+	require.Equal(t, 5, count)
+	require.Len(t, second, 2)
+	require.Equal(t, all[2].Id, second[0].Id)
+	require.Equal(t, all[3].Id, second[1].Id)
+
+	rest, _, err := d.GetComposesAfter(ctx, ORGID1, fortnight, 2, db.ComposeCursor{
+		CreatedAt: second[1].CreatedAt,
+		Id:        second[1].Id,
+	}, []string{})
+	require.NoError(t, err)
+	require.Empty(t, rest)
+}
+
+func testGetComposesFiltered(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	var ids []uuid.UUID
+	for _, request := range []string{
+		`{"distribution": "rhel-9", "image_requests": [{"image_type": "aws"}]}`,
+		`{"distribution": "rhel-9", "image_requests": [{"image_type": "guest-image"}]}`,
+		`{"distribution": "rhel-8", "image_requests": [{"image_type": "qcow2"}]}`,
+		`{"distribution": "rhel-8", "image_requests": [{"image_type": "aws"}]}`,
+	} {
+		id := uuid.New()
+		err = d.InsertCompose(ctx, id, ANR1, EMAIL1, ORGID1, nil, []byte(request), nil, nil, nil, nil)
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID2, nil, []byte(`{"distribution": "rhel-9", "image_requests": [{"image_type": "aws"}]}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, d.SetComposeStatus(ctx, ids[0], "success", nil))
+	require.NoError(t, d.SetComposeStatus(ctx, ids[1], "failure", nil))
+
+	composeIds := func(composes []db.ComposeWithBlueprintVersion) []uuid.UUID {
+		var result []uuid.UUID
+		for _, c := range composes {
+			result = append(result, c.Id)
+		}
+		return result
+	}
+
+	all, count, err := d.GetComposesFiltered(ctx, ORGID1, db.ComposeFilter{Since: fortnight}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 4, count)
+	require.Equal(t, []uuid.UUID{ids[3], ids[2], ids[1], ids[0]}, composeIds(all))
+
+	composes, count, err := d.GetComposesFiltered(ctx, ORGID1, db.ComposeFilter{Since: fortnight, Ascending: true}, 2, 1)
+	require.NoError(t, err)
+	require.Equal(t, 4, count)
+	require.Equal(t, []uuid.UUID{ids[1], ids[2]}, composeIds(composes))
+
+	composes, count, err = d.GetComposesFiltered(ctx, ORGID1, db.ComposeFilter{
+		Since:     fortnight,
+		Ascending: true,
+		After:     &db.ComposeCursor{CreatedAt: all[2].CreatedAt, Id: all[2].Id},
+	}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 4, count)
+	require.Equal(t, []uuid.UUID{ids[2], ids[3]}, composeIds(composes))
+
+	composes, count, err = d.GetComposesFiltered(ctx, ORGID1, db.ComposeFilter{Since: fortnight, ImageTypes: []string{"guest-image", "qcow2"}}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	require.Equal(t, []uuid.UUID{ids[2], ids[1]}, composeIds(composes))
+
+	composes, count, err = d.GetComposesFiltered(ctx, ORGID1, db.ComposeFilter{Since: fortnight, Distributions: []string{"rhel-8"}, IgnoreImageTypes: []string{"qcow2"}}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	require.Equal(t, []uuid.UUID{ids[3]}, composeIds(composes))
+
+	composes, _, err = d.GetComposesFiltered(ctx, ORGID1, db.ComposeFilter{Since: fortnight, Statuses: []string{"success"}}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{ids[0]}, composeIds(composes))
+	composes, _, err = d.GetComposesFiltered(ctx, ORGID1, db.ComposeFilter{Since: fortnight, Statuses: []string{db.ComposeStatusUnfinished, "failure"}}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{ids[3], ids[2], ids[1]}, composeIds(composes))
+
+	composes, count, err = d.GetComposesFiltered(ctx, ORGID1, db.ComposeFilter{
+		Since:         fortnight,
+		CreatedAfter:  &all[2].CreatedAt,
+		CreatedBefore: &all[0].CreatedAt,
+	}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	require.Equal(t, []uuid.UUID{ids[2], ids[1]}, composeIds(composes))
+
+	// the skipped columns are left empty
+	composes, _, err = d.GetComposesFiltered(ctx, ORGID1, db.ComposeFilter{Since: fortnight, SkipRequest: true, SkipBlueprint: true}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, composeIds(all), composeIds(composes))
+	for _, c := range composes {
+		require.Nil(t, c.Request)
+		require.Nil(t, c.BlueprintId)
+	}
+	require.Equal(t, *all[3].Status, *composes[3].Status)
+
+	require.NoError(t, d.SetComposeLabels(ctx, ids[0], map[string]string{"team": "platform", "env": "prod"}))
+	require.NoError(t, d.SetComposeLabels(ctx, ids[2], map[string]string{"team": "platform", "env": "stage"}))
+	composes, count, err = d.GetComposesFiltered(ctx, ORGID1, db.ComposeFilter{Since: fortnight, Labels: map[string]string{"team": "platform"}}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	require.Equal(t, []uuid.UUID{ids[2], ids[0]}, composeIds(composes))
+	composes, count, err = d.GetComposesFiltered(ctx, ORGID1, db.ComposeFilter{Since: fortnight, Labels: map[string]string{"team": "platform", "env": "prod"}}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	require.Equal(t, []uuid.UUID{ids[0]}, composeIds(composes))
+	_, count, err = d.GetComposesFiltered(ctx, ORGID2, db.ComposeFilter{Since: fortnight, Labels: map[string]string{"team": "platform"}}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+
+	// deleted composes are never listed
+	require.NoError(t, d.DeleteCompose(ctx, ids[0], ORGID1))
+	_, count, err = d.GetComposesFiltered(ctx, ORGID1, db.ComposeFilter{Since: fortnight}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
 }
 
 func testCountComposesSince(t *testing.T) {
 	ctx := context.Background()
-	d, err := db.InitDBConnectionPool(connStr(t))
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
 	require.NoError(t, err)
 
 	imageName := "MyImageName"
@@ -198,9 +438,318 @@ func testCountComposesSince(t *testing.T) {
 	require.Equal(t, 3, count)
 }
 
+func testReserveCompose(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, nil, []byte("{}"), nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	// concurrent reservations don't all see the count before the others
+	var wg sync.WaitGroup
+	reservations := make([]db.ComposeReservation, 10)
+	errs := make([]error, len(reservations))
+	for i := range reservations {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reservations[i] = db.ComposeReservation{Id: uuid.New(), OrgId: ORGID1, Quota: common.ToPtr(4), Window: time.Hour}
+			errs[i] = d.ReserveCompose(ctx, &reservations[i])
+		}(i)
+	}
+	wg.Wait()
+	var reserved []db.ComposeReservation
+	for i, err := range errs {
+		if err != nil {
+			require.ErrorIs(t, err, db.QuotaExceededError)
+			continue
+		}
+		reserved = append(reserved, reservations[i])
+	}
+	require.Len(t, reserved, 3)
+
+	// a recorded compose keeps its slot, a released one frees it
+	composeId := uuid.New()
+	err = d.InsertReservedCompose(ctx, &reserved[0], db.ReservedCompose{
+		JobId:            composeId,
+		AccountNumber:    ANR1,
+		Email:            EMAIL1,
+		Request:          []byte("{}"),
+		ExpiresAt:        common.ToPtr(time.Now().Add(time.Hour)),
+		Labels:           map[string]string{"team": "platform"},
+		InjectedDefaults: []byte(`{"timezone": true}`),
+	})
+	require.NoError(t, err)
+	compose, err := d.GetCompose(ctx, composeId, ORGID1)
+	require.NoError(t, err)
+	require.NotNil(t, compose.ExpiresAt)
+	injected, err := d.GetComposeInjectedDefaults(ctx, composeId, ORGID1)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"timezone": true}`, string(injected))
+	_, count, err := d.GetComposesFiltered(ctx, ORGID1, db.ComposeFilter{Since: time.Hour, Labels: map[string]string{"team": "platform"}}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	require.NoError(t, d.ReleaseComposeReservation(ctx, reserved[1].Id))
+	count, err = d.CountComposesSince(ctx, ORGID1, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	reservation := db.ComposeReservation{Id: uuid.New(), OrgId: ORGID1, Quota: common.ToPtr(4), Window: time.Hour}
+	require.NoError(t, d.ReserveCompose(ctx, &reservation))
+	reservation = db.ComposeReservation{Id: uuid.New(), OrgId: ORGID1, Quota: common.ToPtr(4), Window: time.Hour}
+	require.ErrorIs(t, d.ReserveCompose(ctx, &reservation), db.QuotaExceededError)
+
+	// other orgs have their own quota
+	reservation = db.ComposeReservation{Id: uuid.New(), OrgId: ORGID2, Quota: common.ToPtr(4), Window: time.Hour}
+	require.NoError(t, d.ReserveCompose(ctx, &reservation))
+}
+
+func testPriorityLane(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	reserve := func() (*db.ComposeReservation, error) {
+		reservation := &db.ComposeReservation{
+			Id:         uuid.New(),
+			OrgId:      ORGID3,
+			Quota:      common.ToPtr(1),
+			Window:     time.Hour,
+			LaneLimit:  common.ToPtr(2),
+			LaneWindow: time.Hour,
+		}
+		return reservation, d.ReserveCompose(ctx, reservation)
+	}
+	for i := 0; i < 2; i++ {
+		reservation, err := reserve()
+		require.NoError(t, err)
+		require.True(t, reservation.PriorityLane)
+		err = d.InsertReservedCompose(ctx, reservation, db.ReservedCompose{JobId: uuid.New(), AccountNumber: ANR3, Email: EMAIL1, Request: []byte("{}")})
+		require.NoError(t, err)
+	}
+
+	count, err := d.CountPriorityLaneComposesSince(ctx, ORGID3, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	// composes in the lane don't count against the quota, a full lane sends
+	// the compose through it
+	count, err = d.CountComposesSince(ctx, ORGID3, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+	reservation, err := reserve()
+	require.NoError(t, err)
+	require.False(t, reservation.PriorityLane)
+	_, err = reserve()
+	require.ErrorIs(t, err, db.QuotaExceededError)
+}
+
+func testComposePipelines(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	commitId := uuid.New()
+	installerId := uuid.New()
+	err = d.InsertCompose(ctx, commitId, ANR1, EMAIL1, ORGID1, nil, []byte("{}"), nil, nil, nil, nil)
+	require.NoError(t, err)
+	err = d.InsertCompose(ctx, installerId, ANR1, EMAIL1, ORGID1, nil, []byte("{}"), nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	pipelineId := uuid.New()
+	err = d.InsertComposePipeline(ctx, pipelineId, ORGID1, commitId, []byte(`{"image_type": "edge-installer"}`))
+	require.NoError(t, err)
+	compose, err := d.GetCompose(ctx, commitId, ORGID1)
+	require.NoError(t, err)
+	require.Equal(t, pipelineId, *compose.PipelineId)
+
+	pipeline, err := d.GetComposePipeline(ctx, pipelineId, ORGID1)
+	require.NoError(t, err)
+	require.Equal(t, db.ComposePipelineWaiting, pipeline.State)
+	require.Equal(t, commitId, pipeline.CommitComposeId)
+	require.Nil(t, pipeline.InstallerComposeId)
+	require.JSONEq(t, `{"image_type": "edge-installer"}`, string(pipeline.InstallerRequest))
+	_, err = d.GetComposePipeline(ctx, pipelineId, ORGID2)
+	require.ErrorIs(t, err, db.ComposePipelineNotFoundError)
+
+	waiting, err := d.GetWaitingComposePipelines(ctx, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, waiting, 1)
+	require.Equal(t, pipelineId, waiting[0].Id)
+	require.Equal(t, ORGID1, waiting[0].OrgId)
+	require.Equal(t, ANR1, waiting[0].AccountNumber)
+	require.Equal(t, EMAIL1, waiting[0].Email)
+	waiting, err = d.GetWaitingComposePipelines(ctx, &waiting[0], 10)
+	require.NoError(t, err)
+	require.Empty(t, waiting)
+
+	// only one of concurrent transitions from the same state succeeds
+	err = d.SetComposePipelineState(ctx, pipelineId, db.ComposePipelineWaiting, "triggering", nil, nil)
+	require.NoError(t, err)
+	err = d.SetComposePipelineState(ctx, pipelineId, db.ComposePipelineWaiting, "triggering", nil, nil)
+	require.ErrorIs(t, err, db.ComposePipelineStateError)
+
+	waiting, err = d.GetWaitingComposePipelines(ctx, nil, 10)
+	require.NoError(t, err)
+	require.Empty(t, waiting)
+
+	// pipelines are failed by how long they've been in their state
+	stuckCommitId := uuid.New()
+	err = d.InsertCompose(ctx, stuckCommitId, ANR1, EMAIL1, ORGID1, nil, []byte("{}"), nil, nil, nil, nil)
+	require.NoError(t, err)
+	stuckId := uuid.New()
+	err = d.InsertComposePipeline(ctx, stuckId, ORGID1, stuckCommitId, []byte("{}"))
+	require.NoError(t, err)
+	err = d.SetComposePipelineState(ctx, stuckId, db.ComposePipelineWaiting, db.ComposePipelineTriggering, nil, nil)
+	require.NoError(t, err)
+	conn := connect(t)
+	defer conn.Close(ctx)
+	_, err = conn.Exec(ctx, "UPDATE compose_pipelines SET state_changed_at = CURRENT_TIMESTAMP - interval '2 hours' WHERE id = $1", stuckId)
+	require.NoError(t, err)
+	failed, err := d.FailStaleComposePipelines(ctx, db.ComposePipelineTriggering, time.Hour, "stuck")
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{stuckId}, failed)
+	stuck, err := d.GetComposePipeline(ctx, stuckId, ORGID1)
+	require.NoError(t, err)
+	require.Equal(t, db.ComposePipelineFailed, stuck.State)
+	require.Equal(t, "stuck", *stuck.Error)
+	failed, err = d.FailStaleComposePipelines(ctx, db.ComposePipelineTriggering, time.Hour, "stuck")
+	require.NoError(t, err)
+	require.Empty(t, failed)
+
+	err = d.SetComposePipelineState(ctx, pipelineId, "triggering", "triggered", &installerId, nil)
+	require.NoError(t, err)
+	pipeline, err = d.GetComposePipeline(ctx, pipelineId, ORGID1)
+	require.NoError(t, err)
+	require.Equal(t, "triggered", pipeline.State)
+	require.Equal(t, installerId, *pipeline.InstallerComposeId)
+	compose, err = d.GetCompose(ctx, installerId, ORGID1)
+	require.NoError(t, err)
+	require.Equal(t, pipelineId, *compose.PipelineId)
+}
+
+func testTransferComposes(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	var ids []uuid.UUID
+	for _, email := range []string{EMAIL1, "former@test.test", "former@test.test"} {
+		id := uuid.New()
+		err = d.InsertCompose(ctx, id, ANR1, email, ORGID1, nil, []byte("{}"), nil, nil, nil, nil)
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	err = d.InsertCompose(ctx, uuid.New(), ANR2, "former@test.test", ORGID2, nil, []byte("{}"), nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, d.DeleteCompose(ctx, ids[2], ORGID1))
+
+	// only the creator can hand over the compose when it's given
+	require.ErrorIs(t, d.TransferCompose(ctx, ids[0], ORGID1, common.ToPtr("former@test.test"), "other@test.test"), db.ComposeNotFoundError)
+	require.ErrorIs(t, d.TransferCompose(ctx, ids[0], ORGID2, nil, "other@test.test"), db.ComposeNotFoundError)
+	require.ErrorIs(t, d.TransferCompose(ctx, ids[2], ORGID1, nil, "other@test.test"), db.ComposeNotFoundError)
+	require.NoError(t, d.TransferCompose(ctx, ids[0], ORGID1, common.ToPtr(EMAIL1), "other@test.test"))
+	composes, _, err := d.GetComposesFiltered(ctx, ORGID1, db.ComposeFilter{Since: fortnight, Creator: common.ToPtr("other@test.test")}, 100, 0)
+	require.NoError(t, err)
+	require.Len(t, composes, 1)
+	require.Equal(t, ids[0], composes[0].Id)
+
+	// deleted composes are transferred in bulk too, other orgs aren't touched
+	transferred, err := d.TransferComposes(ctx, ORGID1, "former@test.test", EMAIL1)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), transferred)
+	_, count, err := d.GetComposesFiltered(ctx, ORGID1, db.ComposeFilter{Since: fortnight, Creator: common.ToPtr(EMAIL1)}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	_, count, err = d.GetComposesFiltered(ctx, ORGID2, db.ComposeFilter{Since: fortnight, Creator: common.ToPtr("former@test.test")}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
+
+func testUpdateCompose(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	id := uuid.New()
+	err = d.InsertCompose(ctx, id, ANR1, EMAIL1, ORGID1, common.ToPtr("original"), []byte("{}"), nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	listed := func() *db.ComposeEntry {
+		composes, _, err := d.GetComposesFiltered(ctx, ORGID1, db.ComposeFilter{Since: fortnight}, 100, 0)
+		require.NoError(t, err)
+		require.Len(t, composes, 1)
+		return composes[0].ComposeEntry
+	}
+
+	require.NoError(t, d.UpdateCompose(ctx, id, ORGID1, db.ComposeUpdate{Description: common.ToPtr("annotated")}))
+	compose := listed()
+	require.Equal(t, "original", *compose.ImageName)
+	require.Equal(t, "annotated", *compose.Description)
+
+	require.NoError(t, d.UpdateCompose(ctx, id, ORGID1, db.ComposeUpdate{ImageName: common.ToPtr("renamed"), Description: common.ToPtr("")}))
+	compose = listed()
+	require.Equal(t, "renamed", *compose.ImageName)
+	require.Nil(t, compose.Description)
+
+	require.ErrorIs(t, d.UpdateCompose(ctx, id, ORGID2, db.ComposeUpdate{ImageName: common.ToPtr("other")}), db.ComposeNotFoundError)
+	require.NoError(t, d.DeleteCompose(ctx, id, ORGID1))
+	require.ErrorIs(t, d.UpdateCompose(ctx, id, ORGID1, db.ComposeUpdate{ImageName: common.ToPtr("other")}), db.ComposeNotFoundError)
+}
+
+func testCountComposesByKind(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	request := []byte(`{"distribution": "rhel-9", "image_requests": [{"image_type": "aws"}]}`)
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	for i, id := range ids {
+		orgId := ORGID1
+		if i == 2 {
+			orgId = ORGID2
+		}
+		err = d.InsertCompose(ctx, id, ANR1, EMAIL1, orgId, nil, request, nil, nil, nil, nil)
+		require.NoError(t, err)
+	}
+	require.NoError(t, d.SetComposeStatus(ctx, ids[0], "failure", common.ToPtr("DepsolveFailed")))
+	require.NoError(t, d.SetComposeStatus(ctx, ids[1], "failure", common.ToPtr("DepsolveFailed")))
+	// deleted composes were built all the same
+	require.NoError(t, d.DeleteCompose(ctx, ids[1], ORGID1))
+
+	counts, err := d.CountComposesByKind(ctx, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, []db.ComposeCount{
+		{Distribution: "rhel-9", ImageType: "aws", Status: common.ToPtr("failure"), ErrorCode: common.ToPtr("DepsolveFailed"), Count: 2},
+		{Distribution: "rhel-9", ImageType: "aws", Count: 1},
+	}, counts)
+}
+
+func testComposeMetadata(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	id := uuid.New()
+	err = d.InsertCompose(ctx, id, ANR1, EMAIL1, ORGID1, nil, []byte("{}"), nil, nil, nil, nil)
+	require.NoError(t, err)
+	_, err = d.GetComposeMetadata(ctx, id, ORGID1)
+	require.ErrorIs(t, err, db.ComposeMetadataNotFoundError)
+
+	require.NoError(t, d.InsertComposeMetadata(ctx, id, []byte(`{"packages": [{"name": "bash"}]}`)))
+	// the first snapshot is kept
+	require.NoError(t, d.InsertComposeMetadata(ctx, id, []byte(`{"packages": []}`)))
+	metadata, err := d.GetComposeMetadata(ctx, id, ORGID1)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"packages": [{"name": "bash"}]}`, string(metadata))
+
+	_, err = d.GetComposeMetadata(ctx, id, ORGID2)
+	require.ErrorIs(t, err, db.ComposeMetadataNotFoundError)
+}
+
 func testCountGetComposesSince(t *testing.T) {
 	ctx := context.Background()
-	d, err := db.InitDBConnectionPool(connStr(t))
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
 	require.NoError(t, err)
 
 	conn := connect(t)
@@ -234,7 +783,7 @@ func testCountGetComposesSince(t *testing.T) {
 
 func testGetComposeImageType(t *testing.T) {
 	ctx := context.Background()
-	d, err := db.InitDBConnectionPool(connStr(t))
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
 	require.NoError(t, err)
 	conn := connect(t)
 	defer conn.Close(ctx)
@@ -271,7 +820,7 @@ func testGetComposeImageType(t *testing.T) {
 
 func testDeleteCompose(t *testing.T) {
 	ctx := context.Background()
-	d, err := db.InitDBConnectionPool(connStr(t))
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
 	require.NoError(t, err)
 	conn := connect(t)
 	defer conn.Close(ctx)
@@ -289,6 +838,15 @@ func testDeleteCompose(t *testing.T) {
 	err = d.DeleteCompose(ctx, composeId, ORGID1)
 	require.NoError(t, err)
 
+	var deletedAt *time.Time
+	err = conn.QueryRow(ctx, "SELECT deleted_at FROM composes WHERE job_id = $1", composeId).Scan(&deletedAt)
+	require.NoError(t, err)
+	require.NotNil(t, deletedAt)
+
+	// it's gone for the user already
+	err = d.DeleteCompose(ctx, composeId, ORGID1)
+	require.Equal(t, db.ComposeNotFoundError, err)
+
 	_, count, err := d.GetComposes(ctx, ORGID1, fortnight, 100, 0, []string{})
 	require.NoError(t, err)
 	require.Equal(t, 0, count)
@@ -299,9 +857,94 @@ func testDeleteCompose(t *testing.T) {
 	require.Equal(t, 1, count)
 }
 
+func testUnfinishedComposes(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+	conn := connect(t)
+	defer conn.Close(ctx)
+
+	oldId := uuid.New()
+	newId := uuid.New()
+	insert := "INSERT INTO composes(job_id, request, created_at, account_number, org_id) VALUES ($1, $2, CURRENT_TIMESTAMP - $3::interval, $4, $5)"
+	_, err = conn.Exec(ctx, insert, oldId, `{"image_requests": [{"image_type": "aws"}]}`, "5 hours", ANR1, ORGID1)
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx, insert, newId, `{"image_requests": [{"image_type": "aws"}]}`, "1 minute", ANR1, ORGID1)
+	require.NoError(t, err)
+
+	composes, err := d.GetUnfinishedComposes(ctx, nil, time.Hour, fortnight, nil, 100)
+	require.NoError(t, err)
+	require.Len(t, composes, 1)
+	require.Equal(t, oldId, composes[0].Id)
+	require.Equal(t, ORGID1, composes[0].OrgId)
+	require.Equal(t, "aws", composes[0].ImageType)
+
+	errorCode := "WATCHDOG_TIMEOUT"
+	err = d.SetComposeStatus(ctx, oldId, "failure", &errorCode)
+	require.NoError(t, err)
+	err = d.SetComposeStatus(ctx, uuid.New(), "failure", &errorCode)
+	require.Equal(t, db.ComposeNotFoundError, err)
+
+	composes, err = d.GetUnfinishedComposes(ctx, nil, time.Hour, fortnight, nil, 100)
+	require.NoError(t, err)
+	require.Len(t, composes, 0)
+
+	compose, err := d.GetCompose(ctx, oldId, ORGID1)
+	require.NoError(t, err)
+	require.Equal(t, "failure", *compose.Status)
+	require.Equal(t, errorCode, *compose.ErrorCode)
+
+	// composes of other regions are left to the deployment in that region
+	regionalId := uuid.New()
+	region := "eu-west-1"
+	err = d.InsertCompose(ctx, regionalId, ANR1, EMAIL1, ORGID1, nil, []byte(`{"image_requests": [{"image_type": "aws"}]}`), nil, nil, &region, nil)
+	require.NoError(t, err)
+	compose, err = d.GetCompose(ctx, regionalId, ORGID1)
+	require.NoError(t, err)
+	require.Equal(t, region, *compose.Region)
+	_, err = conn.Exec(ctx, "UPDATE composes SET created_at = CURRENT_TIMESTAMP - interval '5 hours' WHERE job_id = $1", regionalId)
+	require.NoError(t, err)
+	composes, err = d.GetUnfinishedComposes(ctx, nil, time.Hour, fortnight, nil, 100)
+	require.NoError(t, err)
+	require.Len(t, composes, 0)
+	composes, err = d.GetUnfinishedComposes(ctx, &region, time.Hour, fortnight, nil, 100)
+	require.NoError(t, err)
+	require.Len(t, composes, 1)
+	require.Equal(t, regionalId, composes[0].Id)
+	require.Equal(t, region, *composes[0].Region)
+
+	// the composes are paged through by age
+	pagedIds := []uuid.UUID{uuid.New(), uuid.New()}
+	_, err = conn.Exec(ctx, insert, pagedIds[0], `{"image_requests": [{"image_type": "aws"}]}`, "3 hours", ANR1, ORGID1)
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx, insert, pagedIds[1], `{"image_requests": [{"image_type": "aws"}]}`, "2 hours", ANR1, ORGID1)
+	require.NoError(t, err)
+	composes, err = d.GetUnfinishedComposes(ctx, nil, time.Hour, fortnight, nil, 1)
+	require.NoError(t, err)
+	require.Len(t, composes, 1)
+	require.Equal(t, pagedIds[0], composes[0].Id)
+	composes, err = d.GetUnfinishedComposes(ctx, nil, time.Hour, fortnight, &composes[0], 1)
+	require.NoError(t, err)
+	require.Len(t, composes, 1)
+	require.Equal(t, pagedIds[1], composes[0].Id)
+	composes, err = d.GetUnfinishedComposes(ctx, nil, time.Hour, fortnight, &composes[0], 1)
+	require.NoError(t, err)
+	require.Len(t, composes, 0)
+
+	// all unfinished composes of the org are listed, with the stored status
+	_, err = conn.Exec(ctx, "UPDATE composes SET status = 'building' WHERE job_id = $1", newId)
+	require.NoError(t, err)
+	composes, err = d.GetOrgUnfinishedComposes(ctx, ORGID1, fortnight)
+	require.NoError(t, err)
+	require.Len(t, composes, 4)
+	require.Equal(t, newId, composes[0].Id)
+	require.Equal(t, "building", *composes[0].Status)
+	require.Nil(t, composes[1].Status)
+}
+
 func testClones(t *testing.T) {
 	ctx := context.Background()
-	d, err := db.InitDBConnectionPool(connStr(t))
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
 	require.NoError(t, err)
 	conn := connect(t)
 	defer conn.Close(ctx)
@@ -311,7 +954,7 @@ func testClones(t *testing.T) {
 	cloneId2 := uuid.New()
 
 	// fkey constraint on compose id
-	require.Error(t, d.InsertClone(ctx, composeId, cloneId, []byte(`
+	require.Error(t, d.InsertClone(ctx, composeId, cloneId, nil, []byte(`
 {
   "region": "us-east-2"
 }
@@ -333,14 +976,14 @@ func testClones(t *testing.T) {
       }
     }
   ]
-}`), nil, nil))
+}`), nil, nil, nil, nil))
 
-	require.NoError(t, d.InsertClone(ctx, composeId, cloneId, []byte(`
+	require.NoError(t, d.InsertClone(ctx, composeId, cloneId, nil, []byte(`
 {
   "region": "us-east-2"
 }
 `)))
-	require.NoError(t, d.InsertClone(ctx, composeId, cloneId2, []byte(`
+	require.NoError(t, d.InsertClone(ctx, composeId, cloneId2, nil, []byte(`
 {
   "region": "eu-central-1"
 }
@@ -371,35 +1014,111 @@ func testClones(t *testing.T) {
 	entry, err = d.GetClone(ctx, cloneId, ORGID1)
 	require.NoError(t, err)
 	require.Equal(t, clones[1], *entry)
-}
 
-func testBlueprints(t *testing.T) {
-	ctx := context.Background()
-	d, err := db.InitDBConnectionPool(connStr(t))
+	// the children of a clone follow it, however recent they are
+	cloneId3 := uuid.New()
+	require.NoError(t, d.InsertClone(ctx, composeId, cloneId3, &cloneId, []byte(`
+{
+  "region": "eu-west-1"
+}
+`)))
+	clones, count, err = d.GetClonesForCompose(ctx, composeId, ORGID1, 100, 0)
 	require.NoError(t, err)
-	conn := connect(t)
-	defer conn.Close(ctx)
+	require.Equal(t, 3, count)
+	require.Equal(t, []uuid.UUID{cloneId2, cloneId, cloneId3}, []uuid.UUID{clones[0].Id, clones[1].Id, clones[2].Id})
+	require.Nil(t, clones[1].ParentId)
+	require.Equal(t, cloneId, *clones[2].ParentId)
 
-	name1 := "name"
-	description1 := "desc"
-	body1 := v1.BlueprintBody{
-		Customizations: v1.Customizations{},
-		Distribution:   "distribution",
-		ImageRequests:  []v1.ImageRequest{},
-	}
-	bodyJson1, err := json.Marshal(body1)
+	entry, err = d.GetClone(ctx, cloneId3, ORGID1)
 	require.NoError(t, err)
+	require.Equal(t, clones[2], *entry)
 
-	id := uuid.New()
-	versionId := uuid.New()
-	err = d.InsertBlueprint(ctx, id, versionId, ORGID1, ANR1, name1, description1, bodyJson1, []byte("{}"))
+	counts, err := d.CountClonesByStatus(ctx, composeId, ORGID1)
 	require.NoError(t, err)
-
-	entry, err := d.GetBlueprint(ctx, id, ORGID1, nil)
+	require.Equal(t, db.CloneStatusCounts{Unfinished: 3}, counts)
+	require.NoError(t, d.SetCloneStatus(ctx, cloneId, "success", []byte(`{"status": "success"}`)))
+	require.NoError(t, d.SetCloneStatus(ctx, cloneId2, "failure", []byte(`{"status": "failure"}`)))
+	require.ErrorIs(t, d.SetCloneStatus(ctx, uuid.New(), "success", []byte(`{}`)), db.CloneNotFoundError)
+	counts, err = d.CountClonesByStatus(ctx, composeId, ORGID1)
 	require.NoError(t, err)
-	fromEntry, err := v1.BlueprintFromEntry(entry)
+	require.Equal(t, db.CloneStatusCounts{Success: 1, Failure: 1, Unfinished: 1}, counts)
+	counts, err = d.CountClonesByStatus(ctx, composeId, ORGID2)
 	require.NoError(t, err)
-	require.Equal(t, body1, fromEntry)
+	require.Equal(t, db.CloneStatusCounts{}, counts)
+
+	entry, err = d.GetClone(ctx, cloneId, ORGID1)
+	require.NoError(t, err)
+	require.Equal(t, "success", *entry.Status)
+	require.JSONEq(t, `{"status": "success"}`, string(entry.UploadStatus))
+	entry, err = d.GetClone(ctx, cloneId3, ORGID1)
+	require.NoError(t, err)
+	require.Nil(t, entry.Status)
+	require.Nil(t, entry.UploadStatus)
+}
+
+func testGetBlueprintVersion(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	id := uuid.New()
+	versionId := uuid.New()
+	err = d.InsertBlueprint(ctx, id, versionId, ORGID1, ANR1, "edge-gateway", "desc", []byte("{}"), []byte("{}"), db.BlueprintOptions{})
+	require.NoError(t, err)
+	newVersionId := uuid.New()
+	err = d.UpdateBlueprint(ctx, newVersionId, id, ORGID1, "edge-gateway", "desc", []byte("{}"), "")
+	require.NoError(t, err)
+
+	// older versions are found as well
+	blueprint, err := d.GetBlueprintVersion(ctx, versionId, ORGID1)
+	require.NoError(t, err)
+	require.Equal(t, id, blueprint.Id)
+	require.Equal(t, "edge-gateway", blueprint.Name)
+	require.Equal(t, 1, blueprint.Version)
+	blueprint, err = d.GetBlueprintVersion(ctx, newVersionId, ORGID1)
+	require.NoError(t, err)
+	require.Equal(t, 2, blueprint.Version)
+
+	_, err = d.GetBlueprintVersion(ctx, versionId, ORGID2)
+	require.ErrorIs(t, err, db.BlueprintNotFoundError)
+
+	versions, err := d.GetBlueprintVersions(ctx, id, ORGID1)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	require.Equal(t, 2, versions[0].Version)
+	require.Equal(t, 1, versions[1].Version)
+	require.False(t, versions[1].LastModifiedAt.After(versions[0].LastModifiedAt))
+	_, err = d.GetBlueprintVersions(ctx, id, ORGID2)
+	require.ErrorIs(t, err, db.BlueprintNotFoundError)
+}
+
+func testBlueprints(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+	conn := connect(t)
+	defer conn.Close(ctx)
+
+	name1 := "name"
+	description1 := "desc"
+	body1 := v1.BlueprintBody{
+		Customizations: v1.Customizations{},
+		Distribution:   "distribution",
+		ImageRequests:  []v1.ImageRequest{},
+	}
+	bodyJson1, err := json.Marshal(body1)
+	require.NoError(t, err)
+
+	id := uuid.New()
+	versionId := uuid.New()
+	err = d.InsertBlueprint(ctx, id, versionId, ORGID1, ANR1, name1, description1, bodyJson1, []byte("{}"), db.BlueprintOptions{})
+	require.NoError(t, err)
+
+	entry, err := d.GetBlueprint(ctx, id, ORGID1, nil)
+	require.NoError(t, err)
+	fromEntry, err := v1.BlueprintFromEntry(entry)
+	require.NoError(t, err)
+	require.Equal(t, body1, fromEntry)
 	require.Equal(t, name1, entry.Name)
 	require.Equal(t, description1, entry.Description)
 	require.Equal(t, 1, entry.Version)
@@ -426,7 +1145,7 @@ func testBlueprints(t *testing.T) {
 	require.NoError(t, err)
 
 	newVersionId := uuid.New()
-	err = d.UpdateBlueprint(ctx, newVersionId, id, ORGID1, name2, description2, bodyJson2)
+	err = d.UpdateBlueprint(ctx, newVersionId, id, ORGID1, name2, description2, bodyJson2, "")
 	require.NoError(t, err)
 	entryUpdated, err := d.GetBlueprint(ctx, id, ORGID1, nil)
 	require.NoError(t, err)
@@ -464,7 +1183,7 @@ func testBlueprints(t *testing.T) {
 	bodyJson3, err := json.Marshal(body3)
 	require.NoError(t, err)
 	newBlueprintId := uuid.New()
-	err = d.UpdateBlueprint(ctx, newBlueprintId, id, ORGID2, name3, description3, bodyJson3)
+	err = d.UpdateBlueprint(ctx, newBlueprintId, id, ORGID2, name3, description3, bodyJson3, "")
 	require.Error(t, err)
 	entryAfterInvalidUpdate, err := d.GetBlueprint(ctx, id, ORGID1, nil)
 	require.NoError(t, err)
@@ -481,37 +1200,37 @@ func testBlueprints(t *testing.T) {
 	newestBlueprintName := "new name"
 
 	// Fail to insert blueprint with the same name
-	err = d.InsertBlueprint(ctx, newestBlueprintId, newestBlueprintVersionId, ORGID1, ANR1, newestBlueprintName, "desc", bodyJson1, []byte("{}"))
+	err = d.InsertBlueprint(ctx, newestBlueprintId, newestBlueprintVersionId, ORGID1, ANR1, newestBlueprintName, "desc", bodyJson1, []byte("{}"), db.BlueprintOptions{})
 	require.Error(t, err)
 
 	newestBlueprintName = "New name 2"
-	err = d.InsertBlueprint(ctx, newestBlueprintId, newestBlueprintVersionId, ORGID1, ANR1, newestBlueprintName, "desc", bodyJson1, []byte("{}"))
+	err = d.InsertBlueprint(ctx, newestBlueprintId, newestBlueprintVersionId, ORGID1, ANR1, newestBlueprintName, "desc", bodyJson1, []byte("{}"), db.BlueprintOptions{})
 	require.NoError(t, err)
-	entries, bpCount, err := d.GetBlueprints(ctx, ORGID1, 100, 0)
+	entries, bpCount, err := d.GetBlueprints(ctx, ORGID1, db.BlueprintFilter{}, 100, 0)
 	require.NoError(t, err)
 	require.Equal(t, 2, bpCount)
 	require.Equal(t, entries[0].Name, newestBlueprintName)
 	require.Equal(t, entries[1].Version, 2)
 
-	err = d.InsertBlueprint(ctx, uuid.New(), uuid.New(), ORGID1, ANR1, "unique name", "unique desc", bodyJson1, []byte("{}"))
-	entries, count, err := d.FindBlueprints(ctx, ORGID1, "", 100, 0)
+	err = d.InsertBlueprint(ctx, uuid.New(), uuid.New(), ORGID1, ANR1, "unique name", "unique desc", bodyJson1, []byte("{}"), db.BlueprintOptions{})
+	entries, count, err := d.FindBlueprints(ctx, ORGID1, "", db.BlueprintFilter{}, 100, 0)
 	require.NoError(t, err)
 	require.Equal(t, 3, count)
-	entries, count, err = d.FindBlueprints(ctx, ORGID1, "unique", 100, 0)
+	entries, count, err = d.FindBlueprints(ctx, ORGID1, "unique", db.BlueprintFilter{}, 100, 0)
 	require.NoError(t, err)
 	require.Equal(t, 1, count)
 	require.Equal(t, "unique name", entries[0].Name)
 
-	entries, count, err = d.FindBlueprints(ctx, ORGID1, "unique desc", 100, 0)
+	entries, count, err = d.FindBlueprints(ctx, ORGID1, "unique desc", db.BlueprintFilter{}, 100, 0)
 	require.NoError(t, err)
 	require.Equal(t, 1, count)
 	require.Equal(t, "unique desc", entries[0].Description)
 
 	// Insert composes for a blueprint
 	clientId := "ui"
-	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, common.ToPtr("image1"), []byte("{}"), &clientId, &versionId)
+	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, common.ToPtr("image1"), []byte("{}"), &clientId, &versionId, nil, nil)
 	require.NoError(t, err)
-	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, common.ToPtr("image2"), []byte("{}"), &clientId, &versionId)
+	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, common.ToPtr("image2"), []byte("{}"), &clientId, &versionId, nil, nil)
 	require.NoError(t, err)
 
 	count, err = d.CountBlueprintComposesSince(ctx, ORGID1, id, nil, (time.Hour * 24 * 14), nil)
@@ -526,16 +1245,44 @@ func testBlueprints(t *testing.T) {
 	require.Equal(t, 0, count)
 }
 
+func testUpdateBlueprintIfVersion(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	id := uuid.New()
+	err = d.InsertBlueprint(ctx, id, uuid.New(), ORGID1, ANR1, "conditional", "desc", []byte("{}"), []byte("{}"), db.BlueprintOptions{})
+	require.NoError(t, err)
+
+	err = d.UpdateBlueprintIfVersion(ctx, uuid.New(), id, ORGID1, "conditional", "desc2", []byte("{}"), 1, "")
+	require.NoError(t, err)
+	entry, err := d.GetBlueprint(ctx, id, ORGID1, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, entry.Version)
+	require.Equal(t, "desc2", entry.Description)
+
+	// version 1 isn't the latest anymore, neither the name nor the body change
+	err = d.UpdateBlueprintIfVersion(ctx, uuid.New(), id, ORGID1, "conditional", "desc3", []byte("{}"), 1, "")
+	require.ErrorIs(t, err, db.BlueprintVersionConflictError)
+	entry, err = d.GetBlueprint(ctx, id, ORGID1, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, entry.Version)
+	require.Equal(t, "desc2", entry.Description)
+
+	err = d.UpdateBlueprintIfVersion(ctx, uuid.New(), id, ORGID2, "conditional", "desc3", []byte("{}"), 2, "")
+	require.ErrorIs(t, err, db.BlueprintNotFoundError)
+}
+
 func testGetBlueprintComposes(t *testing.T) {
 	ctx := context.Background()
-	d, err := db.InitDBConnectionPool(connStr(t))
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
 	require.NoError(t, err)
 	conn := connect(t)
 	defer conn.Close(ctx)
 
 	id := uuid.New()
 	versionId := uuid.New()
-	err = d.InsertBlueprint(ctx, id, versionId, ORGID1, ANR1, "name", "desc", []byte("{}"), []byte("{}"))
+	err = d.InsertBlueprint(ctx, id, versionId, ORGID1, ANR1, "name", "desc", []byte("{}"), []byte("{}"), db.BlueprintOptions{})
 	require.NoError(t, err)
 
 	// get latest version
@@ -544,17 +1291,17 @@ func testGetBlueprintComposes(t *testing.T) {
 	require.Equal(t, 1, version)
 
 	version2Id := uuid.New()
-	err = d.UpdateBlueprint(ctx, version2Id, id, ORGID1, "name", "desc2", []byte("{}"))
+	err = d.UpdateBlueprint(ctx, version2Id, id, ORGID1, "name", "desc2", []byte("{}"), "")
 	require.NoError(t, err)
 
 	clientId := "ui"
-	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, common.ToPtr("image1"), []byte("{}"), &clientId, &versionId)
+	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, common.ToPtr("image1"), []byte("{}"), &clientId, &versionId, nil, nil)
 	require.NoError(t, err)
-	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, common.ToPtr("image2"), []byte("{}"), &clientId, &versionId)
+	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, common.ToPtr("image2"), []byte("{}"), &clientId, &versionId, nil, nil)
 	require.NoError(t, err)
-	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, common.ToPtr("image3"), []byte("{}"), &clientId, nil)
+	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, common.ToPtr("image3"), []byte("{}"), &clientId, nil, nil, nil)
 	require.NoError(t, err)
-	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, common.ToPtr("image4"), []byte("{}"), &clientId, &version2Id)
+	err = d.InsertCompose(ctx, uuid.New(), ANR1, EMAIL1, ORGID1, common.ToPtr("image4"), []byte("{}"), &clientId, &version2Id, nil, nil)
 	require.NoError(t, err)
 
 	count, err := d.CountBlueprintComposesSince(ctx, ORGID1, id, nil, (time.Hour * 24 * 14), nil)
@@ -596,6 +1343,831 @@ func testGetBlueprintComposes(t *testing.T) {
 	require.Equal(t, 2, version)
 }
 
+func testBlueprintLifecycles(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	id := uuid.New()
+	versionId := uuid.New()
+	err = d.InsertBlueprint(ctx, id, versionId, ORGID1, ANR1, "name", "desc", []byte("{}"), []byte("{}"), db.BlueprintOptions{})
+	require.NoError(t, err)
+	otherId := uuid.New()
+	err = d.InsertBlueprint(ctx, otherId, uuid.New(), ORGID2, ANR2, "other", "desc", []byte("{}"), []byte("{}"), db.BlueprintOptions{})
+	require.NoError(t, err)
+
+	// only the latest version counts
+	lifecycles, err := d.GetBlueprintLifecycles(ctx)
+	require.NoError(t, err)
+	require.Empty(t, lifecycles)
+	err = d.UpdateBlueprint(ctx, uuid.New(), id, ORGID1, "name", "desc", []byte(`{"lifecycle": {"keep_last": 1}}`), "")
+	require.NoError(t, err)
+	lifecycles, err = d.GetBlueprintLifecycles(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []db.BlueprintLifecycle{{OrgId: ORGID1, BlueprintId: id, KeepLast: 1}}, lifecycles)
+
+	insert := func(imageType, status string) uuid.UUID {
+		composeId := uuid.New()
+		request := []byte(fmt.Sprintf(`{"image_requests": [{"image_type": %q}]}`, imageType))
+		err := d.InsertCompose(ctx, composeId, ANR1, EMAIL1, ORGID1, nil, request, nil, &versionId, nil, nil)
+		require.NoError(t, err)
+		if status != "" {
+			require.NoError(t, d.SetComposeStatus(ctx, composeId, status, nil))
+		}
+		return composeId
+	}
+	oldestAws := insert("aws", "success")
+	olderAws := insert("aws", "success")
+	insert("guest-image", "success")
+	insert("aws", "success")
+	insert("aws", "failure")
+	insert("aws", "")
+
+	expired, err := d.GetExpiredBlueprintComposes(ctx, ORGID1, id, 1)
+	require.NoError(t, err)
+	require.Len(t, expired, 2)
+	require.Equal(t, olderAws, expired[0].Id)
+	require.Equal(t, oldestAws, expired[1].Id)
+
+	require.NoError(t, d.DeleteCompose(ctx, olderAws, ORGID1))
+	expired, err = d.GetExpiredBlueprintComposes(ctx, ORGID1, id, 1)
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+
+	expired, err = d.GetExpiredBlueprintComposes(ctx, ORGID2, id, 1)
+	require.NoError(t, err)
+	require.Empty(t, expired)
+}
+
+func testGitOpsRepositories(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	id := uuid.New()
+	err = d.InsertGitOpsRepository(ctx, id, ORGID1, "https://example.com/blueprints.git", "main", "blueprints", true)
+	require.NoError(t, err)
+
+	repo, err := d.GetGitOpsRepository(ctx, id, ORGID1)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/blueprints.git", repo.Url)
+	require.Equal(t, "main", repo.Branch)
+	require.Equal(t, "blueprints", repo.Path)
+	require.True(t, repo.AutoBuild)
+	require.Nil(t, repo.LastCommit)
+	require.Nil(t, repo.LastSyncedAt)
+
+	_, err = d.GetGitOpsRepository(ctx, id, ORGID2)
+	require.ErrorIs(t, err, db.GitOpsRepositoryNotFoundError)
+	repos, err := d.GetGitOpsRepositories(ctx, ORGID2)
+	require.NoError(t, err)
+	require.Len(t, repos, 0)
+
+	err = d.SetGitOpsRepositorySynced(ctx, id, ORGID1, "abc123")
+	require.NoError(t, err)
+	err = d.SetGitOpsRepositorySynced(ctx, id, ORGID2, "abc123")
+	require.ErrorIs(t, err, db.GitOpsRepositoryNotFoundError)
+	repos, err = d.GetGitOpsRepositories(ctx, ORGID1)
+	require.NoError(t, err)
+	require.Len(t, repos, 1)
+	require.Equal(t, "abc123", *repos[0].LastCommit)
+	require.NotNil(t, repos[0].LastSyncedAt)
+
+	err = d.DeleteGitOpsRepository(ctx, id, ORGID2)
+	require.ErrorIs(t, err, db.GitOpsRepositoryNotFoundError)
+	err = d.DeleteGitOpsRepository(ctx, id, ORGID1)
+	require.NoError(t, err)
+	_, err = d.GetGitOpsRepository(ctx, id, ORGID1)
+	require.ErrorIs(t, err, db.GitOpsRepositoryNotFoundError)
+}
+
+func testUploadGrants(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	grant := db.UploadGrantEntry{
+		OrgId:     ORGID1,
+		Alias:     "prod",
+		Provider:  "aws",
+		SourceId:  "1",
+		AccountId: common.ToPtr("123456789012"),
+		CreatedBy: EMAIL1,
+	}
+	require.NoError(t, d.InsertUploadGrant(ctx, grant))
+	var e *pgconn.PgError
+	err = d.InsertUploadGrant(ctx, grant)
+	require.True(t, errors.As(err, &e))
+	require.Equal(t, pgerrcode.UniqueViolation, e.Code)
+	// aliases are per org
+	grant.OrgId = ORGID2
+	require.NoError(t, d.InsertUploadGrant(ctx, grant))
+
+	stored, err := d.GetUploadGrant(ctx, ORGID1, "prod")
+	require.NoError(t, err)
+	require.Equal(t, "aws", stored.Provider)
+	require.Equal(t, "123456789012", *stored.AccountId)
+	require.Nil(t, stored.TenantId)
+	require.Equal(t, EMAIL1, stored.CreatedBy)
+
+	require.NoError(t, d.InsertUploadGrant(ctx, db.UploadGrantEntry{
+		OrgId:          ORGID1,
+		Alias:          "azure",
+		Provider:       "azure",
+		SourceId:       "2",
+		TenantId:       common.ToPtr("tenant"),
+		SubscriptionId: common.ToPtr("subscription"),
+		CreatedBy:      EMAIL1,
+	}))
+	grants, err := d.GetUploadGrants(ctx, ORGID1)
+	require.NoError(t, err)
+	require.Len(t, grants, 2)
+	require.Equal(t, "azure", grants[0].Alias)
+	require.Equal(t, "subscription", *grants[0].SubscriptionId)
+	require.Equal(t, "prod", grants[1].Alias)
+
+	require.NoError(t, d.DeleteUploadGrant(ctx, ORGID1, "prod"))
+	require.ErrorIs(t, d.DeleteUploadGrant(ctx, ORGID1, "prod"), db.UploadGrantNotFoundError)
+	_, err = d.GetUploadGrant(ctx, ORGID1, "prod")
+	require.ErrorIs(t, err, db.UploadGrantNotFoundError)
+	_, err = d.GetUploadGrant(ctx, ORGID2, "prod")
+	require.NoError(t, err)
+}
+
+func testOrgPolicies(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	_, err = d.GetOrgPolicy(ctx, ORGID1)
+	require.ErrorIs(t, err, db.OrgPolicyNotFoundError)
+	err = d.DeleteOrgPolicy(ctx, ORGID1)
+	require.ErrorIs(t, err, db.OrgPolicyNotFoundError)
+
+	err = d.SetOrgPolicy(ctx, ORGID1, EMAIL1, []byte(`{"fips": true}`))
+	require.NoError(t, err)
+	policy, err := d.GetOrgPolicy(ctx, ORGID1)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"fips": true}`, string(policy.Policy))
+	require.Equal(t, EMAIL1, policy.UpdatedBy)
+
+	err = d.SetOrgPolicy(ctx, ORGID1, EMAIL1, []byte(`{"banned_packages": ["telnet"]}`))
+	require.NoError(t, err)
+	policy, err = d.GetOrgPolicy(ctx, ORGID1)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"banned_packages": ["telnet"]}`, string(policy.Policy))
+	require.Equal(t, 2, policy.Version)
+	_, err = d.GetOrgPolicy(ctx, ORGID2)
+	require.ErrorIs(t, err, db.OrgPolicyNotFoundError)
+
+	err = d.SetOrgPolicyIfVersion(ctx, ORGID1, EMAIL1, []byte(`{"fips": false}`), 1)
+	require.ErrorIs(t, err, db.OrgPolicyVersionConflictError)
+	err = d.SetOrgPolicyIfVersion(ctx, ORGID1, EMAIL1, []byte(`{"fips": false}`), 2)
+	require.NoError(t, err)
+	policy, err = d.GetOrgPolicy(ctx, ORGID1)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"fips": false}`, string(policy.Policy))
+	require.Equal(t, 3, policy.Version)
+	err = d.SetOrgPolicyIfVersion(ctx, ORGID2, EMAIL1, []byte(`{"fips": false}`), 1)
+	require.ErrorIs(t, err, db.OrgPolicyVersionConflictError)
+
+	err = d.DeleteOrgPolicyIfVersion(ctx, ORGID1, 2)
+	require.ErrorIs(t, err, db.OrgPolicyVersionConflictError)
+	err = d.DeleteOrgPolicyIfVersion(ctx, ORGID1, 3)
+	require.NoError(t, err)
+	_, err = d.GetOrgPolicy(ctx, ORGID1)
+	require.ErrorIs(t, err, db.OrgPolicyNotFoundError)
+
+	err = d.SetOrgPolicy(ctx, ORGID1, EMAIL1, []byte(`{"fips": true}`))
+	require.NoError(t, err)
+	err = d.DeleteOrgPolicy(ctx, ORGID1)
+	require.NoError(t, err)
+	_, err = d.GetOrgPolicy(ctx, ORGID1)
+	require.ErrorIs(t, err, db.OrgPolicyNotFoundError)
+}
+
+func testMonthlyComposeUsage(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+	conn := connect(t)
+	defer conn.Close(ctx)
+
+	insert := "INSERT INTO composes(job_id, request, created_at, account_number, org_id) VALUES ($1, $2, $3, $4, $5)"
+	rhelAws := `{"distribution": "rhel-9", "image_requests": [{"image_type": "aws"}]}`
+	rhelGcp := `{"distribution": "rhel-9", "image_requests": [{"image_type": "gcp"}]}`
+	march := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2024, 4, 30, 23, 0, 0, 0, time.UTC)
+
+	withBlob := uuid.New()
+	_, err = conn.Exec(ctx, insert, withBlob, rhelAws, march, ANR1, ORGID1)
+	require.NoError(t, err)
+	err = d.InsertComposeBlob(ctx, withBlob, "logs", "composes/logs", 100)
+	require.NoError(t, err)
+	err = d.InsertComposeBlob(ctx, withBlob, "manifest", "composes/manifest", 20)
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx, insert, uuid.New(), rhelAws, march, ANR1, ORGID1)
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx, insert, uuid.New(), rhelGcp, april, ANR1, ORGID1)
+	require.NoError(t, err)
+	// out of range or other org
+	_, err = conn.Exec(ctx, insert, uuid.New(), rhelAws, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), ANR1, ORGID1)
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx, insert, uuid.New(), rhelAws, march, ANR2, ORGID2)
+	require.NoError(t, err)
+
+	usage, err := d.GetMonthlyComposeUsage(ctx, ORGID1, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, usage, 2)
+	require.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), usage[0].Month.UTC())
+	require.Equal(t, "rhel-9", usage[0].Distribution)
+	require.Equal(t, "aws", usage[0].ImageType)
+	require.Equal(t, 2, usage[0].Composes)
+	require.Equal(t, int64(120), usage[0].BlobBytes)
+	require.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), usage[1].Month.UTC())
+	require.Equal(t, "gcp", usage[1].ImageType)
+	require.Equal(t, 1, usage[1].Composes)
+	require.Equal(t, int64(0), usage[1].BlobBytes)
+}
+
+func testComposeEvents(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+	conn := connect(t)
+	defer conn.Close(ctx)
+
+	lag, err := d.ComposeEventLag(ctx)
+	require.NoError(t, err)
+	require.Nil(t, lag)
+
+	id := uuid.New()
+	err = d.InsertCompose(ctx, id, ANR1, EMAIL1, ORGID1, nil, json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+	err = d.SetComposeStatus(ctx, id, "success", nil)
+	require.NoError(t, err)
+	// unchanged status, no event
+	err = d.SetComposeStatus(ctx, id, "success", nil)
+	require.NoError(t, err)
+
+	lag, err = d.ComposeEventLag(ctx)
+	require.NoError(t, err)
+	require.Less(t, *lag, time.Minute)
+
+	// the most recent events are held back
+	events, err := d.GetComposeEvents(ctx, ORGID1, 0, 100)
+	require.NoError(t, err)
+	require.Len(t, events, 0)
+
+	_, err = conn.Exec(ctx, "UPDATE compose_events SET created_at = created_at - interval '1 minute'")
+	require.NoError(t, err)
+	events, err = d.GetComposeEvents(ctx, ORGID1, 0, 100)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, db.ComposeEventCreated, events[0].Type)
+	require.Equal(t, id, events[0].ComposeId)
+	require.Equal(t, "aws", *events[0].ImageType)
+	require.Nil(t, events[0].Status)
+	require.Equal(t, db.ComposeEventStatusChanged, events[1].Type)
+	require.Equal(t, "success", *events[1].Status)
+
+	events, err = d.GetComposeEvents(ctx, ORGID1, events[0].Id, 100)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, db.ComposeEventStatusChanged, events[0].Type)
+
+	events, err = d.GetComposeEvents(ctx, ORGID2, 0, 100)
+	require.NoError(t, err)
+	require.Len(t, events, 0)
+
+	// the history of a single compose isn't held back
+	err = d.SetComposeStatus(ctx, id, "failure", nil)
+	require.NoError(t, err)
+	events, err = d.GetComposeHistory(ctx, id, ORGID1)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	require.Equal(t, "failure", *events[2].Status)
+	events, err = d.GetComposeHistory(ctx, id, ORGID2)
+	require.NoError(t, err)
+	require.Len(t, events, 0)
+
+	deleted, err := d.DeleteComposeEvents(ctx, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), deleted)
+	deleted, err = d.DeleteComposeEvents(ctx, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), deleted)
+}
+
+func testComposeExpiry(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	kept := uuid.New()
+	err = d.InsertCompose(ctx, kept, ANR1, EMAIL1, ORGID1, nil, json.RawMessage(`{}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+	expired := uuid.New()
+	err = d.InsertCompose(ctx, expired, ANR1, EMAIL1, ORGID1, nil, json.RawMessage(`{}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+	expiring := uuid.New()
+	err = d.InsertCompose(ctx, expiring, ANR1, EMAIL1, ORGID1, nil, json.RawMessage(`{}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+	elsewhere := uuid.New()
+	err = d.InsertCompose(ctx, elsewhere, ANR1, EMAIL1, ORGID1, nil, json.RawMessage(`{}`), nil, nil, common.ToPtr("eu"), nil)
+	require.NoError(t, err)
+
+	require.NoError(t, d.SetComposeExpiry(ctx, expired, ORGID1, time.Now().Add(-time.Hour)))
+	require.NoError(t, d.SetComposeExpiry(ctx, expiring, ORGID1, time.Now().Add(time.Hour)))
+	require.NoError(t, d.SetComposeExpiry(ctx, elsewhere, ORGID1, time.Now().Add(-time.Hour)))
+	require.ErrorIs(t, d.SetComposeExpiry(ctx, kept, ORGID2, time.Now()), db.ComposeNotFoundError)
+
+	compose, err := d.GetCompose(ctx, kept, ORGID1)
+	require.NoError(t, err)
+	require.Nil(t, compose.ExpiresAt)
+	compose, err = d.GetCompose(ctx, expiring, ORGID1)
+	require.NoError(t, err)
+	require.NotNil(t, compose.ExpiresAt)
+
+	// the reaper of a region only sees its composes
+	composes, err := d.GetExpiredComposes(ctx, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, composes, 1)
+	require.Equal(t, expired, composes[0].Id)
+	require.Equal(t, ORGID1, composes[0].OrgId)
+	composes, err = d.GetExpiredComposes(ctx, common.ToPtr("eu"), 10)
+	require.NoError(t, err)
+	require.Len(t, composes, 1)
+	require.Equal(t, elsewhere, composes[0].Id)
+
+	// deleted composes aren't collected again
+	require.NoError(t, d.DeleteCompose(ctx, expired, ORGID1))
+	composes, err = d.GetExpiredComposes(ctx, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, composes, 0)
+}
+
+func testComposeDrafts(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	insert := func(orgId, email, name string) (db.ComposeDraftEntry, error) {
+		draft := db.ComposeDraftEntry{
+			Id:      uuid.New(),
+			OrgId:   orgId,
+			Email:   email,
+			Name:    name,
+			Request: json.RawMessage(`{"distribution": "rhel-9"}`),
+		}
+		return draft, d.InsertComposeDraft(ctx, 2, &draft)
+	}
+	first, err := insert(ORGID1, EMAIL1, "first")
+	require.NoError(t, err)
+	require.False(t, first.CreatedAt.IsZero())
+	_, err = insert(ORGID1, EMAIL1, "second")
+	require.NoError(t, err)
+	_, err = insert(ORGID1, EMAIL1, "third")
+	require.ErrorIs(t, err, db.ComposeDraftLimitError)
+	// the limit is per user
+	other, err := insert(ORGID2, EMAIL1, "other")
+	require.NoError(t, err)
+
+	first.Name = "updated"
+	first.Request = json.RawMessage(`{"distribution": "rhel-10"}`)
+	require.NoError(t, d.UpdateComposeDraft(ctx, &first))
+	require.True(t, first.UpdatedAt.After(first.CreatedAt))
+	stored, err := d.GetComposeDraft(ctx, first.Id, ORGID1, EMAIL1)
+	require.NoError(t, err)
+	require.Equal(t, "updated", stored.Name)
+	require.JSONEq(t, `{"distribution": "rhel-10"}`, string(stored.Request))
+	_, err = d.GetComposeDraft(ctx, first.Id, ORGID1, "someone@else.com")
+	require.ErrorIs(t, err, db.ComposeDraftNotFoundError)
+	other.OrgId = ORGID1
+	require.ErrorIs(t, d.UpdateComposeDraft(ctx, &other), db.ComposeDraftNotFoundError)
+
+	drafts, err := d.GetComposeDrafts(ctx, ORGID1, EMAIL1)
+	require.NoError(t, err)
+	require.Len(t, drafts, 2)
+	require.Equal(t, "updated", drafts[0].Name)
+	require.Equal(t, "second", drafts[1].Name)
+
+	require.NoError(t, d.DeleteComposeDraft(ctx, first.Id, ORGID1, EMAIL1))
+	require.ErrorIs(t, d.DeleteComposeDraft(ctx, first.Id, ORGID1, EMAIL1), db.ComposeDraftNotFoundError)
+
+	// drafts not updated within the retention are pruned
+	conn := connect(t)
+	defer conn.Close(ctx)
+	_, err = conn.Exec(ctx, "UPDATE compose_drafts SET updated_at = CURRENT_TIMESTAMP - interval '2 hours' WHERE name = 'second'")
+	require.NoError(t, err)
+	deleted, err := d.DeleteComposeDrafts(ctx, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+	drafts, err = d.GetComposeDrafts(ctx, ORGID1, EMAIL1)
+	require.NoError(t, err)
+	require.Empty(t, drafts)
+	_, err = d.GetComposeDraft(ctx, other.Id, ORGID2, EMAIL1)
+	require.NoError(t, err)
+}
+
+func testComposeTemplates(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	insert := func(orgId, name, request string) db.ComposeTemplateEntry {
+		template := db.ComposeTemplateEntry{
+			Id:        uuid.New(),
+			OrgId:     orgId,
+			Name:      name,
+			Request:   json.RawMessage(request),
+			CreatedBy: EMAIL1,
+		}
+		require.NoError(t, d.InsertComposeTemplate(ctx, &template))
+		return template
+	}
+	require.Equal(t, 1, insert(ORGID1, "web", `{"distribution": "rhel-9"}`).Version)
+	require.Equal(t, 2, insert(ORGID1, "web", `{"distribution": "rhel-10"}`).Version)
+	// versions are per org and name
+	require.Equal(t, 1, insert(ORGID2, "web", `{}`).Version)
+	require.Equal(t, 1, insert(ORGID1, "db", `{}`).Version)
+
+	latest, err := d.GetComposeTemplate(ctx, ORGID1, "web", nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, latest.Version)
+	require.JSONEq(t, `{"distribution": "rhel-10"}`, string(latest.Request))
+	first, err := d.GetComposeTemplate(ctx, ORGID1, "web", common.ToPtr(1))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"distribution": "rhel-9"}`, string(first.Request))
+	require.Equal(t, EMAIL1, first.CreatedBy)
+	_, err = d.GetComposeTemplate(ctx, ORGID1, "web", common.ToPtr(3))
+	require.ErrorIs(t, err, db.ComposeTemplateNotFoundError)
+
+	templates, err := d.GetComposeTemplates(ctx, ORGID1)
+	require.NoError(t, err)
+	require.Len(t, templates, 2)
+	require.Equal(t, "db", templates[0].Name)
+	require.Equal(t, "web", templates[1].Name)
+	require.Equal(t, 2, templates[1].Version)
+
+	require.NoError(t, d.DeleteComposeTemplate(ctx, ORGID1, "web"))
+	require.ErrorIs(t, d.DeleteComposeTemplate(ctx, ORGID1, "web"), db.ComposeTemplateNotFoundError)
+	_, err = d.GetComposeTemplate(ctx, ORGID1, "web", nil)
+	require.ErrorIs(t, err, db.ComposeTemplateNotFoundError)
+	_, err = d.GetComposeTemplate(ctx, ORGID2, "web", nil)
+	require.NoError(t, err)
+}
+
+func testBlueprintOwnership(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+	email2 := "user2@test.test"
+
+	privateId := uuid.New()
+	err = d.InsertBlueprint(ctx, privateId, uuid.New(), ORGID1, ANR1, "private", "desc", []byte("{}"), []byte("{}"), db.BlueprintOptions{CreatedBy: EMAIL1, Private: true})
+	require.NoError(t, err)
+	sharedId := uuid.New()
+	err = d.InsertBlueprint(ctx, sharedId, uuid.New(), ORGID1, ANR1, "shared", "desc", []byte("{}"), []byte("{}"), db.BlueprintOptions{CreatedBy: email2})
+	require.NoError(t, err)
+	legacyId := uuid.New()
+	err = d.InsertBlueprint(ctx, legacyId, uuid.New(), ORGID1, ANR1, "legacy", "desc", []byte("{}"), []byte("{}"), db.BlueprintOptions{})
+	require.NoError(t, err)
+
+	entry, err := d.GetBlueprint(ctx, privateId, ORGID1, nil)
+	require.NoError(t, err)
+	require.Equal(t, EMAIL1, entry.CreatedBy)
+	require.Equal(t, EMAIL1, entry.UpdatedBy)
+	require.True(t, entry.Private)
+	entry, err = d.GetBlueprint(ctx, legacyId, ORGID1, nil)
+	require.NoError(t, err)
+	require.Empty(t, entry.CreatedBy)
+	require.False(t, entry.Private)
+
+	ids := func(entries []db.BlueprintWithNoBody) []uuid.UUID {
+		var result []uuid.UUID
+		for _, e := range entries {
+			result = append(result, e.Id)
+		}
+		return result
+	}
+	entries, count, err := d.GetBlueprints(ctx, ORGID1, db.BlueprintFilter{Viewer: EMAIL1}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+	require.ElementsMatch(t, []uuid.UUID{privateId, sharedId, legacyId}, ids(entries))
+	entries, count, err = d.GetBlueprints(ctx, ORGID1, db.BlueprintFilter{Viewer: email2}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	require.ElementsMatch(t, []uuid.UUID{sharedId, legacyId}, ids(entries))
+	entries, count, err = d.GetBlueprints(ctx, ORGID1, db.BlueprintFilter{Viewer: EMAIL1, Owner: db.BlueprintsMine}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	require.Equal(t, []uuid.UUID{privateId}, ids(entries))
+	require.True(t, entries[0].Private)
+	require.Equal(t, EMAIL1, entries[0].CreatedBy)
+	entries, count, err = d.GetBlueprints(ctx, ORGID1, db.BlueprintFilter{Viewer: EMAIL1, Owner: db.BlueprintsShared}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	require.ElementsMatch(t, []uuid.UUID{sharedId, legacyId}, ids(entries))
+	entries, count, err = d.FindBlueprints(ctx, ORGID1, "private", db.BlueprintFilter{Viewer: email2}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+	require.Empty(t, entries)
+
+	// private blueprints need a creator
+	err = d.InsertBlueprint(ctx, uuid.New(), uuid.New(), ORGID1, ANR1, "nobody's", "desc", []byte("{}"), []byte("{}"), db.BlueprintOptions{Private: true})
+	require.Error(t, err)
+	err = d.SetBlueprintPrivate(ctx, legacyId, ORGID1, true)
+	require.Error(t, err)
+
+	err = d.UpdateBlueprint(ctx, uuid.New(), sharedId, ORGID1, "shared", "desc", []byte("{}"), EMAIL1)
+	require.NoError(t, err)
+	require.NoError(t, d.SetBlueprintPrivate(ctx, sharedId, ORGID1, true))
+	entry, err = d.GetBlueprint(ctx, sharedId, ORGID1, nil)
+	require.NoError(t, err)
+	require.Equal(t, email2, entry.CreatedBy)
+	require.Equal(t, EMAIL1, entry.UpdatedBy)
+	require.True(t, entry.Private)
+	require.ErrorIs(t, d.SetBlueprintPrivate(ctx, sharedId, ORGID2, false), db.BlueprintNotFoundError)
+}
+
+func testComposeHookRuns(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	succeeded := uuid.New()
+	err = d.InsertCompose(ctx, succeeded, ANR1, EMAIL1, ORGID1, common.ToPtr("image"), []byte("{}"), nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, d.SetComposeStatus(ctx, succeeded, "success", nil))
+	failed := uuid.New()
+	err = d.InsertCompose(ctx, failed, ANR1, EMAIL1, ORGID1, nil, []byte("{}"), nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, d.SetComposeStatus(ctx, failed, "failure", nil))
+	running := uuid.New()
+	err = d.InsertCompose(ctx, running, ANR1, EMAIL1, ORGID1, nil, []byte("{}"), nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	awaiting, err := d.GetComposesAwaitingHook(ctx, "cmdb", nil, time.Hour, 10)
+	require.NoError(t, err)
+	require.Len(t, awaiting, 1)
+	require.Equal(t, succeeded, awaiting[0].Id)
+	require.Equal(t, ORGID1, awaiting[0].OrgId)
+	require.Equal(t, "image", *awaiting[0].ImageName)
+	require.Equal(t, 0, awaiting[0].Attempts)
+	awaiting, err = d.GetComposesAwaitingHook(ctx, "cmdb", common.ToPtr("eu"), time.Hour, 10)
+	require.NoError(t, err)
+	require.Empty(t, awaiting)
+
+	// pending runs are tried again, the others are done
+	err = d.SetComposeHookRun(ctx, db.ComposeHookRunEntry{ComposeId: succeeded, Hook: "cmdb", Status: db.ComposeHookPending, Attempts: 1, Error: common.ToPtr("unreachable")})
+	require.NoError(t, err)
+	awaiting, err = d.GetComposesAwaitingHook(ctx, "cmdb", nil, time.Hour, 10)
+	require.NoError(t, err)
+	require.Len(t, awaiting, 1)
+	require.Equal(t, 1, awaiting[0].Attempts)
+	err = d.SetComposeHookRun(ctx, db.ComposeHookRunEntry{ComposeId: succeeded, Hook: "cmdb", Status: db.ComposeHookSucceeded, Attempts: 2})
+	require.NoError(t, err)
+	awaiting, err = d.GetComposesAwaitingHook(ctx, "cmdb", nil, time.Hour, 10)
+	require.NoError(t, err)
+	require.Empty(t, awaiting)
+	awaiting, err = d.GetComposesAwaitingHook(ctx, "ansible", nil, time.Hour, 10)
+	require.NoError(t, err)
+	require.Len(t, awaiting, 1)
+
+	err = d.SetComposeHookRun(ctx, db.ComposeHookRunEntry{ComposeId: succeeded, Hook: "ansible", Status: db.ComposeHookFailed, Attempts: 3, Error: common.ToPtr("timed out")})
+	require.NoError(t, err)
+	runs, err := d.GetComposeHookRuns(ctx, succeeded)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	require.Equal(t, "ansible", runs[0].Hook)
+	require.Equal(t, db.ComposeHookFailed, runs[0].Status)
+	require.Equal(t, "timed out", *runs[0].Error)
+	require.Equal(t, "cmdb", runs[1].Hook)
+	require.Equal(t, db.ComposeHookSucceeded, runs[1].Status)
+	require.Equal(t, 2, runs[1].Attempts)
+	require.Nil(t, runs[1].Error)
+	require.False(t, runs[1].UpdatedAt.IsZero())
+
+	runs, err = d.GetComposeHookRuns(ctx, failed)
+	require.NoError(t, err)
+	require.Empty(t, runs)
+}
+
+func testDownloadTokens(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	token := db.DownloadTokenEntry{
+		Id:        uuid.New(),
+		OrgId:     ORGID1,
+		Name:      "build farm",
+		CreatedBy: EMAIL1,
+	}
+	require.NoError(t, d.InsertDownloadToken(ctx, &token, "hash1"))
+	require.False(t, token.CreatedAt.IsZero())
+	other := db.DownloadTokenEntry{
+		Id:        uuid.New(),
+		OrgId:     ORGID2,
+		Name:      "mirror",
+		CreatedBy: EMAIL1,
+	}
+	require.NoError(t, d.InsertDownloadToken(ctx, &other, "hash2"))
+
+	tokens, err := d.GetDownloadTokens(ctx, ORGID1)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	require.Equal(t, token.Id, tokens[0].Id)
+	require.Nil(t, tokens[0].LastUsedAt)
+
+	used, err := d.UseDownloadToken(ctx, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, token.Id, used.Id)
+	require.Equal(t, ORGID1, used.OrgId)
+	require.NotNil(t, used.LastUsedAt)
+	_, err = d.UseDownloadToken(ctx, "hash3")
+	require.ErrorIs(t, err, db.DownloadTokenNotFoundError)
+
+	// tokens are only revoked by their org
+	require.ErrorIs(t, d.DeleteDownloadToken(ctx, token.Id, ORGID2), db.DownloadTokenNotFoundError)
+	require.NoError(t, d.DeleteDownloadToken(ctx, token.Id, ORGID1))
+	_, err = d.UseDownloadToken(ctx, "hash1")
+	require.ErrorIs(t, err, db.DownloadTokenNotFoundError)
+	_, err = d.UseDownloadToken(ctx, "hash2")
+	require.NoError(t, err)
+}
+
+func testWorkspaces(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+	email2 := "user2@test.test"
+
+	workspace := &db.WorkspaceEntry{
+		Id:           uuid.New(),
+		OrgId:        ORGID1,
+		Name:         "finance",
+		Description:  "desc",
+		ComposeQuota: common.ToPtr(5),
+		Policy:       []byte(`{"fips": true}`),
+		Members: []db.WorkspaceMember{
+			{Email: EMAIL1, Role: db.WorkspaceBuilder},
+			{Email: email2, Role: db.WorkspaceViewer},
+		},
+		CreatedBy: EMAIL1,
+	}
+	require.NoError(t, d.InsertWorkspace(ctx, workspace))
+	require.False(t, workspace.CreatedAt.IsZero())
+	other := &db.WorkspaceEntry{Id: uuid.New(), OrgId: ORGID1, Name: "engineering", CreatedBy: EMAIL1}
+	require.NoError(t, d.InsertWorkspace(ctx, other))
+	// names are unique within the org
+	require.Error(t, d.InsertWorkspace(ctx, &db.WorkspaceEntry{Id: uuid.New(), OrgId: ORGID1, Name: "finance"}))
+	require.NoError(t, d.InsertWorkspace(ctx, &db.WorkspaceEntry{Id: uuid.New(), OrgId: ORGID2, Name: "finance"}))
+
+	entry, err := d.GetWorkspace(ctx, workspace.Id, ORGID1)
+	require.NoError(t, err)
+	require.Equal(t, 5, *entry.ComposeQuota)
+	require.JSONEq(t, `{"fips": true}`, string(entry.Policy))
+	require.Equal(t, workspace.Members, entry.Members)
+	_, err = d.GetWorkspace(ctx, workspace.Id, ORGID2)
+	require.ErrorIs(t, err, db.WorkspaceNotFoundError)
+
+	workspaces, err := d.GetWorkspaces(ctx, ORGID1, "")
+	require.NoError(t, err)
+	require.Len(t, workspaces, 2)
+	require.Equal(t, "engineering", workspaces[0].Name)
+	require.Empty(t, workspaces[0].Members)
+	workspaces, err = d.GetWorkspaces(ctx, ORGID1, email2)
+	require.NoError(t, err)
+	require.Len(t, workspaces, 1)
+	require.Equal(t, workspace.Id, workspaces[0].Id)
+
+	role, err := d.GetWorkspaceRole(ctx, workspace.Id, ORGID1, email2)
+	require.NoError(t, err)
+	require.Equal(t, db.WorkspaceViewer, role)
+	role, err = d.GetWorkspaceRole(ctx, other.Id, ORGID1, email2)
+	require.NoError(t, err)
+	require.Empty(t, role)
+	_, err = d.GetWorkspaceRole(ctx, workspace.Id, ORGID2, email2)
+	require.ErrorIs(t, err, db.WorkspaceNotFoundError)
+
+	// members are replaced
+	workspace.Members = []db.WorkspaceMember{{Email: email2, Role: db.WorkspaceBuilder}}
+	workspace.ComposeQuota = nil
+	require.NoError(t, d.UpdateWorkspace(ctx, workspace))
+	entry, err = d.GetWorkspace(ctx, workspace.Id, ORGID1)
+	require.NoError(t, err)
+	require.Nil(t, entry.ComposeQuota)
+	require.Equal(t, workspace.Members, entry.Members)
+	require.ErrorIs(t, d.UpdateWorkspace(ctx, &db.WorkspaceEntry{Id: workspace.Id, OrgId: ORGID2, Name: "finance"}), db.WorkspaceNotFoundError)
+
+	// the blueprints of the workspace are only listed for its members
+	blueprintId := uuid.New()
+	versionId := uuid.New()
+	err = d.InsertBlueprint(ctx, blueprintId, versionId, ORGID1, ANR1, "ledger", "desc", []byte("{}"), []byte("{}"), db.BlueprintOptions{CreatedBy: email2, WorkspaceId: &workspace.Id})
+	require.NoError(t, err)
+	orgId := uuid.New()
+	orgVersionId := uuid.New()
+	err = d.InsertBlueprint(ctx, orgId, orgVersionId, ORGID1, ANR1, "base", "desc", []byte("{}"), []byte("{}"), db.BlueprintOptions{CreatedBy: email2})
+	require.NoError(t, err)
+	blueprint, err := d.GetBlueprint(ctx, blueprintId, ORGID1, nil)
+	require.NoError(t, err)
+	require.Equal(t, workspace.Id, *blueprint.WorkspaceId)
+
+	_, count, err := d.GetBlueprints(ctx, ORGID1, db.BlueprintFilter{Viewer: EMAIL1}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	entries, count, err := d.GetBlueprints(ctx, ORGID1, db.BlueprintFilter{Viewer: email2}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	require.Len(t, entries, 2)
+	entries, count, err = d.GetBlueprints(ctx, ORGID1, db.BlueprintFilter{Viewer: EMAIL1, AllWorkspaces: true, Workspace: &workspace.Id}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	require.Equal(t, blueprintId, entries[0].Id)
+	require.Equal(t, workspace.Id, *entries[0].WorkspaceId)
+	_, count, err = d.FindBlueprints(ctx, ORGID1, "ledger", db.BlueprintFilter{Viewer: EMAIL1}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+	_, count, err = d.FindBlueprints(ctx, ORGID1, "ledger", db.BlueprintFilter{Viewer: email2}, 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+
+	for i := 0; i < 2; i++ {
+		err = d.InsertCompose(ctx, uuid.New(), ANR1, email2, ORGID1, nil, []byte("{}"), nil, &versionId, nil, nil)
+		require.NoError(t, err)
+	}
+	err = d.InsertCompose(ctx, uuid.New(), ANR1, email2, ORGID1, nil, []byte("{}"), nil, nil, nil, nil)
+	require.NoError(t, err)
+	count, err = d.CountWorkspaceComposesSince(ctx, ORGID1, workspace.Id, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	// the reservations of the composes of its blueprints count against the
+	// quota of the workspace, the ones of other composes don't
+	reserve := func(blueprintVersionId uuid.UUID) error {
+		return d.ReserveCompose(ctx, &db.ComposeReservation{Id: uuid.New(), OrgId: ORGID1, Window: time.Hour, BlueprintVersionId: &blueprintVersionId})
+	}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, reserve(versionId))
+	}
+	require.ErrorIs(t, reserve(versionId), db.WorkspaceQuotaExceededError)
+	require.NoError(t, reserve(orgVersionId))
+
+	// workspaces are only deleted without blueprints
+	require.ErrorIs(t, d.DeleteWorkspace(ctx, workspace.Id, ORGID1), db.WorkspaceNotEmptyError)
+	require.ErrorIs(t, d.DeleteWorkspace(ctx, workspace.Id, ORGID2), db.WorkspaceNotFoundError)
+	require.NoError(t, d.DeleteBlueprint(ctx, blueprintId, ORGID1, ANR1))
+	require.NoError(t, d.DeleteWorkspace(ctx, workspace.Id, ORGID1))
+	_, err = d.GetWorkspace(ctx, workspace.Id, ORGID1)
+	require.ErrorIs(t, err, db.WorkspaceNotFoundError)
+}
+
+func testOrgDefaults(t *testing.T) {
+	ctx := context.Background()
+	d, err := db.InitDBConnectionPool(connStr(t), db.PoolConfig{})
+	require.NoError(t, err)
+
+	_, err = d.GetOrgDefaults(ctx, ORGID1)
+	require.ErrorIs(t, err, db.OrgDefaultsNotFoundError)
+	err = d.DeleteOrgDefaults(ctx, ORGID1)
+	require.ErrorIs(t, err, db.OrgDefaultsNotFoundError)
+
+	err = d.SetOrgDefaults(ctx, ORGID1, EMAIL1, []byte(`{"packages": ["falcon-sensor"]}`))
+	require.NoError(t, err)
+	err = d.SetOrgDefaults(ctx, ORGID1, EMAIL1, []byte(`{"timezone": {"timezone": "UTC"}}`))
+	require.NoError(t, err)
+	defaults, err := d.GetOrgDefaults(ctx, ORGID1)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"timezone": {"timezone": "UTC"}}`, string(defaults.Defaults))
+	require.Equal(t, EMAIL1, defaults.UpdatedBy)
+	_, err = d.GetOrgDefaults(ctx, ORGID2)
+	require.ErrorIs(t, err, db.OrgDefaultsNotFoundError)
+
+	composeId := uuid.New()
+	err = d.InsertCompose(ctx, composeId, ANR1, EMAIL1, ORGID1, nil, []byte("{}"), nil, nil, nil, nil)
+	require.NoError(t, err)
+	injected, err := d.GetComposeInjectedDefaults(ctx, composeId, ORGID1)
+	require.NoError(t, err)
+	require.Nil(t, injected)
+	err = d.SetComposeInjectedDefaults(ctx, composeId, ORGID1, []byte(`{"timezone": true}`))
+	require.NoError(t, err)
+	injected, err = d.GetComposeInjectedDefaults(ctx, composeId, ORGID1)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"timezone": true}`, string(injected))
+	// composes of other orgs aren't found
+	err = d.SetComposeInjectedDefaults(ctx, composeId, ORGID2, []byte(`{"timezone": true}`))
+	require.ErrorIs(t, err, db.ComposeNotFoundError)
+	_, err = d.GetComposeInjectedDefaults(ctx, composeId, ORGID2)
+	require.ErrorIs(t, err, db.ComposeNotFoundError)
+
+	err = d.DeleteOrgDefaults(ctx, ORGID1)
+	require.NoError(t, err)
+	_, err = d.GetOrgDefaults(ctx, ORGID1)
+	require.ErrorIs(t, err, db.OrgDefaultsNotFoundError)
+}
+
 func runTest(t *testing.T, f func(*testing.T)) {
 	migrateTern(t)
 	defer tearDown(t)
@@ -605,13 +2177,42 @@ func runTest(t *testing.T, f func(*testing.T)) {
 func TestAll(t *testing.T) {
 	fns := []func(*testing.T){
 		testInsertCompose,
+		testStatementCacheModes,
 		testGetCompose,
+		testComposeGroups,
+		testComposeQueryPlans,
+		testGetComposesAfter,
+		testGetComposesFiltered,
 		testCountComposesSince,
+		testReserveCompose,
+		testPriorityLane,
+		testComposePipelines,
+		testTransferComposes,
+		testUpdateCompose,
+		testCountComposesByKind,
+		testComposeMetadata,
 		testGetComposeImageType,
 		testDeleteCompose,
+		testUnfinishedComposes,
 		testClones,
 		testBlueprints,
+		testGetBlueprintVersion,
+		testUpdateBlueprintIfVersion,
 		testGetBlueprintComposes,
+		testBlueprintLifecycles,
+		testGitOpsRepositories,
+		testUploadGrants,
+		testOrgPolicies,
+		testMonthlyComposeUsage,
+		testComposeEvents,
+		testComposeExpiry,
+		testComposeTemplates,
+		testComposeDrafts,
+		testDownloadTokens,
+		testBlueprintOwnership,
+		testComposeHookRuns,
+		testWorkspaces,
+		testOrgDefaults,
 	}
 
 	for _, f := range fns {
@@ -0,0 +1,212 @@
+// image-builder-worker runs the background subsystems of image-builder apart
+// from the API, so they scale independently and don't compete with requests
+// for latency. The API has to be deployed with SEPARATE_WORKER then.
+//
+// With -telemetry-preview it prints the usage telemetry report it would send
+// now, whether telemetry is enabled or not, and exits.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	sentrylogrus "github.com/getsentry/sentry-go/logrus"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/labstack/gommon/log"
+	"github.com/sirupsen/logrus"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/config"
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/distribution"
+	"github.com/osbuild/image-builder/internal/logger"
+	"github.com/osbuild/image-builder/internal/oauth2"
+	"github.com/osbuild/image-builder/internal/profile"
+	"github.com/osbuild/image-builder/internal/prometheus"
+	"github.com/osbuild/image-builder/internal/readonly"
+	"github.com/osbuild/image-builder/internal/telemetry"
+	v1 "github.com/osbuild/image-builder/internal/v1"
+	"github.com/osbuild/image-builder/internal/worker"
+)
+
+func main() {
+	telemetryPreview := flag.Bool("telemetry-preview", false, "print the telemetry report which would be sent now and exit")
+	flag.Parse()
+
+	conf := config.ImageBuilderConfig{
+		ListenAddress: "localhost:8087",
+		LogLevel:      "INFO",
+		PGHost:        "localhost",
+		PGPort:        "5432",
+		PGDatabase:    "imagebuilder",
+		PGUser:        "postgres",
+		PGPassword:    "foobar",
+		PGSSLMode:     "prefer",
+	}
+
+	err := config.LoadConfigFromEnv(&conf)
+	if err != nil {
+		panic(err)
+	}
+	err = config.CheckAddresses(&conf)
+	if err != nil {
+		panic(fmt.Errorf("invalid addresses in the configuration: %w", err))
+	}
+
+	// nothing may be reached before the profile is checked
+	deploymentProfile, err := profile.New(conf.DeploymentProfile, conf.EgressAllowedHosts)
+	if err != nil {
+		panic(err)
+	}
+	err = deploymentProfile.CheckConfig(&conf)
+	if err != nil {
+		panic(fmt.Errorf("configuration violates the %s deployment profile: %w", conf.DeploymentProfile, err))
+	}
+
+	if conf.GlitchTipDSN != "" {
+		err = sentry.Init(sentry.ClientOptions{
+			Dsn: conf.GlitchTipDSN,
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	err = logger.ConfigLogger(logrus.StandardLogger(), conf.LogLevel)
+	if err != nil {
+		panic(err)
+	}
+
+	if conf.GlitchTipDSN == "" {
+		logrus.Warn("Sentry/Glitchtip was not initialized")
+	} else {
+		sentryhook := sentrylogrus.NewFromClient([]logrus.Level{logrus.PanicLevel,
+			logrus.FatalLevel, logrus.ErrorLevel},
+			sentry.CurrentHub().Client())
+		logrus.AddHook(sentryhook)
+	}
+
+	if conf.CwAccessKeyID != "" {
+		err = logger.AddCloudWatchHook(logrus.StandardLogger(), conf.CwAccessKeyID, conf.CwSecretAccessKey, conf.CwRegion, conf.LogGroup)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if conf.SplunkHost != "" {
+		err = logger.AddSplunkHook(logrus.StandardLogger(), conf.SplunkHost, conf.SplunkPort, conf.SplunkToken)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	poolConf, err := db.ParsePoolConfig(conf.PGPoolMaxConns, conf.PGPoolMaxConnIdleTime, conf.PGStatementCacheMode)
+	if err != nil {
+		panic(err)
+	}
+	dbase, err := db.InitDBConnectionPool(conf.PostgresURL(), poolConf)
+	if err != nil {
+		panic(err)
+	}
+
+	if *telemetryPreview {
+		window := telemetry.DefaultInterval
+		if conf.TelemetryInterval != "" {
+			window, err = time.ParseDuration(conf.TelemetryInterval)
+			if err != nil {
+				panic(err)
+			}
+		}
+		report, err := telemetry.New(dbase, conf.TelemetryURL, nil).Collect(context.Background(), window)
+		if err != nil {
+			panic(err)
+		}
+		body, err := report.Marshal()
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(body))
+		return
+	}
+
+	composerConf := composer.ComposerClientConfig{
+		URL:             conf.ComposerURL,
+		CA:              conf.ComposerCA,
+		StrictResponses: conf.ComposerStrict,
+		RecordDir:       conf.ComposerRecordDir,
+		Tokener: &oauth2.LazyToken{
+			Url:          conf.ComposerTokenURL,
+			ClientId:     conf.ComposerClientId,
+			ClientSecret: conf.ComposerClientSecret,
+		},
+	}
+	compClient, err := composer.NewClient(composerConf)
+	if err != nil {
+		panic(err)
+	}
+	tenants, err := composer.LoadTenants(conf.ComposerTenantsFile, composerConf)
+	if err != nil {
+		panic(err)
+	}
+
+	// the watchdog counts the timeouts per image type
+	prometheus.SetLabelValues("image_type", distribution.ImageTypeNames()...)
+
+	readOnly := readonly.New(conf.ReadOnly, "")
+	if conf.ReadOnlyFile != "" {
+		go readOnly.Watch(context.Background(), conf.ReadOnlyFile, readonly.DefaultInterval)
+	}
+
+	err = worker.Start(context.Background(), &conf, dbase, compClient, tenants, readOnly)
+	if err != nil {
+		panic(err)
+	}
+
+	spec, err := v1.GetSwagger()
+	if err != nil {
+		panic(err)
+	}
+
+	echoServer := echo.New()
+	echoServer.HideBanner = true
+	echoServer.Logger = common.Logger()
+	echoServer.Use(middleware.RecoverWithConfig(middleware.RecoverConfig{
+		LogLevel: log.ERROR,
+	}))
+
+	/* Used for the livenessProbe */
+	echoServer.GET("/status", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, v1.Version{
+			Version:     spec.Info.Version,
+			BuildCommit: common.ToPtr(common.BuildCommit),
+			BuildTime:   common.ToPtr(common.BuildTime),
+		})
+	})
+
+	/* Used for the readinessProbe, the subsystems pause while read-only */
+	echoServer.GET("/ready", func(c echo.Context) error {
+		if enabled, reason := readOnly.Enabled(); enabled {
+			return c.JSON(http.StatusOK, v1.Readiness{
+				Readiness: "read-only",
+				Reason:    &reason,
+			})
+		}
+		return c.JSON(http.StatusOK, v1.Readiness{
+			Readiness: "ready",
+		})
+	})
+
+	prometheus.AddMetricsRoutes(echoServer, conf.MetricsToken)
+
+	logrus.Infof("🚀 Starting image-builder-worker built %s sha %s on %v ...\n", common.BuildTime, common.BuildCommit, conf.ListenAddress)
+	err = echoServer.Start(conf.ListenAddress)
+	if err != nil {
+		panic(err)
+	}
+}
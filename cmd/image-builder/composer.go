@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+)
+
+// regionalComposerClients creates the clients of the composers in the other
+// regions from a comma separated list of region=url pairs, they share the
+// credentials and CA of the local one.
+func regionalComposerClients(spec string, conf composer.ComposerClientConfig) (map[string]*composer.ComposerClient, error) {
+	clients := map[string]*composer.ComposerClient{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		region, url, ok := strings.Cut(entry, "=")
+		if !ok || region == "" || url == "" {
+			return nil, fmt.Errorf("regional composer needs to be in the region=url format")
+		}
+		if _, ok := clients[region]; ok {
+			return nil, fmt.Errorf("region %s is listed twice", region)
+		}
+		conf.URL = url
+		client, err := composer.NewClient(conf)
+		if err != nil {
+			return nil, err
+		}
+		clients[region] = client
+	}
+	return clients, nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+)
+
+func TestRegionalComposerClients(t *testing.T) {
+	clients, err := regionalComposerClients("", composer.ComposerClientConfig{})
+	require.NoError(t, err)
+	require.Empty(t, clients)
+
+	clients, err = regionalComposerClients("eu-west-1=https://eu.example.com, us-west-2=https://us.example.com", composer.ComposerClientConfig{})
+	require.NoError(t, err)
+	require.Len(t, clients, 2)
+	require.Contains(t, clients, "eu-west-1")
+	require.Contains(t, clients, "us-west-2")
+
+	_, err = regionalComposerClients("https://eu.example.com", composer.ComposerClientConfig{})
+	require.Error(t, err)
+
+	_, err = regionalComposerClients("eu-west-1=https://a.example.com,eu-west-1=https://b.example.com", composer.ComposerClientConfig{})
+	require.Error(t, err)
+}
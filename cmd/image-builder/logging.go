@@ -97,6 +97,8 @@ func SkipPath(path string) bool {
 	switch path {
 	case "/metrics":
 		return true
+	case "/metrics/runtime":
+		return true
 	case "/status":
 		return true
 	case "/ready":
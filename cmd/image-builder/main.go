@@ -1,7 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/osbuild/image-builder/internal/oauth2"
 
@@ -13,8 +18,17 @@ import (
 	"github.com/osbuild/image-builder/internal/config"
 	"github.com/osbuild/image-builder/internal/db"
 	"github.com/osbuild/image-builder/internal/distribution"
+	"github.com/osbuild/image-builder/internal/encryption"
+	"github.com/osbuild/image-builder/internal/gitops"
 	"github.com/osbuild/image-builder/internal/logger"
+	"github.com/osbuild/image-builder/internal/profile"
+	"github.com/osbuild/image-builder/internal/prometheus"
+	"github.com/osbuild/image-builder/internal/readonly"
+	"github.com/osbuild/image-builder/internal/repocheck"
+	"github.com/osbuild/image-builder/internal/sharelink"
+	"github.com/osbuild/image-builder/internal/storage"
 	v1 "github.com/osbuild/image-builder/internal/v1"
+	"github.com/osbuild/image-builder/internal/worker"
 
 	"github.com/getsentry/sentry-go"
 	sentryecho "github.com/getsentry/sentry-go/echo"
@@ -26,6 +40,16 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "spec-verify" {
+		err := specVerify(os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	started := time.Now()
 	conf := config.ImageBuilderConfig{
 		ListenAddress: "localhost:8086",
 		LogLevel:      "INFO",
@@ -41,6 +65,20 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	err = config.CheckAddresses(&conf)
+	if err != nil {
+		panic(fmt.Errorf("invalid addresses in the configuration: %w", err))
+	}
+
+	// nothing may be reached before the profile is checked
+	deploymentProfile, err := profile.New(conf.DeploymentProfile, conf.EgressAllowedHosts)
+	if err != nil {
+		panic(err)
+	}
+	err = deploymentProfile.CheckConfig(&conf)
+	if err != nil {
+		panic(fmt.Errorf("configuration violates the %s deployment profile: %w", conf.DeploymentProfile, err))
+	}
 
 	if conf.GlitchTipDSN != "" {
 		err = sentry.Init(sentry.ClientOptions{
@@ -80,15 +118,20 @@ func main() {
 		}
 	}
 
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s", conf.PGUser, conf.PGPassword, conf.PGHost, conf.PGPort, conf.PGDatabase, conf.PGSSLMode)
-	dbase, err := db.InitDBConnectionPool(connStr)
+	poolConf, err := db.ParsePoolConfig(conf.PGPoolMaxConns, conf.PGPoolMaxConnIdleTime, conf.PGStatementCacheMode)
+	if err != nil {
+		panic(err)
+	}
+	dbase, err := db.InitDBConnectionPool(conf.PostgresURL(), poolConf)
 	if err != nil {
 		panic(err)
 	}
 
 	composerConf := composer.ComposerClientConfig{
-		URL: conf.ComposerURL,
-		CA:  conf.ComposerCA,
+		URL:             conf.ComposerURL,
+		CA:              conf.ComposerCA,
+		StrictResponses: conf.ComposerStrict,
+		RecordDir:       conf.ComposerRecordDir,
 		Tokener: &oauth2.LazyToken{
 			Url:          conf.ComposerTokenURL,
 			ClientId:     conf.ComposerClientId,
@@ -110,6 +153,14 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	regionalCompClients, err := regionalComposerClients(conf.ComposerRegionalURLs, composerConf)
+	if err != nil {
+		panic(err)
+	}
+	tenantCompClients, err := composer.LoadTenants(conf.ComposerTenantsFile, composerConf)
+	if err != nil {
+		panic(err)
+	}
 	provClient, err := provisioning.NewClient(provisioning.ProvisioningClientConfig{
 		URL: conf.ProvisioningURL,
 	})
@@ -129,15 +180,79 @@ func main() {
 		panic(err)
 	}
 
+	// the spec is parsed while the distributions are read, Attach finds it
+	// parsed already
+	go func() {
+		_, _ = v1.GetSwagger()
+	}()
+	phaseStarted := time.Now()
 	adr, err := distribution.LoadDistroRegistry(conf.DistributionsDir)
 	if err != nil {
 		panic(err)
 	}
+	prometheus.SetLabelValues("phase", "distributions", "attach", "total")
+	prometheus.StartupDuration.WithLabelValues("distributions").Set(time.Since(phaseStarted).Seconds())
+
+	repoMirrors, err := profile.ParseMirrors(conf.RepoMirrors)
+	if err != nil {
+		panic(err)
+	}
+	adr.MirrorRepositories(repoMirrors)
+	err = deploymentProfile.CheckDistributions(adr)
+	if err != nil {
+		panic(fmt.Errorf("distributions violate the %s deployment profile: %w", conf.DeploymentProfile, err))
+	}
 
 	if len(adr.Available(true).List()) == 0 {
 		panic("no distributions defined")
 	}
 
+	// metric labels only take known values
+	var distroNames []string
+	for _, d := range adr.Available(true).List() {
+		distroNames = append(distroNames, d.Distribution.Name)
+	}
+	prometheus.SetLabelValues("distro", distroNames...)
+	prometheus.SetLabelValues("image_type", distribution.ImageTypeNames()...)
+
+	blobStorage, err := storage.New(storage.Config{
+		Backend:  conf.StorageBackend,
+		LocalDir: conf.StorageLocalDir,
+		S3: storage.S3Config{
+			Bucket:          conf.StorageS3Bucket,
+			Region:          conf.StorageS3Region,
+			Endpoint:        conf.StorageS3Endpoint,
+			AccessKeyID:     conf.StorageS3AccessKeyID,
+			SecretAccessKey: conf.StorageS3SecretKey,
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	var keyring *encryption.Keyring
+	if conf.RequestEncryptionKeys != "" {
+		keyring, err = encryption.ParseKeyring(conf.RequestEncryptionKeys)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	var shareLinks *sharelink.Signer
+	if conf.ShareLinkKeys != "" {
+		shareLinks, err = sharelink.ParseSigner(conf.ShareLinkKeys)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	readOnly := readonly.New(conf.ReadOnly, "")
+
+	var repoChecker *repocheck.Checker
+	if conf.RepoCheckEnabled {
+		repoChecker = repocheck.New(adr, nil)
+	}
+
 	echoServer := echo.New()
 	echoServer.HideBanner = true
 	echoServer.Logger = common.Logger()
@@ -178,6 +293,55 @@ func main() {
 	if conf.IsDebug() {
 		echoServer.Debug = true
 	}
+	var govCloudDistros []string
+	if conf.GovCloudDistros != "" {
+		govCloudDistros = strings.Split(conf.GovCloudDistros, ",")
+	}
+	emulatedArchs, err := v1.ParseEmulatedArchitectures(conf.EmulatedArchitectures)
+	if err != nil {
+		panic(err)
+	}
+	var composeExpiry time.Duration
+	if conf.ComposeExpiry != "" {
+		composeExpiry, err = time.ParseDuration(conf.ComposeExpiry)
+		if err != nil {
+			panic(err)
+		}
+	}
+	// images uploaded to object storage are handed out through signed links
+	var downloadSigner storage.URLSigner
+	if conf.DownloadS3AccessKeyID != "" {
+		downloadSigner, err = storage.NewS3Signer(storage.S3Config{
+			Region:          conf.DownloadS3Region,
+			Endpoint:        conf.DownloadS3Endpoint,
+			AccessKeyID:     conf.DownloadS3AccessKeyID,
+			SecretAccessKey: conf.DownloadS3SecretKey,
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+	var downloadLinkLifetime time.Duration
+	if conf.DownloadLinkLifetime != "" {
+		downloadLinkLifetime, err = time.ParseDuration(conf.DownloadLinkLifetime)
+		if err != nil {
+			panic(err)
+		}
+	}
+	var priorityLaneLimit int
+	if conf.PriorityLaneLimit != "" {
+		priorityLaneLimit, err = strconv.Atoi(conf.PriorityLaneLimit)
+		if err != nil {
+			panic(err)
+		}
+	}
+	var draftLimit int
+	if conf.DraftLimit != "" {
+		draftLimit, err = strconv.Atoi(conf.DraftLimit)
+		if err != nil {
+			panic(err)
+		}
+	}
 	serverConfig := &v1.ServerConfig{
 		EchoServer:      echoServer,
 		CompClient:      compClient,
@@ -188,7 +352,8 @@ func main() {
 		DBase:           dbase,
 
 		AwsConfig: v1.AWSConfig{
-			Region: conf.OsbuildRegion,
+			Region:          conf.OsbuildRegion,
+			GovCloudDistros: govCloudDistros,
 		},
 		GcpConfig: v1.GCPConfig{
 			Region: conf.OsbuildGCPRegion,
@@ -199,13 +364,63 @@ func main() {
 		AllDistros:       adr,
 		DistributionsDir: conf.DistributionsDir,
 		FedoraAuth:       conf.FedoraAuth,
+		Storage:          blobStorage,
+		Keyring:          keyring,
+		RedactRequests:   conf.RedactStoredRequests,
+		GitFetcher:       deploymentProfile.GitFetcher(gitops.NewGit("")),
+		RepoChecker:      repoChecker,
+		Region:           conf.ComposerRegion,
+		ReadOnly:         readOnly,
+		MetricsToken:     conf.MetricsToken,
+
+		RegionalCompClients:   regionalCompClients,
+		TenantComposers:       tenantCompClients,
+		EmulatedArchitectures: emulatedArchs,
+		ShareLinks:            shareLinks,
+		ComposeExpiry:         composeExpiry,
+		DownloadSigner:        downloadSigner,
+		DownloadLinkLifetime:  downloadLinkLifetime,
+		PriorityLaneLimit:     priorityLaneLimit,
+		DraftLimit:            draftLimit,
+	}
+
+	phaseStarted = time.Now()
+	server, err := v1.Attach(serverConfig)
+	if err != nil {
+		panic(err)
 	}
+	prometheus.StartupDuration.WithLabelValues("attach").Set(time.Since(phaseStarted).Seconds())
 
-	err = v1.Attach(serverConfig)
+	err = worker.StartPipelines(context.Background(), &conf, dbase, server, readOnly)
 	if err != nil {
 		panic(err)
 	}
 
+	// a separately deployed image-builder-worker runs them instead
+	if !conf.SeparateWorker {
+		err = worker.Start(context.Background(), &conf, dbase, compClient, tenantCompClients, readOnly)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if conf.ReadOnlyFile != "" {
+		go readOnly.Watch(context.Background(), conf.ReadOnlyFile, readonly.DefaultInterval)
+	}
+
+	if repoChecker != nil {
+		interval := repocheck.DefaultInterval
+		if conf.RepoCheckInterval != "" {
+			interval, err = time.ParseDuration(conf.RepoCheckInterval)
+			if err != nil {
+				panic(err)
+			}
+		}
+		go repoChecker.Run(context.Background(), interval)
+	}
+
+	prometheus.StartupDuration.WithLabelValues("total").Set(time.Since(started).Seconds())
+	logrus.Infof("Ready to listen after %v", time.Since(started))
 	logrus.Infof("🚀 Starting image-builder built %s sha %s server on %v ...\n", common.BuildTime, common.BuildCommit, conf.ListenAddress)
 	err = echoServer.Start(conf.ListenAddress)
 	if err != nil {
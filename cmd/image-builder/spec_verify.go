@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	v1 "github.com/osbuild/image-builder/internal/v1"
+)
+
+// specVerify prints the routes the handlers and the spec disagree on, it
+// fails when there are any. It needs neither configuration nor a database.
+func specVerify(out io.Writer) error {
+	mismatches, err := v1.VerifySpec()
+	if err != nil {
+		return fmt.Errorf("unable to load the spec: %w", err)
+	}
+	for _, m := range mismatches {
+		fmt.Fprintln(out, m)
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%d routes of the handlers and the spec differ", len(mismatches))
+	}
+	fmt.Fprintln(out, "The handlers match the spec")
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/osbuild/image-builder/internal/common"
 	"github.com/osbuild/image-builder/internal/oauth2"
@@ -23,12 +24,19 @@ type ComposerClient struct {
 	composerURL string
 	tokener     oauth2.Tokener
 	client      *http.Client
+	validator   *responseValidator
 }
 
 type ComposerClientConfig struct {
 	URL     string
 	CA      string
 	Tokener oauth2.Tokener
+	// StrictResponses fails the requests whose responses don't match the
+	// API of composer, otherwise they're parsed leniently.
+	StrictResponses bool
+	// RecordDir is where the interactions with composer are recorded to
+	// golden files, see NewReplayHandler. Recording is off if empty.
+	RecordDir string
 }
 
 var contentHeaders = map[string]string{"Content-Type": "application/json"}
@@ -41,12 +49,22 @@ func NewClient(conf ComposerClientConfig) (*ComposerClient, error) {
 	if err != nil {
 		return nil, fmt.Errorf("Error creating compose http client")
 	}
+	if conf.RecordDir != "" {
+		logrus.Warnf("Recording the interactions with composer %s to %s", conf.URL, conf.RecordDir)
+		client.Transport = newRecorder(client.Transport, conf.RecordDir)
+	}
 
 	cc := ComposerClient{
 		composerURL: fmt.Sprintf("%s/api/image-builder-composer/v2", conf.URL),
 		tokener:     conf.Tokener,
 		client:      client,
 	}
+	if conf.StrictResponses {
+		cc.validator, err = newResponseValidator(cc.composerURL)
+		if err != nil {
+			return nil, fmt.Errorf("Error loading the composer API to validate responses: %w", err)
+		}
+	}
 
 	return &cc, nil
 }
@@ -70,7 +88,11 @@ func createClient(composerURL string, ca string) (*http.Client, error) {
 		RootCAs:    caCertPool,
 	}
 
-	transport := &http.Transport{TLSClientConfig: tlsConfig}
+	// the clone keeps the dialer of the default transport, which tries the
+	// IPv6 and IPv4 addresses of dual-stack hosts happy eyeballs style
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.TLSClientConfig = tlsConfig
 	return &http.Client{Transport: transport}, nil
 }
 
@@ -109,9 +131,21 @@ func (cc *ComposerClient) request(method, url string, headers map[string]string,
 			}
 		}
 		resp, err = cc.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cc.validator != nil {
+		err = cc.validator.validate(req, resp)
+		if err != nil {
+			_ = resp.Body.Close()
+			logrus.Errorf("Response of composer to %s %s violates its API: %v", method, url, err)
+			return nil, fmt.Errorf("composer response violates its API: %w", err)
+		}
 	}
 
-	return resp, err
+	return resp, nil
 }
 
 func (cc *ComposerClient) ComposeStatus(id uuid.UUID) (*http.Response, error) {
@@ -122,6 +156,38 @@ func (cc *ComposerClient) ComposeMetadata(id uuid.UUID) (*http.Response, error)
 	return cc.request("GET", fmt.Sprintf("%s/composes/%s/metadata", cc.composerURL, id), nil, nil)
 }
 
+func (cc *ComposerClient) ComposeManifests(id uuid.UUID) (*http.Response, error) {
+	return cc.request("GET", fmt.Sprintf("%s/composes/%s/manifests", cc.composerURL, id), nil, nil)
+}
+
+func (cc *ComposerClient) ComposeLogs(id uuid.UUID) (*http.Response, error) {
+	return cc.request("GET", fmt.Sprintf("%s/composes/%s/logs", cc.composerURL, id), nil, nil)
+}
+
+func (cc *ComposerClient) DeleteCompose(id uuid.UUID) (*http.Response, error) {
+	return cc.request("DELETE", fmt.Sprintf("%s/composes/%s", cc.composerURL, id), nil, nil)
+}
+
+// ErrorCodeComposeNotFound is the code of the error composer answers with
+// for composes it doesn't know.
+const ErrorCodeComposeNotFound = "IMAGE-BUILDER-COMPOSER-15"
+
+// IsComposeNotFound tells if composer answered with body that it doesn't know
+// the compose. The 404 of a route composer lacks isn't, the compose may well
+// still be there.
+func IsComposeNotFound(statusCode int, body []byte) bool {
+	if statusCode != http.StatusNotFound {
+		return false
+	}
+	var cError Error
+	err := json.Unmarshal(body, &cError)
+	return err == nil && cError.Code == ErrorCodeComposeNotFound
+}
+
+func (cc *ComposerClient) CancelCompose(id uuid.UUID) (*http.Response, error) {
+	return cc.request("POST", fmt.Sprintf("%s/composes/%s/cancel", cc.composerURL, id), nil, nil)
+}
+
 func (cc *ComposerClient) Compose(compose ComposeRequest) (*http.Response, error) {
 	buf, err := json.Marshal(compose)
 	if err != nil {
@@ -131,6 +197,25 @@ func (cc *ComposerClient) Compose(compose ComposeRequest) (*http.Response, error
 	return cc.request("POST", fmt.Sprintf("%s/compose", cc.composerURL), contentHeaders, bytes.NewReader(buf))
 }
 
+func (cc *ComposerClient) Depsolve(depsolve DepsolveRequest) (*http.Response, error) {
+	buf, err := json.Marshal(depsolve)
+	if err != nil {
+		return nil, err
+	}
+
+	return cc.request("POST", fmt.Sprintf("%s/depsolve/blueprint", cc.composerURL), contentHeaders, bytes.NewReader(buf))
+}
+
+// TokenExpiry is when the token the client authenticates with expires, false
+// if its tokener doesn't know.
+func (cc *ComposerClient) TokenExpiry() (time.Time, bool) {
+	expirer, ok := cc.tokener.(oauth2.Expirer)
+	if !ok {
+		return time.Time{}, false
+	}
+	return expirer.Expiry(), true
+}
+
 func (cc *ComposerClient) OpenAPI() (*http.Response, error) {
 	return cc.request("GET", fmt.Sprintf("%s/openapi", cc.composerURL), nil, nil)
 }
@@ -0,0 +1,81 @@
+package composer
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/oauth2"
+)
+
+func TestStrictResponses(t *testing.T) {
+	valid := uuid.New()
+	drifted := uuid.New()
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, valid.String()):
+			_, err := fmt.Fprintf(w, `{"href": "", "id": "%s", "kind": "ComposeStatus", "status": "pending", "image_status": {"status": "building"}}`, valid)
+			require.NoError(t, err)
+		case strings.HasSuffix(r.URL.Path, drifted.String()):
+			_, err := fmt.Fprintf(w, `{"href": "", "id": "%s", "kind": "ComposeStatus", "status": "queued", "image_status": {"status": "building"}}`, drifted)
+			require.NoError(t, err)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer apiSrv.Close()
+
+	client := func(strict bool) *ComposerClient {
+		cc, err := NewClient(ComposerClientConfig{
+			URL:             apiSrv.URL,
+			Tokener:         &oauth2.DummyToken{},
+			StrictResponses: strict,
+		})
+		require.NoError(t, err)
+		return cc
+	}
+	strict := client(true)
+	lenient := client(false)
+
+	// the body of a valid response can still be read
+	resp, err := strict.ComposeStatus(valid)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Contains(t, string(body), `"status": "pending"`)
+
+	_, err = strict.ComposeStatus(drifted)
+	require.ErrorContains(t, err, "composer response violates its API: status 200, body at /status: value is not one of the allowed values")
+	resp, err = lenient.ComposeStatus(drifted)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	// composer answers the cancel with a status the API doesn't list
+	_, err = strict.CancelCompose(valid)
+	require.ErrorContains(t, err, "composer response violates its API")
+
+	// operations missing from the API aren't validated
+	resp, err = strict.request(http.MethodGet, fmt.Sprintf("%s/unknown/%s", strict.composerURL, uuid.New()), nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	// nor is the one of the delete
+	_, err = strict.DeleteCompose(uuid.New())
+	require.ErrorContains(t, err, "composer response violates its API")
+}
+
+func TestIsComposeNotFound(t *testing.T) {
+	require.True(t, IsComposeNotFound(http.StatusNotFound, []byte(`{"code": "IMAGE-BUILDER-COMPOSER-15", "reason": "Compose with given id not found"}`)))
+	// routes composer lacks
+	require.False(t, IsComposeNotFound(http.StatusNotFound, []byte(`{"code": "IMAGE-BUILDER-COMPOSER-21", "reason": "Requested resource doesn't exist"}`)))
+	require.False(t, IsComposeNotFound(http.StatusNotFound, []byte("404 page not found")))
+	require.False(t, IsComposeNotFound(http.StatusMethodNotAllowed, []byte(`{"code": "IMAGE-BUILDER-COMPOSER-15"}`)))
+}
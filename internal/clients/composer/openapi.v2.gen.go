@@ -14,6 +14,19 @@ const (
 	BearerScopes = "Bearer.Scopes"
 )
 
+// Defines values for AWSEC2UploadOptionsBootMode.
+const (
+	AWSEC2UploadOptionsBootModeLegacyBios    AWSEC2UploadOptionsBootMode = "legacy-bios"
+	AWSEC2UploadOptionsBootModeUefi          AWSEC2UploadOptionsBootMode = "uefi"
+	AWSEC2UploadOptionsBootModeUefiPreferred AWSEC2UploadOptionsBootMode = "uefi-preferred"
+)
+
+// Defines values for AzureUploadOptionsHyperVGeneration.
+const (
+	AzureUploadOptionsHyperVGenerationV1 AzureUploadOptionsHyperVGeneration = "V1"
+	AzureUploadOptionsHyperVGenerationV2 AzureUploadOptionsHyperVGeneration = "V2"
+)
+
 // Defines values for BlueprintCustomizationsPartitioningMode.
 const (
 	BlueprintCustomizationsPartitioningModeAutoLvm BlueprintCustomizationsPartitioningMode = "auto-lvm"
@@ -104,11 +117,29 @@ type AWSEC2CloneCompose struct {
 
 // AWSEC2UploadOptions defines model for AWSEC2UploadOptions.
 type AWSEC2UploadOptions struct {
+	// BootMode Boot mode the AMI is registered with, defaults to the boot mode of the image type.
+	BootMode   *AWSEC2UploadOptionsBootMode `json:"boot_mode,omitempty"`
+	EnaSupport *bool                        `json:"ena_support,omitempty"`
+
+	// HibernationCapable Configure the image for hibernating instances.
+	HibernationCapable *bool `json:"hibernation_capable,omitempty"`
+
+	// ImdsV2Required Register the AMI with the IMDS support 'v2.0'.
+	ImdsV2Required    *bool    `json:"imds_v2_required,omitempty"`
 	Region            string   `json:"region"`
 	ShareWithAccounts []string `json:"share_with_accounts"`
 	SnapshotName      *string  `json:"snapshot_name,omitempty"`
+
+	// SriovNetSupport Register the AMI with the 'simple' SR-IOV networking support.
+	SriovNetSupport *bool `json:"sriov_net_support,omitempty"`
+
+	// TpmSupport Register the AMI with the TPM support 'v2.0'.
+	TpmSupport *bool `json:"tpm_support,omitempty"`
 }
 
+// AWSEC2UploadOptionsBootMode Boot mode the AMI is registered with, defaults to the boot mode of the image type.
+type AWSEC2UploadOptionsBootMode string
+
 // AWSEC2UploadStatus defines model for AWSEC2UploadStatus.
 type AWSEC2UploadStatus struct {
 	Ami    string `json:"ami"`
@@ -134,6 +165,10 @@ type AWSS3UploadStatus struct {
 
 // AzureUploadOptions defines model for AzureUploadOptions.
 type AzureUploadOptions struct {
+	// HyperVGeneration Choose the VM Image HyperV generation, different features on Azure are available
+	// depending on the HyperV generation.
+	HyperVGeneration *AzureUploadOptionsHyperVGeneration `json:"hyper_v_generation,omitempty"`
+
 	// ImageName Name of the uploaded image. It must be unique in the given resource group.
 	// If name is omitted from the request, a random one based on a UUID is
 	// generated.
@@ -157,6 +192,10 @@ type AzureUploadOptions struct {
 	TenantId string `json:"tenant_id"`
 }
 
+// AzureUploadOptionsHyperVGeneration Choose the VM Image HyperV generation, different features on Azure are available
+// depending on the HyperV generation.
+type AzureUploadOptionsHyperVGeneration string
+
 // AzureUploadStatus defines model for AzureUploadStatus.
 type AzureUploadStatus struct {
 	ImageName string `json:"image_name"`
@@ -436,6 +475,10 @@ type ComposeRequest struct {
 	ImageRequest   *ImageRequest   `json:"image_request,omitempty"`
 	ImageRequests  *[]ImageRequest `json:"image_requests,omitempty"`
 	Koji           *Koji           `json:"koji,omitempty"`
+
+	// Tags Labels attached to the jobs of the compose, workers report them along
+	// with the resources the jobs used.
+	Tags *JobTags `json:"tags,omitempty"`
 }
 
 // ComposeStatus defines model for ComposeStatus.
@@ -573,6 +616,21 @@ type Customizations struct {
 // even when there are one or more mountpoints.
 type CustomizationsPartitioningMode string
 
+// DepsolveRequest defines model for DepsolveRequest.
+type DepsolveRequest struct {
+	Architecture *string       `json:"architecture,omitempty"`
+	Blueprint    Blueprint     `json:"blueprint"`
+	Distribution *string       `json:"distribution,omitempty"`
+	ImageType    *ImageTypes   `json:"image_type,omitempty"`
+	Repositories *[]Repository `json:"repositories,omitempty"`
+}
+
+// DepsolveResponse Blueprint dependency list
+type DepsolveResponse struct {
+	// Packages Package list including NEVRA
+	Packages []PackageMetadataCommon `json:"packages"`
+}
+
 // Directory A custom directory to create in the final artifact.
 type Directory struct {
 	// EnsureParents Ensure that the parent directories exist
@@ -738,6 +796,10 @@ type GCPUploadOptions struct {
 	// 'composer-api-<uuid>' string is used as the image name.
 	ImageName *string `json:"image_name,omitempty"`
 
+	// KmsKeyName Resource name of the Cloud KMS key the imported Compute Engine image is encrypted with.
+	// If not specified, the image is encrypted with a Google-managed key.
+	KmsKeyName *string `json:"kms_key_name,omitempty"`
+
 	// Region The GCP region where the OS image will be imported to and shared from.
 	// The value must be a valid GCP location. See https://cloud.google.com/storage/docs/locations.
 	// If not specified, the multi-region location closest to the source
@@ -837,6 +899,10 @@ type Installer struct {
 	Unattended   *bool     `json:"unattended,omitempty"`
 }
 
+// JobTags Labels attached to the jobs of the compose, workers report them along
+// with the resources the jobs used.
+type JobTags map[string]string
+
 // Kernel defines model for Kernel.
 type Kernel struct {
 	// Append Appends arguments to the bootloader kernel command line
@@ -965,6 +1031,17 @@ type PackageMetadata struct {
 	Version   string  `json:"version"`
 }
 
+// PackageMetadataCommon defines model for PackageMetadataCommon.
+type PackageMetadataCommon struct {
+	Arch      string  `json:"arch"`
+	Epoch     *string `json:"epoch,omitempty"`
+	Name      string  `json:"name"`
+	Release   string  `json:"release"`
+	Signature *string `json:"signature,omitempty"`
+	Type      string  `json:"type"`
+	Version   string  `json:"version"`
+}
+
 // PulpOSTreeUploadOptions defines model for PulpOSTreeUploadOptions.
 type PulpOSTreeUploadOptions struct {
 	// Basepath Basepath for distributing the repository
@@ -1060,11 +1137,22 @@ type UploadOptions struct {
 	union json.RawMessage
 }
 
+// UploadProgress Progress of the upload phase, only reported while the image is being
+// transferred to the target.
+type UploadProgress struct {
+	BytesTotal       *int64 `json:"bytes_total,omitempty"`
+	BytesTransferred int64  `json:"bytes_transferred"`
+}
+
 // UploadStatus defines model for UploadStatus.
 type UploadStatus struct {
 	Options UploadStatus_Options `json:"options"`
-	Status  UploadStatusValue    `json:"status"`
-	Type    UploadTypes          `json:"type"`
+
+	// Progress Progress of the upload phase, only reported while the image is being
+	// transferred to the target.
+	Progress *UploadProgress   `json:"progress,omitempty"`
+	Status   UploadStatusValue `json:"status"`
+	Type     UploadTypes       `json:"type"`
 }
 
 // UploadStatus_Options defines model for UploadStatus.Options.
@@ -1124,6 +1212,9 @@ type PostComposeJSONRequestBody = ComposeRequest
 // PostCloneComposeJSONRequestBody defines body for PostCloneCompose for application/json ContentType.
 type PostCloneComposeJSONRequestBody = CloneComposeBody
 
+// PostDepsolveBlueprintJSONRequestBody defines body for PostDepsolveBlueprint for application/json ContentType.
+type PostDepsolveBlueprintJSONRequestBody = DepsolveRequest
+
 // AsBlueprintFileGroup0 returns the union data inside the BlueprintFile_Group as a BlueprintFileGroup0
 func (t BlueprintFile_Group) AsBlueprintFileGroup0() (BlueprintFileGroup0, error) {
 	var body BlueprintFileGroup0
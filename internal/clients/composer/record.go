@@ -0,0 +1,223 @@
+package composer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/osbuild/image-builder/internal/redact"
+)
+
+// Interaction is a request sent to composer and its response, as stored in a
+// golden file.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Query  string          `json:"query,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	Status      int             `json:"status"`
+	ContentType string          `json:"content_type,omitempty"`
+	Body        json.RawMessage `json:"body,omitempty"`
+}
+
+// recorder passes the requests on to composer and writes every interaction
+// to a golden file named after its operation, the latest interaction of an
+// operation replaces the previous one. Only the method, path, query and body
+// are kept, so credentials sent in headers never reach the files, and the
+// secrets of compose requests and the links of upload statuses are redacted.
+// The operations in unrecordedOperations aren't recorded at all.
+type recorder struct {
+	next http.RoundTripper
+	dir  string
+	mu   sync.Mutex
+}
+
+// unrecordedOperations return the secrets of the composes anywhere in their
+// responses, the manifests hold the stages the customizations end up in and
+// the logs the output of those stages, no rules can redact them.
+var unrecordedOperations = map[string]bool{
+	"GET /composes/{id}/manifests": true,
+	"GET /composes/{id}/logs":      true,
+}
+
+func newRecorder(next http.RoundTripper, dir string) *recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &recorder{next: next, dir: dir}
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	// a failing recording mustn't fail the request
+	err = r.record(req, reqBody, resp, respBody)
+	if err != nil {
+		logrus.Errorf("Unable to record composer interaction %s %s: %v", req.Method, req.URL.Path, err)
+	}
+	return resp, nil
+}
+
+func (r *recorder) record(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte) error {
+	if unrecordedOperations[req.Method+" "+operationPath(req.URL.Path)] {
+		return nil
+	}
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  req.URL.RawQuery,
+		},
+		Response: RecordedResponse{
+			Status:      resp.StatusCode,
+			ContentType: resp.Header.Get("Content-Type"),
+		},
+	}
+	if len(reqBody) > 0 {
+		redacted, err := redact.JSON(reqBody, redact.ComposerRequest)
+		if err != nil {
+			// only JSON is sent to composer, don't risk storing anything else
+			return fmt.Errorf("request body isn't JSON: %w", err)
+		}
+		interaction.Request.Body = redacted
+	}
+	if len(respBody) > 0 {
+		body, err := recordedBody(respBody)
+		if err != nil {
+			return err
+		}
+		interaction.Response.Body = body
+	}
+
+	data, err := json.MarshalIndent(interaction, "", "  ")
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return os.WriteFile(filepath.Join(r.dir, goldenFileName(req.Method, req.URL.Path)), append(data, '\n'), 0600)
+}
+
+// recordedBody keeps JSON readable in the golden files with its secrets
+// redacted, anything else, like the error pages of proxies, is stored as a
+// string.
+func recordedBody(body []byte) (json.RawMessage, error) {
+	if json.Valid(body) {
+		return redact.JSON(body, redact.ComposerResponse)
+	}
+	return json.Marshal(string(body))
+}
+
+// operationPath replaces the ids in the path with {id}, the paths of an
+// operation are the same then.
+func operationPath(path string) string {
+	path = strings.TrimPrefix(path, "/api/image-builder-composer/v2")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, s := range segments {
+		if _, err := uuid.Parse(s); err == nil {
+			segments[i] = "{id}"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// goldenFileName is e.g. GET_composes_id_metadata.json for
+// GET /composes/{id}/metadata.
+func goldenFileName(method, path string) string {
+	op := strings.NewReplacer("/", "_", "{", "", "}", "").Replace(strings.Trim(operationPath(path), "/"))
+	return fmt.Sprintf("%s_%s.json", method, op)
+}
+
+// LoadInteractions reads the golden files recorded into dir.
+func LoadInteractions(dir string) ([]Interaction, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var interactions []Interaction
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Clean(f))
+		if err != nil {
+			return nil, err
+		}
+		var i Interaction
+		err = json.Unmarshal(data, &i)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse golden file %s: %w", f, err)
+		}
+		interactions = append(interactions, i)
+	}
+	return interactions, nil
+}
+
+// NewReplayHandler is a mock composer answering every operation with the
+// response recorded for it, no matter the ids in the path. Operations which
+// weren't recorded are answered with 404.
+func NewReplayHandler(dir string) (http.Handler, error) {
+	interactions, err := LoadInteractions(dir)
+	if err != nil {
+		return nil, err
+	}
+	responses := map[string]RecordedResponse{}
+	for _, i := range interactions {
+		responses[i.Request.Method+" "+operationPath(i.Request.Path)] = i.Response
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, ok := responses[r.Method+" "+operationPath(r.URL.Path)]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if resp.ContentType != "" {
+			w.Header().Set("Content-Type", resp.ContentType)
+		}
+		w.WriteHeader(resp.Status)
+		body := []byte(resp.Body)
+		if !strings.HasPrefix(resp.ContentType, "application/json") {
+			var s string
+			if json.Unmarshal(resp.Body, &s) == nil {
+				body = []byte(s)
+			}
+		}
+		_, _ = w.Write(body)
+	}), nil
+}
@@ -0,0 +1,166 @@
+package composer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/oauth2"
+	"github.com/osbuild/image-builder/internal/redact"
+)
+
+func TestGoldenFileName(t *testing.T) {
+	id := uuid.New()
+	require.Equal(t, "POST_compose.json", goldenFileName(http.MethodPost, "/api/image-builder-composer/v2/compose"))
+	require.Equal(t, "GET_composes_id.json", goldenFileName(http.MethodGet, fmt.Sprintf("/api/image-builder-composer/v2/composes/%s", id)))
+	require.Equal(t, "GET_composes_id_metadata.json", goldenFileName(http.MethodGet, fmt.Sprintf("/api/image-builder-composer/v2/composes/%s/metadata", id)))
+}
+
+func TestRecordReplay(t *testing.T) {
+	id := uuid.New()
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer accesstoken", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/image-builder-composer/v2/compose":
+			var req ComposeRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			// composer gets the secrets, only the recording lacks them
+			require.Equal(t, "secret-key", req.Customizations.Subscription.ActivationKey)
+			w.WriteHeader(http.StatusCreated)
+			_, err := fmt.Fprintf(w, `{"href": "/api/image-builder-composer/v2/compose", "id": "%s", "kind": "ComposeId"}`, id)
+			require.NoError(t, err)
+		case fmt.Sprintf("/api/image-builder-composer/v2/composes/%s", id):
+			_, err := fmt.Fprintf(w, `{"href": "/api/image-builder-composer/v2/composes/%[1]s", "id": "%[1]s", "kind": "ComposeStatus", "status": "success",
+				"image_status": {"status": "success", "upload_status": {"status": "success", "type": "aws.s3", "options": {"url": "https://bucket.example.com/image?X-Amz-Signature=signature"}}}}`, id)
+			require.NoError(t, err)
+		case fmt.Sprintf("/api/image-builder-composer/v2/composes/%s/metadata", id):
+			w.Header().Set("Content-Type", "text/plain")
+			_, err := w.Write([]byte("not json"))
+			require.NoError(t, err)
+		case fmt.Sprintf("/api/image-builder-composer/v2/composes/%s/logs", id):
+			_, err := w.Write([]byte(`{"href": "", "id": "", "kind": "ComposeLogs", "image_builds": ["password: secret"]}`))
+			require.NoError(t, err)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer apiSrv.Close()
+
+	dir := t.TempDir()
+	client, err := NewClient(ComposerClientConfig{
+		URL:       apiSrv.URL,
+		Tokener:   &oauth2.DummyToken{},
+		RecordDir: dir,
+	})
+	require.NoError(t, err)
+
+	resp, err := client.Compose(ComposeRequest{
+		Distribution: "rhel-9.4",
+		Customizations: &Customizations{
+			Subscription: &Subscription{
+				ActivationKey: "secret-key",
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	// the recorder hands the body on
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Contains(t, string(body), id.String())
+
+	for _, request := range []func(uuid.UUID) (*http.Response, error){client.ComposeStatus, client.ComposeMetadata, client.ComposeLogs} {
+		resp, err = request(id)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "POST_compose.json"))
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "secret-key")
+	require.NotContains(t, string(data), "accesstoken")
+	require.Contains(t, string(data), redact.Digest("secret-key"))
+	// the presigned links of the uploads are redacted too
+	data, err = os.ReadFile(filepath.Join(dir, "GET_composes_id.json"))
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "signature")
+	require.Contains(t, string(data), redact.Digest("https://bucket.example.com/image?X-Amz-Signature=signature"))
+	// the logs can't be redacted, they aren't recorded
+	_, err = os.Stat(filepath.Join(dir, "GET_composes_id_logs.json"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	interactions, err := LoadInteractions(dir)
+	require.NoError(t, err)
+	require.Len(t, interactions, 3)
+
+	// a mock composer serves the recordings for any id
+	mockSrv := httptest.NewServer(mustReplayHandler(t, dir))
+	defer mockSrv.Close()
+	mock, err := NewClient(ComposerClientConfig{
+		URL:     mockSrv.URL,
+		Tokener: &oauth2.DummyToken{},
+	})
+	require.NoError(t, err)
+
+	resp, err = mock.Compose(ComposeRequest{Distribution: "rhel-9.4"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	replayed, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.JSONEq(t, string(body), string(replayed))
+
+	resp, err = mock.ComposeMetadata(uuid.New())
+	require.NoError(t, err)
+	replayed, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, "not json", string(replayed))
+	require.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+
+	resp, err = mock.ComposeLogs(uuid.New())
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+// TestRecordedContract checks the interactions recorded from composer still
+// match the API the client was generated from.
+func TestRecordedContract(t *testing.T) {
+	const composerURL = "http://composer.test/api/image-builder-composer/v2"
+	validator, err := newResponseValidator(composerURL)
+	require.NoError(t, err)
+
+	interactions, err := LoadInteractions("testdata/recorded")
+	require.NoError(t, err)
+	require.NotEmpty(t, interactions)
+	for _, i := range interactions {
+		t.Run(i.Request.Method+" "+operationPath(i.Request.Path), func(t *testing.T) {
+			req, err := http.NewRequest(i.Request.Method, "http://composer.test"+i.Request.Path, bytes.NewReader(i.Request.Body))
+			require.NoError(t, err)
+			req.URL.RawQuery = i.Request.Query
+			resp := &http.Response{
+				StatusCode: i.Response.Status,
+				Header:     http.Header{"Content-Type": []string{i.Response.ContentType}},
+				Body:       io.NopCloser(bytes.NewReader(i.Response.Body)),
+			}
+			require.NoError(t, validator.validate(req, resp))
+		})
+	}
+}
+
+func mustReplayHandler(t *testing.T, dir string) http.Handler {
+	h, err := NewReplayHandler(dir)
+	require.NoError(t, err)
+	return h
+}
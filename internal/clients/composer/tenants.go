@@ -0,0 +1,123 @@
+package composer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/osbuild/image-builder/internal/oauth2"
+)
+
+// tenantRegionPrefix marks the region recorded on the composes built by the
+// composer of an org, followed by its org id.
+const tenantRegionPrefix = "tenant-"
+
+// Tenant is the capacity an org brought along, its composes don't take up
+// the shared one.
+type Tenant struct {
+	// Client reaches the composer of the org with its credentials, nil
+	// submits the composes to the shared composer.
+	Client *ComposerClient
+	// WorkerPool tags the jobs of the org for the workers dedicated to it,
+	// empty leaves them to the shared workers.
+	WorkerPool string
+}
+
+// TenantRegion is the region recorded on the composes built by the composer
+// of the org.
+func TenantRegion(orgID string) string {
+	return tenantRegionPrefix + orgID
+}
+
+// TenantOrg returns the org whose composer built the composes of region,
+// false for regions of the shared composers.
+func TenantOrg(region string) (string, bool) {
+	return strings.CutPrefix(region, tenantRegionPrefix)
+}
+
+// tenantEntry is an entry of the COMPOSER_TENANTS_FILE, which is keyed by
+// org id:
+//
+//	{
+//	    "000001":{
+//	        "url":"https://composer.example.com",
+//	        "token_url":"https://sso.example.com/token",
+//	        "client_id":"builder",
+//	        "client_secret":"...",
+//	        "worker_pool":"example"
+//	    },
+//	    "000002":{
+//	        "worker_pool":"dedicated"
+//	    }
+//	}
+//
+// Orgs without a url build on the shared composer. The ones with a url need
+// their own credentials, the token of the shared composer isn't sent to
+// theirs, only the shared CA is used when they don't bring one.
+type tenantEntry struct {
+	URL          string `json:"url"`
+	CA           string `json:"ca_path"`
+	TokenURL     string `json:"token_url"`
+	ClientId     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	WorkerPool   string `json:"worker_pool"`
+}
+
+// LoadTenants loads the composers and worker pools orgs brought along, none
+// without a file. conf is the one of the shared composer, its credentials
+// aren't used for the composers of the orgs.
+func LoadTenants(path string, conf ComposerClientConfig) (map[string]Tenant, error) {
+	tenants := map[string]Tenant{}
+	if path == "" {
+		return tenants, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries map[string]tenantEntry
+	err = json.Unmarshal(data, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	for orgID, entry := range entries {
+		tenant := Tenant{
+			WorkerPool: entry.WorkerPool,
+		}
+		if entry.URL == "" {
+			if entry.ClientId != "" || entry.TokenURL != "" || entry.CA != "" {
+				return nil, fmt.Errorf("the composer of org %s needs a url", orgID)
+			}
+			if entry.WorkerPool == "" {
+				return nil, fmt.Errorf("org %s needs a composer or a worker pool", orgID)
+			}
+			tenants[orgID] = tenant
+			continue
+		}
+
+		if entry.TokenURL == "" || entry.ClientId == "" || entry.ClientSecret == "" {
+			return nil, fmt.Errorf("the composer of org %s needs a token_url, client_id and client_secret", orgID)
+		}
+		c := ComposerClientConfig{
+			URL: entry.URL,
+			CA:  entry.CA,
+			Tokener: &oauth2.LazyToken{
+				Url:          entry.TokenURL,
+				ClientId:     entry.ClientId,
+				ClientSecret: entry.ClientSecret,
+			},
+			StrictResponses: conf.StrictResponses,
+			RecordDir:       conf.RecordDir,
+		}
+		if c.CA == "" {
+			c.CA = conf.CA
+		}
+		tenant.Client, err = NewClient(c)
+		if err != nil {
+			return nil, fmt.Errorf("composer of org %s: %w", orgID, err)
+		}
+		tenants[orgID] = tenant
+	}
+	return tenants, nil
+}
@@ -0,0 +1,51 @@
+package composer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTenants(t *testing.T) {
+	tenants, err := LoadTenants("", ComposerClientConfig{})
+	require.NoError(t, err)
+	require.Empty(t, tenants)
+
+	load := func(content string) (map[string]Tenant, error) {
+		path := filepath.Join(t.TempDir(), "tenants.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return LoadTenants(path, ComposerClientConfig{})
+	}
+	tenants, err = load(`{
+		"000001": {"url": "https://composer.example.com", "token_url": "https://sso.example.com/token", "client_id": "builder", "client_secret": "secret", "worker_pool": "acme"},
+		"000002": {"worker_pool": "dedicated"}
+	}`)
+	require.NoError(t, err)
+	require.Len(t, tenants, 2)
+	require.NotNil(t, tenants["000001"].Client)
+	require.Equal(t, "acme", tenants["000001"].WorkerPool)
+	require.Nil(t, tenants["000002"].Client)
+	require.Equal(t, "dedicated", tenants["000002"].WorkerPool)
+
+	// the shared credentials aren't sent to the composer of an org
+	_, err = load(`{"000001": {"url": "https://composer.example.com"}}`)
+	require.ErrorContains(t, err, "needs a token_url, client_id and client_secret")
+	_, err = load(`{"000001": {"url": "https://composer.example.com", "token_url": "https://sso.example.com/token", "client_id": "builder"}}`)
+	require.ErrorContains(t, err, "needs a token_url, client_id and client_secret")
+	_, err = load(`{"000001": {"client_id": "builder"}}`)
+	require.ErrorContains(t, err, "needs a url")
+	_, err = load(`{"000001": {}}`)
+	require.ErrorContains(t, err, "needs a composer or a worker pool")
+	_, err = load(`[]`)
+	require.Error(t, err)
+}
+
+func TestTenantRegion(t *testing.T) {
+	orgID, ok := TenantOrg(TenantRegion("000001"))
+	require.True(t, ok)
+	require.Equal(t, "000001", orgID)
+	_, ok = TenantOrg("us-east-1")
+	require.False(t, ok)
+}
@@ -0,0 +1,83 @@
+package composer
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	legacyrouter "github.com/getkin/kin-openapi/routers/legacy"
+)
+
+//go:embed openapi.v2.yml
+var openAPISpec []byte
+
+// responseValidator checks the responses of composer against the API the
+// client was generated from, so a drift of the contract fails the request
+// instead of being parsed into whatever fields still match.
+type responseValidator struct {
+	router routers.Router
+}
+
+func newResponseValidator(composerURL string) (*responseValidator, error) {
+	spec, err := openapi3.NewLoader().LoadFromData(openAPISpec)
+	if err != nil {
+		return nil, err
+	}
+	// routes are matched against the composer the client talks to
+	spec.Servers = openapi3.Servers{{URL: composerURL}}
+	router, err := legacyrouter.NewRouter(spec)
+	if err != nil {
+		return nil, err
+	}
+	return &responseValidator{router: router}, nil
+}
+
+// validate returns why the response violates the API, the body is put back
+// for the caller to read. Operations the API lacks aren't checked.
+func (v *responseValidator) validate(req *http.Request, resp *http.Response) error {
+	route, pathParams, err := v.router.FindRoute(req)
+	if err != nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+		},
+		Status: resp.StatusCode,
+		Header: resp.Header,
+		Options: &openapi3filter.Options{
+			IncludeResponseStatus: true,
+		},
+	}
+	input.SetBodyBytes(body)
+	err = openapi3filter.ValidateResponse(req.Context(), input)
+
+	// the schema errors dump the whole schema, where it failed is enough
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		// allOf and the like wrap the error of what failed in them
+		var origin *openapi3.SchemaError
+		for errors.As(schemaErr.Origin, &origin) {
+			schemaErr = origin
+		}
+		return fmt.Errorf("status %d, body at /%s: %s", resp.StatusCode, strings.Join(schemaErr.JSONPointer(), "/"), schemaErr.Reason)
+	}
+	return err
+}
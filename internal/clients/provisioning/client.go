@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 
 	"github.com/redhatinsights/identity"
 )
@@ -50,3 +51,19 @@ func (pc *ProvisioningClient) GetUploadInfo(ctx context.Context, sourceID string
 		"x-rh-identity": id,
 	}, nil)
 }
+
+func (pc *ProvisioningClient) GetInstanceTypes(ctx context.Context, provider string, params GetInstanceTypeListAllParams) (*http.Response, error) {
+	id, ok := identity.GetIdentityHeader(ctx)
+	if !ok {
+		return nil, fmt.Errorf("Unable to get identity from context")
+	}
+
+	query := url.Values{}
+	query.Set("region", params.Region)
+	if params.Zone != nil {
+		query.Set("zone", *params.Zone)
+	}
+	return pc.request("GET", fmt.Sprintf("%s/instance_types/%s?%s", pc.url, url.PathEscape(provider), query.Encode()), map[string]string{
+		"x-rh-identity": id,
+	}, nil)
+}
@@ -290,8 +290,10 @@ type V1SourceUploadInfoResponse struct {
 		SubscriptionId *string   `json:"subscription_id,omitempty"`
 		TenantId       *string   `json:"tenant_id,omitempty"`
 	} `json:"azure"`
-	Gcp      *interface{} `json:"gcp"`
-	Provider *string      `json:"provider,omitempty"`
+	Gcp *struct {
+		ProjectId *string `json:"project_id,omitempty"`
+	} `json:"gcp"`
+	Provider *string `json:"provider,omitempty"`
 }
 
 // Limit defines model for Limit.
@@ -53,7 +53,10 @@ func NewClient(conf RecommendationsClientConfig) (*RecommendationsClient, error)
 }
 
 func createClient(recommendationsURL string, ca string, proxyURL string) (*http.Client, error) {
-	var transport *http.Transport
+	// the clone keeps the dialer of the default transport, which tries the
+	// IPv6 and IPv4 addresses of dual-stack hosts happy eyeballs style
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
 
 	if strings.HasPrefix(recommendationsURL, "https") && ca != "" {
 		caCert, err := os.ReadFile(filepath.Clean(ca))
@@ -69,11 +72,7 @@ func createClient(recommendationsURL string, ca string, proxyURL string) (*http.
 			RootCAs:    caCertPool,
 		}
 
-		transport = &http.Transport{
-			TLSClientConfig: tlsConfig,
-		}
-	} else {
-		transport = &http.Transport{}
+		transport.TLSClientConfig = tlsConfig
 	}
 
 	if proxyURL != "" {
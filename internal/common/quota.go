@@ -47,41 +47,67 @@ type Quota struct {
 // environment variable.
 // If the variable is unset (or an empty string), the check is disabled and always returns true.
 func CheckQuota(ctx context.Context, orgID string, dB db.DB, quotaFile string) (bool, error) {
+	remaining, err := RemainingQuota(ctx, orgID, dB, quotaFile)
+	if err != nil {
+		return false, err
+	}
+	return remaining == nil || *remaining > 0, nil
+}
+
+// Returns the number of requests OrgID can still make during the current sliding window, or nil if
+// the quota check is disabled.
+func RemainingQuota(ctx context.Context, orgID string, dB db.DB, quotaFile string) (*int, error) {
+	quota, err := LoadQuota(orgID, quotaFile)
+	if err != nil || quota == nil {
+		return nil, err
+	}
+	remaining, err := RemainingQuotaOf(ctx, orgID, dB, *quota)
+	if err != nil {
+		return nil, err
+	}
+	return &remaining, nil
+}
+
+// Returns the number of requests OrgID can still make during the current sliding window of an already
+// loaded quota.
+func RemainingQuotaOf(ctx context.Context, orgID string, dB db.DB, quota Quota) (int, error) {
+	// read user created requests
+	count, err := dB.CountComposesSince(ctx, orgID, quota.SlidingWindow)
+	if err != nil {
+		return 0, err
+	}
+	remaining := quota.Quota - count
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, nil
+}
+
+// Returns the quota of OrgID from the quota file, or nil if the quota check is disabled.
+func LoadQuota(orgID string, quotaFile string) (*Quota, error) {
 	if quotaFile == "" {
-		return true, nil
+		return nil, nil
 	}
-	var authorizedRequests int
-	var slidingWindow time.Duration
 
 	// read proper values from quotas' file
 	var quotas map[string]Quota
 	jsonFile, err := os.Open(filepath.Clean(quotaFile))
 	if _, ok := err.(*os.PathError); ok {
-		return false, fmt.Errorf("No config file for quotas found at %s\n", quotaFile)
-	} else {
-		rawJsonFile, err := io.ReadAll(jsonFile)
-		if err != nil {
-			return false, fmt.Errorf("Failed to read quota file %q: %s", quotaFile, err.Error())
-		}
-		err = json.Unmarshal(rawJsonFile, &quotas)
-		if err != nil {
-			return false, fmt.Errorf("Failed to unmarshal quota file %q: %s", quotaFile, err.Error())
-		}
-		if quota, ok := quotas[orgID]; ok {
-			authorizedRequests = quota.Quota
-			slidingWindow = quota.SlidingWindow
-		} else if quota, ok := quotas["default"]; ok {
-			authorizedRequests = quota.Quota
-			slidingWindow = quota.SlidingWindow
-		} else {
-			return false, fmt.Errorf("No default values in the quotas' file %s\n", quotaFile)
-		}
+		return nil, fmt.Errorf("No config file for quotas found at %s\n", quotaFile)
 	}
-
-	// read user created requests
-	count, err := dB.CountComposesSince(ctx, orgID, slidingWindow)
+	defer jsonFile.Close()
+	rawJsonFile, err := io.ReadAll(jsonFile)
 	if err != nil {
-		return false, err
+		return nil, fmt.Errorf("Failed to read quota file %q: %s", quotaFile, err.Error())
+	}
+	err = json.Unmarshal(rawJsonFile, &quotas)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal quota file %q: %s", quotaFile, err.Error())
+	}
+	if quota, ok := quotas[orgID]; ok {
+		return &quota, nil
+	} else if quota, ok := quotas["default"]; ok {
+		return &quota, nil
 	}
-	return count < authorizedRequests, nil
+	return nil, fmt.Errorf("No default values in the quotas' file %s\n", quotaFile)
 }
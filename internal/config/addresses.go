@@ -0,0 +1,126 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// URLs returns the upstream URLs of the configuration by their environment
+// variable, the unset ones included.
+func (c *ImageBuilderConfig) URLs() map[string]string {
+	urls := map[string]string{
+		"COMPOSER_URL":              c.ComposerURL,
+		"COMPOSER_TOKEN_URL":        c.ComposerTokenURL,
+		"PROVISIONING_URL":          c.ProvisioningURL,
+		"CONTENT_SOURCES_URL":       c.ContentSourcesURL,
+		"CONTENT_SOURCES_REPO_URL":  c.ContentSourcesRepoURL,
+		"RECOMMENDATIONS_URL":       c.RecommendURL,
+		"RECOMMENDATIONS_TOKEN_URL": c.RecommendTokenURL,
+		"RECOMMENDATIONS_PROXY":     c.RecommendProxy,
+		"GLITCHTIP_DSN":             c.GlitchTipDSN,
+		"STORAGE_S3_ENDPOINT":       c.StorageS3Endpoint,
+		"TELEMETRY_URL":             c.TelemetryURL,
+	}
+	for _, entry := range strings.Split(c.ComposerRegionalURLs, ",") {
+		region, u, _ := strings.Cut(entry, "=")
+		urls[fmt.Sprintf("COMPOSER_REGIONAL_URLS (%s)", strings.TrimSpace(region))] = strings.TrimSpace(u)
+	}
+	return urls
+}
+
+// PostgresURL is the connection string of the database. PG_HOST can be an
+// IPv6 literal with or without brackets.
+func (c *ImageBuilderConfig) PostgresURL() string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.PGUser, c.PGPassword),
+		Host:     net.JoinHostPort(TrimBrackets(c.PGHost), c.PGPort),
+		Path:     "/" + c.PGDatabase,
+		RawQuery: url.Values{"sslmode": []string{c.PGSSLMode}}.Encode(),
+	}
+	return u.String()
+}
+
+// TrimBrackets turns a bracketed IPv6 literal like [fd00::1] into the
+// address, other hosts are returned as they are.
+func TrimBrackets(host string) string {
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		return host[1 : len(host)-1]
+	}
+	return host
+}
+
+// CheckAddresses validates the listen address, the hosts and the URLs of the
+// configuration. An IPv6 literal missing its brackets in a host:port pair or
+// a URL is reported here, the port would be misread otherwise.
+func CheckAddresses(conf *ImageBuilderConfig) error {
+	var errs []error
+	if conf.ListenAddress != "" {
+		if err := checkHostPort(conf.ListenAddress); err != nil {
+			errs = append(errs, fmt.Errorf("LISTEN_ADDRESS: %w", err))
+		}
+	}
+	if err := checkHost(conf.PGHost); err != nil {
+		errs = append(errs, fmt.Errorf("PG_HOST: %w", err))
+	}
+	if err := checkHost(conf.SplunkHost); err != nil {
+		errs = append(errs, fmt.Errorf("SPLUNK_HEC_HOST: %w", err))
+	}
+
+	urls := conf.URLs()
+	envs := make([]string, 0, len(urls))
+	for env := range urls {
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
+	for _, env := range envs {
+		if u := urls[env]; u != "" {
+			if err := checkURL(u); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", env, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func checkHostPort(hostPort string) error {
+	host, _, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		if strings.Count(hostPort, ":") > 1 && !strings.HasPrefix(hostPort, "[") {
+			return fmt.Errorf("%s: IPv6 addresses need brackets, like [::]:8086", hostPort)
+		}
+		return err
+	}
+	if strings.Contains(host, ":") && net.ParseIP(host) == nil {
+		return fmt.Errorf("%s: %s is not an IPv6 address", hostPort, host)
+	}
+	return nil
+}
+
+func checkHost(host string) error {
+	if !strings.Contains(host, ":") {
+		return nil
+	}
+	if net.ParseIP(TrimBrackets(host)) == nil {
+		return fmt.Errorf("%s is neither a host name nor an IP address, the port is configured separately", host)
+	}
+	return nil
+}
+
+func checkURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if strings.Count(u.Host, ":") > 1 && !strings.HasPrefix(u.Host, "[") {
+		return fmt.Errorf("%s: IPv6 addresses in URLs need brackets, like http://[fd00::1]:8080", rawURL)
+	}
+	if strings.Contains(u.Hostname(), ":") && net.ParseIP(u.Hostname()) == nil {
+		return fmt.Errorf("%s: %s is not an IPv6 address", rawURL, u.Hostname())
+	}
+	return nil
+}
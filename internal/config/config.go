@@ -15,7 +15,13 @@ type ImageBuilderConfig struct {
 	ComposerClientId      string `env:"COMPOSER_CLIENT_ID"`
 	ComposerClientSecret  string `env:"COMPOSER_CLIENT_SECRET"`
 	ComposerCA            string `env:"COMPOSER_CA_PATH"`
+	ComposerRegion        string `env:"COMPOSER_REGION"`
+	ComposerRegionalURLs  string `env:"COMPOSER_REGIONAL_URLS"`
+	ComposerTenantsFile   string `env:"COMPOSER_TENANTS_FILE"`
+	ComposerStrict        bool   `env:"COMPOSER_STRICT_RESPONSES"`
+	ComposerRecordDir     string `env:"COMPOSER_RECORD_DIR"`
 	OsbuildRegion         string `env:"OSBUILD_AWS_REGION"`
+	GovCloudDistros       string `env:"OSBUILD_AWS_GOVCLOUD_DISTROS"`
 	OsbuildGCPRegion      string `env:"OSBUILD_GCP_REGION"`
 	OsbuildGCPBucket      string `env:"OSBUILD_GCP_BUCKET"`
 	DistributionsDir      string `env:"DISTRIBUTIONS_DIR"`
@@ -28,6 +34,9 @@ type ImageBuilderConfig struct {
 	PGUser                string `env:"PGUSER"`
 	PGPassword            string `env:"PGPASSWORD"`
 	PGSSLMode             string `env:"PGSSLMODE"`
+	PGPoolMaxConns        string `env:"PG_POOL_MAX_CONNS"`
+	PGPoolMaxConnIdleTime string `env:"PG_POOL_MAX_CONN_IDLE_TIME"`
+	PGStatementCacheMode  string `env:"PG_STATEMENT_CACHE_MODE"`
 	QuotaFile             string `env:"QUOTA_FILE"`
 	AllowFile             string `env:"ALLOW_FILE"`
 	SplunkHost            string `env:"SPLUNK_HEC_HOST"`
@@ -44,6 +53,47 @@ type ImageBuilderConfig struct {
 	RecommendCA           string `env:"RECOMMENDATIONS_CA_PATH"`
 	GlitchTipDSN          string `env:"GLITCHTIP_DSN"`
 	FedoraAuth            bool   `env:"FEDORA_AUTH"`
+	WatchdogEnabled       bool   `env:"WATCHDOG_ENABLED"`
+	WatchdogInterval      string `env:"WATCHDOG_INTERVAL"`
+	EventsRetention       string `env:"EVENTS_RETENTION"`
+	DraftsRetention       string `env:"DRAFTS_RETENTION"`
+	StorageBackend        string `env:"STORAGE_BACKEND"`
+	StorageLocalDir       string `env:"STORAGE_LOCAL_DIR"`
+	StorageS3Bucket       string `env:"STORAGE_S3_BUCKET"`
+	StorageS3Region       string `env:"STORAGE_S3_REGION"`
+	StorageS3Endpoint     string `env:"STORAGE_S3_ENDPOINT"`
+	StorageS3AccessKeyID  string `env:"STORAGE_S3_ACCESS_KEY_ID"`
+	StorageS3SecretKey    string `env:"STORAGE_S3_SECRET_ACCESS_KEY"`
+	RequestEncryptionKeys string `env:"REQUEST_ENCRYPTION_KEYS"`
+	RedactStoredRequests  bool   `env:"REDACT_STORED_REQUESTS"`
+	RepoCheckEnabled      bool   `env:"REPO_CHECK_ENABLED"`
+	RepoCheckInterval     string `env:"REPO_CHECK_INTERVAL"`
+	ReadOnly              bool   `env:"READ_ONLY"`
+	ReadOnlyFile          string `env:"READ_ONLY_FILE"`
+	MetricsToken          string `env:"METRICS_TOKEN"`
+	LifecycleEnabled      bool   `env:"LIFECYCLE_ENABLED"`
+	LifecycleInterval     string `env:"LIFECYCLE_INTERVAL"`
+	SeparateWorker        bool   `env:"SEPARATE_WORKER"`
+	DeploymentProfile     string `env:"DEPLOYMENT_PROFILE"`
+	EgressAllowedHosts    string `env:"EGRESS_ALLOWED_HOSTS"`
+	RepoMirrors           string `env:"REPO_MIRRORS"`
+	EmulatedArchitectures string `env:"EMULATED_ARCHITECTURES"`
+	ShareLinkKeys         string `env:"SHARE_LINK_KEYS"`
+	TelemetryEnabled      bool   `env:"TELEMETRY_ENABLED"`
+	TelemetryURL          string `env:"TELEMETRY_URL"`
+	TelemetryInterval     string `env:"TELEMETRY_INTERVAL"`
+	ComposeExpiry         string `env:"COMPOSE_EXPIRY"`
+	GCEnabled             bool   `env:"GC_ENABLED"`
+	GCInterval            string `env:"GC_INTERVAL"`
+	HooksInterval         string `env:"HOOKS_INTERVAL"`
+	PipelinesInterval     string `env:"PIPELINES_INTERVAL"`
+	DownloadLinkLifetime  string `env:"DOWNLOAD_LINK_LIFETIME"`
+	DownloadS3Region      string `env:"DOWNLOAD_S3_REGION"`
+	DownloadS3Endpoint    string `env:"DOWNLOAD_S3_ENDPOINT"`
+	DownloadS3AccessKeyID string `env:"DOWNLOAD_S3_ACCESS_KEY_ID"`
+	DownloadS3SecretKey   string `env:"DOWNLOAD_S3_SECRET_ACCESS_KEY"`
+	PriorityLaneLimit     string `env:"PRIORITY_LANE_LIMIT"`
+	DraftLimit            string `env:"DRAFT_LIMIT"`
 }
 
 func (ibc *ImageBuilderConfig) IsDebug() bool {
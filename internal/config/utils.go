@@ -3,8 +3,10 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/joho/godotenv"
@@ -65,11 +67,11 @@ func LoadConfigFromEnv(conf *ImageBuilderConfig) error {
 		}
 
 		if endpoint, ok := clowder.DependencyEndpoints["provisioning-backend"]["api"]; ok {
-			conf.ProvisioningURL = fmt.Sprintf("http://%s:%d/api/provisioning/v1", endpoint.Hostname, endpoint.Port)
+			conf.ProvisioningURL = clowderURL(endpoint.Hostname, endpoint.Port, "/api/provisioning/v1")
 		}
 
 		if endpoint, ok := clowder.DependencyEndpoints["content-sources-backend"]["service"]; ok {
-			conf.ContentSourcesURL = fmt.Sprintf("http://%s:%d/api/content-sources/v1", endpoint.Hostname, endpoint.Port)
+			conf.ContentSourcesURL = clowderURL(endpoint.Hostname, endpoint.Port, "/api/content-sources/v1")
 		}
 
 		if strings.Contains(*clowder.LoadedConfig.Metadata.EnvName, "ephemeral") {
@@ -79,3 +81,9 @@ func LoadConfigFromEnv(conf *ImageBuilderConfig) error {
 
 	return nil
 }
+
+// clowderURL is the URL of a dependency's endpoint, whose host can be an IPv6
+// address.
+func clowderURL(host string, port int, path string) string {
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(port)) + path
+}
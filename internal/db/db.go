@@ -15,7 +15,11 @@ import (
 var ComposeNotFoundError = errors.New("Compose not found")
 var CloneNotFoundError = errors.New("Clone not found")
 var BlueprintNotFoundError = errors.New("blueprint not found")
+var BlueprintVersionConflictError = errors.New("blueprint has a newer version")
 var AffectedRowsMismatchError = errors.New("Unexpected affected rows")
+var ComposeBlobNotFoundError = errors.New("Compose blob not found")
+var ComposeMetadataNotFoundError = errors.New("Compose metadata not found")
+var QuotaExceededError = errors.New("Compose quota exceeded")
 
 type dB struct {
 	Pool *pgxpool.Pool
@@ -27,6 +31,37 @@ type ComposeEntry struct {
 	CreatedAt time.Time
 	ImageName *string
 	ClientId  *string
+	Status    *string
+	ErrorCode *string
+	// Region of the deployment the compose was created by, nil for composes
+	// created before regions were configured.
+	Region             *string
+	BlueprintVersionId *uuid.UUID
+	// ParentComposeId is the failed compose this one retried
+	ParentComposeId *uuid.UUID
+	// GroupId is the compose group of a request built for several
+	// architectures
+	GroupId *uuid.UUID
+	// Description is the free-form annotation of the compose
+	Description *string
+	// ExpiresAt is when the artifacts of the compose get deleted, nil keeps
+	// them
+	ExpiresAt *time.Time
+	// PipelineId is the pipeline chaining an installer to a commit compose,
+	// set on both of them
+	PipelineId *uuid.UUID
+}
+
+// UnfinishedCompose is a compose which has not been recorded in a terminal
+// (success or failure) state yet.
+type UnfinishedCompose struct {
+	Id        uuid.UUID
+	OrgId     string
+	ImageType string
+	CreatedAt time.Time
+	Region    *string
+	// Status is the non-terminal status stored for the compose, if any.
+	Status *string
 }
 
 type ComposeWithBlueprintVersion struct {
@@ -35,11 +70,41 @@ type ComposeWithBlueprintVersion struct {
 	BlueprintVersion *int
 }
 
+// ComposeCursor is the position of a compose in the listing, which is ordered
+// by creation time and id, newest first.
+type ComposeCursor struct {
+	CreatedAt time.Time
+	Id        uuid.UUID
+}
+
+// ComposeBlobEntry references an artifact of a compose kept in object storage.
+type ComposeBlobEntry struct {
+	ComposeId  uuid.UUID
+	Kind       string
+	StorageKey string
+	Size       int64
+	CreatedAt  time.Time
+}
+
 type CloneEntry struct {
 	Id        uuid.UUID
 	ComposeId uuid.UUID
+	// ParentId is the first clone of the request which cloned to several
+	// regions, nil for that clone itself
+	ParentId  *uuid.UUID
 	Request   json.RawMessage
 	CreatedAt time.Time
+	// Status and UploadStatus are only recorded once the clone finished
+	Status       *string
+	UploadStatus json.RawMessage
+}
+
+// CloneStatusCounts counts the clones of a compose, the ones which weren't
+// recorded as finished are unfinished.
+type CloneStatusCounts struct {
+	Success    int
+	Failure    int
+	Unfinished int
 }
 
 type BlueprintEntry struct {
@@ -50,6 +115,14 @@ type BlueprintEntry struct {
 	Name        string
 	Description string
 	Metadata    json.RawMessage
+	// CreatedBy and UpdatedBy are emails, empty for blueprints which predate
+	// recording them
+	CreatedBy string
+	UpdatedBy string
+	// Private blueprints are only visible to their creator
+	Private bool
+	// WorkspaceId is the workspace of the blueprint, nil for the whole org
+	WorkspaceId *uuid.UUID
 }
 
 type BlueprintWithNoBody struct {
@@ -58,49 +131,164 @@ type BlueprintWithNoBody struct {
 	Name           string
 	Description    string
 	LastModifiedAt time.Time
+	CreatedBy      string
+	Private        bool
+	WorkspaceId    *uuid.UUID
 }
 
 type DB interface {
-	InsertCompose(ctx context.Context, jobId uuid.UUID, accountNumber, email, orgId string, imageName *string, request json.RawMessage, clientId *string, blueprintVersionId *uuid.UUID) error
+	Ping(ctx context.Context) error
+	InsertCompose(ctx context.Context, jobId uuid.UUID, accountNumber, email, orgId string, imageName *string, request json.RawMessage, clientId *string, blueprintVersionId *uuid.UUID, region *string, parentComposeId *uuid.UUID) error
+	ReserveCompose(ctx context.Context, reservation *ComposeReservation) error
+	InsertReservedCompose(ctx context.Context, reservation *ComposeReservation, compose ReservedCompose) error
+	ReleaseComposeReservation(ctx context.Context, id uuid.UUID) error
 	GetComposes(ctx context.Context, orgId string, since time.Duration, limit, offset int, ignoreImageTypes []string) ([]ComposeWithBlueprintVersion, int, error)
+	GetComposesAfter(ctx context.Context, orgId string, since time.Duration, limit int, after ComposeCursor, ignoreImageTypes []string) ([]ComposeWithBlueprintVersion, int, error)
+	GetComposesFiltered(ctx context.Context, orgId string, filter ComposeFilter, limit, offset int) ([]ComposeWithBlueprintVersion, int, error)
+	ExplainComposeQueries(ctx context.Context, orgId string) (map[string]QueryPlan, error)
 	GetLatestBlueprintVersionNumber(ctx context.Context, orgId string, blueprintId uuid.UUID) (int, error)
 	GetBlueprintComposes(ctx context.Context, orgId string, blueprintId uuid.UUID, blueprintVersion *int, since time.Duration, limit, offset int, ignoreImageTypes []string) ([]BlueprintCompose, error)
 	GetCompose(ctx context.Context, jobId uuid.UUID, orgId string) (*ComposeEntry, error)
 	GetComposeImageType(ctx context.Context, jobId uuid.UUID, orgId string) (string, error)
 	CountComposesSince(ctx context.Context, orgId string, duration time.Duration) (int, error)
+	CountPriorityLaneComposesSince(ctx context.Context, orgId string, duration time.Duration) (int, error)
 	CountBlueprintComposesSince(ctx context.Context, orgId string, blueprintId uuid.UUID, blueprintVersion *int, since time.Duration, ignoreImageTypes []string) (int, error)
 	DeleteCompose(ctx context.Context, jobId uuid.UUID, orgId string) error
-
-	InsertClone(ctx context.Context, composeId, cloneId uuid.UUID, request json.RawMessage) error
+	GetUnfinishedComposes(ctx context.Context, region *string, olderThan, newerThan time.Duration, after *UnfinishedCompose, limit int) ([]UnfinishedCompose, error)
+	GetOrgUnfinishedComposes(ctx context.Context, orgId string, since time.Duration) ([]UnfinishedCompose, error)
+	SetComposeStatus(ctx context.Context, jobId uuid.UUID, status string, errorCode *string) error
+	GetComposeEvents(ctx context.Context, orgId string, after int64, limit int) ([]ComposeEventEntry, error)
+	GetComposeHistory(ctx context.Context, composeId uuid.UUID, orgId string) ([]ComposeEventEntry, error)
+	DeleteComposeEvents(ctx context.Context, retention time.Duration) (int64, error)
+	ComposeEventLag(ctx context.Context) (*time.Duration, error)
+	GetMonthlyComposeUsage(ctx context.Context, orgId string, from, to time.Time) ([]MonthlyComposeUsage, error)
+	CountComposesByKind(ctx context.Context, since time.Duration) ([]ComposeCount, error)
+
+	InsertComposeGroup(ctx context.Context, groupId uuid.UUID, orgId string) error
+	AddComposeToGroup(ctx context.Context, groupId, composeId uuid.UUID) error
+	GetComposeGroup(ctx context.Context, groupId uuid.UUID, orgId string) ([]ComposeEntry, error)
+	InsertComposePipeline(ctx context.Context, id uuid.UUID, orgId string, commitComposeId uuid.UUID, installerRequest json.RawMessage) error
+	GetComposePipeline(ctx context.Context, id uuid.UUID, orgId string) (*ComposePipelineEntry, error)
+	SetComposePipelineState(ctx context.Context, id uuid.UUID, from, to string, installerComposeId *uuid.UUID, errorMessage *string) error
+	GetWaitingComposePipelines(ctx context.Context, after *WaitingComposePipeline, limit int) ([]WaitingComposePipeline, error)
+	FailStaleComposePipelines(ctx context.Context, state string, olderThan time.Duration, errorMessage string) ([]uuid.UUID, error)
+	SetComposeLabels(ctx context.Context, composeId uuid.UUID, labels map[string]string) error
+	TransferCompose(ctx context.Context, composeId uuid.UUID, orgId string, from *string, to string) error
+	TransferComposes(ctx context.Context, orgId, from, to string) (int64, error)
+	UpdateCompose(ctx context.Context, composeId uuid.UUID, orgId string, update ComposeUpdate) error
+
+	InsertComposeBlob(ctx context.Context, composeId uuid.UUID, kind, storageKey string, size int64) error
+	GetComposeBlob(ctx context.Context, composeId uuid.UUID, orgId, kind string) (*ComposeBlobEntry, error)
+	InsertComposeMetadata(ctx context.Context, composeId uuid.UUID, metadata json.RawMessage) error
+	GetComposeMetadata(ctx context.Context, composeId uuid.UUID, orgId string) (json.RawMessage, error)
+	SetComposeExpiry(ctx context.Context, composeId uuid.UUID, orgId string, expiresAt time.Time) error
+	GetExpiredComposes(ctx context.Context, region *string, limit int) ([]ExpiredCompose, error)
+
+	InsertClone(ctx context.Context, composeId, cloneId uuid.UUID, parentId *uuid.UUID, request json.RawMessage) error
 	GetClonesForCompose(ctx context.Context, composeId uuid.UUID, orgId string, limit, offset int) ([]CloneEntry, int, error)
 	GetClone(ctx context.Context, id uuid.UUID, orgId string) (*CloneEntry, error)
+	SetCloneStatus(ctx context.Context, id uuid.UUID, status string, uploadStatus json.RawMessage) error
+	CountClonesByStatus(ctx context.Context, composeId uuid.UUID, orgId string) (CloneStatusCounts, error)
 
-	InsertBlueprint(ctx context.Context, id uuid.UUID, versionId uuid.UUID, orgID, accountNumber, name, description string, body json.RawMessage, metadata json.RawMessage) error
+	InsertBlueprint(ctx context.Context, id uuid.UUID, versionId uuid.UUID, orgID, accountNumber, name, description string, body json.RawMessage, metadata json.RawMessage, opts BlueprintOptions) error
 	GetBlueprint(ctx context.Context, id uuid.UUID, orgID string, version *int) (*BlueprintEntry, error)
-	UpdateBlueprint(ctx context.Context, id uuid.UUID, blueprintId uuid.UUID, orgId string, name string, description string, body json.RawMessage) error
-	GetBlueprints(ctx context.Context, orgID string, limit, offset int) ([]BlueprintWithNoBody, int, error)
-	FindBlueprints(ctx context.Context, orgID, search string, limit, offset int) ([]BlueprintWithNoBody, int, error)
+	GetBlueprintVersion(ctx context.Context, versionId uuid.UUID, orgID string) (*BlueprintWithNoBody, error)
+	GetBlueprintVersions(ctx context.Context, id uuid.UUID, orgID string) ([]BlueprintWithNoBody, error)
+	UpdateBlueprint(ctx context.Context, id uuid.UUID, blueprintId uuid.UUID, orgId string, name string, description string, body json.RawMessage, updatedBy string) error
+	UpdateBlueprintIfVersion(ctx context.Context, id uuid.UUID, blueprintId uuid.UUID, orgId string, name string, description string, body json.RawMessage, version int, updatedBy string) error
+	SetBlueprintPrivate(ctx context.Context, id uuid.UUID, orgID string, private bool) error
+	GetBlueprints(ctx context.Context, orgID string, filter BlueprintFilter, limit, offset int) ([]BlueprintWithNoBody, int, error)
+	FindBlueprints(ctx context.Context, orgID, search string, filter BlueprintFilter, limit, offset int) ([]BlueprintWithNoBody, int, error)
 	FindBlueprintByName(ctx context.Context, orgID, nameQuery string) (*BlueprintWithNoBody, error)
 	DeleteBlueprint(ctx context.Context, id uuid.UUID, orgID, accountNumber string) error
+	GetBlueprintLifecycles(ctx context.Context) ([]BlueprintLifecycle, error)
+	GetExpiredBlueprintComposes(ctx context.Context, orgId string, blueprintId uuid.UUID, keepLast int) ([]BlueprintCompose, error)
+
+	InsertGitOpsRepository(ctx context.Context, id uuid.UUID, orgId, url, branch, path string, autoBuild bool) error
+	GetGitOpsRepository(ctx context.Context, id uuid.UUID, orgId string) (*GitOpsRepositoryEntry, error)
+	GetGitOpsRepositories(ctx context.Context, orgId string) ([]GitOpsRepositoryEntry, error)
+	DeleteGitOpsRepository(ctx context.Context, id uuid.UUID, orgId string) error
+	SetGitOpsRepositorySynced(ctx context.Context, id uuid.UUID, orgId, commit string) error
+
+	InsertUploadGrant(ctx context.Context, grant UploadGrantEntry) error
+	GetUploadGrant(ctx context.Context, orgId, alias string) (*UploadGrantEntry, error)
+	GetUploadGrants(ctx context.Context, orgId string) ([]UploadGrantEntry, error)
+	DeleteUploadGrant(ctx context.Context, orgId, alias string) error
+
+	InsertComposeTemplate(ctx context.Context, template *ComposeTemplateEntry) error
+	GetComposeTemplate(ctx context.Context, orgId, name string, version *int) (*ComposeTemplateEntry, error)
+	GetComposeTemplates(ctx context.Context, orgId string) ([]ComposeTemplateEntry, error)
+	DeleteComposeTemplate(ctx context.Context, orgId, name string) error
+
+	InsertComposeDraft(ctx context.Context, limit int, draft *ComposeDraftEntry) error
+	GetComposeDraft(ctx context.Context, id uuid.UUID, orgId, email string) (*ComposeDraftEntry, error)
+	GetComposeDrafts(ctx context.Context, orgId, email string) ([]ComposeDraftEntry, error)
+	UpdateComposeDraft(ctx context.Context, draft *ComposeDraftEntry) error
+	DeleteComposeDraft(ctx context.Context, id uuid.UUID, orgId, email string) error
+	DeleteComposeDrafts(ctx context.Context, retention time.Duration) (int64, error)
+
+	InsertDownloadToken(ctx context.Context, token *DownloadTokenEntry, tokenHash string) error
+	GetDownloadTokens(ctx context.Context, orgId string) ([]DownloadTokenEntry, error)
+	UseDownloadToken(ctx context.Context, tokenHash string) (*DownloadTokenEntry, error)
+	DeleteDownloadToken(ctx context.Context, id uuid.UUID, orgId string) error
+
+	GetComposesAwaitingHook(ctx context.Context, hook string, region *string, since time.Duration, limit int) ([]ComposeAwaitingHook, error)
+	SetComposeHookRun(ctx context.Context, run ComposeHookRunEntry) error
+	GetComposeHookRuns(ctx context.Context, composeId uuid.UUID) ([]ComposeHookRunEntry, error)
+
+	GetOrgPolicy(ctx context.Context, orgId string) (*OrgPolicyEntry, error)
+	SetOrgPolicy(ctx context.Context, orgId, updatedBy string, policy json.RawMessage) error
+	SetOrgPolicyIfVersion(ctx context.Context, orgId, updatedBy string, policy json.RawMessage, version int) error
+	DeleteOrgPolicy(ctx context.Context, orgId string) error
+	DeleteOrgPolicyIfVersion(ctx context.Context, orgId string, version int) error
+
+	GetOrgDefaults(ctx context.Context, orgId string) (*OrgDefaultsEntry, error)
+	SetOrgDefaults(ctx context.Context, orgId, updatedBy string, defaults json.RawMessage) error
+	DeleteOrgDefaults(ctx context.Context, orgId string) error
+	SetComposeInjectedDefaults(ctx context.Context, composeId uuid.UUID, orgId string, injected json.RawMessage) error
+	GetComposeInjectedDefaults(ctx context.Context, composeId uuid.UUID, orgId string) (json.RawMessage, error)
+
+	InsertWorkspace(ctx context.Context, workspace *WorkspaceEntry) error
+	GetWorkspace(ctx context.Context, id uuid.UUID, orgId string) (*WorkspaceEntry, error)
+	GetWorkspaces(ctx context.Context, orgId, member string) ([]WorkspaceEntry, error)
+	UpdateWorkspace(ctx context.Context, workspace *WorkspaceEntry) error
+	DeleteWorkspace(ctx context.Context, id uuid.UUID, orgId string) error
+	GetWorkspaceRole(ctx context.Context, id uuid.UUID, orgId, email string) (string, error)
+	CountWorkspaceComposesSince(ctx context.Context, orgId string, id uuid.UUID, since time.Duration) (int, error)
 }
 
 const (
 	sqlInsertCompose = `
-		INSERT INTO composes(job_id, request, created_at, account_number, email, org_id, image_name, client_id, blueprint_version_id)
-		VALUES ($1, $2, CURRENT_TIMESTAMP, $3, $4, $5, $6, $7, $8)`
+		INSERT INTO composes(job_id, request, created_at, account_number, email, org_id, image_name, client_id, blueprint_version_id, region, parent_compose_id)
+		VALUES ($1, $2, CURRENT_TIMESTAMP, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	// serializes the quota checks of an org until the end of the transaction
+	sqlLockOrgComposes = `
+		SELECT pg_advisory_xact_lock(hashtext('composes'), hashtext($1))`
 
 	sqlGetComposes = `
-	    SELECT composes.job_id, composes.request, composes.created_at, composes.image_name, composes.client_id, blueprint_versions.blueprint_id, blueprint_versions.version
+	    SELECT composes.job_id, composes.request, composes.created_at, composes.image_name, composes.client_id, composes.status, composes.error_code, composes.region, composes.description, composes.expires_at, blueprint_versions.blueprint_id, blueprint_versions.version
 	    FROM composes LEFT JOIN blueprint_versions ON composes.blueprint_version_id = blueprint_versions.id
 		WHERE org_id = $1
-		AND CURRENT_TIMESTAMP - composes.created_at <= $2
-		AND ($3::text[] is NULL OR request->'image_requests'->0->>'image_type' <> ALL($3))
+		AND composes.created_at >= CURRENT_TIMESTAMP - $2::interval
+		AND ($3::text[] is NULL OR composes.image_type <> ALL($3))
 		AND deleted = FALSE
-		ORDER BY composes.created_at DESC
+		ORDER BY composes.created_at DESC, composes.job_id DESC
 		LIMIT $4 OFFSET $5`
 
+	sqlGetComposesAfter = `
+	    SELECT composes.job_id, composes.request, composes.created_at, composes.image_name, composes.client_id, composes.status, composes.error_code, composes.region, composes.description, composes.expires_at, blueprint_versions.blueprint_id, blueprint_versions.version
+	    FROM composes LEFT JOIN blueprint_versions ON composes.blueprint_version_id = blueprint_versions.id
+		WHERE org_id = $1
+		AND composes.created_at >= CURRENT_TIMESTAMP - $2::interval
+		AND ($3::text[] is NULL OR composes.image_type <> ALL($3))
+		AND deleted = FALSE
+		AND (composes.created_at, composes.job_id) < ($5, $6)
+		ORDER BY composes.created_at DESC, composes.job_id DESC
+		LIMIT $4`
+
 	sqlGetCompose = `
-		SELECT job_id, request, created_at, image_name, client_id
+		SELECT job_id, request, created_at, image_name, client_id, status, error_code, region, blueprint_version_id, parent_compose_id, group_id, expires_at, pipeline_id
 		FROM composes
 		WHERE org_id=$1 AND job_id=$2 AND deleted=FALSE`
 
@@ -112,32 +300,85 @@ const (
 	sqlCountActiveComposesSince = `
 		SELECT COUNT(*)
 		FROM composes
-		WHERE org_id=$1 AND CURRENT_TIMESTAMP - created_at <= $2 AND deleted = FALSE
-		AND ($3::text[] is NULL OR request->'image_requests'->0->>'image_type' <> ALL($3))`
+		WHERE org_id=$1 AND created_at >= CURRENT_TIMESTAMP - $2::interval AND deleted = FALSE
+		AND ($3::text[] is NULL OR image_type <> ALL($3))`
 
+	// composes of the priority lane don't count against the quota
 	sqlCountComposesSince = `
 		SELECT COUNT(*)
 		FROM composes
-		WHERE org_id=$1 AND CURRENT_TIMESTAMP - created_at <= $2`
+		WHERE org_id=$1 AND created_at >= CURRENT_TIMESTAMP - $2::interval
+		AND NOT priority_lane`
+
+	sqlCountPriorityLaneComposesSince = `
+		SELECT COUNT(*)
+		FROM composes
+		WHERE org_id=$1 AND created_at >= CURRENT_TIMESTAMP - $2::interval
+		AND priority_lane`
+
+	sqlSetComposePriorityLane = `
+		UPDATE composes
+		SET priority_lane = TRUE
+		WHERE job_id = $1`
 
 	sqlDeleteCompose = `
 		UPDATE composes
-		SET deleted = TRUE
-		WHERE org_id=$1 AND job_id=$2
+		SET deleted = TRUE, deleted_at = CURRENT_TIMESTAMP
+		WHERE org_id=$1 AND job_id=$2 AND deleted = FALSE
         `
 
+	sqlGetUnfinishedComposes = `
+		SELECT job_id, org_id, request->'image_requests'->0->>'image_type', created_at, region, status
+		FROM composes
+		WHERE deleted = FALSE
+		AND (status IS NULL OR status NOT IN ('success', 'failure'))
+		AND CURRENT_TIMESTAMP - created_at >= $1
+		AND CURRENT_TIMESTAMP - created_at <= $2
+		AND region IS NOT DISTINCT FROM $4
+		AND ($5::timestamp IS NULL OR (created_at, job_id) > ($5, $6))
+		ORDER BY created_at ASC, job_id ASC
+		LIMIT $3`
+
+	sqlGetOrgUnfinishedComposes = `
+		SELECT job_id, org_id, request->'image_requests'->0->>'image_type', created_at, region, status
+		FROM composes
+		WHERE org_id = $1 AND deleted = FALSE
+		AND (status IS NULL OR status NOT IN ('success', 'failure'))
+		AND CURRENT_TIMESTAMP - created_at <= $2
+		ORDER BY created_at DESC`
+
+	sqlSetComposeStatus = `
+		UPDATE composes
+		SET status = $2, error_code = $3
+		FROM (SELECT job_id, status, error_code FROM composes WHERE job_id = $1 FOR UPDATE) AS old
+		WHERE composes.job_id = old.job_id
+		RETURNING old.status IS DISTINCT FROM $2 OR old.error_code IS DISTINCT FROM $3`
+
+	sqlInsertComposeBlob = `
+		INSERT INTO compose_blobs(compose_id, kind, storage_key, size)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (compose_id, kind) DO UPDATE SET storage_key = $3, size = $4, created_at = CURRENT_TIMESTAMP`
+
+	sqlGetComposeBlob = `
+		SELECT compose_blobs.compose_id, compose_blobs.kind, compose_blobs.storage_key, compose_blobs.size, compose_blobs.created_at
+		FROM compose_blobs INNER JOIN composes ON compose_blobs.compose_id = composes.job_id
+		WHERE compose_blobs.compose_id = $1 AND composes.org_id = $2 AND compose_blobs.kind = $3`
+
 	sqlInsertClone = `
-		INSERT INTO clones(id, compose_id, request, created_at)
-		VALUES($1, $2, $3, CURRENT_TIMESTAMP)`
+		INSERT INTO clones(id, compose_id, parent_id, request, created_at)
+		VALUES($1, $2, $3, $4, CURRENT_TIMESTAMP)`
 
+	// the children follow their parent clone
 	sqlGetClonesForCompose = `
-		SELECT clones.id, clones.compose_id, clones.request, clones.created_at
+		SELECT clones.id, clones.compose_id, clones.parent_id, clones.request, clones.created_at, clones.status, clones.upload_status
 		FROM clones
+		LEFT JOIN clones parents ON parents.id = clones.parent_id
 		WHERE clones.compose_id=$1 AND $1 in (
 			SELECT composes.job_id
 			FROM composes
 			WHERE composes.org_id=$2)
-		ORDER BY created_at DESC
+		ORDER BY COALESCE(parents.created_at, clones.created_at) DESC, COALESCE(clones.parent_id, clones.id),
+			clones.parent_id IS NOT NULL, clones.created_at
 		LIMIT $3 OFFSET $4`
 
 	sqlCountClonesForCompose = `
@@ -148,8 +389,23 @@ const (
 			FROM composes
 			WHERE composes.org_id=$2)`
 
+	sqlCountClonesByStatus = `
+		SELECT COUNT(*) FILTER (WHERE clones.status = 'success'),
+			COUNT(*) FILTER (WHERE clones.status = 'failure'),
+			COUNT(*) FILTER (WHERE clones.status IS NULL)
+		FROM clones
+		WHERE clones.compose_id=$1 AND $1 in (
+			SELECT composes.job_id
+			FROM composes
+			WHERE composes.org_id=$2)`
+
+	sqlSetCloneStatus = `
+		UPDATE clones
+		SET status = $2, upload_status = $3
+		WHERE id = $1`
+
 	sqlGetClone = `
-		SELECT clones.id, clones.compose_id, clones.request, clones.created_at
+		SELECT clones.id, clones.compose_id, clones.parent_id, clones.request, clones.created_at, clones.status, clones.upload_status
 		FROM clones
 		WHERE clones.id=$1 AND clones.compose_id in (
 			SELECT composes.job_id
@@ -157,13 +413,14 @@ const (
 			WHERE composes.org_id=$2)`
 )
 
-func InitDBConnectionPool(connStr string) (DB, error) {
+func InitDBConnectionPool(connStr string, poolConf PoolConfig) (DB, error) {
 	dbConfig, err := pgxpool.ParseConfig(connStr)
 	if err != nil {
 		return nil, err
 	}
 
 	dbConfig.ConnConfig.Tracer = &dbTracer{}
+	poolConf.apply(dbConfig)
 
 	pool, err := pgxpool.NewWithConfig(context.Background(), dbConfig)
 	if err != nil {
@@ -173,15 +430,23 @@ func InitDBConnectionPool(connStr string) (DB, error) {
 	return &dB{pool}, nil
 }
 
-func (db *dB) InsertCompose(ctx context.Context, jobId uuid.UUID, accountNumber, email, orgId string, imageName *string, request json.RawMessage, clientId *string, blueprintVersionId *uuid.UUID) error {
-	conn, err := db.Pool.Acquire(ctx)
+// Ping checks a connection of the pool can reach the database.
+func (db *dB) Ping(ctx context.Context) error {
+	return db.Pool.Ping(ctx)
+}
+
+func (db *dB) InsertCompose(ctx context.Context, jobId uuid.UUID, accountNumber, email, orgId string, imageName *string, request json.RawMessage, clientId *string, blueprintVersionId *uuid.UUID, region *string, parentComposeId *uuid.UUID) error {
+	return db.withTransaction(ctx, func(tx pgx.Tx) error {
+		return insertCompose(ctx, tx, jobId, accountNumber, email, orgId, imageName, request, clientId, blueprintVersionId, region, parentComposeId)
+	})
+}
+
+func insertCompose(ctx context.Context, tx pgx.Tx, jobId uuid.UUID, accountNumber, email, orgId string, imageName *string, request json.RawMessage, clientId *string, blueprintVersionId *uuid.UUID, region *string, parentComposeId *uuid.UUID) error {
+	_, err := tx.Exec(ctx, sqlInsertCompose, jobId, request, accountNumber, email, orgId, imageName, clientId, blueprintVersionId, region, parentComposeId)
 	if err != nil {
 		return err
 	}
-	defer conn.Release()
-
-	_, err = conn.Exec(ctx, sqlInsertCompose, jobId, request, accountNumber, email, orgId, imageName, clientId, blueprintVersionId)
-	return err
+	return insertComposeEvent(ctx, tx, jobId, ComposeEventCreated)
 }
 
 func (db *dB) GetCompose(ctx context.Context, jobId uuid.UUID, orgId string) (*ComposeEntry, error) {
@@ -194,7 +459,7 @@ func (db *dB) GetCompose(ctx context.Context, jobId uuid.UUID, orgId string) (*C
 	result := conn.QueryRow(ctx, sqlGetCompose, orgId, jobId)
 
 	var compose ComposeEntry
-	err = result.Scan(&compose.Id, &compose.Request, &compose.CreatedAt, &compose.ImageName, &compose.ClientId)
+	err = result.Scan(&compose.Id, &compose.Request, &compose.CreatedAt, &compose.ImageName, &compose.ClientId, &compose.Status, &compose.ErrorCode, &compose.Region, &compose.BlueprintVersionId, &compose.ParentComposeId, &compose.GroupId, &compose.ExpiresAt, &compose.PipelineId)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ComposeNotFoundError
@@ -229,16 +494,43 @@ func (db *dB) GetComposeImageType(ctx context.Context, jobId uuid.UUID, orgId st
 }
 
 func (db *dB) GetComposes(ctx context.Context, orgId string, since time.Duration, limit, offset int, ignoreImageTypes []string) ([]ComposeWithBlueprintVersion, int, error) {
+	return db.listComposes(ctx, orgId, since, ignoreImageTypes, sqlGetComposes, limit, offset)
+}
+
+// GetComposesAfter lists the composes which come after the cursor, composes
+// created in the meantime don't shift the pages like they do with offsets.
+func (db *dB) GetComposesAfter(ctx context.Context, orgId string, since time.Duration, limit int, after ComposeCursor, ignoreImageTypes []string) ([]ComposeWithBlueprintVersion, int, error) {
+	return db.listComposes(ctx, orgId, since, ignoreImageTypes, sqlGetComposesAfter, limit, after.CreatedAt, after.Id)
+}
+
+func (db *dB) listComposes(ctx context.Context, orgId string, since time.Duration, ignoreImageTypes []string, query string, page ...interface{}) ([]ComposeWithBlueprintVersion, int, error) {
 	conn, err := db.Pool.Acquire(ctx)
 	if err != nil {
 		return nil, 0, err
 	}
 	defer conn.Release()
-	result, err := conn.Query(ctx, sqlGetComposes, orgId, since, ignoreImageTypes, limit, offset)
+	result, err := conn.Query(ctx, query, append([]interface{}{orgId, since, ignoreImageTypes}, page...)...)
 	if err != nil {
 		return nil, 0, err
 	}
 
+	composes, err := scanComposes(result)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var count int
+	err = conn.QueryRow(ctx, sqlCountActiveComposesSince, orgId, since, ignoreImageTypes).Scan(&count)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return composes, count, nil
+}
+
+// scanComposes reads the rows of the compose listings, they select the
+// columns of ComposeWithBlueprintVersion in order.
+func scanComposes(result pgx.Rows) ([]ComposeWithBlueprintVersion, error) {
 	defer result.Close()
 
 	var composes []ComposeWithBlueprintVersion
@@ -248,38 +540,56 @@ func (db *dB) GetComposes(ctx context.Context, orgId string, since time.Duration
 		var createdAt time.Time
 		var imageName *string
 		var clientId *string
+		var status *string
+		var errorCode *string
+		var region *string
+		var description *string
+		var expiresAt *time.Time
 		var blueprintId *uuid.UUID
 		var blueprintVersion *int
-		err = result.Scan(&jobId, &request, &createdAt, &imageName, &clientId, &blueprintId, &blueprintVersion)
+		err := result.Scan(&jobId, &request, &createdAt, &imageName, &clientId, &status, &errorCode, &region, &description, &expiresAt, &blueprintId, &blueprintVersion)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 		composes = append(composes, ComposeWithBlueprintVersion{
 			&ComposeEntry{
-				jobId,
-				request,
-				createdAt,
-				imageName,
-				clientId,
+				Id:          jobId,
+				Request:     request,
+				CreatedAt:   createdAt,
+				ImageName:   imageName,
+				ClientId:    clientId,
+				Status:      status,
+				ErrorCode:   errorCode,
+				Region:      region,
+				Description: description,
+				ExpiresAt:   expiresAt,
 			},
 			blueprintId,
 			blueprintVersion,
 		})
 	}
-	if err = result.Err(); err != nil {
-		return nil, 0, err
+	return composes, result.Err()
+}
+
+func (db *dB) CountComposesSince(ctx context.Context, orgId string, duration time.Duration) (int, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return 0, err
 	}
+	defer conn.Release()
 
 	var count int
-	err = conn.QueryRow(ctx, sqlCountActiveComposesSince, orgId, since, ignoreImageTypes).Scan(&count)
+	err = conn.QueryRow(ctx,
+		sqlCountComposesSince,
+		orgId, duration).Scan(&count)
 	if err != nil {
-		return nil, 0, err
+		return 0, err
 	}
 
-	return composes, count, nil
+	return count, nil
 }
 
-func (db *dB) CountComposesSince(ctx context.Context, orgId string, duration time.Duration) (int, error) {
+func (db *dB) CountPriorityLaneComposesSince(ctx context.Context, orgId string, duration time.Duration) (int, error) {
 	conn, err := db.Pool.Acquire(ctx)
 	if err != nil {
 		return 0, err
@@ -287,13 +597,10 @@ func (db *dB) CountComposesSince(ctx context.Context, orgId string, duration tim
 	defer conn.Release()
 
 	var count int
-	err = conn.QueryRow(ctx,
-		sqlCountComposesSince,
-		orgId, duration).Scan(&count)
+	err = conn.QueryRow(ctx, sqlCountPriorityLaneComposesSince, orgId, duration).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
-
 	return count, nil
 }
 
@@ -312,14 +619,123 @@ func (db *dB) DeleteCompose(ctx context.Context, jobId uuid.UUID, orgId string)
 	return err
 }
 
-func (db *dB) InsertClone(ctx context.Context, composeId, cloneId uuid.UUID, request json.RawMessage) error {
+// GetUnfinishedComposes only returns the composes created in the region, nil
+// selects composes created before regions were configured. They are ordered
+// by age, after continues with the ones following it.
+func (db *dB) GetUnfinishedComposes(ctx context.Context, region *string, olderThan, newerThan time.Duration, after *UnfinishedCompose, limit int) ([]UnfinishedCompose, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	var afterCreatedAt *time.Time
+	var afterId *uuid.UUID
+	if after != nil {
+		afterCreatedAt = &after.CreatedAt
+		afterId = &after.Id
+	}
+	rows, err := conn.Query(ctx, sqlGetUnfinishedComposes, olderThan, newerThan, limit, region, afterCreatedAt, afterId)
+	if err != nil {
+		return nil, err
+	}
+	return scanUnfinishedComposes(rows)
+}
+
+// GetOrgUnfinishedComposes returns all unfinished composes of the org created
+// in the last since, the newest first.
+func (db *dB) GetOrgUnfinishedComposes(ctx context.Context, orgId string, since time.Duration) ([]UnfinishedCompose, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, sqlGetOrgUnfinishedComposes, orgId, since)
+	if err != nil {
+		return nil, err
+	}
+	return scanUnfinishedComposes(rows)
+}
+
+func scanUnfinishedComposes(rows pgx.Rows) ([]UnfinishedCompose, error) {
+	defer rows.Close()
+
+	var composes []UnfinishedCompose
+	var err error
+	for rows.Next() {
+		var compose UnfinishedCompose
+		var imageType *string
+		err = rows.Scan(&compose.Id, &compose.OrgId, &imageType, &compose.CreatedAt, &compose.Region, &compose.Status)
+		if err != nil {
+			return nil, err
+		}
+		if imageType != nil {
+			compose.ImageType = *imageType
+		}
+		composes = append(composes, compose)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return composes, nil
+}
+
+// SetComposeStatus records a status change event if the status or error code
+// differ from the stored ones.
+func (db *dB) SetComposeStatus(ctx context.Context, jobId uuid.UUID, status string, errorCode *string) error {
+	return db.withTransaction(ctx, func(tx pgx.Tx) error {
+		var changed bool
+		txErr := tx.QueryRow(ctx, sqlSetComposeStatus, jobId, status, errorCode).Scan(&changed)
+		if errors.Is(txErr, pgx.ErrNoRows) {
+			return ComposeNotFoundError
+		}
+		if txErr != nil || !changed {
+			return txErr
+		}
+		return insertComposeEvent(ctx, tx, jobId, ComposeEventStatusChanged)
+	})
+}
+
+func (db *dB) InsertComposeBlob(ctx context.Context, composeId uuid.UUID, kind, storageKey string, size int64) error {
 	conn, err := db.Pool.Acquire(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
 
-	_, err = conn.Exec(ctx, sqlInsertClone, cloneId, composeId, request)
+	_, err = conn.Exec(ctx, sqlInsertComposeBlob, composeId, kind, storageKey, size)
+	return err
+}
+
+func (db *dB) GetComposeBlob(ctx context.Context, composeId uuid.UUID, orgId, kind string) (*ComposeBlobEntry, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	var blob ComposeBlobEntry
+	err = conn.QueryRow(ctx, sqlGetComposeBlob, composeId, orgId, kind).Scan(&blob.ComposeId, &blob.Kind, &blob.StorageKey, &blob.Size, &blob.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ComposeBlobNotFoundError
+		}
+		return nil, err
+	}
+
+	return &blob, nil
+}
+
+func (db *dB) InsertClone(ctx context.Context, composeId, cloneId uuid.UUID, parentId *uuid.UUID, request json.RawMessage) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, sqlInsertClone, cloneId, composeId, parentId, request)
 	return err
 }
 
@@ -340,17 +756,23 @@ func (db *dB) GetClonesForCompose(ctx context.Context, composeId uuid.UUID, orgI
 	for rows.Next() {
 		var id uuid.UUID
 		var composeID uuid.UUID
+		var parentID *uuid.UUID
 		var request json.RawMessage
 		var createdAt time.Time
-		err = rows.Scan(&id, &composeID, &request, &createdAt)
+		var status *string
+		var uploadStatus json.RawMessage
+		err = rows.Scan(&id, &composeID, &parentID, &request, &createdAt, &status, &uploadStatus)
 		if err != nil {
 			return nil, 0, err
 		}
 		clones = append(clones, CloneEntry{
 			id,
 			composeID,
+			parentID,
 			request,
 			createdAt,
+			status,
+			uploadStatus,
 		})
 	}
 	if err = rows.Err(); err != nil {
@@ -375,7 +797,7 @@ func (db *dB) GetClone(ctx context.Context, id uuid.UUID, orgId string) (*CloneE
 	defer conn.Release()
 
 	var clone CloneEntry
-	err = conn.QueryRow(ctx, sqlGetClone, id, orgId).Scan(&clone.Id, &clone.ComposeId, &clone.Request, &clone.CreatedAt)
+	err = conn.QueryRow(ctx, sqlGetClone, id, orgId).Scan(&clone.Id, &clone.ComposeId, &clone.ParentId, &clone.Request, &clone.CreatedAt, &clone.Status, &clone.UploadStatus)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, CloneNotFoundError
@@ -386,3 +808,37 @@ func (db *dB) GetClone(ctx context.Context, id uuid.UUID, orgId string) (*CloneE
 
 	return &clone, nil
 }
+
+// SetCloneStatus records the final status of the clone, along with its upload
+// status.
+func (db *dB) SetCloneStatus(ctx context.Context, id uuid.UUID, status string, uploadStatus json.RawMessage) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, sqlSetCloneStatus, id, status, uploadStatus)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return CloneNotFoundError
+	}
+	return nil
+}
+
+func (db *dB) CountClonesByStatus(ctx context.Context, composeId uuid.UUID, orgId string) (CloneStatusCounts, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return CloneStatusCounts{}, err
+	}
+	defer conn.Release()
+
+	var counts CloneStatusCounts
+	err = conn.QueryRow(ctx, sqlCountClonesByStatus, composeId, orgId).Scan(&counts.Success, &counts.Failure, &counts.Unfinished)
+	if err != nil {
+		return CloneStatusCounts{}, err
+	}
+	return counts, nil
+}
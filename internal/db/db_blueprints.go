@@ -17,10 +17,51 @@ type BlueprintCompose struct {
 	BlueprintVersion int
 }
 
+// BlueprintLifecycle is the lifecycle policy of the latest version of a
+// blueprint.
+type BlueprintLifecycle struct {
+	OrgId       string
+	BlueprintId uuid.UUID
+	KeepLast    int
+}
+
+// BlueprintFilter narrows down the blueprints listed to the ones visible to
+// the viewer: the blueprints shared with the org and their own private ones,
+// of the workspaces they are a member of.
+type BlueprintFilter struct {
+	// Viewer is the email of the user listing the blueprints
+	Viewer string
+	// Owner is BlueprintsMine, BlueprintsShared or empty for both
+	Owner string
+	// Workspace only lists the blueprints of a workspace
+	Workspace *uuid.UUID
+	// AllWorkspaces lists the blueprints of the workspaces the viewer isn't
+	// a member of too, for org admins
+	AllWorkspaces bool
+}
+
+// BlueprintOptions are who a blueprint is created by and who sees it, the
+// zero value is a blueprint of the whole org without a creator.
+type BlueprintOptions struct {
+	// CreatedBy is the email of the creator
+	CreatedBy string
+	// Private blueprints are only visible to their creator, they need one
+	Private bool
+	// WorkspaceId is the workspace the blueprint belongs to, nil for the org
+	WorkspaceId *uuid.UUID
+}
+
+const (
+	// BlueprintsMine are the blueprints created by the viewer
+	BlueprintsMine = "mine"
+	// BlueprintsShared are the blueprints others shared with the org
+	BlueprintsShared = "shared"
+)
+
 const (
 	sqlInsertBlueprint = `
-		INSERT INTO blueprints(id, org_id, account_number, name, description, metadata)
-		VALUES($1, $2, $3, $4, $5, $6)`
+		INSERT INTO blueprints(id, org_id, account_number, name, description, metadata, created_by, updated_by, private, workspace_id)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $7, $8, $9)`
 
 	sqlInsertVersion = `
 		INSERT INTO blueprint_versions(id, blueprint_id, version, body)
@@ -57,16 +98,63 @@ const (
 		    AND blueprints.deleted = FALSE
 		AND ($5::text[] is NULL OR composes.request->'image_requests'->0->>'image_type' <> ALL($5))`
 
+	sqlGetBlueprintLifecycles = `
+		SELECT org_id, id, keep_last::integer
+		FROM (
+			SELECT DISTINCT ON (blueprints.id) blueprints.org_id, blueprints.id, blueprint_versions.body->'lifecycle'->>'keep_last' AS keep_last
+			FROM blueprints INNER JOIN blueprint_versions ON blueprint_versions.blueprint_id = blueprints.id
+			WHERE blueprints.deleted = FALSE
+			ORDER BY blueprints.id, blueprint_versions.version DESC
+		) AS latest
+		WHERE keep_last IS NOT NULL`
+
+	// the newest successful composes of each image type are kept
+	sqlGetExpiredBlueprintComposes = `
+		SELECT version, job_id, request, created_at, image_name, client_id, status
+		FROM (
+			SELECT blueprint_versions.version, composes.job_id, composes.request, composes.created_at, composes.image_name, composes.client_id, composes.status,
+				ROW_NUMBER() OVER (
+					PARTITION BY composes.request->'image_requests'->0->>'image_type'
+					ORDER BY composes.created_at DESC, composes.job_id DESC
+				) AS position
+			FROM composes INNER JOIN blueprint_versions ON composes.blueprint_version_id = blueprint_versions.id
+			INNER JOIN blueprints ON blueprint_versions.blueprint_id = blueprints.id
+			WHERE composes.org_id = $1 AND blueprints.org_id = $1
+				AND blueprint_versions.blueprint_id = $2
+				AND composes.status = 'success'
+				AND composes.deleted = FALSE
+				AND blueprints.deleted = FALSE
+		) AS successful
+		WHERE position > $3
+		ORDER BY created_at DESC, job_id DESC`
+
 	sqlGetBlueprint = `
-		SELECT blueprints.id, blueprint_versions.id, blueprints.name, blueprints.description, blueprint_versions.version, blueprint_versions.body, blueprints.metadata
+		SELECT blueprints.id, blueprint_versions.id, blueprints.name, blueprints.description, blueprint_versions.version, blueprint_versions.body, blueprints.metadata,
+			COALESCE(blueprints.created_by, ''), COALESCE(blueprints.updated_by, ''), blueprints.private, blueprints.workspace_id
 		FROM blueprints INNER JOIN blueprint_versions ON blueprint_versions.blueprint_id = blueprints.id
 		WHERE blueprints.deleted = FALSE AND blueprints.id = $1 AND blueprints.org_id = $2
 			AND ($3::int is NULL OR blueprint_versions.version = $3)
 		ORDER BY blueprint_versions.created_at DESC LIMIT 1`
 
+	sqlGetBlueprintVersion = `
+		SELECT blueprints.id, blueprints.name, blueprints.description, blueprint_versions.version, blueprint_versions.created_at
+		FROM blueprints INNER JOIN blueprint_versions ON blueprint_versions.blueprint_id = blueprints.id
+		WHERE blueprints.deleted = FALSE AND blueprint_versions.id = $1 AND blueprints.org_id = $2`
+
+	sqlGetBlueprintVersions = `
+		SELECT blueprints.id, blueprints.name, blueprints.description, blueprint_versions.version, blueprint_versions.created_at
+		FROM blueprints INNER JOIN blueprint_versions ON blueprint_versions.blueprint_id = blueprints.id
+		WHERE blueprints.deleted = FALSE AND blueprints.id = $1 AND blueprints.org_id = $2
+		ORDER BY blueprint_versions.version DESC`
+
 	sqlUpdateBlueprint = `
 		UPDATE blueprints
-		SET name = $3, description = $4
+		SET name = $3, description = $4, updated_by = $5
+		WHERE deleted = FALSE AND id = $1 AND org_id = $2`
+
+	sqlSetBlueprintPrivate = `
+		UPDATE blueprints
+		SET private = $3
 		WHERE deleted = FALSE AND id = $1 AND org_id = $2`
 
 	sqlUpdateBlueprintVersion = `
@@ -86,35 +174,52 @@ const (
 			WHERE deleted = FALSE
 			AND id = $2
 			AND org_id = $4
-        );`
+        )
+		HAVING $5::int IS NULL OR MAX(version) = $5;`
 
 	sqlDeleteBlueprintComposes = `
 		UPDATE composes
-		SET deleted = TRUE
+		SET deleted = TRUE, deleted_at = CURRENT_TIMESTAMP
 		FROM blueprint_versions
 		WHERE composes.blueprint_version_id = blueprint_versions.id
-		AND composes.org_id=$1 AND blueprint_versions.blueprint_id=$2`
+		AND composes.org_id=$1 AND blueprint_versions.blueprint_id=$2
+		AND composes.deleted = FALSE`
 
 	sqlDeleteBlueprint = `UPDATE blueprints SET deleted = TRUE, name = id WHERE deleted = FALSE AND id = $1 AND org_id = $2 AND account_number = $3`
 
 	sqlGetBlueprints = `
-		SELECT blueprints.id, blueprints.name, blueprints.description, MAX(blueprint_versions.version) as version, MAX(blueprint_versions.created_at) as last_modified_at
+		SELECT blueprints.id, blueprints.name, blueprints.description, MAX(blueprint_versions.version) as version, MAX(blueprint_versions.created_at) as last_modified_at,
+			COALESCE(blueprints.created_by, ''), blueprints.private, blueprints.workspace_id
 		FROM blueprints INNER JOIN blueprint_versions ON blueprint_versions.blueprint_id = blueprints.id
 		WHERE blueprints.deleted = FALSE AND blueprints.org_id = $1
+			AND (blueprints.private = FALSE OR blueprints.created_by = $4)
+			AND ($5::text = '' OR ($5 = 'mine' AND blueprints.created_by = $4)
+				OR ($5 = 'shared' AND blueprints.private = FALSE AND blueprints.created_by IS DISTINCT FROM $4))
+			AND ($6::uuid IS NULL OR blueprints.workspace_id = $6)
+			AND (blueprints.workspace_id IS NULL OR $7::boolean
+				OR blueprints.workspace_id IN (SELECT workspace_id FROM workspace_members WHERE email = $4))
 		GROUP BY blueprints.id
 		ORDER BY last_modified_at DESC
 		LIMIT $2 OFFSET $3`
 
 	sqlFindBlueprints = `
-		SELECT blueprints.id, blueprints.name, blueprints.description, MAX(blueprint_versions.version) as version, MAX(blueprint_versions.created_at) as last_modified_at
+		SELECT blueprints.id, blueprints.name, blueprints.description, MAX(blueprint_versions.version) as version, MAX(blueprint_versions.created_at) as last_modified_at,
+			COALESCE(blueprints.created_by, ''), blueprints.private, blueprints.workspace_id
 		FROM blueprints INNER JOIN blueprint_versions ON blueprint_versions.blueprint_id = blueprints.id
 		WHERE blueprints.deleted = FALSE AND blueprints.org_id = $1 AND ($4::text = '%%' OR blueprints.name ILIKE $4 OR blueprints.description ILIKE $4)
+			AND (blueprints.private = FALSE OR blueprints.created_by = $5)
+			AND ($6::text = '' OR ($6 = 'mine' AND blueprints.created_by = $5)
+				OR ($6 = 'shared' AND blueprints.private = FALSE AND blueprints.created_by IS DISTINCT FROM $5))
+			AND ($7::uuid IS NULL OR blueprints.workspace_id = $7)
+			AND (blueprints.workspace_id IS NULL OR $8::boolean
+				OR blueprints.workspace_id IN (SELECT workspace_id FROM workspace_members WHERE email = $5))
 		GROUP BY blueprints.id
 		ORDER BY last_modified_at DESC
 		LIMIT $2 OFFSET $3`
 
 	sqlFindBlueprintByName = `
-		SELECT blueprints.id, blueprints.name, blueprints.description, MAX(blueprint_versions.version) as version, MAX(blueprint_versions.created_at) as last_modified_at
+		SELECT blueprints.id, blueprints.name, blueprints.description, MAX(blueprint_versions.version) as version, MAX(blueprint_versions.created_at) as last_modified_at,
+			COALESCE(blueprints.created_by, ''), blueprints.private, blueprints.workspace_id
 		FROM blueprints INNER JOIN blueprint_versions ON blueprint_versions.blueprint_id = blueprints.id
 		WHERE blueprints.deleted = FALSE AND blueprints.name = $1 AND blueprints.org_id = $2
 		GROUP BY blueprints.id
@@ -124,12 +229,24 @@ const (
 	sqlCountFilteredBlueprints = `
 		SELECT COUNT(*)
 		FROM blueprints
-		WHERE blueprints.deleted = FALSE AND blueprints.org_id = $1 AND ($2::text = '%%' OR blueprints.name ILIKE $2 OR blueprints.description ILIKE $2)`
+		WHERE blueprints.deleted = FALSE AND blueprints.org_id = $1 AND ($2::text = '%%' OR blueprints.name ILIKE $2 OR blueprints.description ILIKE $2)
+			AND (blueprints.private = FALSE OR blueprints.created_by = $3)
+			AND ($4::text = '' OR ($4 = 'mine' AND blueprints.created_by = $3)
+				OR ($4 = 'shared' AND blueprints.private = FALSE AND blueprints.created_by IS DISTINCT FROM $3))
+			AND ($5::uuid IS NULL OR blueprints.workspace_id = $5)
+			AND (blueprints.workspace_id IS NULL OR $6::boolean
+				OR blueprints.workspace_id IN (SELECT workspace_id FROM workspace_members WHERE email = $3))`
 
 	sqlGetBlueprintsCount = `
 		SELECT COUNT(*)
 		FROM blueprints
-		WHERE blueprints.deleted = FALSE AND blueprints.org_id = $1`
+		WHERE blueprints.deleted = FALSE AND blueprints.org_id = $1
+			AND (blueprints.private = FALSE OR blueprints.created_by = $2)
+			AND ($3::text = '' OR ($3 = 'mine' AND blueprints.created_by = $2)
+				OR ($3 = 'shared' AND blueprints.private = FALSE AND blueprints.created_by IS DISTINCT FROM $2))
+			AND ($4::uuid IS NULL OR blueprints.workspace_id = $4)
+			AND (blueprints.workspace_id IS NULL OR $5::boolean
+				OR blueprints.workspace_id IN (SELECT workspace_id FROM workspace_members WHERE email = $2))`
 )
 
 // GetLatestBlueprintVersionNumber gets the latest version number of a blueprint.
@@ -212,7 +329,71 @@ func (db *dB) GetBlueprintComposes(ctx context.Context, orgId string, blueprintI
 	return composes, nil
 }
 
-func (db *dB) InsertBlueprint(ctx context.Context, id uuid.UUID, versionId uuid.UUID, orgID, accountNumber, name, description string, body json.RawMessage, metadata json.RawMessage) error {
+// GetBlueprintLifecycles returns the policies of all blueprints which have
+// one in their latest version.
+func (db *dB) GetBlueprintLifecycles(ctx context.Context) ([]BlueprintLifecycle, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	result, err := conn.Query(ctx, sqlGetBlueprintLifecycles)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	var lifecycles []BlueprintLifecycle
+	for result.Next() {
+		var l BlueprintLifecycle
+		err = result.Scan(&l.OrgId, &l.BlueprintId, &l.KeepLast)
+		if err != nil {
+			return nil, err
+		}
+		lifecycles = append(lifecycles, l)
+	}
+	if err = result.Err(); err != nil {
+		return nil, err
+	}
+	return lifecycles, nil
+}
+
+// GetExpiredBlueprintComposes returns the successful composes of the blueprint
+// which are older than the keepLast newest ones of their image type, newest
+// first.
+func (db *dB) GetExpiredBlueprintComposes(ctx context.Context, orgId string, blueprintId uuid.UUID, keepLast int) ([]BlueprintCompose, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	result, err := conn.Query(ctx, sqlGetExpiredBlueprintComposes, orgId, blueprintId, keepLast)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	var composes []BlueprintCompose
+	for result.Next() {
+		entry := BlueprintCompose{
+			BlueprintId: blueprintId,
+		}
+		err = result.Scan(&entry.BlueprintVersion, &entry.Id, &entry.Request, &entry.CreatedAt, &entry.ImageName, &entry.ClientId, &entry.Status)
+		if err != nil {
+			return nil, err
+		}
+		composes = append(composes, entry)
+	}
+	if err = result.Err(); err != nil {
+		return nil, err
+	}
+	return composes, nil
+}
+
+// InsertBlueprint stores a blueprint with the given options.
+func (db *dB) InsertBlueprint(ctx context.Context, id uuid.UUID, versionId uuid.UUID, orgID, accountNumber, name, description string, body json.RawMessage, metadata json.RawMessage, opts BlueprintOptions) error {
 	conn, err := db.Pool.Acquire(ctx)
 	if err != nil {
 		return err
@@ -220,7 +401,7 @@ func (db *dB) InsertBlueprint(ctx context.Context, id uuid.UUID, versionId uuid.
 	defer conn.Release()
 
 	err = db.withTransaction(ctx, func(tx pgx.Tx) error {
-		tag, txErr := tx.Exec(ctx, sqlInsertBlueprint, id, orgID, accountNumber, name, description, metadata)
+		tag, txErr := tx.Exec(ctx, sqlInsertBlueprint, id, orgID, accountNumber, name, description, metadata, nullIfEmpty(opts.CreatedBy), opts.Private, opts.WorkspaceId)
 		if txErr != nil {
 			return txErr
 		}
@@ -249,7 +430,7 @@ func (db *dB) GetBlueprint(ctx context.Context, id uuid.UUID, orgID string, vers
 
 	var result BlueprintEntry
 	row := conn.QueryRow(ctx, sqlGetBlueprint, id, orgID, version)
-	err = row.Scan(&result.Id, &result.VersionId, &result.Name, &result.Description, &result.Version, &result.Body, &result.Metadata)
+	err = row.Scan(&result.Id, &result.VersionId, &result.Name, &result.Description, &result.Version, &result.Body, &result.Metadata, &result.CreatedBy, &result.UpdatedBy, &result.Private, &result.WorkspaceId)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, BlueprintNotFoundError
@@ -260,7 +441,70 @@ func (db *dB) GetBlueprint(ctx context.Context, id uuid.UUID, orgID string, vers
 	return &result, err
 }
 
-func (db *dB) UpdateBlueprint(ctx context.Context, id uuid.UUID, blueprintId uuid.UUID, orgId string, name string, description string, body json.RawMessage) error {
+// GetBlueprintVersion returns the blueprint a version belongs to, its version
+// is the one asked for and LastModifiedAt is when that version was created.
+func (db *dB) GetBlueprintVersion(ctx context.Context, versionId uuid.UUID, orgID string) (*BlueprintWithNoBody, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	var result BlueprintWithNoBody
+	err = conn.QueryRow(ctx, sqlGetBlueprintVersion, versionId, orgID).Scan(&result.Id, &result.Name, &result.Description, &result.Version, &result.LastModifiedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, BlueprintNotFoundError
+		}
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetBlueprintVersions returns all versions of the blueprint, the latest
+// first. LastModifiedAt is when each version was created.
+func (db *dB) GetBlueprintVersions(ctx context.Context, id uuid.UUID, orgID string) ([]BlueprintWithNoBody, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, sqlGetBlueprintVersions, id, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []BlueprintWithNoBody
+	for rows.Next() {
+		var version BlueprintWithNoBody
+		err = rows.Scan(&version.Id, &version.Name, &version.Description, &version.Version, &version.LastModifiedAt)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, BlueprintNotFoundError
+	}
+	return versions, nil
+}
+
+func (db *dB) UpdateBlueprint(ctx context.Context, id uuid.UUID, blueprintId uuid.UUID, orgId string, name string, description string, body json.RawMessage, updatedBy string) error {
+	return db.updateBlueprint(ctx, id, blueprintId, orgId, name, description, body, nil, updatedBy)
+}
+
+// UpdateBlueprintIfVersion adds a version to the blueprint only when its latest
+// version is still the given one, BlueprintVersionConflictError otherwise.
+func (db *dB) UpdateBlueprintIfVersion(ctx context.Context, id uuid.UUID, blueprintId uuid.UUID, orgId string, name string, description string, body json.RawMessage, version int, updatedBy string) error {
+	return db.updateBlueprint(ctx, id, blueprintId, orgId, name, description, body, &version, updatedBy)
+}
+
+func (db *dB) updateBlueprint(ctx context.Context, id uuid.UUID, blueprintId uuid.UUID, orgId string, name string, description string, body json.RawMessage, version *int, updatedBy string) error {
 	conn, err := db.Pool.Acquire(ctx)
 	if err != nil {
 		return err
@@ -268,7 +512,7 @@ func (db *dB) UpdateBlueprint(ctx context.Context, id uuid.UUID, blueprintId uui
 	defer conn.Release()
 
 	err = db.withTransaction(ctx, func(tx pgx.Tx) error {
-		tag, txErr := tx.Exec(ctx, sqlUpdateBlueprint, blueprintId, orgId, name, description)
+		tag, txErr := tx.Exec(ctx, sqlUpdateBlueprint, blueprintId, orgId, name, description, nullIfEmpty(updatedBy))
 		if txErr != nil {
 			return txErr
 		}
@@ -281,10 +525,14 @@ func (db *dB) UpdateBlueprint(ctx context.Context, id uuid.UUID, blueprintId uui
 			return fmt.Errorf("blueprint not updated: %w, expected 1, returned %d", AffectedRowsMismatchError, tag.RowsAffected())
 		}
 
-		tag, txErr = tx.Exec(ctx, sqlUpdateBlueprintVersion, id, blueprintId, body, orgId)
+		// updating the blueprint locks it, concurrent updates see each other's versions
+		tag, txErr = tx.Exec(ctx, sqlUpdateBlueprintVersion, id, blueprintId, body, orgId, version)
 		if txErr != nil {
 			return txErr
 		}
+		if version != nil && tag.RowsAffected() == 0 {
+			return BlueprintVersionConflictError
+		}
 		if tag.RowsAffected() != 1 {
 			return fmt.Errorf("new blueprint version not created: %w, expected 1, returned %d", AffectedRowsMismatchError, tag.RowsAffected())
 		}
@@ -294,6 +542,25 @@ func (db *dB) UpdateBlueprint(ctx context.Context, id uuid.UUID, blueprintId uui
 	return err
 }
 
+// SetBlueprintPrivate makes the blueprint private to its creator or shares it
+// with the org.
+func (db *dB) SetBlueprintPrivate(ctx context.Context, id uuid.UUID, orgID string, private bool) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, sqlSetBlueprintPrivate, id, orgID, private)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return BlueprintNotFoundError
+	}
+	return nil
+}
+
 func (db *dB) DeleteBlueprint(ctx context.Context, id uuid.UUID, orgID, accountNumber string) error {
 	conn, err := db.Pool.Acquire(ctx)
 	if err != nil {
@@ -329,7 +596,7 @@ func (db *dB) FindBlueprintByName(ctx context.Context, orgID, nameQuery string)
 	var result BlueprintWithNoBody
 
 	row := conn.QueryRow(ctx, sqlFindBlueprintByName, nameQuery, orgID)
-	err = row.Scan(&result.Id, &result.Name, &result.Description, &result.Version, &result.LastModifiedAt)
+	err = row.Scan(&result.Id, &result.Name, &result.Description, &result.Version, &result.LastModifiedAt, &result.CreatedBy, &result.Private, &result.WorkspaceId)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -339,7 +606,7 @@ func (db *dB) FindBlueprintByName(ctx context.Context, orgID, nameQuery string)
 	return &result, nil
 }
 
-func (db *dB) FindBlueprints(ctx context.Context, orgID, search string, limit, offset int) ([]BlueprintWithNoBody, int, error) {
+func (db *dB) FindBlueprints(ctx context.Context, orgID, search string, filter BlueprintFilter, limit, offset int) ([]BlueprintWithNoBody, int, error) {
 	conn, err := db.Pool.Acquire(ctx)
 	if err != nil {
 		return nil, 0, err
@@ -347,7 +614,7 @@ func (db *dB) FindBlueprints(ctx context.Context, orgID, search string, limit, o
 	defer conn.Release()
 
 	searchQuery := "%" + search + "%"
-	rows, err := conn.Query(ctx, sqlFindBlueprints, orgID, limit, offset, searchQuery)
+	rows, err := conn.Query(ctx, sqlFindBlueprints, orgID, limit, offset, searchQuery, filter.Viewer, filter.Owner, filter.Workspace, filter.AllWorkspaces)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -356,7 +623,7 @@ func (db *dB) FindBlueprints(ctx context.Context, orgID, search string, limit, o
 	var blueprints []BlueprintWithNoBody
 	for rows.Next() {
 		var blueprint BlueprintWithNoBody
-		err = rows.Scan(&blueprint.Id, &blueprint.Name, &blueprint.Description, &blueprint.Version, &blueprint.LastModifiedAt)
+		err = rows.Scan(&blueprint.Id, &blueprint.Name, &blueprint.Description, &blueprint.Version, &blueprint.LastModifiedAt, &blueprint.CreatedBy, &blueprint.Private, &blueprint.WorkspaceId)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -364,7 +631,7 @@ func (db *dB) FindBlueprints(ctx context.Context, orgID, search string, limit, o
 	}
 
 	var count int
-	err = conn.QueryRow(ctx, sqlCountFilteredBlueprints, orgID, searchQuery).Scan(&count)
+	err = conn.QueryRow(ctx, sqlCountFilteredBlueprints, orgID, searchQuery, filter.Viewer, filter.Owner, filter.Workspace, filter.AllWorkspaces).Scan(&count)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -372,14 +639,14 @@ func (db *dB) FindBlueprints(ctx context.Context, orgID, search string, limit, o
 	return blueprints, count, nil
 }
 
-func (db *dB) GetBlueprints(ctx context.Context, orgID string, limit, offset int) ([]BlueprintWithNoBody, int, error) {
+func (db *dB) GetBlueprints(ctx context.Context, orgID string, filter BlueprintFilter, limit, offset int) ([]BlueprintWithNoBody, int, error) {
 	conn, err := db.Pool.Acquire(ctx)
 	if err != nil {
 		return nil, 0, err
 	}
 	defer conn.Release()
 
-	rows, err := conn.Query(ctx, sqlGetBlueprints, orgID, limit, offset)
+	rows, err := conn.Query(ctx, sqlGetBlueprints, orgID, limit, offset, filter.Viewer, filter.Owner, filter.Workspace, filter.AllWorkspaces)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -388,17 +655,26 @@ func (db *dB) GetBlueprints(ctx context.Context, orgID string, limit, offset int
 	var blueprints []BlueprintWithNoBody
 	for rows.Next() {
 		var blueprint BlueprintWithNoBody
-		err = rows.Scan(&blueprint.Id, &blueprint.Name, &blueprint.Description, &blueprint.Version, &blueprint.LastModifiedAt)
+		err = rows.Scan(&blueprint.Id, &blueprint.Name, &blueprint.Description, &blueprint.Version, &blueprint.LastModifiedAt, &blueprint.CreatedBy, &blueprint.Private, &blueprint.WorkspaceId)
 		if err != nil {
 			return nil, 0, err
 		}
 		blueprints = append(blueprints, blueprint)
 	}
 	var count int
-	err = conn.QueryRow(ctx, sqlGetBlueprintsCount, orgID).Scan(&count)
+	err = conn.QueryRow(ctx, sqlGetBlueprintsCount, orgID, filter.Viewer, filter.Owner, filter.Workspace, filter.AllWorkspaces).Scan(&count)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	return blueprints, count, nil
 }
+
+// nullIfEmpty stores users without an email, like some service accounts, as
+// unknown.
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,187 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ComposeDraftNotFoundError = errors.New("compose draft not found")
+	ComposeDraftLimitError    = errors.New("compose draft limit reached")
+)
+
+// ComposeDraftEntry is a partially filled compose request saved by a user,
+// only that user can see it.
+type ComposeDraftEntry struct {
+	Id        uuid.UUID
+	OrgId     string
+	Email     string
+	Name      string
+	Request   json.RawMessage
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+const (
+	sqlLockUserComposeDrafts = `
+		SELECT pg_advisory_xact_lock(hashtext('compose_drafts'), hashtext($1 || '/' || $2))`
+
+	sqlCountComposeDrafts = `
+		SELECT COUNT(*)
+		FROM compose_drafts
+		WHERE org_id = $1 AND email = $2`
+
+	sqlInsertComposeDraft = `
+		INSERT INTO compose_drafts(id, org_id, email, name, request)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at, updated_at`
+
+	sqlGetComposeDraft = `
+		SELECT id, org_id, email, name, request, created_at, updated_at
+		FROM compose_drafts
+		WHERE id = $1 AND org_id = $2 AND email = $3`
+
+	sqlGetComposeDrafts = `
+		SELECT id, org_id, email, name, request, created_at, updated_at
+		FROM compose_drafts
+		WHERE org_id = $1 AND email = $2
+		ORDER BY updated_at DESC`
+
+	sqlUpdateComposeDraft = `
+		UPDATE compose_drafts
+		SET name = $4, request = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND org_id = $2 AND email = $3
+		RETURNING created_at, updated_at`
+
+	sqlDeleteComposeDraft = `
+		DELETE FROM compose_drafts
+		WHERE id = $1 AND org_id = $2 AND email = $3`
+
+	sqlDeleteComposeDraftsBefore = `
+		DELETE FROM compose_drafts
+		WHERE CURRENT_TIMESTAMP - updated_at > $1`
+)
+
+func scanComposeDraft(row pgx.Row) (*ComposeDraftEntry, error) {
+	var e ComposeDraftEntry
+	err := row.Scan(&e.Id, &e.OrgId, &e.Email, &e.Name, &e.Request, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// InsertComposeDraft saves the draft unless the user already has limit
+// drafts, ComposeDraftLimitError is returned then. The creation and update
+// times are set on the entry.
+func (db *dB) InsertComposeDraft(ctx context.Context, limit int, draft *ComposeDraftEntry) error {
+	return db.withTransaction(ctx, func(tx pgx.Tx) error {
+		_, txErr := tx.Exec(ctx, sqlLockUserComposeDrafts, draft.OrgId, draft.Email)
+		if txErr != nil {
+			return txErr
+		}
+		var count int
+		txErr = tx.QueryRow(ctx, sqlCountComposeDrafts, draft.OrgId, draft.Email).Scan(&count)
+		if txErr != nil {
+			return txErr
+		}
+		if count >= limit {
+			return ComposeDraftLimitError
+		}
+		return tx.QueryRow(ctx, sqlInsertComposeDraft, draft.Id, draft.OrgId, draft.Email, draft.Name, draft.Request).Scan(&draft.CreatedAt, &draft.UpdatedAt)
+	})
+}
+
+func (db *dB) GetComposeDraft(ctx context.Context, id uuid.UUID, orgId, email string) (*ComposeDraftEntry, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	draft, err := scanComposeDraft(conn.QueryRow(ctx, sqlGetComposeDraft, id, orgId, email))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ComposeDraftNotFoundError
+	}
+	return draft, err
+}
+
+// GetComposeDrafts returns the drafts of the user, the most recently updated
+// first.
+func (db *dB) GetComposeDrafts(ctx context.Context, orgId, email string) ([]ComposeDraftEntry, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, sqlGetComposeDrafts, orgId, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var drafts []ComposeDraftEntry
+	for rows.Next() {
+		draft, err := scanComposeDraft(rows)
+		if err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, *draft)
+	}
+	return drafts, rows.Err()
+}
+
+// UpdateComposeDraft replaces the name and request of the draft, the
+// creation and update times are set on the entry.
+func (db *dB) UpdateComposeDraft(ctx context.Context, draft *ComposeDraftEntry) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	err = conn.QueryRow(ctx, sqlUpdateComposeDraft, draft.Id, draft.OrgId, draft.Email, draft.Name, draft.Request).Scan(&draft.CreatedAt, &draft.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ComposeDraftNotFoundError
+	}
+	return err
+}
+
+func (db *dB) DeleteComposeDraft(ctx context.Context, id uuid.UUID, orgId, email string) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, sqlDeleteComposeDraft, id, orgId, email)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ComposeDraftNotFoundError
+	}
+	return nil
+}
+
+// DeleteComposeDrafts removes the drafts which weren't updated within
+// retention and returns how many were removed.
+func (db *dB) DeleteComposeDrafts(ctx context.Context, retention time.Duration) (int64, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, sqlDeleteComposeDraftsBefore, retention)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExpiredCompose is a compose whose artifacts are past their expiry and
+// haven't been collected yet.
+type ExpiredCompose struct {
+	Id        uuid.UUID
+	OrgId     string
+	ExpiresAt time.Time
+	Region    *string
+}
+
+const (
+	sqlSetComposeExpiry = `
+		UPDATE composes
+		SET expires_at = $3
+		WHERE org_id = $1 AND job_id = $2 AND deleted = FALSE`
+
+	sqlGetExpiredComposes = `
+		SELECT job_id, org_id, expires_at, region
+		FROM composes
+		WHERE deleted = FALSE
+		AND expires_at <= CURRENT_TIMESTAMP
+		AND region IS NOT DISTINCT FROM $1
+		ORDER BY expires_at ASC
+		LIMIT $2`
+)
+
+func (db *dB) SetComposeExpiry(ctx context.Context, composeId uuid.UUID, orgId string, expiresAt time.Time) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, sqlSetComposeExpiry, orgId, composeId, expiresAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ComposeNotFoundError
+	}
+	return nil
+}
+
+// GetExpiredComposes returns the expired composes created in the region, the
+// ones expired for the longest first. nil selects composes created before
+// regions were configured.
+func (db *dB) GetExpiredComposes(ctx context.Context, region *string, limit int) ([]ExpiredCompose, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, sqlGetExpiredComposes, region, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var composes []ExpiredCompose
+	for rows.Next() {
+		var c ExpiredCompose
+		err = rows.Scan(&c.Id, &c.OrgId, &c.ExpiresAt, &c.Region)
+		if err != nil {
+			return nil, err
+		}
+		composes = append(composes, c)
+	}
+	return composes, rows.Err()
+}
@@ -0,0 +1,181 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ComposeStatusUnfinished filters on the composes without a recorded status,
+// the ones which are still building or haven't been looked at since they
+// finished.
+const ComposeStatusUnfinished = "unfinished"
+
+// ComposeFilter narrows down the composes listed by GetComposesFiltered,
+// empty fields don't filter.
+type ComposeFilter struct {
+	// Since is how far back composes are listed
+	Since            time.Duration
+	IgnoreImageTypes []string
+	ImageTypes       []string
+	Distributions    []string
+	// Statuses are recorded statuses or ComposeStatusUnfinished
+	Statuses      []string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// Labels all need to be attached to the listed composes
+	Labels map[string]string
+	// Creator is the email of the user recorded as the creator
+	Creator *string
+	// Ascending lists the oldest composes first
+	Ascending bool
+	// After continues a listing with the same filter after the cursor,
+	// offsets are ignored then
+	After *ComposeCursor
+
+	// SkipRequest lists the composes without their requests, which make up
+	// the bulk of the rows
+	SkipRequest bool
+	// SkipBlueprint lists the composes without their blueprint and its
+	// version, the blueprint versions aren't joined then
+	SkipBlueprint bool
+}
+
+// selectComposes is the start of the listing, the columns which are skipped
+// are selected as NULL so the rows are scanned the same way.
+func selectComposes(filter ComposeFilter) string {
+	request := "composes.request"
+	if filter.SkipRequest {
+		request = "NULL::jsonb"
+	}
+	blueprint := "blueprint_versions.blueprint_id, blueprint_versions.version"
+	from := "composes LEFT JOIN blueprint_versions ON composes.blueprint_version_id = blueprint_versions.id"
+	if filter.SkipBlueprint {
+		blueprint = "NULL::uuid, NULL::integer"
+		from = "composes"
+	}
+	return fmt.Sprintf(`
+	SELECT composes.job_id, %s, composes.created_at, composes.image_name, composes.client_id, composes.status, composes.error_code, composes.region, composes.description, composes.expires_at, %s
+	FROM %s`, request, blueprint, from)
+}
+
+// composeQuery collects the conditions of a compose listing, every %s of a
+// condition becomes the placeholder of the next argument.
+type composeQuery struct {
+	conditions []string
+	args       []interface{}
+}
+
+func (q *composeQuery) where(condition string, args ...interface{}) {
+	placeholders := make([]interface{}, len(args))
+	for i := range args {
+		placeholders[i] = q.arg(args[i])
+	}
+	q.conditions = append(q.conditions, fmt.Sprintf(condition, placeholders...))
+}
+
+func (q *composeQuery) arg(arg interface{}) string {
+	q.args = append(q.args, arg)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+func (q *composeQuery) whereClause() string {
+	return "WHERE " + strings.Join(q.conditions, " AND ")
+}
+
+func newComposeQuery(orgId string, filter ComposeFilter) *composeQuery {
+	q := &composeQuery{}
+	q.where("composes.org_id = %s", orgId)
+	q.where("composes.deleted = FALSE")
+	if filter.Since > 0 {
+		q.where("composes.created_at >= CURRENT_TIMESTAMP - %s::interval", filter.Since)
+	}
+	if len(filter.IgnoreImageTypes) > 0 {
+		q.where("composes.image_type <> ALL(%s)", filter.IgnoreImageTypes)
+	}
+	if len(filter.ImageTypes) > 0 {
+		q.where("composes.image_type = ANY(%s)", filter.ImageTypes)
+	}
+	if len(filter.Distributions) > 0 {
+		q.where("composes.request->>'distribution' = ANY(%s)", filter.Distributions)
+	}
+	if len(filter.Statuses) > 0 {
+		if slices.Contains(filter.Statuses, ComposeStatusUnfinished) {
+			q.where("(composes.status IS NULL OR composes.status = ANY(%s))", filter.Statuses)
+		} else {
+			q.where("composes.status = ANY(%s)", filter.Statuses)
+		}
+	}
+	if filter.CreatedAfter != nil {
+		q.where("composes.created_at >= %s", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		q.where("composes.created_at < %s", *filter.CreatedBefore)
+	}
+	if filter.Creator != nil {
+		q.where("composes.email = %s", *filter.Creator)
+	}
+	keys := make([]string, 0, len(filter.Labels))
+	for key := range filter.Labels {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		q.where("EXISTS (SELECT 1 FROM compose_labels WHERE compose_labels.compose_id = composes.job_id AND compose_labels.key = %s AND compose_labels.value = %s)", key, filter.Labels[key])
+	}
+	return q
+}
+
+// sqlQuery is a query along with its arguments.
+type sqlQuery struct {
+	sql  string
+	args []interface{}
+}
+
+// filteredComposeQueries builds the query counting the composes matching the
+// filter and the one listing a page of them.
+func filteredComposeQueries(orgId string, filter ComposeFilter, limit, offset int) (sqlQuery, sqlQuery) {
+	q := newComposeQuery(orgId, filter)
+	count := sqlQuery{"SELECT COUNT(*) FROM composes " + q.whereClause(), slices.Clone(q.args)}
+
+	order, compare := "DESC", "<"
+	if filter.Ascending {
+		order, compare = "ASC", ">"
+	}
+	if filter.After != nil {
+		q.where("(composes.created_at, composes.job_id) "+compare+" (%s, %s)", filter.After.CreatedAt, filter.After.Id)
+		offset = 0
+	}
+	list := fmt.Sprintf("%s %s ORDER BY composes.created_at %s, composes.job_id %s LIMIT %s OFFSET %s",
+		selectComposes(filter), q.whereClause(), order, order, q.arg(limit), q.arg(offset))
+	return count, sqlQuery{list, q.args}
+}
+
+// GetComposesFiltered lists the composes matching the filter, the count is
+// the one of all matching composes.
+func (db *dB) GetComposesFiltered(ctx context.Context, orgId string, filter ComposeFilter, limit, offset int) ([]ComposeWithBlueprintVersion, int, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer conn.Release()
+
+	countQuery, listQuery := filteredComposeQueries(orgId, filter, limit, offset)
+	var count int
+	err = conn.QueryRow(ctx, countQuery.sql, countQuery.args...).Scan(&count)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result, err := conn.Query(ctx, listQuery.sql, listQuery.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	composes, err := scanComposes(result)
+	if err != nil {
+		return nil, 0, err
+	}
+	return composes, count, nil
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const (
+	sqlInsertComposeGroup = `
+		INSERT INTO compose_groups(id, org_id)
+		VALUES ($1, $2)`
+
+	sqlAddComposeToGroup = `
+		UPDATE composes
+		SET group_id = $1
+		WHERE job_id = $2`
+
+	sqlGetComposeGroup = `
+		SELECT job_id, request, created_at, image_name, client_id, status, error_code, region, blueprint_version_id, parent_compose_id, group_id
+		FROM composes
+		WHERE org_id = $1 AND group_id = $2 AND deleted = FALSE
+		ORDER BY created_at ASC, job_id ASC`
+)
+
+// InsertComposeGroup records a compose group, the composes of a request built
+// for several architectures are added to it as they are submitted.
+func (db *dB) InsertComposeGroup(ctx context.Context, groupId uuid.UUID, orgId string) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, sqlInsertComposeGroup, groupId, orgId)
+	return err
+}
+
+func (db *dB) AddComposeToGroup(ctx context.Context, groupId, composeId uuid.UUID) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, sqlAddComposeToGroup, groupId, composeId)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() != 1 {
+		return ComposeNotFoundError
+	}
+	return nil
+}
+
+// GetComposeGroup returns the composes of the group in the order they were
+// submitted.
+func (db *dB) GetComposeGroup(ctx context.Context, groupId uuid.UUID, orgId string) ([]ComposeEntry, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, sqlGetComposeGroup, orgId, groupId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var composes []ComposeEntry
+	for rows.Next() {
+		var compose ComposeEntry
+		err = rows.Scan(&compose.Id, &compose.Request, &compose.CreatedAt, &compose.ImageName, &compose.ClientId, &compose.Status, &compose.ErrorCode, &compose.Region, &compose.BlueprintVersionId, &compose.ParentComposeId, &compose.GroupId)
+		if err != nil {
+			return nil, err
+		}
+		composes = append(composes, compose)
+	}
+	return composes, rows.Err()
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of the run of a post-processing hook on a compose.
+const (
+	// ComposeHookPending runs are tried again, they failed before
+	ComposeHookPending   = "pending"
+	ComposeHookSucceeded = "succeeded"
+	// ComposeHookFailed runs failed every attempt, they aren't tried again
+	ComposeHookFailed = "failed"
+)
+
+// ComposeHookRunEntry is how a post-processing hook fared on a compose, Error
+// is the one of the latest attempt.
+type ComposeHookRunEntry struct {
+	ComposeId uuid.UUID
+	Hook      string
+	Status    string
+	Attempts  int
+	Error     *string
+	UpdatedAt time.Time
+}
+
+// ComposeAwaitingHook is a succeeded compose a hook still has to run on,
+// Attempts are the ones which failed so far.
+type ComposeAwaitingHook struct {
+	Id        uuid.UUID
+	OrgId     string
+	ImageName *string
+	Request   json.RawMessage
+	CreatedAt time.Time
+	Attempts  int
+}
+
+const (
+	sqlGetComposesAwaitingHook = `
+		SELECT composes.job_id, composes.org_id, composes.image_name, composes.request, composes.created_at, COALESCE(compose_hook_runs.attempts, 0)
+		FROM composes
+		LEFT JOIN compose_hook_runs ON compose_hook_runs.compose_id = composes.job_id AND compose_hook_runs.hook = $1
+		WHERE composes.deleted = FALSE AND composes.status = 'success'
+		AND CURRENT_TIMESTAMP - composes.created_at <= $2
+		AND composes.region IS NOT DISTINCT FROM $4
+		AND (compose_hook_runs.status IS NULL OR compose_hook_runs.status = 'pending')
+		ORDER BY composes.created_at ASC
+		LIMIT $3`
+
+	sqlSetComposeHookRun = `
+		INSERT INTO compose_hook_runs(compose_id, hook, status, attempts, error)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (compose_id, hook) DO UPDATE
+		SET status = EXCLUDED.status, attempts = EXCLUDED.attempts, error = EXCLUDED.error, updated_at = CURRENT_TIMESTAMP`
+
+	sqlGetComposeHookRuns = `
+		SELECT compose_id, hook, status, attempts, error, updated_at
+		FROM compose_hook_runs
+		WHERE compose_id = $1
+		ORDER BY hook`
+)
+
+// GetComposesAwaitingHook returns the composes of the region created within
+// since which succeeded and the hook hasn't run on yet, or failed on but is to
+// be tried again. The oldest come first.
+func (db *dB) GetComposesAwaitingHook(ctx context.Context, hook string, region *string, since time.Duration, limit int) ([]ComposeAwaitingHook, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, sqlGetComposesAwaitingHook, hook, since, limit, region)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var composes []ComposeAwaitingHook
+	for rows.Next() {
+		var c ComposeAwaitingHook
+		err = rows.Scan(&c.Id, &c.OrgId, &c.ImageName, &c.Request, &c.CreatedAt, &c.Attempts)
+		if err != nil {
+			return nil, err
+		}
+		composes = append(composes, c)
+	}
+	return composes, rows.Err()
+}
+
+// SetComposeHookRun records the outcome of the latest attempt of the hook on
+// the compose, the time of the update is set by the database.
+func (db *dB) SetComposeHookRun(ctx context.Context, run ComposeHookRunEntry) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, sqlSetComposeHookRun, run.ComposeId, run.Hook, run.Status, run.Attempts, run.Error)
+	return err
+}
+
+// GetComposeHookRuns returns the hooks which ran on the compose, by name.
+func (db *dB) GetComposeHookRuns(ctx context.Context, composeId uuid.UUID) ([]ComposeHookRunEntry, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, sqlGetComposeHookRuns, composeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []ComposeHookRunEntry
+	for rows.Next() {
+		var run ComposeHookRunEntry
+		err = rows.Scan(&run.ComposeId, &run.Hook, &run.Status, &run.Attempts, &run.Error, &run.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
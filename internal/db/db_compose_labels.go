@@ -0,0 +1,27 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const sqlInsertComposeLabel = `
+	INSERT INTO compose_labels(compose_id, key, value)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (compose_id, key) DO UPDATE SET value = EXCLUDED.value`
+
+// SetComposeLabels attaches the labels to the compose, composes are listed by
+// them with ComposeFilter.Labels.
+func (db *dB) SetComposeLabels(ctx context.Context, composeId uuid.UUID, labels map[string]string) error {
+	return db.withTransaction(ctx, func(tx pgx.Tx) error {
+		for key, value := range labels {
+			_, err := tx.Exec(ctx, sqlInsertComposeLabel, composeId, key, value)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	sqlInsertComposeMetadata = `
+		INSERT INTO compose_metadata(compose_id, metadata)
+		VALUES ($1, $2)
+		ON CONFLICT (compose_id) DO NOTHING`
+
+	sqlGetComposeMetadata = `
+		SELECT compose_metadata.metadata
+		FROM compose_metadata INNER JOIN composes ON compose_metadata.compose_id = composes.job_id
+		WHERE compose_metadata.compose_id = $1 AND composes.org_id = $2`
+)
+
+// InsertComposeMetadata snapshots the metadata of a finished compose, so it
+// outlives what composer retains. The first snapshot is kept.
+func (db *dB) InsertComposeMetadata(ctx context.Context, composeId uuid.UUID, metadata json.RawMessage) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, sqlInsertComposeMetadata, composeId, metadata)
+	return err
+}
+
+func (db *dB) GetComposeMetadata(ctx context.Context, composeId uuid.UUID, orgId string) (json.RawMessage, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	var metadata json.RawMessage
+	err = conn.QueryRow(ctx, sqlGetComposeMetadata, composeId, orgId).Scan(&metadata)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ComposeMetadataNotFoundError
+	}
+	if err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
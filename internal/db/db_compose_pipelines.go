@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var ComposePipelineNotFoundError = errors.New("compose pipeline not found")
+
+// ComposePipelineStateError occurs when a pipeline isn't in the state it is
+// moved from anymore, someone else moved it already.
+var ComposePipelineStateError = errors.New("compose pipeline changed its state")
+
+const (
+	// ComposePipelineWaiting is the state pipelines are inserted in.
+	ComposePipelineWaiting = "waiting"
+	// ComposePipelineTriggering pipelines are submitting their installer.
+	ComposePipelineTriggering = "triggering"
+	// ComposePipelineFailed pipelines don't build their installer.
+	ComposePipelineFailed = "failed"
+)
+
+type ComposePipelineEntry struct {
+	Id              uuid.UUID
+	State           string
+	CommitComposeId uuid.UUID
+	// InstallerComposeId is set once the installer compose was submitted
+	InstallerComposeId *uuid.UUID
+	// InstallerRequest is the image request of the installer, its ostree
+	// options are completed with the commit when it is submitted
+	InstallerRequest json.RawMessage
+	Error            *string
+	CreatedAt        time.Time
+}
+
+// WaitingComposePipeline is a pipeline waiting for its commit compose along
+// with who submitted the commit, the installer is submitted on their behalf.
+type WaitingComposePipeline struct {
+	ComposePipelineEntry
+	OrgId         string
+	AccountNumber string
+	Email         string
+}
+
+const (
+	sqlInsertComposePipeline = `
+		INSERT INTO compose_pipelines(id, org_id, state, commit_compose_id, installer_request)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	sqlAddComposeToPipeline = `
+		UPDATE composes
+		SET pipeline_id = $1
+		WHERE job_id = $2`
+
+	sqlGetComposePipeline = `
+		SELECT id, state, commit_compose_id, installer_compose_id, installer_request, error, created_at
+		FROM compose_pipelines
+		WHERE org_id = $1 AND id = $2`
+
+	sqlSetComposePipelineState = `
+		UPDATE compose_pipelines
+		SET state = $3, installer_compose_id = COALESCE($4, installer_compose_id), error = $5, state_changed_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND state = $2`
+
+	sqlGetWaitingComposePipelines = `
+		SELECT compose_pipelines.id, compose_pipelines.state, compose_pipelines.commit_compose_id, compose_pipelines.installer_compose_id,
+			compose_pipelines.installer_request, compose_pipelines.error, compose_pipelines.created_at,
+			compose_pipelines.org_id, composes.account_number, COALESCE(composes.email, '')
+		FROM compose_pipelines INNER JOIN composes ON compose_pipelines.commit_compose_id = composes.job_id
+		WHERE compose_pipelines.state = $1
+		AND ($2::timestamp IS NULL OR (compose_pipelines.created_at, compose_pipelines.id) > ($2, $3))
+		ORDER BY compose_pipelines.created_at ASC, compose_pipelines.id ASC
+		LIMIT $4`
+
+	sqlFailStaleComposePipelines = `
+		UPDATE compose_pipelines
+		SET state = $2, error = $4, state_changed_at = CURRENT_TIMESTAMP
+		WHERE state = $1 AND state_changed_at < CURRENT_TIMESTAMP - $3::interval
+		RETURNING id`
+)
+
+// InsertComposePipeline records a pipeline waiting for the commit compose,
+// which is added to it.
+func (db *dB) InsertComposePipeline(ctx context.Context, id uuid.UUID, orgId string, commitComposeId uuid.UUID, installerRequest json.RawMessage) error {
+	return db.withTransaction(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, sqlInsertComposePipeline, id, orgId, ComposePipelineWaiting, commitComposeId, installerRequest)
+		if err != nil {
+			return err
+		}
+		tag, err := tx.Exec(ctx, sqlAddComposeToPipeline, id, commitComposeId)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() != 1 {
+			return ComposeNotFoundError
+		}
+		return nil
+	})
+}
+
+func (db *dB) GetComposePipeline(ctx context.Context, id uuid.UUID, orgId string) (*ComposePipelineEntry, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	var p ComposePipelineEntry
+	err = conn.QueryRow(ctx, sqlGetComposePipeline, orgId, id).Scan(&p.Id, &p.State, &p.CommitComposeId, &p.InstallerComposeId, &p.InstallerRequest, &p.Error, &p.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ComposePipelineNotFoundError
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SetComposePipelineState moves the pipeline from one state to the next, a
+// pipeline which isn't in the from state anymore fails with
+// ComposePipelineStateError. A submitted installer compose is added to the
+// pipeline along with it.
+func (db *dB) SetComposePipelineState(ctx context.Context, id uuid.UUID, from, to string, installerComposeId *uuid.UUID, errorMessage *string) error {
+	return db.withTransaction(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, sqlSetComposePipelineState, id, from, to, installerComposeId, errorMessage)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ComposePipelineStateError
+		}
+		if installerComposeId == nil {
+			return nil
+		}
+		tag, err = tx.Exec(ctx, sqlAddComposeToPipeline, id, *installerComposeId)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() != 1 {
+			return ComposeNotFoundError
+		}
+		return nil
+	})
+}
+
+// GetWaitingComposePipelines returns the pipelines waiting for their commit
+// compose, the oldest first. after continues with the ones following it.
+func (db *dB) GetWaitingComposePipelines(ctx context.Context, after *WaitingComposePipeline, limit int) ([]WaitingComposePipeline, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	var afterCreatedAt *time.Time
+	var afterId *uuid.UUID
+	if after != nil {
+		afterCreatedAt = &after.CreatedAt
+		afterId = &after.Id
+	}
+	rows, err := conn.Query(ctx, sqlGetWaitingComposePipelines, ComposePipelineWaiting, afterCreatedAt, afterId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pipelines []WaitingComposePipeline
+	for rows.Next() {
+		var p WaitingComposePipeline
+		err = rows.Scan(&p.Id, &p.State, &p.CommitComposeId, &p.InstallerComposeId, &p.InstallerRequest, &p.Error, &p.CreatedAt, &p.OrgId, &p.AccountNumber, &p.Email)
+		if err != nil {
+			return nil, err
+		}
+		pipelines = append(pipelines, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return pipelines, nil
+}
+
+// FailStaleComposePipelines fails the pipelines which have been in the state
+// for longer than olderThan with the error message, their ids are returned.
+func (db *dB) FailStaleComposePipelines(ctx context.Context, state string, olderThan time.Duration, errorMessage string) ([]uuid.UUID, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, sqlFailStaleComposePipelines, state, ComposePipelineFailed, olderThan, errorMessage)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,222 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ReservationTimeout is how long a reservation holds its slot at most, the
+// ones of requests which never got to record their compose are dropped then.
+const ReservationTimeout = 15 * time.Minute
+
+// ComposeReservation holds a slot of the quota of an org while its compose is
+// submitted to composer, composes which don't fit aren't built at all.
+type ComposeReservation struct {
+	Id    uuid.UUID
+	OrgId string
+	// Quota limits the composes of the org in the Window, nil for no limit.
+	Quota *int
+	// Window is the sliding window the quotas of the org and its workspaces
+	// apply to.
+	Window time.Duration
+	// LaneLimit is how many composes of the org the priority lane takes in
+	// the LaneWindow, nil keeps the compose out of the lane. The compose
+	// goes through the quota when the lane is full.
+	LaneLimit  *int
+	LaneWindow time.Duration
+	// BlueprintVersionId of a blueprint in a workspace with a quota holds
+	// the compose to that quota too, in the Window.
+	BlueprintVersionId *uuid.UUID
+
+	// PriorityLane is set by ReserveCompose when the compose got into the
+	// priority lane.
+	PriorityLane bool
+}
+
+// ReservedCompose is a compose submitted to composer on a reservation.
+type ReservedCompose struct {
+	JobId              uuid.UUID
+	AccountNumber      string
+	Email              string
+	ImageName          *string
+	Request            json.RawMessage
+	ClientId           *string
+	BlueprintVersionId *uuid.UUID
+	Region             *string
+	ParentComposeId    *uuid.UUID
+	// ExpiresAt is when the artifacts of the compose expire, nil if they
+	// don't.
+	ExpiresAt *time.Time
+	// Labels the compose is listed by.
+	Labels map[string]string
+	// InjectedDefaults is what the defaults of the org merged into the
+	// compose, nil if nothing was.
+	InjectedDefaults json.RawMessage
+}
+
+const (
+	sqlDeleteStaleComposeReservations = `
+		DELETE FROM compose_reservations
+		WHERE created_at < CURRENT_TIMESTAMP - $1::interval`
+
+	// the reservations are younger than any window
+	sqlCountReservedComposesSince = `
+		SELECT
+			(SELECT COUNT(*)
+			FROM composes
+			WHERE org_id = $1 AND created_at >= CURRENT_TIMESTAMP - $2::interval
+			AND priority_lane = $3)
+			+ (SELECT COUNT(*)
+			FROM compose_reservations
+			WHERE org_id = $1 AND priority_lane = $3)`
+
+	sqlGetBlueprintVersionWorkspace = `
+		SELECT workspaces.id, workspaces.compose_quota
+		FROM blueprint_versions INNER JOIN blueprints ON blueprint_versions.blueprint_id = blueprints.id
+		INNER JOIN workspaces ON blueprints.workspace_id = workspaces.id
+		WHERE blueprint_versions.id = $1`
+
+	sqlCountReservedWorkspaceComposesSince = `
+		SELECT
+			(SELECT COUNT(*)
+			FROM composes INNER JOIN blueprint_versions ON composes.blueprint_version_id = blueprint_versions.id
+			INNER JOIN blueprints ON blueprint_versions.blueprint_id = blueprints.id
+			WHERE composes.org_id = $1 AND blueprints.workspace_id = $2
+			AND composes.created_at >= CURRENT_TIMESTAMP - $3::interval)
+			+ (SELECT COUNT(*)
+			FROM compose_reservations
+			WHERE org_id = $1 AND workspace_id = $2)`
+
+	sqlInsertComposeReservation = `
+		INSERT INTO compose_reservations(id, org_id, priority_lane, workspace_id)
+		VALUES ($1, $2, $3, $4)`
+
+	sqlDeleteComposeReservation = `
+		DELETE FROM compose_reservations
+		WHERE id = $1`
+)
+
+// ReserveCompose takes a slot in the priority lane when the reservation asks
+// for one and the lane isn't full, a slot of the quota otherwise.
+// QuotaExceededError is returned when the quota is used up,
+// WorkspaceQuotaExceededError when the one of the workspace of the blueprint
+// is. Concurrent reservations of the same org, and so of its workspaces, wait
+// for each other, so they can't all pass the checks before any of them is
+// recorded.
+func (db *dB) ReserveCompose(ctx context.Context, reservation *ComposeReservation) error {
+	return db.withTransaction(ctx, func(tx pgx.Tx) error {
+		_, txErr := tx.Exec(ctx, sqlLockOrgComposes, reservation.OrgId)
+		if txErr != nil {
+			return txErr
+		}
+		_, txErr = tx.Exec(ctx, sqlDeleteStaleComposeReservations, ReservationTimeout)
+		if txErr != nil {
+			return txErr
+		}
+
+		var workspaceId *uuid.UUID
+		if reservation.BlueprintVersionId != nil {
+			var id uuid.UUID
+			var quota *int
+			txErr = tx.QueryRow(ctx, sqlGetBlueprintVersionWorkspace, *reservation.BlueprintVersionId).Scan(&id, &quota)
+			if txErr != nil && !errors.Is(txErr, pgx.ErrNoRows) {
+				return txErr
+			}
+			if txErr == nil {
+				workspaceId = &id
+			}
+			if quota != nil {
+				var count int
+				txErr = tx.QueryRow(ctx, sqlCountReservedWorkspaceComposesSince, reservation.OrgId, id, reservation.Window).Scan(&count)
+				if txErr != nil {
+					return txErr
+				}
+				if count >= *quota {
+					return WorkspaceQuotaExceededError
+				}
+			}
+		}
+
+		priorityLane := false
+		if reservation.LaneLimit != nil {
+			var count int
+			txErr = tx.QueryRow(ctx, sqlCountReservedComposesSince, reservation.OrgId, reservation.LaneWindow, true).Scan(&count)
+			if txErr != nil {
+				return txErr
+			}
+			priorityLane = count < *reservation.LaneLimit
+		}
+		if !priorityLane && reservation.Quota != nil {
+			var count int
+			txErr = tx.QueryRow(ctx, sqlCountReservedComposesSince, reservation.OrgId, reservation.Window, false).Scan(&count)
+			if txErr != nil {
+				return txErr
+			}
+			if count >= *reservation.Quota {
+				return QuotaExceededError
+			}
+		}
+
+		_, txErr = tx.Exec(ctx, sqlInsertComposeReservation, reservation.Id, reservation.OrgId, priorityLane, workspaceId)
+		if txErr != nil {
+			return txErr
+		}
+		reservation.PriorityLane = priorityLane
+		return nil
+	})
+}
+
+// InsertReservedCompose records the compose in the slot of the reservation,
+// which is released, along with everything attached to it.
+func (db *dB) InsertReservedCompose(ctx context.Context, reservation *ComposeReservation, compose ReservedCompose) error {
+	return db.withTransaction(ctx, func(tx pgx.Tx) error {
+		txErr := insertCompose(ctx, tx, compose.JobId, compose.AccountNumber, compose.Email, reservation.OrgId, compose.ImageName, compose.Request, compose.ClientId, compose.BlueprintVersionId, compose.Region, compose.ParentComposeId)
+		if txErr != nil {
+			return txErr
+		}
+		if reservation.PriorityLane {
+			_, txErr = tx.Exec(ctx, sqlSetComposePriorityLane, compose.JobId)
+			if txErr != nil {
+				return txErr
+			}
+		}
+		if compose.ExpiresAt != nil {
+			_, txErr = tx.Exec(ctx, sqlSetComposeExpiry, reservation.OrgId, compose.JobId, *compose.ExpiresAt)
+			if txErr != nil {
+				return txErr
+			}
+		}
+		if compose.InjectedDefaults != nil {
+			_, txErr = tx.Exec(ctx, sqlSetComposeInjectedDefaults, reservation.OrgId, compose.JobId, compose.InjectedDefaults)
+			if txErr != nil {
+				return txErr
+			}
+		}
+		for key, value := range compose.Labels {
+			_, txErr = tx.Exec(ctx, sqlInsertComposeLabel, compose.JobId, key, value)
+			if txErr != nil {
+				return txErr
+			}
+		}
+		_, txErr = tx.Exec(ctx, sqlDeleteComposeReservation, reservation.Id)
+		return txErr
+	})
+}
+
+// ReleaseComposeReservation frees the slot of a compose which wasn't
+// submitted after all.
+func (db *dB) ReleaseComposeReservation(ctx context.Context, id uuid.UUID) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, sqlDeleteComposeReservation, id)
+	return err
+}
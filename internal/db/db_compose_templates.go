@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var ComposeTemplateNotFoundError = errors.New("compose template not found")
+
+// ComposeTemplateEntry is a published version of a template, templates are
+// identified by their name within the org and versions are never changed.
+type ComposeTemplateEntry struct {
+	Id          uuid.UUID
+	OrgId       string
+	Name        string
+	Version     int
+	Description string
+	Request     json.RawMessage
+	CreatedBy   string
+	CreatedAt   time.Time
+}
+
+const (
+	sqlInsertComposeTemplate = `
+		INSERT INTO compose_templates(id, org_id, name, version, description, request, created_by)
+		SELECT $1, $2, $3, COALESCE(MAX(version), 0) + 1, $4, $5, $6
+		FROM compose_templates
+		WHERE org_id = $2 AND name = $3
+		RETURNING version, created_at`
+
+	sqlGetComposeTemplate = `
+		SELECT id, org_id, name, version, description, request, created_by, created_at
+		FROM compose_templates
+		WHERE org_id = $1 AND name = $2 AND ($3::integer IS NULL OR version = $3)
+		ORDER BY version DESC
+		LIMIT 1`
+
+	sqlGetComposeTemplates = `
+		SELECT DISTINCT ON (name) id, org_id, name, version, description, request, created_by, created_at
+		FROM compose_templates
+		WHERE org_id = $1
+		ORDER BY name, version DESC`
+
+	sqlDeleteComposeTemplate = `
+		DELETE FROM compose_templates
+		WHERE org_id = $1 AND name = $2`
+)
+
+func scanComposeTemplate(row pgx.Row) (*ComposeTemplateEntry, error) {
+	var e ComposeTemplateEntry
+	err := row.Scan(&e.Id, &e.OrgId, &e.Name, &e.Version, &e.Description, &e.Request, &e.CreatedBy, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// InsertComposeTemplate publishes the next version of the template, the
+// version and creation time are set on the entry. Concurrent publishes of
+// the same template fail with a unique violation.
+func (db *dB) InsertComposeTemplate(ctx context.Context, template *ComposeTemplateEntry) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	return conn.QueryRow(ctx, sqlInsertComposeTemplate, template.Id, template.OrgId, template.Name, template.Description, template.Request, template.CreatedBy).Scan(&template.Version, &template.CreatedAt)
+}
+
+// GetComposeTemplate returns the version of the template, the latest one if
+// version is nil.
+func (db *dB) GetComposeTemplate(ctx context.Context, orgId, name string, version *int) (*ComposeTemplateEntry, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	template, err := scanComposeTemplate(conn.QueryRow(ctx, sqlGetComposeTemplate, orgId, name, version))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ComposeTemplateNotFoundError
+	}
+	return template, err
+}
+
+// GetComposeTemplates returns the latest version of every template of the
+// org, ordered by name.
+func (db *dB) GetComposeTemplates(ctx context.Context, orgId string) ([]ComposeTemplateEntry, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, sqlGetComposeTemplates, orgId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []ComposeTemplateEntry
+	for rows.Next() {
+		template, err := scanComposeTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *template)
+	}
+	return templates, rows.Err()
+}
+
+// DeleteComposeTemplate deletes all versions of the template.
+func (db *dB) DeleteComposeTemplate(ctx context.Context, orgId, name string) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, sqlDeleteComposeTemplate, orgId, name)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ComposeTemplateNotFoundError
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const (
+	sqlTransferCompose = `
+		UPDATE composes
+		SET email = $4
+		WHERE org_id = $1 AND job_id = $2 AND deleted = FALSE
+		AND ($3::varchar IS NULL OR email = $3)`
+
+	sqlTransferComposes = `
+		UPDATE composes
+		SET email = $3
+		WHERE org_id = $1 AND email = $2`
+)
+
+// TransferCompose records to as the creator of the compose, when from is set
+// only if from created it. ComposeNotFoundError is returned when the compose
+// wasn't transferred.
+func (db *dB) TransferCompose(ctx context.Context, composeId uuid.UUID, orgId string, from *string, to string) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, sqlTransferCompose, orgId, composeId, from, to)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() != 1 {
+		return ComposeNotFoundError
+	}
+	return nil
+}
+
+// TransferComposes records to as the creator of all the composes of the org
+// which from created, deleted ones included, and returns how many there were.
+func (db *dB) TransferComposes(ctx context.Context, orgId, from, to string) (int64, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, sqlTransferComposes, orgId, from, to)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
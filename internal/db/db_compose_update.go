@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// ComposeUpdate changes how a compose is listed, nil fields keep their value
+// and empty ones are cleared.
+type ComposeUpdate struct {
+	ImageName   *string
+	Description *string
+}
+
+const sqlUpdateCompose = `
+	UPDATE composes
+	SET image_name = CASE WHEN $3::boolean THEN NULLIF($4::varchar, '') ELSE image_name END,
+		description = CASE WHEN $5::boolean THEN NULLIF($6::varchar, '') ELSE description END
+	WHERE org_id = $1 AND job_id = $2 AND deleted = FALSE`
+
+func (db *dB) UpdateCompose(ctx context.Context, composeId uuid.UUID, orgId string, update ComposeUpdate) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, sqlUpdateCompose, orgId, composeId, update.ImageName != nil, update.ImageName, update.Description != nil, update.Description)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() != 1 {
+		return ComposeNotFoundError
+	}
+	return nil
+}
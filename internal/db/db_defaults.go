@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var OrgDefaultsNotFoundError = errors.New("org defaults not found")
+
+// OrgDefaultsEntry holds the customizations merged into every compose of an
+// org.
+type OrgDefaultsEntry struct {
+	OrgId     string
+	Defaults  json.RawMessage
+	UpdatedBy string
+	UpdatedAt time.Time
+}
+
+const (
+	sqlGetOrgDefaults = `
+		SELECT org_id, defaults, updated_by, updated_at
+		FROM org_defaults
+		WHERE org_id = $1`
+
+	sqlSetOrgDefaults = `
+		INSERT INTO org_defaults(org_id, defaults, updated_by)
+		VALUES($1, $2, $3)
+		ON CONFLICT (org_id) DO UPDATE
+		SET defaults = EXCLUDED.defaults, updated_by = EXCLUDED.updated_by, updated_at = CURRENT_TIMESTAMP`
+
+	sqlDeleteOrgDefaults = `
+		DELETE FROM org_defaults
+		WHERE org_id = $1`
+
+	sqlSetComposeInjectedDefaults = `
+		UPDATE composes
+		SET injected_defaults = $3
+		WHERE org_id = $1 AND job_id = $2 AND deleted = FALSE`
+
+	sqlGetComposeInjectedDefaults = `
+		SELECT injected_defaults
+		FROM composes
+		WHERE org_id = $1 AND job_id = $2 AND deleted = FALSE`
+)
+
+func (db *dB) GetOrgDefaults(ctx context.Context, orgId string) (*OrgDefaultsEntry, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	var d OrgDefaultsEntry
+	err = conn.QueryRow(ctx, sqlGetOrgDefaults, orgId).Scan(&d.OrgId, &d.Defaults, &d.UpdatedBy, &d.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, OrgDefaultsNotFoundError
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// SetOrgDefaults creates or replaces the defaults of the org.
+func (db *dB) SetOrgDefaults(ctx context.Context, orgId, updatedBy string, defaults json.RawMessage) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, sqlSetOrgDefaults, orgId, defaults, updatedBy)
+	return err
+}
+
+func (db *dB) DeleteOrgDefaults(ctx context.Context, orgId string) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, sqlDeleteOrgDefaults, orgId)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return OrgDefaultsNotFoundError
+	}
+	return nil
+}
+
+// SetComposeInjectedDefaults records what the defaults of the org merged into
+// the compose.
+func (db *dB) SetComposeInjectedDefaults(ctx context.Context, composeId uuid.UUID, orgId string, injected json.RawMessage) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, sqlSetComposeInjectedDefaults, orgId, composeId, injected)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ComposeNotFoundError
+	}
+	return nil
+}
+
+// GetComposeInjectedDefaults returns what the defaults of the org merged into
+// the compose, nil if nothing was.
+func (db *dB) GetComposeInjectedDefaults(ctx context.Context, composeId uuid.UUID, orgId string) (json.RawMessage, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	var injected json.RawMessage
+	err = conn.QueryRow(ctx, sqlGetComposeInjectedDefaults, orgId, composeId).Scan(&injected)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ComposeNotFoundError
+	}
+	if err != nil {
+		return nil, err
+	}
+	return injected, nil
+}
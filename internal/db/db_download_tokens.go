@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var DownloadTokenNotFoundError = errors.New("download token not found")
+
+// DownloadTokenEntry is a download token of an org, the token itself isn't
+// stored, only its hash.
+type DownloadTokenEntry struct {
+	Id         uuid.UUID
+	OrgId      string
+	Name       string
+	CreatedBy  string
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+}
+
+const (
+	sqlInsertDownloadToken = `
+		INSERT INTO download_tokens(id, org_id, name, token_hash, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`
+
+	sqlGetDownloadTokens = `
+		SELECT id, org_id, name, created_by, created_at, last_used_at
+		FROM download_tokens
+		WHERE org_id = $1
+		ORDER BY created_at DESC`
+
+	sqlUseDownloadToken = `
+		UPDATE download_tokens
+		SET last_used_at = CURRENT_TIMESTAMP
+		WHERE token_hash = $1
+		RETURNING id, org_id, name, created_by, created_at, last_used_at`
+
+	sqlDeleteDownloadToken = `
+		DELETE FROM download_tokens
+		WHERE id = $1 AND org_id = $2`
+)
+
+func scanDownloadToken(row pgx.Row) (*DownloadTokenEntry, error) {
+	var e DownloadTokenEntry
+	err := row.Scan(&e.Id, &e.OrgId, &e.Name, &e.CreatedBy, &e.CreatedAt, &e.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// InsertDownloadToken stores the token by its hash, the creation time is set
+// on the entry.
+func (db *dB) InsertDownloadToken(ctx context.Context, token *DownloadTokenEntry, tokenHash string) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	return conn.QueryRow(ctx, sqlInsertDownloadToken, token.Id, token.OrgId, token.Name, tokenHash, token.CreatedBy).Scan(&token.CreatedAt)
+}
+
+func (db *dB) GetDownloadTokens(ctx context.Context, orgId string) ([]DownloadTokenEntry, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, sqlGetDownloadTokens, orgId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []DownloadTokenEntry
+	for rows.Next() {
+		token, err := scanDownloadToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+	return tokens, rows.Err()
+}
+
+// UseDownloadToken returns the token of the hash and records that it was
+// used, DownloadTokenNotFoundError if there's none, like after it was revoked.
+func (db *dB) UseDownloadToken(ctx context.Context, tokenHash string) (*DownloadTokenEntry, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	token, err := scanDownloadToken(conn.QueryRow(ctx, sqlUseDownloadToken, tokenHash))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, DownloadTokenNotFoundError
+	}
+	return token, err
+}
+
+// DeleteDownloadToken revokes the token, it can't be used from then on.
+func (db *dB) DeleteDownloadToken(ctx context.Context, id uuid.UUID, orgId string) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, sqlDeleteDownloadToken, id, orgId)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return DownloadTokenNotFoundError
+	}
+	return nil
+}
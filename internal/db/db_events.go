@@ -0,0 +1,150 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Types of compose events.
+const (
+	ComposeEventCreated       = "compose.created"
+	ComposeEventStatusChanged = "compose.status_changed"
+)
+
+// ComposeEventEntry records a change in the lifecycle of a compose, the
+// status and error code are the ones the compose had after the change.
+type ComposeEventEntry struct {
+	Id        int64
+	OrgId     string
+	ComposeId uuid.UUID
+	Type      string
+	ImageType *string
+	Status    *string
+	ErrorCode *string
+	CreatedAt time.Time
+}
+
+const (
+	sqlInsertComposeEvent = `
+		INSERT INTO compose_events(org_id, compose_id, type, image_type, status, error_code)
+		SELECT org_id, job_id, $2, request->'image_requests'->0->>'image_type', status, error_code
+		FROM composes
+		WHERE job_id = $1`
+
+	// Ids are handed out when inserting, so an event with a lower id can still
+	// become visible after one with a higher id. Holding back the most recent
+	// events makes it very unlikely to skip one when paging by id.
+	sqlGetComposeEvents = `
+		SELECT id, org_id, compose_id, type, image_type, status, error_code, created_at
+		FROM compose_events
+		WHERE org_id = $1 AND id > $2
+		AND created_at <= CURRENT_TIMESTAMP - interval '5 seconds'
+		ORDER BY id
+		LIMIT $3`
+
+	sqlGetComposeHistory = `
+		SELECT id, org_id, compose_id, type, image_type, status, error_code, created_at
+		FROM compose_events
+		WHERE compose_id = $1 AND org_id = $2
+		ORDER BY id`
+
+	sqlGetComposeEventLag = `
+		SELECT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP - max(created_at))
+		FROM compose_events`
+
+	sqlDeleteComposeEventsBefore = `
+		DELETE FROM compose_events
+		WHERE CURRENT_TIMESTAMP - created_at > $1`
+)
+
+func insertComposeEvent(ctx context.Context, tx pgx.Tx, composeId uuid.UUID, eventType string) error {
+	_, err := tx.Exec(ctx, sqlInsertComposeEvent, composeId, eventType)
+	return err
+}
+
+// GetComposeEvents returns the events of the org with an id bigger than
+// after, oldest first.
+func (db *dB) GetComposeEvents(ctx context.Context, orgId string, after int64, limit int) ([]ComposeEventEntry, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, sqlGetComposeEvents, orgId, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanComposeEvents(rows)
+}
+
+// GetComposeHistory returns all events of a single compose, oldest first.
+func (db *dB) GetComposeHistory(ctx context.Context, composeId uuid.UUID, orgId string) ([]ComposeEventEntry, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, sqlGetComposeHistory, composeId, orgId)
+	if err != nil {
+		return nil, err
+	}
+	return scanComposeEvents(rows)
+}
+
+func scanComposeEvents(rows pgx.Rows) ([]ComposeEventEntry, error) {
+	defer rows.Close()
+
+	var events []ComposeEventEntry
+	for rows.Next() {
+		var e ComposeEventEntry
+		err := rows.Scan(&e.Id, &e.OrgId, &e.ComposeId, &e.Type, &e.ImageType, &e.Status, &e.ErrorCode, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// DeleteComposeEvents removes the events older than retention and returns how
+// many were removed.
+func (db *dB) DeleteComposeEvents(ctx context.Context, retention time.Duration) (int64, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, sqlDeleteComposeEventsBefore, retention)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ComposeEventLag is the time since the last event was recorded, nil if there
+// are none.
+func (db *dB) ComposeEventLag(ctx context.Context) (*time.Duration, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	var seconds *float64
+	err = conn.QueryRow(ctx, sqlGetComposeEventLag).Scan(&seconds)
+	if err != nil || seconds == nil {
+		return nil, err
+	}
+	lag := time.Duration(*seconds * float64(time.Second))
+	return &lag, nil
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QueryPlan is a node of the plan of a query as EXPLAIN reports it.
+type QueryPlan struct {
+	NodeType     string      `json:"Node Type"`
+	RelationName string      `json:"Relation Name,omitempty"`
+	IndexName    string      `json:"Index Name,omitempty"`
+	Plans        []QueryPlan `json:"Plans,omitempty"`
+}
+
+// SeqScans lists the tables the plan reads sequentially.
+func (p QueryPlan) SeqScans() []string {
+	var tables []string
+	if p.NodeType == "Seq Scan" {
+		tables = append(tables, p.RelationName)
+	}
+	for _, child := range p.Plans {
+		tables = append(tables, child.SeqScans()...)
+	}
+	return tables
+}
+
+// Indexes lists the indexes the plan reads.
+func (p QueryPlan) Indexes() []string {
+	var indexes []string
+	if p.IndexName != "" {
+		indexes = append(indexes, p.IndexName)
+	}
+	for _, child := range p.Plans {
+		indexes = append(indexes, child.Indexes()...)
+	}
+	return indexes
+}
+
+// composeQueries are the queries listing and counting the composes of an org,
+// with arguments like the ones of the API.
+func composeQueries(orgId string) map[string]sqlQuery {
+	since := 14 * 24 * time.Hour
+	ignored := []string{"rhel-edge-installer"}
+	cursor := ComposeCursor{CreatedAt: time.Now(), Id: uuid.Nil}
+	queries := map[string]sqlQuery{
+		"GetComposes":              {sqlGetComposes, []interface{}{orgId, since, ignored, 100, 0}},
+		"GetComposesAfter":         {sqlGetComposesAfter, []interface{}{orgId, since, ignored, 100, cursor.CreatedAt, cursor.Id}},
+		"CountActiveComposesSince": {sqlCountActiveComposesSince, []interface{}{orgId, since, ignored}},
+		"CountComposesSince":       {sqlCountComposesSince, []interface{}{orgId, since}},
+	}
+	count, list := filteredComposeQueries(orgId, ComposeFilter{Since: since, ImageTypes: []string{"aws"}, After: &cursor}, 100, 0)
+	queries["CountComposesFiltered"] = count
+	queries["GetComposesFiltered"] = list
+	_, list = filteredComposeQueries(orgId, ComposeFilter{Since: since, Labels: map[string]string{"team": "platform", "env": "prod"}}, 100, 0)
+	queries["GetComposesLabeled"] = list
+	return queries
+}
+
+// ExplainComposeQueries plans the queries listing and counting the composes of
+// the org, by the name of the method running them. Sequential scans are
+// disabled while planning, so the plans show whether the indexes serve the
+// queries at all rather than what suits the current size of the tables best.
+func (db *dB) ExplainComposeQueries(ctx context.Context, orgId string) (map[string]QueryPlan, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	_, err = tx.Exec(ctx, "SET LOCAL enable_seqscan = off")
+	if err != nil {
+		return nil, err
+	}
+	plans := map[string]QueryPlan{}
+	for name, query := range composeQueries(orgId) {
+		var explained []byte
+		err = tx.QueryRow(ctx, "EXPLAIN (FORMAT JSON) "+query.sql, query.args...).Scan(&explained)
+		if err != nil {
+			return nil, fmt.Errorf("explaining %s: %w", name, err)
+		}
+		var result []struct {
+			Plan QueryPlan `json:"Plan"`
+		}
+		err = json.Unmarshal(explained, &result)
+		if err != nil {
+			return nil, fmt.Errorf("explaining %s: %w", name, err)
+		}
+		if len(result) != 1 {
+			return nil, fmt.Errorf("explaining %s: expected one plan, got %d", name, len(result))
+		}
+		plans[name] = result[0].Plan
+	}
+	return plans, nil
+}
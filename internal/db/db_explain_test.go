@@ -0,0 +1,54 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryPlan(t *testing.T) {
+	var plan QueryPlan
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"Node Type": "Limit",
+		"Plans": [{
+			"Node Type": "Nested Loop",
+			"Plans": [
+				{"Node Type": "Index Scan", "Relation Name": "composes", "Index Name": "composes_org_listing_idx"},
+				{"Node Type": "Seq Scan", "Relation Name": "blueprint_versions"}
+			]
+		}]
+	}`), &plan))
+	require.Equal(t, []string{"blueprint_versions"}, plan.SeqScans())
+	require.Equal(t, []string{"composes_org_listing_idx"}, plan.Indexes())
+}
+
+func TestComposeQueries(t *testing.T) {
+	for name, query := range composeQueries("000000") {
+		// every placeholder has its argument
+		require.Contains(t, query.sql, fmt.Sprintf("$%d", len(query.args)), name)
+		require.NotContains(t, query.sql, fmt.Sprintf("$%d", len(query.args)+1), name)
+		// the age of composes can't be looked up in an index
+		require.NotRegexp(t, `CURRENT_TIMESTAMP - (composes\.)?created_at`, query.sql, name)
+	}
+}
+
+func TestFilteredComposeQueriesLabels(t *testing.T) {
+	count, list := filteredComposeQueries("000000", ComposeFilter{Labels: map[string]string{"team": "platform", "env": "prod"}}, 100, 0)
+	// the labels are matched in a stable order
+	require.Equal(t, []interface{}{"000000", "env", "prod", "team", "platform"}, count.args)
+	require.Contains(t, count.sql, "compose_labels.key = $2 AND compose_labels.value = $3")
+	require.Contains(t, count.sql, "compose_labels.key = $4 AND compose_labels.value = $5")
+	require.Equal(t, []interface{}{"000000", "env", "prod", "team", "platform", 100, 0}, list.args)
+}
+
+func TestFilteredComposeQueriesSkip(t *testing.T) {
+	_, list := filteredComposeQueries("000000", ComposeFilter{}, 100, 0)
+	require.Contains(t, list.sql, "composes.request")
+	require.Contains(t, list.sql, "JOIN blueprint_versions")
+
+	_, list = filteredComposeQueries("000000", ComposeFilter{SkipRequest: true, SkipBlueprint: true}, 100, 0)
+	require.NotContains(t, list.sql, "composes.request")
+	require.NotContains(t, list.sql, "blueprint_versions")
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// MonthlyComposeUsage sums up the composes of an org for one month,
+// distribution and image type.
+type MonthlyComposeUsage struct {
+	Month        time.Time
+	Distribution string
+	ImageType    string
+	Composes     int
+	// BlobBytes is the size of the compose artifacts kept in object storage
+	BlobBytes int64
+}
+
+const (
+	sqlGetMonthlyComposeUsage = `
+		SELECT date_trunc('month', composes.created_at),
+		       COALESCE(composes.request->>'distribution', ''),
+		       COALESCE(composes.request->'image_requests'->0->>'image_type', ''),
+		       COUNT(*),
+		       COALESCE(SUM(blobs.size), 0)::bigint
+		FROM composes
+		LEFT JOIN (
+			SELECT compose_id, SUM(size) AS size
+			FROM compose_blobs
+			GROUP BY compose_id
+		) blobs ON blobs.compose_id = composes.job_id
+		WHERE composes.org_id = $1
+		AND composes.created_at >= $2 AND composes.created_at < $3
+		GROUP BY 1, 2, 3
+		ORDER BY 1, 2, 3`
+)
+
+// GetMonthlyComposeUsage returns the usage of the org per month between from
+// and to, deleted composes included. Months without composes are left out.
+func (db *dB) GetMonthlyComposeUsage(ctx context.Context, orgId string, from, to time.Time) ([]MonthlyComposeUsage, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, sqlGetMonthlyComposeUsage, orgId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []MonthlyComposeUsage
+	for rows.Next() {
+		var u MonthlyComposeUsage
+		err = rows.Scan(&u.Month, &u.Distribution, &u.ImageType, &u.Composes, &u.BlobBytes)
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
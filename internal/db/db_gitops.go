@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var GitOpsRepositoryNotFoundError = errors.New("gitops repository not found")
+
+// GitOpsRepositoryEntry is a git repository blueprints of an org are synced
+// from.
+type GitOpsRepositoryEntry struct {
+	Id           uuid.UUID
+	OrgId        string
+	Url          string
+	Branch       string
+	Path         string
+	AutoBuild    bool
+	LastCommit   *string
+	LastSyncedAt *time.Time
+	CreatedAt    time.Time
+}
+
+const (
+	sqlInsertGitOpsRepository = `
+		INSERT INTO gitops_repositories(id, org_id, url, branch, path, auto_build)
+		VALUES($1, $2, $3, $4, $5, $6)`
+
+	sqlGetGitOpsRepository = `
+		SELECT id, org_id, url, branch, path, auto_build, last_commit, last_synced_at, created_at
+		FROM gitops_repositories
+		WHERE id = $1 AND org_id = $2`
+
+	sqlGetGitOpsRepositories = `
+		SELECT id, org_id, url, branch, path, auto_build, last_commit, last_synced_at, created_at
+		FROM gitops_repositories
+		WHERE org_id = $1
+		ORDER BY created_at DESC`
+
+	sqlDeleteGitOpsRepository = `
+		DELETE FROM gitops_repositories
+		WHERE id = $1 AND org_id = $2`
+
+	sqlSetGitOpsRepositorySynced = `
+		UPDATE gitops_repositories
+		SET last_commit = $3, last_synced_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND org_id = $2`
+)
+
+func scanGitOpsRepository(row pgx.Row) (*GitOpsRepositoryEntry, error) {
+	var r GitOpsRepositoryEntry
+	err := row.Scan(&r.Id, &r.OrgId, &r.Url, &r.Branch, &r.Path, &r.AutoBuild, &r.LastCommit, &r.LastSyncedAt, &r.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (db *dB) InsertGitOpsRepository(ctx context.Context, id uuid.UUID, orgId, url, branch, path string, autoBuild bool) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, sqlInsertGitOpsRepository, id, orgId, url, branch, path, autoBuild)
+	return err
+}
+
+func (db *dB) GetGitOpsRepository(ctx context.Context, id uuid.UUID, orgId string) (*GitOpsRepositoryEntry, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	repo, err := scanGitOpsRepository(conn.QueryRow(ctx, sqlGetGitOpsRepository, id, orgId))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, GitOpsRepositoryNotFoundError
+	}
+	return repo, err
+}
+
+func (db *dB) GetGitOpsRepositories(ctx context.Context, orgId string) ([]GitOpsRepositoryEntry, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, sqlGetGitOpsRepositories, orgId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var repos []GitOpsRepositoryEntry
+	for rows.Next() {
+		repo, err := scanGitOpsRepository(rows)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, *repo)
+	}
+	return repos, rows.Err()
+}
+
+func (db *dB) DeleteGitOpsRepository(ctx context.Context, id uuid.UUID, orgId string) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, sqlDeleteGitOpsRepository, id, orgId)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return GitOpsRepositoryNotFoundError
+	}
+	return nil
+}
+
+// SetGitOpsRepositorySynced records the commit the blueprints were last
+// synced from.
+func (db *dB) SetGitOpsRepositorySynced(ctx context.Context, id uuid.UUID, orgId, commit string) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, sqlSetGitOpsRepositorySynced, id, orgId, commit)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return GitOpsRepositoryNotFoundError
+	}
+	return nil
+}
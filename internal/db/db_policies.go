@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var OrgPolicyNotFoundError = errors.New("org policy not found")
+var OrgPolicyVersionConflictError = errors.New("org policy has a newer version")
+
+// OrgPolicyEntry holds the guardrails every compose of an org has to satisfy.
+type OrgPolicyEntry struct {
+	OrgId     string
+	Policy    json.RawMessage
+	UpdatedBy string
+	UpdatedAt time.Time
+	Version   int
+}
+
+const (
+	sqlGetOrgPolicy = `
+		SELECT org_id, policy, updated_by, updated_at, version
+		FROM org_policies
+		WHERE org_id = $1`
+
+	sqlSetOrgPolicy = `
+		INSERT INTO org_policies(org_id, policy, updated_by)
+		VALUES($1, $2, $3)
+		ON CONFLICT (org_id) DO UPDATE
+		SET policy = EXCLUDED.policy, updated_by = EXCLUDED.updated_by, updated_at = CURRENT_TIMESTAMP,
+			version = org_policies.version + 1`
+
+	sqlSetOrgPolicyIfVersion = `
+		UPDATE org_policies
+		SET policy = $2, updated_by = $3, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE org_id = $1 AND version = $4`
+
+	sqlDeleteOrgPolicy = `
+		DELETE FROM org_policies
+		WHERE org_id = $1 AND ($2::integer IS NULL OR version = $2)`
+)
+
+func (db *dB) GetOrgPolicy(ctx context.Context, orgId string) (*OrgPolicyEntry, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	var p OrgPolicyEntry
+	err = conn.QueryRow(ctx, sqlGetOrgPolicy, orgId).Scan(&p.OrgId, &p.Policy, &p.UpdatedBy, &p.UpdatedAt, &p.Version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, OrgPolicyNotFoundError
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SetOrgPolicy creates or replaces the policy of the org.
+func (db *dB) SetOrgPolicy(ctx context.Context, orgId, updatedBy string, policy json.RawMessage) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, sqlSetOrgPolicy, orgId, policy, updatedBy)
+	return err
+}
+
+// SetOrgPolicyIfVersion replaces the policy of the org only when it's still at
+// the given version, OrgPolicyVersionConflictError otherwise.
+func (db *dB) SetOrgPolicyIfVersion(ctx context.Context, orgId, updatedBy string, policy json.RawMessage, version int) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, sqlSetOrgPolicyIfVersion, orgId, policy, updatedBy, version)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return OrgPolicyVersionConflictError
+	}
+	return nil
+}
+
+func (db *dB) DeleteOrgPolicy(ctx context.Context, orgId string) error {
+	return db.deleteOrgPolicy(ctx, orgId, nil)
+}
+
+// DeleteOrgPolicyIfVersion removes the policy of the org only when it's still
+// at the given version, OrgPolicyVersionConflictError otherwise.
+func (db *dB) DeleteOrgPolicyIfVersion(ctx context.Context, orgId string, version int) error {
+	return db.deleteOrgPolicy(ctx, orgId, &version)
+}
+
+func (db *dB) deleteOrgPolicy(ctx context.Context, orgId string, version *int) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, sqlDeleteOrgPolicy, orgId, version)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		if version != nil {
+			return OrgPolicyVersionConflictError
+		}
+		return OrgPolicyNotFoundError
+	}
+	return nil
+}
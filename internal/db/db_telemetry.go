@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// ComposeCount is the number of composes of a distribution and image type
+// which ended up with the same status and error code, across all orgs.
+type ComposeCount struct {
+	Distribution string
+	ImageType    string
+	Status       *string
+	ErrorCode    *string
+	Count        int
+}
+
+const sqlCountComposesByKind = `
+	SELECT COALESCE(request->>'distribution', ''), COALESCE(image_type, ''), status, error_code, COUNT(*)
+	FROM composes
+	WHERE created_at >= CURRENT_TIMESTAMP - $1::interval
+	GROUP BY 1, 2, 3, 4
+	ORDER BY 1, 2, 3, 4`
+
+// CountComposesByKind counts the composes created since, deleted ones
+// included, by distribution, image type, status and error code.
+func (db *dB) CountComposesByKind(ctx context.Context, since time.Duration) ([]ComposeCount, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, sqlCountComposesByKind, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []ComposeCount
+	for rows.Next() {
+		var c ComposeCount
+		err = rows.Scan(&c.Distribution, &c.ImageType, &c.Status, &c.ErrorCode, &c.Count)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var UploadGrantNotFoundError = errors.New("upload grant not found")
+
+// UploadGrantEntry is a cloud account an org authorized to share images
+// with, resolved from a source once. Aws grants have an account id, azure
+// ones a tenant and subscription id.
+type UploadGrantEntry struct {
+	OrgId          string
+	Alias          string
+	Provider       string
+	SourceId       string
+	AccountId      *string
+	TenantId       *string
+	SubscriptionId *string
+	CreatedBy      string
+	CreatedAt      time.Time
+}
+
+const (
+	sqlInsertUploadGrant = `
+		INSERT INTO upload_grants(org_id, alias, provider, source_id, account_id, tenant_id, subscription_id, created_by)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	sqlGetUploadGrant = `
+		SELECT org_id, alias, provider, source_id, account_id, tenant_id, subscription_id, created_by, created_at
+		FROM upload_grants
+		WHERE org_id = $1 AND alias = $2`
+
+	sqlGetUploadGrants = `
+		SELECT org_id, alias, provider, source_id, account_id, tenant_id, subscription_id, created_by, created_at
+		FROM upload_grants
+		WHERE org_id = $1
+		ORDER BY alias`
+
+	sqlDeleteUploadGrant = `
+		DELETE FROM upload_grants
+		WHERE org_id = $1 AND alias = $2`
+)
+
+func scanUploadGrant(row pgx.Row) (*UploadGrantEntry, error) {
+	var g UploadGrantEntry
+	err := row.Scan(&g.OrgId, &g.Alias, &g.Provider, &g.SourceId, &g.AccountId, &g.TenantId, &g.SubscriptionId, &g.CreatedBy, &g.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// InsertUploadGrant fails with a unique violation if the org already has a
+// grant with the alias.
+func (db *dB) InsertUploadGrant(ctx context.Context, grant UploadGrantEntry) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, sqlInsertUploadGrant, grant.OrgId, grant.Alias, grant.Provider, grant.SourceId, grant.AccountId, grant.TenantId, grant.SubscriptionId, grant.CreatedBy)
+	return err
+}
+
+func (db *dB) GetUploadGrant(ctx context.Context, orgId, alias string) (*UploadGrantEntry, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	grant, err := scanUploadGrant(conn.QueryRow(ctx, sqlGetUploadGrant, orgId, alias))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, UploadGrantNotFoundError
+	}
+	return grant, err
+}
+
+func (db *dB) GetUploadGrants(ctx context.Context, orgId string) ([]UploadGrantEntry, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, sqlGetUploadGrants, orgId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var grants []UploadGrantEntry
+	for rows.Next() {
+		grant, err := scanUploadGrant(rows)
+		if err != nil {
+			return nil, err
+		}
+		grants = append(grants, *grant)
+	}
+	return grants, rows.Err()
+}
+
+func (db *dB) DeleteUploadGrant(ctx context.Context, orgId, alias string) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, sqlDeleteUploadGrant, orgId, alias)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return UploadGrantNotFoundError
+	}
+	return nil
+}
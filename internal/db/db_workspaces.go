@@ -0,0 +1,298 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var WorkspaceNotFoundError = errors.New("workspace not found")
+var WorkspaceNotEmptyError = errors.New("workspace has blueprints")
+var WorkspaceQuotaExceededError = errors.New("Workspace compose quota exceeded")
+
+const (
+	// WorkspaceViewer members see the blueprints of the workspace
+	WorkspaceViewer = "viewer"
+	// WorkspaceBuilder members also change and compose them
+	WorkspaceBuilder = "builder"
+)
+
+// WorkspaceEntry is a workspace within an org, its blueprints are only
+// visible to its members and the org admins.
+type WorkspaceEntry struct {
+	Id          uuid.UUID
+	OrgId       string
+	Name        string
+	Description string
+	// ComposeQuota limits the composes of the blueprints of the workspace
+	// within the sliding window of the org quota, nil for no limit
+	ComposeQuota *int
+	// Policy applies to the composes of the workspace on top of the policy
+	// of the org, nil for none
+	Policy    json.RawMessage
+	Members   []WorkspaceMember
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+type WorkspaceMember struct {
+	Email string
+	Role  string
+}
+
+const (
+	sqlInsertWorkspace = `
+		INSERT INTO workspaces(id, org_id, name, description, compose_quota, policy, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at`
+
+	sqlInsertWorkspaceMember = `
+		INSERT INTO workspace_members(workspace_id, email, role)
+		VALUES ($1, $2, $3)`
+
+	sqlDeleteWorkspaceMembers = `
+		DELETE FROM workspace_members
+		WHERE workspace_id = $1`
+
+	sqlGetWorkspace = `
+		SELECT id, org_id, name, description, compose_quota, policy, created_by, created_at
+		FROM workspaces
+		WHERE id = $1 AND org_id = $2`
+
+	sqlGetWorkspaces = `
+		SELECT id, org_id, name, description, compose_quota, policy, created_by, created_at
+		FROM workspaces
+		WHERE org_id = $1
+			AND ($2::text = '' OR id IN (SELECT workspace_id FROM workspace_members WHERE email = $2))
+		ORDER BY name`
+
+	sqlGetWorkspaceMembers = `
+		SELECT workspace_id, email, role
+		FROM workspace_members
+		WHERE workspace_id = ANY($1)
+		ORDER BY email`
+
+	sqlUpdateWorkspace = `
+		UPDATE workspaces
+		SET name = $3, description = $4, compose_quota = $5, policy = $6
+		WHERE id = $1 AND org_id = $2`
+
+	sqlDeleteWorkspace = `
+		DELETE FROM workspaces
+		WHERE id = $1 AND org_id = $2
+			AND NOT EXISTS (SELECT 1 FROM blueprints WHERE workspace_id = $1 AND deleted = FALSE)`
+
+	sqlWorkspaceExists = `
+		SELECT EXISTS (SELECT 1 FROM workspaces WHERE id = $1 AND org_id = $2)`
+
+	sqlGetWorkspaceRole = `
+		SELECT workspace_members.role
+		FROM workspaces LEFT JOIN workspace_members
+			ON workspace_members.workspace_id = workspaces.id AND workspace_members.email = $3
+		WHERE workspaces.id = $1 AND workspaces.org_id = $2`
+
+	sqlCountWorkspaceComposesSince = `
+		SELECT COUNT(*)
+		FROM composes INNER JOIN blueprint_versions ON composes.blueprint_version_id = blueprint_versions.id
+		INNER JOIN blueprints ON blueprint_versions.blueprint_id = blueprints.id
+		WHERE composes.org_id = $1 AND blueprints.workspace_id = $2
+			AND composes.created_at >= CURRENT_TIMESTAMP - $3::interval`
+)
+
+func scanWorkspace(row pgx.Row) (*WorkspaceEntry, error) {
+	var w WorkspaceEntry
+	err := row.Scan(&w.Id, &w.OrgId, &w.Name, &w.Description, &w.ComposeQuota, &w.Policy, &w.CreatedBy, &w.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func insertWorkspaceMembers(ctx context.Context, tx pgx.Tx, workspace *WorkspaceEntry) error {
+	for _, m := range workspace.Members {
+		_, err := tx.Exec(ctx, sqlInsertWorkspaceMember, workspace.Id, m.Email, m.Role)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InsertWorkspace stores the workspace with its members, the creation time is
+// set on the entry.
+func (db *dB) InsertWorkspace(ctx context.Context, workspace *WorkspaceEntry) error {
+	return db.withTransaction(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, sqlInsertWorkspace, workspace.Id, workspace.OrgId, workspace.Name, workspace.Description, workspace.ComposeQuota, workspace.Policy, workspace.CreatedBy).Scan(&workspace.CreatedAt)
+		if err != nil {
+			return err
+		}
+		return insertWorkspaceMembers(ctx, tx, workspace)
+	})
+}
+
+func (db *dB) GetWorkspace(ctx context.Context, id uuid.UUID, orgId string) (*WorkspaceEntry, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	workspace, err := scanWorkspace(conn.QueryRow(ctx, sqlGetWorkspace, id, orgId))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, WorkspaceNotFoundError
+	}
+	if err != nil {
+		return nil, err
+	}
+	workspaces := []WorkspaceEntry{*workspace}
+	err = getWorkspaceMembers(ctx, conn, workspaces)
+	if err != nil {
+		return nil, err
+	}
+	return &workspaces[0], nil
+}
+
+// GetWorkspaces returns the workspaces of the org sorted by name, only the
+// ones the member belongs to unless member is empty.
+func (db *dB) GetWorkspaces(ctx context.Context, orgId, member string) ([]WorkspaceEntry, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, sqlGetWorkspaces, orgId, member)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var workspaces []WorkspaceEntry
+	for rows.Next() {
+		workspace, err := scanWorkspace(rows)
+		if err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, *workspace)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	err = getWorkspaceMembers(ctx, conn, workspaces)
+	if err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+func getWorkspaceMembers(ctx context.Context, conn *pgxpool.Conn, workspaces []WorkspaceEntry) error {
+	if len(workspaces) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(workspaces))
+	byId := make(map[uuid.UUID]*WorkspaceEntry, len(workspaces))
+	for i := range workspaces {
+		ids = append(ids, workspaces[i].Id)
+		byId[workspaces[i].Id] = &workspaces[i]
+	}
+
+	rows, err := conn.Query(ctx, sqlGetWorkspaceMembers, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id uuid.UUID
+		var m WorkspaceMember
+		err = rows.Scan(&id, &m.Email, &m.Role)
+		if err != nil {
+			return err
+		}
+		byId[id].Members = append(byId[id].Members, m)
+	}
+	return rows.Err()
+}
+
+// UpdateWorkspace replaces the workspace, its members included.
+func (db *dB) UpdateWorkspace(ctx context.Context, workspace *WorkspaceEntry) error {
+	return db.withTransaction(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, sqlUpdateWorkspace, workspace.Id, workspace.OrgId, workspace.Name, workspace.Description, workspace.ComposeQuota, workspace.Policy)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return WorkspaceNotFoundError
+		}
+		_, err = tx.Exec(ctx, sqlDeleteWorkspaceMembers, workspace.Id)
+		if err != nil {
+			return err
+		}
+		return insertWorkspaceMembers(ctx, tx, workspace)
+	})
+}
+
+// DeleteWorkspace deletes the workspace, WorkspaceNotEmptyError while it
+// still has blueprints.
+func (db *dB) DeleteWorkspace(ctx context.Context, id uuid.UUID, orgId string) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, sqlDeleteWorkspace, id, orgId)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+	var exists bool
+	err = conn.QueryRow(ctx, sqlWorkspaceExists, id, orgId).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return WorkspaceNotEmptyError
+	}
+	return WorkspaceNotFoundError
+}
+
+// GetWorkspaceRole returns the role of the member in the workspace, empty if
+// they aren't a member.
+func (db *dB) GetWorkspaceRole(ctx context.Context, id uuid.UUID, orgId, email string) (string, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Release()
+
+	var role *string
+	err = conn.QueryRow(ctx, sqlGetWorkspaceRole, id, orgId, email).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", WorkspaceNotFoundError
+	}
+	if err != nil || role == nil {
+		return "", err
+	}
+	return *role, nil
+}
+
+// CountWorkspaceComposesSince counts the composes of the blueprints of the
+// workspace, deleted ones included like for the quota of the org.
+func (db *dB) CountWorkspaceComposesSince(ctx context.Context, orgId string, id uuid.UUID, since time.Duration) (int, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	var count int
+	err = conn.QueryRow(ctx, sqlCountWorkspaceComposesSince, orgId, id, since).Scan(&count)
+	return count, err
+}
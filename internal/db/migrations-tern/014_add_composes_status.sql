@@ -0,0 +1,4 @@
+ALTER TABLE composes ADD status varchar;
+ALTER TABLE composes ADD error_code varchar;
+
+CREATE INDEX ON composes(created_at) WHERE status IS NULL OR status NOT IN ('success', 'failure');
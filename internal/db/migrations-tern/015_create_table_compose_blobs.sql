@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS compose_blobs(
+       compose_id uuid NOT NULL REFERENCES composes(job_id) ON DELETE CASCADE,
+       kind varchar NOT NULL,
+       storage_key varchar NOT NULL,
+       size bigint NOT NULL,
+       created_at timestamp NOT NULL DEFAULT current_timestamp,
+
+       PRIMARY KEY (compose_id, kind)
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS gitops_repositories(
+       id uuid PRIMARY KEY,
+       org_id varchar NOT NULL,
+       url varchar NOT NULL,
+       branch varchar NOT NULL,
+       path varchar NOT NULL,
+       auto_build boolean NOT NULL DEFAULT FALSE,
+       last_commit varchar,
+       last_synced_at timestamp,
+       created_at timestamp NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX ON gitops_repositories(org_id);
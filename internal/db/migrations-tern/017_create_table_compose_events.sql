@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS compose_events(
+       id bigserial PRIMARY KEY,
+       org_id varchar NOT NULL,
+       compose_id uuid NOT NULL,
+       type varchar NOT NULL,
+       image_type varchar,
+       status varchar,
+       error_code varchar,
+       created_at timestamp NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX ON compose_events(org_id, id);
+CREATE INDEX ON compose_events(created_at);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS org_policies(
+       org_id varchar PRIMARY KEY,
+       policy jsonb NOT NULL,
+       updated_by varchar NOT NULL,
+       updated_at timestamp NOT NULL DEFAULT current_timestamp
+);
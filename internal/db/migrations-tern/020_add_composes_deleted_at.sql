@@ -0,0 +1 @@
+ALTER TABLE composes ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
@@ -0,0 +1 @@
+ALTER TABLE composes ADD COLUMN IF NOT EXISTS parent_compose_id uuid NULL REFERENCES composes (job_id) ON DELETE SET NULL;
@@ -0,0 +1 @@
+ALTER TABLE org_policies ADD COLUMN IF NOT EXISTS version int NOT NULL DEFAULT 1;
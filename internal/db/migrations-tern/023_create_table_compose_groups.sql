@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS compose_groups(
+       id uuid PRIMARY KEY,
+       org_id varchar NOT NULL,
+       created_at timestamp NOT NULL DEFAULT current_timestamp
+);
+
+ALTER TABLE composes ADD COLUMN IF NOT EXISTS group_id uuid NULL REFERENCES compose_groups (id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS composes_group_id_idx ON composes (group_id);
@@ -0,0 +1,10 @@
+-- The listings filter on the image type, as a column the index covers it and
+-- the counts don't have to read the requests.
+ALTER TABLE composes ADD COLUMN IF NOT EXISTS image_type varchar GENERATED ALWAYS AS (request->'image_requests'->0->>'image_type') STORED;
+
+-- Keyset pagination walks this index from the cursor on, the counts are
+-- answered from it alone.
+CREATE INDEX IF NOT EXISTS composes_org_listing_idx ON composes (org_id, created_at DESC, job_id DESC) INCLUDE (deleted, image_type, status);
+
+-- superseded by the listing index
+DROP INDEX IF EXISTS composes_org_id_idx;
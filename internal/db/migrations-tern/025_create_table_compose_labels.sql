@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS compose_labels(
+       compose_id uuid NOT NULL REFERENCES composes (job_id) ON DELETE CASCADE,
+       key varchar NOT NULL,
+       value varchar NOT NULL,
+       PRIMARY KEY (compose_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS compose_labels_key_value_idx ON compose_labels (key, value);
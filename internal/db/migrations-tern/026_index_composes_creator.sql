@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS composes_org_email_idx ON composes (org_id, email);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS compose_metadata(
+       compose_id uuid PRIMARY KEY REFERENCES composes(job_id) ON DELETE CASCADE,
+       metadata jsonb NOT NULL,
+       created_at timestamp NOT NULL DEFAULT current_timestamp
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS upload_grants(
+       org_id varchar NOT NULL,
+       alias varchar NOT NULL,
+       provider varchar NOT NULL,
+       source_id varchar NOT NULL,
+       account_id varchar,
+       tenant_id varchar,
+       subscription_id varchar,
+       created_by varchar NOT NULL,
+       created_at timestamp NOT NULL DEFAULT current_timestamp,
+       PRIMARY KEY (org_id, alias)
+);
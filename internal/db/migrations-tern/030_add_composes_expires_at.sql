@@ -0,0 +1,2 @@
+ALTER TABLE composes ADD COLUMN IF NOT EXISTS expires_at timestamptz;
+CREATE INDEX IF NOT EXISTS composes_expires_at_idx ON composes (expires_at) WHERE deleted = FALSE AND expires_at IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE clones ADD COLUMN IF NOT EXISTS parent_id uuid REFERENCES clones(id) ON DELETE CASCADE;
@@ -0,0 +1,2 @@
+ALTER TABLE clones ADD COLUMN IF NOT EXISTS status varchar;
+ALTER TABLE clones ADD COLUMN IF NOT EXISTS upload_status jsonb;
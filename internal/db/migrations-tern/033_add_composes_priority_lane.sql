@@ -0,0 +1 @@
+ALTER TABLE composes ADD COLUMN IF NOT EXISTS priority_lane boolean NOT NULL DEFAULT FALSE;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS compose_pipelines(
+       id uuid PRIMARY KEY,
+       org_id varchar NOT NULL,
+       state varchar NOT NULL,
+       commit_compose_id uuid NOT NULL REFERENCES composes (job_id) ON DELETE CASCADE,
+       installer_compose_id uuid NULL REFERENCES composes (job_id) ON DELETE SET NULL,
+       installer_request jsonb NOT NULL,
+       error varchar NULL,
+       created_at timestamp NOT NULL DEFAULT current_timestamp
+);
+
+ALTER TABLE composes ADD COLUMN IF NOT EXISTS pipeline_id uuid NULL REFERENCES compose_pipelines (id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS composes_pipeline_id_idx ON composes (pipeline_id);
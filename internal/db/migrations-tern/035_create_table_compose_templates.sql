@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS compose_templates(
+       id uuid PRIMARY KEY,
+       org_id varchar NOT NULL,
+       name varchar NOT NULL,
+       version integer NOT NULL,
+       description varchar NOT NULL DEFAULT '',
+       request jsonb NOT NULL,
+       created_by varchar NOT NULL,
+       created_at timestamp NOT NULL DEFAULT current_timestamp,
+       UNIQUE (org_id, name, version)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS compose_drafts(
+       id uuid PRIMARY KEY,
+       org_id varchar NOT NULL,
+       email varchar NOT NULL,
+       name varchar NOT NULL DEFAULT '',
+       request jsonb NOT NULL,
+       created_at timestamp NOT NULL DEFAULT current_timestamp,
+       updated_at timestamp NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX ON compose_drafts(org_id, email);
+CREATE INDEX ON compose_drafts(updated_at);
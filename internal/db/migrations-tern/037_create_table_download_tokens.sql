@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS download_tokens(
+       id uuid PRIMARY KEY,
+       org_id varchar NOT NULL,
+       name varchar NOT NULL,
+       token_hash varchar NOT NULL UNIQUE,
+       created_by varchar NOT NULL,
+       created_at timestamp NOT NULL DEFAULT current_timestamp,
+       last_used_at timestamp NULL
+);
+
+CREATE INDEX ON download_tokens(org_id);
@@ -0,0 +1,8 @@
+ALTER TABLE blueprints
+    ADD COLUMN created_by varchar NULL,
+    ADD COLUMN updated_by varchar NULL,
+    ADD COLUMN private boolean NOT NULL DEFAULT FALSE;
+
+-- blueprints created before are shared with their org, nobody owns them
+ALTER TABLE blueprints
+    ADD CONSTRAINT blueprints_private_creator CHECK (NOT private OR created_by IS NOT NULL);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS compose_hook_runs(
+       compose_id uuid NOT NULL REFERENCES composes(job_id) ON DELETE CASCADE,
+       hook varchar NOT NULL,
+       status varchar NOT NULL,
+       attempts integer NOT NULL,
+       error varchar NULL,
+       updated_at timestamp NOT NULL DEFAULT current_timestamp,
+       PRIMARY KEY (compose_id, hook)
+);
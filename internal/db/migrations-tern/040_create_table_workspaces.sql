@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS workspaces(
+       id uuid PRIMARY KEY,
+       org_id varchar NOT NULL,
+       name varchar NOT NULL,
+       description varchar NOT NULL,
+       compose_quota integer NULL,
+       policy jsonb NULL,
+       created_by varchar NOT NULL,
+       created_at timestamp NOT NULL DEFAULT current_timestamp,
+       UNIQUE (org_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members(
+       workspace_id uuid NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+       email varchar NOT NULL,
+       role varchar NOT NULL,
+       PRIMARY KEY (workspace_id, email)
+);
+
+CREATE INDEX ON workspace_members(email);
+
+-- blueprints without a workspace belong to the whole org, workspaces with
+-- blueprints left aren't deleted
+ALTER TABLE blueprints
+    ADD COLUMN workspace_id uuid NULL REFERENCES workspaces(id) ON DELETE SET NULL;
+
+CREATE INDEX ON blueprints(workspace_id);
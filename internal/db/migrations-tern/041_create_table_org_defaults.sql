@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS org_defaults(
+       org_id varchar PRIMARY KEY,
+       defaults jsonb NOT NULL,
+       updated_by varchar NOT NULL,
+       updated_at timestamp NOT NULL DEFAULT current_timestamp
+);
+
+-- what the defaults of the org merged into the compose, NULL when nothing was
+ALTER TABLE composes ADD COLUMN IF NOT EXISTS injected_defaults jsonb NULL;
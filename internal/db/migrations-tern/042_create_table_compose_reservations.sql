@@ -0,0 +1,10 @@
+-- slots of the quota held while the composes are submitted to composer, they
+-- count against the quota like recorded composes
+CREATE TABLE IF NOT EXISTS compose_reservations(
+       id uuid PRIMARY KEY,
+       org_id varchar NOT NULL,
+       priority_lane boolean NOT NULL DEFAULT FALSE,
+       created_at timestamp NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX ON compose_reservations(org_id);
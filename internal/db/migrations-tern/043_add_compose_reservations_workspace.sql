@@ -0,0 +1,3 @@
+-- the reservations of the composes of the blueprints of a workspace count
+-- against the quota of the workspace
+ALTER TABLE compose_reservations ADD COLUMN IF NOT EXISTS workspace_id uuid NULL;
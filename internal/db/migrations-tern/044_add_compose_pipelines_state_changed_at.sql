@@ -0,0 +1,3 @@
+-- pipelines left in a state for too long are found by when they got into it
+ALTER TABLE compose_pipelines ADD COLUMN IF NOT EXISTS state_changed_at timestamp NOT NULL DEFAULT current_timestamp;
+CREATE INDEX IF NOT EXISTS compose_pipelines_state_idx ON compose_pipelines (state, state_changed_at);
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PoolConfig tunes the connection pool, zero values keep the defaults of pgx.
+type PoolConfig struct {
+	MaxConns        int32
+	MaxConnIdleTime time.Duration
+	// StatementCacheMode is prepare, describe or none. Behind a connection
+	// pooler in transaction mode, like pgbouncer, consecutive statements may
+	// run on different server connections and the statements prepared on one
+	// of them are missing on the others. Only describe and none are safe
+	// there, they don't keep prepared statements around.
+	StatementCacheMode string
+}
+
+var statementCacheModes = map[string]pgx.QueryExecMode{
+	"prepare":  pgx.QueryExecModeCacheStatement,
+	"describe": pgx.QueryExecModeCacheDescribe,
+	"none":     pgx.QueryExecModeDescribeExec,
+}
+
+// ParsePoolConfig parses the settings of the pool as they are configured,
+// empty ones keep the defaults.
+func ParsePoolConfig(maxConns, maxConnIdleTime, statementCacheMode string) (PoolConfig, error) {
+	var pc PoolConfig
+	if maxConns != "" {
+		n, err := strconv.ParseInt(maxConns, 10, 32)
+		if err != nil || n < 1 {
+			return PoolConfig{}, fmt.Errorf("invalid maximum of pool connections %q", maxConns)
+		}
+		pc.MaxConns = int32(n)
+	}
+	if maxConnIdleTime != "" {
+		d, err := time.ParseDuration(maxConnIdleTime)
+		if err != nil {
+			return PoolConfig{}, fmt.Errorf("invalid idle time of pool connections: %w", err)
+		}
+		pc.MaxConnIdleTime = d
+	}
+	if statementCacheMode != "" {
+		if _, ok := statementCacheModes[statementCacheMode]; !ok {
+			return PoolConfig{}, fmt.Errorf("unknown statement cache mode %q, expected prepare, describe or none", statementCacheMode)
+		}
+		pc.StatementCacheMode = statementCacheMode
+	}
+	return pc, nil
+}
+
+func (pc PoolConfig) apply(dbConfig *pgxpool.Config) {
+	if pc.MaxConns > 0 {
+		dbConfig.MaxConns = pc.MaxConns
+	}
+	if pc.MaxConnIdleTime > 0 {
+		dbConfig.MaxConnIdleTime = pc.MaxConnIdleTime
+	}
+	if mode, ok := statementCacheModes[pc.StatementCacheMode]; ok {
+		dbConfig.ConnConfig.DefaultQueryExecMode = mode
+	}
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePoolConfig(t *testing.T) {
+	pc, err := ParsePoolConfig("", "", "")
+	require.NoError(t, err)
+	require.Equal(t, PoolConfig{}, pc)
+
+	pc, err = ParsePoolConfig("20", "5m", "describe")
+	require.NoError(t, err)
+	require.Equal(t, PoolConfig{MaxConns: 20, MaxConnIdleTime: 5 * time.Minute, StatementCacheMode: "describe"}, pc)
+
+	for _, args := range [][3]string{
+		{"0", "", ""},
+		{"many", "", ""},
+		{"", "often", ""},
+		{"", "", "session"},
+	} {
+		_, err = ParsePoolConfig(args[0], args[1], args[2])
+		require.Error(t, err, args)
+	}
+}
+
+func TestPoolConfigApply(t *testing.T) {
+	dbConfig, err := pgxpool.ParseConfig("postgres://postgres@localhost:5432/imagebuilder")
+	require.NoError(t, err)
+	defaults := *dbConfig
+	PoolConfig{}.apply(dbConfig)
+	require.Equal(t, defaults.MaxConns, dbConfig.MaxConns)
+	require.Equal(t, defaults.MaxConnIdleTime, dbConfig.MaxConnIdleTime)
+	require.Equal(t, pgx.QueryExecModeCacheStatement, dbConfig.ConnConfig.DefaultQueryExecMode)
+
+	PoolConfig{MaxConns: 3, MaxConnIdleTime: time.Minute, StatementCacheMode: "none"}.apply(dbConfig)
+	require.Equal(t, int32(3), dbConfig.MaxConns)
+	require.Equal(t, time.Minute, dbConfig.MaxConnIdleTime)
+	require.Equal(t, pgx.QueryExecModeDescribeExec, dbConfig.ConnConfig.DefaultQueryExecMode)
+}
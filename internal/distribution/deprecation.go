@@ -0,0 +1,59 @@
+package distribution
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Deprecation marks a distribution or an image type as being retired. It can
+// still be built until the sunset, clients are told to migrate in the
+// meantime.
+type Deprecation struct {
+	Since  time.Time `json:"since"`
+	Sunset time.Time `json:"sunset"`
+	// Message tells users what to migrate to
+	Message string `json:"message"`
+}
+
+// ImageTypeDeprecation returns the deprecation of an image type on this
+// architecture, aliases share the deprecation of their image type.
+func (arch Architecture) ImageTypeDeprecation(imageType string) *Deprecation {
+	d, ok := arch.DeprecatedImageTypes[CanonicalImageType(imageType)]
+	if !ok {
+		return nil
+	}
+	return &d
+}
+
+func (d Deprecation) validate() error {
+	if d.Since.IsZero() || d.Sunset.IsZero() {
+		return fmt.Errorf("deprecation needs both since and sunset")
+	}
+	if !d.Since.Before(d.Sunset) {
+		return fmt.Errorf("deprecation since %s isn't before its sunset %s", d.Since, d.Sunset)
+	}
+	return nil
+}
+
+func (dist DistributionFile) validateDeprecations() error {
+	if dist.Distribution.Deprecation != nil {
+		if err := dist.Distribution.Deprecation.validate(); err != nil {
+			return fmt.Errorf("%s: %w", dist.Distribution.Name, err)
+		}
+	}
+	for _, arch := range []*Architecture{dist.ArchX86, dist.Aarch64} {
+		if arch == nil {
+			continue
+		}
+		for it, d := range arch.DeprecatedImageTypes {
+			if !slices.ContainsFunc(arch.ImageTypes, func(name string) bool { return CanonicalImageType(name) == it }) {
+				return fmt.Errorf("%s: deprecated image type %s isn't one of its image types", dist.Distribution.Name, it)
+			}
+			if err := d.validate(); err != nil {
+				return fmt.Errorf("%s: %s: %w", dist.Distribution.Name, it, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package distribution
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageTypeDeprecation(t *testing.T) {
+	dep := Deprecation{
+		Since:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Sunset:  time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		Message: "use vsphere-ova",
+	}
+	arch := Architecture{
+		ImageTypes:           []string{"aws", "vsphere"},
+		DeprecatedImageTypes: map[string]Deprecation{"vsphere": dep},
+	}
+	require.Equal(t, &dep, arch.ImageTypeDeprecation("vsphere"))
+	require.Equal(t, &dep, arch.ImageTypeDeprecation("vmdk"))
+	require.Nil(t, arch.ImageTypeDeprecation("aws"))
+}
+
+func TestValidateDeprecations(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	dist := func(dep *Deprecation, imageTypes map[string]Deprecation) DistributionFile {
+		return DistributionFile{
+			Distribution: DistributionItem{Name: "rhel-87", Deprecation: dep},
+			ArchX86: &Architecture{
+				ImageTypes:           []string{"aws", "rhel-edge-commit"},
+				DeprecatedImageTypes: imageTypes,
+			},
+		}
+	}
+
+	require.NoError(t, dist(nil, nil).validateDeprecations())
+	require.NoError(t, dist(&Deprecation{Since: since, Sunset: sunset}, map[string]Deprecation{
+		"edge-commit": {Since: since, Sunset: sunset},
+	}).validateDeprecations())
+
+	require.Error(t, dist(&Deprecation{Since: since}, nil).validateDeprecations())
+	require.Error(t, dist(&Deprecation{Since: sunset, Sunset: since}, nil).validateDeprecations())
+	require.Error(t, dist(nil, map[string]Deprecation{
+		"gcp": {Since: since, Sunset: sunset},
+	}).validateDeprecations())
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -26,6 +27,8 @@ type DistributionItem struct {
 	// that are not visible in the UI and their package lists are huge.
 	// This is very useful for Fedora.
 	NoPackageList bool `json:"no_package_list"`
+
+	Deprecation *Deprecation `json:"deprecation,omitempty"`
 }
 
 type DistributionFile struct {
@@ -34,14 +37,22 @@ type DistributionFile struct {
 	ArchX86          *Architecture    `json:"x86_64,omitempty"`
 	Aarch64          *Architecture    `json:"aarch64,omitempty"`
 	OscapName        string           `json:"oscap_name"`
+	// PackageConflicts are the packages of the distribution which can't be
+	// installed together, checked before composer depsolves them
+	PackageConflicts []PackageConflict `json:"package_conflicts,omitempty"`
 }
 
 type Architecture struct {
 	ImageTypes   []string     `json:"image_types"`
 	Repositories []Repository `json:"repositories"`
+	// keyed by the canonical name of the image type
+	DeprecatedImageTypes map[string]Deprecation `json:"deprecated_image_types,omitempty"`
 
 	// not part of distro.json, loaded dynamically in ReadDistribution
 	Packages map[string][]Package
+	// comps groups by repository, only repositories with a groups file
+	// have an entry
+	Groups map[string][]PackageGroup
 }
 
 type Repository struct {
@@ -59,6 +70,14 @@ type Package struct {
 	Summary string `json:"summary"`
 }
 
+// PackageGroup is a comps group, it's installed by listing its id or name
+// prefixed with @ among the packages.
+type PackageGroup struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
 type PackagesFile struct {
 	Data []Package `json:"data"`
 }
@@ -117,6 +136,24 @@ func (arch Architecture) FindPackages(search string) []Package {
 	return pkgs
 }
 
+// HasGroups tells if there is group metadata for the architecture, without it
+// groups can't be validated.
+func (arch Architecture) HasGroups() bool {
+	return len(arch.Groups) > 0
+}
+
+// FindGroup looks a group up by its id or name, ignoring case like dnf does.
+func (arch Architecture) FindGroup(name string) *PackageGroup {
+	for _, r := range arch.Repositories {
+		for i, g := range arch.Groups[r.Id] {
+			if strings.EqualFold(g.Id, name) || strings.EqualFold(g.Name, name) {
+				return &arch.Groups[r.Id][i]
+			}
+		}
+	}
+	return nil
+}
+
 func (arch Architecture) validate() error {
 	for _, r := range arch.Repositories {
 		sourceSet := false
@@ -193,6 +230,14 @@ func readDistribution(distsDir, distroIn string) (d DistributionFile, err error)
 		return
 	}
 
+	if err = d.validateDeprecations(); err != nil {
+		return
+	}
+
+	if err = d.validatePackageConflicts(); err != nil {
+		return
+	}
+
 	if !d.Distribution.NoPackageList {
 		var x86Pkgs map[string][]Package
 		x86Pkgs, err = readPackages(d.ArchX86.Repositories, "x86_64", distsDir, distroIn)
@@ -209,6 +254,16 @@ func readDistribution(distsDir, distroIn string) (d DistributionFile, err error)
 		d.Aarch64.Packages = aarch64
 	}
 
+	for archName, arch := range map[string]*Architecture{"x86_64": d.ArchX86, "aarch64": d.Aarch64} {
+		if arch == nil {
+			continue
+		}
+		arch.Groups, err = readGroups(arch.Repositories, archName, distsDir, distroIn)
+		if err != nil {
+			return
+		}
+	}
+
 	return
 }
 
@@ -235,3 +290,34 @@ func readPackages(repos []Repository, archName, distsDir, distroIn string) (map[
 
 	return pkgs, nil
 }
+
+// readGroups reads the comps groups of the repositories, the groups files are
+// optional. Without any the result is nil.
+func readGroups(repos []Repository, archName, distsDir, distroIn string) (map[string][]PackageGroup, error) {
+	var groups map[string][]PackageGroup
+	for _, r := range repos {
+		p, err := filepath.EvalSymlinks(filepath.Join(distsDir, distroIn))
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(filepath.Clean(filepath.Join(distsDir, distroIn, fmt.Sprintf("%s-%s-%s-groups.json", filepath.Base(p), archName, r.Id))))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var gs []PackageGroup
+		err = json.Unmarshal(data, &gs)
+		if err != nil {
+			return nil, err
+		}
+		if groups == nil {
+			groups = make(map[string][]PackageGroup)
+		}
+		groups[r.Id] = gs
+	}
+
+	return groups, nil
+}
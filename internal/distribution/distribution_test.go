@@ -1,6 +1,8 @@
 package distribution
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -230,3 +232,44 @@ func TestArchitecture_validate(t *testing.T) {
 		})
 	}
 }
+
+func TestArchitecture_FindGroup(t *testing.T) {
+	distsDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(distsDir, "test-distro"), 0700))
+	distro := `{
+  "module_platform_id": "platform:el9",
+  "distribution": {"name": "test-distro", "description": "Test distro"},
+  "x86_64": {
+    "image_types": ["guest-image"],
+    "repositories": [
+      {"id": "baseos", "baseurl": "https://example.com/baseos", "rhsm": false},
+      {"id": "appstream", "baseurl": "https://example.com/appstream", "rhsm": false}
+    ]
+  },
+  "aarch64": {
+    "image_types": ["guest-image"],
+    "repositories": [{"id": "baseos", "baseurl": "https://example.com/baseos", "rhsm": false}]
+  }
+}`
+	files := map[string]string{
+		"test-distro.json":                           distro,
+		"test-distro-x86_64-baseos-packages.json":    "[]",
+		"test-distro-x86_64-appstream-packages.json": "[]",
+		"test-distro-aarch64-baseos-packages.json":   "[]",
+		"test-distro-x86_64-appstream-groups.json":   `[{"id": "development", "name": "Development Tools", "description": "A basic development environment."}]`,
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(distsDir, "test-distro", name), []byte(content), 0600))
+	}
+
+	d, err := readDistribution(distsDir, "test-distro")
+	require.NoError(t, err)
+
+	require.True(t, d.ArchX86.HasGroups())
+	require.Equal(t, "development", d.ArchX86.FindGroup("Development Tools").Id)
+	require.Equal(t, "development", d.ArchX86.FindGroup("DEVELOPMENT").Id)
+	require.Nil(t, d.ArchX86.FindGroup("core"))
+
+	require.False(t, d.Aarch64.HasGroups())
+	require.Nil(t, d.Aarch64.FindGroup("development"))
+}
@@ -2,6 +2,11 @@ package distribution
 
 import (
 	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/osbuild/image-builder/internal/common"
 )
 
 // AllDistroRegistry holds all distribution that image-builder knows
@@ -21,13 +26,25 @@ func LoadDistroRegistry(distsDir string) (*AllDistroRegistry, error) {
 		distros: make(map[string]*DistributionFile),
 	}
 
-	for _, f := range files {
-		d, err := readDistribution(distsDir, f.Name())
-		if err != nil {
-			return nil, err
-		}
+	// the distributions are read in parallel, the error of the first one in
+	// the directory is returned
+	distros := make([]DistributionFile, len(files))
+	errs := make([]error, len(files))
+	var wg sync.WaitGroup
+	for i, f := range files {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			distros[i], errs[i] = readDistribution(distsDir, name)
+		}(i, f.Name())
+	}
+	wg.Wait()
 
-		dr.distros[f.Name()] = &d
+	for i, f := range files {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		dr.distros[f.Name()] = &distros[i]
 	}
 
 	return dr, nil
@@ -84,3 +101,55 @@ func (dr DistroRegistry) Get(name string) (*DistributionFile, error) {
 
 	return df, nil
 }
+
+// MirrorRepositories points the repositories of all distributions at mirrors.
+// Baseurls and metalinks starting with a key of mirrors get that prefix
+// replaced by its value.
+func (adr *AllDistroRegistry) MirrorRepositories(mirrors map[string]string) {
+	mirror := func(u *string) *string {
+		if u == nil {
+			return nil
+		}
+		for prefix, replacement := range mirrors {
+			if strings.HasPrefix(*u, prefix) {
+				return common.ToPtr(replacement + strings.TrimPrefix(*u, prefix))
+			}
+		}
+		return u
+	}
+	for _, d := range adr.distros {
+		for _, arch := range []*Architecture{d.ArchX86, d.Aarch64} {
+			if arch == nil {
+				continue
+			}
+			for i := range arch.Repositories {
+				arch.Repositories[i].Baseurl = mirror(arch.Repositories[i].Baseurl)
+				arch.Repositories[i].Metalink = mirror(arch.Repositories[i].Metalink)
+			}
+		}
+	}
+}
+
+// RepositoryURLs returns the baseurls and metalinks of the repositories of
+// all distributions.
+func (adr *AllDistroRegistry) RepositoryURLs() []string {
+	seen := map[string]bool{}
+	var urls []string
+	for _, d := range adr.distros {
+		for _, arch := range []*Architecture{d.ArchX86, d.Aarch64} {
+			if arch == nil {
+				continue
+			}
+			for _, r := range arch.Repositories {
+				for _, u := range []*string{r.Baseurl, r.Metalink} {
+					if u != nil && *u != "" && !seen[*u] {
+						seen[*u] = true
+						urls = append(urls, *u)
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(urls)
+	return urls
+}
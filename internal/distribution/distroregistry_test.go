@@ -1,6 +1,8 @@
 package distribution
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -28,6 +30,17 @@ func TestDistroRegistry_List(t *testing.T) {
 	}
 }
 
+func TestLoadDistroRegistry_Broken(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"broken-a", "broken-b"} {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, d), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, d, d+".json"), []byte(d[len(d)-1:]), 0o600))
+	}
+	// every distribution is read, the error is the one of the first
+	_, err := LoadDistroRegistry(dir)
+	require.ErrorContains(t, err, "invalid character 'a'")
+}
+
 func TestDistroRegistry_Get(t *testing.T) {
 	dr, err := LoadDistroRegistry("../../distributions")
 	require.NoError(t, err)
@@ -48,6 +61,12 @@ func TestDistroRegistry_Get(t *testing.T) {
 			Name:             "rhel-94",
 			RestrictedAccess: false,
 		},
+		PackageConflicts: []PackageConflict{
+			{Packages: []string{"curl", "curl-minimal"}, Reason: "curl-minimal only supports the common protocols"},
+			{Packages: []string{"libcurl", "libcurl-minimal"}, Reason: "libcurl-minimal only supports the common protocols"},
+			{Packages: []string{"coreutils", "coreutils-single"}, Reason: "coreutils-single is the single binary build for minimal images"},
+			{Packages: []string{"podman-docker", "docker-ce"}, Reason: "podman-docker replaces the docker command with podman"},
+		},
 		ArchX86: &Architecture{
 			ImageTypes: []string{"aws", "gcp", "azure", "rhel-edge-commit", "rhel-edge-installer", "edge-commit", "edge-installer", "guest-image", "image-installer", "oci", "vsphere", "vsphere-ova", "wsl"},
 			Repositories: []Repository{
@@ -112,3 +131,21 @@ func TestDistroRegistry_Get(t *testing.T) {
 	require.Nil(t, result)
 	require.Equal(t, DistributionNotFound, err)
 }
+
+func TestAllDistroRegistry_MirrorRepositories(t *testing.T) {
+	dr, err := LoadDistroRegistry("../../distributions")
+	require.NoError(t, err)
+	require.Contains(t, dr.RepositoryURLs(), "https://cdn.redhat.com/content/dist/rhel9/9/x86_64/baseos/os")
+
+	dr.MirrorRepositories(map[string]string{
+		"https://cdn.redhat.com/":           "https://mirror.internal/cdn/",
+		"https://mirrors.fedoraproject.org": "https://mirror.internal/fedora",
+	})
+	urls := dr.RepositoryURLs()
+	require.Contains(t, urls, "https://mirror.internal/cdn/content/dist/rhel9/9/x86_64/baseos/os")
+	require.Contains(t, urls, "https://mirror.internal/fedora/metalink?repo=fedora-40&arch=x86_64")
+	for _, u := range urls {
+		require.NotContains(t, u, "cdn.redhat.com")
+		require.NotContains(t, u, "mirrors.fedoraproject.org")
+	}
+}
@@ -0,0 +1,115 @@
+package distribution
+
+// ImageType describes an image type which can be requested, aliases are
+// accepted in its place. The names passed on to composer are the canonical
+// ones.
+type ImageType struct {
+	Name        string
+	DisplayName string
+	Aliases     []string
+}
+
+var imageTypes = []ImageType{
+	{
+		Name:        "aws",
+		DisplayName: "Amazon Web Services (AMI)",
+		Aliases:     []string{"ami"},
+	},
+	{
+		Name:        "azure",
+		DisplayName: "Microsoft Azure (VHD)",
+		Aliases:     []string{"vhd"},
+	},
+	{
+		Name:        "edge-commit",
+		DisplayName: "RHEL for Edge Commit (.tar)",
+		Aliases:     []string{"rhel-edge-commit"},
+	},
+	{
+		Name:        "edge-installer",
+		DisplayName: "RHEL for Edge Installer (.iso)",
+		Aliases:     []string{"rhel-edge-installer"},
+	},
+	{
+		Name:        "gcp",
+		DisplayName: "Google Cloud Platform",
+	},
+	{
+		Name:        "guest-image",
+		DisplayName: "Virtualization - Guest image (.qcow2)",
+		Aliases:     []string{"qcow2", "virtualization"},
+	},
+	{
+		Name:        "image-installer",
+		DisplayName: "Bare metal - Installer (.iso)",
+	},
+	{
+		Name:        "iot-commit",
+		DisplayName: "Fedora IoT Commit (.tar)",
+	},
+	{
+		Name:        "oci",
+		DisplayName: "Oracle Cloud Infrastructure (.qcow2)",
+	},
+	{
+		Name:        "openstack",
+		DisplayName: "OpenStack (.qcow2)",
+	},
+	{
+		Name:        "vsphere",
+		DisplayName: "VMware vSphere (.vmdk)",
+		Aliases:     []string{"vmdk"},
+	},
+	{
+		Name:        "vsphere-ova",
+		DisplayName: "VMware vSphere (.ova)",
+	},
+	{
+		Name:        "wsl",
+		DisplayName: "Windows Subsystem for Linux (.tar.gz)",
+	},
+}
+
+// LookupImageType finds an image type by its name or one of its aliases.
+func LookupImageType(name string) (*ImageType, bool) {
+	for i, it := range imageTypes {
+		if it.Name == name {
+			return &imageTypes[i], true
+		}
+		for _, a := range it.Aliases {
+			if a == name {
+				return &imageTypes[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// CanonicalImageType resolves aliases, unknown names are returned as they are.
+func CanonicalImageType(name string) string {
+	it, ok := LookupImageType(name)
+	if !ok {
+		return name
+	}
+	return it.Name
+}
+
+// ImageTypeDisplayName returns a name meant for humans, it falls back to the
+// name itself for unknown image types.
+func ImageTypeDisplayName(name string) string {
+	it, ok := LookupImageType(name)
+	if !ok {
+		return name
+	}
+	return it.DisplayName
+}
+
+// ImageTypeNames lists all names of image types, aliases included.
+func ImageTypeNames() []string {
+	var names []string
+	for _, it := range imageTypes {
+		names = append(names, it.Name)
+		names = append(names, it.Aliases...)
+	}
+	return names
+}
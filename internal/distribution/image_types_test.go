@@ -0,0 +1,46 @@
+package distribution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalImageType(t *testing.T) {
+	require.Equal(t, "aws", CanonicalImageType("aws"))
+	require.Equal(t, "aws", CanonicalImageType("ami"))
+	require.Equal(t, "azure", CanonicalImageType("vhd"))
+	require.Equal(t, "guest-image", CanonicalImageType("qcow2"))
+	require.Equal(t, "guest-image", CanonicalImageType("virtualization"))
+	require.Equal(t, "unknown", CanonicalImageType("unknown"))
+
+	require.Equal(t, "Amazon Web Services (AMI)", ImageTypeDisplayName("ami"))
+	require.Equal(t, "unknown", ImageTypeDisplayName("unknown"))
+}
+
+func TestImageTypeAliasesUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for _, it := range imageTypes {
+		for _, name := range append([]string{it.Name}, it.Aliases...) {
+			require.False(t, seen[name], name)
+			seen[name] = true
+		}
+	}
+}
+
+// every image type offered by a distribution needs to be known
+func TestDistributionImageTypesKnown(t *testing.T) {
+	dr, err := LoadDistroRegistry("../../distributions")
+	require.NoError(t, err)
+	for _, d := range dr.Available(true).List() {
+		for _, arch := range []*Architecture{d.ArchX86, d.Aarch64} {
+			if arch == nil {
+				continue
+			}
+			for _, name := range arch.ImageTypes {
+				_, ok := LookupImageType(name)
+				require.True(t, ok, "%s: %s", d.Distribution.Name, name)
+			}
+		}
+	}
+}
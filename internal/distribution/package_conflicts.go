@@ -0,0 +1,38 @@
+package distribution
+
+import (
+	"fmt"
+	"slices"
+)
+
+// PackageConflict is a set of packages of which at most one can be
+// installed, like curl and curl-minimal.
+type PackageConflict struct {
+	Packages []string `json:"packages"`
+	// Reason tells users which one to pick
+	Reason string `json:"reason"`
+}
+
+// Conflicts returns the packages of the conflict which are among packages,
+// when there's more than one of them.
+func (c PackageConflict) Conflicts(packages []string) []string {
+	var found []string
+	for _, p := range c.Packages {
+		if slices.Contains(packages, p) {
+			found = append(found, p)
+		}
+	}
+	if len(found) < 2 {
+		return nil
+	}
+	return found
+}
+
+func (dist DistributionFile) validatePackageConflicts() error {
+	for _, c := range dist.PackageConflicts {
+		if len(c.Packages) < 2 {
+			return fmt.Errorf("%s: package conflict %v needs at least two packages", dist.Distribution.Name, c.Packages)
+		}
+	}
+	return nil
+}
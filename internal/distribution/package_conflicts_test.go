@@ -0,0 +1,24 @@
+package distribution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageConflicts(t *testing.T) {
+	c := PackageConflict{
+		Packages: []string{"podman-docker", "docker-ce", "moby-engine"},
+		Reason:   "podman-docker replaces the docker command with podman",
+	}
+	require.Nil(t, c.Conflicts([]string{"vim", "podman-docker"}))
+	require.Equal(t, []string{"podman-docker", "moby-engine"}, c.Conflicts([]string{"moby-engine", "vim", "podman-docker"}))
+
+	dist := DistributionFile{
+		Distribution:     DistributionItem{Name: "fedora-41"},
+		PackageConflicts: []PackageConflict{c},
+	}
+	require.NoError(t, dist.validatePackageConflicts())
+	dist.PackageConflicts = append(dist.PackageConflicts, PackageConflict{Packages: []string{"curl"}})
+	require.Error(t, dist.validatePackageConflicts())
+}
@@ -0,0 +1,98 @@
+package distribution
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// release is the version of a distribution as encoded in its name, rhel-94
+// is RHEL 9.4, rhel-8.10 RHEL 8.10 and fedora-40 Fedora 40.
+type release struct {
+	family string
+	major  int
+	minor  int
+}
+
+// parseRelease fails for names which don't denote a release, like the
+// nightly composes.
+func parseRelease(name string) (release, bool) {
+	family, version, ok := strings.Cut(name, "-")
+	if !ok || family == "" || strings.Contains(version, "-") {
+		return release{}, false
+	}
+	majorStr, minorStr, dotted := strings.Cut(version, ".")
+	if !dotted && family == "rhel" && len(version) > 1 {
+		majorStr, minorStr = version[:len(version)-1], version[len(version)-1:]
+	}
+	major, err := strconv.Atoi(majorStr)
+	if err != nil {
+		return release{}, false
+	}
+	minor := 0
+	if minorStr != "" {
+		minor, err = strconv.Atoi(minorStr)
+		if err != nil {
+			return release{}, false
+		}
+	}
+	return release{family, major, minor}, true
+}
+
+// MajorVersion returns the major version of the named release, 9 for rhel-94
+// or centos-9. Names which don't denote a release have none.
+func MajorVersion(name string) (int, bool) {
+	r, ok := parseRelease(name)
+	return r.major, ok
+}
+
+func (r release) newerThan(o release) bool {
+	if r.major != o.major {
+		return r.major > o.major
+	}
+	return r.minor > o.minor
+}
+
+// UpgradeTargets returns the names of the releases of the same distribution
+// newer than the named one, oldest first. Aliases like rhel-9 aren't listed,
+// the release they point to is. Nightlies have no upgrade targets.
+func (dr DistroRegistry) UpgradeTargets(name string) ([]string, error) {
+	d, err := dr.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	current, ok := parseRelease(d.Distribution.Name)
+	if !ok {
+		return nil, nil
+	}
+
+	releases := map[string]release{}
+	var targets []string
+	for n, t := range dr.distros {
+		if n != t.Distribution.Name {
+			continue
+		}
+		r, ok := parseRelease(n)
+		if !ok || r.family != current.family || !r.newerThan(current) {
+			continue
+		}
+		releases[n] = r
+		targets = append(targets, n)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		return releases[targets[j]].newerThan(releases[targets[i]])
+	})
+	return targets, nil
+}
+
+// HasPackage tells if one of the repositories of the architecture ships the
+// package, it's always false without package lists.
+func (arch Architecture) HasPackage(name string) bool {
+	for _, pkgs := range arch.Packages {
+		if slices.ContainsFunc(pkgs, func(p Package) bool { return p.Name == name }) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package distribution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRelease(t *testing.T) {
+	for name, expected := range map[string]release{
+		"rhel-94":   {"rhel", 9, 4},
+		"rhel-8.10": {"rhel", 8, 10},
+		"rhel-8":    {"rhel", 8, 0},
+		"centos-10": {"centos", 10, 0},
+		"fedora-40": {"fedora", 40, 0},
+	} {
+		r, ok := parseRelease(name)
+		require.True(t, ok, name)
+		require.Equal(t, expected, r, name)
+	}
+
+	for _, name := range []string{"rhel-9-nightly", "rhel", "rhel-x"} {
+		_, ok := parseRelease(name)
+		require.False(t, ok, name)
+	}
+
+	major, ok := MajorVersion("rhel-8.10")
+	require.True(t, ok)
+	require.Equal(t, 8, major)
+	_, ok = MajorVersion("rhel-10-nightly")
+	require.False(t, ok)
+}
+
+func TestDistroRegistry_UpgradeTargets(t *testing.T) {
+	adr, err := LoadDistroRegistry("../../distributions")
+	require.NoError(t, err)
+	dr := adr.Available(true)
+
+	targets, err := dr.UpgradeTargets("rhel-89")
+	require.NoError(t, err)
+	require.Equal(t, []string{"rhel-8.10", "rhel-90", "rhel-91", "rhel-92", "rhel-93", "rhel-94"}, targets)
+
+	// aliases upgrade from the release they point to
+	targets, err = dr.UpgradeTargets("rhel-9")
+	require.NoError(t, err)
+	require.Empty(t, targets)
+
+	targets, err = dr.UpgradeTargets("fedora-39")
+	require.NoError(t, err)
+	require.Equal(t, []string{"fedora-40", "fedora-41"}, targets)
+
+	targets, err = dr.UpgradeTargets("rhel-9-nightly")
+	require.NoError(t, err)
+	require.Empty(t, targets)
+
+	_, err = dr.UpgradeTargets("rhel-42")
+	require.ErrorIs(t, err, DistributionNotFound)
+}
+
+func TestArchitecture_HasPackage(t *testing.T) {
+	arch := Architecture{
+		Packages: map[string][]Package{
+			"baseos": {{Name: "bash"}},
+		},
+	}
+	require.True(t, arch.HasPackage("bash"))
+	require.False(t, arch.HasPackage("bas"))
+	require.False(t, Architecture{}.HasPackage("bash"))
+}
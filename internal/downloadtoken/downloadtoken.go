@@ -0,0 +1,49 @@
+// Package downloadtoken generates the download tokens of organizations, which
+// let build farms without a console session fetch the images of the org and
+// nothing else.
+//
+// A token is a fixed prefix followed by 32 random bytes, base64 encoded. Only
+// the SHA-256 of a token is stored, the token itself is shown once.
+package downloadtoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// Prefix tells download tokens apart from other secrets, for users and for
+// secret scanners.
+const Prefix = "ibdt_"
+
+var ErrInvalidToken = errors.New("invalid download token")
+
+// New returns a new token and the hash to store for it.
+func New() (string, string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", "", err
+	}
+	token := Prefix + base64.RawURLEncoding.EncodeToString(secret)
+	return token, Hash(token), nil
+}
+
+// Hash returns what's stored for the token.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// FromHeader returns the token of an Authorization header with the Bearer
+// scheme, ErrInvalidToken if it holds none.
+func FromHeader(header string) (string, error) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || !strings.HasPrefix(token, Prefix) {
+		return "", ErrInvalidToken
+	}
+	return token, nil
+}
@@ -0,0 +1,37 @@
+package downloadtoken
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	token, hash, err := New()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(token, Prefix))
+	require.Equal(t, Hash(token), hash)
+	require.Len(t, hash, 64)
+
+	other, _, err := New()
+	require.NoError(t, err)
+	require.NotEqual(t, token, other)
+}
+
+func TestFromHeader(t *testing.T) {
+	token, _, err := New()
+	require.NoError(t, err)
+
+	parsed, err := FromHeader("Bearer " + token)
+	require.NoError(t, err)
+	require.Equal(t, token, parsed)
+	parsed, err = FromHeader("bearer " + token)
+	require.NoError(t, err)
+	require.Equal(t, token, parsed)
+
+	for _, header := range []string{"", token, "Basic " + token, "Bearer", "Bearer sometoken"} {
+		_, err = FromHeader(header)
+		require.ErrorIs(t, err, ErrInvalidToken)
+	}
+}
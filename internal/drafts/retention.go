@@ -0,0 +1,51 @@
+// Package drafts expires the compose drafts users saved and never came back
+// to, so abandoned wizard sessions don't pile up.
+package drafts
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultRetention is how long drafts are kept after their last update.
+	DefaultRetention = 30 * 24 * time.Hour
+
+	pruneInterval = time.Hour
+)
+
+type Pruner interface {
+	DeleteComposeDrafts(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+// Prune deletes the drafts not updated within retention.
+func Prune(ctx context.Context, p Pruner, retention time.Duration) error {
+	deleted, err := p.DeleteComposeDrafts(ctx, retention)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		logrus.Infof("Deleted %d compose drafts not updated for %v", deleted, retention)
+	}
+	return nil
+}
+
+// RunRetention prunes the drafts every hour until the context is cancelled.
+func RunRetention(ctx context.Context, p Pruner, retention time.Duration) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	for {
+		err := Prune(ctx, p, retention)
+		if err != nil {
+			logrus.Errorf("Pruning compose drafts failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
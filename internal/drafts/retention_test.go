@@ -0,0 +1,38 @@
+package drafts
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakePruner struct {
+	retention time.Duration
+	err       error
+}
+
+func (f *fakePruner) DeleteComposeDrafts(ctx context.Context, retention time.Duration) (int64, error) {
+	f.retention = retention
+	return 3, f.err
+}
+
+func TestPrune(t *testing.T) {
+	p := &fakePruner{}
+	require.NoError(t, Prune(context.Background(), p, time.Hour))
+	require.Equal(t, time.Hour, p.retention)
+
+	p.err = errors.New("db gone")
+	require.ErrorIs(t, Prune(context.Background(), p, time.Hour), p.err)
+}
+
+func TestRunRetentionStops(t *testing.T) {
+	p := &fakePruner{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// returns right after the first prune
+	RunRetention(ctx, p, DefaultRetention)
+	require.Equal(t, DefaultRetention, p.retention)
+}
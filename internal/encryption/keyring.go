@@ -0,0 +1,173 @@
+// Package encryption implements envelope encryption for data stored at rest.
+//
+// Data is encrypted with a data key, the data key itself is encrypted with a
+// key-encryption key and stored next to the data. Data keys are rotated
+// regularly, key-encryption keys are rotated by adding a new key in front of
+// the keyring and keeping the old ones around until nothing references them
+// anymore.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DataKeyLifetime is how long a data key is used before a new one is generated.
+const DataKeyLifetime = time.Hour
+
+var ErrUnknownKey = errors.New("unknown key-encryption key")
+
+type Envelope struct {
+	// KeyId identifies the key-encryption key DataKey was encrypted with
+	KeyId      string `json:"kid"`
+	DataKey    []byte `json:"dek"`
+	KeyNonce   []byte `json:"dek_nonce"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"data"`
+}
+
+type dataKey struct {
+	plain     []byte
+	sealed    []byte
+	nonce     []byte
+	createdAt time.Time
+}
+
+// Keyring holds the key-encryption keys. The first key encrypts new data keys,
+// all of them can decrypt.
+type Keyring struct {
+	current string
+	keys    map[string][]byte
+
+	mu  sync.Mutex
+	dek *dataKey
+}
+
+// ParseKeyring parses a comma separated list of id:base64-key pairs, keys
+// need to be 16, 24 or 32 bytes long.
+func ParseKeyring(spec string) (*Keyring, error) {
+	k := Keyring{
+		keys: map[string][]byte{},
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("key entry needs to be in the id:key format")
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %s is not valid base64: %w", id, err)
+		}
+		if _, err := aes.NewCipher(key); err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		if _, ok := k.keys[id]; ok {
+			return nil, fmt.Errorf("key %s is listed twice", id)
+		}
+		if k.current == "" {
+			k.current = id
+		}
+		k.keys[id] = key
+	}
+	if k.current == "" {
+		return nil, fmt.Errorf("no keys in keyring")
+	}
+	return &k, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := io.ReadFull(rand.Reader, b)
+	return b, err
+}
+
+func (k *Keyring) dataKey() (*dataKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.dek != nil && time.Since(k.dek.createdAt) < DataKeyLifetime {
+		return k.dek, nil
+	}
+
+	plain, err := randomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+	kek, err := newGCM(k.keys[k.current])
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomBytes(kek.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+	k.dek = &dataKey{
+		plain:     plain,
+		sealed:    kek.Seal(nil, nonce, plain, []byte(k.current)),
+		nonce:     nonce,
+		createdAt: time.Now(),
+	}
+	return k.dek, nil
+}
+
+func (k *Keyring) Seal(plaintext []byte) (*Envelope, error) {
+	dek, err := k.dataKey()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(dek.plain)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{
+		KeyId:      k.current,
+		DataKey:    dek.sealed,
+		KeyNonce:   dek.nonce,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, nil),
+	}, nil
+}
+
+func (k *Keyring) Open(e *Envelope) ([]byte, error) {
+	key, ok := k.keys[e.KeyId]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, e.KeyId)
+	}
+	kek, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plainKey, err := kek.Open(nil, e.KeyNonce, e.DataKey, []byte(e.KeyId))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt data key: %w", err)
+	}
+	aead, err := newGCM(plainKey)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, e.Nonce, e.Ciphertext, nil)
+}
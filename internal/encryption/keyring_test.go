@@ -0,0 +1,81 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func key(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+}
+
+func TestParseKeyring(t *testing.T) {
+	k, err := ParseKeyring("new:" + key('a') + ", old:" + key('b'))
+	require.NoError(t, err)
+	require.Equal(t, "new", k.current)
+	require.Len(t, k.keys, 2)
+
+	for _, spec := range []string{
+		"",
+		" , ",
+		key('a'),
+		"a:notbase64!",
+		"a:" + base64.StdEncoding.EncodeToString([]byte("short")),
+		"a:" + key('a') + ",a:" + key('b'),
+	} {
+		_, err = ParseKeyring(spec)
+		require.Error(t, err, spec)
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	k, err := ParseKeyring("a:" + key('a'))
+	require.NoError(t, err)
+
+	e, err := k.Seal([]byte("secret"))
+	require.NoError(t, err)
+	require.Equal(t, "a", e.KeyId)
+	require.NotContains(t, string(e.Ciphertext), "secret")
+
+	plain, err := k.Open(e)
+	require.NoError(t, err)
+	require.Equal(t, "secret", string(plain))
+
+	// the data key is reused until it expires
+	e2, err := k.Seal([]byte("secret"))
+	require.NoError(t, err)
+	require.Equal(t, e.DataKey, e2.DataKey)
+	require.NotEqual(t, e.Nonce, e2.Nonce)
+
+	k.dek.createdAt = k.dek.createdAt.Add(-DataKeyLifetime)
+	e3, err := k.Seal([]byte("secret"))
+	require.NoError(t, err)
+	require.NotEqual(t, e.DataKey, e3.DataKey)
+
+	e.Ciphertext[0] ^= 0xff
+	_, err = k.Open(e)
+	require.Error(t, err)
+}
+
+func TestKeyRotation(t *testing.T) {
+	old, err := ParseKeyring("a:" + key('a'))
+	require.NoError(t, err)
+	e, err := old.Seal([]byte("secret"))
+	require.NoError(t, err)
+
+	rotated, err := ParseKeyring("b:" + key('b') + ",a:" + key('a'))
+	require.NoError(t, err)
+	plain, err := rotated.Open(e)
+	require.NoError(t, err)
+	require.Equal(t, "secret", string(plain))
+
+	e, err = rotated.Seal([]byte("secret"))
+	require.NoError(t, err)
+	require.Equal(t, "b", e.KeyId)
+
+	_, err = old.Open(e)
+	require.ErrorIs(t, err, ErrUnknownKey)
+}
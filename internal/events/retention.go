@@ -0,0 +1,51 @@
+// Package events keeps the compose event log from growing forever, events
+// are only kept long enough for consumers to catch up after an outage.
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultRetention is how long compose events are kept.
+	DefaultRetention = 30 * 24 * time.Hour
+
+	pruneInterval = time.Hour
+)
+
+type Pruner interface {
+	DeleteComposeEvents(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+// Prune deletes the events older than retention.
+func Prune(ctx context.Context, p Pruner, retention time.Duration) error {
+	deleted, err := p.DeleteComposeEvents(ctx, retention)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		logrus.Infof("Deleted %d compose events older than %v", deleted, retention)
+	}
+	return nil
+}
+
+// RunRetention prunes the events every hour until the context is cancelled.
+func RunRetention(ctx context.Context, p Pruner, retention time.Duration) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	for {
+		err := Prune(ctx, p, retention)
+		if err != nil {
+			logrus.Errorf("Pruning compose events failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Package gc collects the artifacts of expired composes. Composer deletes the
+// compose along with the images it uploaded, afterwards the compose is
+// deleted from the listings like a user would.
+package gc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/readonly"
+)
+
+const (
+	// DefaultInterval is how often expired composes are collected.
+	DefaultInterval = time.Hour
+
+	batchSize = 100
+)
+
+// ComposeDeleter is the part of the composer client the reaper needs.
+type ComposeDeleter interface {
+	DeleteCompose(id uuid.UUID) (*http.Response, error)
+}
+
+type Reaper struct {
+	db       db.DB
+	client   ComposeDeleter
+	region   *string
+	readOnly *readonly.Mode
+}
+
+// New creates a reaper for the composes created in region, which client has
+// to be the composer of. Composes of other regions are collected by the
+// deployment there, the ones of tenants with a composer of their own by a
+// reaper per tenant region.
+func New(dbase db.DB, client ComposeDeleter, region *string) *Reaper {
+	return &Reaper{
+		db:     dbase,
+		client: client,
+		region: region,
+	}
+}
+
+// PauseWhileReadOnly skips collecting in read-only mode, nothing can be
+// deleted then.
+func (r *Reaper) PauseWhileReadOnly(m *readonly.Mode) *Reaper {
+	r.readOnly = m
+	return r
+}
+
+// Run calls Collect every interval until the context is cancelled.
+func (r *Reaper) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if enabled, _ := r.readOnly.Enabled(); !enabled {
+			err := r.Collect(ctx)
+			if err != nil {
+				logrus.Errorf("Collecting expired composes failed: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collect deletes a batch of expired composes, the rest is left to the next
+// run. A compose composer fails to delete is retried next time, it doesn't
+// stop the others from being collected.
+func (r *Reaper) Collect(ctx context.Context) error {
+	expired, err := r.db.GetExpiredComposes(ctx, r.region, batchSize)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, c := range expired {
+		err = r.deleteArtifacts(c.Id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("compose %s: %w", c.Id, err))
+			continue
+		}
+		err = r.db.DeleteCompose(ctx, c.Id, c.OrgId)
+		if err != nil && !errors.Is(err, db.ComposeNotFoundError) {
+			errs = append(errs, err)
+			continue
+		}
+		logrus.Infof("Deleted compose %s of org %s, it expired at %v", c.Id, c.OrgId, c.ExpiresAt)
+	}
+	return errors.Join(errs...)
+}
+
+// deleteArtifacts is done for composes composer doesn't know anymore, their
+// artifacts are gone already.
+func (r *Reaper) deleteArtifacts(id uuid.UUID) error {
+	resp, err := r.client.DeleteCompose(id)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logrus.Errorf("Unable to close composer response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if composer.IsComposeNotFound(resp.StatusCode, body) {
+		return nil
+	}
+	return fmt.Errorf("composer returned %d: %s", resp.StatusCode, body)
+}
@@ -0,0 +1,91 @@
+package gc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+type fakeDB struct {
+	db.DB
+	expired []db.ExpiredCompose
+	region  *string
+	deleted map[uuid.UUID]string
+}
+
+func (f *fakeDB) GetExpiredComposes(ctx context.Context, region *string, limit int) ([]db.ExpiredCompose, error) {
+	f.region = region
+	return f.expired, nil
+}
+
+func (f *fakeDB) DeleteCompose(ctx context.Context, jobId uuid.UUID, orgId string) error {
+	f.deleted[jobId] = orgId
+	return nil
+}
+
+type fakeComposer struct {
+	statuses map[uuid.UUID]int
+	bodies   map[uuid.UUID]string
+	deleted  []uuid.UUID
+}
+
+func (f *fakeComposer) DeleteCompose(id uuid.UUID) (*http.Response, error) {
+	f.deleted = append(f.deleted, id)
+	status, ok := f.statuses[id]
+	if !ok {
+		status = http.StatusOK
+	}
+	body, ok := f.bodies[id]
+	if !ok {
+		body = "{}"
+	}
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+func TestCollect(t *testing.T) {
+	expired := uuid.New()
+	forgotten := uuid.New()
+	failing := uuid.New()
+	routeless := uuid.New()
+
+	fdb := &fakeDB{
+		expired: []db.ExpiredCompose{
+			{Id: expired, OrgId: "000000", ExpiresAt: time.Now().Add(-time.Hour)},
+			{Id: forgotten, OrgId: "000001", ExpiresAt: time.Now().Add(-time.Hour)},
+			{Id: failing, OrgId: "000000", ExpiresAt: time.Now().Add(-time.Minute)},
+			{Id: routeless, OrgId: "000000", ExpiresAt: time.Now().Add(-time.Minute)},
+		},
+		deleted: map[uuid.UUID]string{},
+	}
+	client := &fakeComposer{
+		statuses: map[uuid.UUID]int{
+			forgotten: http.StatusNotFound,
+			failing:   http.StatusInternalServerError,
+			routeless: http.StatusNotFound,
+		},
+		bodies: map[uuid.UUID]string{
+			forgotten: `{"code": "IMAGE-BUILDER-COMPOSER-15"}`,
+			routeless: "404 page not found",
+		},
+	}
+
+	err := New(fdb, client, common.ToPtr("eu")).Collect(context.Background())
+	require.ErrorContains(t, err, failing.String())
+	require.ErrorContains(t, err, routeless.String())
+	require.Equal(t, "eu", *fdb.region)
+	require.Equal(t, []uuid.UUID{expired, forgotten, failing, routeless}, client.deleted)
+	// the failing composes are kept, so they're retried next time
+	require.Equal(t, map[uuid.UUID]string{
+		expired:   "000000",
+		forgotten: "000001",
+	}, fdb.deleted)
+}
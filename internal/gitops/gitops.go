@@ -0,0 +1,176 @@
+// Package gitops fetches blueprints from git repositories, so orgs can keep
+// their blueprints in git and have image-builder follow it.
+package gitops
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/invopop/yaml"
+)
+
+// Blueprints bigger than this are skipped, they can't be valid anyway.
+const maxBlueprintSize = 1024 * 1024
+
+// Snapshot holds the blueprint files found at a commit, keyed by their path
+// relative to the repository root.
+type Snapshot struct {
+	Commit string
+	Files  map[string][]byte
+}
+
+type Fetcher interface {
+	Fetch(ctx context.Context, repoURL, branch, dir string) (*Snapshot, error)
+}
+
+// ValidateURL only accepts https repositories, other transports would give
+// orgs access to the local filesystem or need credentials set up.
+func ValidateURL(repoURL string) error {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("only https repositories are supported")
+	}
+	if u.User != nil {
+		return fmt.Errorf("repository URL must not contain credentials")
+	}
+	return nil
+}
+
+// ValidateDir makes sure the blueprint directory stays inside the repository.
+func ValidateDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	clean := path.Clean(dir)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("directory %q is outside of the repository", dir)
+	}
+	return nil
+}
+
+// IsBlueprintFile tells which files of the repository are considered blueprints.
+func IsBlueprintFile(name string) bool {
+	switch path.Ext(name) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// ParseBlueprint converts a YAML (or JSON) blueprint to JSON.
+func ParseBlueprint(data []byte) ([]byte, error) {
+	return yaml.YAMLToJSON(data)
+}
+
+// Git shallow clones repositories using the git binary.
+type Git struct {
+	tmpDir string
+}
+
+// NewGit clones into tmpDir, the system default is used if it's empty.
+func NewGit(tmpDir string) *Git {
+	return &Git{tmpDir: tmpDir}
+}
+
+func (g *Git) Fetch(ctx context.Context, repoURL, branch, dir string) (*Snapshot, error) {
+	err := ValidateURL(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	err = ValidateDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	checkout, err := os.MkdirTemp(g.tmpDir, "gitops-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.RemoveAll(checkout) }()
+
+	_, err = g.git(ctx, "", "clone", "--quiet", "--depth", "1", "--single-branch", "--branch", branch, "--", repoURL, checkout)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := g.git(ctx, checkout, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := readBlueprints(checkout, dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read blueprints from %s: %w", repoURL, err)
+	}
+	return &Snapshot{
+		Commit: commit,
+		Files:  files,
+	}, nil
+}
+
+// readBlueprints collects the blueprint files below dir of the checkout.
+func readBlueprints(checkout, dir string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	root := filepath.Join(checkout, filepath.FromSlash(path.Clean("/"+dir)))
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// symlinks could point outside of the checkout
+		if !d.Type().IsRegular() || !IsBlueprintFile(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() > maxBlueprintSize {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(checkout, p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func (g *Git) git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// never wait for credentials, and don't pick up any local configuration
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL=/dev/null")
+	out, err := cmd.Output()
+	if err != nil {
+		var stderr string
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			stderr = strings.TrimSpace(string(exitErr.Stderr))
+		}
+		return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, stderr)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,255 @@
+// Package hooks post-processes composes once they succeeded, like registering
+// the image in a CMDB, starting an Ansible job or copying the artifact
+// somewhere else. Hooks are plugins compiled in and registered at startup,
+// each with its own timeout and number of attempts. How every hook fared is
+// recorded on the compose.
+//
+// A compose counts as succeeded once its status is recorded, when it's
+// requested or by the watchdog. Hooks run at least once per compose, they can
+// run again when the runner stops in the middle of one.
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/readonly"
+)
+
+const (
+	// DefaultInterval is how often succeeded composes are looked for.
+	DefaultInterval = time.Minute
+
+	// DefaultTimeout applies to hooks registered without one.
+	DefaultTimeout = 5 * time.Minute
+
+	// DefaultAttempts applies to hooks registered without a number of
+	// attempts.
+	DefaultAttempts = 3
+
+	// composes succeed long before this, the watchdog fails them otherwise
+	lookback = 24 * time.Hour
+
+	batchSize = 100
+)
+
+// Compose is the succeeded compose a hook runs on. The request is the one
+// stored, its customizations can be encrypted.
+type Compose struct {
+	Id        uuid.UUID
+	OrgId     string
+	ImageName *string
+	Request   json.RawMessage
+	CreatedAt time.Time
+}
+
+// Hook post-processes a compose, an error has it tried again later. Hooks
+// have to stop once the context is done.
+type Hook interface {
+	Run(ctx context.Context, compose Compose) error
+}
+
+// HookFunc lets a function be a Hook.
+type HookFunc func(ctx context.Context, compose Compose) error
+
+func (f HookFunc) Run(ctx context.Context, compose Compose) error {
+	return f(ctx, compose)
+}
+
+// Options of a hook, the zero values stand for the defaults.
+type Options struct {
+	// Timeout of every attempt
+	Timeout time.Duration
+	// Attempts is how often the hook is tried before it's given up on
+	Attempts int
+}
+
+type registration struct {
+	name     string
+	hook     Hook
+	timeout  time.Duration
+	attempts int
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]registration{}
+)
+
+// Register makes the hook run on every compose succeeding from then on, under
+// the name recorded on the compose. It panics when the name is taken,
+// registering a hook twice is a programming error.
+func Register(name string, hook Hook, opts Options) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if name == "" || hook == nil {
+		panic("hooks: Register needs a name and a hook")
+	}
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("hooks: Register called twice for %s", name))
+	}
+	r := registration{
+		name:     name,
+		hook:     hook,
+		timeout:  opts.Timeout,
+		attempts: opts.Attempts,
+	}
+	if r.timeout <= 0 {
+		r.timeout = DefaultTimeout
+	}
+	if r.attempts <= 0 {
+		r.attempts = DefaultAttempts
+	}
+	registry[name] = r
+}
+
+// Registered returns the names of the registered hooks, sorted.
+func Registered() []string {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return sortedNames(registry)
+}
+
+// Runner runs the registered hooks on the succeeded composes.
+type Runner struct {
+	db       db.DB
+	region   *string
+	readOnly *readonly.Mode
+	hooks    []registration
+}
+
+// New creates a runner of the hooks registered so far for the composes
+// created in region, the deployments of other regions run the hooks on
+// theirs. The composes of tenants with a composer of their own get a runner
+// per tenant region.
+func New(dbase db.DB, region *string) *Runner {
+	r := &Runner{
+		db:     dbase,
+		region: region,
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, name := range sortedNames(registry) {
+		r.hooks = append(r.hooks, registry[name])
+	}
+	return r
+}
+
+func sortedNames(m map[string]registration) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PauseWhileReadOnly skips the hooks in read-only mode, their outcome can't
+// be recorded.
+func (r *Runner) PauseWhileReadOnly(m *readonly.Mode) *Runner {
+	r.readOnly = m
+	return r
+}
+
+// Run calls Process every interval until the context is cancelled.
+func (r *Runner) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if enabled, _ := r.readOnly.Enabled(); !enabled {
+			err := r.Process(ctx)
+			if err != nil {
+				logrus.Errorf("Running compose hooks failed: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Process runs every hook on the succeeded composes it isn't done with, one
+// attempt each. A failing hook doesn't stop the others.
+func (r *Runner) Process(ctx context.Context) error {
+	var errs []error
+	for _, h := range r.hooks {
+		composes, err := r.db.GetComposesAwaitingHook(ctx, h.name, r.region, lookback, batchSize)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, c := range composes {
+			run := db.ComposeHookRunEntry{
+				ComposeId: c.Id,
+				Hook:      h.name,
+				Status:    db.ComposeHookSucceeded,
+				Attempts:  c.Attempts + 1,
+			}
+			hookErr := runHook(ctx, h, Compose{
+				Id:        c.Id,
+				OrgId:     c.OrgId,
+				ImageName: c.ImageName,
+				Request:   c.Request,
+				CreatedAt: c.CreatedAt,
+			})
+			if ctx.Err() != nil {
+				// the runner stops, the hook runs again next time
+				return errors.Join(append(errs, ctx.Err())...)
+			}
+			if hookErr != nil {
+				run.Status = db.ComposeHookPending
+				if run.Attempts >= h.attempts {
+					run.Status = db.ComposeHookFailed
+				}
+				msg := hookErr.Error()
+				run.Error = &msg
+				logrus.Warnf("Hook %s failed on compose %v (attempt %d of %d): %v", h.name, c.Id, run.Attempts, h.attempts, hookErr)
+			}
+			err = r.db.SetComposeHookRun(ctx, run)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// runHook gives up on hooks which don't return in time, even when they ignore
+// the context. Panics are errors of the hook.
+func runHook(ctx context.Context, h registration, compose Compose) error {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("hook panicked: %v", p)
+			}
+		}()
+		done <- h.hook.Run(ctx, compose)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("hook timed out after %v", h.timeout)
+		}
+		return ctx.Err()
+	}
+}
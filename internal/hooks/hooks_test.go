@@ -0,0 +1,130 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+type fakeDB struct {
+	db.DB
+	awaiting map[string][]db.ComposeAwaitingHook
+	runs     []db.ComposeHookRunEntry
+}
+
+func (f *fakeDB) GetComposesAwaitingHook(ctx context.Context, hook string, region *string, since time.Duration, limit int) ([]db.ComposeAwaitingHook, error) {
+	return f.awaiting[hook], nil
+}
+
+func (f *fakeDB) SetComposeHookRun(ctx context.Context, run db.ComposeHookRunEntry) error {
+	f.runs = append(f.runs, run)
+	return nil
+}
+
+// isolateRegistry gives the test a registry of its own.
+func isolateRegistry(t *testing.T) {
+	registryMu.Lock()
+	saved := registry
+	registry = map[string]registration{}
+	registryMu.Unlock()
+	t.Cleanup(func() {
+		registryMu.Lock()
+		registry = saved
+		registryMu.Unlock()
+	})
+}
+
+func TestRegister(t *testing.T) {
+	isolateRegistry(t)
+	noop := HookFunc(func(ctx context.Context, compose Compose) error { return nil })
+
+	Register("cmdb", noop, Options{})
+	Register("ansible", noop, Options{Timeout: time.Minute, Attempts: 1})
+	require.Equal(t, []string{"ansible", "cmdb"}, Registered())
+	require.Equal(t, DefaultTimeout, registry["cmdb"].timeout)
+	require.Equal(t, DefaultAttempts, registry["cmdb"].attempts)
+	require.Equal(t, time.Minute, registry["ansible"].timeout)
+
+	require.Panics(t, func() { Register("cmdb", noop, Options{}) })
+	require.Panics(t, func() { Register("", noop, Options{}) })
+	require.Panics(t, func() { Register("copy", nil, Options{}) })
+}
+
+func TestProcess(t *testing.T) {
+	isolateRegistry(t)
+	first := uuid.New()
+	retried := uuid.New()
+	lastAttempt := uuid.New()
+
+	var ran []uuid.UUID
+	Register("cmdb", HookFunc(func(ctx context.Context, compose Compose) error {
+		ran = append(ran, compose.Id)
+		require.Equal(t, "000000", compose.OrgId)
+		return nil
+	}), Options{})
+	Register("ansible", HookFunc(func(ctx context.Context, compose Compose) error {
+		return errors.New("tower unreachable")
+	}), Options{Attempts: 3})
+	Register("copy", HookFunc(func(ctx context.Context, compose Compose) error {
+		panic("nil bucket")
+	}), Options{Attempts: 1})
+	Register("slow", HookFunc(func(ctx context.Context, compose Compose) error {
+		time.Sleep(time.Second)
+		return nil
+	}), Options{Timeout: 10 * time.Millisecond})
+
+	fdb := &fakeDB{
+		awaiting: map[string][]db.ComposeAwaitingHook{
+			"cmdb":    {{Id: first, OrgId: "000000"}},
+			"ansible": {{Id: retried, OrgId: "000000", Attempts: 1}, {Id: lastAttempt, OrgId: "000000", Attempts: 2}},
+			"copy":    {{Id: first, OrgId: "000000"}},
+			"slow":    {{Id: first, OrgId: "000000"}},
+		},
+	}
+	require.NoError(t, New(fdb, nil).Process(context.Background()))
+	require.Equal(t, []uuid.UUID{first}, ran)
+
+	byHook := map[string][]db.ComposeHookRunEntry{}
+	for _, run := range fdb.runs {
+		byHook[run.Hook] = append(byHook[run.Hook], run)
+	}
+	require.Equal(t, []db.ComposeHookRunEntry{{ComposeId: first, Hook: "cmdb", Status: db.ComposeHookSucceeded, Attempts: 1}}, byHook["cmdb"])
+
+	// failures are retried until the hook ran out of attempts
+	require.Len(t, byHook["ansible"], 2)
+	require.Equal(t, db.ComposeHookPending, byHook["ansible"][0].Status)
+	require.Equal(t, 2, byHook["ansible"][0].Attempts)
+	require.Equal(t, "tower unreachable", *byHook["ansible"][0].Error)
+	require.Equal(t, db.ComposeHookFailed, byHook["ansible"][1].Status)
+	require.Equal(t, 3, byHook["ansible"][1].Attempts)
+
+	require.Equal(t, db.ComposeHookFailed, byHook["copy"][0].Status)
+	require.Contains(t, *byHook["copy"][0].Error, "nil bucket")
+	require.Equal(t, db.ComposeHookPending, byHook["slow"][0].Status)
+	require.Contains(t, *byHook["slow"][0].Error, "timed out")
+}
+
+func TestProcessStopsWithContext(t *testing.T) {
+	isolateRegistry(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	Register("cmdb", HookFunc(func(ctx context.Context, compose Compose) error {
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	}), Options{})
+
+	fdb := &fakeDB{
+		awaiting: map[string][]db.ComposeAwaitingHook{
+			"cmdb": {{Id: uuid.New()}, {Id: uuid.New()}},
+		},
+	}
+	require.ErrorIs(t, New(fdb, nil).Process(ctx), context.Canceled)
+	// the interrupted run isn't counted as an attempt
+	require.Empty(t, fdb.runs)
+}
@@ -0,0 +1,84 @@
+package jsonpatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	for _, tc := range []struct {
+		doc, patch, result string
+	}{
+		{`{"foo": "bar"}`, `[{"op": "add", "path": "/baz", "value": "qux"}]`, `{"baz": "qux", "foo": "bar"}`},
+		{`{"foo": ["bar", "baz"]}`, `[{"op": "add", "path": "/foo/1", "value": "qux"}]`, `{"foo": ["bar", "qux", "baz"]}`},
+		{`{"foo": ["bar"]}`, `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`, `{"foo": ["bar", ["abc", "def"]]}`},
+		{`{"baz": "qux", "foo": "bar"}`, `[{"op": "remove", "path": "/baz"}]`, `{"foo": "bar"}`},
+		{`{"foo": ["bar", "qux", "baz"]}`, `[{"op": "remove", "path": "/foo/1"}]`, `{"foo": ["bar", "baz"]}`},
+		{`{"baz": "qux", "foo": "bar"}`, `[{"op": "replace", "path": "/baz", "value": "boo"}]`, `{"baz": "boo", "foo": "bar"}`},
+		{`{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`, `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`, `{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`},
+		{`{"foo": ["all", "grass", "cows", "eat"]}`, `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`, `{"foo": ["all", "cows", "eat", "grass"]}`},
+		{`{"foo": {"bar": 1}}`, `[{"op": "copy", "from": "/foo", "path": "/baz"}, {"op": "add", "path": "/baz/qux", "value": 2}]`, `{"foo": {"bar": 1}, "baz": {"bar": 1, "qux": 2}}`},
+		{`{"a/b": 1, "m~n": 2}`, `[{"op": "test", "path": "/a~1b", "value": 1.0}, {"op": "remove", "path": "/m~0n"}]`, `{"a/b": 1}`},
+		{`{"size": 68719476737}`, `[{"op": "test", "path": "/size", "value": 68719476737}]`, `{"size": 68719476737}`},
+		{`{"foo": "bar"}`, `[{"op": "replace", "path": "", "value": {"baz": "qux"}}]`, `{"baz": "qux"}`},
+		// null is a value of its own, not a missing one
+		{`{"foo": "bar"}`, `[{"op": "replace", "path": "/foo", "value": null}]`, `{"foo": null}`},
+		{`{"foo": null}`, `[{"op": "test", "path": "/foo", "value": null}, {"op": "add", "path": "/baz", "value": null}]`, `{"foo": null, "baz": null}`},
+	} {
+		result, err := Apply([]byte(tc.doc), []byte(tc.patch))
+		require.NoError(t, err, tc.patch)
+		require.JSONEq(t, tc.result, string(result), tc.patch)
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	doc := `{"foo": ["bar"], "baz": {"qux": 1}}`
+	for _, patch := range []string{
+		`{"op": "add", "path": "/a", "value": 1}`,
+		`[{"op": "frobnicate", "path": "/foo"}]`,
+		`[{"op": "add", "path": "foo", "value": 1}]`,
+		`[{"op": "add", "path": "/foo/2", "value": 1}]`,
+		`[{"op": "add", "path": "/foo/01", "value": 1}]`,
+		`[{"op": "add", "path": "/missing/a", "value": 1}]`,
+		`[{"op": "add", "path": "/a"}]`,
+		`[{"op": "replace", "path": "/foo"}]`,
+		`[{"op": "remove", "path": "/missing"}]`,
+		`[{"op": "remove", "path": "/foo/1"}]`,
+		`[{"op": "replace", "path": "/missing", "value": 1}]`,
+		`[{"op": "move", "from": "/baz", "path": "/baz/qux/x"}]`,
+		// the first operation doesn't apply when the second fails
+		`[{"op": "add", "path": "/a", "value": 1}, {"op": "remove", "path": "/a/b"}]`,
+	} {
+		_, err := Apply([]byte(doc), []byte(patch))
+		require.ErrorIs(t, err, ErrInvalidPatch, patch)
+	}
+
+	_, err := Apply([]byte(doc), []byte(`[{"op": "test", "path": "/baz/qux", "value": 2}]`))
+	require.ErrorIs(t, err, ErrTestFailed)
+	_, err = Apply([]byte(doc), []byte(`[{"op": "test", "path": "/baz/qux", "value": null}]`))
+	require.ErrorIs(t, err, ErrTestFailed)
+}
+
+func TestMergePatch(t *testing.T) {
+	for _, tc := range []struct {
+		doc, patch, result string
+	}{
+		{`{"a": "b"}`, `{"a": "c"}`, `{"a": "c"}`},
+		{`{"a": "b"}`, `{"b": "c"}`, `{"a": "b", "b": "c"}`},
+		{`{"a": "b", "b": "c"}`, `{"a": null}`, `{"b": "c"}`},
+		{`{"a": ["b"]}`, `{"a": "c"}`, `{"a": "c"}`},
+		{`{"a": {"b": "c"}}`, `{"a": {"b": "d", "c": null}}`, `{"a": {"b": "d"}}`},
+		{`{"a": [{"b": "c"}]}`, `{"a": [1]}`, `{"a": [1]}`},
+		{`{"e": null}`, `{"a": 1}`, `{"e": null, "a": 1}`},
+		{`{"a": "b"}`, `["c"]`, `["c"]`},
+		{`{"a": {"b": "c"}}`, `{"a": {"b": {"d": null}}}`, `{"a": {"b": {}}}`},
+	} {
+		result, err := MergePatch([]byte(tc.doc), []byte(tc.patch))
+		require.NoError(t, err, tc.patch)
+		require.JSONEq(t, tc.result, string(result), tc.patch)
+	}
+
+	_, err := MergePatch([]byte(`{}`), []byte(`{"a": `))
+	require.ErrorIs(t, err, ErrInvalidPatch)
+}
@@ -0,0 +1,82 @@
+// Package lifecycle applies the lifecycle policies of blueprints, images a
+// policy doesn't keep anymore are deleted.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/readonly"
+)
+
+// DefaultInterval is how often the policies are applied.
+const DefaultInterval = time.Hour
+
+type Collector struct {
+	db       db.DB
+	readOnly *readonly.Mode
+}
+
+func New(dbase db.DB) *Collector {
+	return &Collector{
+		db: dbase,
+	}
+}
+
+// PauseWhileReadOnly skips collecting in read-only mode, nothing can be
+// deleted then.
+func (c *Collector) PauseWhileReadOnly(m *readonly.Mode) *Collector {
+	c.readOnly = m
+	return c
+}
+
+// Run calls Collect every interval until the context is cancelled.
+func (c *Collector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if enabled, _ := c.readOnly.Enabled(); !enabled {
+			err := c.Collect(ctx)
+			if err != nil {
+				logrus.Errorf("Applying blueprint lifecycle policies failed: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collect deletes the composes of all blueprints which their policy doesn't
+// keep. A failing blueprint doesn't stop the others from being collected.
+func (c *Collector) Collect(ctx context.Context) error {
+	lifecycles, err := c.db.GetBlueprintLifecycles(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, l := range lifecycles {
+		expired, err := c.db.GetExpiredBlueprintComposes(ctx, l.OrgId, l.BlueprintId, l.KeepLast)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, compose := range expired {
+			err = c.db.DeleteCompose(ctx, compose.Id, l.OrgId)
+			if err != nil && !errors.Is(err, db.ComposeNotFoundError) {
+				errs = append(errs, err)
+				continue
+			}
+			logrus.Infof("Deleted compose %s of blueprint %s, its lifecycle policy keeps the last %d images", compose.Id, l.BlueprintId, l.KeepLast)
+		}
+	}
+	return errors.Join(errs...)
+}
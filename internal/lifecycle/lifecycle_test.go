@@ -0,0 +1,58 @@
+package lifecycle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+type fakeDB struct {
+	db.DB
+	lifecycles []db.BlueprintLifecycle
+	expired    map[uuid.UUID][]db.BlueprintCompose
+	deleted    map[uuid.UUID]string
+}
+
+func (f *fakeDB) GetBlueprintLifecycles(ctx context.Context) ([]db.BlueprintLifecycle, error) {
+	return f.lifecycles, nil
+}
+
+func (f *fakeDB) GetExpiredBlueprintComposes(ctx context.Context, orgId string, blueprintId uuid.UUID, keepLast int) ([]db.BlueprintCompose, error) {
+	return f.expired[blueprintId], nil
+}
+
+func (f *fakeDB) DeleteCompose(ctx context.Context, jobId uuid.UUID, orgId string) error {
+	f.deleted[jobId] = orgId
+	return nil
+}
+
+func TestCollect(t *testing.T) {
+	bp1 := uuid.New()
+	bp2 := uuid.New()
+	old1 := uuid.New()
+	old2 := uuid.New()
+
+	fdb := &fakeDB{
+		lifecycles: []db.BlueprintLifecycle{
+			{OrgId: "000000", BlueprintId: bp1, KeepLast: 1},
+			{OrgId: "000001", BlueprintId: bp2, KeepLast: 3},
+		},
+		expired: map[uuid.UUID][]db.BlueprintCompose{
+			bp1: {
+				{ComposeEntry: db.ComposeEntry{Id: old1}, BlueprintId: bp1},
+				{ComposeEntry: db.ComposeEntry{Id: old2}, BlueprintId: bp1},
+			},
+		},
+		deleted: map[uuid.UUID]string{},
+	}
+
+	require.NoError(t, New(fdb).Collect(context.Background()))
+	require.Equal(t, map[uuid.UUID]string{
+		old1: "000000",
+		old2: "000000",
+	}, fdb.deleted)
+}
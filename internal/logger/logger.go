@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"net"
 	"os"
 	"strings"
 	"time"
@@ -136,7 +137,12 @@ func AddCloudWatchHook(log *logrus.Logger, key, secret, region, group string) er
 	return nil
 }
 
+// AddSplunkHook logs to the Splunk HEC on host and port, host can be an IPv6
+// address with or without brackets.
 func AddSplunkHook(log *logrus.Logger, host, port, token string) error {
+	if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+		host = "[" + host + "]"
+	}
 	hook, err := slogger.NewSplunkHook(context.Background(), host, port, token, "image-builder")
 	if err != nil {
 		return err
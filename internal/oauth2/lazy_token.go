@@ -19,6 +19,11 @@ type Tokener interface {
 	ForceRefresh(context.Context) (string, error)
 }
 
+// Expirer is implemented by the tokeners which know when their token expires.
+type Expirer interface {
+	Expiry() time.Time
+}
+
 type LazyToken struct {
 	// Url represents the URL used for acquiring the token.
 	Url string
@@ -64,6 +69,14 @@ func (lt *LazyToken) Token(ctx context.Context) (string, error) {
 	return lt.acquireNewToken(ctx, false)
 }
 
+// Expiry is when the cached token expires, zero before the first one was
+// acquired.
+func (lt *LazyToken) Expiry() time.Time {
+	lt.mutex.Lock()
+	defer lt.mutex.Unlock()
+	return lt.Expiration
+}
+
 // ForceRefresh is a function that responsible for fetching a new access token.
 func (lt *LazyToken) ForceRefresh(ctx context.Context) (string, error) {
 	return lt.acquireNewToken(ctx, true)
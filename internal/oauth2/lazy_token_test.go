@@ -41,10 +41,12 @@ func TestLazyToken(t *testing.T) {
 		ClientId:     clientID,
 		ClientSecret: clientSecret,
 	}
+	require.True(t, lazyToken.Expiry().IsZero())
 	ctx := context.Background()
 	token, err := lazyToken.Token(ctx)
 	require.NoError(t, err)
 	require.Equal(t, "mock-token-1", token)
+	require.Equal(t, lazyToken.Expiration, lazyToken.Expiry())
 
 	token, err = lazyToken.Token(ctx)
 	require.NoError(t, err)
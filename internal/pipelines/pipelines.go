@@ -0,0 +1,107 @@
+// Package pipelines moves compose pipelines on in the background. Reading the
+// status of the commit compose of a pipeline moves it on as well, the driver
+// covers the pipelines nobody polls, which would never build their installer
+// otherwise, and the ones left behind while their installer was submitted.
+package pipelines
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/readonly"
+)
+
+const (
+	// DefaultInterval is how often the waiting pipelines are moved on.
+	DefaultInterval = time.Minute
+
+	// TriggerTimeout is how long submitting the installer of a pipeline
+	// takes at most. Pipelines triggering for longer were left behind by a
+	// request or driver which stopped in the middle, they are failed.
+	TriggerTimeout = 15 * time.Minute
+
+	batchSize = 100
+)
+
+// Advancer submits the installer of a waiting pipeline once its commit was
+// built, or fails the pipeline along with the commit.
+type Advancer interface {
+	AdvanceComposePipeline(ctx context.Context, pipeline db.WaitingComposePipeline) error
+}
+
+type Driver struct {
+	db       db.DB
+	advancer Advancer
+	readOnly *readonly.Mode
+}
+
+func New(dbase db.DB, advancer Advancer) *Driver {
+	return &Driver{
+		db:       dbase,
+		advancer: advancer,
+	}
+}
+
+// PauseWhileReadOnly skips driving in read-only mode, no installer can be
+// submitted then.
+func (d *Driver) PauseWhileReadOnly(m *readonly.Mode) *Driver {
+	d.readOnly = m
+	return d
+}
+
+// Run calls Drive every interval until the context is cancelled.
+func (d *Driver) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if enabled, _ := d.readOnly.Enabled(); !enabled {
+			err := d.Drive(ctx)
+			if err != nil {
+				logrus.Errorf("Driving compose pipelines failed: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drive fails the pipelines triggering for longer than TriggerTimeout and
+// moves on all waiting ones. A failing pipeline doesn't stop the others, it's
+// tried again on the next run.
+func (d *Driver) Drive(ctx context.Context) error {
+	failed, err := d.db.FailStaleComposePipelines(ctx, db.ComposePipelineTriggering, TriggerTimeout, "The installer compose wasn't submitted in time")
+	if err != nil {
+		return err
+	}
+	for _, id := range failed {
+		logrus.Warnf("Failed compose pipeline %s, its installer wasn't submitted in time", id)
+	}
+
+	var errs []error
+	var after *db.WaitingComposePipeline
+	for {
+		pipelines, err := d.db.GetWaitingComposePipelines(ctx, after, batchSize)
+		if err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		for _, p := range pipelines {
+			err = d.advancer.AdvanceComposePipeline(ctx, p)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("pipeline %s: %w", p.Id, err))
+			}
+		}
+		if len(pipelines) < batchSize {
+			return errors.Join(errs...)
+		}
+		after = &pipelines[len(pipelines)-1]
+	}
+}
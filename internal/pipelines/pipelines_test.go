@@ -0,0 +1,87 @@
+package pipelines
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+type fakeDB struct {
+	db.DB
+	waiting []db.WaitingComposePipeline
+	stale   []uuid.UUID
+	failed  []string
+}
+
+// the pipelines are listed in order, as they'd be by age
+func (f *fakeDB) GetWaitingComposePipelines(ctx context.Context, after *db.WaitingComposePipeline, limit int) ([]db.WaitingComposePipeline, error) {
+	pipelines := f.waiting
+	if after != nil {
+		for i, p := range pipelines {
+			if p.Id == after.Id {
+				pipelines = pipelines[i+1:]
+				break
+			}
+		}
+	}
+	if len(pipelines) > limit {
+		pipelines = pipelines[:limit]
+	}
+	return pipelines, nil
+}
+
+func (f *fakeDB) FailStaleComposePipelines(ctx context.Context, state string, olderThan time.Duration, errorMessage string) ([]uuid.UUID, error) {
+	f.failed = append(f.failed, state)
+	stale := f.stale
+	f.stale = nil
+	return stale, nil
+}
+
+type fakeAdvancer struct {
+	advanced []uuid.UUID
+	failing  uuid.UUID
+}
+
+func (f *fakeAdvancer) AdvanceComposePipeline(ctx context.Context, pipeline db.WaitingComposePipeline) error {
+	if pipeline.Id == f.failing {
+		return errors.New("composer is down")
+	}
+	f.advanced = append(f.advanced, pipeline.Id)
+	return nil
+}
+
+func TestDrive(t *testing.T) {
+	fdb := &fakeDB{
+		stale: []uuid.UUID{uuid.New()},
+	}
+	var ids []uuid.UUID
+	// more than a batch, the driver pages through them
+	for i := 0; i < batchSize+5; i++ {
+		p := db.WaitingComposePipeline{OrgId: "000000"}
+		p.Id = uuid.New()
+		ids = append(ids, p.Id)
+		fdb.waiting = append(fdb.waiting, p)
+	}
+	advancer := &fakeAdvancer{failing: ids[3]}
+
+	err := New(fdb, advancer).Drive(context.Background())
+	require.ErrorContains(t, err, ids[3].String())
+	require.ErrorContains(t, err, "composer is down")
+	require.Equal(t, []string{db.ComposePipelineTriggering}, fdb.failed)
+
+	// a failing pipeline doesn't stop the others
+	require.Len(t, advancer.advanced, len(ids)-1)
+	require.NotContains(t, advancer.advanced, ids[3])
+	require.Equal(t, ids[batchSize+4], advancer.advanced[len(advancer.advanced)-1])
+
+	advancer.failing = uuid.Nil
+	advancer.advanced = nil
+	require.NoError(t, New(fdb, advancer).Drive(context.Background()))
+	require.Equal(t, ids, advancer.advanced)
+}
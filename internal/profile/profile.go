@@ -0,0 +1,168 @@
+// Package profile enforces the deployment profile. The air-gapped profile
+// only lets the service reach an allow-listed set of hosts, the composers and
+// internal mirrors, and refuses to start with a configuration reaching others.
+package profile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/osbuild/image-builder/internal/config"
+	"github.com/osbuild/image-builder/internal/distribution"
+	"github.com/osbuild/image-builder/internal/gitops"
+)
+
+const (
+	// Default puts no restrictions on the configuration.
+	Default = ""
+	// AirGapped restricts all egress to the allowed hosts.
+	AirGapped = "airgapped"
+)
+
+type Profile struct {
+	name         string
+	allowedHosts []string
+}
+
+// New creates the profile from its name and the comma separated hosts it
+// allows to reach. A host starting with a dot allows all its subdomains.
+func New(name, allowedHosts string) (*Profile, error) {
+	p := &Profile{name: name}
+	switch name {
+	case Default:
+		return p, nil
+	case AirGapped:
+	default:
+		return nil, fmt.Errorf("unknown deployment profile %q", name)
+	}
+
+	for _, h := range strings.Split(allowedHosts, ",") {
+		h = config.TrimBrackets(strings.ToLower(strings.TrimSpace(h)))
+		if h != "" {
+			p.allowedHosts = append(p.allowedHosts, h)
+		}
+	}
+	if len(p.allowedHosts) == 0 {
+		return nil, fmt.Errorf("the %s profile needs the hosts it may reach in EGRESS_ALLOWED_HOSTS", name)
+	}
+	return p, nil
+}
+
+func (p *Profile) AirGapped() bool {
+	return p.name == AirGapped
+}
+
+func (p *Profile) allowedHost(host string) bool {
+	host = config.TrimBrackets(strings.ToLower(host))
+	for _, h := range p.allowedHosts {
+		if host == h || (strings.HasPrefix(h, ".") && strings.HasSuffix(host, h)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed tells if the service may reach rawURL, outside of the air-gapped
+// profile it may reach everything.
+func (p *Profile) Allowed(rawURL string) bool {
+	if !p.AirGapped() {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	return p.allowedHost(u.Hostname())
+}
+
+// CheckConfig lists everything the configuration reaches beyond the allowed
+// hosts.
+func (p *Profile) CheckConfig(conf *config.ImageBuilderConfig) error {
+	if !p.AirGapped() {
+		return nil
+	}
+
+	var errs []error
+	urls := conf.URLs()
+	envs := make([]string, 0, len(urls))
+	for env := range urls {
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
+	for _, env := range envs {
+		if u := urls[env]; u != "" && !p.Allowed(u) {
+			errs = append(errs, fmt.Errorf("%s: %s is not an allowed host", env, u))
+		}
+	}
+
+	if conf.SplunkHost != "" && !p.allowedHost(conf.SplunkHost) {
+		errs = append(errs, fmt.Errorf("SPLUNK_HEC_HOST: %s is not an allowed host", conf.SplunkHost))
+	}
+	if conf.CwAccessKeyID != "" {
+		errs = append(errs, errors.New("CW_AWS_ACCESS_KEY_ID: logging to CloudWatch reaches AWS"))
+	}
+	if conf.StorageBackend == "s3" && conf.StorageS3Endpoint == "" {
+		errs = append(errs, errors.New("STORAGE_S3_ENDPOINT: the s3 storage backend needs an internal endpoint"))
+	}
+	return errors.Join(errs...)
+}
+
+// CheckDistributions lists the repositories of the distributions which aren't
+// served by the allowed hosts, REPO_MIRRORS can point them at internal mirrors.
+func (p *Profile) CheckDistributions(adr *distribution.AllDistroRegistry) error {
+	if !p.AirGapped() {
+		return nil
+	}
+	var errs []error
+	for _, u := range adr.RepositoryURLs() {
+		if !p.Allowed(u) {
+			errs = append(errs, fmt.Errorf("repository %s is not served by an allowed host", u))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GitFetcher keeps the gitops sync from cloning repositories of hosts which
+// aren't allowed.
+func (p *Profile) GitFetcher(f gitops.Fetcher) gitops.Fetcher {
+	if !p.AirGapped() {
+		return f
+	}
+	return &restrictedFetcher{
+		Fetcher: f,
+		profile: p,
+	}
+}
+
+type restrictedFetcher struct {
+	gitops.Fetcher
+	profile *Profile
+}
+
+func (f *restrictedFetcher) Fetch(ctx context.Context, repoURL, branch, dir string) (*gitops.Snapshot, error) {
+	if !f.profile.Allowed(repoURL) {
+		return nil, fmt.Errorf("repositories of this host can't be reached from this deployment")
+	}
+	return f.Fetcher.Fetch(ctx, repoURL, branch, dir)
+}
+
+// ParseMirrors parses the comma separated prefix=replacement pairs of
+// REPO_MIRRORS.
+func ParseMirrors(spec string) (map[string]string, error) {
+	mirrors := map[string]string{}
+	if spec == "" {
+		return mirrors, nil
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		prefix, replacement, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || prefix == "" || replacement == "" {
+			return nil, fmt.Errorf("invalid repository mirror %q, expected prefix=replacement", entry)
+		}
+		mirrors[prefix] = replacement
+	}
+	return mirrors, nil
+}
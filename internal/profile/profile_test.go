@@ -0,0 +1,106 @@
+package profile
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/config"
+	"github.com/osbuild/image-builder/internal/distribution"
+	"github.com/osbuild/image-builder/internal/gitops"
+)
+
+func TestNew(t *testing.T) {
+	_, err := New("unknown", "")
+	require.Error(t, err)
+	_, err = New(AirGapped, " , ")
+	require.Error(t, err)
+
+	p, err := New(Default, "")
+	require.NoError(t, err)
+	require.False(t, p.AirGapped())
+	require.True(t, p.Allowed("https://example.com"))
+}
+
+func TestAllowed(t *testing.T) {
+	p, err := New(AirGapped, "composer.svc, .mirror.internal")
+	require.NoError(t, err)
+	require.True(t, p.Allowed("https://composer.svc:8080/api/image-builder-composer/v2"))
+	require.True(t, p.Allowed("https://rhel.mirror.internal/content"))
+	require.False(t, p.Allowed("https://mirror.internal.example.com/content"))
+	require.False(t, p.Allowed("https://cdn.redhat.com/content"))
+	require.False(t, p.Allowed("not a url"))
+
+	p, err = New(AirGapped, "[fd00::1],fd00::2")
+	require.NoError(t, err)
+	require.True(t, p.Allowed("https://[fd00::1]:8080/api/image-builder-composer/v2"))
+	require.True(t, p.Allowed("https://[fd00::2]/api/image-builder-composer/v2"))
+	require.False(t, p.Allowed("https://[fd00::3]/api/image-builder-composer/v2"))
+	require.True(t, p.allowedHost("[fd00::1]"))
+}
+
+func TestCheckConfig(t *testing.T) {
+	p, err := New(AirGapped, "composer.svc,sso.svc")
+	require.NoError(t, err)
+
+	conf := &config.ImageBuilderConfig{
+		ComposerURL:      "https://composer.svc",
+		ComposerTokenURL: "https://sso.svc/token",
+	}
+	require.NoError(t, p.CheckConfig(conf))
+
+	conf.ComposerRegionalURLs = "eu=https://composer.eu.example.com"
+	conf.GlitchTipDSN = "https://key@glitchtip.example.com/1"
+	conf.CwAccessKeyID = "id"
+	conf.StorageBackend = "s3"
+	conf.TelemetryURL = "https://telemetry.example.com/reports"
+	err = p.CheckConfig(conf)
+	require.ErrorContains(t, err, "COMPOSER_REGIONAL_URLS (eu)")
+	require.ErrorContains(t, err, "GLITCHTIP_DSN")
+	require.ErrorContains(t, err, "CW_AWS_ACCESS_KEY_ID")
+	require.ErrorContains(t, err, "STORAGE_S3_ENDPOINT")
+	require.ErrorContains(t, err, "TELEMETRY_URL")
+}
+
+func TestCheckDistributions(t *testing.T) {
+	adr, err := distribution.LoadDistroRegistry("../../distributions")
+	require.NoError(t, err)
+
+	p, err := New(AirGapped, "mirror.internal")
+	require.NoError(t, err)
+	require.Error(t, p.CheckDistributions(adr))
+
+	mirrors, err := ParseMirrors(strings.Join([]string{
+		"https://cdn.redhat.com/=https://mirror.internal/cdn/",
+		"https://mirrors.fedoraproject.org/=https://mirror.internal/fedora/",
+		"https://packages.cloud.google.com/=https://mirror.internal/google/",
+		"http://download.devel.redhat.com/=https://mirror.internal/devel/",
+		"http://mirror.stream.centos.org/=https://mirror.internal/centos/",
+		"https://composes.stream.centos.org/=https://mirror.internal/centos-composes/",
+	}, ","))
+	require.NoError(t, err)
+	adr.MirrorRepositories(mirrors)
+	require.NoError(t, p.CheckDistributions(adr))
+
+	_, err = ParseMirrors("https://cdn.redhat.com/")
+	require.Error(t, err)
+}
+
+type fakeFetcher struct{}
+
+func (fakeFetcher) Fetch(ctx context.Context, repoURL, branch, dir string) (*gitops.Snapshot, error) {
+	return &gitops.Snapshot{}, nil
+}
+
+func TestGitFetcher(t *testing.T) {
+	p, err := New(AirGapped, "git.internal")
+	require.NoError(t, err)
+	f := p.GitFetcher(fakeFetcher{})
+
+	_, err = f.Fetch(context.Background(), "https://git.internal/blueprints.git", "main", "")
+	require.NoError(t, err)
+	_, err = f.Fetch(context.Background(), "https://github.com/org/blueprints.git", "main", "")
+	require.Error(t, err)
+}
@@ -0,0 +1,43 @@
+package prometheus
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// AddMetricsRoutes serves the business metrics on /metrics and the internals
+// of the process on /metrics/runtime, they are of no use outside of the
+// scraper. OpenMetrics is needed to expose the trace exemplars.
+func AddMetricsRoutes(e *echo.Echo, token string) {
+	auth := metricsAuth(token)
+	e.GET("/metrics", echo.WrapHandler(promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(BusinessGatherer(prometheus.DefaultGatherer), promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	)), auth)
+	e.GET("/metrics/runtime", echo.WrapHandler(
+		promhttp.HandlerFor(RuntimeGatherer(prometheus.DefaultGatherer), promhttp.HandlerOpts{}),
+	), auth)
+}
+
+// metricsAuth requires the bearer token on the metrics endpoints, without one
+// they rely on network policies to keep them internal.
+func metricsAuth(token string) echo.MiddlewareFunc {
+	return func(nextHandler echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if token == "" {
+				return nextHandler(ctx)
+			}
+			bearer, ok := strings.CutPrefix(ctx.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+				ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+				return echo.NewHTTPError(http.StatusUnauthorized, "Metrics need to be scraped with the configured bearer token")
+			}
+			return nextHandler(ctx)
+		}
+	}
+}
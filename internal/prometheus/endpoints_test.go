@@ -0,0 +1,35 @@
+package prometheus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddMetricsRoutes(t *testing.T) {
+	scrape := func(e *echo.Echo, path, token string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	open := echo.New()
+	AddMetricsRoutes(open, "")
+	require.Equal(t, http.StatusOK, scrape(open, "/metrics", ""))
+	require.Equal(t, http.StatusOK, scrape(open, "/metrics/runtime", ""))
+
+	guarded := echo.New()
+	AddMetricsRoutes(guarded, "scraper-token")
+	for _, path := range []string{"/metrics", "/metrics/runtime"} {
+		require.Equal(t, http.StatusUnauthorized, scrape(guarded, path, ""))
+		require.Equal(t, http.StatusUnauthorized, scrape(guarded, path, "wrong"))
+		require.Equal(t, http.StatusOK, scrape(guarded, path, "scraper-token"))
+	}
+}
@@ -0,0 +1,43 @@
+package prometheus
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// filteredGatherer only passes on the metric families keep accepts.
+type filteredGatherer struct {
+	gatherer prometheus.Gatherer
+	keep     func(name string) bool
+}
+
+func (f filteredGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := f.gatherer.Gather()
+	var kept []*dto.MetricFamily
+	for _, mf := range families {
+		if f.keep(mf.GetName()) {
+			kept = append(kept, mf)
+		}
+	}
+	return kept, err
+}
+
+func isBusinessMetric(name string) bool {
+	return strings.HasPrefix(name, namespace+"_")
+}
+
+// BusinessGatherer gathers the metrics about what the service does, requests
+// and composes.
+func BusinessGatherer(g prometheus.Gatherer) prometheus.Gatherer {
+	return filteredGatherer{g, isBusinessMetric}
+}
+
+// RuntimeGatherer gathers everything else, the internals of the process and
+// the go runtime.
+func RuntimeGatherer(g prometheus.Gatherer) prometheus.Gatherer {
+	return filteredGatherer{g, func(name string) bool {
+		return !isBusinessMetric(name)
+	}}
+}
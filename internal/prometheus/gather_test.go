@@ -0,0 +1,33 @@
+package prometheus
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGatherers(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(collectors.NewGoCollector())
+	counter := prometheus.NewCounter(prometheus.CounterOpts{
+		Name:      "things_total",
+		Namespace: namespace,
+		Subsystem: subsystem,
+	})
+	reg.MustRegister(counter)
+	counter.Inc()
+
+	business, err := BusinessGatherer(reg).Gather()
+	require.NoError(t, err)
+	require.Len(t, business, 1)
+	require.Equal(t, "image_builder_crc_things_total", business[0].GetName())
+
+	runtime, err := RuntimeGatherer(reg).Gather()
+	require.NoError(t, err)
+	require.NotEmpty(t, runtime)
+	for _, mf := range runtime {
+		require.NotEqual(t, "image_builder_crc_things_total", mf.GetName())
+	}
+}
@@ -0,0 +1,187 @@
+package prometheus
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+)
+
+// OtherLabelValue replaces label values which would make the number of series
+// grow without bounds.
+const OtherLabelValue = "other"
+
+// Every label value is a new series, these identify tenants or single
+// requests and must never become one.
+var forbiddenLabels = map[string]bool{
+	"org_id":         true,
+	"org":            true,
+	"account":        true,
+	"account_number": true,
+	"email":          true,
+	"user":           true,
+	"username":       true,
+	"compose_id":     true,
+	"blueprint_id":   true,
+}
+
+// Labels without a list of known values can take at most this many distinct
+// values.
+const defaultMaxLabelValues = 200
+
+// Offenders are logged once, but not more than this many per label.
+const maxLoggedOffenders = 20
+
+type labelGuard struct {
+	mu       sync.Mutex
+	name     string
+	known    map[string]bool
+	max      int
+	seen     map[string]bool
+	offended map[string]bool
+}
+
+func (g *labelGuard) value(v string) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.known != nil {
+		if g.known[v] {
+			return v
+		}
+	} else if g.seen[v] {
+		return v
+	} else if len(g.seen) < g.max {
+		g.seen[v] = true
+		return v
+	}
+
+	if !g.offended[v] && len(g.offended) < maxLoggedOffenders {
+		g.offended[v] = true
+		logrus.Warnf("Metric label %s: coalescing unexpected value %q to %q", g.name, v, OtherLabelValue)
+	}
+	return OtherLabelValue
+}
+
+// Registry creates metrics whose label values are bounded, guards are shared
+// between all metrics using a label of the same name.
+type Registry struct {
+	factory promauto.Factory
+
+	mu     sync.Mutex
+	guards map[string]*labelGuard
+}
+
+func NewRegistry(reg prometheus.Registerer) *Registry {
+	return &Registry{
+		factory: promauto.With(reg),
+		guards:  map[string]*labelGuard{},
+	}
+}
+
+var defaultRegistry = NewRegistry(prometheus.DefaultRegisterer)
+
+// SetLabelValues restricts a label to the given values, everything else is
+// reported as OtherLabelValue.
+func (r *Registry) SetLabelValues(label string, values ...string) {
+	g := r.guard(label)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.known = map[string]bool{OtherLabelValue: true}
+	for _, v := range values {
+		g.known[v] = true
+	}
+}
+
+// SetLabelValues restricts a label of the metrics in this package.
+func SetLabelValues(label string, values ...string) {
+	defaultRegistry.SetLabelValues(label, values...)
+}
+
+func (r *Registry) guard(label string) *labelGuard {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g, ok := r.guards[label]
+	if !ok {
+		g = &labelGuard{
+			name:     label,
+			max:      defaultMaxLabelValues,
+			seen:     map[string]bool{},
+			offended: map[string]bool{},
+		}
+		r.guards[label] = g
+	}
+	return g
+}
+
+func (r *Registry) labelGuards(labels []string) []*labelGuard {
+	var guards []*labelGuard
+	for _, l := range labels {
+		if forbiddenLabels[l] {
+			panic(fmt.Sprintf("metric label %s is not allowed, it has unbounded cardinality", l))
+		}
+		guards = append(guards, r.guard(l))
+	}
+	return guards
+}
+
+func guardValues(guards []*labelGuard, values []string) []string {
+	guarded := make([]string, len(values))
+	for i, v := range values {
+		if i < len(guards) {
+			v = guards[i].value(v)
+		}
+		guarded[i] = v
+	}
+	return guarded
+}
+
+type CounterVec struct {
+	vec    *prometheus.CounterVec
+	guards []*labelGuard
+}
+
+func (r *Registry) NewCounterVec(opts prometheus.CounterOpts, labels []string) *CounterVec {
+	return &CounterVec{
+		guards: r.labelGuards(labels),
+		vec:    r.factory.NewCounterVec(opts, labels),
+	}
+}
+
+func (c *CounterVec) WithLabelValues(values ...string) prometheus.Counter {
+	return c.vec.WithLabelValues(guardValues(c.guards, values)...)
+}
+
+type HistogramVec struct {
+	vec    *prometheus.HistogramVec
+	guards []*labelGuard
+}
+
+func (r *Registry) NewHistogramVec(opts prometheus.HistogramOpts, labels []string) *HistogramVec {
+	return &HistogramVec{
+		guards: r.labelGuards(labels),
+		vec:    r.factory.NewHistogramVec(opts, labels),
+	}
+}
+
+func (h *HistogramVec) WithLabelValues(values ...string) prometheus.Observer {
+	return h.vec.WithLabelValues(guardValues(h.guards, values)...)
+}
+
+type GaugeVec struct {
+	vec    *prometheus.GaugeVec
+	guards []*labelGuard
+}
+
+func (r *Registry) NewGaugeVec(opts prometheus.GaugeOpts, labels []string) *GaugeVec {
+	return &GaugeVec{
+		guards: r.labelGuards(labels),
+		vec:    r.factory.NewGaugeVec(opts, labels),
+	}
+}
+
+func (g *GaugeVec) WithLabelValues(values ...string) prometheus.Gauge {
+	return g.vec.WithLabelValues(guardValues(g.guards, values)...)
+}
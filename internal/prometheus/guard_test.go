@@ -0,0 +1,62 @@
+package prometheus
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func countSeries(c prometheus.Collector) int {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+	n := 0
+	for range ch {
+		n++
+	}
+	return n
+}
+
+func counterValue(t *testing.T, c prometheus.Counter) float64 {
+	var m dto.Metric
+	require.NoError(t, c.Write(&m))
+	return m.GetCounter().GetValue()
+}
+
+func TestRegistryKnownValues(t *testing.T) {
+	r := NewRegistry(prometheus.NewRegistry())
+	r.SetLabelValues("distro", "rhel-9", "centos-9")
+	c := r.NewCounterVec(prometheus.CounterOpts{Name: "test_total"}, []string{"distro", "code"})
+
+	c.WithLabelValues("rhel-9", "200").Inc()
+	c.WithLabelValues("not-a-distro", "200").Inc()
+	c.WithLabelValues("another-one", "200").Inc()
+
+	require.Equal(t, float64(1), counterValue(t, c.vec.WithLabelValues("rhel-9", "200")))
+	require.Equal(t, float64(2), counterValue(t, c.vec.WithLabelValues(OtherLabelValue, "200")))
+	require.Equal(t, 2, countSeries(c.vec))
+}
+
+func TestRegistryBoundedValues(t *testing.T) {
+	r := NewRegistry(prometheus.NewRegistry())
+	h := r.NewHistogramVec(prometheus.HistogramOpts{Name: "test_seconds"}, []string{"path"})
+
+	for i := 0; i < defaultMaxLabelValues+10; i++ {
+		h.WithLabelValues(fmt.Sprintf("/path/%d", i)).Observe(1)
+	}
+	// values seen before the limit keep working
+	h.WithLabelValues("/path/0").Observe(1)
+	require.Equal(t, defaultMaxLabelValues+1, countSeries(h.vec))
+}
+
+func TestRegistryForbiddenLabels(t *testing.T) {
+	r := NewRegistry(prometheus.NewRegistry())
+	require.Panics(t, func() {
+		r.NewCounterVec(prometheus.CounterOpts{Name: "test_total"}, []string{"org_id"})
+	})
+}
@@ -16,8 +16,10 @@ const (
 	subsystem = "crc"
 )
 
+// Labelled metrics go through defaultRegistry, which keeps their cardinality
+// bounded.
 var (
-	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	httpDuration = defaultRegistry.NewHistogramVec(prometheus.HistogramOpts{
 		Name:      "http_duration_seconds",
 		Namespace: namespace,
 		Subsystem: subsystem,
@@ -47,7 +49,7 @@ var (
 )
 
 var (
-	ReqCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	ReqCounter = defaultRegistry.NewCounterVec(prometheus.CounterOpts{
 		Name:      "request_count",
 		Namespace: namespace,
 		Subsystem: subsystem,
@@ -55,6 +57,81 @@ var (
 	}, []string{"method", "path", "code"})
 )
 
+var (
+	WatchdogTimeouts = defaultRegistry.NewCounterVec(prometheus.CounterOpts{
+		Name:      "watchdog_timeouts_total",
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Help:      "Number of composes failed by the watchdog because they got stuck.",
+	}, []string{"image_type"})
+
+	WatchdogRecovered = promauto.NewCounter(prometheus.CounterOpts{
+		Name:      "watchdog_recovered_total",
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Help:      "Number of composes the watchdog found finished when re-querying composer.",
+	})
+)
+
+var (
+	RepositoryReachable = defaultRegistry.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "repository_reachable",
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Help:      "Whether the metadata of a distribution repository could be fetched in the last check.",
+	}, []string{"repository"})
+
+	RepositoryLastModified = defaultRegistry.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "repository_last_modified_timestamp_seconds",
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Help:      "When the metadata of a distribution repository last changed, as reported by the mirror.",
+	}, []string{"repository"})
+)
+
+var (
+	ComposeCustomizations = defaultRegistry.NewCounterVec(prometheus.CounterOpts{
+		Name:      "compose_customizations_total",
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Help:      "Number of composes using a customization, none for composes without any.",
+	}, []string{"customization"})
+
+	ComposeArchitectures = defaultRegistry.NewCounterVec(prometheus.CounterOpts{
+		Name:      "compose_architectures_total",
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Help:      "Number of composes per architecture, and whether it's built under emulation.",
+	}, []string{"architecture", "emulated"})
+
+	DeprecatedComposes = defaultRegistry.NewCounterVec(prometheus.CounterOpts{
+		Name:      "deprecated_composes_total",
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Help:      "Number of composes of a deprecated distribution or image type, to track the migration off them.",
+	}, []string{"distribution", "image_type"})
+)
+
+var (
+	PriorityLaneComposes = defaultRegistry.NewCounterVec(prometheus.CounterOpts{
+		Name:      "priority_lane_composes_total",
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Help:      "Number of composes flagged as security rebuilds, by whether they got into the priority lane or overflowed it.",
+	}, []string{"lane"})
+)
+
+var (
+	StartupDuration = defaultRegistry.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "startup_duration_seconds",
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Help:      "How long the phases of starting the service took, total is the time until it listened for requests.",
+	}, []string{"phase"})
+)
+
+var traceIDRegex = regexp.MustCompile("^[0-9a-f]{32}$")
+
 func pathLabel(path string) string {
 	r := regexp.MustCompile(":(.*)")
 	segments := strings.Split(path, "/")
@@ -72,11 +149,27 @@ func PrometheusMW(nextHandler echo.HandlerFunc) echo.HandlerFunc {
 		}
 
 		timer := prometheus.NewTimer(httpDuration.WithLabelValues(ctx.Path()))
-		defer timer.ObserveDuration()
+		defer timer.ObserveDurationWithExemplar(traceExemplar(ctx.Request().Header.Get("traceparent")))
 		return nextHandler(ctx)
 	}
 }
 
+// traceExemplar links an observation to the trace of the request, taken from
+// the W3C trace context header set by the gateway. It returns nil for missing
+// or malformed headers, the observation is recorded without an exemplar then.
+func traceExemplar(traceparent string) prometheus.Labels {
+	// version-traceid-parentid-flags
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return nil
+	}
+	traceID := parts[1]
+	if !traceIDRegex.MatchString(traceID) || traceID == strings.Repeat("0", 32) {
+		return nil
+	}
+	return prometheus.Labels{"trace_id": traceID}
+}
+
 func StatusMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(ctx echo.Context) error {
 		// call the next handler to see if
@@ -0,0 +1,88 @@
+// Package readonly tracks whether the service only serves reads, which it does
+// while composer or the primary database fail over.
+package readonly
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultInterval is how often the trigger file is looked at.
+const DefaultInterval = 10 * time.Second
+
+type Mode struct {
+	configured       bool
+	configuredReason string
+
+	mu      sync.RWMutex
+	enabled bool
+	reason  string
+}
+
+// New creates the mode as configured at startup, Watch can switch it at
+// runtime.
+func New(enabled bool, reason string) *Mode {
+	return &Mode{
+		configured:       enabled,
+		configuredReason: reason,
+		enabled:          enabled,
+		reason:           reason,
+	}
+}
+
+// Enabled tells if mutations are rejected and why, a nil mode never is.
+func (m *Mode) Enabled() (bool, string) {
+	if m == nil {
+		return false, ""
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.enabled, m.reason
+}
+
+func (m *Mode) Set(enabled bool, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.enabled != enabled {
+		logrus.Infof("Read-only mode enabled: %t (%s)", enabled, reason)
+	}
+	m.enabled = enabled
+	m.reason = reason
+}
+
+// Watch enables the mode for as long as the file at path exists, its content
+// is the reason. Without the file the configured state applies. This lets
+// operators fail over all replicas at once through a mounted config map.
+func (m *Mode) Watch(ctx context.Context, path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		m.check(path)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Mode) check(path string) {
+	content, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		m.Set(m.configured, m.configuredReason)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Unable to read the read-only mode file %s: %v", path, err)
+		return
+	}
+	m.Set(true, strings.TrimSpace(string(content)))
+}
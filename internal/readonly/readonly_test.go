@@ -0,0 +1,42 @@
+package readonly
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNilMode(t *testing.T) {
+	var m *Mode
+	enabled, _ := m.Enabled()
+	require.False(t, enabled)
+}
+
+func TestCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "read-only")
+	m := New(false, "")
+
+	m.check(path)
+	enabled, _ := m.Enabled()
+	require.False(t, enabled)
+
+	require.NoError(t, os.WriteFile(path, []byte("composer failover\n"), 0600))
+	m.check(path)
+	enabled, reason := m.Enabled()
+	require.True(t, enabled)
+	require.Equal(t, "composer failover", reason)
+
+	require.NoError(t, os.Remove(path))
+	m.check(path)
+	enabled, _ = m.Enabled()
+	require.False(t, enabled)
+
+	// without the file the configured state comes back
+	m = New(true, "database failover")
+	m.check(path)
+	enabled, reason = m.Enabled()
+	require.True(t, enabled)
+	require.Equal(t, "database failover", reason)
+}
@@ -0,0 +1,137 @@
+// Package redact replaces secrets in JSON documents with their digests, so
+// documents can be logged or kept around without leaking credentials while
+// still allowing to tell whether two values were the same.
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// Rule selects values by a dot separated path of object keys, a key suffixed
+// with [] descends into every element of the array stored under it.
+type Rule string
+
+// ComposeRequest covers the image-builder compose request.
+var ComposeRequest = []Rule{
+	"customizations.subscription.activation-key",
+	"customizations.users[].password",
+	"customizations.files[].data",
+	"customizations.ignition.embedded.config",
+}
+
+// ComposerRequest covers the request sent to composer, which has hashed
+// passwords filled in and uses snake case field names.
+var ComposerRequest = []Rule{
+	"customizations.subscription.activation_key",
+	"customizations.users[].password",
+	"customizations.files[].data",
+	"customizations.ignition.embedded.config",
+}
+
+// ComposerResponse covers the responses of composer, the upload statuses
+// hold presigned links to the images.
+var ComposerResponse = []Rule{
+	"image_status.upload_status.options.url",
+	"image_status.upload_statuses[].options.url",
+	"image_statuses[].upload_status.options.url",
+	"image_statuses[].upload_statuses[].options.url",
+}
+
+const digestPrefix = "redacted:sha256:"
+
+// Digest is what a redacted string value is replaced with.
+func Digest(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return digestPrefix + hex.EncodeToString(sum[:])
+}
+
+// JSON returns data with the values selected by the rules redacted. Rules
+// which don't match anything are ignored, as are matches which aren't strings.
+func JSON(data []byte, rules []Rule) ([]byte, error) {
+	var doc interface{}
+	err := json.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rules {
+		walk(doc, strings.Split(string(r), "."), Digest)
+	}
+	return json.Marshal(doc)
+}
+
+// Redacted tells if any of the values selected by the rules was redacted,
+// such documents can't be used in place of the original anymore.
+func Redacted(data []byte, rules []Rule) (bool, error) {
+	var doc interface{}
+	err := json.Unmarshal(data, &doc)
+	if err != nil {
+		return false, err
+	}
+	redacted := false
+	for _, r := range rules {
+		walk(doc, strings.Split(string(r), "."), func(s string) string {
+			if strings.HasPrefix(s, digestPrefix) {
+				redacted = true
+			}
+			return s
+		})
+	}
+	return redacted, nil
+}
+
+// Value marshals v and redacts the result, it's meant for logging so
+// failures are returned in place of the document.
+func Value(v interface{}, rules []Rule) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "unable to marshal: " + err.Error()
+	}
+	data, err = JSON(data, rules)
+	if err != nil {
+		return "unable to redact: " + err.Error()
+	}
+	return string(data)
+}
+
+// walk replaces the string values selected by the path with what fn returns
+// for them.
+func walk(node interface{}, path []string, fn func(string) string) {
+	obj, ok := node.(map[string]interface{})
+	if !ok || len(path) == 0 {
+		return
+	}
+
+	key, each := strings.CutSuffix(path[0], "[]")
+	child, ok := obj[key]
+	if !ok {
+		return
+	}
+
+	if each {
+		arr, ok := child.([]interface{})
+		if !ok {
+			return
+		}
+		for i, elem := range arr {
+			if len(path) == 1 {
+				if s, ok := elem.(string); ok {
+					arr[i] = fn(s)
+				}
+				continue
+			}
+			walk(elem, path[1:], fn)
+		}
+		return
+	}
+
+	if len(path) == 1 {
+		if s, ok := child.(string); ok {
+			obj[key] = fn(s)
+		}
+		return
+	}
+	walk(child, path[1:], fn)
+}
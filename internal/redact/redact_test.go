@@ -0,0 +1,126 @@
+package redact
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDigest(t *testing.T) {
+	require.Equal(t, Digest("secret"), Digest("secret"))
+	require.NotEqual(t, Digest("secret"), Digest("other"))
+	require.True(t, strings.HasPrefix(Digest("secret"), "redacted:sha256:"))
+	require.NotContains(t, Digest("secret"), "secret")
+}
+
+func TestRules(t *testing.T) {
+	cases := []struct {
+		name  string
+		rules []Rule
+		in    string
+		out   string
+	}{
+		{
+			name:  "activation key",
+			rules: ComposeRequest,
+			in:    `{"customizations":{"subscription":{"activation-key":"key","organization":1}}}`,
+			out:   `{"customizations":{"subscription":{"activation-key":"` + Digest("key") + `","organization":1}}}`,
+		},
+		{
+			name:  "composer activation key",
+			rules: ComposerRequest,
+			in:    `{"customizations":{"subscription":{"activation_key":"key"}}}`,
+			out:   `{"customizations":{"subscription":{"activation_key":"` + Digest("key") + `"}}}`,
+		},
+		{
+			name:  "user passwords",
+			rules: ComposerRequest,
+			in:    `{"customizations":{"users":[{"name":"a","password":"$6$hash"},{"name":"b"}]}}`,
+			out:   `{"customizations":{"users":[{"name":"a","password":"` + Digest("$6$hash") + `"},{"name":"b"}]}}`,
+		},
+		{
+			name:  "file contents",
+			rules: ComposeRequest,
+			in:    `{"customizations":{"files":[{"path":"/etc/x","data":"contents"}]}}`,
+			out:   `{"customizations":{"files":[{"data":"` + Digest("contents") + `","path":"/etc/x"}]}}`,
+		},
+		{
+			name:  "ignition config",
+			rules: ComposeRequest,
+			in:    `{"customizations":{"ignition":{"embedded":{"config":"conf"}}}}`,
+			out:   `{"customizations":{"ignition":{"embedded":{"config":"` + Digest("conf") + `"}}}}`,
+		},
+		{
+			name:  "upload links",
+			rules: ComposerResponse,
+			in:    `{"image_status":{"upload_status":{"options":{"url":"link"}}},"image_statuses":[{"upload_statuses":[{"options":{"url":"link"}},{"options":{"ami":"ami-1"}}]}]}`,
+			out:   `{"image_status":{"upload_status":{"options":{"url":"` + Digest("link") + `"}}},"image_statuses":[{"upload_statuses":[{"options":{"url":"` + Digest("link") + `"}},{"options":{"ami":"ami-1"}}]}]}`,
+		},
+		{
+			name:  "no customizations",
+			rules: ComposeRequest,
+			in:    `{"distribution":"rhel-9"}`,
+			out:   `{"distribution":"rhel-9"}`,
+		},
+		{
+			name:  "unexpected types are left alone",
+			rules: ComposeRequest,
+			in:    `{"customizations":{"users":{"password":"x"},"files":[1],"subscription":{"activation-key":1}}}`,
+			out:   `{"customizations":{"files":[1],"subscription":{"activation-key":1},"users":{"password":"x"}}}`,
+		},
+		{
+			name:  "array of strings",
+			rules: []Rule{"tokens[]"},
+			in:    `{"tokens":["a","b"]}`,
+			out:   `{"tokens":["` + Digest("a") + `","` + Digest("b") + `"]}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, err := JSON([]byte(c.in), c.rules)
+			require.NoError(t, err)
+			require.JSONEq(t, c.out, string(out))
+		})
+	}
+}
+
+func TestValue(t *testing.T) {
+	v := map[string]interface{}{
+		"customizations": map[string]interface{}{
+			"subscription": map[string]interface{}{
+				"activation-key": "key",
+			},
+		},
+	}
+	out := Value(v, ComposeRequest)
+	require.NotContains(t, out, `"key"`)
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(out), &doc))
+
+	_, err := JSON([]byte("not json"), ComposeRequest)
+	require.Error(t, err)
+}
+
+func TestRedacted(t *testing.T) {
+	doc := []byte(`{"customizations": {"users": [{"name": "admin", "password": "secret"}], "files": [{"data": "redacted:sha256:abc"}]}}`)
+	redacted, err := Redacted(doc, ComposeRequest)
+	require.NoError(t, err)
+	require.True(t, redacted)
+
+	// only the values the rules select count
+	redacted, err = Redacted([]byte(`{"image_name": "redacted:sha256:abc", "customizations": {"users": [{"password": "secret"}]}}`), ComposeRequest)
+	require.NoError(t, err)
+	require.False(t, redacted)
+
+	out, err := JSON([]byte(`{"customizations": {"users": [{"password": "secret"}]}}`), ComposeRequest)
+	require.NoError(t, err)
+	redacted, err = Redacted(out, ComposeRequest)
+	require.NoError(t, err)
+	require.True(t, redacted)
+
+	_, err = Redacted([]byte("not json"), ComposeRequest)
+	require.Error(t, err)
+}
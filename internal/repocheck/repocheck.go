@@ -0,0 +1,220 @@
+// Package repocheck periodically checks that the repositories of the
+// distributions can be reached, so broken mirrors show up before composes
+// fail to depsolve.
+package repocheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/osbuild/image-builder/internal/distribution"
+	"github.com/osbuild/image-builder/internal/prometheus"
+)
+
+const (
+	// DefaultInterval is how often the repositories are checked.
+	DefaultInterval = 15 * time.Minute
+
+	requestTimeout = 10 * time.Second
+	workers        = 8
+)
+
+// Result is the outcome of the last check of a repository URL, which can be
+// shared by several distributions.
+type Result struct {
+	URL           string
+	Distributions []string
+	Architecture  string
+	Reachable     bool
+	StatusCode    int
+	LastModified  *time.Time
+	Error         string
+	CheckedAt     time.Time
+}
+
+type target struct {
+	url           string
+	architecture  string
+	distributions []string
+}
+
+type Checker struct {
+	client  *http.Client
+	targets []target
+
+	mu      sync.RWMutex
+	results map[string]Result
+}
+
+// New collects the repositories of all distributions. Repositories which
+// need RHSM client certificates are left out, they can't be checked without
+// an entitlement.
+func New(adr *distribution.AllDistroRegistry, client *http.Client) *Checker {
+	if client == nil {
+		client = &http.Client{Timeout: requestTimeout}
+	}
+
+	byURL := map[string]*target{}
+	for _, d := range adr.Available(true).List() {
+		archs := map[string]*distribution.Architecture{
+			"x86_64":  d.ArchX86,
+			"aarch64": d.Aarch64,
+		}
+		for archName, arch := range archs {
+			if arch == nil {
+				continue
+			}
+			for _, r := range arch.Repositories {
+				if r.Rhsm {
+					continue
+				}
+				u := metadataURL(r)
+				if u == "" {
+					continue
+				}
+				t, ok := byURL[u]
+				if !ok {
+					t = &target{url: u, architecture: archName}
+					byURL[u] = t
+				}
+				t.distributions = append(t.distributions, d.Distribution.Name)
+			}
+		}
+	}
+
+	c := &Checker{
+		client:  client,
+		results: map[string]Result{},
+	}
+	var urls []string
+	for u, t := range byURL {
+		sort.Strings(t.distributions)
+		c.targets = append(c.targets, *t)
+		urls = append(urls, u)
+	}
+	sort.Slice(c.targets, func(i, j int) bool { return c.targets[i].url < c.targets[j].url })
+	prometheus.SetLabelValues("repository", urls...)
+	return c
+}
+
+// metadataURL is what gets requested to decide if a repository works, for
+// baseurls that's the repomd.xml every repository has.
+func metadataURL(r distribution.Repository) string {
+	if r.Baseurl != nil && *r.Baseurl != "" {
+		return strings.TrimSuffix(*r.Baseurl, "/") + "/repodata/repomd.xml"
+	}
+	if r.Metalink != nil {
+		return *r.Metalink
+	}
+	return ""
+}
+
+// Run checks all repositories every interval until the context is cancelled.
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		c.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check requests the metadata of every repository once.
+func (c *Checker) Check(ctx context.Context) {
+	targets := make(chan target)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range targets {
+				c.store(c.check(ctx, t))
+			}
+		}()
+	}
+	for _, t := range c.targets {
+		targets <- t
+	}
+	close(targets)
+	wg.Wait()
+}
+
+func (c *Checker) check(ctx context.Context, t target) Result {
+	result := Result{
+		URL:           t.url,
+		Distributions: t.distributions,
+		Architecture:  t.architecture,
+		CheckedAt:     time.Now().UTC(),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, t.url, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer func() {
+		err := resp.Body.Close()
+		if err != nil {
+			logrus.Errorf("Unable to close response body of %s: %v", t.url, err)
+		}
+	}()
+
+	result.StatusCode = resp.StatusCode
+	result.Reachable = resp.StatusCode == http.StatusOK
+	if !result.Reachable {
+		result.Error = fmt.Sprintf("unexpected status %s", resp.Status)
+	}
+	if lm, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		result.LastModified = &lm
+	}
+	return result
+}
+
+func (c *Checker) store(r Result) {
+	c.mu.Lock()
+	previous, seen := c.results[r.URL]
+	c.results[r.URL] = r
+	c.mu.Unlock()
+
+	if !r.Reachable && (!seen || previous.Reachable) {
+		logrus.Warnf("Repository %s of %s is unreachable: %s", r.URL, strings.Join(r.Distributions, ", "), r.Error)
+	}
+
+	reachable := 0.0
+	if r.Reachable {
+		reachable = 1
+	}
+	prometheus.RepositoryReachable.WithLabelValues(r.URL).Set(reachable)
+	if r.LastModified != nil {
+		prometheus.RepositoryLastModified.WithLabelValues(r.URL).Set(float64(r.LastModified.Unix()))
+	}
+}
+
+// Results returns the last result of every repository checked so far.
+func (c *Checker) Results() []Result {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	results := make([]Result, 0, len(c.results))
+	for _, r := range c.results {
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].URL < results[j].URL })
+	return results
+}
@@ -0,0 +1,84 @@
+package repocheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/distribution"
+)
+
+const testDistro = `{
+  "distribution": {
+    "name": "test-distro",
+    "no_package_list": true
+  },
+  "x86_64": {
+    "image_types": ["guest-image"],
+    "repositories": [
+      {"id": "baseos", "baseurl": "%[1]s/baseos/"},
+      {"id": "appstream", "metalink": "%[1]s/metalink"},
+      {"id": "rhsm", "baseurl": "%[1]s/rhsm", "rhsm": true}
+    ]
+  },
+  "aarch64": {
+    "image_types": ["guest-image"],
+    "repositories": [
+      {"id": "baseos", "baseurl": "%[1]s/broken"}
+    ]
+  }
+}`
+
+func TestCheck(t *testing.T) {
+	lastModified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodHead, r.Method)
+		requested = append(requested, r.URL.Path)
+		switch r.URL.Path {
+		case "/baseos/repodata/repomd.xml":
+			w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		case "/metalink":
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	distsDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(distsDir, "test-distro"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(distsDir, "test-distro", "test-distro.json"), []byte(fmt.Sprintf(testDistro, srv.URL)), 0600))
+	adr, err := distribution.LoadDistroRegistry(distsDir)
+	require.NoError(t, err)
+
+	c := New(adr, srv.Client())
+	require.Len(t, c.Results(), 0)
+	c.Check(context.Background())
+
+	require.ElementsMatch(t, []string{"/baseos/repodata/repomd.xml", "/metalink", "/broken/repodata/repomd.xml"}, requested)
+	results := c.Results()
+	require.Len(t, results, 3)
+
+	require.Equal(t, srv.URL+"/baseos/repodata/repomd.xml", results[0].URL)
+	require.True(t, results[0].Reachable)
+	require.Equal(t, "x86_64", results[0].Architecture)
+	require.Equal(t, []string{"test-distro"}, results[0].Distributions)
+	require.Equal(t, lastModified, *results[0].LastModified)
+
+	require.Equal(t, srv.URL+"/broken/repodata/repomd.xml", results[1].URL)
+	require.False(t, results[1].Reachable)
+	require.Equal(t, http.StatusNotFound, results[1].StatusCode)
+	require.Equal(t, "aarch64", results[1].Architecture)
+	require.Contains(t, results[1].Error, "404")
+
+	require.Equal(t, srv.URL+"/metalink", results[2].URL)
+	require.True(t, results[2].Reachable)
+	require.Nil(t, results[2].LastModified)
+}
@@ -0,0 +1,122 @@
+// Package sharelink signs and verifies the tokens of share links, which grant
+// read-only access to a single compose until they expire.
+//
+// A token is the id of the signing key, the link and its HMAC-SHA256, each
+// base64 encoded and separated by dots. Keys are rotated by adding a new key
+// in front and keeping the old ones until the links they signed expired.
+package sharelink
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MinKeyLength is the shortest accepted signing key, in bytes.
+const MinKeyLength = 32
+
+var (
+	ErrInvalidToken = errors.New("invalid share link")
+	ErrExpired      = errors.New("share link expired")
+)
+
+// Link is what a token grants access to.
+type Link struct {
+	ComposeId uuid.UUID `json:"c"`
+	OrgId     string    `json:"o"`
+	ExpiresAt time.Time `json:"e"`
+}
+
+type Signer struct {
+	current string
+	keys    map[string][]byte
+}
+
+// ParseSigner parses a comma separated list of id:base64-key pairs, the first
+// key signs new links, all of them verify.
+func ParseSigner(spec string) (*Signer, error) {
+	s := Signer{
+		keys: map[string][]byte{},
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" || strings.Contains(id, ".") {
+			return nil, fmt.Errorf("key entry needs to be in the id:key format")
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %s is not valid base64: %w", id, err)
+		}
+		if len(key) < MinKeyLength {
+			return nil, fmt.Errorf("key %s is shorter than %d bytes", id, MinKeyLength)
+		}
+		if _, ok := s.keys[id]; ok {
+			return nil, fmt.Errorf("key %s is listed twice", id)
+		}
+		if s.current == "" {
+			s.current = id
+		}
+		s.keys[id] = key
+	}
+	if s.current == "" {
+		return nil, fmt.Errorf("no share link keys")
+	}
+	return &s, nil
+}
+
+func mac(key []byte, id, payload string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(id + "." + payload))
+	return m.Sum(nil)
+}
+
+func (s *Signer) Sign(link Link) (string, error) {
+	data, err := json.Marshal(link)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	signature := base64.RawURLEncoding.EncodeToString(mac(s.keys[s.current], s.current, payload))
+	return strings.Join([]string{s.current, payload, signature}, "."), nil
+}
+
+// Verify returns the link of a token signed by one of the keys, as long as it
+// hasn't expired at now.
+func (s *Signer) Verify(token string, now time.Time) (Link, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Link{}, ErrInvalidToken
+	}
+	key, ok := s.keys[parts[0]]
+	if !ok {
+		return Link{}, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, mac(key, parts[0], parts[1])) {
+		return Link{}, ErrInvalidToken
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Link{}, ErrInvalidToken
+	}
+	var link Link
+	err = json.Unmarshal(data, &link)
+	if err != nil || link.ComposeId == uuid.Nil || link.OrgId == "" {
+		return Link{}, ErrInvalidToken
+	}
+	if !now.Before(link.ExpiresAt) {
+		return Link{}, ErrExpired
+	}
+	return link, nil
+}
@@ -0,0 +1,90 @@
+package sharelink
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func key(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+}
+
+func TestParseSigner(t *testing.T) {
+	s, err := ParseSigner("new:" + key('a') + ", old:" + key('b'))
+	require.NoError(t, err)
+	require.Equal(t, "new", s.current)
+	require.Len(t, s.keys, 2)
+
+	for _, spec := range []string{
+		"",
+		" , ",
+		key('a'),
+		"a.b:" + key('a'),
+		"a:notbase64!",
+		"a:" + base64.StdEncoding.EncodeToString([]byte("short")),
+		"a:" + key('a') + ",a:" + key('b'),
+	} {
+		_, err = ParseSigner(spec)
+		require.Error(t, err, spec)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	link := Link{
+		ComposeId: uuid.New(),
+		OrgId:     "000000",
+		ExpiresAt: now.Add(time.Hour),
+	}
+
+	s, err := ParseSigner("a:" + key('a'))
+	require.NoError(t, err)
+	token, err := s.Sign(link)
+	require.NoError(t, err)
+
+	verified, err := s.Verify(token, now)
+	require.NoError(t, err)
+	require.Equal(t, link, verified)
+
+	_, err = s.Verify(token, now.Add(time.Hour))
+	require.ErrorIs(t, err, ErrExpired)
+
+	parts := strings.Split(token, ".")
+	for _, tampered := range []string{
+		"",
+		"a." + parts[1],
+		"b." + parts[1] + "." + parts[2],
+		"a." + parts[1] + "x." + parts[2],
+		"a." + parts[1] + "." + parts[2][1:],
+	} {
+		_, err = s.Verify(tampered, now)
+		require.ErrorIs(t, err, ErrInvalidToken, tampered)
+	}
+
+	// other keys don't verify it
+	other, err := ParseSigner("a:" + key('b'))
+	require.NoError(t, err)
+	_, err = other.Verify(token, now)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestKeyRotation(t *testing.T) {
+	now := time.Now()
+	old, err := ParseSigner("old:" + key('a'))
+	require.NoError(t, err)
+	token, err := old.Sign(Link{ComposeId: uuid.New(), OrgId: "000000", ExpiresAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+
+	rotated, err := ParseSigner("new:" + key('b') + ",old:" + key('a'))
+	require.NoError(t, err)
+	_, err = rotated.Verify(token, now)
+	require.NoError(t, err)
+	newToken, err := rotated.Sign(Link{ComposeId: uuid.New(), OrgId: "000000", ExpiresAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(newToken, "new."))
+}
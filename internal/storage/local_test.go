@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	l, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	key := ComposeKey(uuid.New(), "metadata")
+	_, err = l.Get(ctx, key)
+	require.ErrorIs(t, err, ErrObjectNotFound)
+
+	require.NoError(t, l.Put(ctx, key, []byte("first")))
+	require.NoError(t, l.Put(ctx, key, []byte("second")))
+	data, err := l.Get(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, []byte("second"), data)
+
+	require.NoError(t, l.Delete(ctx, key))
+	_, err = l.Get(ctx, key)
+	require.ErrorIs(t, err, ErrObjectNotFound)
+	// deleting twice is fine
+	require.NoError(t, l.Delete(ctx, key))
+
+	_, err = l.Get(ctx, "../escape")
+	require.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	s, err := New(Config{})
+	require.NoError(t, err)
+	require.Nil(t, s)
+
+	s, err = New(Config{Backend: "local", LocalDir: t.TempDir()})
+	require.NoError(t, err)
+	require.IsType(t, &Local{}, s)
+
+	_, err = New(Config{Backend: "floppy"})
+	require.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// URLSigner turns the URL of an object into a link which can be downloaded
+// from for a limited time only.
+type URLSigner interface {
+	SignURL(rawURL string, lifetime time.Duration) (string, error)
+}
+
+// S3Signer presigns GET requests of objects in the buckets its credentials
+// can read, the bucket of the URL is used so the configured one is ignored.
+// GCS objects are signed the same way through its XML API.
+type S3Signer struct {
+	client *s3.S3
+}
+
+func NewS3Signer(conf S3Config) (*S3Signer, error) {
+	if conf.AccessKeyID == "" {
+		return nil, fmt.Errorf("s3 signer needs credentials")
+	}
+	awsConf := aws.NewConfig().
+		WithRegion(conf.Region).
+		WithCredentials(credentials.NewStaticCredentials(conf.AccessKeyID, conf.SecretAccessKey, ""))
+	if conf.Endpoint != "" {
+		awsConf = awsConf.WithEndpoint(conf.Endpoint).WithS3ForcePathStyle(true)
+	}
+
+	sess, err := session.NewSession(awsConf)
+	if err != nil {
+		return nil, err
+	}
+	return &S3Signer{client: s3.New(sess)}, nil
+}
+
+func (s *S3Signer) SignURL(rawURL string, lifetime time.Duration) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	bucket, key, err := objectOf(u)
+	if err != nil {
+		return "", err
+	}
+	req, _ := s.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	return req.Presign(lifetime)
+}
+
+// objectOf returns the bucket and key of an object URL. AWS hosts the bucket
+// either in the host name or in the first path segment, other object storages
+// always in the path. Signatures in the query are dropped.
+func objectOf(u *url.URL) (string, string, error) {
+	path := strings.TrimPrefix(u.Path, "/")
+	host := u.Hostname()
+	if strings.HasSuffix(host, ".amazonaws.com") && !strings.HasPrefix(host, "s3.") && !strings.HasPrefix(host, "s3-") {
+		i := strings.Index(host, ".s3")
+		if i <= 0 || path == "" {
+			return "", "", fmt.Errorf("%s is not an object URL", u.Redacted())
+		}
+		return host[:i], path, nil
+	}
+
+	bucket, key, ok := strings.Cut(path, "/")
+	if !ok || bucket == "" || key == "" {
+		return "", "", fmt.Errorf("%s is not an object URL", u.Redacted())
+	}
+	return bucket, key, nil
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestObjectOf(t *testing.T) {
+	cases := map[string][2]string{
+		"https://bucket.s3.amazonaws.com/composes/image.qcow2":                     {"bucket", "composes/image.qcow2"},
+		"https://bucket.s3.us-east-1.amazonaws.com/image.qcow2?X-Amz-Signature=ab": {"bucket", "image.qcow2"},
+		"https://s3.us-east-1.amazonaws.com/bucket/image.qcow2":                    {"bucket", "image.qcow2"},
+		"https://storage.googleapis.com/bucket/image.iso":                          {"bucket", "image.iso"},
+	}
+	for raw, want := range cases {
+		u, err := url.Parse(raw)
+		require.NoError(t, err)
+		bucket, key, err := objectOf(u)
+		require.NoError(t, err, raw)
+		require.Equal(t, want, [2]string{bucket, key}, raw)
+	}
+
+	for _, raw := range []string{"https://bucket.s3.amazonaws.com/", "https://storage.googleapis.com/bucket"} {
+		u, err := url.Parse(raw)
+		require.NoError(t, err)
+		_, _, err = objectOf(u)
+		require.Error(t, err, raw)
+	}
+}
+
+func TestS3Signer(t *testing.T) {
+	_, err := NewS3Signer(S3Config{Region: "us-east-1"})
+	require.Error(t, err)
+
+	s, err := NewS3Signer(S3Config{
+		Region:          "us-east-1",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+	})
+	require.NoError(t, err)
+	signed, err := s.SignURL("https://bucket.s3.us-east-1.amazonaws.com/image.qcow2?X-Amz-Signature=stale", 15*time.Minute)
+	require.NoError(t, err)
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	require.Equal(t, "/image.qcow2", u.Path)
+	require.Contains(t, u.Host, "bucket")
+	require.Equal(t, "900", u.Query().Get("X-Amz-Expires"))
+	require.NotEmpty(t, u.Query().Get("X-Amz-Signature"))
+	require.NotEqual(t, "stale", u.Query().Get("X-Amz-Signature"))
+	require.NotContains(t, signed, "secret")
+}
@@ -0,0 +1,47 @@
+// Package storage keeps large compose artifacts (manifests, package lists,
+// SBOMs, ...) out of the database. Only a reference to the object is stored
+// in postgres.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var ErrObjectNotFound = errors.New("object not found")
+
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type Config struct {
+	// Backend is one of "local" or "s3", empty disables the storage.
+	Backend  string
+	LocalDir string
+	S3       S3Config
+}
+
+// New returns nil if no backend is configured.
+func New(conf Config) (Storage, error) {
+	switch conf.Backend {
+	case "":
+		return nil, nil
+	case "local":
+		return NewLocal(conf.LocalDir)
+	case "s3":
+		return NewS3(conf.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", conf.Backend)
+	}
+}
+
+// ComposeKey returns the key under which the artifact of the given kind is
+// stored for a compose.
+func ComposeKey(composeId uuid.UUID, kind string) string {
+	return fmt.Sprintf("composes/%s/%s", composeId, kind)
+}
@@ -0,0 +1,181 @@
+// Package telemetry reports the anonymous usage of on-premise deployments
+// whose administrators opted in. Only aggregate counts of builds and their
+// errors are sent, nothing in them identifies an org, a user or a compose.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+const (
+	// DefaultInterval is how often a report is sent, each one covers the
+	// composes created since the previous one.
+	DefaultInterval = 24 * time.Hour
+
+	// Format is raised whenever the meaning of the report fields changes.
+	Format = 1
+
+	requestTimeout = 30 * time.Second
+
+	// unclassifiedError is the class of the failures without an error code,
+	// the builds which failed in composer
+	unclassifiedError = "unclassified"
+)
+
+// Report is exactly what gets sent.
+type Report struct {
+	Format int          `json:"format"`
+	From   time.Time    `json:"from"`
+	To     time.Time    `json:"to"`
+	Builds []BuildCount `json:"builds"`
+	Errors []ErrorCount `json:"errors"`
+}
+
+// BuildCount is the number of builds of an image type of a distribution
+// which ended up in the same status.
+type BuildCount struct {
+	Distribution string `json:"distribution"`
+	ImageType    string `json:"image_type"`
+	Status       string `json:"status"`
+	Count        int    `json:"count"`
+}
+
+// ErrorCount is the number of failed builds of an error class.
+type ErrorCount struct {
+	Class string `json:"class"`
+	Count int    `json:"count"`
+}
+
+// Marshal encodes the report the way it is sent.
+func (r Report) Marshal() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+type Reporter struct {
+	db       db.DB
+	endpoint string
+	client   *http.Client
+}
+
+// New creates a reporter sending to endpoint, a nil client uses a default
+// one.
+func New(dbase db.DB, endpoint string, client *http.Client) *Reporter {
+	if client == nil {
+		client = &http.Client{Timeout: requestTimeout}
+	}
+	return &Reporter{
+		db:       dbase,
+		endpoint: endpoint,
+		client:   client,
+	}
+}
+
+// Run sends a report every interval until the context is cancelled, the
+// first one once the first interval passed.
+func (r *Reporter) Run(ctx context.Context, interval time.Duration) {
+	logrus.Infof("Sending anonymous usage telemetry to %s every %s", r.endpoint, interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		report, err := r.Collect(ctx, interval)
+		if err != nil {
+			logrus.Errorf("Collecting the telemetry report failed: %v", err)
+			continue
+		}
+		err = r.Send(ctx, report)
+		if err != nil {
+			logrus.Errorf("Sending the telemetry report failed: %v", err)
+		}
+	}
+}
+
+// Collect counts the builds of the composes created in the last window.
+func (r *Reporter) Collect(ctx context.Context, window time.Duration) (Report, error) {
+	to := time.Now().UTC()
+	counts, err := r.db.CountComposesByKind(ctx, window)
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{
+		Format: Format,
+		From:   to.Add(-window),
+		To:     to,
+		Builds: []BuildCount{},
+		Errors: []ErrorCount{},
+	}
+	builds := map[BuildCount]int{}
+	errs := map[string]int{}
+	for _, c := range counts {
+		status := db.ComposeStatusUnfinished
+		if c.Status != nil {
+			status = *c.Status
+		}
+		builds[BuildCount{Distribution: c.Distribution, ImageType: c.ImageType, Status: status}] += c.Count
+		if status == "failure" {
+			class := unclassifiedError
+			if c.ErrorCode != nil {
+				class = *c.ErrorCode
+			}
+			errs[class] += c.Count
+		}
+	}
+	for b, count := range builds {
+		b.Count = count
+		report.Builds = append(report.Builds, b)
+	}
+	sort.Slice(report.Builds, func(i, j int) bool {
+		a, b := report.Builds[i], report.Builds[j]
+		if a.Distribution != b.Distribution {
+			return a.Distribution < b.Distribution
+		}
+		if a.ImageType != b.ImageType {
+			return a.ImageType < b.ImageType
+		}
+		return a.Status < b.Status
+	})
+	for class, count := range errs {
+		report.Errors = append(report.Errors, ErrorCount{Class: class, Count: count})
+	}
+	sort.Slice(report.Errors, func(i, j int) bool { return report.Errors[i].Class < report.Errors[j].Class })
+	return report, nil
+}
+
+// Send posts the report to the endpoint.
+func (r *Reporter) Send(ctx context.Context, report Report) error {
+	body, err := report.Marshal()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s answered with status %d", r.endpoint, resp.StatusCode)
+	}
+	logrus.Infof("Sent the telemetry report of %d kinds of builds", len(report.Builds))
+	return nil
+}
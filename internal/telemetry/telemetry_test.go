@@ -0,0 +1,92 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+type fakeDB struct {
+	db.DB
+	counts []db.ComposeCount
+	since  time.Duration
+}
+
+func (f *fakeDB) CountComposesByKind(ctx context.Context, since time.Duration) ([]db.ComposeCount, error) {
+	f.since = since
+	return f.counts, nil
+}
+
+func TestCollect(t *testing.T) {
+	dbase := &fakeDB{
+		counts: []db.ComposeCount{
+			{Distribution: "rhel-9", ImageType: "guest-image", Status: common.ToPtr("failure"), ErrorCode: common.ToPtr("DepsolveFailed"), Count: 2},
+			{Distribution: "rhel-9", ImageType: "guest-image", Status: common.ToPtr("failure"), Count: 1},
+			{Distribution: "rhel-9", ImageType: "aws", Status: common.ToPtr("success"), Count: 5},
+			{Distribution: "centos-9", ImageType: "aws", Count: 3},
+			{Distribution: "centos-9", ImageType: "aws", Status: common.ToPtr("failure"), ErrorCode: common.ToPtr("DepsolveFailed"), Count: 1},
+		},
+	}
+	report, err := New(dbase, "", nil).Collect(context.Background(), time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, dbase.since)
+	require.Equal(t, time.Hour, report.To.Sub(report.From))
+	require.Equal(t, Format, report.Format)
+	require.Equal(t, []BuildCount{
+		{Distribution: "centos-9", ImageType: "aws", Status: "failure", Count: 1},
+		{Distribution: "centos-9", ImageType: "aws", Status: db.ComposeStatusUnfinished, Count: 3},
+		{Distribution: "rhel-9", ImageType: "aws", Status: "success", Count: 5},
+		{Distribution: "rhel-9", ImageType: "guest-image", Status: "failure", Count: 3},
+	}, report.Builds)
+	require.Equal(t, []ErrorCount{
+		{Class: "DepsolveFailed", Count: 3},
+		{Class: unclassifiedError, Count: 1},
+	}, report.Errors)
+
+	// an empty report still has lists
+	dbase.counts = nil
+	report, err = New(dbase, "", nil).Collect(context.Background(), time.Hour)
+	require.NoError(t, err)
+	body, err := report.Marshal()
+	require.NoError(t, err)
+	require.Contains(t, string(body), `"builds": []`)
+	require.Contains(t, string(body), `"errors": []`)
+}
+
+func TestSend(t *testing.T) {
+	report := Report{
+		Format: Format,
+		Builds: []BuildCount{{Distribution: "rhel-9", ImageType: "aws", Status: "success", Count: 5}},
+		Errors: []ErrorCount{},
+	}
+	expected, err := report.Marshal()
+	require.NoError(t, err)
+
+	status := http.StatusNoContent
+	var received []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var err error
+		received, err = io.ReadAll(r.Body)
+		require.NoError(t, err)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	// the preview is exactly what gets sent
+	reporter := New(&fakeDB{}, srv.URL, srv.Client())
+	require.NoError(t, reporter.Send(context.Background(), report))
+	require.Equal(t, expected, received)
+
+	status = http.StatusInternalServerError
+	require.Error(t, reporter.Send(context.Background(), report))
+}
@@ -0,0 +1,73 @@
+package tutils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/redhatinsights/identity"
+)
+
+// IdentityBuilder builds x-rh-identity headers for tests which need another
+// identity than the AuthString ones. It starts out like those, an entitled
+// org admin.
+type IdentityBuilder struct {
+	id identity.XRHID
+}
+
+func NewIdentity(orgId string) *IdentityBuilder {
+	entitlements := map[string]identity.ServiceDetails{}
+	for _, service := range []string{"insights", "rhel", "smart_management", "openshift", "hybrid", "migrations", "ansible"} {
+		entitlements[service] = identity.ServiceDetails{IsEntitled: true}
+	}
+	return &IdentityBuilder{
+		id: identity.XRHID{
+			Identity: identity.Identity{
+				AccountNumber: "000000",
+				OrgID:         orgId,
+				Internal:      identity.Internal{OrgID: orgId},
+				Type:          "User",
+				User: identity.User{
+					Username:  "user",
+					Email:     "user@user.user",
+					FirstName: "user",
+					LastName:  "user",
+					Active:    true,
+					OrgAdmin:  true,
+					Internal:  true,
+					Locale:    "en-US",
+				},
+			},
+			Entitlements: entitlements,
+		},
+	}
+}
+
+func (b *IdentityBuilder) Email(email string) *IdentityBuilder {
+	b.id.Identity.User.Email = email
+	return b
+}
+
+func (b *IdentityBuilder) OrgAdmin(admin bool) *IdentityBuilder {
+	b.id.Identity.User.OrgAdmin = admin
+	return b
+}
+
+// Type sets the type of the identity, like System or ServiceAccount.
+func (b *IdentityBuilder) Type(identityType string) *IdentityBuilder {
+	b.id.Identity.Type = identityType
+	return b
+}
+
+func (b *IdentityBuilder) Entitled(service string, entitled bool) *IdentityBuilder {
+	b.id.Entitlements[service] = identity.ServiceDetails{IsEntitled: entitled}
+	return b
+}
+
+// Base64 returns the header value.
+func (b *IdentityBuilder) Base64() string {
+	data, err := json.Marshal(b.id)
+	if err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
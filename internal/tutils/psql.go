@@ -132,5 +132,5 @@ func (p *PSQLContainer) NewDB() (db.DB, error) {
 	if err != nil {
 		return nil, fmt.Errorf("tern command error: %w, output: %s", err, out)
 	}
-	return db.InitDBConnectionPool(fmt.Sprintf("postgres://postgres@localhost:%d/%s", p.port, dbName))
+	return db.InitDBConnectionPool(fmt.Sprintf("postgres://postgres@localhost:%d/%s", p.port, dbName), db.PoolConfig{})
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	fedora_identity "github.com/osbuild/community-gateway/oidc-authorizer/pkg/identity"
@@ -55,67 +56,110 @@ func GetResponseBody(t *testing.T, url string, auth *string) (int, string) {
 }
 
 func PostResponseBody(t *testing.T, url string, compose interface{}) (int, string) {
-	buf, err := json.Marshal(compose)
-	require.NoError(t, err)
+	return ResponseBody(t, "POST", url, AuthString0, compose)
+}
+
+func PutResponseBody(t *testing.T, url string, compose interface{}) (int, string) {
+	return ResponseBody(t, "PUT", url, AuthString0, compose)
+}
+
+func DeleteResponseBody(t *testing.T, url string) (int, string) {
+	return ResponseBody(t, "DELETE", url, AuthString0, nil)
+}
+
+// ResponseBody sends the body as JSON with the identity header auth, see
+// NewIdentity for other identities than the AuthString ones. A nil body
+// sends none.
+func ResponseBody(t *testing.T, method string, url string, auth string, body interface{}) (int, string) {
+	var reader io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(buf)
+	}
 
 	client := &http.Client{}
-	request, err := http.NewRequest("POST", url, bytes.NewReader(buf))
+	request, err := http.NewRequest(method, url, reader)
 	require.NoError(t, err)
 	request.Header.Add("Content-Type", "application/json")
-	request.Header.Add("x-rh-identity", AuthString0)
+	request.Header.Add("x-rh-identity", auth)
 
 	response, err := client.Do(request)
 	require.NoError(t, err)
-	if err != nil {
-		/* #nosec G307 */
-		defer response.Body.Close()
+	/* #nosec G307 */
+	defer response.Body.Close()
+
+	respBody, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+
+	return response.StatusCode, string(respBody)
+}
+
+// PatchResponse sends the patch as is, the headers are returned for the ETag.
+func PatchResponse(t *testing.T, url string, contentType string, patch string, ifMatch string) (int, http.Header, string) {
+	client := &http.Client{}
+	request, err := http.NewRequest("PATCH", url, bytes.NewReader([]byte(patch)))
+	require.NoError(t, err)
+	request.Header.Add("Content-Type", contentType)
+	request.Header.Add("x-rh-identity", AuthString0)
+	if ifMatch != "" {
+		request.Header.Add("If-Match", ifMatch)
 	}
 
+	response, err := client.Do(request)
+	require.NoError(t, err)
+	/* #nosec G307 */
+	defer response.Body.Close()
+
 	body, err := io.ReadAll(response.Body)
 	require.NoError(t, err)
 
-	return response.StatusCode, string(body)
+	return response.StatusCode, response.Header, string(body)
 }
 
-func PutResponseBody(t *testing.T, url string, compose interface{}) (int, string) {
-	buf, err := json.Marshal(compose)
-	require.NoError(t, err)
+// ConditionalResponse sends the body as JSON with the If-Match header, the
+// headers are returned for the ETag. A nil body sends none.
+func ConditionalResponse(t *testing.T, method string, url string, body interface{}, ifMatch string) (int, http.Header, string) {
+	var reader io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(buf)
+	}
 
 	client := &http.Client{}
-	request, err := http.NewRequest("PUT", url, bytes.NewReader(buf))
+	request, err := http.NewRequest(method, url, reader)
 	require.NoError(t, err)
 	request.Header.Add("Content-Type", "application/json")
 	request.Header.Add("x-rh-identity", AuthString0)
+	request.Header.Add("If-Match", ifMatch)
 
 	response, err := client.Do(request)
 	require.NoError(t, err)
-	if err != nil {
-		/* #nosec G307 */
-		defer response.Body.Close()
-	}
+	/* #nosec G307 */
+	defer response.Body.Close()
 
-	body, err := io.ReadAll(response.Body)
+	respBody, err := io.ReadAll(response.Body)
 	require.NoError(t, err)
 
-	return response.StatusCode, string(body)
+	return response.StatusCode, response.Header, string(respBody)
 }
 
-func DeleteResponseBody(t *testing.T, url string) (int, string) {
+// RawResponse sends the body as is with the content type.
+func RawResponse(t *testing.T, method string, url string, contentType string, body string) (int, string) {
 	client := &http.Client{}
-	request, err := http.NewRequest("DELETE", url, nil)
+	request, err := http.NewRequest(method, url, strings.NewReader(body))
 	require.NoError(t, err)
-	request.Header.Add("Content-Type", "application/json")
+	request.Header.Add("Content-Type", contentType)
 	request.Header.Add("x-rh-identity", AuthString0)
 
 	response, err := client.Do(request)
 	require.NoError(t, err)
-	if err != nil {
-		/* #nosec G307 */
-		defer response.Body.Close()
-	}
+	/* #nosec G307 */
+	defer response.Body.Close()
 
-	body, err := io.ReadAll(response.Body)
+	respBody, err := io.ReadAll(response.Body)
 	require.NoError(t, err)
 
-	return response.StatusCode, string(body)
+	return response.StatusCode, string(respBody)
 }
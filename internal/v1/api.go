@@ -7,12 +7,32 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/oapi-codegen/runtime"
 	openapi_types "github.com/oapi-codegen/runtime/types"
 )
 
+// Defines values for AWSUploadRequestOptionsBootMode.
+const (
+	LegacyBios    AWSUploadRequestOptionsBootMode = "legacy-bios"
+	Uefi          AWSUploadRequestOptionsBootMode = "uefi"
+	UefiPreferred AWSUploadRequestOptionsBootMode = "uefi-preferred"
+)
+
+// Defines values for AzureUploadRequestOptionsHyperVGeneration.
+const (
+	V1 AzureUploadRequestOptionsHyperVGeneration = "V1"
+	V2 AzureUploadRequestOptionsHyperVGeneration = "V2"
+)
+
+// Defines values for BlueprintVisibility.
+const (
+	Org     BlueprintVisibility = "org"
+	Private BlueprintVisibility = "private"
+)
+
 // Defines values for ClientId.
 const (
 	Api ClientId = "api"
@@ -27,6 +47,27 @@ const (
 	CloneStatusResponseStatusSuccess CloneStatusResponseStatus = "success"
 )
 
+// Defines values for ComposeEventType.
+const (
+	ComposeCreated       ComposeEventType = "compose.created"
+	ComposeStatusChanged ComposeEventType = "compose.status_changed"
+)
+
+// Defines values for ComposeHookRunStatus.
+const (
+	ComposeHookRunStatusFailed    ComposeHookRunStatus = "failed"
+	ComposeHookRunStatusPending   ComposeHookRunStatus = "pending"
+	ComposeHookRunStatusSucceeded ComposeHookRunStatus = "succeeded"
+)
+
+// Defines values for ComposePipelineState.
+const (
+	ComposePipelineStateFailed     ComposePipelineState = "failed"
+	ComposePipelineStateTriggered  ComposePipelineState = "triggered"
+	ComposePipelineStateTriggering ComposePipelineState = "triggering"
+	ComposePipelineStateWaiting    ComposePipelineState = "waiting"
+)
+
 // Defines values for CustomizationsPartitioningMode.
 const (
 	AutoLvm CustomizationsPartitioningMode = "auto-lvm"
@@ -89,6 +130,22 @@ const (
 	Plain  FileDataEncoding = "plain"
 )
 
+// Defines values for GitOpsBlueprintStateState.
+const (
+	Conflict GitOpsBlueprintStateState = "conflict"
+	Created  GitOpsBlueprintStateState = "created"
+	InSync   GitOpsBlueprintStateState = "in_sync"
+	Missing  GitOpsBlueprintStateState = "missing"
+	Modified GitOpsBlueprintStateState = "modified"
+	Updated  GitOpsBlueprintStateState = "updated"
+)
+
+// Defines values for ImageRequestAdditionalArchitectures.
+const (
+	ImageRequestAdditionalArchitecturesAarch64 ImageRequestAdditionalArchitectures = "aarch64"
+	ImageRequestAdditionalArchitecturesX8664   ImageRequestAdditionalArchitectures = "x86_64"
+)
+
 // Defines values for ImageRequestArchitecture.
 const (
 	ImageRequestArchitectureAarch64 ImageRequestArchitecture = "aarch64"
@@ -116,14 +173,53 @@ const (
 	ImageTypesGuestImage        ImageTypes = "guest-image"
 	ImageTypesImageInstaller    ImageTypes = "image-installer"
 	ImageTypesOci               ImageTypes = "oci"
+	ImageTypesQcow2             ImageTypes = "qcow2"
 	ImageTypesRhelEdgeCommit    ImageTypes = "rhel-edge-commit"
 	ImageTypesRhelEdgeInstaller ImageTypes = "rhel-edge-installer"
 	ImageTypesVhd               ImageTypes = "vhd"
+	ImageTypesVirtualization    ImageTypes = "virtualization"
+	ImageTypesVmdk              ImageTypes = "vmdk"
 	ImageTypesVsphere           ImageTypes = "vsphere"
 	ImageTypesVsphereOva        ImageTypes = "vsphere-ova"
 	ImageTypesWsl               ImageTypes = "wsl"
 )
 
+// Defines values for JSONPatchOperationOp.
+const (
+	Add     JSONPatchOperationOp = "add"
+	Copy    JSONPatchOperationOp = "copy"
+	Move    JSONPatchOperationOp = "move"
+	Remove  JSONPatchOperationOp = "remove"
+	Replace JSONPatchOperationOp = "replace"
+	Test    JSONPatchOperationOp = "test"
+)
+
+// Defines values for LaunchFeature.
+const (
+	LocalStorage         LaunchFeature = "local-storage"
+	NestedVirtualization LaunchFeature = "nested-virtualization"
+)
+
+// Defines values for MarketplaceCheckName.
+const (
+	MarketplaceCheckNameAmi              MarketplaceCheckName = "ami"
+	MarketplaceCheckNameDefaultPasswords MarketplaceCheckName = "default-passwords"
+	MarketplaceCheckNameSshKeys          MarketplaceCheckName = "ssh-keys"
+	MarketplaceCheckNameSshdConfig       MarketplaceCheckName = "sshd-config"
+)
+
+// Defines values for OrgPolicySecretScanning.
+const (
+	Block OrgPolicySecretScanning = "block"
+	Warn  OrgPolicySecretScanning = "warn"
+)
+
+// Defines values for UploadGrantProvider.
+const (
+	UploadGrantProviderAws   UploadGrantProvider = "aws"
+	UploadGrantProviderAzure UploadGrantProvider = "azure"
+)
+
 // Defines values for UploadStatusStatus.
 const (
 	Failure UploadStatusStatus = "failure"
@@ -141,17 +237,66 @@ const (
 	UploadTypesOciObjectstorage UploadTypes = "oci.objectstorage"
 )
 
+// Defines values for WorkspaceRole.
+const (
+	Builder WorkspaceRole = "builder"
+	Viewer  WorkspaceRole = "viewer"
+)
+
+// Defines values for GetBlueprintInstanceTypesParamsProvider.
+const (
+	GetBlueprintInstanceTypesParamsProviderAws   GetBlueprintInstanceTypesParamsProvider = "aws"
+	GetBlueprintInstanceTypesParamsProviderAzure GetBlueprintInstanceTypesParamsProvider = "azure"
+	GetBlueprintInstanceTypesParamsProviderGcp   GetBlueprintInstanceTypesParamsProvider = "gcp"
+)
+
+// Defines values for GetBlueprintsParamsOwner.
+const (
+	Mine   GetBlueprintsParamsOwner = "mine"
+	Shared GetBlueprintsParamsOwner = "shared"
+)
+
+// Defines values for GetComposesParamsFields.
+const (
+	GetComposesParamsFieldsBlueprintId      GetComposesParamsFields = "blueprint_id"
+	GetComposesParamsFieldsBlueprintVersion GetComposesParamsFields = "blueprint_version"
+	GetComposesParamsFieldsClientId         GetComposesParamsFields = "client_id"
+	GetComposesParamsFieldsCreatedAt        GetComposesParamsFields = "created_at"
+	GetComposesParamsFieldsDescription      GetComposesParamsFields = "description"
+	GetComposesParamsFieldsExpiresAt        GetComposesParamsFields = "expires_at"
+	GetComposesParamsFieldsId               GetComposesParamsFields = "id"
+	GetComposesParamsFieldsImageName        GetComposesParamsFields = "image_name"
+	GetComposesParamsFieldsRequest          GetComposesParamsFields = "request"
+	GetComposesParamsFieldsStatus           GetComposesParamsFields = "status"
+)
+
+// Defines values for GetComposesParamsStatus.
+const (
+	GetComposesParamsStatusFailure    GetComposesParamsStatus = "failure"
+	GetComposesParamsStatusSuccess    GetComposesParamsStatus = "success"
+	GetComposesParamsStatusUnfinished GetComposesParamsStatus = "unfinished"
+)
+
+// Defines values for GetComposesParamsSort.
+const (
+	GetComposesParamsSortCreatedAt      GetComposesParamsSort = "created_at"
+	GetComposesParamsSortMinusCreatedAt GetComposesParamsSort = "-created_at"
+)
+
 // Defines values for GetPackagesParamsArchitecture.
 const (
 	GetPackagesParamsArchitectureAarch64 GetPackagesParamsArchitecture = "aarch64"
 	GetPackagesParamsArchitectureX8664   GetPackagesParamsArchitecture = "x86_64"
 )
 
-// AWSEC2Clone defines model for AWSEC2Clone.
+// AWSEC2Clone Exactly one of region and regions has to be given.
 type AWSEC2Clone struct {
 	// Region A region as described in
 	// https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/using-regions-availability-zones.html#concepts-regions
-	Region string `json:"region"`
+	Region *string `json:"region,omitempty"`
+
+	// Regions Regions to clone to in one request, a clone is created for each of them
+	Regions *[]string `json:"regions,omitempty"`
 
 	// ShareWithAccounts An array of AWS account IDs as described in
 	// https://docs.aws.amazon.com/IAM/latest/UserGuide/console_account-alias.html
@@ -169,10 +314,35 @@ type AWSS3UploadStatus struct {
 
 // AWSUploadRequestOptions defines model for AWSUploadRequestOptions.
 type AWSUploadRequestOptions struct {
+	// BootMode Boot mode the AMI is registered with. aarch64 images only boot with UEFI. Defaults to
+	// the boot mode of the image type.
+	BootMode *AWSUploadRequestOptionsBootMode `json:"boot_mode,omitempty"`
+
+	// EnaSupport Register the AMI with Elastic Network Adapter support, defaults to true.
+	EnaSupport *bool `json:"ena_support,omitempty"`
+
+	// HibernationCapable Prepare the image for instances launched with hibernation enabled.
+	HibernationCapable *bool `json:"hibernation_capable,omitempty"`
+
+	// ImdsV2Required Instances launched from the AMI require IMDSv2 for the instance metadata service.
+	ImdsV2Required    *bool     `json:"imds_v2_required,omitempty"`
 	ShareWithAccounts *[]string `json:"share_with_accounts,omitempty"`
-	ShareWithSources  *[]string `json:"share_with_sources,omitempty"`
+
+	// ShareWithGrants aliases of upload grants whose aws accounts the image is shared with
+	ShareWithGrants  *[]string `json:"share_with_grants,omitempty"`
+	ShareWithSources *[]string `json:"share_with_sources,omitempty"`
+
+	// SriovNetSupport Register the AMI with enhanced networking through the Intel 82599 VF, only for x86_64.
+	SriovNetSupport *bool `json:"sriov_net_support,omitempty"`
+
+	// TpmSupport Register the AMI with NitroTPM 2.0 support, requires the uefi boot mode.
+	TpmSupport *bool `json:"tpm_support,omitempty"`
 }
 
+// AWSUploadRequestOptionsBootMode Boot mode the AMI is registered with. aarch64 images only boot with UEFI. Defaults to
+// the boot mode of the image type.
+type AWSUploadRequestOptionsBootMode string
+
 // AWSUploadStatus defines model for AWSUploadStatus.
 type AWSUploadStatus struct {
 	Ami    string `json:"ami"`
@@ -181,8 +351,11 @@ type AWSUploadStatus struct {
 
 // ArchitectureItem defines model for ArchitectureItem.
 type ArchitectureItem struct {
-	Arch       string   `json:"arch"`
-	ImageTypes []string `json:"image_types"`
+	Arch string `json:"arch"`
+
+	// ImageTypeDetails Display names of the image types, in the same order as image_types.
+	ImageTypeDetails *[]ImageTypeDetails `json:"image_type_details,omitempty"`
+	ImageTypes       []string            `json:"image_types"`
 
 	// Repositories Base repositories for the given distribution and architecture.
 	Repositories []Repository `json:"repositories"`
@@ -193,6 +366,14 @@ type Architectures = []ArchitectureItem
 
 // AzureUploadRequestOptions defines model for AzureUploadRequestOptions.
 type AzureUploadRequestOptions struct {
+	// Grant Alias of an upload grant resolving the tenant and subscription IDs, instead of a source_id
+	// or a tenant_id and subscription_id.
+	Grant *string `json:"grant,omitempty"`
+
+	// HyperVGeneration Hyper-V generation of the image. V1 images boot with BIOS and are only available for
+	// x86_64, V2 images boot with UEFI. Defaults to V1 for x86_64 and V2 for aarch64.
+	HyperVGeneration *AzureUploadRequestOptionsHyperVGeneration `json:"hyper_v_generation,omitempty"`
+
 	// ImageName Name of the created image.
 	// Must begin with a letter or number, end with a letter, number or underscore, and may contain only letters, numbers, underscores, periods, or hyphens.
 	// The total length is limited to 60 characters.
@@ -216,11 +397,27 @@ type AzureUploadRequestOptions struct {
 	TenantId *string `json:"tenant_id,omitempty"`
 }
 
+// AzureUploadRequestOptionsHyperVGeneration Hyper-V generation of the image. V1 images boot with BIOS and are only available for
+// x86_64, V2 images boot with UEFI. Defaults to V1 for x86_64 and V2 for aarch64.
+type AzureUploadRequestOptionsHyperVGeneration string
+
 // AzureUploadStatus defines model for AzureUploadStatus.
 type AzureUploadStatus struct {
 	ImageName string `json:"image_name"`
 }
 
+// BlueprintCompatibilityReport What the blueprint uses which the new release lacks.
+type BlueprintCompatibilityReport struct {
+	// MissingPackages packages and package groups no repository of the new release ships, for any of the architectures
+	MissingPackages []string `json:"missing_packages"`
+
+	// PackagesChecked false when the new release publishes no package lists, the packages couldn't be checked
+	PackagesChecked bool `json:"packages_checked"`
+
+	// UnsupportedImageTypes image types the new release can't build, for any of the architectures
+	UnsupportedImageTypes []ImageTypes `json:"unsupported_image_types"`
+}
+
 // BlueprintExportResponse defines model for BlueprintExportResponse.
 type BlueprintExportResponse struct {
 	Customizations Customizations `json:"customizations"`
@@ -235,13 +432,47 @@ type BlueprintExportResponse struct {
 	Name         string            `json:"name"`
 }
 
+// BlueprintInstanceTypesResponse defines model for BlueprintInstanceTypesResponse.
+type BlueprintInstanceTypesResponse struct {
+	Data []InstanceType `json:"data"`
+
+	// LaunchRequirements What instances launched from the images of the blueprint need at least. Only the instance
+	// types meeting them are offered for launching the images, see /blueprints/{id}/instance_types.
+	LaunchRequirements *LaunchRequirements `json:"launch_requirements,omitempty"`
+}
+
 // BlueprintItem defines model for BlueprintItem.
 type BlueprintItem struct {
+	// CreatedBy email of the user who created the blueprint, unknown for older blueprints
+	CreatedBy      *string            `json:"created_by,omitempty"`
 	Description    string             `json:"description"`
 	Id             openapi_types.UUID `json:"id"`
 	LastModifiedAt string             `json:"last_modified_at"`
 	Name           string             `json:"name"`
 	Version        int                `json:"version"`
+
+	// Visibility Who in the organization sees the blueprint. Private blueprints are only visible to the user
+	// who created them, only they can change the visibility.
+	Visibility  BlueprintVisibility `json:"visibility"`
+	WorkspaceId *openapi_types.UUID `json:"workspace_id,omitempty"`
+}
+
+// BlueprintLifecycle Which images of the blueprint are kept. The others are deleted periodically, check
+// /blueprints/{id}/lifecycle/preview for what would be deleted.
+type BlueprintLifecycle struct {
+	// KeepLast Number of successful images of each image type to keep, older ones are deleted.
+	// Failed and running composes are never deleted.
+	KeepLast int `json:"keep_last"`
+}
+
+// BlueprintLifecyclePreview defines model for BlueprintLifecyclePreview.
+type BlueprintLifecyclePreview struct {
+	// Data the composes which would be deleted, newest first
+	Data []ComposesResponseItem `json:"data"`
+
+	// Lifecycle Which images of the blueprint are kept. The others are deleted periodically, check
+	// /blueprints/{id}/lifecycle/preview for what would be deleted.
+	Lifecycle *BlueprintLifecycle `json:"lifecycle,omitempty"`
 }
 
 // BlueprintMetadata defines model for BlueprintMetadata.
@@ -250,8 +481,32 @@ type BlueprintMetadata struct {
 	ParentId   *openapi_types.UUID `json:"parent_id"`
 }
 
+// BlueprintOutdatedImage An image of the blueprint, packages are the changes from the packages of its last compose to
+// the ones a rebuild would install.
+type BlueprintOutdatedImage struct {
+	Architecture string `json:"architecture"`
+
+	// ComposeId the last successful compose of the image, absent when there is none
+	ComposeId *openapi_types.UUID `json:"compose_id,omitempty"`
+	ImageType ImageTypes          `json:"image_type"`
+
+	// Outdated whether rebuilding the image would change its packages, false when it was never built
+	Outdated bool                `json:"outdated"`
+	Packages *ComposePackageDiff `json:"packages,omitempty"`
+}
+
+// BlueprintOutdatedResponse defines model for BlueprintOutdatedResponse.
+type BlueprintOutdatedResponse struct {
+	Images []BlueprintOutdatedImage `json:"images"`
+
+	// Outdated whether rebuilding any of the images would change its packages
+	Outdated bool `json:"outdated"`
+}
+
 // BlueprintResponse defines model for BlueprintResponse.
 type BlueprintResponse struct {
+	// CreatedBy email of the user who created the blueprint, unknown for older blueprints
+	CreatedBy      *string        `json:"created_by,omitempty"`
 	Customizations Customizations `json:"customizations"`
 	Description    string         `json:"description"`
 
@@ -264,9 +519,41 @@ type BlueprintResponse struct {
 
 	// ImageRequests Array of image requests. Having more image requests in a single blueprint is currently not supported.
 	ImageRequests []ImageRequest `json:"image_requests"`
-	Name          string         `json:"name"`
+
+	// LaunchRequirements What instances launched from the images of the blueprint need at least. Only the instance
+	// types meeting them are offered for launching the images, see /blueprints/{id}/instance_types.
+	LaunchRequirements *LaunchRequirements `json:"launch_requirements,omitempty"`
+
+	// Lifecycle Which images of the blueprint are kept. The others are deleted periodically, check
+	// /blueprints/{id}/lifecycle/preview for what would be deleted.
+	Lifecycle *BlueprintLifecycle `json:"lifecycle,omitempty"`
+	Name      string              `json:"name"`
+
+	// UpdatedBy email of the user who saved the latest version, unknown for older blueprints
+	UpdatedBy *string `json:"updated_by,omitempty"`
+	// Visibility Who in the organization sees the blueprint. Private blueprints are only visible to the user
+	// who created them, only they can change the visibility.
+	Visibility BlueprintVisibility `json:"visibility"`
+
+	// WorkspaceId workspace the blueprint belongs to, the whole organization when omitted
+	WorkspaceId *openapi_types.UUID `json:"workspace_id,omitempty"`
+}
+
+// BlueprintVersion defines model for BlueprintVersion.
+type BlueprintVersion struct {
+	CreatedAt string `json:"created_at"`
+	Version   int    `json:"version"`
+}
+
+// BlueprintVersionsResponse defines model for BlueprintVersionsResponse.
+type BlueprintVersionsResponse struct {
+	Data []BlueprintVersion `json:"data"`
 }
 
+// BlueprintVisibility Who in the organization sees the blueprint. Private blueprints are only visible to the user
+// who created them, only they can change the visibility.
+type BlueprintVisibility string
+
 // BlueprintsResponse defines model for BlueprintsResponse.
 type BlueprintsResponse struct {
 	Data  []BlueprintItem   `json:"data"`
@@ -274,6 +561,16 @@ type BlueprintsResponse struct {
 	Meta  ListResponseMeta  `json:"meta"`
 }
 
+// BuildMetadata Stamps the build into the image, so running instances can be traced back to it. The
+// build is described in /etc/image-builder.json, along with the labels of the compose, and
+// IMAGE_ID, IMAGE_VERSION, IMAGE_BUILDER_BUILD_ID and IMAGE_BUILDER_BUILD_DATE are added to
+// /etc/os-release on the first boot. The build ID is added to the labels of the compose as
+// image-builder.build-id, the compose ID isn't known before the image is built.
+type BuildMetadata struct {
+	// Motd greet logins with the build too
+	Motd *bool `json:"motd,omitempty"`
+}
+
 // ClientId defines model for ClientId.
 type ClientId string
 
@@ -284,15 +581,30 @@ type CloneRequest struct {
 
 // CloneResponse defines model for CloneResponse.
 type CloneResponse struct {
+	// Error why the clone of failed_region failed
+	Error *string `json:"error,omitempty"`
+
+	// FailedRegion the region the clones stopped at, only the regions before it were
+	// cloned to
+	FailedRegion *string `json:"failed_region,omitempty"`
+
+	// Id the clone of the first region
 	Id openapi_types.UUID `json:"id"`
+
+	// Ids the clones of all regions, in the order of the regions
+	Ids []openapi_types.UUID `json:"ids"`
 }
 
 // CloneStatusResponse defines model for CloneStatusResponse.
 type CloneStatusResponse struct {
 	ComposeId *openapi_types.UUID         `json:"compose_id,omitempty"`
 	Options   CloneStatusResponse_Options `json:"options"`
-	Status    CloneStatusResponseStatus   `json:"status"`
-	Type      UploadTypes                 `json:"type"`
+
+	// Progress Progress of the upload phase. Only present while the image is being
+	// transferred to the upload target and the target reports it.
+	Progress *UploadProgress           `json:"progress,omitempty"`
+	Status   CloneStatusResponseStatus `json:"status"`
+	Type     UploadTypes               `json:"type"`
 }
 
 // CloneStatusResponse_Options defines model for CloneStatusResponse.Options.
@@ -305,9 +617,10 @@ type CloneStatusResponseStatus string
 
 // ClonesResponse defines model for ClonesResponse.
 type ClonesResponse struct {
-	Data  []ClonesResponseItem `json:"data"`
-	Links ListResponseLinks    `json:"links"`
-	Meta  ListResponseMeta     `json:"meta"`
+	Data    []ClonesResponseItem `json:"data"`
+	Links   ListResponseLinks    `json:"links"`
+	Meta    ListResponseMeta     `json:"meta"`
+	Summary ClonesSummary        `json:"summary"`
 }
 
 // ClonesResponseItem defines model for ClonesResponseItem.
@@ -316,7 +629,142 @@ type ClonesResponseItem struct {
 	ComposeId openapi_types.UUID `json:"compose_id"`
 	CreatedAt string             `json:"created_at"`
 	Id        openapi_types.UUID `json:"id"`
-	Request   CloneRequest       `json:"request"`
+
+	// ParentId the clone of the first region of the request which cloned to several regions,
+	// absent for that clone itself
+	ParentId *openapi_types.UUID  `json:"parent_id,omitempty"`
+	Request  CloneRequest         `json:"request"`
+	Status   *CloneStatusResponse `json:"status,omitempty"`
+}
+
+// ClonesSummary defines model for ClonesSummary.
+type ClonesSummary struct {
+	Failure int `json:"failure"`
+	Success int `json:"success"`
+
+	// Unfinished clones which were not recorded as finished
+	Unfinished int `json:"unfinished"`
+}
+
+// ComposeDiff defines model for ComposeDiff.
+type ComposeDiff struct {
+	Packages *ComposePackageDiff `json:"packages,omitempty"`
+
+	// RequestChanges the fields of the compose requests which differ, objects are compared field by field,
+	// arrays of objects of the same length element by element and other arrays as a whole
+	RequestChanges []ComposeRequestChange `json:"request_changes"`
+}
+
+// ComposeDownload defines model for ComposeDownload.
+type ComposeDownload struct {
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// Url link to the image, only valid until expires_at
+	Url string `json:"url"`
+}
+
+// ComposeDraft defines model for ComposeDraft.
+type ComposeDraft struct {
+	CreatedAt string             `json:"created_at"`
+	Id        openapi_types.UUID `json:"id"`
+	Name      string             `json:"name"`
+
+	// Request the partially filled compose request, as it was saved
+	Request   map[string]interface{} `json:"request"`
+	UpdatedAt string                 `json:"updated_at"`
+}
+
+// ComposeDraftRequest defines model for ComposeDraftRequest.
+type ComposeDraftRequest struct {
+	Name *string `json:"name,omitempty"`
+
+	// Request Partially filled compose request, any of its fields can be left out. Saved as it is, up to
+	// 256 KiB.
+	Request map[string]interface{} `json:"request"`
+}
+
+// ComposeDraftsResponse defines model for ComposeDraftsResponse.
+type ComposeDraftsResponse struct {
+	Data []ComposeDraft `json:"data"`
+}
+
+// ComposeEvent defines model for ComposeEvent.
+type ComposeEvent struct {
+	ComposeId openapi_types.UUID `json:"compose_id"`
+	CreatedAt string             `json:"created_at"`
+	ErrorCode *string            `json:"error_code,omitempty"`
+
+	// Id cursor of the event
+	Id        string  `json:"id"`
+	ImageType *string `json:"image_type,omitempty"`
+
+	// Status status of the compose after the event
+	Status *string          `json:"status,omitempty"`
+	Type   ComposeEventType `json:"type"`
+}
+
+// ComposeEventType defines model for ComposeEvent.Type.
+type ComposeEventType string
+
+// ComposeEventsResponse defines model for ComposeEventsResponse.
+type ComposeEventsResponse struct {
+	Data []ComposeEvent `json:"data"`
+
+	// NextCursor pass as since to get the events following this page
+	NextCursor string `json:"next_cursor"`
+}
+
+// ComposeExpiry defines model for ComposeExpiry.
+type ComposeExpiry struct {
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ComposeGroupMember defines model for ComposeGroupMember.
+type ComposeGroupMember struct {
+	Architecture string             `json:"architecture"`
+	Id           openapi_types.UUID `json:"id"`
+	ImageStatus  ImageStatus        `json:"image_status"`
+}
+
+// ComposeGroupStatus The composes built from the same request for several architectures. The status of the group
+// is a failure as soon as one compose failed and a success once all of them succeeded.
+type ComposeGroupStatus struct {
+	Composes    []ComposeGroupMember `json:"composes"`
+	Id          openapi_types.UUID   `json:"id"`
+	ImageStatus ImageStatus          `json:"image_status"`
+}
+
+// ComposeHookRun defines model for ComposeHookRun.
+type ComposeHookRun struct {
+	Attempts int `json:"attempts"`
+
+	// Error why the latest attempt failed
+	Error *string `json:"error,omitempty"`
+
+	// Hook name of the hook
+	Hook string `json:"hook"`
+
+	// Status pending while the hook is tried again after failing, failed once it failed every attempt
+	Status    ComposeHookRunStatus `json:"status"`
+	UpdatedAt string               `json:"updated_at"`
+}
+
+// ComposeHookRunStatus pending while the hook is tried again after failing, failed once it failed every attempt
+type ComposeHookRunStatus string
+
+// ComposeLabels Key/value labels attached to the compose, composes can be listed by them with the
+// label_selector parameter. Keys are 1 to 63 alphanumeric characters, dashes, underscores
+// and dots, starting and ending with an alphanumeric character, values follow the same
+// rules but can be empty.
+type ComposeLabels map[string]string
+
+// ComposeLintResponse defines model for ComposeLintResponse.
+type ComposeLintResponse struct {
+	// Errors problems which would make the compose fail
+	Errors []string `json:"errors"`
+
+	// Warnings problems which wouldn't prevent the compose
+	Warnings []string `json:"warnings"`
 }
 
 // ComposeMetadata defines model for ComposeMetadata.
@@ -326,6 +774,63 @@ type ComposeMetadata struct {
 
 	// Packages Package list including NEVRA
 	Packages *[]PackageMetadata `json:"packages,omitempty"`
+
+	// PinnedPackages The packages as name-[epoch:]version-release.arch, to pin them to the same versions in
+	// another build
+	PinnedPackages *[]string `json:"pinned_packages,omitempty"`
+}
+
+// ComposePackageChange defines model for ComposePackageChange.
+type ComposePackageChange struct {
+	Arch string `json:"arch"`
+
+	// From the version in the image of the compose, as [epoch:]version-release
+	From string `json:"from"`
+	Name string `json:"name"`
+
+	// To the version in the other image, as [epoch:]version-release
+	To string `json:"to"`
+}
+
+// ComposePackageDiff defines model for ComposePackageDiff.
+type ComposePackageDiff struct {
+	// Added the packages only the other image has, as name-[epoch:]version-release.arch
+	Added   []string               `json:"added"`
+	Changed []ComposePackageChange `json:"changed"`
+
+	// Removed the packages only the image of the compose has, as name-[epoch:]version-release.arch
+	Removed []string `json:"removed"`
+}
+
+// ComposePipeline The edge-installer compose chained to an edge-commit compose. The installer is submitted
+// once the commit was built, with the URL of the commit as its ostree URL.
+type ComposePipeline struct {
+	CommitComposeId openapi_types.UUID `json:"commit_compose_id"`
+
+	// Error why the pipeline failed
+	Error *string            `json:"error,omitempty"`
+	Id    openapi_types.UUID `json:"id"`
+
+	// InstallerComposeId set once the installer compose was submitted
+	InstallerComposeId *openapi_types.UUID `json:"installer_compose_id,omitempty"`
+
+	// State waiting for the commit compose to finish, triggering while the installer compose is
+	// submitted, triggered once it was, failed when the commit compose failed or the
+	// installer compose couldn't be submitted
+	State ComposePipelineState `json:"state"`
+}
+
+// ComposePipelineState waiting for the commit compose to finish, triggering while the installer compose is
+// submitted, triggered once it was, failed when the commit compose failed or the
+// installer compose couldn't be submitted
+type ComposePipelineState string
+
+// ComposePipelineRequest Chains an installer to the compose, which has to build an edge-commit uploaded to
+// aws.s3. Once the commit was built, the installer is composed from the same distribution
+// and customizations with the URL of the commit as its ostree URL, unless the image
+// request sets one, and the ref of the commit.
+type ComposePipelineRequest struct {
+	Installer ImageRequest `json:"installer"`
 }
 
 // ComposeRequest defines model for ComposeRequest.
@@ -343,26 +848,186 @@ type ComposeRequest struct {
 
 	// ImageRequests Array of exactly one image request. Having more image requests in one compose is currently not supported.
 	ImageRequests []ImageRequest `json:"image_requests"`
+
+	// Labels Key/value labels attached to the compose, composes can be listed by them with the
+	// label_selector parameter. Keys are 1 to 63 alphanumeric characters, dashes, underscores
+	// and dots, starting and ending with an alphanumeric character, values follow the same
+	// rules but can be empty.
+	Labels *ComposeLabels `json:"labels,omitempty"`
+
+	// Pipeline Chains an installer to the compose, which has to build an edge-commit uploaded to
+	// aws.s3. Once the commit was built, the installer is composed from the same distribution
+	// and customizations with the URL of the commit as its ostree URL, unless the image
+	// request sets one, and the ref of the commit.
+	Pipeline *ComposePipelineRequest `json:"pipeline,omitempty"`
+
+	// Security The compose rebuilds an image for a security fix, only organization administrators can
+	// flag composes. Flagged composes skip the compose quota of the organization up to a
+	// limit set by the deployment and are built with priority, the ones over the limit are
+	// treated like any other compose.
+	Security *bool `json:"security,omitempty"`
+}
+
+// ComposeRequestChange defines model for ComposeRequestChange.
+type ComposeRequestChange struct {
+	// From the value in the request of the compose, not set when the field was added
+	From *interface{} `json:"from,omitempty"`
+
+	// Path JSON pointer to the field in the requests
+	Path string `json:"path"`
+
+	// To the value in the request of the other compose, not set when the field was removed
+	To *interface{} `json:"to,omitempty"`
 }
 
 // ComposeResponse defines model for ComposeResponse.
 type ComposeResponse struct {
+	// GroupId the compose group when the request was built for several architectures
+	GroupId *openapi_types.UUID `json:"group_id,omitempty"`
+
+	// Id the compose, or the compose of the first architecture when the request was built for
+	// several architectures
 	Id openapi_types.UUID `json:"id"`
+
+	// InjectedDefaults what the defaults of the organization merged into the compose
+	InjectedDefaults *InjectedDefaults `json:"injected_defaults,omitempty"`
+
+	// Warnings problems found with the request which didn't prevent the compose, like embedded secrets, or
+	// notes about the build, like an architecture built under emulation taking longer or a
+	// deprecated distribution or image type. These include the warnings of the linter.
+	Warnings *[]string `json:"warnings,omitempty"`
+}
+
+// ComposeShareRequest defines model for ComposeShareRequest.
+type ComposeShareRequest struct {
+	// ShareWithAccounts An array of AWS account IDs as described in
+	// https://docs.aws.amazon.com/IAM/latest/UserGuide/console_account-alias.html
+	ShareWithAccounts []string `json:"share_with_accounts"`
 }
 
 // ComposeStatus defines model for ComposeStatus.
 type ComposeStatus struct {
-	ImageStatus ImageStatus    `json:"image_status"`
-	Request     ComposeRequest `json:"request"`
+	// Group The composes built from the same request for several architectures. The status of the group
+	// is a failure as soon as one compose failed and a success once all of them succeeded.
+	Group       *ComposeGroupStatus `json:"group,omitempty"`
+	ImageStatus ImageStatus         `json:"image_status"`
+
+	// InjectedDefaults what the defaults of the organization merged into the compose
+	InjectedDefaults *InjectedDefaults `json:"injected_defaults,omitempty"`
+
+	// ParentComposeId the failed compose this compose retried
+	ParentComposeId *openapi_types.UUID `json:"parent_compose_id,omitempty"`
+
+	// Pipeline The edge-installer compose chained to an edge-commit compose. The installer is submitted
+	// once the commit was built, with the URL of the commit as its ostree URL.
+	Pipeline *ComposePipeline `json:"pipeline,omitempty"`
+
+	// PostProcessing the post-processing hooks which ran on the compose after it succeeded
+	PostProcessing *[]ComposeHookRun `json:"post_processing,omitempty"`
+	Request        ComposeRequest    `json:"request"`
 }
 
 // ComposeStatusError defines model for ComposeStatusError.
 type ComposeStatusError struct {
+	// Code Set when the failure was determined by image-builder itself rather than
+	// reported by the build service, e.g. WATCHDOG_TIMEOUT for a compose which
+	// did not finish in time.
+	Code    *string      `json:"code,omitempty"`
 	Details *interface{} `json:"details,omitempty"`
 	Id      int          `json:"id"`
 	Reason  string       `json:"reason"`
 }
 
+// ComposeTemplate defines model for ComposeTemplate.
+type ComposeTemplate struct {
+	CreatedAt   string         `json:"created_at"`
+	CreatedBy   string         `json:"created_by"`
+	Description string         `json:"description"`
+	Name        string         `json:"name"`
+	Request     ComposeRequest `json:"request"`
+	Version     int            `json:"version"`
+}
+
+// ComposeTemplateOverrides defines model for ComposeTemplateOverrides.
+type ComposeTemplateOverrides struct {
+	Customizations *Customizations `json:"customizations,omitempty"`
+
+	// Distribution List of all distributions that image builder supports. A user might not have access to
+	// restricted distributions.
+	//
+	// Restricted distributions include the RHEL nightlies and the Fedora distributions.
+	Distribution     *Distributions `json:"distribution,omitempty"`
+	ImageDescription *string        `json:"image_description,omitempty"`
+	ImageName        *string        `json:"image_name,omitempty"`
+
+	// ImageRequests replace the image requests of the template, e.g. to upload elsewhere
+	ImageRequests *[]ImageRequest `json:"image_requests,omitempty"`
+
+	// Labels Key/value labels attached to the compose, composes can be listed by them with the
+	// label_selector parameter. Keys are 1 to 63 alphanumeric characters, dashes, underscores
+	// and dots, starting and ending with an alphanumeric character, values follow the same
+	// rules but can be empty.
+	Labels *ComposeLabels `json:"labels,omitempty"`
+
+	// Packages packages installed in addition to the ones of the template
+	Packages *[]string `json:"packages,omitempty"`
+
+	// Version version of the template to compose, the latest one if omitted
+	Version *int `json:"version,omitempty"`
+}
+
+// ComposeTemplatesResponse defines model for ComposeTemplatesResponse.
+type ComposeTemplatesResponse struct {
+	Data []ComposeTemplate `json:"data"`
+}
+
+// ComposeTransferRequest defines model for ComposeTransferRequest.
+type ComposeTransferRequest struct {
+	// Creator email address of the user recorded as the creator from now on
+	Creator string `json:"creator"`
+}
+
+// ComposeUpdateRequest defines model for ComposeUpdateRequest.
+type ComposeUpdateRequest struct {
+	Description *string `json:"description,omitempty"`
+	ImageName   *string `json:"image_name,omitempty"`
+}
+
+// ComposeValidationResponse defines model for ComposeValidationResponse.
+type ComposeValidationResponse struct {
+	// Errors problems the compose would be rejected with or fail on, empty if it would be built
+	Errors []string `json:"errors"`
+
+	// Packages The packages the image would contain as name-[epoch:]version-release.arch, only present
+	// without errors
+	Packages *[]string `json:"packages,omitempty"`
+
+	// Warnings problems which wouldn't prevent the compose
+	Warnings []string `json:"warnings"`
+}
+
+// ComposesProjectedItem The requested fields of a ComposesResponseItem, the ones which weren't requested or are
+// null are left out.
+type ComposesProjectedItem struct {
+	BlueprintId      *openapi_types.UUID `json:"blueprint_id,omitempty"`
+	BlueprintVersion *int                `json:"blueprint_version,omitempty"`
+	ClientId         *ClientId           `json:"client_id,omitempty"`
+	CreatedAt        *string             `json:"created_at,omitempty"`
+	Description      *string             `json:"description,omitempty"`
+	ExpiresAt        *string             `json:"expires_at,omitempty"`
+	Id               *openapi_types.UUID `json:"id,omitempty"`
+	ImageName        *string             `json:"image_name,omitempty"`
+	Request          *ComposeRequest     `json:"request,omitempty"`
+	Status           *string             `json:"status,omitempty"`
+}
+
+// ComposesProjectedResponse defines model for ComposesProjectedResponse.
+type ComposesProjectedResponse struct {
+	Data  []ComposesProjectedItem `json:"data"`
+	Links ListResponseLinks       `json:"links"`
+	Meta  ListResponseMeta        `json:"meta"`
+}
+
 // ComposesResponse defines model for ComposesResponse.
 type ComposesResponse struct {
 	Data  []ComposesResponseItem `json:"data"`
@@ -376,9 +1041,34 @@ type ComposesResponseItem struct {
 	BlueprintVersion *int                `json:"blueprint_version"`
 	ClientId         *ClientId           `json:"client_id,omitempty"`
 	CreatedAt        string              `json:"created_at"`
-	Id               openapi_types.UUID  `json:"id"`
-	ImageName        *string             `json:"image_name,omitempty"`
-	Request          ComposeRequest      `json:"request"`
+
+	// Description free-form description the compose was annotated with
+	Description *string `json:"description,omitempty"`
+
+	// ExpiresAt when the artifacts of the compose get deleted, absent if they're kept
+	ExpiresAt *string            `json:"expires_at,omitempty"`
+	Id        openapi_types.UUID `json:"id"`
+	ImageName *string            `json:"image_name,omitempty"`
+	Request   ComposeRequest     `json:"request"`
+
+	// Status status recorded for the compose, null while it's unfinished or hasn't been looked at
+	// since it finished
+	Status *string `json:"status"`
+}
+
+// ComposesTransferRequest defines model for ComposesTransferRequest.
+type ComposesTransferRequest struct {
+	// From email address of the user whose composes are transferred
+	From string `json:"from"`
+
+	// To email address of the user recorded as the creator from now on
+	To string `json:"to"`
+}
+
+// ComposesTransferResponse defines model for ComposesTransferResponse.
+type ComposesTransferResponse struct {
+	// Transferred number of composes which were transferred
+	Transferred int `json:"transferred"`
 }
 
 // Container defines model for Container.
@@ -405,9 +1095,26 @@ type CreateBlueprintRequest struct {
 	Distribution Distributions `json:"distribution"`
 
 	// ImageRequests Array of image requests. Having more image requests in a single blueprint is currently not supported.
-	ImageRequests []ImageRequest     `json:"image_requests"`
-	Metadata      *BlueprintMetadata `json:"metadata,omitempty"`
-	Name          string             `json:"name"`
+	ImageRequests []ImageRequest `json:"image_requests"`
+
+	// LaunchRequirements What instances launched from the images of the blueprint need at least. Only the instance
+	// types meeting them are offered for launching the images, see /blueprints/{id}/instance_types.
+	LaunchRequirements *LaunchRequirements `json:"launch_requirements,omitempty"`
+
+	// Lifecycle Which images of the blueprint are kept. The others are deleted periodically, check
+	// /blueprints/{id}/lifecycle/preview for what would be deleted.
+	Lifecycle *BlueprintLifecycle `json:"lifecycle,omitempty"`
+	Metadata  *BlueprintMetadata  `json:"metadata,omitempty"`
+	Name      string              `json:"name"`
+
+	// Visibility Who in the organization sees the blueprint. Private blueprints are only visible to the user
+	// who created them, only they can change the visibility.
+	Visibility *BlueprintVisibility `json:"visibility,omitempty"`
+
+	// WorkspaceId workspace the blueprint belongs to, only its members and the organization administrators
+	// see it and only its builders change and compose it. Blueprints without one belong to
+	// the whole organization. The workspace of a blueprint is set when creating it.
+	WorkspaceId *openapi_types.UUID `json:"workspace_id,omitempty"`
 }
 
 // CreateBlueprintResponse defines model for CreateBlueprintResponse.
@@ -415,6 +1122,45 @@ type CreateBlueprintResponse struct {
 	Id openapi_types.UUID `json:"id"`
 }
 
+// CreateGitOpsRepositoryRequest defines model for CreateGitOpsRepositoryRequest.
+type CreateGitOpsRepositoryRequest struct {
+	// AutoBuild build images for changed blueprints on every sync
+	AutoBuild *bool `json:"auto_build,omitempty"`
+
+	// Branch branch to sync from
+	Branch *string `json:"branch,omitempty"`
+
+	// Path directory of the repository containing the blueprints
+	Path *string `json:"path,omitempty"`
+	Url  string  `json:"url"`
+}
+
+// CreateUploadGrantRequest defines model for CreateUploadGrantRequest.
+type CreateUploadGrantRequest struct {
+	// Alias name upload requests reference the grant by
+	Alias string `json:"alias"`
+
+	// SourceId ID of the source resolved to the cloud account
+	SourceId string `json:"source_id"`
+}
+
+// CurrentUsage defines model for CurrentUsage.
+type CurrentUsage struct {
+	// Queued Number of composes waiting for a worker
+	Queued int `json:"queued"`
+
+	// RemainingSlots Number of composes which can still be requested in the current quota window.
+	// Omitted if no quota applies to the organization.
+	RemainingSlots *int `json:"remaining_slots,omitempty"`
+
+	// Running Number of composes currently being built or uploaded
+	Running int `json:"running"`
+
+	// Unknown Number of unfinished composes whose state couldn't be determined right now,
+	// they still count against the quota.
+	Unknown int `json:"unknown"`
+}
+
 // CustomRepository Repository configuration for custom repositories.
 // At least one of the 'baseurl', 'mirrorlist', 'metalink' properties must
 // be specified. If more of them are specified, the order of precedence is
@@ -439,6 +1185,12 @@ type CustomRepository struct {
 
 // Customizations defines model for Customizations.
 type Customizations struct {
+	// BuildMetadata Stamps the build into the image, so running instances can be traced back to it. The
+	// build is described in /etc/image-builder.json, along with the labels of the compose, and
+	// IMAGE_ID, IMAGE_VERSION, IMAGE_BUILDER_BUILD_ID and IMAGE_BUILDER_BUILD_DATE are added to
+	// /etc/os-release on the first boot. The build ID is added to the labels of the compose as
+	// image-builder.build-id, the compose ID isn't known before the image is built.
+	BuildMetadata      *BuildMetadata      `json:"build_metadata,omitempty"`
 	Containers         *[]Container        `json:"containers,omitempty"`
 	CustomRepositories *[]CustomRepository `json:"custom_repositories,omitempty"`
 	Directories        *[]Directory        `json:"directories,omitempty"`
@@ -473,6 +1225,9 @@ type Customizations struct {
 	// Locale Locale configuration
 	Locale   *Locale   `json:"locale,omitempty"`
 	Openscap *OpenSCAP `json:"openscap,omitempty"`
+
+	// Packages Packages to install. Package groups are selected by their id or name prefixed
+	// with @, e.g. @core or @Development Tools.
 	Packages *[]string `json:"packages,omitempty"`
 
 	// PartitioningMode Select how the disk image will be partitioned. 'auto-lvm' will use raw unless
@@ -497,9 +1252,25 @@ type Customizations struct {
 // even when there are one or more mountpoints.
 type CustomizationsPartitioningMode string
 
-// Directory A custom directory to create in the final artifact.
-type Directory struct {
-	// EnsureParents Ensure that the parent directories exist
+// DependencyHealth defines model for DependencyHealth.
+type DependencyHealth struct {
+	// Error why the dependency is considered unreachable
+	Error *string `json:"error,omitempty"`
+
+	// LatencyMs how long the check took
+	LatencyMs int  `json:"latency_ms"`
+	Reachable bool `json:"reachable"`
+
+	// Region region of the composer, omitted for the one of the region of the service
+	Region *string `json:"region,omitempty"`
+
+	// StatusCode HTTP status of the response, omitted if no response was received
+	StatusCode *int `json:"status_code,omitempty"`
+}
+
+// Directory A custom directory to create in the final artifact.
+type Directory struct {
+	// EnsureParents Ensure that the parent directories exist
 	EnsureParents *bool `json:"ensure_parents,omitempty"`
 
 	// Group Group of the directory as a group name or a gid
@@ -558,6 +1329,58 @@ type Distributions string
 // DistributionsResponse List of distributions this user is allowed to build.
 type DistributionsResponse = []DistributionItem
 
+// DownloadToken defines model for DownloadToken.
+type DownloadToken struct {
+	CreatedAt string `json:"created_at"`
+
+	// CreatedBy email of the user who created the token
+	CreatedBy  string             `json:"created_by"`
+	Id         openapi_types.UUID `json:"id"`
+	LastUsedAt *string            `json:"last_used_at,omitempty"`
+	Name       string             `json:"name"`
+
+	// Token the token, only returned when it is created
+	Token *string `json:"token,omitempty"`
+}
+
+// DownloadTokenRequest defines model for DownloadTokenRequest.
+type DownloadTokenRequest struct {
+	// Name what the token is used for
+	Name string `json:"name"`
+}
+
+// DownloadTokensResponse defines model for DownloadTokensResponse.
+type DownloadTokensResponse struct {
+	Data []DownloadToken `json:"data"`
+}
+
+// DuplicateBlueprintRequest defines model for DuplicateBlueprintRequest.
+type DuplicateBlueprintRequest struct {
+	// Distribution List of all distributions that image builder supports. A user might not have access to
+	// restricted distributions.
+	//
+	// Restricted distributions include the RHEL nightlies and the Fedora distributions.
+	Distribution *Distributions `json:"distribution,omitempty"`
+
+	// Name name of the new blueprint, defaults to the name of the blueprint followed by the
+	// distribution, or by copy if it stays the same
+	Name *string `json:"name,omitempty"`
+
+	// RemapRepositories point the repositories at the major version of the new distribution, their URLs are
+	// kept as they are otherwise
+	RemapRepositories *bool `json:"remap_repositories,omitempty"`
+}
+
+// DuplicateBlueprintResponse defines model for DuplicateBlueprintResponse.
+type DuplicateBlueprintResponse struct {
+	// Id UUID of the new blueprint
+	Id                   openapi_types.UUID    `json:"id"`
+	RemappedRepositories []RepositoryRemapping `json:"remapped_repositories"`
+
+	// Report What the blueprint uses which the new release lacks.
+	Report BlueprintCompatibilityReport `json:"report"`
+}
+
 // FDO FIDO device onboard configuration
 type FDO struct {
 	DiunPubKeyHash         *string `json:"diun_pub_key_hash,omitempty"`
@@ -645,6 +1468,11 @@ type FirewallCustomization struct {
 
 // GCPUploadRequestOptions defines model for GCPUploadRequestOptions.
 type GCPUploadRequestOptions struct {
+	// KmsKeyName Cloud KMS key the image is encrypted with (customer-managed encryption key). The key
+	// has to belong to the project of the source, which has to grant the image builder
+	// service account the Cloud KMS CryptoKey Encrypter/Decrypter role on it.
+	KmsKeyName *string `json:"kms_key_name,omitempty"`
+
 	// ShareWithAccounts List of valid Google accounts to share the imported Compute Node image with.
 	// Each string must contain a specifier of the account type. Valid formats are:
 	//   - 'user:{emailid}': An email address that represents a specific
@@ -658,6 +1486,9 @@ type GCPUploadRequestOptions struct {
 	//     If not specified, the imported Compute Node image is not shared with any
 	//     account.
 	ShareWithAccounts *[]string `json:"share_with_accounts,omitempty"`
+
+	// SourceId GCP source of the project owning the kms key, required with kms_key_name.
+	SourceId *string `json:"source_id,omitempty"`
 }
 
 // GCPUploadStatus defines model for GCPUploadStatus.
@@ -666,6 +1497,59 @@ type GCPUploadStatus struct {
 	ProjectId string `json:"project_id"`
 }
 
+// GitOpsBlueprintState defines model for GitOpsBlueprintState.
+type GitOpsBlueprintState struct {
+	BlueprintId *openapi_types.UUID `json:"blueprint_id,omitempty"`
+
+	// Composes composes started for the blueprint when auto_build is enabled
+	Composes *[]ComposeResponse `json:"composes,omitempty"`
+
+	// File path of the blueprint in the repository
+	File string `json:"file"`
+	Name string `json:"name"`
+
+	// State Drift reports in_sync, modified (the stored blueprint differs from git) or missing (not
+	// stored yet). A sync reports in_sync, created or updated. Both report conflict when the
+	// name is taken by a blueprint the user can't see, a sync also when they can't change it.
+	State GitOpsBlueprintStateState `json:"state"`
+}
+
+// GitOpsBlueprintStateState Drift reports in_sync, modified (the stored blueprint differs from git) or missing (not
+// stored yet). A sync reports in_sync, created or updated. Both report conflict when the
+// name is taken by a blueprint the user can't see, a sync also when they can't change it.
+type GitOpsBlueprintStateState string
+
+// GitOpsDriftResponse defines model for GitOpsDriftResponse.
+type GitOpsDriftResponse struct {
+	Commit string                 `json:"commit"`
+	Data   []GitOpsBlueprintState `json:"data"`
+}
+
+// GitOpsRepositoriesResponse defines model for GitOpsRepositoriesResponse.
+type GitOpsRepositoriesResponse struct {
+	Data []GitOpsRepository `json:"data"`
+}
+
+// GitOpsRepository defines model for GitOpsRepository.
+type GitOpsRepository struct {
+	AutoBuild bool               `json:"auto_build"`
+	Branch    string             `json:"branch"`
+	CreatedAt string             `json:"created_at"`
+	Id        openapi_types.UUID `json:"id"`
+
+	// LastCommit commit the blueprints were last synced from
+	LastCommit   *string `json:"last_commit,omitempty"`
+	LastSyncedAt *string `json:"last_synced_at,omitempty"`
+	Path         string  `json:"path"`
+	Url          string  `json:"url"`
+}
+
+// GitOpsSyncResponse defines model for GitOpsSyncResponse.
+type GitOpsSyncResponse struct {
+	Commit string                 `json:"commit"`
+	Data   []GitOpsBlueprintState `json:"data"`
+}
+
 // Group defines model for Group.
 type Group struct {
 	// Gid Group id of the group to create (optional)
@@ -677,8 +1561,12 @@ type Group struct {
 
 // HTTPError defines model for HTTPError.
 type HTTPError struct {
-	Detail string `json:"detail"`
-	Title  string `json:"title"`
+	// Code Identifies the error, unlike the detail it doesn't change. One of
+	// quota-exceeded, forbidden-distribution, invalid-customization and
+	// upstream-unavailable, others can be added.
+	Code   *string `json:"code,omitempty"`
+	Detail string  `json:"detail"`
+	Title  string  `json:"title"`
 }
 
 // HTTPErrorList defines model for HTTPErrorList.
@@ -686,6 +1574,28 @@ type HTTPErrorList struct {
 	Errors []HTTPError `json:"errors"`
 }
 
+// HealthSummary defines model for HealthSummary.
+type HealthSummary struct {
+	CheckedAt string `json:"checked_at"`
+
+	// Composer the composer of the region of the service first, followed by the ones of the other regions
+	Composer []DependencyHealth `json:"composer"`
+
+	// ComposerTokenExpiresAt when the token the service authenticates to composer with expires, omitted until
+	// the first one was acquired
+	ComposerTokenExpiresAt *string          `json:"composer_token_expires_at,omitempty"`
+	Database               DependencyHealth `json:"database"`
+
+	// EventLagSeconds time since the last compose event was recorded, consumers of the event stream see
+	// nothing newer. Omitted if there are no events.
+	EventLagSeconds *int `json:"event_lag_seconds,omitempty"`
+
+	// PollerLagSeconds age of the oldest compose of the region whose build hasn't been recorded as finished,
+	// composes stuck in composer show up here until the watchdog fails them. Omitted if
+	// there are none.
+	PollerLagSeconds *int `json:"poller_lag_seconds,omitempty"`
+}
+
 // Ignition Ignition configuration
 type Ignition struct {
 	Embedded  *IgnitionEmbedded  `json:"embedded,omitempty"`
@@ -705,6 +1615,11 @@ type IgnitionFirstboot struct {
 
 // ImageRequest defines model for ImageRequest.
 type ImageRequest struct {
+	// AdditionalArchitectures Further architectures the image is built for. A compose request fans out into one
+	// compose per architecture, tracked as a compose group. Blueprints build them as separate
+	// image requests.
+	AdditionalArchitectures *[]ImageRequestAdditionalArchitectures `json:"additional_architectures,omitempty"`
+
 	// Architecture CPU architecture of the image, x86_64 and aarch64 are currently supported.
 	Architecture ImageRequestArchitecture `json:"architecture"`
 	ImageType    ImageTypes               `json:"image_type"`
@@ -723,6 +1638,9 @@ type ImageRequest struct {
 	UploadRequest UploadRequest `json:"upload_request"`
 }
 
+// ImageRequestAdditionalArchitectures defines model for ImageRequest.AdditionalArchitectures.
+type ImageRequestAdditionalArchitectures string
+
 // ImageRequestArchitecture CPU architecture of the image, x86_64 and aarch64 are currently supported.
 type ImageRequestArchitecture string
 
@@ -736,9 +1654,30 @@ type ImageStatus struct {
 // ImageStatusStatus defines model for ImageStatus.Status.
 type ImageStatusStatus string
 
+// ImageTypeDetails defines model for ImageTypeDetails.
+type ImageTypeDetails struct {
+	DisplayName string `json:"display_name"`
+	Name        string `json:"name"`
+}
+
 // ImageTypes defines model for ImageTypes.
 type ImageTypes string
 
+// InjectedDefaults what the defaults of the organization merged into the compose
+type InjectedDefaults struct {
+	// Files the paths of the files, including the ones replacing files of the request
+	Files *[]string `json:"files,omitempty"`
+
+	// Locale the request set no locale
+	Locale *bool `json:"locale,omitempty"`
+
+	// Packages the packages the request didn't include
+	Packages *[]string `json:"packages,omitempty"`
+
+	// Timezone the request set no timezone
+	Timezone *bool `json:"timezone,omitempty"`
+}
+
 // Installer Anaconda installer configuration
 type Installer struct {
 	SudoNopasswd *[]string `json:"sudo-nopasswd,omitempty"`
@@ -747,6 +1686,34 @@ type Installer struct {
 	Unattended *bool `json:"unattended,omitempty"`
 }
 
+// InstanceType defines model for InstanceType.
+type InstanceType struct {
+	Architecture string          `json:"architecture"`
+	Features     []LaunchFeature `json:"features"`
+	MemoryMib    int             `json:"memory_mib"`
+	Name         string          `json:"name"`
+
+	// StorageGb size of the instance storage
+	StorageGb int `json:"storage_gb"`
+	Vcpus     int `json:"vcpus"`
+}
+
+// JSONPatchOperation defines model for JSONPatchOperation.
+type JSONPatchOperation struct {
+	// From JSON Pointer to the member moved or copied
+	From *string              `json:"from,omitempty"`
+	Op   JSONPatchOperationOp `json:"op"`
+
+	// Path JSON Pointer to the member the operation applies to
+	Path string `json:"path"`
+
+	// Value the value added, replaced with or tested for
+	Value interface{} `json:"value,omitempty"`
+}
+
+// JSONPatchOperationOp defines model for JSONPatchOperation.Op.
+type JSONPatchOperationOp string
+
 // Kernel defines model for Kernel.
 type Kernel struct {
 	// Append Appends arguments to the bootloader kernel command line
@@ -756,10 +1723,26 @@ type Kernel struct {
 	Name *string `json:"name,omitempty"`
 }
 
+// LaunchFeature Capability of an instance type. nested-virtualization lets the instances run virtual machines
+// of their own, local-storage gives them instance storage besides their disks.
+type LaunchFeature string
+
+// LaunchRequirements What instances launched from the images of the blueprint need at least. Only the instance
+// types meeting them are offered for launching the images, see /blueprints/{id}/instance_types.
+type LaunchRequirements struct {
+	Features     *[]LaunchFeature `json:"features,omitempty"`
+	MinMemoryMib *int             `json:"min_memory_mib,omitempty"`
+	MinVcpus     *int             `json:"min_vcpus,omitempty"`
+}
+
 // ListResponseLinks defines model for ListResponseLinks.
 type ListResponseLinks struct {
 	First string `json:"first"`
 	Last  string `json:"last"`
+
+	// Next The following page, continuing from a cursor. Absent on the last page. Only provided
+	// by listings supporting cursors.
+	Next *string `json:"next,omitempty"`
 }
 
 // ListResponseMeta defines model for ListResponseMeta.
@@ -776,6 +1759,43 @@ type Locale struct {
 	Languages *[]string `json:"languages,omitempty"`
 }
 
+// MarketplaceCheck defines model for MarketplaceCheck.
+type MarketplaceCheck struct {
+	// Details what made the check fail
+	Details *[]string            `json:"details,omitempty"`
+	Name    MarketplaceCheckName `json:"name"`
+	Passed  bool                 `json:"passed"`
+}
+
+// MarketplaceCheckName defines model for MarketplaceCheck.Name.
+type MarketplaceCheckName string
+
+// MarketplaceSubmission defines model for MarketplaceSubmission.
+type MarketplaceSubmission struct {
+	Checks    []MarketplaceCheck `json:"checks"`
+	ComposeId openapi_types.UUID `json:"compose_id"`
+
+	// Manifest the details of the AddDeliveryOptions change, only when the AMI is ready
+	Manifest *map[string]interface{} `json:"manifest,omitempty"`
+
+	// Ready all checks passed
+	Ready bool `json:"ready"`
+}
+
+// MarketplaceSubmissionRequest defines model for MarketplaceSubmissionRequest.
+type MarketplaceSubmissionRequest struct {
+	// AccessRoleArn role AWS Marketplace assumes to copy the AMI
+	AccessRoleArn           string  `json:"access_role_arn"`
+	RecommendedInstanceType *string `json:"recommended_instance_type,omitempty"`
+	ReleaseNotes            string  `json:"release_notes"`
+	SshPort                 *int    `json:"ssh_port,omitempty"`
+	UsageInstructions       string  `json:"usage_instructions"`
+
+	// Username user to log into instances of the AMI as
+	Username     string `json:"username"`
+	VersionTitle string `json:"version_title"`
+}
+
 // OCIUploadRequestOptions defines model for OCIUploadRequestOptions.
 type OCIUploadRequestOptions = map[string]interface{}
 
@@ -813,6 +1833,77 @@ type OpenSCAP struct {
 	ProfileName *string `json:"profile_name,omitempty"`
 }
 
+// OrgDefaults defines model for OrgDefaults.
+type OrgDefaults struct {
+	// Files files every image has, like the CA certificate of the organization, they replace the files
+	// of the request at the same path
+	Files *[]File `json:"files,omitempty"`
+
+	// Locale Locale configuration
+	Locale *Locale `json:"locale,omitempty"`
+
+	// Packages packages added to every image
+	Packages *[]string `json:"packages,omitempty"`
+
+	// Timezone Timezone configuration
+	Timezone *Timezone `json:"timezone,omitempty"`
+}
+
+// OrgDefaultsResponse defines model for OrgDefaultsResponse.
+type OrgDefaultsResponse struct {
+	Defaults  OrgDefaults `json:"defaults"`
+	UpdatedAt string      `json:"updated_at"`
+
+	// UpdatedBy email of the administrator who last changed the defaults
+	UpdatedBy string `json:"updated_by"`
+}
+
+// OrgPolicy defines model for OrgPolicy.
+type OrgPolicy struct {
+	// AllowedUploadTypes the only upload targets images can be built for, all are allowed when omitted
+	AllowedUploadTypes *[]UploadTypes `json:"allowed_upload_types,omitempty"`
+
+	// BannedPackages packages no image may include
+	BannedPackages *[]string `json:"banned_packages,omitempty"`
+
+	// ComposeExpiryDays the artifacts of the composes of the organization, like the images uploaded to the cloud,
+	// are deleted this many days after they were requested, instead of when the deployment
+	// expires them, if at all
+	ComposeExpiryDays *int `json:"compose_expiry_days,omitempty"`
+
+	// CostCenter cost center the builds of the organization are charged to, the build jobs are tagged with
+	// it along with the organization and account
+	CostCenter *string `json:"cost_center,omitempty"`
+
+	// Fips every image has to enable FIPS mode
+	Fips *bool `json:"fips,omitempty"`
+
+	// OpenscapProfileId OpenSCAP profile every image has to be hardened with
+	OpenscapProfileId *string `json:"openscap_profile_id,omitempty"`
+
+	// RequiredPackages packages every image has to include, security agents for example
+	RequiredPackages *[]string `json:"required_packages,omitempty"`
+
+	// SecretScanning What happens to composes embedding credentials, like AWS keys or private keys, in files
+	// or ignition configs. With warn the compose is built, the response lists warnings and the
+	// flagged content is only stored redacted. With block the compose is rejected.
+	SecretScanning *OrgPolicySecretScanning `json:"secret_scanning,omitempty"`
+}
+
+// OrgPolicySecretScanning What happens to composes embedding credentials, like AWS keys or private keys, in files
+// or ignition configs. With warn the compose is built, the response lists warnings and the
+// flagged content is only stored redacted. With block the compose is rejected.
+type OrgPolicySecretScanning string
+
+// OrgPolicyResponse defines model for OrgPolicyResponse.
+type OrgPolicyResponse struct {
+	Policy    OrgPolicy `json:"policy"`
+	UpdatedAt string    `json:"updated_at"`
+
+	// UpdatedBy email of the administrator who last changed the policy
+	UpdatedBy string `json:"updated_by"`
+}
+
 // Package defines model for Package.
 type Package struct {
 	Name    string `json:"name"`
@@ -838,9 +1929,23 @@ type PackagesResponse struct {
 	Meta  ListResponseMeta  `json:"meta"`
 }
 
+// PublishComposeTemplateRequest defines model for PublishComposeTemplateRequest.
+type PublishComposeTemplateRequest struct {
+	Description *string `json:"description,omitempty"`
+
+	// Name name of the template, composes of the template are labelled with it
+	Name    string         `json:"name"`
+	Request ComposeRequest `json:"request"`
+}
+
 // Readiness defines model for Readiness.
 type Readiness struct {
+	// Readiness ready, or read-only while composer or the database fail over. In read-only mode
+	// mutations are rejected with 503 and compose statuses are served from the database.
 	Readiness string `json:"readiness"`
+
+	// Reason why the service is in read-only mode
+	Reason *string `json:"reason,omitempty"`
 }
 
 // RecommendPackageRequest defines model for RecommendPackageRequest.
@@ -854,6 +1959,11 @@ type RecommendationsResponse struct {
 	Packages []string `json:"packages"`
 }
 
+// RepositoriesHealth defines model for RepositoriesHealth.
+type RepositoriesHealth struct {
+	Data []RepositoryHealth `json:"data"`
+}
+
 // Repository defines model for Repository.
 type Repository struct {
 	Baseurl  *string `json:"baseurl,omitempty"`
@@ -869,6 +1979,60 @@ type Repository struct {
 	Rhsm           bool    `json:"rhsm"`
 }
 
+// RepositoryHealth defines model for RepositoryHealth.
+type RepositoryHealth struct {
+	Architecture string `json:"architecture"`
+	CheckedAt    string `json:"checked_at"`
+
+	// Distributions distributions using the repository
+	Distributions []string `json:"distributions"`
+
+	// Error why the repository is considered unreachable
+	Error *string `json:"error,omitempty"`
+
+	// LastModified when the repository metadata last changed, as reported by the mirror
+	LastModified *string `json:"last_modified,omitempty"`
+	Reachable    bool    `json:"reachable"`
+
+	// StatusCode HTTP status of the response, omitted if no response was received
+	StatusCode *int `json:"status_code,omitempty"`
+
+	// Url URL which was requested, the repomd.xml or the metalink of the repository
+	Url string `json:"url"`
+}
+
+// RepositoryRemapping defines model for RepositoryRemapping.
+type RepositoryRemapping struct {
+	// From URL of the repository in the blueprint
+	From string `json:"from"`
+
+	// To URL of the repository in the new blueprint
+	To string `json:"to"`
+}
+
+// RetargetBlueprintRequest defines model for RetargetBlueprintRequest.
+type RetargetBlueprintRequest struct {
+	Distribution Distributions `json:"distribution"`
+
+	// Name name of the new blueprint, defaults to the name of the blueprint followed by the distribution
+	Name *string `json:"name,omitempty"`
+}
+
+// RetargetBlueprintResponse defines model for RetargetBlueprintResponse.
+type RetargetBlueprintResponse struct {
+	// Id UUID of the new blueprint
+	Id openapi_types.UUID `json:"id"`
+
+	// Report What the blueprint uses which the new release lacks.
+	Report BlueprintCompatibilityReport `json:"report"`
+}
+
+// RollbackBlueprintRequest defines model for RollbackBlueprintRequest.
+type RollbackBlueprintRequest struct {
+	// Version the version to roll back to
+	Version int `json:"version"`
+}
+
 // Services defines model for Services.
 type Services struct {
 	// Disabled List of services to disable by default
@@ -881,6 +2045,29 @@ type Services struct {
 	Masked *[]string `json:"masked,omitempty"`
 }
 
+// ShareLink defines model for ShareLink.
+type ShareLink struct {
+	ExpiresAt string `json:"expires_at"`
+	Token     string `json:"token"`
+
+	// Url URL of the status of the shared compose, /logs appended to it gets the logs
+	Url string `json:"url"`
+}
+
+// ShareLinkRequest defines model for ShareLinkRequest.
+type ShareLinkRequest struct {
+	// ExpiresIn seconds until the link expires, at most a week
+	ExpiresIn *int `json:"expires_in,omitempty"`
+}
+
+// SharedCompose defines model for SharedCompose.
+type SharedCompose struct {
+	// ExpiresAt when the share link expires
+	ExpiresAt   string             `json:"expires_at"`
+	Id          openapi_types.UUID `json:"id"`
+	ImageStatus ImageStatus        `json:"image_status"`
+}
+
 // Subscription defines model for Subscription.
 type Subscription struct {
 	ActivationKey string `json:"activation-key"`
@@ -902,6 +2089,31 @@ type Timezone struct {
 	Timezone *string `json:"timezone,omitempty"`
 }
 
+// UploadGrant defines model for UploadGrant.
+type UploadGrant struct {
+	// AccountId aws account the images are shared with
+	AccountId *string             `json:"account_id,omitempty"`
+	Alias     string              `json:"alias"`
+	CreatedAt string              `json:"created_at"`
+	CreatedBy *string             `json:"created_by,omitempty"`
+	Provider  UploadGrantProvider `json:"provider"`
+	SourceId  string              `json:"source_id"`
+
+	// SubscriptionId azure subscription the images are uploaded to
+	SubscriptionId *string `json:"subscription_id,omitempty"`
+
+	// TenantId azure tenant the images are uploaded to
+	TenantId *string `json:"tenant_id,omitempty"`
+}
+
+// UploadGrantProvider defines model for UploadGrant.Provider.
+type UploadGrantProvider string
+
+// UploadGrantsResponse defines model for UploadGrantsResponse.
+type UploadGrantsResponse struct {
+	Data []UploadGrant `json:"data"`
+}
+
 // UploadRequest defines model for UploadRequest.
 type UploadRequest struct {
 	Options UploadRequest_Options `json:"options"`
@@ -913,11 +2125,25 @@ type UploadRequest_Options struct {
 	union json.RawMessage
 }
 
+// UploadProgress Progress of the upload phase. Only present while the image is being
+// transferred to the upload target and the target reports it.
+type UploadProgress struct {
+	// BytesTotal Total size of the upload in bytes, if known
+	BytesTotal *int64 `json:"bytes_total,omitempty"`
+
+	// BytesTransferred Number of bytes transferred so far
+	BytesTransferred int64 `json:"bytes_transferred"`
+}
+
 // UploadStatus defines model for UploadStatus.
 type UploadStatus struct {
 	Options UploadStatus_Options `json:"options"`
-	Status  UploadStatusStatus   `json:"status"`
-	Type    UploadTypes          `json:"type"`
+
+	// Progress Progress of the upload phase. Only present while the image is being
+	// transferred to the upload target and the target reports it.
+	Progress *UploadProgress    `json:"progress,omitempty"`
+	Status   UploadStatusStatus `json:"status"`
+	Type     UploadTypes        `json:"type"`
 }
 
 // UploadStatus_Options defines model for UploadStatus.Options.
@@ -931,6 +2157,31 @@ type UploadStatusStatus string
 // UploadTypes defines model for UploadTypes.
 type UploadTypes string
 
+// UsageForecast defines model for UsageForecast.
+type UsageForecast struct {
+	// Composes composes expected in the month over all distributions and image types
+	Composes      int                 `json:"composes"`
+	Data          []UsageForecastItem `json:"data"`
+	HistoryMonths int                 `json:"history_months"`
+
+	// Month the month which is forecasted
+	Month string `json:"month"`
+
+	// StorageBytes bytes of compose artifacts expected to be added to object storage in the month
+	StorageBytes int64 `json:"storage_bytes"`
+}
+
+// UsageForecastItem defines model for UsageForecastItem.
+type UsageForecastItem struct {
+	Composes     int    `json:"composes"`
+	Distribution string `json:"distribution"`
+
+	// History composes of the months the forecast is based on, oldest first
+	History      []int  `json:"history"`
+	ImageType    string `json:"image_type"`
+	StorageBytes int64  `json:"storage_bytes"`
+}
+
 // User defines model for User.
 type User struct {
 	Name   string `json:"name"`
@@ -944,6 +2195,58 @@ type Version struct {
 	Version     string  `json:"version"`
 }
 
+// Workspace A workspace splits the blueprints of an organization, only its members and the organization
+// administrators see them. The policy applies to the composes of the blueprints of the
+// workspace on top of the one of the organization, its cost center and compose expiry are the
+// ones of the organization though.
+type Workspace struct {
+	ComposeQuota *int   `json:"compose_quota,omitempty"`
+	CreatedAt    string `json:"created_at"`
+
+	// CreatedBy email of the administrator who created the workspace
+	CreatedBy   string             `json:"created_by"`
+	Description string             `json:"description"`
+	Id          openapi_types.UUID `json:"id"`
+	Members     []WorkspaceMember  `json:"members"`
+	Name        string             `json:"name"`
+	Policy      *OrgPolicy         `json:"policy,omitempty"`
+}
+
+// WorkspaceMember defines model for WorkspaceMember.
+type WorkspaceMember struct {
+	Email string `json:"email"`
+
+	// Role Viewers see the blueprints of the workspace and their composes, builders also create,
+	// change and compose them.
+	Role WorkspaceRole `json:"role"`
+}
+
+// WorkspaceRequest defines model for WorkspaceRequest.
+type WorkspaceRequest struct {
+	// ComposeQuota composes the blueprints of the workspace can request within the sliding window of the
+	// quota of the organization, on top of the quota of the organization. No limit when omitted.
+	ComposeQuota *int               `json:"compose_quota,omitempty"`
+	Description  *string            `json:"description,omitempty"`
+	Members      *[]WorkspaceMember `json:"members,omitempty"`
+	Name         string             `json:"name"`
+	Policy       *OrgPolicy         `json:"policy,omitempty"`
+}
+
+// WorkspaceRole Viewers see the blueprints of the workspace and their composes, builders also create,
+// change and compose them.
+type WorkspaceRole string
+
+// WorkspacesResponse defines model for WorkspacesResponse.
+type WorkspacesResponse struct {
+	Data []Workspace `json:"data"`
+}
+
+// GetUsageForecastParams defines parameters for GetUsageForecast.
+type GetUsageForecastParams struct {
+	// HistoryMonths number of complete months the forecast is based on, default 6
+	HistoryMonths *int `form:"history_months,omitempty" json:"history_months,omitempty"`
+}
+
 // GetBlueprintsParams defines parameters for GetBlueprints.
 type GetBlueprintsParams struct {
 	// Name fetch blueprint with specific name
@@ -952,6 +2255,13 @@ type GetBlueprintsParams struct {
 	// Search search for blueprints by name or description
 	Search *string `form:"search,omitempty" json:"search,omitempty"`
 
+	// Owner only the blueprints created by the user (mine) or only the ones others share
+	// with the organization (shared), default both
+	Owner *GetBlueprintsParamsOwner `form:"owner,omitempty" json:"owner,omitempty"`
+
+	// WorkspaceId only the blueprints of the workspace
+	WorkspaceId *openapi_types.UUID `form:"workspace_id,omitempty" json:"workspace_id,omitempty"`
+
 	// Limit max amount of blueprints, default 100
 	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
 
@@ -959,6 +2269,9 @@ type GetBlueprintsParams struct {
 	Offset *int `form:"offset,omitempty" json:"offset,omitempty"`
 }
 
+// GetBlueprintsParamsOwner defines parameters for GetBlueprints.
+type GetBlueprintsParamsOwner string
+
 // GetBlueprintParams defines parameters for GetBlueprint.
 type GetBlueprintParams struct {
 	// Version Filter by a specific version of the Blueprint we want to fetch.
@@ -966,9 +2279,30 @@ type GetBlueprintParams struct {
 	Version *int `form:"version,omitempty" json:"version,omitempty"`
 }
 
+// PatchBlueprintApplicationJSONPatchPlusJSONBody defines parameters for PatchBlueprint.
+type PatchBlueprintApplicationJSONPatchPlusJSONBody = []JSONPatchOperation
+
+// PatchBlueprintApplicationMergePatchPlusJSONBody defines parameters for PatchBlueprint.
+type PatchBlueprintApplicationMergePatchPlusJSONBody = map[string]interface{}
+
+// PatchBlueprintParams defines parameters for PatchBlueprint.
+type PatchBlueprintParams struct {
+	// IfMatch ETag of the version the patch was made for
+	IfMatch *string `json:"If-Match,omitempty"`
+}
+
+// UpdateBlueprintParams defines parameters for UpdateBlueprint.
+type UpdateBlueprintParams struct {
+	// IfMatch ETag of the version the update was made for
+	IfMatch *string `json:"If-Match,omitempty"`
+}
+
 // ComposeBlueprintJSONBody defines parameters for ComposeBlueprint.
 type ComposeBlueprintJSONBody struct {
 	ImageTypes *[]ImageTypes `json:"image_types,omitempty"`
+
+	// Version version of the blueprint to compose, defaults to the latest one
+	Version *int `json:"version,omitempty"`
 }
 
 // GetBlueprintComposesParams defines parameters for GetBlueprintComposes.
@@ -987,6 +2321,24 @@ type GetBlueprintComposesParams struct {
 	IgnoreImageTypes *[]ImageTypes `form:"ignoreImageTypes,omitempty" json:"ignoreImageTypes,omitempty"`
 }
 
+// GetBlueprintInstanceTypesParams defines parameters for GetBlueprintInstanceTypes.
+type GetBlueprintInstanceTypesParams struct {
+	// Provider cloud provider the images are launched in
+	Provider GetBlueprintInstanceTypesParamsProvider `form:"provider" json:"provider"`
+
+	// Region region to list the instance types of
+	Region string `form:"region" json:"region"`
+
+	// Zone availability zone to list the instance types of, required for azure
+	Zone *string `form:"zone,omitempty" json:"zone,omitempty"`
+
+	// InstanceType only check this instance type
+	InstanceType *string `form:"instance_type,omitempty" json:"instance_type,omitempty"`
+}
+
+// GetBlueprintInstanceTypesParamsProvider defines parameters for GetBlueprintInstanceTypes.
+type GetBlueprintInstanceTypesParamsProvider string
+
 // GetComposesParams defines parameters for GetComposes.
 type GetComposesParams struct {
 	// Limit max amount of composes, default 100
@@ -995,8 +2347,62 @@ type GetComposesParams struct {
 	// Offset composes page offset, default 0
 	Offset *int `form:"offset,omitempty" json:"offset,omitempty"`
 
+	// Cursor Opaque position to continue listing from, taken from the next link of the previous
+	// page. Unlike with offsets, composes created while iterating don't cause others to be
+	// skipped or listed twice. Cursors stay valid until the compose they point at falls out
+	// of the listed window. Can't be combined with offset.
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
+
 	// IgnoreImageTypes Filter the composes on image type. The filter is optional and can be specified multiple times.
 	IgnoreImageTypes *[]ImageTypes `form:"ignoreImageTypes,omitempty" json:"ignoreImageTypes,omitempty"`
+
+	// ImageType Only list composes of these image types, aliases match each other. Can be specified multiple
+	// times.
+	ImageType *[]ImageTypes `form:"image_type,omitempty" json:"image_type,omitempty"`
+
+	// Distribution Only list composes of these distributions. Can be specified multiple times.
+	Distribution *[]Distributions `form:"distribution,omitempty" json:"distribution,omitempty"`
+
+	// Status Only list composes in these states. Unfinished composes are the ones still building, or which
+	// weren't looked at since they finished. Can be specified multiple times.
+	Status *[]GetComposesParamsStatus `form:"status,omitempty" json:"status,omitempty"`
+
+	// CreatedAfter Only list composes created at or after this time.
+	CreatedAfter *time.Time `form:"created_after,omitempty" json:"created_after,omitempty"`
+
+	// CreatedBefore Only list composes created before this time.
+	CreatedBefore *time.Time `form:"created_before,omitempty" json:"created_before,omitempty"`
+
+	// LabelSelector Only list composes carrying all of these labels, as comma separated key=value pairs.
+	LabelSelector *string `form:"label_selector,omitempty" json:"label_selector,omitempty"`
+
+	// Creator Only list composes recorded as created by the user with this email address.
+	Creator *string `form:"creator,omitempty" json:"creator,omitempty"`
+
+	// Sort Order of the composes, newest first by default.
+	Sort *GetComposesParamsSort `form:"sort,omitempty" json:"sort,omitempty"`
+
+	// Fields Comma separated list of the fields to return for every compose, all of them by default.
+	// Leaving out the request skips decrypting and decoding the stored compose requests, which
+	// keeps the listing cheap for dashboards only showing names and dates.
+	Fields *[]GetComposesParamsFields `form:"fields,omitempty" json:"fields,omitempty"`
+}
+
+// GetComposesParamsStatus defines parameters for GetComposes.
+type GetComposesParamsStatus string
+
+// GetComposesParamsSort defines parameters for GetComposes.
+type GetComposesParamsSort string
+
+// GetComposesParamsFields defines parameters for GetComposes.
+type GetComposesParamsFields string
+
+// DeleteComposeParams defines parameters for DeleteCompose.
+type DeleteComposeParams struct {
+	// DeleteArtifacts Also delete the compose in osbuild-composer, which removes the artifacts it keeps, like
+	// the manifests, logs and images stored by the service. Images uploaded to cloud accounts are not
+	// affected.
+	DeleteArtifacts *bool `form:"delete_artifacts,omitempty" json:"delete_artifacts,omitempty"`
 }
 
 // GetComposeClonesParams defines parameters for GetComposeClones.
@@ -1008,6 +2414,15 @@ type GetComposeClonesParams struct {
 	Offset *int `form:"offset,omitempty" json:"offset,omitempty"`
 }
 
+// GetEventsParams defines parameters for GetEvents.
+type GetEventsParams struct {
+	// Since only return events after this cursor, omit to start with the oldest event still kept
+	Since *string `form:"since,omitempty" json:"since,omitempty"`
+
+	// Limit max amount of events, default 100
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
 // GetPackagesParams defines parameters for GetPackages.
 type GetPackagesParams struct {
 	// Distribution distribution to look up packages for
@@ -1026,27 +2441,120 @@ type GetPackagesParams struct {
 	Offset *int `form:"offset,omitempty" json:"offset,omitempty"`
 }
 
+// DeleteOrgPolicyParams defines parameters for DeleteOrgPolicy.
+type DeleteOrgPolicyParams struct {
+	// IfMatch ETag of the policy to remove
+	IfMatch *string `json:"If-Match,omitempty"`
+}
+
+// SetOrgPolicyParams defines parameters for SetOrgPolicy.
+type SetOrgPolicyParams struct {
+	// IfMatch ETag of the policy the update was made for
+	IfMatch *string `json:"If-Match,omitempty"`
+}
+
 // GetPackagesParamsArchitecture defines parameters for GetPackages.
 type GetPackagesParamsArchitecture string
 
+// GetComposeTemplateParams defines parameters for GetComposeTemplate.
+type GetComposeTemplateParams struct {
+	// Version version of the template, the latest one if omitted
+	Version *int `form:"version,omitempty" json:"version,omitempty"`
+}
+
 // CreateBlueprintJSONRequestBody defines body for CreateBlueprint for application/json ContentType.
 type CreateBlueprintJSONRequestBody = CreateBlueprintRequest
 
+// PatchBlueprintApplicationJSONPatchPlusJSONRequestBody defines body for PatchBlueprint for application/json-patch+json ContentType.
+type PatchBlueprintApplicationJSONPatchPlusJSONRequestBody = PatchBlueprintApplicationJSONPatchPlusJSONBody
+
+// PatchBlueprintApplicationMergePatchPlusJSONRequestBody defines body for PatchBlueprint for application/merge-patch+json ContentType.
+type PatchBlueprintApplicationMergePatchPlusJSONRequestBody = PatchBlueprintApplicationMergePatchPlusJSONBody
+
 // UpdateBlueprintJSONRequestBody defines body for UpdateBlueprint for application/json ContentType.
 type UpdateBlueprintJSONRequestBody = CreateBlueprintRequest
 
 // ComposeBlueprintJSONRequestBody defines body for ComposeBlueprint for application/json ContentType.
 type ComposeBlueprintJSONRequestBody ComposeBlueprintJSONBody
 
+// DuplicateBlueprintJSONRequestBody defines body for DuplicateBlueprint for application/json ContentType.
+type DuplicateBlueprintJSONRequestBody = DuplicateBlueprintRequest
+
+// RetargetBlueprintJSONRequestBody defines body for RetargetBlueprint for application/json ContentType.
+type RetargetBlueprintJSONRequestBody = RetargetBlueprintRequest
+
+// RollbackBlueprintJSONRequestBody defines body for RollbackBlueprint for application/json ContentType.
+type RollbackBlueprintJSONRequestBody = RollbackBlueprintRequest
+
 // ComposeImageJSONRequestBody defines body for ComposeImage for application/json ContentType.
 type ComposeImageJSONRequestBody = ComposeRequest
 
+// LintComposeJSONRequestBody defines body for LintCompose for application/json ContentType.
+type LintComposeJSONRequestBody = ComposeRequest
+
+// ValidateComposeJSONRequestBody defines body for ValidateCompose for application/json ContentType.
+type ValidateComposeJSONRequestBody = ComposeRequest
+
+// TransferComposesJSONRequestBody defines body for TransferComposes for application/json ContentType.
+type TransferComposesJSONRequestBody = ComposesTransferRequest
+
+// UpdateComposeJSONRequestBody defines body for UpdateCompose for application/json ContentType.
+type UpdateComposeJSONRequestBody = ComposeUpdateRequest
+
 // CloneComposeJSONRequestBody defines body for CloneCompose for application/json ContentType.
 type CloneComposeJSONRequestBody = CloneRequest
 
+// ExtendComposeExpiryJSONRequestBody defines body for ExtendComposeExpiry for application/json ContentType.
+type ExtendComposeExpiryJSONRequestBody = ComposeExpiry
+
+// CreateMarketplaceSubmissionJSONRequestBody defines body for CreateMarketplaceSubmission for application/json ContentType.
+type CreateMarketplaceSubmissionJSONRequestBody = MarketplaceSubmissionRequest
+
+// ShareComposeJSONRequestBody defines body for ShareCompose for application/json ContentType.
+type ShareComposeJSONRequestBody = ComposeShareRequest
+
+// CreateComposeShareLinkJSONRequestBody defines body for CreateComposeShareLink for application/json ContentType.
+type CreateComposeShareLinkJSONRequestBody = ShareLinkRequest
+
+// TransferComposeJSONRequestBody defines body for TransferCompose for application/json ContentType.
+type TransferComposeJSONRequestBody = ComposeTransferRequest
+
+// SetOrgDefaultsJSONRequestBody defines body for SetOrgDefaults for application/json ContentType.
+type SetOrgDefaultsJSONRequestBody = OrgDefaults
+
+// CreateDownloadTokenJSONRequestBody defines body for CreateDownloadToken for application/json ContentType.
+type CreateDownloadTokenJSONRequestBody = DownloadTokenRequest
+
+// CreateComposeDraftJSONRequestBody defines body for CreateComposeDraft for application/json ContentType.
+type CreateComposeDraftJSONRequestBody = ComposeDraftRequest
+
+// UpdateComposeDraftJSONRequestBody defines body for UpdateComposeDraft for application/json ContentType.
+type UpdateComposeDraftJSONRequestBody = ComposeDraftRequest
+
 // RecommendPackageJSONRequestBody defines body for RecommendPackage for application/json ContentType.
 type RecommendPackageJSONRequestBody = RecommendPackageRequest
 
+// CreateGitOpsRepositoryJSONRequestBody defines body for CreateGitOpsRepository for application/json ContentType.
+type CreateGitOpsRepositoryJSONRequestBody = CreateGitOpsRepositoryRequest
+
+// SetOrgPolicyJSONRequestBody defines body for SetOrgPolicy for application/json ContentType.
+type SetOrgPolicyJSONRequestBody = OrgPolicy
+
+// PublishComposeTemplateJSONRequestBody defines body for PublishComposeTemplate for application/json ContentType.
+type PublishComposeTemplateJSONRequestBody = PublishComposeTemplateRequest
+
+// ComposeFromTemplateJSONRequestBody defines body for ComposeFromTemplate for application/json ContentType.
+type ComposeFromTemplateJSONRequestBody = ComposeTemplateOverrides
+
+// CreateUploadGrantJSONRequestBody defines body for CreateUploadGrant for application/json ContentType.
+type CreateUploadGrantJSONRequestBody = CreateUploadGrantRequest
+
+// CreateWorkspaceJSONRequestBody defines body for CreateWorkspace for application/json ContentType.
+type CreateWorkspaceJSONRequestBody = WorkspaceRequest
+
+// UpdateWorkspaceJSONRequestBody defines body for UpdateWorkspace for application/json ContentType.
+type UpdateWorkspaceJSONRequestBody = WorkspaceRequest
+
 // AsAWSEC2Clone returns the union data inside the CloneRequest as a AWSEC2Clone
 func (t CloneRequest) AsAWSEC2Clone() (AWSEC2Clone, error) {
 	var body AWSEC2Clone
@@ -1753,9 +3261,24 @@ func (t *UploadStatus_Options) UnmarshalJSON(b []byte) error {
 
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// forecast the compose usage of the organization for next month
+	// (GET /admin/forecast)
+	GetUsageForecast(ctx echo.Context, params GetUsageForecastParams) error
+	// get a summary of the health of the service
+	// (GET /admin/health/summary)
+	GetHealthSummary(ctx echo.Context) error
+	// get the reachability of the distribution repositories
+	// (GET /admin/repositories)
+	GetRepositoriesHealth(ctx echo.Context) error
+	// generate a support bundle for a compose
+	// (POST /admin/support-bundle/{composeId})
+	CreateSupportBundle(ctx echo.Context, composeId openapi_types.UUID) error
 	// get the architectures and their image types available for a given distribution
 	// (GET /architectures/{distribution})
 	GetArchitectures(ctx echo.Context, distribution Distributions) error
+	// get a download link for an image with a download token
+	// (GET /artifacts/{composeId}/download)
+	GetArtifactDownload(ctx echo.Context, composeId openapi_types.UUID) error
 	// get a collection of blueprints
 	// (GET /blueprints)
 	GetBlueprints(ctx echo.Context, params GetBlueprintsParams) error
@@ -1768,48 +3291,180 @@ type ServerInterface interface {
 	// get detail of a blueprint
 	// (GET /blueprints/{id})
 	GetBlueprint(ctx echo.Context, id openapi_types.UUID, params GetBlueprintParams) error
+	// patch a blueprint
+	// (PATCH /blueprints/{id})
+	PatchBlueprint(ctx echo.Context, id openapi_types.UUID, params PatchBlueprintParams) error
 	// update blueprint
 	// (PUT /blueprints/{id})
-	UpdateBlueprint(ctx echo.Context, id openapi_types.UUID) error
+	UpdateBlueprint(ctx echo.Context, id openapi_types.UUID, params UpdateBlueprintParams) error
 	// create new compose from blueprint
 	// (POST /blueprints/{id}/compose)
 	ComposeBlueprint(ctx echo.Context, id openapi_types.UUID) error
 	// get composes associated with a blueprint
 	// (GET /blueprints/{id}/composes)
 	GetBlueprintComposes(ctx echo.Context, id openapi_types.UUID, params GetBlueprintComposesParams) error
+	// duplicate a blueprint
+	// (POST /blueprints/{id}/duplicate)
+	DuplicateBlueprint(ctx echo.Context, id openapi_types.UUID) error
 	// export a blueprint
 	// (GET /blueprints/{id}/export)
 	ExportBlueprint(ctx echo.Context, id openapi_types.UUID) error
+	// get the instance types the images of a blueprint can be launched on
+	// (GET /blueprints/{id}/instance_types)
+	GetBlueprintInstanceTypes(ctx echo.Context, id openapi_types.UUID, params GetBlueprintInstanceTypesParams) error
+	// preview the lifecycle policy of a blueprint
+	// (GET /blueprints/{id}/lifecycle/preview)
+	PreviewBlueprintLifecycle(ctx echo.Context, id openapi_types.UUID) error
+	// check if rebuilding the images of a blueprint would pick up updates
+	// (GET /blueprints/{id}/outdated)
+	GetBlueprintOutdated(ctx echo.Context, id openapi_types.UUID) error
+	// clone a blueprint to a newer release of its distribution
+	// (POST /blueprints/{id}/retarget)
+	RetargetBlueprint(ctx echo.Context, id openapi_types.UUID) error
+	// roll a blueprint back to one of its versions
+	// (POST /blueprints/{id}/rollback)
+	RollbackBlueprint(ctx echo.Context, id openapi_types.UUID) error
+	// get the versions of a blueprint
+	// (GET /blueprints/{id}/versions)
+	GetBlueprintVersions(ctx echo.Context, id openapi_types.UUID) error
 	// get status of a compose clone
 	// (GET /clones/{id})
 	GetCloneStatus(ctx echo.Context, id openapi_types.UUID) error
 	// compose image
 	// (POST /compose)
 	ComposeImage(ctx echo.Context) error
+	// check a compose request without composing it
+	// (POST /compose/lint)
+	LintCompose(ctx echo.Context) error
+	// dry-run a compose request
+	// (POST /compose/validate)
+	ValidateCompose(ctx echo.Context) error
 	// get a collection of previous compose requests for the logged in user
 	// (GET /composes)
 	GetComposes(ctx echo.Context, params GetComposesParams) error
+	// transfer the composes of a user to another one
+	// (POST /composes/transfer)
+	TransferComposes(ctx echo.Context) error
 	// delete a compose
 	// (DELETE /composes/{composeId})
-	DeleteCompose(ctx echo.Context, composeId openapi_types.UUID) error
+	DeleteCompose(ctx echo.Context, composeId openapi_types.UUID, params DeleteComposeParams) error
 	// get status of an image compose
 	// (GET /composes/{composeId})
 	GetComposeStatus(ctx echo.Context, composeId openapi_types.UUID) error
+	// rename or annotate a compose
+	// (PATCH /composes/{composeId})
+	UpdateCompose(ctx echo.Context, composeId openapi_types.UUID) error
+	// export everything needed to reproduce an image compose
+	// (GET /composes/{composeId}/bundle)
+	GetComposeBundle(ctx echo.Context, composeId openapi_types.UUID) error
+	// cancel an image compose
+	// (POST /composes/{composeId}/cancel)
+	CancelCompose(ctx echo.Context, composeId openapi_types.UUID) error
 	// clone a compose
 	// (POST /composes/{composeId}/clone)
 	CloneCompose(ctx echo.Context, composeId openapi_types.UUID) error
 	// get clones of a compose
 	// (GET /composes/{composeId}/clones)
 	GetComposeClones(ctx echo.Context, composeId openapi_types.UUID, params GetComposeClonesParams) error
+	// compare two composes
+	// (GET /composes/{composeId}/diff/{otherComposeId})
+	GetComposeDiff(ctx echo.Context, composeId openapi_types.UUID, otherComposeId openapi_types.UUID) error
+	// get a download link for the image of a compose
+	// (GET /composes/{composeId}/download)
+	GetComposeDownload(ctx echo.Context, composeId openapi_types.UUID) error
+	// stream the status updates of an image compose
+	// (GET /composes/{composeId}/events)
+	GetComposeEvents(ctx echo.Context, composeId openapi_types.UUID) error
+	// extend the expiry of an image compose
+	// (PUT /composes/{composeId}/expiry)
+	ExtendComposeExpiry(ctx echo.Context, composeId openapi_types.UUID) error
+	// get the build log of an image compose
+	// (GET /composes/{composeId}/logs)
+	GetComposeLogs(ctx echo.Context, composeId openapi_types.UUID) error
+	// get the osbuild manifest of an image compose
+	// (GET /composes/{composeId}/manifest)
+	GetComposeManifest(ctx echo.Context, composeId openapi_types.UUID) error
+	// prepare the AMI of a compose for AWS Marketplace
+	// (POST /composes/{composeId}/marketplace-submission)
+	CreateMarketplaceSubmission(ctx echo.Context, composeId openapi_types.UUID) error
 	// get metadata of an image compose
 	// (GET /composes/{composeId}/metadata)
 	GetComposeMetadata(ctx echo.Context, composeId openapi_types.UUID) error
+	// retry a failed image compose
+	// (POST /composes/{composeId}/retry)
+	RetryCompose(ctx echo.Context, composeId openapi_types.UUID) error
+	// share the image of a compose with AWS accounts
+	// (POST /composes/{composeId}/share)
+	ShareCompose(ctx echo.Context, composeId openapi_types.UUID) error
+	// create a link sharing the status of an image compose
+	// (POST /composes/{composeId}/share-link)
+	CreateComposeShareLink(ctx echo.Context, composeId openapi_types.UUID) error
+	// transfer an image compose to another user
+	// (POST /composes/{composeId}/transfer)
+	TransferCompose(ctx echo.Context, composeId openapi_types.UUID) error
+	// remove the defaults of the organization
+	// (DELETE /defaults)
+	DeleteOrgDefaults(ctx echo.Context) error
+	// get the defaults of the organization
+	// (GET /defaults)
+	GetOrgDefaults(ctx echo.Context) error
+	// set the defaults of the organization
+	// (PUT /defaults)
+	SetOrgDefaults(ctx echo.Context) error
 	// get the distributions available to this user
 	// (GET /distributions)
 	GetDistributions(ctx echo.Context) error
+	// get the newer releases a distribution can be upgraded to
+	// (GET /distributions/{distribution}/upgrade-targets)
+	GetUpgradeTargets(ctx echo.Context, distribution Distributions) error
+	// get the download tokens of the organization
+	// (GET /download-tokens)
+	GetDownloadTokens(ctx echo.Context) error
+	// create a download token
+	// (POST /download-tokens)
+	CreateDownloadToken(ctx echo.Context) error
+	// revoke a download token
+	// (DELETE /download-tokens/{id})
+	DeleteDownloadToken(ctx echo.Context, id openapi_types.UUID) error
+	// get the compose drafts of the user
+	// (GET /drafts)
+	GetComposeDrafts(ctx echo.Context) error
+	// save a compose draft
+	// (POST /drafts)
+	CreateComposeDraft(ctx echo.Context) error
+	// delete a compose draft
+	// (DELETE /drafts/{id})
+	DeleteComposeDraft(ctx echo.Context, id openapi_types.UUID) error
+	// get a compose draft
+	// (GET /drafts/{id})
+	GetComposeDraft(ctx echo.Context, id openapi_types.UUID) error
+	// update a compose draft
+	// (PUT /drafts/{id})
+	UpdateComposeDraft(ctx echo.Context, id openapi_types.UUID) error
+	// get the compose lifecycle events of the organization
+	// (GET /events)
+	GetEvents(ctx echo.Context, params GetEventsParams) error
 	// List recommended packages.
 	// (POST /experimental/recommendations)
 	RecommendPackage(ctx echo.Context) error
+	// get the git repositories blueprints are synced from
+	// (GET /gitops/repositories)
+	GetGitOpsRepositories(ctx echo.Context) error
+	// register a git repository to sync blueprints from
+	// (POST /gitops/repositories)
+	CreateGitOpsRepository(ctx echo.Context) error
+	// stop syncing blueprints from a git repository
+	// (DELETE /gitops/repositories/{id})
+	DeleteGitOpsRepository(ctx echo.Context, id openapi_types.UUID) error
+	// get a git repository
+	// (GET /gitops/repositories/{id})
+	GetGitOpsRepository(ctx echo.Context, id openapi_types.UUID) error
+	// compare the blueprints in git with the stored ones
+	// (GET /gitops/repositories/{id}/drift)
+	GetGitOpsDrift(ctx echo.Context, id openapi_types.UUID) error
+	// sync blueprints from a git repository
+	// (POST /gitops/repositories/{id}/sync)
+	SyncGitOpsRepository(ctx echo.Context, id openapi_types.UUID) error
 	// get the available profiles for a given distribution. This is a temporary endpoint meant to be removed soon.
 	// (GET /oscap/{distribution}/profiles)
 	GetOscapProfiles(ctx echo.Context, distribution Distributions) error
@@ -1819,12 +3474,69 @@ type ServerInterface interface {
 
 	// (GET /packages)
 	GetPackages(ctx echo.Context, params GetPackagesParams) error
+	// remove the image building policy of the organization
+	// (DELETE /policy)
+	DeleteOrgPolicy(ctx echo.Context, params DeleteOrgPolicyParams) error
+	// get the image building policy of the organization
+	// (GET /policy)
+	GetOrgPolicy(ctx echo.Context) error
+	// set the image building policy of the organization
+	// (PUT /policy)
+	SetOrgPolicy(ctx echo.Context, params SetOrgPolicyParams) error
 	// return the readiness
 	// (GET /ready)
 	GetReadiness(ctx echo.Context) error
+	// get the status of a shared image compose
+	// (GET /shared/{token})
+	GetSharedCompose(ctx echo.Context, token string) error
+	// get the logs of a shared image compose
+	// (GET /shared/{token}/logs)
+	GetSharedComposeLogs(ctx echo.Context, token string) error
+	// get the compose templates of the organization
+	// (GET /templates)
+	GetComposeTemplates(ctx echo.Context) error
+	// publish a compose template
+	// (POST /templates)
+	PublishComposeTemplate(ctx echo.Context) error
+	// delete all versions of a compose template
+	// (DELETE /templates/{name})
+	DeleteComposeTemplate(ctx echo.Context, name string) error
+	// get a version of a compose template
+	// (GET /templates/{name})
+	GetComposeTemplate(ctx echo.Context, name string, params GetComposeTemplateParams) error
+	// compose an image from a compose template
+	// (POST /templates/{name}/compose)
+	ComposeFromTemplate(ctx echo.Context, name string) error
+	// get the cloud accounts the organization pre-authorized to share images with
+	// (GET /upload-grants)
+	GetUploadGrants(ctx echo.Context) error
+	// pre-authorize a cloud account to share images with
+	// (POST /upload-grants)
+	CreateUploadGrant(ctx echo.Context) error
+	// revoke an upload grant
+	// (DELETE /upload-grants/{alias})
+	DeleteUploadGrant(ctx echo.Context, alias string) error
+	// get the current compose usage of the organization
+	// (GET /usage/current)
+	GetCurrentUsage(ctx echo.Context) error
 	// get the service version
 	// (GET /version)
 	GetVersion(ctx echo.Context) error
+	// get the workspaces of the organization
+	// (GET /workspaces)
+	GetWorkspaces(ctx echo.Context) error
+	// create a workspace
+	// (POST /workspaces)
+	CreateWorkspace(ctx echo.Context) error
+	// delete a workspace
+	// (DELETE /workspaces/{id})
+	DeleteWorkspace(ctx echo.Context, id openapi_types.UUID) error
+	// get a workspace
+	// (GET /workspaces/{id})
+	GetWorkspace(ctx echo.Context, id openapi_types.UUID) error
+	// update a workspace
+	// (PUT /workspaces/{id})
+	UpdateWorkspace(ctx echo.Context, id openapi_types.UUID) error
 }
 
 // ServerInterfaceWrapper converts echo contexts to parameters.
@@ -1832,11 +3544,63 @@ type ServerInterfaceWrapper struct {
 	Handler ServerInterface
 }
 
-// GetArchitectures converts echo context to params.
-func (w *ServerInterfaceWrapper) GetArchitectures(ctx echo.Context) error {
+// GetUsageForecast converts echo context to params.
+func (w *ServerInterfaceWrapper) GetUsageForecast(ctx echo.Context) error {
 	var err error
-	// ------------- Path parameter "distribution" -------------
-	var distribution Distributions
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetUsageForecastParams
+	// ------------- Optional query parameter "history_months" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "history_months", ctx.QueryParams(), &params.HistoryMonths)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter history_months: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetUsageForecast(ctx, params)
+	return err
+}
+
+// GetHealthSummary converts echo context to params.
+func (w *ServerInterfaceWrapper) GetHealthSummary(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetHealthSummary(ctx)
+	return err
+}
+
+// GetRepositoriesHealth converts echo context to params.
+func (w *ServerInterfaceWrapper) GetRepositoriesHealth(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetRepositoriesHealth(ctx)
+	return err
+}
+
+// CreateSupportBundle converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateSupportBundle(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.CreateSupportBundle(ctx, composeId)
+	return err
+}
+
+// GetArchitectures converts echo context to params.
+func (w *ServerInterfaceWrapper) GetArchitectures(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "distribution" -------------
+	var distribution Distributions
 
 	err = runtime.BindStyledParameterWithOptions("simple", "distribution", ctx.Param("distribution"), &distribution, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
 	if err != nil {
@@ -1848,6 +3612,22 @@ func (w *ServerInterfaceWrapper) GetArchitectures(ctx echo.Context) error {
 	return err
 }
 
+// GetArtifactDownload converts echo context to params.
+func (w *ServerInterfaceWrapper) GetArtifactDownload(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetArtifactDownload(ctx, composeId)
+	return err
+}
+
 // GetBlueprints converts echo context to params.
 func (w *ServerInterfaceWrapper) GetBlueprints(ctx echo.Context) error {
 	var err error
@@ -1868,6 +3648,20 @@ func (w *ServerInterfaceWrapper) GetBlueprints(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter search: %s", err))
 	}
 
+	// ------------- Optional query parameter "owner" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "owner", ctx.QueryParams(), &params.Owner)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter owner: %s", err))
+	}
+
+	// ------------- Optional query parameter "workspace_id" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "workspace_id", ctx.QueryParams(), &params.WorkspaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter workspace_id: %s", err))
+	}
+
 	// ------------- Optional query parameter "limit" -------------
 
 	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
@@ -1937,6 +3731,42 @@ func (w *ServerInterfaceWrapper) GetBlueprint(ctx echo.Context) error {
 	return err
 }
 
+// PatchBlueprint converts echo context to params.
+func (w *ServerInterfaceWrapper) PatchBlueprint(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PatchBlueprintParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "If-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-Match")]; found {
+		var IfMatch string
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for If-Match, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "If-Match", valueList[0], &IfMatch, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter If-Match: %s", err))
+		}
+
+		params.IfMatch = &IfMatch
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PatchBlueprint(ctx, id, params)
+	return err
+}
+
 // UpdateBlueprint converts echo context to params.
 func (w *ServerInterfaceWrapper) UpdateBlueprint(ctx echo.Context) error {
 	var err error
@@ -1948,8 +3778,28 @@ func (w *ServerInterfaceWrapper) UpdateBlueprint(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
 	}
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params UpdateBlueprintParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "If-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-Match")]; found {
+		var IfMatch string
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for If-Match, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "If-Match", valueList[0], &IfMatch, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter If-Match: %s", err))
+		}
+
+		params.IfMatch = &IfMatch
+	}
+
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.UpdateBlueprint(ctx, id)
+	err = w.Handler.UpdateBlueprint(ctx, id, params)
 	return err
 }
 
@@ -2015,8 +3865,8 @@ func (w *ServerInterfaceWrapper) GetBlueprintComposes(ctx echo.Context) error {
 	return err
 }
 
-// ExportBlueprint converts echo context to params.
-func (w *ServerInterfaceWrapper) ExportBlueprint(ctx echo.Context) error {
+// DuplicateBlueprint converts echo context to params.
+func (w *ServerInterfaceWrapper) DuplicateBlueprint(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "id" -------------
 	var id openapi_types.UUID
@@ -2027,12 +3877,12 @@ func (w *ServerInterfaceWrapper) ExportBlueprint(ctx echo.Context) error {
 	}
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.ExportBlueprint(ctx, id)
+	err = w.Handler.DuplicateBlueprint(ctx, id)
 	return err
 }
 
-// GetCloneStatus converts echo context to params.
-func (w *ServerInterfaceWrapper) GetCloneStatus(ctx echo.Context) error {
+// ExportBlueprint converts echo context to params.
+func (w *ServerInterfaceWrapper) ExportBlueprint(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "id" -------------
 	var id openapi_types.UUID
@@ -2043,223 +3893,953 @@ func (w *ServerInterfaceWrapper) GetCloneStatus(ctx echo.Context) error {
 	}
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GetCloneStatus(ctx, id)
+	err = w.Handler.ExportBlueprint(ctx, id)
 	return err
 }
 
-// ComposeImage converts echo context to params.
-func (w *ServerInterfaceWrapper) ComposeImage(ctx echo.Context) error {
+// GetBlueprintInstanceTypes converts echo context to params.
+func (w *ServerInterfaceWrapper) GetBlueprintInstanceTypes(ctx echo.Context) error {
 	var err error
+	// ------------- Path parameter "id" -------------
+	var id openapi_types.UUID
 
-	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.ComposeImage(ctx)
-	return err
-}
-
-// GetComposes converts echo context to params.
-func (w *ServerInterfaceWrapper) GetComposes(ctx echo.Context) error {
-	var err error
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
 
 	// Parameter object where we will unmarshal all parameters from the context
-	var params GetComposesParams
-	// ------------- Optional query parameter "limit" -------------
+	var params GetBlueprintInstanceTypesParams
+	// ------------- Required query parameter "provider" -------------
 
-	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
+	err = runtime.BindQueryParameter("form", true, true, "provider", ctx.QueryParams(), &params.Provider)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter provider: %s", err))
 	}
 
-	// ------------- Optional query parameter "offset" -------------
+	// ------------- Required query parameter "region" -------------
 
-	err = runtime.BindQueryParameter("form", true, false, "offset", ctx.QueryParams(), &params.Offset)
+	err = runtime.BindQueryParameter("form", true, true, "region", ctx.QueryParams(), &params.Region)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter offset: %s", err))
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter region: %s", err))
 	}
 
-	// ------------- Optional query parameter "ignoreImageTypes" -------------
+	// ------------- Optional query parameter "zone" -------------
 
-	err = runtime.BindQueryParameter("form", true, false, "ignoreImageTypes", ctx.QueryParams(), &params.IgnoreImageTypes)
+	err = runtime.BindQueryParameter("form", true, false, "zone", ctx.QueryParams(), &params.Zone)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter ignoreImageTypes: %s", err))
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter zone: %s", err))
+	}
+
+	// ------------- Optional query parameter "instance_type" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "instance_type", ctx.QueryParams(), &params.InstanceType)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter instance_type: %s", err))
 	}
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GetComposes(ctx, params)
+	err = w.Handler.GetBlueprintInstanceTypes(ctx, id, params)
 	return err
 }
 
-// DeleteCompose converts echo context to params.
-func (w *ServerInterfaceWrapper) DeleteCompose(ctx echo.Context) error {
+// PreviewBlueprintLifecycle converts echo context to params.
+func (w *ServerInterfaceWrapper) PreviewBlueprintLifecycle(ctx echo.Context) error {
 	var err error
-	// ------------- Path parameter "composeId" -------------
-	var composeId openapi_types.UUID
+	// ------------- Path parameter "id" -------------
+	var id openapi_types.UUID
 
-	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
 	}
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.DeleteCompose(ctx, composeId)
+	err = w.Handler.PreviewBlueprintLifecycle(ctx, id)
 	return err
 }
 
-// GetComposeStatus converts echo context to params.
-func (w *ServerInterfaceWrapper) GetComposeStatus(ctx echo.Context) error {
+// GetBlueprintOutdated converts echo context to params.
+func (w *ServerInterfaceWrapper) GetBlueprintOutdated(ctx echo.Context) error {
 	var err error
-	// ------------- Path parameter "composeId" -------------
-	var composeId openapi_types.UUID
+	// ------------- Path parameter "id" -------------
+	var id openapi_types.UUID
 
-	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
 	}
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GetComposeStatus(ctx, composeId)
+	err = w.Handler.GetBlueprintOutdated(ctx, id)
 	return err
 }
 
-// CloneCompose converts echo context to params.
-func (w *ServerInterfaceWrapper) CloneCompose(ctx echo.Context) error {
+// RetargetBlueprint converts echo context to params.
+func (w *ServerInterfaceWrapper) RetargetBlueprint(ctx echo.Context) error {
 	var err error
-	// ------------- Path parameter "composeId" -------------
-	var composeId openapi_types.UUID
+	// ------------- Path parameter "id" -------------
+	var id openapi_types.UUID
 
-	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
 	}
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.CloneCompose(ctx, composeId)
+	err = w.Handler.RetargetBlueprint(ctx, id)
 	return err
 }
 
-// GetComposeClones converts echo context to params.
-func (w *ServerInterfaceWrapper) GetComposeClones(ctx echo.Context) error {
+// RollbackBlueprint converts echo context to params.
+func (w *ServerInterfaceWrapper) RollbackBlueprint(ctx echo.Context) error {
 	var err error
-	// ------------- Path parameter "composeId" -------------
-	var composeId openapi_types.UUID
+	// ------------- Path parameter "id" -------------
+	var id openapi_types.UUID
 
-	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
 	}
 
-	// Parameter object where we will unmarshal all parameters from the context
-	var params GetComposeClonesParams
-	// ------------- Optional query parameter "limit" -------------
-
-	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
-	}
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.RollbackBlueprint(ctx, id)
+	return err
+}
 
-	// ------------- Optional query parameter "offset" -------------
+// GetBlueprintVersions converts echo context to params.
+func (w *ServerInterfaceWrapper) GetBlueprintVersions(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id openapi_types.UUID
 
-	err = runtime.BindQueryParameter("form", true, false, "offset", ctx.QueryParams(), &params.Offset)
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter offset: %s", err))
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
 	}
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GetComposeClones(ctx, composeId, params)
+	err = w.Handler.GetBlueprintVersions(ctx, id)
 	return err
 }
 
-// GetComposeMetadata converts echo context to params.
-func (w *ServerInterfaceWrapper) GetComposeMetadata(ctx echo.Context) error {
+// GetCloneStatus converts echo context to params.
+func (w *ServerInterfaceWrapper) GetCloneStatus(ctx echo.Context) error {
 	var err error
-	// ------------- Path parameter "composeId" -------------
-	var composeId openapi_types.UUID
+	// ------------- Path parameter "id" -------------
+	var id openapi_types.UUID
 
-	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
 	}
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GetComposeMetadata(ctx, composeId)
+	err = w.Handler.GetCloneStatus(ctx, id)
 	return err
 }
 
-// GetDistributions converts echo context to params.
-func (w *ServerInterfaceWrapper) GetDistributions(ctx echo.Context) error {
+// ComposeImage converts echo context to params.
+func (w *ServerInterfaceWrapper) ComposeImage(ctx echo.Context) error {
 	var err error
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GetDistributions(ctx)
+	err = w.Handler.ComposeImage(ctx)
 	return err
 }
 
-// RecommendPackage converts echo context to params.
-func (w *ServerInterfaceWrapper) RecommendPackage(ctx echo.Context) error {
+// LintCompose converts echo context to params.
+func (w *ServerInterfaceWrapper) LintCompose(ctx echo.Context) error {
 	var err error
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.RecommendPackage(ctx)
+	err = w.Handler.LintCompose(ctx)
 	return err
 }
 
-// GetOscapProfiles converts echo context to params.
-func (w *ServerInterfaceWrapper) GetOscapProfiles(ctx echo.Context) error {
+// ValidateCompose converts echo context to params.
+func (w *ServerInterfaceWrapper) ValidateCompose(ctx echo.Context) error {
 	var err error
-	// ------------- Path parameter "distribution" -------------
-	var distribution Distributions
-
-	err = runtime.BindStyledParameterWithOptions("simple", "distribution", ctx.Param("distribution"), &distribution, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter distribution: %s", err))
-	}
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GetOscapProfiles(ctx, distribution)
+	err = w.Handler.ValidateCompose(ctx)
 	return err
 }
 
-// GetOscapCustomizations converts echo context to params.
-func (w *ServerInterfaceWrapper) GetOscapCustomizations(ctx echo.Context) error {
+// GetComposes converts echo context to params.
+func (w *ServerInterfaceWrapper) GetComposes(ctx echo.Context) error {
 	var err error
-	// ------------- Path parameter "distribution" -------------
-	var distribution Distributions
 
-	err = runtime.BindStyledParameterWithOptions("simple", "distribution", ctx.Param("distribution"), &distribution, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetComposesParams
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter distribution: %s", err))
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
 	}
 
-	// ------------- Path parameter "profile" -------------
-	var profile DistributionProfileItem
+	// ------------- Optional query parameter "offset" -------------
 
-	err = runtime.BindStyledParameterWithOptions("simple", "profile", ctx.Param("profile"), &profile, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	err = runtime.BindQueryParameter("form", true, false, "offset", ctx.QueryParams(), &params.Offset)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter profile: %s", err))
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter offset: %s", err))
 	}
 
-	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GetOscapCustomizations(ctx, distribution, profile)
-	return err
-}
+	// ------------- Optional query parameter "cursor" -------------
 
-// GetPackages converts echo context to params.
-func (w *ServerInterfaceWrapper) GetPackages(ctx echo.Context) error {
-	var err error
+	err = runtime.BindQueryParameter("form", true, false, "cursor", ctx.QueryParams(), &params.Cursor)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter cursor: %s", err))
+	}
 
-	// Parameter object where we will unmarshal all parameters from the context
-	var params GetPackagesParams
-	// ------------- Required query parameter "distribution" -------------
+	// ------------- Optional query parameter "ignoreImageTypes" -------------
 
-	err = runtime.BindQueryParameter("form", true, true, "distribution", ctx.QueryParams(), &params.Distribution)
+	err = runtime.BindQueryParameter("form", true, false, "ignoreImageTypes", ctx.QueryParams(), &params.IgnoreImageTypes)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter distribution: %s", err))
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter ignoreImageTypes: %s", err))
 	}
 
-	// ------------- Required query parameter "architecture" -------------
+	// ------------- Optional query parameter "image_type" -------------
 
-	err = runtime.BindQueryParameter("form", true, true, "architecture", ctx.QueryParams(), &params.Architecture)
+	err = runtime.BindQueryParameter("form", true, false, "image_type", ctx.QueryParams(), &params.ImageType)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter architecture: %s", err))
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter image_type: %s", err))
+	}
+
+	// ------------- Optional query parameter "distribution" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "distribution", ctx.QueryParams(), &params.Distribution)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter distribution: %s", err))
+	}
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", ctx.QueryParams(), &params.Status)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter status: %s", err))
+	}
+
+	// ------------- Optional query parameter "created_after" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "created_after", ctx.QueryParams(), &params.CreatedAfter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter created_after: %s", err))
+	}
+
+	// ------------- Optional query parameter "created_before" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "created_before", ctx.QueryParams(), &params.CreatedBefore)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter created_before: %s", err))
+	}
+
+	// ------------- Optional query parameter "label_selector" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "label_selector", ctx.QueryParams(), &params.LabelSelector)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter label_selector: %s", err))
+	}
+
+	// ------------- Optional query parameter "creator" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "creator", ctx.QueryParams(), &params.Creator)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter creator: %s", err))
+	}
+
+	// ------------- Optional query parameter "sort" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "sort", ctx.QueryParams(), &params.Sort)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter sort: %s", err))
+	}
+
+	// ------------- Optional query parameter "fields" -------------
+
+	err = runtime.BindQueryParameter("form", false, false, "fields", ctx.QueryParams(), &params.Fields)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter fields: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetComposes(ctx, params)
+	return err
+}
+
+// TransferComposes converts echo context to params.
+func (w *ServerInterfaceWrapper) TransferComposes(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.TransferComposes(ctx)
+	return err
+}
+
+// DeleteCompose converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteCompose(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params DeleteComposeParams
+	// ------------- Optional query parameter "delete_artifacts" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "delete_artifacts", ctx.QueryParams(), &params.DeleteArtifacts)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter delete_artifacts: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteCompose(ctx, composeId, params)
+	return err
+}
+
+// GetComposeStatus converts echo context to params.
+func (w *ServerInterfaceWrapper) GetComposeStatus(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetComposeStatus(ctx, composeId)
+	return err
+}
+
+// UpdateCompose converts echo context to params.
+func (w *ServerInterfaceWrapper) UpdateCompose(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.UpdateCompose(ctx, composeId)
+	return err
+}
+
+// GetComposeBundle converts echo context to params.
+func (w *ServerInterfaceWrapper) GetComposeBundle(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetComposeBundle(ctx, composeId)
+	return err
+}
+
+// CancelCompose converts echo context to params.
+func (w *ServerInterfaceWrapper) CancelCompose(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.CancelCompose(ctx, composeId)
+	return err
+}
+
+// CloneCompose converts echo context to params.
+func (w *ServerInterfaceWrapper) CloneCompose(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.CloneCompose(ctx, composeId)
+	return err
+}
+
+// GetComposeClones converts echo context to params.
+func (w *ServerInterfaceWrapper) GetComposeClones(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetComposeClonesParams
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
+	}
+
+	// ------------- Optional query parameter "offset" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "offset", ctx.QueryParams(), &params.Offset)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter offset: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetComposeClones(ctx, composeId, params)
+	return err
+}
+
+// GetComposeDiff converts echo context to params.
+func (w *ServerInterfaceWrapper) GetComposeDiff(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// ------------- Path parameter "otherComposeId" -------------
+	var otherComposeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "otherComposeId", ctx.Param("otherComposeId"), &otherComposeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter otherComposeId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetComposeDiff(ctx, composeId, otherComposeId)
+	return err
+}
+
+// GetComposeDownload converts echo context to params.
+func (w *ServerInterfaceWrapper) GetComposeDownload(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetComposeDownload(ctx, composeId)
+	return err
+}
+
+// GetComposeEvents converts echo context to params.
+func (w *ServerInterfaceWrapper) GetComposeEvents(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetComposeEvents(ctx, composeId)
+	return err
+}
+
+// ExtendComposeExpiry converts echo context to params.
+func (w *ServerInterfaceWrapper) ExtendComposeExpiry(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ExtendComposeExpiry(ctx, composeId)
+	return err
+}
+
+// GetComposeLogs converts echo context to params.
+func (w *ServerInterfaceWrapper) GetComposeLogs(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetComposeLogs(ctx, composeId)
+	return err
+}
+
+// GetComposeManifest converts echo context to params.
+func (w *ServerInterfaceWrapper) GetComposeManifest(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetComposeManifest(ctx, composeId)
+	return err
+}
+
+// CreateMarketplaceSubmission converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateMarketplaceSubmission(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.CreateMarketplaceSubmission(ctx, composeId)
+	return err
+}
+
+// GetComposeMetadata converts echo context to params.
+func (w *ServerInterfaceWrapper) GetComposeMetadata(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetComposeMetadata(ctx, composeId)
+	return err
+}
+
+// RetryCompose converts echo context to params.
+func (w *ServerInterfaceWrapper) RetryCompose(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.RetryCompose(ctx, composeId)
+	return err
+}
+
+// ShareCompose converts echo context to params.
+func (w *ServerInterfaceWrapper) ShareCompose(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ShareCompose(ctx, composeId)
+	return err
+}
+
+// CreateComposeShareLink converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateComposeShareLink(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.CreateComposeShareLink(ctx, composeId)
+	return err
+}
+
+// TransferCompose converts echo context to params.
+func (w *ServerInterfaceWrapper) TransferCompose(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "composeId" -------------
+	var composeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "composeId", ctx.Param("composeId"), &composeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter composeId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.TransferCompose(ctx, composeId)
+	return err
+}
+
+// DeleteOrgDefaults converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteOrgDefaults(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteOrgDefaults(ctx)
+	return err
+}
+
+// GetOrgDefaults converts echo context to params.
+func (w *ServerInterfaceWrapper) GetOrgDefaults(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetOrgDefaults(ctx)
+	return err
+}
+
+// SetOrgDefaults converts echo context to params.
+func (w *ServerInterfaceWrapper) SetOrgDefaults(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.SetOrgDefaults(ctx)
+	return err
+}
+
+// GetDistributions converts echo context to params.
+func (w *ServerInterfaceWrapper) GetDistributions(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetDistributions(ctx)
+	return err
+}
+
+// GetUpgradeTargets converts echo context to params.
+func (w *ServerInterfaceWrapper) GetUpgradeTargets(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "distribution" -------------
+	var distribution Distributions
+
+	err = runtime.BindStyledParameterWithOptions("simple", "distribution", ctx.Param("distribution"), &distribution, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter distribution: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetUpgradeTargets(ctx, distribution)
+	return err
+}
+
+// GetDownloadTokens converts echo context to params.
+func (w *ServerInterfaceWrapper) GetDownloadTokens(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetDownloadTokens(ctx)
+	return err
+}
+
+// CreateDownloadToken converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateDownloadToken(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.CreateDownloadToken(ctx)
+	return err
+}
+
+// DeleteDownloadToken converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteDownloadToken(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteDownloadToken(ctx, id)
+	return err
+}
+
+// GetComposeDrafts converts echo context to params.
+func (w *ServerInterfaceWrapper) GetComposeDrafts(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetComposeDrafts(ctx)
+	return err
+}
+
+// CreateComposeDraft converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateComposeDraft(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.CreateComposeDraft(ctx)
+	return err
+}
+
+// DeleteComposeDraft converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteComposeDraft(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteComposeDraft(ctx, id)
+	return err
+}
+
+// GetComposeDraft converts echo context to params.
+func (w *ServerInterfaceWrapper) GetComposeDraft(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetComposeDraft(ctx, id)
+	return err
+}
+
+// UpdateComposeDraft converts echo context to params.
+func (w *ServerInterfaceWrapper) UpdateComposeDraft(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.UpdateComposeDraft(ctx, id)
+	return err
+}
+
+// GetEvents converts echo context to params.
+func (w *ServerInterfaceWrapper) GetEvents(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetEventsParams
+	// ------------- Optional query parameter "since" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "since", ctx.QueryParams(), &params.Since)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter since: %s", err))
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetEvents(ctx, params)
+	return err
+}
+
+// RecommendPackage converts echo context to params.
+func (w *ServerInterfaceWrapper) RecommendPackage(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.RecommendPackage(ctx)
+	return err
+}
+
+// GetGitOpsRepositories converts echo context to params.
+func (w *ServerInterfaceWrapper) GetGitOpsRepositories(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetGitOpsRepositories(ctx)
+	return err
+}
+
+// CreateGitOpsRepository converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateGitOpsRepository(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.CreateGitOpsRepository(ctx)
+	return err
+}
+
+// DeleteGitOpsRepository converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteGitOpsRepository(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteGitOpsRepository(ctx, id)
+	return err
+}
+
+// GetGitOpsRepository converts echo context to params.
+func (w *ServerInterfaceWrapper) GetGitOpsRepository(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetGitOpsRepository(ctx, id)
+	return err
+}
+
+// GetGitOpsDrift converts echo context to params.
+func (w *ServerInterfaceWrapper) GetGitOpsDrift(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetGitOpsDrift(ctx, id)
+	return err
+}
+
+// SyncGitOpsRepository converts echo context to params.
+func (w *ServerInterfaceWrapper) SyncGitOpsRepository(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.SyncGitOpsRepository(ctx, id)
+	return err
+}
+
+// GetOscapProfiles converts echo context to params.
+func (w *ServerInterfaceWrapper) GetOscapProfiles(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "distribution" -------------
+	var distribution Distributions
+
+	err = runtime.BindStyledParameterWithOptions("simple", "distribution", ctx.Param("distribution"), &distribution, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter distribution: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetOscapProfiles(ctx, distribution)
+	return err
+}
+
+// GetOscapCustomizations converts echo context to params.
+func (w *ServerInterfaceWrapper) GetOscapCustomizations(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "distribution" -------------
+	var distribution Distributions
+
+	err = runtime.BindStyledParameterWithOptions("simple", "distribution", ctx.Param("distribution"), &distribution, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter distribution: %s", err))
+	}
+
+	// ------------- Path parameter "profile" -------------
+	var profile DistributionProfileItem
+
+	err = runtime.BindStyledParameterWithOptions("simple", "profile", ctx.Param("profile"), &profile, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter profile: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetOscapCustomizations(ctx, distribution, profile)
+	return err
+}
+
+// GetPackages converts echo context to params.
+func (w *ServerInterfaceWrapper) GetPackages(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetPackagesParams
+	// ------------- Required query parameter "distribution" -------------
+
+	err = runtime.BindQueryParameter("form", true, true, "distribution", ctx.QueryParams(), &params.Distribution)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter distribution: %s", err))
+	}
+
+	// ------------- Required query parameter "architecture" -------------
+
+	err = runtime.BindQueryParameter("form", true, true, "architecture", ctx.QueryParams(), &params.Architecture)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter architecture: %s", err))
 	}
 
 	// ------------- Required query parameter "search" -------------
@@ -2288,6 +4868,73 @@ func (w *ServerInterfaceWrapper) GetPackages(ctx echo.Context) error {
 	return err
 }
 
+// DeleteOrgPolicy converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteOrgPolicy(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params DeleteOrgPolicyParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "If-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-Match")]; found {
+		var IfMatch string
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for If-Match, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "If-Match", valueList[0], &IfMatch, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter If-Match: %s", err))
+		}
+
+		params.IfMatch = &IfMatch
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteOrgPolicy(ctx, params)
+	return err
+}
+
+// GetOrgPolicy converts echo context to params.
+func (w *ServerInterfaceWrapper) GetOrgPolicy(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetOrgPolicy(ctx)
+	return err
+}
+
+// SetOrgPolicy converts echo context to params.
+func (w *ServerInterfaceWrapper) SetOrgPolicy(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params SetOrgPolicyParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "If-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-Match")]; found {
+		var IfMatch string
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for If-Match, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "If-Match", valueList[0], &IfMatch, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter If-Match: %s", err))
+		}
+
+		params.IfMatch = &IfMatch
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.SetOrgPolicy(ctx, params)
+	return err
+}
+
 // GetReadiness converts echo context to params.
 func (w *ServerInterfaceWrapper) GetReadiness(ctx echo.Context) error {
 	var err error
@@ -2297,6 +4944,156 @@ func (w *ServerInterfaceWrapper) GetReadiness(ctx echo.Context) error {
 	return err
 }
 
+// GetSharedCompose converts echo context to params.
+func (w *ServerInterfaceWrapper) GetSharedCompose(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "token" -------------
+	var token string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "token", ctx.Param("token"), &token, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter token: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetSharedCompose(ctx, token)
+	return err
+}
+
+// GetSharedComposeLogs converts echo context to params.
+func (w *ServerInterfaceWrapper) GetSharedComposeLogs(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "token" -------------
+	var token string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "token", ctx.Param("token"), &token, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter token: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetSharedComposeLogs(ctx, token)
+	return err
+}
+
+// GetComposeTemplates converts echo context to params.
+func (w *ServerInterfaceWrapper) GetComposeTemplates(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetComposeTemplates(ctx)
+	return err
+}
+
+// PublishComposeTemplate converts echo context to params.
+func (w *ServerInterfaceWrapper) PublishComposeTemplate(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PublishComposeTemplate(ctx)
+	return err
+}
+
+// DeleteComposeTemplate converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteComposeTemplate(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "name" -------------
+	var name string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "name", ctx.Param("name"), &name, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter name: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteComposeTemplate(ctx, name)
+	return err
+}
+
+// GetComposeTemplate converts echo context to params.
+func (w *ServerInterfaceWrapper) GetComposeTemplate(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "name" -------------
+	var name string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "name", ctx.Param("name"), &name, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter name: %s", err))
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetComposeTemplateParams
+	// ------------- Optional query parameter "version" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "version", ctx.QueryParams(), &params.Version)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter version: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetComposeTemplate(ctx, name, params)
+	return err
+}
+
+// ComposeFromTemplate converts echo context to params.
+func (w *ServerInterfaceWrapper) ComposeFromTemplate(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "name" -------------
+	var name string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "name", ctx.Param("name"), &name, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter name: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ComposeFromTemplate(ctx, name)
+	return err
+}
+
+// GetUploadGrants converts echo context to params.
+func (w *ServerInterfaceWrapper) GetUploadGrants(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetUploadGrants(ctx)
+	return err
+}
+
+// CreateUploadGrant converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateUploadGrant(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.CreateUploadGrant(ctx)
+	return err
+}
+
+// DeleteUploadGrant converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteUploadGrant(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "alias" -------------
+	var alias string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "alias", ctx.Param("alias"), &alias, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter alias: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteUploadGrant(ctx, alias)
+	return err
+}
+
+// GetCurrentUsage converts echo context to params.
+func (w *ServerInterfaceWrapper) GetCurrentUsage(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetCurrentUsage(ctx)
+	return err
+}
+
 // GetVersion converts echo context to params.
 func (w *ServerInterfaceWrapper) GetVersion(ctx echo.Context) error {
 	var err error
@@ -2306,6 +5103,72 @@ func (w *ServerInterfaceWrapper) GetVersion(ctx echo.Context) error {
 	return err
 }
 
+// GetWorkspaces converts echo context to params.
+func (w *ServerInterfaceWrapper) GetWorkspaces(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetWorkspaces(ctx)
+	return err
+}
+
+// CreateWorkspace converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateWorkspace(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.CreateWorkspace(ctx)
+	return err
+}
+
+// DeleteWorkspace converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteWorkspace(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteWorkspace(ctx, id)
+	return err
+}
+
+// GetWorkspace converts echo context to params.
+func (w *ServerInterfaceWrapper) GetWorkspace(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetWorkspace(ctx, id)
+	return err
+}
+
+// UpdateWorkspace converts echo context to params.
+func (w *ServerInterfaceWrapper) UpdateWorkspace(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.UpdateWorkspace(ctx, id)
+	return err
+}
+
 // This is a simple interface which specifies echo.Route addition functions which
 // are present on both echo.Echo and echo.Group, since we want to allow using
 // either of them for path registration
@@ -2334,29 +5197,97 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL
 		Handler: si,
 	}
 
+	router.GET(baseURL+"/admin/forecast", wrapper.GetUsageForecast)
+	router.GET(baseURL+"/admin/health/summary", wrapper.GetHealthSummary)
+	router.GET(baseURL+"/admin/repositories", wrapper.GetRepositoriesHealth)
+	router.POST(baseURL+"/admin/support-bundle/:composeId", wrapper.CreateSupportBundle)
 	router.GET(baseURL+"/architectures/:distribution", wrapper.GetArchitectures)
+	router.GET(baseURL+"/artifacts/:composeId/download", wrapper.GetArtifactDownload)
 	router.GET(baseURL+"/blueprints", wrapper.GetBlueprints)
 	router.POST(baseURL+"/blueprints", wrapper.CreateBlueprint)
 	router.DELETE(baseURL+"/blueprints/:id", wrapper.DeleteBlueprint)
 	router.GET(baseURL+"/blueprints/:id", wrapper.GetBlueprint)
+	router.PATCH(baseURL+"/blueprints/:id", wrapper.PatchBlueprint)
 	router.PUT(baseURL+"/blueprints/:id", wrapper.UpdateBlueprint)
 	router.POST(baseURL+"/blueprints/:id/compose", wrapper.ComposeBlueprint)
 	router.GET(baseURL+"/blueprints/:id/composes", wrapper.GetBlueprintComposes)
+	router.POST(baseURL+"/blueprints/:id/duplicate", wrapper.DuplicateBlueprint)
 	router.GET(baseURL+"/blueprints/:id/export", wrapper.ExportBlueprint)
+	router.GET(baseURL+"/blueprints/:id/instance_types", wrapper.GetBlueprintInstanceTypes)
+	router.GET(baseURL+"/blueprints/:id/lifecycle/preview", wrapper.PreviewBlueprintLifecycle)
+	router.GET(baseURL+"/blueprints/:id/outdated", wrapper.GetBlueprintOutdated)
+	router.POST(baseURL+"/blueprints/:id/retarget", wrapper.RetargetBlueprint)
+	router.POST(baseURL+"/blueprints/:id/rollback", wrapper.RollbackBlueprint)
+	router.GET(baseURL+"/blueprints/:id/versions", wrapper.GetBlueprintVersions)
 	router.GET(baseURL+"/clones/:id", wrapper.GetCloneStatus)
 	router.POST(baseURL+"/compose", wrapper.ComposeImage)
+	router.POST(baseURL+"/compose/lint", wrapper.LintCompose)
+	router.POST(baseURL+"/compose/validate", wrapper.ValidateCompose)
 	router.GET(baseURL+"/composes", wrapper.GetComposes)
+	router.POST(baseURL+"/composes/transfer", wrapper.TransferComposes)
 	router.DELETE(baseURL+"/composes/:composeId", wrapper.DeleteCompose)
 	router.GET(baseURL+"/composes/:composeId", wrapper.GetComposeStatus)
+	router.PATCH(baseURL+"/composes/:composeId", wrapper.UpdateCompose)
+	router.GET(baseURL+"/composes/:composeId/bundle", wrapper.GetComposeBundle)
+	router.POST(baseURL+"/composes/:composeId/cancel", wrapper.CancelCompose)
 	router.POST(baseURL+"/composes/:composeId/clone", wrapper.CloneCompose)
 	router.GET(baseURL+"/composes/:composeId/clones", wrapper.GetComposeClones)
+	router.GET(baseURL+"/composes/:composeId/diff/:otherComposeId", wrapper.GetComposeDiff)
+	router.GET(baseURL+"/composes/:composeId/download", wrapper.GetComposeDownload)
+	router.GET(baseURL+"/composes/:composeId/events", wrapper.GetComposeEvents)
+	router.PUT(baseURL+"/composes/:composeId/expiry", wrapper.ExtendComposeExpiry)
+	router.GET(baseURL+"/composes/:composeId/logs", wrapper.GetComposeLogs)
+	router.GET(baseURL+"/composes/:composeId/manifest", wrapper.GetComposeManifest)
+	router.POST(baseURL+"/composes/:composeId/marketplace-submission", wrapper.CreateMarketplaceSubmission)
 	router.GET(baseURL+"/composes/:composeId/metadata", wrapper.GetComposeMetadata)
+	router.POST(baseURL+"/composes/:composeId/retry", wrapper.RetryCompose)
+	router.POST(baseURL+"/composes/:composeId/share", wrapper.ShareCompose)
+	router.POST(baseURL+"/composes/:composeId/share-link", wrapper.CreateComposeShareLink)
+	router.POST(baseURL+"/composes/:composeId/transfer", wrapper.TransferCompose)
+	router.DELETE(baseURL+"/defaults", wrapper.DeleteOrgDefaults)
+	router.GET(baseURL+"/defaults", wrapper.GetOrgDefaults)
+	router.PUT(baseURL+"/defaults", wrapper.SetOrgDefaults)
 	router.GET(baseURL+"/distributions", wrapper.GetDistributions)
+	router.GET(baseURL+"/distributions/:distribution/upgrade-targets", wrapper.GetUpgradeTargets)
+	router.GET(baseURL+"/download-tokens", wrapper.GetDownloadTokens)
+	router.POST(baseURL+"/download-tokens", wrapper.CreateDownloadToken)
+	router.DELETE(baseURL+"/download-tokens/:id", wrapper.DeleteDownloadToken)
+	router.GET(baseURL+"/drafts", wrapper.GetComposeDrafts)
+	router.POST(baseURL+"/drafts", wrapper.CreateComposeDraft)
+	router.DELETE(baseURL+"/drafts/:id", wrapper.DeleteComposeDraft)
+	router.GET(baseURL+"/drafts/:id", wrapper.GetComposeDraft)
+	router.PUT(baseURL+"/drafts/:id", wrapper.UpdateComposeDraft)
+	router.GET(baseURL+"/events", wrapper.GetEvents)
 	router.POST(baseURL+"/experimental/recommendations", wrapper.RecommendPackage)
+	router.GET(baseURL+"/gitops/repositories", wrapper.GetGitOpsRepositories)
+	router.POST(baseURL+"/gitops/repositories", wrapper.CreateGitOpsRepository)
+	router.DELETE(baseURL+"/gitops/repositories/:id", wrapper.DeleteGitOpsRepository)
+	router.GET(baseURL+"/gitops/repositories/:id", wrapper.GetGitOpsRepository)
+	router.GET(baseURL+"/gitops/repositories/:id/drift", wrapper.GetGitOpsDrift)
+	router.POST(baseURL+"/gitops/repositories/:id/sync", wrapper.SyncGitOpsRepository)
 	router.GET(baseURL+"/oscap/:distribution/profiles", wrapper.GetOscapProfiles)
 	router.GET(baseURL+"/oscap/:distribution/:profile/customizations", wrapper.GetOscapCustomizations)
 	router.GET(baseURL+"/packages", wrapper.GetPackages)
+	router.DELETE(baseURL+"/policy", wrapper.DeleteOrgPolicy)
+	router.GET(baseURL+"/policy", wrapper.GetOrgPolicy)
+	router.PUT(baseURL+"/policy", wrapper.SetOrgPolicy)
 	router.GET(baseURL+"/ready", wrapper.GetReadiness)
+	router.GET(baseURL+"/shared/:token", wrapper.GetSharedCompose)
+	router.GET(baseURL+"/shared/:token/logs", wrapper.GetSharedComposeLogs)
+	router.GET(baseURL+"/templates", wrapper.GetComposeTemplates)
+	router.POST(baseURL+"/templates", wrapper.PublishComposeTemplate)
+	router.DELETE(baseURL+"/templates/:name", wrapper.DeleteComposeTemplate)
+	router.GET(baseURL+"/templates/:name", wrapper.GetComposeTemplate)
+	router.POST(baseURL+"/templates/:name/compose", wrapper.ComposeFromTemplate)
+	router.GET(baseURL+"/upload-grants", wrapper.GetUploadGrants)
+	router.POST(baseURL+"/upload-grants", wrapper.CreateUploadGrant)
+	router.DELETE(baseURL+"/upload-grants/:alias", wrapper.DeleteUploadGrant)
+	router.GET(baseURL+"/usage/current", wrapper.GetCurrentUsage)
 	router.GET(baseURL+"/version", wrapper.GetVersion)
+	router.GET(baseURL+"/workspaces", wrapper.GetWorkspaces)
+	router.POST(baseURL+"/workspaces", wrapper.CreateWorkspace)
+	router.DELETE(baseURL+"/workspaces/:id", wrapper.DeleteWorkspace)
+	router.GET(baseURL+"/workspaces/:id", wrapper.GetWorkspace)
+	router.PUT(baseURL+"/workspaces/:id", wrapper.UpdateWorkspace)
 
 }
@@ -0,0 +1,39 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/labstack/echo/v4"
+
+	iberrors "github.com/osbuild/image-builder/pkg/errors"
+)
+
+// apiError returns the echo error of a public error, the error handler reports
+// its code along with the message.
+func apiError(code iberrors.Code, status int, message string) *echo.HTTPError {
+	return &echo.HTTPError{
+		Code:     status,
+		Message:  message,
+		Internal: iberrors.New(code, status, message),
+	}
+}
+
+// withInternal sets what's logged about he without dropping its code.
+func withInternal(he *echo.HTTPError, internal error) *echo.HTTPError {
+	if he.Internal != nil {
+		internal = fmt.Errorf("%w: %w", he.Internal, internal)
+	}
+	return he.SetInternal(internal)
+}
+
+// errorCode returns the code of the public error err carries, nil if there's
+// none.
+func errorCode(err error) *string {
+	var e *iberrors.Error
+	if !errors.As(err, &e) || e.Code == "" {
+		return nil
+	}
+	code := string(e.Code)
+	return &code
+}
@@ -0,0 +1,44 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/common"
+	iberrors "github.com/osbuild/image-builder/pkg/errors"
+)
+
+func TestHTTPErrorHandlerCodes(t *testing.T) {
+	s := &Server{}
+	handle := func(err error) (int, HTTPErrorList) {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		s.HTTPErrorHandler(err, e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), rec))
+		var errs HTTPErrorList
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &errs))
+		return rec.Code, errs
+	}
+
+	status, errs := handle(apiError(iberrors.CodeQuotaExceeded, http.StatusForbidden, "Quota exceeded for user"))
+	require.Equal(t, http.StatusForbidden, status)
+	require.Equal(t, []HTTPError{{Code: common.ToPtr("quota-exceeded"), Title: "403", Detail: "Quota exceeded for user"}}, errs.Errors)
+
+	// the code survives what's added for the log
+	status, errs = handle(withInternal(apiError(iberrors.CodeUpstreamUnavailable, http.StatusInternalServerError, "Failed posting compose request to osbuild-composer"), fmt.Errorf("connection refused")))
+	require.Equal(t, http.StatusInternalServerError, status)
+	require.Equal(t, common.ToPtr("upstream-unavailable"), errs.Errors[0].Code)
+
+	status, errs = handle(iberrors.New(iberrors.CodeInvalidCustomization, http.StatusBadRequest, "Unknown package groups: @none"))
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, []HTTPError{{Code: common.ToPtr("invalid-customization"), Title: "400", Detail: "Unknown package groups: @none"}}, errs.Errors)
+
+	status, errs = handle(echo.NewHTTPError(http.StatusNotFound, "Compose not found"))
+	require.Equal(t, http.StatusNotFound, status)
+	require.Nil(t, errs.Errors[0].Code)
+}
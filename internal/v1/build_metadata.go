@@ -0,0 +1,182 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+)
+
+// buildIdLabel traces a compose back from the build ID stamped into its
+// image, the compose ID is only known once composer accepted the request.
+const buildIdLabel = "image-builder.build-id"
+
+const (
+	buildMetadataPath = "/etc/image-builder.json"
+	// the variables are added to /etc/os-release on the first boot by
+	// osReleaseUnit, the file belongs to the release package and can't be
+	// replaced at build time
+	osReleaseVarsPath = "/etc/image-builder.os-release"
+	osReleaseUnit     = "image-builder-os-release.service"
+	motdPath          = "/etc/motd.d/image-builder"
+)
+
+// the unit replaces the os-release symlink with a copy carrying the build,
+// the variables of the copy are there on the following boots
+var osReleaseUnitData = fmt.Sprintf(`[Unit]
+Description=Add the image-builder build to os-release
+ConditionPathExists=%[1]s
+
+[Service]
+Type=oneshot
+ExecStart=/bin/sh -c 'grep -qs "^IMAGE_BUILDER_BUILD_ID=" /etc/os-release || { cat /etc/os-release %[1]s > /etc/os-release.image-builder && mv -f /etc/os-release.image-builder /etc/os-release; }'
+
+[Install]
+WantedBy=multi-user.target
+`, osReleaseVarsPath)
+
+// buildStamp is what /etc/image-builder.json records about the build.
+type buildStamp struct {
+	BuildId      uuid.UUID          `json:"build_id"`
+	BuildDate    time.Time          `json:"build_date"`
+	Distribution string             `json:"distribution"`
+	ImageType    string             `json:"image_type"`
+	Architecture string             `json:"architecture"`
+	ImageName    *string            `json:"image_name,omitempty"`
+	Blueprint    *buildStampVersion `json:"blueprint,omitempty"`
+	Labels       map[string]string  `json:"labels,omitempty"`
+}
+
+type buildStampVersion struct {
+	Id      uuid.UUID `json:"id"`
+	Name    string    `json:"name"`
+	Version int       `json:"version"`
+}
+
+// newBuildStamp describes the build of the compose request, the blueprint
+// is looked up when the compose builds a version of one.
+func (h *Handlers) newBuildStamp(ctx echo.Context, composeRequest ComposeRequest, blueprintVersionId *uuid.UUID) (*buildStamp, error) {
+	stamp := &buildStamp{
+		BuildId:      uuid.New(),
+		BuildDate:    time.Now().UTC().Truncate(time.Second),
+		Distribution: string(composeRequest.Distribution),
+		ImageType:    string(composeRequest.ImageRequests[0].ImageType),
+		Architecture: string(composeRequest.ImageRequests[0].Architecture),
+		ImageName:    composeRequest.ImageName,
+	}
+	if composeRequest.Labels != nil {
+		stamp.Labels = *composeRequest.Labels
+	}
+	if blueprintVersionId != nil {
+		userID, err := h.server.getIdentity(ctx)
+		if err != nil {
+			return nil, err
+		}
+		blueprint, err := h.server.db.GetBlueprintVersion(ctx.Request().Context(), *blueprintVersionId, userID.OrgID())
+		if err != nil {
+			return nil, err
+		}
+		stamp.Blueprint = &buildStampVersion{
+			Id:      blueprint.Id,
+			Name:    blueprint.Name,
+			Version: blueprint.Version,
+		}
+	}
+	return stamp, nil
+}
+
+var osReleaseIdInvalid = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// osReleaseVars names the image after the blueprint, or the image name
+// without one, IMAGE_ID only allows lower case characters, digits, dots,
+// dashes and underscores.
+func (s *buildStamp) osReleaseVars() string {
+	var name string
+	var version *int
+	if s.Blueprint != nil {
+		name = s.Blueprint.Name
+		version = &s.Blueprint.Version
+	} else if s.ImageName != nil {
+		name = *s.ImageName
+	}
+
+	var vars strings.Builder
+	if id := strings.Trim(osReleaseIdInvalid.ReplaceAllString(strings.ToLower(name), "-"), "-"); id != "" {
+		fmt.Fprintf(&vars, "IMAGE_ID=%s\n", id)
+	}
+	if version != nil {
+		fmt.Fprintf(&vars, "IMAGE_VERSION=%d\n", *version)
+	}
+	fmt.Fprintf(&vars, "IMAGE_BUILDER_BUILD_ID=%s\n", s.BuildId)
+	fmt.Fprintf(&vars, "IMAGE_BUILDER_BUILD_DATE=%s\n", s.BuildDate.Format(time.RFC3339))
+	return vars.String()
+}
+
+func (s *buildStamp) motd() string {
+	image := s.ImageType
+	if s.Blueprint != nil {
+		image = fmt.Sprintf("%s version %d", s.Blueprint.Name, s.Blueprint.Version)
+	} else if s.ImageName != nil {
+		image = *s.ImageName
+	}
+	return fmt.Sprintf("%s, built by image-builder on %s (build %s)\n", image, s.BuildDate.Format(time.DateOnly), s.BuildId)
+}
+
+// stampBuildMetadata adds the files describing the build to the
+// customizations, and enables the unit adding it to os-release.
+func stampBuildMetadata(customizations *composer.Customizations, stamp *buildStamp, motd bool) (*composer.Customizations, error) {
+	metadata, err := json.MarshalIndent(stamp, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if customizations == nil {
+		customizations = &composer.Customizations{}
+	}
+
+	files := []composer.File{
+		{
+			Path: buildMetadataPath,
+			Data: common.ToPtr(string(metadata) + "\n"),
+			Mode: common.ToPtr("0644"),
+		},
+		{
+			Path: osReleaseVarsPath,
+			Data: common.ToPtr(stamp.osReleaseVars()),
+			Mode: common.ToPtr("0644"),
+		},
+		{
+			Path: "/etc/systemd/system/" + osReleaseUnit,
+			Data: common.ToPtr(osReleaseUnitData),
+			Mode: common.ToPtr("0644"),
+		},
+	}
+	if motd {
+		files = append(files, composer.File{
+			Path:          motdPath,
+			Data:          common.ToPtr(stamp.motd()),
+			Mode:          common.ToPtr("0644"),
+			EnsureParents: common.ToPtr(true),
+		})
+	}
+	if customizations.Files != nil {
+		files = append(*customizations.Files, files...)
+	}
+	customizations.Files = &files
+
+	if customizations.Services == nil {
+		customizations.Services = &composer.Services{}
+	}
+	enabled := []string{osReleaseUnit}
+	if customizations.Services.Enabled != nil {
+		enabled = append(*customizations.Services.Enabled, enabled...)
+	}
+	customizations.Services.Enabled = &enabled
+	return customizations, nil
+}
@@ -0,0 +1,84 @@
+package v1
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+)
+
+func TestBuildStampOsReleaseVars(t *testing.T) {
+	buildId := uuid.MustParse("b1e4bd37-8bbc-4ce4-a5c5-bd8e35bd2bd3")
+	stamp := &buildStamp{
+		BuildId:   buildId,
+		BuildDate: time.Date(2026, 10, 15, 8, 30, 0, 0, time.UTC),
+		ImageType: "guest-image",
+	}
+	require.Equal(t, `IMAGE_BUILDER_BUILD_ID=b1e4bd37-8bbc-4ce4-a5c5-bd8e35bd2bd3
+IMAGE_BUILDER_BUILD_DATE=2026-10-15T08:30:00Z
+`, stamp.osReleaseVars())
+	require.Equal(t, "guest-image, built by image-builder on 2026-10-15 (build b1e4bd37-8bbc-4ce4-a5c5-bd8e35bd2bd3)\n", stamp.motd())
+
+	stamp.ImageName = common.ToPtr("My Web Server!")
+	require.Contains(t, stamp.osReleaseVars(), "IMAGE_ID=my-web-server\n")
+	require.NotContains(t, stamp.osReleaseVars(), "IMAGE_VERSION")
+
+	// the blueprint names the image
+	stamp.Blueprint = &buildStampVersion{
+		Id:      uuid.New(),
+		Name:    "edge_Gateway",
+		Version: 3,
+	}
+	require.Equal(t, `IMAGE_ID=edge_gateway
+IMAGE_VERSION=3
+IMAGE_BUILDER_BUILD_ID=b1e4bd37-8bbc-4ce4-a5c5-bd8e35bd2bd3
+IMAGE_BUILDER_BUILD_DATE=2026-10-15T08:30:00Z
+`, stamp.osReleaseVars())
+	require.Equal(t, "edge_Gateway version 3, built by image-builder on 2026-10-15 (build b1e4bd37-8bbc-4ce4-a5c5-bd8e35bd2bd3)\n", stamp.motd())
+}
+
+func TestStampBuildMetadata(t *testing.T) {
+	stamp := &buildStamp{
+		BuildId:      uuid.New(),
+		BuildDate:    time.Now().UTC().Truncate(time.Second),
+		Distribution: "rhel-9",
+		ImageType:    "guest-image",
+		Architecture: "x86_64",
+		Labels:       map[string]string{"team": "platform"},
+	}
+
+	customizations, err := stampBuildMetadata(nil, stamp, false)
+	require.NoError(t, err)
+	require.Len(t, *customizations.Files, 3)
+	require.Equal(t, []string{osReleaseUnit}, *customizations.Services.Enabled)
+
+	files := map[string]string{}
+	for _, f := range *customizations.Files {
+		files[f.Path] = *f.Data
+	}
+	var recorded buildStamp
+	require.NoError(t, json.Unmarshal([]byte(files[buildMetadataPath]), &recorded))
+	require.Equal(t, *stamp, recorded)
+	require.Equal(t, stamp.osReleaseVars(), files[osReleaseVarsPath])
+	require.Contains(t, files["/etc/systemd/system/"+osReleaseUnit], osReleaseVarsPath)
+	require.NotContains(t, files, motdPath)
+
+	// the customizations of the request are kept
+	customizations, err = stampBuildMetadata(&composer.Customizations{
+		Files:    &[]composer.File{{Path: "/etc/custom"}},
+		Services: &composer.Services{Enabled: &[]string{"sshd"}},
+		Hostname: common.ToPtr("gateway"),
+	}, stamp, true)
+	require.NoError(t, err)
+	require.Len(t, *customizations.Files, 5)
+	require.Equal(t, "/etc/custom", (*customizations.Files)[0].Path)
+	require.Equal(t, motdPath, (*customizations.Files)[4].Path)
+	require.Equal(t, stamp.motd(), *(*customizations.Files)[4].Data)
+	require.Equal(t, []string{"sshd", osReleaseUnit}, *customizations.Services.Enabled)
+	require.Equal(t, "gateway", *customizations.Hostname)
+}
@@ -0,0 +1,58 @@
+package v1
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/storage"
+)
+
+// Kinds of compose artifacts which are kept in object storage.
+const (
+	blobKindMetadata = "metadata"
+	blobKindLogs     = "logs"
+)
+
+// loadComposeBlob returns nil if no storage is configured or nothing was
+// stored for the compose yet.
+func (h *Handlers) loadComposeBlob(ctx echo.Context, composeId uuid.UUID, kind string) ([]byte, error) {
+	if h.server.storage == nil {
+		return nil, nil
+	}
+
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	blob, err := h.server.db.GetComposeBlob(ctx.Request().Context(), composeId, userID.OrgID(), kind)
+	if errors.Is(err, db.ComposeBlobNotFoundError) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := h.server.storage.Get(ctx.Request().Context(), blob.StorageKey)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		ctx.Logger().Warnf("Blob %s of compose %v is referenced but missing from storage", kind, composeId)
+		return nil, nil
+	}
+	return data, err
+}
+
+func (h *Handlers) storeComposeBlob(ctx echo.Context, composeId uuid.UUID, kind string, data []byte) error {
+	if h.server.storage == nil {
+		return nil
+	}
+
+	key := storage.ComposeKey(composeId, kind)
+	err := h.server.storage.Put(ctx.Request().Context(), key, data)
+	if err != nil {
+		return err
+	}
+	return h.server.db.InsertComposeBlob(ctx.Request().Context(), composeId, kind, key, int64(len(data)))
+}
@@ -0,0 +1,103 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/distribution"
+)
+
+// composeListingWindow is how far back composes are listed.
+const composeListingWindow = time.Hour * 24 * 14
+
+// composeFilter translates the filters of the compose listing for the
+// database, along with the query parameters which repeat them in the links to
+// other pages.
+func composeFilter(params GetComposesParams) (db.ComposeFilter, url.Values, error) {
+	query := url.Values{}
+	filter := db.ComposeFilter{
+		Since:            composeListingWindow,
+		IgnoreImageTypes: convertIgnoreImageTypeToSlice(params.IgnoreImageTypes),
+	}
+	for _, it := range filter.IgnoreImageTypes {
+		query.Add("ignoreImageTypes", it)
+	}
+
+	if params.ImageType != nil {
+		for _, it := range *params.ImageType {
+			query.Add("image_type", string(it))
+			filter.ImageTypes = append(filter.ImageTypes, imageTypeNames(string(it))...)
+		}
+	}
+	if params.Distribution != nil {
+		for _, d := range *params.Distribution {
+			query.Add("distribution", string(d))
+			filter.Distributions = append(filter.Distributions, string(d))
+		}
+	}
+	if params.Status != nil {
+		for _, s := range *params.Status {
+			switch s {
+			case GetComposesParamsStatusSuccess, GetComposesParamsStatusFailure:
+				filter.Statuses = append(filter.Statuses, string(s))
+			case GetComposesParamsStatusUnfinished:
+				filter.Statuses = append(filter.Statuses, db.ComposeStatusUnfinished)
+			default:
+				return db.ComposeFilter{}, nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unknown compose status %s", s))
+			}
+			query.Add("status", string(s))
+		}
+	}
+
+	if params.CreatedAfter != nil {
+		filter.CreatedAfter = params.CreatedAfter
+		query.Set("created_after", params.CreatedAfter.Format(time.RFC3339Nano))
+	}
+	if params.CreatedBefore != nil {
+		filter.CreatedBefore = params.CreatedBefore
+		query.Set("created_before", params.CreatedBefore.Format(time.RFC3339Nano))
+	}
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && !filter.CreatedAfter.Before(*filter.CreatedBefore) {
+		return db.ComposeFilter{}, nil, echo.NewHTTPError(http.StatusBadRequest, "created_after needs to be before created_before")
+	}
+
+	if params.LabelSelector != nil {
+		labels, err := parseLabelSelector(*params.LabelSelector)
+		if err != nil {
+			return db.ComposeFilter{}, nil, err
+		}
+		filter.Labels = labels
+		query.Set("label_selector", *params.LabelSelector)
+	}
+	if params.Creator != nil {
+		filter.Creator = params.Creator
+		query.Set("creator", *params.Creator)
+	}
+
+	if params.Sort != nil {
+		switch *params.Sort {
+		case GetComposesParamsSortCreatedAt:
+			filter.Ascending = true
+		case GetComposesParamsSortMinusCreatedAt:
+		default:
+			return db.ComposeFilter{}, nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unknown sort order %s", *params.Sort))
+		}
+		query.Set("sort", string(*params.Sort))
+	}
+	return filter, query, nil
+}
+
+// imageTypeNames lists all names composes of the image type could have been
+// requested with.
+func imageTypeNames(name string) []string {
+	it, ok := distribution.LookupImageType(name)
+	if !ok {
+		return []string{name}
+	}
+	return append([]string{it.Name}, it.Aliases...)
+}
@@ -0,0 +1,61 @@
+package v1
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+func TestComposeFilter(t *testing.T) {
+	filter, query, err := composeFilter(GetComposesParams{})
+	require.NoError(t, err)
+	require.Equal(t, db.ComposeFilter{Since: composeListingWindow}, filter)
+	require.Empty(t, query)
+
+	after := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	before := after.Add(24 * time.Hour)
+	filter, query, err = composeFilter(GetComposesParams{
+		IgnoreImageTypes: &[]ImageTypes{ImageTypesAws},
+		ImageType:        &[]ImageTypes{ImageTypesQcow2},
+		Distribution:     &[]Distributions{Rhel9},
+		Status:           &[]GetComposesParamsStatus{GetComposesParamsStatusFailure, GetComposesParamsStatusUnfinished},
+		CreatedAfter:     &after,
+		CreatedBefore:    &before,
+		LabelSelector:    common.ToPtr("team=platform, env=prod"),
+		Creator:          common.ToPtr("user@example.com"),
+		Sort:             common.ToPtr(GetComposesParamsSortCreatedAt),
+	})
+	require.NoError(t, err)
+	require.Equal(t, db.ComposeFilter{
+		Since:            composeListingWindow,
+		IgnoreImageTypes: []string{"aws"},
+		ImageTypes:       []string{"guest-image", "qcow2", "virtualization"},
+		Distributions:    []string{"rhel-9"},
+		Statuses:         []string{"failure", db.ComposeStatusUnfinished},
+		CreatedAfter:     &after,
+		CreatedBefore:    &before,
+		Labels:           map[string]string{"team": "platform", "env": "prod"},
+		Creator:          common.ToPtr("user@example.com"),
+		Ascending:        true,
+	}, filter)
+	require.Equal(t, "created_after=2024-05-01T00%3A00%3A00Z&created_before=2024-05-02T00%3A00%3A00Z&creator=user%40example.com&distribution=rhel-9&ignoreImageTypes=aws&image_type=qcow2&label_selector=team%3Dplatform%2C+env%3Dprod&sort=created_at&status=failure&status=unfinished", query.Encode())
+
+	for _, params := range []GetComposesParams{
+		{Status: &[]GetComposesParamsStatus{"building"}},
+		{Sort: common.ToPtr(GetComposesParamsSort("name"))},
+		{CreatedAfter: &before, CreatedBefore: &after},
+		{LabelSelector: common.ToPtr("team")},
+		{LabelSelector: common.ToPtr("team=platform,team=infra")},
+	} {
+		_, _, err = composeFilter(params)
+		var httpError *echo.HTTPError
+		require.ErrorAs(t, err, &httpError)
+		require.Equal(t, http.StatusBadRequest, httpError.Code)
+	}
+}
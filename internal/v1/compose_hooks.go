@@ -0,0 +1,32 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// composeHookRuns returns how the post-processing hooks fared on the compose,
+// nil when none ran.
+func (h *Handlers) composeHookRuns(ctx echo.Context, composeId uuid.UUID) (*[]ComposeHookRun, error) {
+	entries, err := h.server.db.GetComposeHookRuns(ctx.Request().Context(), composeId)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	runs := make([]ComposeHookRun, 0, len(entries))
+	for _, e := range entries {
+		runs = append(runs, ComposeHookRun{
+			Hook:      e.Hook,
+			Status:    ComposeHookRunStatus(e.Status),
+			Attempts:  e.Attempts,
+			Error:     e.Error,
+			UpdatedAt: e.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	return &runs, nil
+}
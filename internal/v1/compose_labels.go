@@ -0,0 +1,49 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// composeLabelRegex matches the keys of compose labels and the values which
+// aren't empty, neither can contain the separators of label selectors.
+var composeLabelRegex = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?$`)
+
+func validComposeLabel(key, value string) bool {
+	return composeLabelRegex.MatchString(key) && (value == "" || composeLabelRegex.MatchString(value))
+}
+
+// validateComposeLabels checks the label keys, which the spec can't describe,
+// and the values.
+func validateComposeLabels(labels *ComposeLabels) error {
+	if labels == nil {
+		return nil
+	}
+	for key, value := range *labels {
+		if !validComposeLabel(key, value) {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid compose label %s=%s", key, value))
+		}
+	}
+	return nil
+}
+
+// parseLabelSelector parses comma separated key=value pairs, the composes
+// listed need to carry all of them.
+func parseLabelSelector(selector string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, pair := range strings.Split(selector, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || !validComposeLabel(key, value) {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid label selector %q, expected comma separated key=value pairs", pair))
+		}
+		if v, ok := labels[key]; ok && v != value {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Label %s is selected with different values", key))
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
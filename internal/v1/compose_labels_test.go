@@ -0,0 +1,39 @@
+package v1
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateComposeLabels(t *testing.T) {
+	require.NoError(t, validateComposeLabels(nil))
+	require.NoError(t, validateComposeLabels(&ComposeLabels{"team": "platform", "app.kubernetes_io-name": "web", "empty": ""}))
+
+	for _, labels := range []ComposeLabels{
+		{"": "value"},
+		{"-team": "platform"},
+		{"team=x": "platform"},
+		{"team": "platform,env=prod"},
+		{"team": "platform-"},
+		{strings.Repeat("a", 64): "value"},
+	} {
+		require.Error(t, validateComposeLabels(&labels), labels)
+	}
+}
+
+func TestParseLabelSelector(t *testing.T) {
+	labels, err := parseLabelSelector("team=platform,env=prod")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"team": "platform", "env": "prod"}, labels)
+
+	labels, err = parseLabelSelector("team=platform, team=platform,empty=")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"team": "platform", "empty": ""}, labels)
+
+	for _, selector := range []string{"", "team", "team=platform,", "=platform", "team=a=b", "team=a,team=b"} {
+		_, err = parseLabelSelector(selector)
+		require.Error(t, err, selector)
+	}
+}
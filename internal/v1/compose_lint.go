@@ -0,0 +1,136 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/labstack/echo/v4"
+)
+
+// largePackageSetSize is the number of packages from which on the build is
+// noticeably slower.
+const largePackageSetSize = 200
+
+// cloudImageTypes are the image types only reachable over ssh once
+// deployed.
+var cloudImageTypes = []ImageTypes{
+	ImageTypesAws,
+	ImageTypesAzure,
+	ImageTypesGcp,
+	ImageTypesOci,
+}
+
+// lintComposeRequest points out what is allowed in a compose request but
+// likely not what the user intended.
+func lintComposeRequest(cr *ComposeRequest) []string {
+	var warnings []string
+	cust := cr.Customizations
+
+	if cust != nil && cust.Packages != nil {
+		packages := *cust.Packages
+		if len(packages) > largePackageSetSize {
+			warnings = append(warnings, fmt.Sprintf("Installing %d packages will slow down the build", len(packages)))
+		}
+		seen := map[string]bool{}
+		var duplicates []string
+		for _, p := range packages {
+			if seen[p] && !slices.Contains(duplicates, p) {
+				duplicates = append(duplicates, p)
+			}
+			seen[p] = true
+		}
+		for _, p := range duplicates {
+			warnings = append(warnings, fmt.Sprintf("Package %s is listed more than once", p))
+		}
+	}
+
+	if len(cr.ImageRequests) > 0 && slices.Contains(cloudImageTypes, canonicalImageType(cr.ImageRequests[0].ImageType)) {
+		if cust == nil || cust.Users == nil || len(*cust.Users) == 0 {
+			warnings = append(warnings, "No ssh key is configured, instances of the image can only be logged into with the keys of the cloud provider")
+		}
+	}
+	return warnings
+}
+
+// clientErrorMessages returns what is wrong with a request according to a
+// client error, other errors are returned to fail the request.
+func clientErrorMessages(err error) ([]string, error) {
+	if err == nil {
+		return nil, nil
+	}
+	var httpError *echo.HTTPError
+	if !errors.As(err, &httpError) || httpError.Code >= http.StatusInternalServerError {
+		return nil, err
+	}
+	if violations, ok := httpError.Message.(policyViolations); ok {
+		return violations, nil
+	}
+	return []string{fmt.Sprint(httpError.Message)}, nil
+}
+
+// LintCompose runs the checks of a compose without composing, errors are the
+// ones the compose would be rejected with.
+func (h *Handlers) LintCompose(ctx echo.Context) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	var composeRequest ComposeRequest
+	err = ctx.Bind(&composeRequest)
+	if err != nil {
+		return err
+	}
+
+	result := ComposeLintResponse{
+		Errors:   []string{},
+		Warnings: []string{},
+	}
+	// client errors are results of the linter, others fail the request
+	check := func(err error) error {
+		messages, err := clientErrorMessages(err)
+		result.Errors = append(result.Errors, messages...)
+		return err
+	}
+
+	if len(composeRequest.ImageRequests) != 1 {
+		result.Errors = append(result.Errors, "Exactly one image request should be included")
+		return ctx.JSON(http.StatusOK, result)
+	}
+	if string(composeRequest.ImageRequests[0].UploadRequest.Type) == "" {
+		result.Errors = append(result.Errors, "Exactly one upload request should be included")
+	}
+
+	d, err := h.server.getDistro(ctx, composeRequest.Distribution)
+	if err == nil {
+		arch, err := d.Architecture(string(composeRequest.ImageRequests[0].Architecture))
+		if err == nil && arch != nil {
+			if err := check(validatePackageGroups(arch, composeRequest.Customizations)); err != nil {
+				return err
+			}
+			warnings, _, _ := deprecations(d, arch, composeRequest.ImageRequests[0].ImageType)
+			result.Warnings = append(result.Warnings, warnings...)
+		} else if err := check(err); err != nil {
+			return err
+		}
+	} else if err := check(err); err != nil {
+		return err
+	}
+
+	if err := check(validateComposeRequest(&composeRequest)); err != nil {
+		return err
+	}
+	secrets := scanComposeRequest(&composeRequest)
+	_, err = h.checkOrgPolicy(ctx, userID.OrgID(), &composeRequest, secrets)
+	if err := check(err); err != nil {
+		return err
+	}
+
+	result.Warnings = append(result.Warnings, secrets...)
+	if w := h.server.emulationWarning(composeRequest.ImageRequests[0].Architecture); w != "" {
+		result.Warnings = append(result.Warnings, w)
+	}
+	result.Warnings = append(result.Warnings, lintComposeRequest(&composeRequest)...)
+	return ctx.JSON(http.StatusOK, result)
+}
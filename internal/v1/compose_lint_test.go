@@ -0,0 +1,95 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestLintComposeRequest(t *testing.T) {
+	cr := ComposeRequest{
+		Customizations: &Customizations{
+			Packages: &[]string{"vim", "tmux"},
+			Users:    &[]User{{Name: "admin", SshKey: "ssh-ed25519 AAAA"}},
+		},
+		ImageRequests: []ImageRequest{
+			{
+				ImageType: ImageTypesAmi,
+			},
+		},
+	}
+	require.Empty(t, lintComposeRequest(&cr))
+
+	packages := []string{"vim", "vim"}
+	for i := 0; i < largePackageSetSize; i++ {
+		packages = append(packages, fmt.Sprintf("package-%d", i))
+	}
+	cr.Customizations.Packages = &packages
+	cr.Customizations.Users = nil
+	require.Equal(t, []string{
+		"Installing 202 packages will slow down the build",
+		"Package vim is listed more than once",
+		"No ssh key is configured, instances of the image can only be logged into with the keys of the cloud provider",
+	}, lintComposeRequest(&cr))
+
+	// guests are logged into on their console
+	cr.Customizations = nil
+	cr.ImageRequests[0].ImageType = ImageTypesGuestImage
+	require.Empty(t, lintComposeRequest(&cr))
+}
+
+func TestLintCompose(t *testing.T) {
+	srv, tokenSrv := startServer(t, &testServerClientsConf{}, &ServerConfig{
+		DistributionsDir: "../../distributions",
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	var uo UploadRequest_Options
+	require.NoError(t, uo.FromAWSUploadRequestOptions(AWSUploadRequestOptions{
+		ShareWithAccounts: &[]string{"test-account"},
+	}))
+	payload := ComposeRequest{
+		Customizations: &Customizations{
+			Packages: &[]string{"vim", "vim"},
+		},
+		Distribution: "rhel-9",
+		ImageRequests: []ImageRequest{
+			{
+				Architecture: "x86_64",
+				ImageType:    ImageTypesAws,
+				UploadRequest: UploadRequest{
+					Type:    UploadTypesAws,
+					Options: uo,
+				},
+			},
+		},
+	}
+	respStatusCode, body := tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/compose/lint", payload)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var result ComposeLintResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Empty(t, result.Errors)
+	require.Equal(t, []string{
+		"Package vim is listed more than once",
+		"No ssh key is configured, instances of the image can only be logged into with the keys of the cloud provider",
+	}, result.Warnings)
+
+	// errors don't hide the warnings
+	payload.ImageRequests[0].Size = common.ToPtr(uint64(FSMaxSize + 1))
+	respStatusCode, body = tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/compose/lint", payload)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Equal(t, []string{fmt.Sprintf("Total AWS image size cannot exceed %d bytes", FSMaxSize)}, result.Errors)
+	require.Len(t, result.Warnings, 2)
+}
@@ -0,0 +1,97 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/osbuild/image-builder/internal/encryption"
+	"github.com/osbuild/image-builder/internal/redact"
+)
+
+// The customizations can contain usernames, activation keys and embedded
+// files, they are stored encrypted under this key instead. The rest of the
+// request stays readable, the database filters on the image requests.
+const encryptedCustomizationsKey = "encrypted_customizations"
+
+// sealComposeRequest marshals the request for storage in the database. With
+// redaction enabled, or requested for this compose, the secrets are replaced
+// by their digests beforehand and can't be recovered anymore.
+func (s *Server) sealComposeRequest(cr ComposeRequest, redactSecrets bool) (json.RawMessage, error) {
+	raw, err := json.Marshal(cr)
+	if err != nil {
+		return nil, err
+	}
+	if s.redactRequests || redactSecrets {
+		raw, err = redact.JSON(raw, redact.ComposeRequest)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s.encryptCustomizations(raw)
+}
+
+// encryptCustomizations seals the customizations of the marshalled request
+// with the keyring, if there is one.
+func (s *Server) encryptCustomizations(raw json.RawMessage) (json.RawMessage, error) {
+	if s.keyring == nil {
+		return raw, nil
+	}
+
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(raw, &fields)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := fields["customizations"]; !ok {
+		return raw, nil
+	}
+	envelope, err := s.keyring.Seal(fields["customizations"])
+	if err != nil {
+		return nil, err
+	}
+	fields[encryptedCustomizationsKey], err = json.Marshal(envelope)
+	if err != nil {
+		return nil, err
+	}
+	delete(fields, "customizations")
+	return json.Marshal(fields)
+}
+
+// openComposeRequest reverses sealComposeRequest, requests stored before
+// encryption got enabled are read as they are.
+func (s *Server) openComposeRequest(raw json.RawMessage, cr *ComposeRequest) error {
+	raw, err := s.decryptCustomizations(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, cr)
+}
+
+// decryptCustomizations reverses encryptCustomizations.
+func (s *Server) decryptCustomizations(raw json.RawMessage) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(raw, &fields)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, ok := fields[encryptedCustomizationsKey]
+	if !ok {
+		return raw, nil
+	}
+	if s.keyring == nil {
+		return nil, fmt.Errorf("compose request is encrypted but no keyring is configured")
+	}
+
+	var envelope encryption.Envelope
+	err = json.Unmarshal(sealed, &envelope)
+	if err != nil {
+		return nil, err
+	}
+	fields["customizations"], err = s.keyring.Open(&envelope)
+	if err != nil {
+		return nil, err
+	}
+	delete(fields, encryptedCustomizationsKey)
+	return json.Marshal(fields)
+}
@@ -0,0 +1,136 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+type reservationDB struct {
+	db.DB
+	reserveErr error
+	insertErr  error
+	laneFull   bool
+	released   atomic.Int32
+}
+
+func (r *reservationDB) ReserveCompose(ctx context.Context, reservation *db.ComposeReservation) error {
+	if r.reserveErr != nil {
+		return r.reserveErr
+	}
+	if r.laneFull && reservation.LaneLimit != nil {
+		reservation.LaneLimit = common.ToPtr(0)
+	}
+	return r.DB.ReserveCompose(ctx, reservation)
+}
+
+func (r *reservationDB) InsertReservedCompose(ctx context.Context, reservation *db.ComposeReservation, compose db.ReservedCompose) error {
+	if r.insertErr != nil {
+		return r.insertErr
+	}
+	return r.DB.InsertReservedCompose(ctx, reservation, compose)
+}
+
+func (r *reservationDB) ReleaseComposeReservation(ctx context.Context, id uuid.UUID) error {
+	r.released.Add(1)
+	return r.DB.ReleaseComposeReservation(ctx, id)
+}
+
+func TestComposeReservation(t *testing.T) {
+	var composes, cancels atomic.Int32
+	composerURL := mockService(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/cancel") {
+			cancels.Add(1)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		composes.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		require.NoError(t, json.NewEncoder(w).Encode(composer.ComposeId{Id: uuid.New()}))
+	})
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	mock := &reservationDB{DB: dbase}
+	startTestServer(t, &testServerClientsConf{ComposerURL: composerURL}, &ServerConfig{DBase: mock})
+
+	payload := map[string]interface{}{
+		"distribution": "centos-9",
+		"image_requests": []map[string]interface{}{
+			{
+				"architecture":   "x86_64",
+				"image_type":     "guest-image",
+				"upload_request": map[string]interface{}{"type": "aws.s3", "options": map[string]interface{}{}},
+			},
+		},
+	}
+
+	// composes without a slot aren't built
+	mock.reserveErr = db.QuotaExceededError
+	respStatusCode, body := tutils.PostResponseBody(t, apiURL("/compose"), payload)
+	require.Equal(t, http.StatusForbidden, respStatusCode, body)
+	require.Equal(t, int32(0), composes.Load())
+
+	// builds which can't be recorded are cancelled, their slot is freed
+	mock.reserveErr = nil
+	mock.insertErr = errors.New("database gone")
+	respStatusCode, body = tutils.PostResponseBody(t, apiURL("/compose"), payload)
+	require.Equal(t, http.StatusInternalServerError, respStatusCode, body)
+	require.Equal(t, int32(1), composes.Load())
+	require.Equal(t, int32(1), cancels.Load())
+	require.Equal(t, int32(1), mock.released.Load())
+
+	mock.insertErr = nil
+	respStatusCode, body = tutils.PostResponseBody(t, apiURL("/compose"), payload)
+	require.Equal(t, http.StatusCreated, respStatusCode, body)
+	require.Equal(t, int32(1), cancels.Load())
+}
+
+func TestComposeReservationPriorityLane(t *testing.T) {
+	var tags composer.JobTags
+	composerURL := mockService(t, func(w http.ResponseWriter, r *http.Request) {
+		var request composer.ComposeRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		tags = *request.Tags
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		require.NoError(t, json.NewEncoder(w).Encode(composer.ComposeId{Id: uuid.New()}))
+	})
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	mock := &reservationDB{DB: dbase}
+	startTestServer(t, &testServerClientsConf{ComposerURL: composerURL}, &ServerConfig{DBase: mock, PriorityLaneLimit: 5})
+
+	payload := map[string]interface{}{
+		"distribution": "centos-9",
+		"security":     true,
+		"image_requests": []map[string]interface{}{
+			{
+				"architecture":   "x86_64",
+				"image_type":     "guest-image",
+				"upload_request": map[string]interface{}{"type": "aws.s3", "options": map[string]interface{}{}},
+			},
+		},
+	}
+	respStatusCode, body := tutils.PostResponseBody(t, apiURL("/compose"), payload)
+	require.Equal(t, http.StatusCreated, respStatusCode, body)
+	require.Equal(t, "security", tags["priority"])
+
+	// the lane filled up after it was checked, the job isn't prioritized
+	mock.laneFull = true
+	respStatusCode, body = tutils.PostResponseBody(t, apiURL("/compose"), payload)
+	require.Equal(t, http.StatusCreated, respStatusCode, body)
+	require.NotContains(t, tags, "priority")
+}
@@ -0,0 +1,125 @@
+package v1
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// composes of a template are labelled with it, so they can be listed by the
+// label_selector of the composes
+const (
+	templateLabel        = "image-builder.template"
+	templateVersionLabel = "image-builder.template-version"
+)
+
+// mergeComposeTemplate applies the overrides to the compose request of the
+// template version. Fields of the overrides replace the ones of the template,
+// customizations field by field, packages and labels are added to the ones
+// of the template instead.
+func mergeComposeTemplate(template ComposeRequest, name string, version int, overrides ComposeTemplateOverrides) (ComposeRequest, error) {
+	// the template is copied, nothing it points to is changed
+	var merged ComposeRequest
+	data, err := json.Marshal(template)
+	if err != nil {
+		return ComposeRequest{}, err
+	}
+	err = json.Unmarshal(data, &merged)
+	if err != nil {
+		return ComposeRequest{}, err
+	}
+
+	if overrides.Distribution != nil {
+		merged.Distribution = *overrides.Distribution
+	}
+	if overrides.ImageName != nil {
+		merged.ImageName = overrides.ImageName
+	}
+	if overrides.ImageDescription != nil {
+		merged.ImageDescription = overrides.ImageDescription
+	}
+	if overrides.ImageRequests != nil {
+		merged.ImageRequests = *overrides.ImageRequests
+	}
+
+	var packages []string
+	if merged.Customizations != nil && merged.Customizations.Packages != nil {
+		packages = append(packages, *merged.Customizations.Packages...)
+	}
+	if overrides.Customizations != nil {
+		if overrides.Customizations.Packages != nil {
+			packages = append(packages, *overrides.Customizations.Packages...)
+		}
+		merged.Customizations, err = mergeCustomizations(merged.Customizations, overrides.Customizations)
+		if err != nil {
+			return ComposeRequest{}, err
+		}
+	}
+	if overrides.Packages != nil {
+		packages = append(packages, *overrides.Packages...)
+	}
+	if len(packages) > 0 {
+		if merged.Customizations == nil {
+			merged.Customizations = &Customizations{}
+		}
+		merged.Customizations.Packages = &[]string{}
+		seen := map[string]bool{}
+		for _, pkg := range packages {
+			if !seen[pkg] {
+				seen[pkg] = true
+				*merged.Customizations.Packages = append(*merged.Customizations.Packages, pkg)
+			}
+		}
+	}
+
+	labels := ComposeLabels{}
+	if merged.Labels != nil {
+		for k, v := range *merged.Labels {
+			labels[k] = v
+		}
+	}
+	if overrides.Labels != nil {
+		for k, v := range *overrides.Labels {
+			labels[k] = v
+		}
+	}
+	labels[templateLabel] = name
+	labels[templateVersionLabel] = strconv.Itoa(version)
+	merged.Labels = &labels
+	return merged, nil
+}
+
+// mergeCustomizations replaces the fields of the template's customizations
+// the overrides set.
+func mergeCustomizations(template, overrides *Customizations) (*Customizations, error) {
+	fields := map[string]json.RawMessage{}
+	if template != nil {
+		data, err := json.Marshal(template)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(data, &fields)
+		if err != nil {
+			return nil, err
+		}
+	}
+	data, err := json.Marshal(overrides)
+	if err != nil {
+		return nil, err
+	}
+	var overridden map[string]json.RawMessage
+	err = json.Unmarshal(data, &overridden)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range overridden {
+		fields[k] = v
+	}
+
+	data, err = json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	var merged Customizations
+	err = json.Unmarshal(data, &merged)
+	return &merged, err
+}
@@ -0,0 +1,100 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/common"
+)
+
+func TestMergeComposeTemplate(t *testing.T) {
+	var uo UploadRequest_Options
+	require.NoError(t, uo.FromAWSS3UploadRequestOptions(AWSS3UploadRequestOptions{}))
+	template := ComposeRequest{
+		Distribution: "rhel-9",
+		ImageName:    common.ToPtr("web-server"),
+		ImageRequests: []ImageRequest{
+			{
+				Architecture: "x86_64",
+				ImageType:    ImageTypesGuestImage,
+				UploadRequest: UploadRequest{
+					Type:    UploadTypesAwsS3,
+					Options: uo,
+				},
+			},
+		},
+		Customizations: &Customizations{
+			Packages: &[]string{"nginx", "vim"},
+			Hostname: common.ToPtr("web"),
+			Fips: &FIPS{
+				Enabled: common.ToPtr(true),
+			},
+		},
+		Labels: &ComposeLabels{"team": "web", "tier": "frontend"},
+	}
+
+	// without overrides it's the template, labelled with it
+	merged, err := mergeComposeTemplate(template, "web", 2, ComposeTemplateOverrides{})
+	require.NoError(t, err)
+	require.Equal(t, template.Distribution, merged.Distribution)
+	require.Equal(t, template.ImageRequests, merged.ImageRequests)
+	require.Equal(t, template.Customizations, merged.Customizations)
+	require.Equal(t, ComposeLabels{
+		"team":               "web",
+		"tier":               "frontend",
+		templateLabel:        "web",
+		templateVersionLabel: "2",
+	}, *merged.Labels)
+
+	var gcp UploadRequest_Options
+	require.NoError(t, gcp.FromGCPUploadRequestOptions(GCPUploadRequestOptions{}))
+	imageRequests := []ImageRequest{
+		{
+			Architecture: "x86_64",
+			ImageType:    ImageTypesGcp,
+			UploadRequest: UploadRequest{
+				Type:    UploadTypesGcp,
+				Options: gcp,
+			},
+		},
+	}
+	merged, err = mergeComposeTemplate(template, "web", 2, ComposeTemplateOverrides{
+		Distribution:  common.ToPtr(Distributions("rhel-10")),
+		ImageName:     common.ToPtr("web-server-eu"),
+		ImageRequests: &imageRequests,
+		Packages:      &[]string{"tmux", "vim"},
+		Customizations: &Customizations{
+			Hostname: common.ToPtr("web-eu"),
+			Packages: &[]string{"curl"},
+		},
+		Labels: &ComposeLabels{"tier": "edge", templateLabel: "other"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, Distributions("rhel-10"), merged.Distribution)
+	require.Equal(t, "web-server-eu", *merged.ImageName)
+	require.Equal(t, imageRequests, merged.ImageRequests)
+	require.Equal(t, []string{"nginx", "vim", "curl", "tmux"}, *merged.Customizations.Packages)
+	require.Equal(t, "web-eu", *merged.Customizations.Hostname)
+	// customizations the overrides don't set are kept
+	require.True(t, *merged.Customizations.Fips.Enabled)
+	require.Equal(t, ComposeLabels{
+		"team":               "web",
+		"tier":               "edge",
+		templateLabel:        "web",
+		templateVersionLabel: "2",
+	}, *merged.Labels)
+
+	// the template isn't touched
+	require.Equal(t, []string{"nginx", "vim"}, *template.Customizations.Packages)
+	require.Equal(t, "web", *template.Customizations.Hostname)
+	require.Equal(t, ComposeLabels{"team": "web", "tier": "frontend"}, *template.Labels)
+
+	// packages of a template without customizations
+	template.Customizations = nil
+	merged, err = mergeComposeTemplate(template, "web", 1, ComposeTemplateOverrides{
+		Packages: &[]string{"tmux"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"tmux"}, *merged.Customizations.Packages)
+}
@@ -0,0 +1,140 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+)
+
+// ValidateCompose runs a compose request through everything a compose goes
+// through before it is built, up to the depsolve of its packages by
+// composer. Client errors are the problems of the request, others fail it.
+func (h *Handlers) ValidateCompose(ctx echo.Context) error {
+	var composeRequest ComposeRequest
+	err := ctx.Bind(&composeRequest)
+	if err != nil {
+		return err
+	}
+
+	result := ComposeValidationResponse{
+		Errors:   []string{},
+		Warnings: []string{},
+	}
+	reject := func(err error) error {
+		messages, err := clientErrorMessages(err)
+		if err != nil {
+			return err
+		}
+		result.Errors = append(result.Errors, messages...)
+		return ctx.JSON(http.StatusOK, result)
+	}
+
+	prepared, err := h.prepareCompose(ctx, &composeRequest)
+	if err != nil {
+		return reject(err)
+	}
+	packages, err := h.depsolveCompose(ctx, prepared.composerRequest)
+	if err != nil {
+		return reject(err)
+	}
+	result.Packages = &packages
+
+	result.Warnings = append(result.Warnings, prepared.secrets...)
+	if w := h.server.emulationWarning(composeRequest.ImageRequests[0].Architecture); w != "" {
+		result.Warnings = append(result.Warnings, w)
+	}
+	warnings, _, _ := deprecations(prepared.distro, prepared.arch, composeRequest.ImageRequests[0].ImageType)
+	result.Warnings = append(result.Warnings, warnings...)
+	result.Warnings = append(result.Warnings, lintComposeRequest(&composeRequest)...)
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// depsolveCompose resolves the packages the compose would install, as
+// name-[epoch:]version-release.arch.
+func (h *Handlers) depsolveCompose(ctx echo.Context, cr composer.ComposeRequest) ([]string, error) {
+	depsolved, err := h.depsolvePackages(ctx, cr)
+	if err != nil {
+		return nil, err
+	}
+	packages := make([]string, 0, len(depsolved))
+	for _, pkg := range depsolved {
+		packages = append(packages, nevra(pkg.Name, pkg.Epoch, pkg.Version, pkg.Release, pkg.Arch))
+	}
+	return packages, nil
+}
+
+// depsolvePackages resolves the packages the compose would install against
+// the current content of its repositories.
+func (h *Handlers) depsolvePackages(ctx echo.Context, cr composer.ComposeRequest) ([]composer.PackageMetadataCommon, error) {
+	blueprint := composer.Blueprint{
+		Name: "dry-run",
+	}
+	repositories := slices.Clone(cr.ImageRequest.Repositories)
+	if cust := cr.Customizations; cust != nil {
+		if cust.Packages != nil {
+			var packages []composer.Package
+			var groups []composer.PackageGroup
+			for _, p := range *cust.Packages {
+				if group, ok := strings.CutPrefix(p, "@"); ok {
+					groups = append(groups, composer.PackageGroup{Name: group})
+				} else {
+					packages = append(packages, composer.Package{Name: p})
+				}
+			}
+			if len(packages) > 0 {
+				blueprint.Packages = &packages
+			}
+			if len(groups) > 0 {
+				blueprint.Groups = &groups
+			}
+		}
+		if cust.PayloadRepositories != nil {
+			repositories = append(repositories, *cust.PayloadRepositories...)
+		}
+	}
+
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cClient, _ := h.server.composerForOrg(userID.OrgID())
+	resp, err := cClient.Depsolve(composer.DepsolveRequest{
+		Blueprint:    blueprint,
+		Distribution: &cr.Distribution,
+		Architecture: &cr.ImageRequest.Architecture,
+		ImageType:    &cr.ImageRequest.ImageType,
+		Repositories: &repositories,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(ctx, resp.Body)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		httpError := echo.NewHTTPError(http.StatusInternalServerError, "Failed depsolving the packages of the compose")
+		var serviceStat composer.Error
+		if json.Unmarshal(body, &serviceStat) == nil {
+			httpError = composerRequestError(resp.StatusCode, serviceStat)
+		}
+		_ = httpError.SetInternal(fmt.Errorf("%s", body))
+		return nil, httpError
+	}
+
+	var depsolved composer.DepsolveResponse
+	err = json.Unmarshal(body, &depsolved)
+	if err != nil {
+		return nil, err
+	}
+	return depsolved.Packages, nil
+}
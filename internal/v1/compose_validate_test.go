@@ -0,0 +1,115 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestValidateCompose(t *testing.T) {
+	depsolves := 0
+	var depsolveRequest composer.DepsolveRequest
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.Equal(t, "/api/image-builder-composer/v2/depsolve/blueprint", r.URL.Path)
+		depsolves++
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&depsolveRequest))
+		w.Header().Set("Content-Type", "application/json")
+		if (*depsolveRequest.Blueprint.Packages)[0].Name == "missing" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, err := w.Write([]byte(`{"href": "", "id": "1000", "kind": "Error", "code": "IMAGE-BUILDER-COMPOSER-1000", "reason": "DNF error occurred: MarkingErrors", "details": "Error occurred when marking packages for installation: Problems in request: missing packages: missing"}`))
+			require.NoError(t, err)
+			return
+		}
+		_, err := w.Write([]byte(`{"packages": [
+			{"type": "rpm", "name": "vim-enhanced", "version": "8.2.2637", "release": "20.el9", "epoch": "2", "arch": "x86_64"},
+			{"type": "rpm", "name": "bash", "version": "5.1.8", "release": "6.el9", "arch": "x86_64"}
+		]}`))
+		require.NoError(t, err)
+	}))
+	defer apiSrv.Close()
+
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
+		DBase:            dbase,
+		DistributionsDir: "../../distributions",
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	var uo UploadRequest_Options
+	require.NoError(t, uo.FromAWSUploadRequestOptions(AWSUploadRequestOptions{
+		ShareWithAccounts: &[]string{"test-account"},
+	}))
+	payload := ComposeRequest{
+		Customizations: &Customizations{
+			Packages: &[]string{"vim-enhanced", "@core"},
+		},
+		Distribution: "rhel-9",
+		ImageRequests: []ImageRequest{
+			{
+				Architecture: "x86_64",
+				ImageType:    ImageTypesAws,
+				UploadRequest: UploadRequest{
+					Type:    UploadTypesAws,
+					Options: uo,
+				},
+			},
+		},
+	}
+	respStatusCode, body := tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/compose/validate", payload)
+	require.Equal(t, http.StatusOK, respStatusCode, body)
+	var result ComposeValidationResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Empty(t, result.Errors)
+	require.Equal(t, []string{"vim-enhanced-2:8.2.2637-20.el9.x86_64", "bash-5.1.8-6.el9.x86_64"}, *result.Packages)
+	require.Equal(t, []string{
+		"No ssh key is configured, instances of the image can only be logged into with the keys of the cloud provider",
+	}, result.Warnings)
+	require.Equal(t, []composer.Package{{Name: "vim-enhanced"}}, *depsolveRequest.Blueprint.Packages)
+	require.Equal(t, []composer.PackageGroup{{Name: "core"}}, *depsolveRequest.Blueprint.Groups)
+	require.Equal(t, "x86_64", *depsolveRequest.Architecture)
+	require.NotEmpty(t, *depsolveRequest.Repositories)
+
+	// nothing was composed
+	composes, _, err := dbase.GetComposes(context.Background(), "000000", 14*24*time.Hour, 100, 0, nil)
+	require.NoError(t, err)
+	require.Empty(t, composes)
+
+	// packages which can't be depsolved are problems of the request
+	payload.Customizations.Packages = &[]string{"missing"}
+	respStatusCode, body = tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/compose/validate", payload)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	result = ComposeValidationResponse{}
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Len(t, result.Errors, 1)
+	require.Contains(t, result.Errors[0], "missing packages: missing")
+	require.Nil(t, result.Packages)
+	require.Equal(t, 2, depsolves)
+
+	// composer isn't asked about requests which fail the checks
+	payload.ImageRequests[0].Size = common.ToPtr(uint64(FSMaxSize + 1))
+	respStatusCode, body = tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/compose/validate", payload)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	result = ComposeValidationResponse{}
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Equal(t, []string{fmt.Sprintf("Total AWS image size cannot exceed %d bytes", FSMaxSize)}, result.Errors)
+	require.Equal(t, 2, depsolves)
+}
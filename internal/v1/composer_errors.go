@@ -0,0 +1,85 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	iberrors "github.com/osbuild/image-builder/pkg/errors"
+)
+
+// Parts of composer's error messages which tell about its deployment rather
+// than about the request: cluster internal hostnames, addresses and the ids of
+// jobs and workers.
+var composerErrorSanitizers = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?i)\b[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*\.(svc|cluster\.local|internal|local|localdomain|lan|corp)\b(:\d+)?`), "[internal host]"},
+	{regexp.MustCompile(`\b(\d{1,3}\.){3}\d{1,3}(:\d+)?\b`), "[internal address]"},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "[id]"},
+}
+
+// sanitizeComposerMessage strips what users shouldn't see from a message of
+// composer, what's left is about their request.
+func sanitizeComposerMessage(message string) string {
+	for _, s := range composerErrorSanitizers {
+		message = s.pattern.ReplaceAllString(message, s.replacement)
+	}
+	return strings.TrimSpace(message)
+}
+
+// sanitizeComposerDetails sanitizes all strings in the details of an error,
+// which can be nested arbitrarily.
+func sanitizeComposerDetails(details interface{}) interface{} {
+	switch d := details.(type) {
+	case string:
+		return sanitizeComposerMessage(d)
+	case []interface{}:
+		sanitized := make([]interface{}, len(d))
+		for i, v := range d {
+			sanitized[i] = sanitizeComposerDetails(v)
+		}
+		return sanitized
+	case map[string]interface{}:
+		sanitized := make(map[string]interface{}, len(d))
+		for k, v := range d {
+			sanitized[k] = sanitizeComposerDetails(v)
+		}
+		return sanitized
+	default:
+		return d
+	}
+}
+
+// composerRequestError turns an error composer returned for a compose request
+// into the one shown to the user. Rejections of the request, like packages
+// which can't be depsolved or invalid customizations, are passed on
+// sanitized, everything else stays an internal error.
+func composerRequestError(statusCode int, cErr composer.Error) *echo.HTTPError {
+	switch cErr.Id {
+	case "10":
+		return echo.NewHTTPError(http.StatusBadRequest, "Error resolving OSTree repo")
+	case "24", "29":
+		// missing baseurl in payload repository, gpg key not set when check_gpg is true
+		return apiError(iberrors.CodeInvalidCustomization, http.StatusBadRequest, sanitizeComposerMessage(cErr.Reason))
+	}
+
+	if statusCode != http.StatusBadRequest && statusCode != http.StatusUnprocessableEntity {
+		return apiError(iberrors.CodeUpstreamUnavailable, http.StatusInternalServerError, "Failed posting compose request to osbuild-composer")
+	}
+	message := sanitizeComposerMessage(cErr.Reason)
+	if cErr.Details != nil {
+		if details, ok := sanitizeComposerDetails(*cErr.Details).(string); ok && details != "" {
+			message = fmt.Sprintf("%s: %s", message, details)
+		}
+	}
+	if message == "" {
+		return apiError(iberrors.CodeUpstreamUnavailable, http.StatusInternalServerError, "Failed posting compose request to osbuild-composer")
+	}
+	return apiError(iberrors.CodeInvalidCustomization, http.StatusBadRequest, fmt.Sprintf("osbuild-composer rejected the compose request: %s", message))
+}
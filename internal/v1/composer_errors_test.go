@@ -0,0 +1,66 @@
+package v1
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+)
+
+func TestSanitizeComposerMessage(t *testing.T) {
+	cases := map[string]string{
+		"DNF error occurred: MarkingErrors: Error occurred when marking packages for installation: Problems in request:\nmissing packages: foo": "DNF error occurred: MarkingErrors: Error occurred when marking packages for installation: Problems in request:\nmissing packages: foo",
+		"failed to reach rpmrepo.osbuild-composer.svc:8080 for job 3f1b2c4d-aaaa-bbbb-cccc-1234567890ab":                                        "failed to reach [internal host] for job [id]",
+		"worker at 10.128.4.17:443 timed out":                                                    "worker at [internal address] timed out",
+		"cache-0.cache.image-builder.svc.cluster.local refused the connection":                   "[internal host] refused the connection",
+		"repository https://cdn.redhat.com/content/dist/rhel9/9/x86_64/baseos/os is unavailable": "repository https://cdn.redhat.com/content/dist/rhel9/9/x86_64/baseos/os is unavailable",
+	}
+	for in, out := range cases {
+		require.Equal(t, out, sanitizeComposerMessage(in))
+	}
+}
+
+func TestSanitizeComposerDetails(t *testing.T) {
+	details := []interface{}{
+		map[string]interface{}{
+			"id":     float64(5),
+			"reason": "depsolve on worker-1.osbuild.internal failed",
+		},
+		"nothing to hide",
+	}
+	require.Equal(t, []interface{}{
+		map[string]interface{}{
+			"id":     float64(5),
+			"reason": "depsolve on [internal host] failed",
+		},
+		"nothing to hide",
+	}, sanitizeComposerDetails(details))
+}
+
+func TestComposerRequestError(t *testing.T) {
+	var details interface{} = "package nonexistent-package not found on 10.0.0.3"
+	cases := []struct {
+		statusCode int
+		cErr       composer.Error
+		code       int
+		message    string
+		errCode    *string
+	}{
+		{http.StatusOK, composer.Error{Id: "10", Reason: "not ok"}, http.StatusBadRequest, "Error resolving OSTree repo", nil},
+		{http.StatusBadRequest, composer.Error{Id: "29", Reason: "gpg key not set"}, http.StatusBadRequest, "gpg key not set", common.ToPtr("invalid-customization")},
+		{http.StatusBadRequest, composer.Error{Id: "8", Reason: "DNF error occurred", Details: &details}, http.StatusBadRequest,
+			"osbuild-composer rejected the compose request: DNF error occurred: package nonexistent-package not found on [internal address]", common.ToPtr("invalid-customization")},
+		{http.StatusBadRequest, composer.Error{Id: "30"}, http.StatusInternalServerError, "Failed posting compose request to osbuild-composer", common.ToPtr("upstream-unavailable")},
+		{http.StatusInternalServerError, composer.Error{Id: "1", Reason: "database at db.svc down"}, http.StatusInternalServerError, "Failed posting compose request to osbuild-composer", common.ToPtr("upstream-unavailable")},
+		{http.StatusUnauthorized, composer.Error{Id: "401", Reason: "token expired"}, http.StatusInternalServerError, "Failed posting compose request to osbuild-composer", common.ToPtr("upstream-unavailable")},
+	}
+	for _, c := range cases {
+		httpErr := composerRequestError(c.statusCode, c.cErr)
+		require.Equal(t, c.code, httpErr.Code)
+		require.Equal(t, c.message, httpErr.Message)
+		require.Equal(t, c.errCode, errorCode(httpErr))
+	}
+}
@@ -0,0 +1,48 @@
+package v1
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+// composeCursor is the keyset of the last compose on a page, clients only get
+// to see it encoded so it can change without breaking them.
+type composeCursor struct {
+	CreatedAt time.Time `json:"c"`
+	Id        uuid.UUID `json:"i"`
+}
+
+func encodeComposeCursor(c db.ComposeCursor) (string, error) {
+	data, err := json.Marshal(composeCursor{
+		CreatedAt: c.CreatedAt,
+		Id:        c.Id,
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeComposeCursor(cursor string) (db.ComposeCursor, error) {
+	invalid := echo.NewHTTPError(http.StatusBadRequest, "Invalid cursor")
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return db.ComposeCursor{}, invalid
+	}
+	var c composeCursor
+	err = json.Unmarshal(data, &c)
+	if err != nil || c.CreatedAt.IsZero() || c.Id == uuid.Nil {
+		return db.ComposeCursor{}, invalid
+	}
+	return db.ComposeCursor{
+		CreatedAt: c.CreatedAt,
+		Id:        c.Id,
+	}, nil
+}
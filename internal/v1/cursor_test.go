@@ -0,0 +1,29 @@
+package v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+func TestComposeCursor(t *testing.T) {
+	c := db.ComposeCursor{
+		CreatedAt: time.Date(2024, 5, 1, 10, 20, 30, 123456000, time.UTC),
+		Id:        uuid.New(),
+	}
+	encoded, err := encodeComposeCursor(c)
+	require.NoError(t, err)
+	decoded, err := decodeComposeCursor(encoded)
+	require.NoError(t, err)
+	require.True(t, c.CreatedAt.Equal(decoded.CreatedAt))
+	require.Equal(t, c.Id, decoded.Id)
+
+	for _, invalid := range []string{"", "garbage!", "e30"} {
+		_, err = decodeComposeCursor(invalid)
+		require.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,53 @@
+package v1
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/osbuild/image-builder/internal/prometheus"
+)
+
+// customizationNone labels composes without customizations, so adoption can be
+// put in relation to all composes.
+const customizationNone = "none"
+
+// customizationNames lists the json names of all customizations, they are the
+// values of the customization label.
+func customizationNames() []string {
+	names := []string{customizationNone}
+	t := reflect.TypeOf(Customizations{})
+	for i := 0; i < t.NumField(); i++ {
+		names = append(names, customizationName(t.Field(i)))
+	}
+	return names
+}
+
+func customizationName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return name
+}
+
+// usedCustomizations lists the customizations which are set in the request.
+func usedCustomizations(c *Customizations) []string {
+	var used []string
+	if c != nil {
+		v := reflect.ValueOf(*c)
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Field(i).IsZero() {
+				used = append(used, customizationName(v.Type().Field(i)))
+			}
+		}
+	}
+	if len(used) == 0 {
+		return []string{customizationNone}
+	}
+	return used
+}
+
+// countCustomizations records which features the compose uses. Nothing about
+// who composed ends up in the labels.
+func countCustomizations(c *Customizations) {
+	for _, name := range usedCustomizations(c) {
+		prometheus.ComposeCustomizations.WithLabelValues(name).Inc()
+	}
+}
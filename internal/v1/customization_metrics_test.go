@@ -0,0 +1,21 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/common"
+)
+
+func TestUsedCustomizations(t *testing.T) {
+	require.Equal(t, []string{customizationNone}, usedCustomizations(nil))
+	require.Equal(t, []string{customizationNone}, usedCustomizations(&Customizations{}))
+
+	used := usedCustomizations(&Customizations{
+		Hostname: common.ToPtr("host"),
+		Packages: &[]string{"vim"},
+	})
+	require.ElementsMatch(t, []string{"hostname", "packages"}, used)
+	require.Subset(t, customizationNames(), used)
+}
@@ -0,0 +1,53 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/distribution"
+	"github.com/osbuild/image-builder/internal/prometheus"
+)
+
+// deprecationWarnings returns a warning for the deprecation of the
+// distribution and of the image type of a compose. The Deprecation and Sunset
+// headers of the response carry the earliest dates of the two.
+func deprecationWarnings(ctx echo.Context, d *distribution.DistributionFile, arch *distribution.Architecture, imageType ImageTypes) []string {
+	warnings, since, sunset := deprecations(d, arch, imageType)
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	ctx.Response().Header().Set("Deprecation", fmt.Sprintf("@%d", since.Unix()))
+	ctx.Response().Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+	prometheus.DeprecatedComposes.WithLabelValues(d.Distribution.Name, string(canonicalImageType(imageType))).Inc()
+	return warnings
+}
+
+// deprecations returns the deprecation warnings of a compose along with the
+// earliest since and sunset among them.
+func deprecations(d *distribution.DistributionFile, arch *distribution.Architecture, imageType ImageTypes) (warnings []string, since, sunset time.Time) {
+	add := func(subject string, dep *distribution.Deprecation) {
+		w := fmt.Sprintf("%s is deprecated and can't be built after %s", subject, dep.Sunset.Format(time.DateOnly))
+		if dep.Message != "" {
+			w = fmt.Sprintf("%s: %s", w, dep.Message)
+		}
+		warnings = append(warnings, w)
+		if since.IsZero() || dep.Since.Before(since) {
+			since = dep.Since
+		}
+		if sunset.IsZero() || dep.Sunset.Before(sunset) {
+			sunset = dep.Sunset
+		}
+	}
+
+	if dep := d.Distribution.Deprecation; dep != nil {
+		add(d.Distribution.Name, dep)
+	}
+	if dep := arch.ImageTypeDeprecation(string(imageType)); dep != nil {
+		add(fmt.Sprintf("The %s image type of %s", canonicalImageType(imageType), d.Distribution.Name), dep)
+	}
+	return warnings, since, sunset
+}
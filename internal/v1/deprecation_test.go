@@ -0,0 +1,61 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/distribution"
+)
+
+func TestDeprecationWarnings(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	arch := &distribution.Architecture{
+		ImageTypes: []string{"aws", "vsphere"},
+		DeprecatedImageTypes: map[string]distribution.Deprecation{
+			"vsphere": {
+				Since:   since.AddDate(0, 1, 0),
+				Sunset:  time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+				Message: "build vsphere-ova images instead",
+			},
+		},
+	}
+	d := &distribution.DistributionFile{
+		Distribution: distribution.DistributionItem{Name: "rhel-87"},
+		ArchX86:      arch,
+	}
+
+	newContext := func() (echo.Context, *httptest.ResponseRecorder) {
+		rec := httptest.NewRecorder()
+		return echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/compose", nil), rec), rec
+	}
+
+	ctx, rec := newContext()
+	require.Empty(t, deprecationWarnings(ctx, d, arch, ImageTypesAws))
+	require.Empty(t, rec.Header().Get("Deprecation"))
+	require.Empty(t, rec.Header().Get("Sunset"))
+
+	ctx, rec = newContext()
+	require.Equal(t, []string{
+		"The vsphere image type of rhel-87 is deprecated and can't be built after 2024-07-01: build vsphere-ova images instead",
+	}, deprecationWarnings(ctx, d, arch, ImageTypesVsphere))
+	require.Equal(t, "@1706745600", rec.Header().Get("Deprecation"))
+	require.Equal(t, "Mon, 01 Jul 2024 00:00:00 GMT", rec.Header().Get("Sunset"))
+
+	// the earliest dates of both deprecations make the headers
+	d.Distribution.Deprecation = &distribution.Deprecation{
+		Since:  since,
+		Sunset: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+	}
+	ctx, rec = newContext()
+	require.Equal(t, []string{
+		"rhel-87 is deprecated and can't be built after 2024-12-31",
+		"The vsphere image type of rhel-87 is deprecated and can't be built after 2024-07-01: build vsphere-ova images instead",
+	}, deprecationWarnings(ctx, d, arch, ImageTypesVmdk))
+	require.Equal(t, "@1704067200", rec.Header().Get("Deprecation"))
+	require.Equal(t, "Mon, 01 Jul 2024 00:00:00 GMT", rec.Header().Get("Sunset"))
+}
@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/osbuild/image-builder/internal/prometheus"
+)
+
+// ParseEmulatedArchitectures parses the comma separated arch=duration pairs of
+// EMULATED_ARCHITECTURES, the duration is how long a build of the
+// architecture typically takes on the emulating workers.
+func ParseEmulatedArchitectures(spec string) (map[string]time.Duration, error) {
+	archs := map[string]time.Duration{}
+	if spec == "" {
+		return archs, nil
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		arch, duration, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || arch == "" {
+			return nil, fmt.Errorf("invalid emulated architecture %q, expected arch=duration", entry)
+		}
+		d, err := time.ParseDuration(duration)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid build duration of emulated architecture %q", entry)
+		}
+		archs[arch] = d
+	}
+	return archs, nil
+}
+
+// emulationWarning tells users requesting an architecture which the
+// deployment has no native workers for that the build will be slower, empty
+// for native architectures.
+func (s *Server) emulationWarning(arch ImageRequestArchitecture) string {
+	duration, ok := s.emulatedArchs[string(arch)]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s images are built under emulation, expect the build to take about %s", arch, formatBuildDuration(duration))
+}
+
+// countArchitecture tracks the demand for each architecture, and how much of
+// it has to be served by emulation.
+func (s *Server) countArchitecture(arch ImageRequestArchitecture) {
+	_, emulated := s.emulatedArchs[string(arch)]
+	prometheus.ComposeArchitectures.WithLabelValues(string(arch), fmt.Sprintf("%t", emulated)).Inc()
+}
+
+func formatBuildDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute).Minutes())
+	if minutes < 1 {
+		return "a minute"
+	}
+	if minutes < 60 || minutes%60 != 0 {
+		return fmt.Sprintf("%d minutes", minutes)
+	}
+	if minutes == 60 {
+		return "an hour"
+	}
+	return fmt.Sprintf("%d hours", minutes/60)
+}
+
+func architectureNames() []string {
+	return []string{
+		string(ImageRequestArchitectureAarch64),
+		string(ImageRequestArchitectureX8664),
+	}
+}
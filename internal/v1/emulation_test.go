@@ -0,0 +1,37 @@
+package v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEmulatedArchitectures(t *testing.T) {
+	archs, err := ParseEmulatedArchitectures("")
+	require.NoError(t, err)
+	require.Empty(t, archs)
+
+	archs, err = ParseEmulatedArchitectures("aarch64=45m, s390x=2h")
+	require.NoError(t, err)
+	require.Equal(t, map[string]time.Duration{
+		"aarch64": 45 * time.Minute,
+		"s390x":   2 * time.Hour,
+	}, archs)
+
+	_, err = ParseEmulatedArchitectures("aarch64")
+	require.Error(t, err)
+	_, err = ParseEmulatedArchitectures("aarch64=slow")
+	require.Error(t, err)
+}
+
+func TestEmulationWarning(t *testing.T) {
+	s := &Server{emulatedArchs: map[string]time.Duration{"aarch64": 90 * time.Minute}}
+	require.Empty(t, s.emulationWarning(ImageRequestArchitectureX8664))
+	require.Equal(t, "aarch64 images are built under emulation, expect the build to take about 90 minutes",
+		s.emulationWarning(ImageRequestArchitectureAarch64))
+
+	s.emulatedArchs["aarch64"] = 2 * time.Hour
+	require.Equal(t, "aarch64 images are built under emulation, expect the build to take about 2 hours",
+		s.emulationWarning(ImageRequestArchitectureAarch64))
+}
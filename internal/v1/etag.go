@@ -0,0 +1,24 @@
+package v1
+
+import (
+	"fmt"
+	"strings"
+)
+
+// versionETag identifies a version of a resource, every update of the
+// resource bumps its version.
+func versionETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// etagMatches compares the entity tags of an If-Match header to the current
+// one, weak tags never match.
+func etagMatches(ifMatch, etag string) bool {
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
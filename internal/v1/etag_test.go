@@ -0,0 +1,16 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestETagMatches(t *testing.T) {
+	require.True(t, etagMatches(`"2"`, `"2"`))
+	require.True(t, etagMatches(`"1", "2"`, `"2"`))
+	require.True(t, etagMatches(`*`, `"2"`))
+	require.False(t, etagMatches(`"1"`, `"2"`))
+	require.False(t, etagMatches(`W/"2"`, `"2"`))
+	require.False(t, etagMatches(`2`, `"2"`))
+}
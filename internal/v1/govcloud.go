@@ -0,0 +1,40 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+// isGovCloudRegion tells if the region is in one of the AWS GovCloud
+// partitions, which are isolated from the commercial one.
+func isGovCloudRegion(region string) bool {
+	return strings.HasPrefix(region, "us-gov-")
+}
+
+// checkGovCloudClone rejects clones into GovCloud regions unless the
+// deployment permits the distribution of the compose there. Composer's workers
+// hold the credentials and endpoints of the partition.
+func (s *Server) checkGovCloudClone(region string, composeEntry *db.ComposeEntry) error {
+	if !isGovCloudRegion(region) {
+		return nil
+	}
+	if len(s.aws.GovCloudDistros) == 0 {
+		return echo.NewHTTPError(http.StatusForbidden, "Cloning into AWS GovCloud regions is not available")
+	}
+
+	var composeRequest ComposeRequest
+	err := s.openComposeRequest(composeEntry.Request, &composeRequest)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(s.aws.GovCloudDistros, string(composeRequest.Distribution)) {
+		return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("Images of %s can't be cloned into AWS GovCloud regions", composeRequest.Distribution))
+	}
+	return nil
+}
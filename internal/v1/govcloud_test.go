@@ -0,0 +1,33 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+func TestCheckGovCloudClone(t *testing.T) {
+	entry := &db.ComposeEntry{
+		Request: json.RawMessage(`{"distribution": "rhel-94", "image_requests": [{"image_type": "aws"}]}`),
+	}
+
+	s := &Server{}
+	require.NoError(t, s.checkGovCloudClone("us-east-1", entry))
+	err := s.checkGovCloudClone("us-gov-west-1", entry)
+	var he *echo.HTTPError
+	require.ErrorAs(t, err, &he)
+	require.Equal(t, http.StatusForbidden, he.Code)
+
+	s.aws.GovCloudDistros = []string{"rhel-93"}
+	err = s.checkGovCloudClone("us-gov-east-1", entry)
+	require.ErrorAs(t, err, &he)
+	require.Equal(t, http.StatusForbidden, he.Code)
+
+	s.aws.GovCloudDistros = []string{"rhel-93", "rhel-94"}
+	require.NoError(t, s.checkGovCloudClone("us-gov-east-1", entry))
+}
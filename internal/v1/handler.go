@@ -5,8 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"net/url"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -17,6 +20,8 @@ import (
 	"github.com/osbuild/image-builder/internal/clients/provisioning"
 	"github.com/osbuild/image-builder/internal/common"
 	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/distribution"
+	"github.com/osbuild/image-builder/internal/watchdog"
 
 	"github.com/labstack/echo/v4"
 )
@@ -44,7 +49,7 @@ func (h *Handlers) newLinksWithExtraParams(path string, count, limit int, params
 	fullPath.RawQuery = params.Encode()
 	last := fullPath.String()
 
-	return ListResponseLinks{first, last}
+	return ListResponseLinks{First: first, Last: last}
 }
 
 func (h *Handlers) GetVersion(ctx echo.Context) error {
@@ -57,6 +62,22 @@ func (h *Handlers) GetVersion(ctx echo.Context) error {
 }
 
 func (h *Handlers) GetReadiness(ctx echo.Context) error {
+	// no request is served before the router of the spec is built, this
+	// waits for the build Attach started
+	err := h.server.router.build()
+	if err != nil {
+		httpErr := echo.NewHTTPError(http.StatusServiceUnavailable, "Unable to validate requests")
+		return httpErr.SetInternal(err)
+	}
+
+	// reads are still served while composer fails over
+	if enabled, reason := h.server.readOnly.Enabled(); enabled {
+		return ctx.JSON(http.StatusOK, Readiness{
+			Readiness: "read-only",
+			Reason:    &reason,
+		})
+	}
+
 	resp, err := h.server.cClient.OpenAPI()
 	if err != nil {
 		return err
@@ -71,11 +92,9 @@ func (h *Handlers) GetReadiness(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to contact osbuild-composer: %s", body))
 	}
 
-	ready := map[string]string{
-		"readiness": "ready",
-	}
-
-	return ctx.JSON(http.StatusOK, ready)
+	return ctx.JSON(http.StatusOK, Readiness{
+		Readiness: "ready",
+	})
 }
 
 func (h *Handlers) GetOpenapiJson(ctx echo.Context) error {
@@ -92,7 +111,7 @@ func (h *Handlers) GetDistributions(ctx echo.Context) error {
 	var distributions DistributionsResponse
 	for k, d := range dr.Map() {
 		if d.IsRestricted() {
-			allowOk, err := h.server.allowList.IsAllowed(userID.OrgID(), d.Distribution.Name)
+			allowOk, err := h.server.isAllowed(ctx, userID.OrgID(), d.Distribution.Name)
 			if err != nil {
 				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 			}
@@ -146,22 +165,35 @@ func (h *Handlers) GetArchitectures(ctx echo.Context, distro Distributions) erro
 
 	if d.ArchX86 != nil {
 		archs = append(archs, ArchitectureItem{
-			Arch:         "x86_64",
-			ImageTypes:   d.ArchX86.ImageTypes,
-			Repositories: reposArchX86,
+			Arch:             "x86_64",
+			ImageTypes:       d.ArchX86.ImageTypes,
+			ImageTypeDetails: imageTypeDetails(d.ArchX86.ImageTypes),
+			Repositories:     reposArchX86,
 		})
 	}
 	if d.Aarch64 != nil {
 		archs = append(archs, ArchitectureItem{
-			Arch:         "aarch64",
-			ImageTypes:   d.Aarch64.ImageTypes,
-			Repositories: reposAarch64,
+			Arch:             "aarch64",
+			ImageTypes:       d.Aarch64.ImageTypes,
+			ImageTypeDetails: imageTypeDetails(d.Aarch64.ImageTypes),
+			Repositories:     reposAarch64,
 		})
 	}
 
 	return ctx.JSON(http.StatusOK, archs)
 }
 
+func imageTypeDetails(imageTypes []string) *[]ImageTypeDetails {
+	details := make([]ImageTypeDetails, 0, len(imageTypes))
+	for _, it := range imageTypes {
+		details = append(details, ImageTypeDetails{
+			Name:        it,
+			DisplayName: distribution.ImageTypeDisplayName(it),
+		})
+	}
+	return &details
+}
+
 func (h *Handlers) GetPackages(ctx echo.Context, params GetPackagesParams) error {
 	d, err := h.server.getDistro(ctx, params.Distribution)
 	if err != nil {
@@ -214,9 +246,9 @@ func (h *Handlers) GetPackages(ctx echo.Context, params GetPackagesParams) error
 			len(packages),
 		},
 		Links: ListResponseLinks{
-			fmt.Sprintf("%v/v%v/packages?search=%v&distribution=%v&architecture=%v&offset=0&limit=%v",
+			First: fmt.Sprintf("%v/v%v/packages?search=%v&distribution=%v&architecture=%v&offset=0&limit=%v",
 				RoutePrefix(), h.server.spec.Info.Version, params.Search, params.Distribution, params.Architecture, limit),
-			fmt.Sprintf("%v/v%v/packages?search=%v&distribution=%v&architecture=%v&offset=%v&limit=%v",
+			Last: fmt.Sprintf("%v/v%v/packages?search=%v&distribution=%v&architecture=%v&offset=%v&limit=%v",
 				RoutePrefix(), h.server.spec.Info.Version, params.Search, params.Distribution, params.Architecture, lastOffset, limit),
 		},
 		Data: packages[offset:upto],
@@ -229,19 +261,107 @@ func (h *Handlers) GetComposeStatus(ctx echo.Context, composeId uuid.UUID) error
 		return err
 	}
 
-	resp, err := h.server.cClient.ComposeStatus(composeId)
+	status, err := h.unsignedComposeStatus(ctx, composeEntry)
+	if err != nil {
+		return err
+	}
+	if composeEntry.PipelineId != nil {
+		status.Pipeline, err = h.composePipelineStatus(ctx, composeEntry, status)
+		if err != nil {
+			return err
+		}
+	}
+	err = h.server.signUploadStatus(status.ImageStatus.UploadStatus)
+	if err != nil {
+		return err
+	}
+	if composeEntry.GroupId != nil {
+		status.Group, err = h.composeGroupStatus(ctx, *composeEntry.GroupId, status, composeEntry.Id)
+		if err != nil {
+			return err
+		}
+	}
+	status.PostProcessing, err = h.composeHookRuns(ctx, composeEntry.Id)
 	if err != nil {
 		return err
 	}
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	status.InjectedDefaults, err = h.composeInjectedDefaults(ctx, composeEntry.Id, userID.OrgID())
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, status)
+}
+
+// abandonedComposeReasons explains the error codes of composes image-builder
+// gave up on itself.
+var abandonedComposeReasons = map[string]string{
+	watchdog.ErrorCodeTimeout: "Compose did not finish in time",
+	ErrorCodeCancelled:        "Compose was cancelled",
+}
+
+// composeStatus asks the composer which built the compose about its status,
+// finished composes get their status recorded.
+func (h *Handlers) composeStatus(ctx echo.Context, composeEntry *db.ComposeEntry) (ComposeStatus, error) {
+	status, err := h.unsignedComposeStatus(ctx, composeEntry)
+	if err != nil {
+		return ComposeStatus{}, err
+	}
+	err = h.server.signUploadStatus(status.ImageStatus.UploadStatus)
+	if err != nil {
+		return ComposeStatus{}, err
+	}
+	return status, nil
+}
+
+// unsignedComposeStatus is the status with the upload URL composer returned,
+// it mustn't be handed out as is.
+func (h *Handlers) unsignedComposeStatus(ctx echo.Context, composeEntry *db.ComposeEntry) (ComposeStatus, error) {
+	composeId := composeEntry.Id
+	if reason, ok := abandonedComposeReasons[common.FromPtr(composeEntry.ErrorCode)]; ok {
+		// whatever composer says about the compose now, it was given up on
+		var composeRequest ComposeRequest
+		err := h.server.openComposeRequest(composeEntry.Request, &composeRequest)
+		if err != nil {
+			return ComposeStatus{}, err
+		}
+		return ComposeStatus{
+			ImageStatus: ImageStatus{
+				Status: ImageStatusStatusFailure,
+				Error: &ComposeStatusError{
+					Code:   composeEntry.ErrorCode,
+					Reason: reason,
+				},
+			},
+			Request:         composeRequest,
+			ParentComposeId: composeEntry.ParentComposeId,
+		}, nil
+	}
+
+	if enabled, _ := h.server.readOnly.Enabled(); enabled {
+		return h.storedComposeStatus(composeEntry)
+	}
+
+	cClient, err := h.server.composerFor(composeEntry.Region)
+	if err != nil {
+		return ComposeStatus{}, err
+	}
+	resp, err := cClient.ComposeStatus(composeId)
+	if err != nil {
+		return ComposeStatus{}, err
+	}
 	defer closeBody(ctx, resp.Body)
 
 	if resp.StatusCode == http.StatusNotFound {
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
-			return err
+			return ComposeStatus{}, err
 		}
 		// Composes can get deleted in composer, usually when the image is expired
-		return echo.NewHTTPError(http.StatusNotFound, string(body))
+		return ComposeStatus{}, echo.NewHTTPError(http.StatusNotFound, string(body))
 	} else if resp.StatusCode != http.StatusOK {
 		httpError := echo.NewHTTPError(http.StatusInternalServerError, "Failed querying compose status")
 		body, err := io.ReadAll(resp.Body)
@@ -250,38 +370,74 @@ func (h *Handlers) GetComposeStatus(ctx echo.Context, composeId uuid.UUID) error
 		} else {
 			_ = httpError.SetInternal(fmt.Errorf("%s", body))
 		}
-		return httpError
+		return ComposeStatus{}, httpError
 	}
 
 	var composeRequest ComposeRequest
-	err = json.Unmarshal(composeEntry.Request, &composeRequest)
+	err = h.server.openComposeRequest(composeEntry.Request, &composeRequest)
 	if err != nil {
-		return err
+		return ComposeStatus{}, err
 	}
 
 	var cloudStat composer.ComposeStatus
 	err = json.NewDecoder(resp.Body).Decode(&cloudStat)
 	if err != nil {
-		return err
+		return ComposeStatus{}, err
 	}
 
 	us, err := parseComposerUploadStatus(cloudStat.ImageStatus.UploadStatus)
 	if err != nil {
-		return err
+		return ComposeStatus{}, err
 	}
 	status := ComposeStatus{
 		ImageStatus: ImageStatus{
 			Status:       ImageStatusStatus(cloudStat.ImageStatus.Status),
 			UploadStatus: us,
 		},
-		Request: composeRequest,
+		Request:         composeRequest,
+		ParentComposeId: composeEntry.ParentComposeId,
 	}
 
 	if cloudStat.ImageStatus.Error != nil {
 		status.ImageStatus.Error = parseComposeStatusError(ctx, cloudStat.ImageStatus.Error)
 	}
 
-	return ctx.JSON(http.StatusOK, status)
+	// record when the compose finished, this emits the lifecycle event
+	finished := status.ImageStatus.Status == ImageStatusStatusSuccess || status.ImageStatus.Status == ImageStatusStatusFailure
+	if finished && common.FromPtr(composeEntry.Status) != string(status.ImageStatus.Status) {
+		err = h.server.db.SetComposeStatus(ctx.Request().Context(), composeId, string(status.ImageStatus.Status), nil)
+		if err != nil {
+			ctx.Logger().Errorf("Unable to store status of compose %v: %v", composeId, err)
+		}
+	}
+
+	return status, nil
+}
+
+// storedComposeStatus answers from the database alone, composer can't be asked
+// in read-only mode. Only finished composes have their status stored, the
+// others are reported as pending.
+func (h *Handlers) storedComposeStatus(composeEntry *db.ComposeEntry) (ComposeStatus, error) {
+	var composeRequest ComposeRequest
+	err := h.server.openComposeRequest(composeEntry.Request, &composeRequest)
+	if err != nil {
+		return ComposeStatus{}, err
+	}
+
+	status := ImageStatusStatusPending
+	switch common.FromPtr(composeEntry.Status) {
+	case string(ImageStatusStatusSuccess):
+		status = ImageStatusStatusSuccess
+	case string(ImageStatusStatusFailure):
+		status = ImageStatusStatusFailure
+	}
+	return ComposeStatus{
+		ImageStatus: ImageStatus{
+			Status: status,
+		},
+		Request:         composeRequest,
+		ParentComposeId: composeEntry.ParentComposeId,
+	}, nil
 }
 
 func parseComposerUploadStatus(us *composer.UploadStatus) (*UploadStatus, error) {
@@ -350,10 +506,19 @@ func parseComposerUploadStatus(us *composer.UploadStatus) (*UploadStatus, error)
 		}
 	}
 
+	var progress *UploadProgress
+	if us.Progress != nil {
+		progress = &UploadProgress{
+			BytesTransferred: us.Progress.BytesTransferred,
+			BytesTotal:       us.Progress.BytesTotal,
+		}
+	}
+
 	return &UploadStatus{
-		Options: options,
-		Status:  UploadStatusStatus(us.Status),
-		Type:    UploadTypes(us.Type),
+		Options:  options,
+		Progress: progress,
+		Status:   UploadStatusStatus(us.Status),
+		Type:     UploadTypes(us.Type),
 	}, nil
 }
 
@@ -365,9 +530,12 @@ func parseComposeStatusError(ctx echo.Context, composeErr *composer.ComposeStatu
 	// Default top-level error
 	fbErr := &ComposeStatusError{
 		Id:      composeErr.Id,
-		Reason:  composeErr.Reason,
+		Reason:  sanitizeComposerMessage(composeErr.Reason),
 		Details: composeErr.Details,
 	}
+	if composeErr.Details != nil {
+		fbErr.Details = common.ToPtr(sanitizeComposerDetails(*composeErr.Details))
+	}
 
 	switch composeErr.Id {
 	case 5: // manifest error: depsolve dependency failure
@@ -404,12 +572,23 @@ func parseComposeStatusError(ctx echo.Context, composeErr *composer.ComposeStatu
 	}
 }
 
-func (h *Handlers) DeleteCompose(ctx echo.Context, composeId uuid.UUID) error {
+func (h *Handlers) DeleteCompose(ctx echo.Context, composeId uuid.UUID, params DeleteComposeParams) error {
 	userID, err := h.server.getIdentity(ctx)
 	if err != nil {
 		return err
 	}
 
+	if params.DeleteArtifacts != nil && *params.DeleteArtifacts {
+		composeEntry, err := h.getComposeByIdAndOrgId(ctx, composeId)
+		if err != nil {
+			return err
+		}
+		err = h.deleteComposerCompose(ctx, composeEntry)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = h.server.db.DeleteCompose(ctx.Request().Context(), composeId, userID.OrgID())
 	if err != nil {
 		if errors.Is(err, db.ComposeNotFoundError) {
@@ -421,24 +600,133 @@ func (h *Handlers) DeleteCompose(ctx echo.Context, composeId uuid.UUID) error {
 	return ctx.NoContent(http.StatusOK)
 }
 
+// UpdateCompose renames or annotates a compose, only the listings change,
+// the request it was built from is kept as it was.
+func (h *Handlers) UpdateCompose(ctx echo.Context, composeId uuid.UUID) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	var update UpdateComposeJSONRequestBody
+	err = ctx.Bind(&update)
+	if err != nil {
+		return err
+	}
+
+	err = h.server.db.UpdateCompose(ctx.Request().Context(), composeId, userID.OrgID(), db.ComposeUpdate{
+		ImageName:   update.ImageName,
+		Description: update.Description,
+	})
+	if errors.Is(err, db.ComposeNotFoundError) {
+		return echo.NewHTTPError(http.StatusNotFound, err)
+	}
+	if err != nil {
+		return err
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// deleteComposerCompose deletes the compose in the composer which built it,
+// along with the artifacts it keeps. Composes composer doesn't know anymore
+// are fine, a composer which can't delete composes isn't.
+func (h *Handlers) deleteComposerCompose(ctx echo.Context, composeEntry *db.ComposeEntry) error {
+	cClient, err := h.server.composerFor(composeEntry.Region)
+	if err != nil {
+		return err
+	}
+	resp, err := cClient.DeleteCompose(composeEntry.Id)
+	if err != nil {
+		return err
+	}
+	defer closeBody(ctx, resp.Body)
+
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if composer.IsComposeNotFound(resp.StatusCode, body) {
+		return nil
+	}
+	httpError := echo.NewHTTPError(http.StatusInternalServerError, "Failed deleting compose in osbuild-composer")
+	_ = httpError.SetInternal(fmt.Errorf("%s", body))
+	return httpError
+}
+
 func (h *Handlers) GetComposeMetadata(ctx echo.Context, composeId uuid.UUID) error {
-	err := h.canUserAccessComposeId(ctx, composeId)
+	composeEntry, err := h.getComposeByIdAndOrgId(ctx, composeId)
 	if err != nil {
 		return err
 	}
 
-	resp, err := h.server.cClient.ComposeMetadata(composeId)
+	metadata, err := h.composeMetadata(ctx, composeEntry)
 	if err != nil {
 		return err
 	}
+	return ctx.JSONBlob(http.StatusOK, metadata)
+}
+
+// composeMetadata returns the ComposeMetadata of the compose as JSON, with
+// the packages pinned to the versions it was built with.
+func (h *Handlers) composeMetadata(ctx echo.Context, composeEntry *db.ComposeEntry) ([]byte, error) {
+	metadata, err := h.loadComposeMetadata(ctx, composeEntry)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.Packages != nil {
+		metadata.PinnedPackages = common.ToPtr(pinnedPackages(*metadata.Packages))
+	}
+	return json.Marshal(metadata)
+}
+
+// loadComposeMetadata returns the metadata of the compose from its snapshot
+// in the db, from object storage if it was kept there before the snapshots,
+// or else from composer.
+func (h *Handlers) loadComposeMetadata(ctx echo.Context, composeEntry *db.ComposeEntry) (ComposeMetadata, error) {
+	composeId := composeEntry.Id
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return ComposeMetadata{}, err
+	}
+	var metadata ComposeMetadata
+	snapshot, err := h.server.db.GetComposeMetadata(ctx.Request().Context(), composeId, userID.OrgID())
+	if err == nil {
+		err = json.Unmarshal(snapshot, &metadata)
+		return metadata, err
+	}
+	if !errors.Is(err, db.ComposeMetadataNotFoundError) {
+		return ComposeMetadata{}, err
+	}
+	stored, err := h.loadComposeBlob(ctx, composeId, blobKindMetadata)
+	if err != nil {
+		return ComposeMetadata{}, err
+	}
+	if stored != nil {
+		err = json.Unmarshal(stored, &metadata)
+		return metadata, err
+	}
+	if err := h.server.readOnlyError(); err != nil {
+		return ComposeMetadata{}, err
+	}
+
+	cClient, err := h.server.composerFor(composeEntry.Region)
+	if err != nil {
+		return ComposeMetadata{}, err
+	}
+	resp, err := cClient.ComposeMetadata(composeId)
+	if err != nil {
+		return ComposeMetadata{}, err
+	}
 	defer closeBody(ctx, resp.Body)
 
 	if resp.StatusCode == http.StatusNotFound {
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
-			return err
+			return ComposeMetadata{}, err
 		}
-		return echo.NewHTTPError(http.StatusNotFound, string(body))
+		return ComposeMetadata{}, echo.NewHTTPError(http.StatusNotFound, string(body))
 	} else if resp.StatusCode != http.StatusOK {
 		httpError := echo.NewHTTPError(http.StatusInternalServerError, "Failed querying compose status")
 		body, err := io.ReadAll(resp.Body)
@@ -447,13 +735,13 @@ func (h *Handlers) GetComposeMetadata(ctx echo.Context, composeId uuid.UUID) err
 		} else {
 			_ = httpError.SetInternal(fmt.Errorf("%s", body))
 		}
-		return httpError
+		return ComposeMetadata{}, httpError
 	}
 
 	var cloudStat composer.ComposeMetadata
 	err = json.NewDecoder(resp.Body).Decode(&cloudStat)
 	if err != nil {
-		return err
+		return ComposeMetadata{}, err
 	}
 
 	var packages []PackageMetadata
@@ -472,12 +760,42 @@ func (h *Handlers) GetComposeMetadata(ctx echo.Context, composeId uuid.UUID) err
 			}
 		}
 	}
-	status := ComposeMetadata{
+	metadata = ComposeMetadata{
 		OstreeCommit: cloudStat.OstreeCommit,
 		Packages:     &packages,
 	}
 
-	return ctx.JSON(http.StatusOK, status)
+	// the package list is only known once the compose finished, it doesn't change after that
+	if cloudStat.Packages != nil {
+		data, err := json.Marshal(metadata)
+		if err != nil {
+			return ComposeMetadata{}, err
+		}
+		err = h.server.db.InsertComposeMetadata(ctx.Request().Context(), composeId, data)
+		if err != nil {
+			ctx.Logger().Errorf("Unable to snapshot metadata of compose %v: %v", composeId, err)
+		}
+	}
+
+	return metadata, nil
+}
+
+// pinnedPackages formats the packages as name-[epoch:]version-release.arch,
+// which package managers install the exact versions of.
+func pinnedPackages(packages []PackageMetadata) []string {
+	pinned := make([]string, 0, len(packages))
+	for _, pkg := range packages {
+		pinned = append(pinned, nevra(pkg.Name, pkg.Epoch, pkg.Version, pkg.Release, pkg.Arch))
+	}
+	return pinned
+}
+
+// nevra leaves out the epoch when it is 0, as rpm does
+func nevra(name string, epoch *string, version, release, arch string) string {
+	if epoch != nil && *epoch != "" && *epoch != "0" {
+		return fmt.Sprintf("%s-%s:%s-%s.%s", name, *epoch, version, release, arch)
+	}
+	return fmt.Sprintf("%s-%s-%s.%s", name, version, release, arch)
 }
 
 // return compose from the database or error when user does not have composeId associated to its OrgId in the DB
@@ -534,150 +852,315 @@ func (h *Handlers) GetComposes(ctx echo.Context, params GetComposesParams) error
 	if params.Offset != nil {
 		offset = *params.Offset
 	}
-	ignoreImageTypeStrings := convertIgnoreImageTypeToSlice(params.IgnoreImageTypes)
-
-	// composes in the last 14 days
-	composes, count, err := h.server.db.GetComposes(ctx.Request().Context(), userID.OrgID(), (time.Hour * 24 * 14), limit, offset, ignoreImageTypeStrings)
+	filter, filterQuery, err := composeFilter(params)
 	if err != nil {
 		return err
 	}
-
-	data := []ComposesResponseItem{}
-	for _, c := range composes {
-		var cmpr ComposeRequest
-		err = json.Unmarshal(c.Request, &cmpr)
+	if params.Cursor != nil {
+		if params.Offset != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Cursor and offset can't be combined")
+		}
+		after, err := decodeComposeCursor(*params.Cursor)
 		if err != nil {
 			return err
 		}
-		data = append(data, ComposesResponseItem{
+		filter.After = &after
+	}
+	// the requests are the bulk of the rows and have to be decrypted and
+	// decoded, the blueprints have to be joined
+	if params.Fields != nil {
+		filter.SkipRequest = !slices.Contains(*params.Fields, GetComposesParamsFieldsRequest)
+		filter.SkipBlueprint = !slices.Contains(*params.Fields, GetComposesParamsFieldsBlueprintId) &&
+			!slices.Contains(*params.Fields, GetComposesParamsFieldsBlueprintVersion)
+	}
+	composes, count, err := h.server.db.GetComposesFiltered(ctx.Request().Context(), userID.OrgID(), filter, limit, offset)
+	if err != nil {
+		return err
+	}
+
+	data := []ComposesResponseItem{}
+	projected := []ComposesProjectedItem{}
+	for _, c := range composes {
+		item := ComposesResponseItem{
 			CreatedAt:        c.CreatedAt.Format(time.RFC3339),
 			Id:               c.Id,
 			ImageName:        c.ImageName,
+			Description:      c.Description,
 			BlueprintId:      c.BlueprintId,
 			BlueprintVersion: c.BlueprintVersion,
-			Request:          cmpr,
 			ClientId:         (*ClientId)(c.ClientId),
-		})
+			Status:           c.Status,
+		}
+		if c.ExpiresAt != nil {
+			item.ExpiresAt = common.ToPtr(c.ExpiresAt.Format(time.RFC3339))
+		}
+		if !filter.SkipRequest {
+			err = h.server.openComposeRequest(c.Request, &item.Request)
+			if err != nil {
+				return err
+			}
+		}
+		if params.Fields != nil {
+			projected = append(projected, projectComposesResponseItem(item, *params.Fields))
+			continue
+		}
+		data = append(data, item)
 	}
 
+	links := h.newLinksWithExtraParams("composes", count, limit, maps.Clone(filterQuery))
+	// a full page might not be the last one
+	if len(composes) == limit {
+		last := composes[len(composes)-1]
+		cursor, err := encodeComposeCursor(db.ComposeCursor{
+			CreatedAt: last.CreatedAt,
+			Id:        last.Id,
+		})
+		if err != nil {
+			return err
+		}
+		next := url.URL{Path: fmt.Sprintf("%v/v%v/composes", RoutePrefix(), h.server.spec.Info.Version)}
+		query := maps.Clone(filterQuery)
+		query.Set("limit", strconv.Itoa(limit))
+		query.Set("cursor", cursor)
+		next.RawQuery = query.Encode()
+		links.Next = common.ToPtr(next.String())
+	}
+
+	if params.Fields != nil {
+		return ctx.JSON(http.StatusOK, ComposesProjectedResponse{
+			Data:  projected,
+			Meta:  ListResponseMeta{count},
+			Links: links,
+		})
+	}
 	return ctx.JSON(http.StatusOK, ComposesResponse{
 		Data:  data,
 		Meta:  ListResponseMeta{count},
-		Links: h.newLinksWithExtraParams("composes", count, limit, url.Values{}),
+		Links: links,
 	})
 }
 
+// projectComposesResponseItem keeps only the requested fields of the item
+func projectComposesResponseItem(item ComposesResponseItem, fields []GetComposesParamsFields) ComposesProjectedItem {
+	var projection ComposesProjectedItem
+	for _, f := range fields {
+		switch f {
+		case GetComposesParamsFieldsId:
+			projection.Id = &item.Id
+		case GetComposesParamsFieldsCreatedAt:
+			projection.CreatedAt = &item.CreatedAt
+		case GetComposesParamsFieldsImageName:
+			projection.ImageName = item.ImageName
+		case GetComposesParamsFieldsRequest:
+			projection.Request = &item.Request
+		case GetComposesParamsFieldsClientId:
+			projection.ClientId = item.ClientId
+		case GetComposesParamsFieldsBlueprintId:
+			projection.BlueprintId = item.BlueprintId
+		case GetComposesParamsFieldsBlueprintVersion:
+			projection.BlueprintVersion = item.BlueprintVersion
+		case GetComposesParamsFieldsDescription:
+			projection.Description = item.Description
+		case GetComposesParamsFieldsExpiresAt:
+			projection.ExpiresAt = item.ExpiresAt
+		case GetComposesParamsFieldsStatus:
+			projection.Status = item.Status
+		}
+	}
+	return projection
+}
+
+var (
+	awsRegionRegex    = regexp.MustCompile(`^[a-z]{2}(-gov)?-[a-z]+-[0-9]+$`)
+	awsAccountIdRegex = regexp.MustCompile(`^[0-9]{12}$`)
+)
+
 func (h *Handlers) CloneCompose(ctx echo.Context, composeId uuid.UUID) error {
-	err := h.canUserAccessComposeId(ctx, composeId)
+	composeEntry, err := h.getComposeByIdAndOrgId(ctx, composeId)
 	if err != nil {
 		return err
 	}
-
-	userID, err := h.server.getIdentity(ctx)
+	cClient, err := h.server.composerFor(composeEntry.Region)
 	if err != nil {
 		return err
 	}
-	imageType, err := h.server.db.GetComposeImageType(ctx.Request().Context(), composeId, userID.OrgID())
+
+	err = h.checkAWSCompose(ctx, composeId, "Cloning a compose is only available for AWS composes")
 	if err != nil {
-		if errors.Is(err, db.ComposeNotFoundError) {
-			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unable to find compose %v", composeId))
-		}
-		ctx.Logger().Errorf("Error querying image type for compose %v: %v", composeId, err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Something went wrong querying the compose")
+		return err
 	}
 
-	var resp *http.Response
-	var rawCR json.RawMessage
-	if ImageTypes(imageType) == ImageTypesAws || ImageTypes(imageType) == ImageTypesAmi {
-		var awsEC2CloneReq AWSEC2Clone
-		err = ctx.Bind(&awsEC2CloneReq)
-		if err != nil {
-			return err
+	var awsEC2CloneReq AWSEC2Clone
+	err = ctx.Bind(&awsEC2CloneReq)
+	if err != nil {
+		return err
+	}
+	var regions []string
+	switch {
+	case awsEC2CloneReq.Region != nil && awsEC2CloneReq.Regions == nil:
+		regions = []string{*awsEC2CloneReq.Region}
+	case awsEC2CloneReq.Region == nil && awsEC2CloneReq.Regions != nil:
+		regions = *awsEC2CloneReq.Regions
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "Either region or regions has to be given")
+	}
+	// none of the regions is cloned to unless all of them can be
+	for _, region := range regions {
+		if !awsRegionRegex.MatchString(region) {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid AWS region: %s", region))
 		}
-
-		rawCR, err = json.Marshal(awsEC2CloneReq)
+		err = h.server.checkGovCloudClone(region, composeEntry)
 		if err != nil {
 			return err
 		}
+	}
 
-		var shareWithAccounts []string
-		if awsEC2CloneReq.ShareWithAccounts != nil {
-			shareWithAccounts = append(shareWithAccounts, *awsEC2CloneReq.ShareWithAccounts...)
-		}
-
-		if awsEC2CloneReq.ShareWithSources != nil {
-			for _, source := range *awsEC2CloneReq.ShareWithSources {
-				resp, err := h.server.pClient.GetUploadInfo(ctx.Request().Context(), source)
-				if err != nil {
-					ctx.Logger().Error(err)
-					return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unable to request source: %s", source))
-				}
-				defer closeBody(ctx, resp.Body)
+	var shareWithAccounts []string
+	if awsEC2CloneReq.ShareWithAccounts != nil {
+		shareWithAccounts = append(shareWithAccounts, *awsEC2CloneReq.ShareWithAccounts...)
+	}
 
-				var uploadInfo provisioning.V1SourceUploadInfoResponse
-				err = json.NewDecoder(resp.Body).Decode(&uploadInfo)
-				if err != nil {
-					return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Unable to resolve source: %s", source))
-				}
+	if awsEC2CloneReq.ShareWithSources != nil {
+		for _, source := range *awsEC2CloneReq.ShareWithSources {
+			resp, err := h.server.pClient.GetUploadInfo(ctx.Request().Context(), source)
+			if err != nil {
+				ctx.Logger().Error(err)
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unable to request source: %s", source))
+			}
+			defer closeBody(ctx, resp.Body)
 
-				if uploadInfo.Aws == nil || uploadInfo.Aws.AccountId == nil || len(*uploadInfo.Aws.AccountId) != 12 {
-					return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unable to resolve source %s to an aws account id: %v", source, uploadInfo.Aws.AccountId))
-				}
+			var uploadInfo provisioning.V1SourceUploadInfoResponse
+			err = json.NewDecoder(resp.Body).Decode(&uploadInfo)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Unable to resolve source: %s", source))
+			}
 
-				ctx.Logger().Info(fmt.Sprintf("Resolved source %s, to account id %s", strings.Replace(source, "\n", "", -1), *uploadInfo.Aws.AccountId))
-				shareWithAccounts = append(shareWithAccounts, *uploadInfo.Aws.AccountId)
+			if uploadInfo.Aws == nil || uploadInfo.Aws.AccountId == nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unable to resolve source %s to an aws account id", source))
 			}
+
+			ctx.Logger().Info(fmt.Sprintf("Resolved source %s, to account id %s", strings.Replace(source, "\n", "", -1), *uploadInfo.Aws.AccountId))
+			shareWithAccounts = append(shareWithAccounts, *uploadInfo.Aws.AccountId)
 		}
+	}
 
-		var ccb composer.CloneComposeBody
-		err = ccb.FromAWSEC2CloneCompose(composer.AWSEC2CloneCompose{
-			Region:            awsEC2CloneReq.Region,
-			ShareWithAccounts: &shareWithAccounts,
-		})
-		if err != nil {
-			return err
+	for _, account := range shareWithAccounts {
+		if !awsAccountIdRegex.MatchString(account) {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid AWS account id: %s", account))
 		}
+	}
 
-		resp, err = h.server.cClient.CloneCompose(composeId, ccb)
+	// the clones of the following regions are children of the first one,
+	// every clone records the request of its own region
+	var ids []uuid.UUID
+	var parentId *uuid.UUID
+	for _, region := range regions {
+		regionReq := awsEC2CloneReq
+		regionReq.Region = common.ToPtr(region)
+		regionReq.Regions = nil
+		cloneId, err := h.cloneComposeToRegion(ctx, cClient, composeId, regionReq, shareWithAccounts, parentId)
 		if err != nil {
-			return err
+			if len(ids) == 0 {
+				return err
+			}
+			// the clones already created keep going, the client needs them
+			// to follow their status
+			ctx.Logger().Errorf("Cloning compose %v stopped at region %s: %v", composeId, region, err)
+			message := "Something went wrong creating the clone"
+			var httpErr *echo.HTTPError
+			if errors.As(err, &httpErr) {
+				message = fmt.Sprint(httpErr.Message)
+			}
+			return ctx.JSON(http.StatusMultiStatus, CloneResponse{
+				Id:           ids[0],
+				Ids:          ids,
+				FailedRegion: common.ToPtr(region),
+				Error:        common.ToPtr(message),
+			})
+		}
+		if parentId == nil {
+			parentId = common.ToPtr(cloneId)
 		}
-	} else {
-		return echo.NewHTTPError(http.StatusBadRequest, "Cloning a compose is only available for AWS composes")
+		ids = append(ids, cloneId)
 	}
 
+	return ctx.JSON(http.StatusCreated, CloneResponse{
+		Id:  ids[0],
+		Ids: ids,
+	})
+}
+
+// checkAWSCompose rejects composes of other image types than aws with the
+// message.
+func (h *Handlers) checkAWSCompose(ctx echo.Context, composeId uuid.UUID, message string) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	imageType, err := h.server.db.GetComposeImageType(ctx.Request().Context(), composeId, userID.OrgID())
+	if err != nil {
+		if errors.Is(err, db.ComposeNotFoundError) {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unable to find compose %v", composeId))
+		}
+		ctx.Logger().Errorf("Error querying image type for compose %v: %v", composeId, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Something went wrong querying the compose")
+	}
+	if canonicalImageType(ImageTypes(imageType)) != ImageTypesAws {
+		return echo.NewHTTPError(http.StatusBadRequest, message)
+	}
+	return nil
+}
+
+// cloneComposeToRegion starts the clone of a single region in composer and
+// records it.
+func (h *Handlers) cloneComposeToRegion(ctx echo.Context, cClient *composer.ComposerClient, composeId uuid.UUID, awsEC2CloneReq AWSEC2Clone, shareWithAccounts []string, parentId *uuid.UUID) (uuid.UUID, error) {
+	rawCR, err := json.Marshal(awsEC2CloneReq)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	var ccb composer.CloneComposeBody
+	err = ccb.FromAWSEC2CloneCompose(composer.AWSEC2CloneCompose{
+		Region:            *awsEC2CloneReq.Region,
+		ShareWithAccounts: &shareWithAccounts,
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	resp, err := cClient.CloneCompose(composeId, ccb)
+	if err != nil {
+		return uuid.Nil, err
+	}
 	if resp == nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Something went wrong creating the clone")
+		return uuid.Nil, echo.NewHTTPError(http.StatusInternalServerError, "Something went wrong creating the clone")
 	}
 	defer closeBody(ctx, resp.Body)
 	if resp.StatusCode != http.StatusCreated {
 		var cError composer.Error
 		err = json.NewDecoder(resp.Body).Decode(&cError)
 		if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, "Unable to parse error returned by image-builder-composer service")
+			return uuid.Nil, echo.NewHTTPError(http.StatusInternalServerError, "Unable to parse error returned by image-builder-composer service")
 		}
 		if cError.Code == ComposeRunningOrFailedError {
-			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("image-builder-composer compose failed: %s", cError.Reason))
+			return uuid.Nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("image-builder-composer compose failed: %s", cError.Reason))
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("image-builder-composer service returned an error: %s", cError.Reason))
+		return uuid.Nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("image-builder-composer service returned an error: %s", cError.Reason))
 	}
 
 	var cloneResponse composer.CloneComposeResponse
 	err = json.NewDecoder(resp.Body).Decode(&cloneResponse)
 	if err != nil {
 		ctx.Logger().Errorf("Unable to decode CloneComposeResponse: %v", err)
-		return err
+		return uuid.Nil, err
 	}
 
-	err = h.server.db.InsertClone(ctx.Request().Context(), composeId, cloneResponse.Id, rawCR)
+	err = h.server.db.InsertClone(ctx.Request().Context(), composeId, cloneResponse.Id, parentId, rawCR)
 	if err != nil {
 		ctx.Logger().Errorf("Error inserting clone into db for compose %v: %v", err, composeId)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Something went wrong saving the clone")
+		return uuid.Nil, echo.NewHTTPError(http.StatusInternalServerError, "Something went wrong saving the clone")
 	}
-
-	return ctx.JSON(http.StatusCreated, CloneResponse{
-		Id: cloneResponse.Id,
-	})
+	return cloneResponse.Id, nil
 }
 
 func (h *Handlers) GetCloneStatus(ctx echo.Context, id uuid.UUID) error {
@@ -698,33 +1181,68 @@ func (h *Handlers) GetCloneStatus(ctx echo.Context, id uuid.UUID) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Requested clone cannot be found")
 	}
 
-	resp, err := h.server.cClient.CloneStatus(id)
+	// clones run on the composer of the cloned compose
+	composeEntry, err := h.server.db.GetCompose(ctx.Request().Context(), cloneEntry.ComposeId, userID.OrgID())
+	if err != nil {
+		ctx.Logger().Errorf("Error querying compose %v of clone %v: %v", cloneEntry.ComposeId, id, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Something went wrong querying this clone")
+	}
+	status, err := h.cloneStatus(ctx, cloneEntry, composeEntry)
 	if err != nil {
-		ctx.Logger().Errorf("Error requesting clone status for clone %v: %v", id, err)
 		return err
 	}
+	return ctx.JSON(http.StatusOK, status)
+}
+
+// cloneStatus asks composer for the status of the clone, unless it finished
+// already. The final status doesn't change anymore, it's recorded and served
+// from the db from then on, also in read-only mode.
+func (h *Handlers) cloneStatus(ctx echo.Context, cloneEntry *db.CloneEntry, composeEntry *db.ComposeEntry) (CloneStatusResponse, error) {
+	if cloneEntry.UploadStatus != nil {
+		var status CloneStatusResponse
+		err := json.Unmarshal(cloneEntry.UploadStatus, &status)
+		if err != nil {
+			return CloneStatusResponse{}, err
+		}
+		return status, nil
+	}
+
+	if err := h.server.readOnlyError(); err != nil {
+		return CloneStatusResponse{}, err
+	}
+
+	id := cloneEntry.Id
+	cClient, err := h.server.composerFor(composeEntry.Region)
+	if err != nil {
+		return CloneStatusResponse{}, err
+	}
+	resp, err := cClient.CloneStatus(id)
+	if err != nil {
+		ctx.Logger().Errorf("Error requesting clone status for clone %v: %v", id, err)
+		return CloneStatusResponse{}, err
+	}
 	defer closeBody(ctx, resp.Body)
 	if resp.StatusCode != http.StatusOK {
 		var cErr composer.Error
 		err = json.NewDecoder(resp.Body).Decode(&cErr)
 		if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, "Unable to parse composer error")
+			return CloneStatusResponse{}, echo.NewHTTPError(http.StatusInternalServerError, "Unable to parse composer error")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Unable to create clone job: %v", cErr.Reason))
+		return CloneStatusResponse{}, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Unable to create clone job: %v", cErr.Reason))
 	}
 
 	var cloudStat composer.CloneStatus
 	err = json.NewDecoder(resp.Body).Decode(&cloudStat)
 	if err != nil {
 		ctx.Logger().Errorf("Unable to decode clone status: %v", err)
-		return err
+		return CloneStatusResponse{}, err
 	}
 
 	var options CloneStatusResponse_Options
 	uo, err := cloudStat.Options.AsAWSEC2UploadStatus()
 	if err != nil {
 		ctx.Logger().Errorf("Unable to decode clone status: %v", err)
-		return err
+		return CloneStatusResponse{}, err
 	}
 
 	err = options.FromAWSUploadStatus(AWSUploadStatus{
@@ -733,19 +1251,31 @@ func (h *Handlers) GetCloneStatus(ctx echo.Context, id uuid.UUID) error {
 	})
 	if err != nil {
 		ctx.Logger().Errorf("Unable to encode clone status: %v", err)
-		return err
+		return CloneStatusResponse{}, err
 	}
 
-	return ctx.JSON(http.StatusOK, CloneStatusResponse{
+	status := CloneStatusResponse{
 		ComposeId: &cloneEntry.ComposeId,
 		Status:    CloneStatusResponseStatus(cloudStat.Status),
 		Type:      UploadTypes(cloudStat.Type),
 		Options:   options,
-	})
+	}
+	if status.Status == CloneStatusResponseStatusSuccess || status.Status == CloneStatusResponseStatusFailure {
+		uploadStatus, err := json.Marshal(status)
+		if err != nil {
+			return CloneStatusResponse{}, err
+		}
+		err = h.server.db.SetCloneStatus(ctx.Request().Context(), id, string(status.Status), uploadStatus)
+		if err != nil {
+			ctx.Logger().Errorf("Error recording the status of clone %v: %v", id, err)
+			return CloneStatusResponse{}, err
+		}
+	}
+	return status, nil
 }
 
 func (h *Handlers) GetComposeClones(ctx echo.Context, composeId uuid.UUID, params GetComposeClonesParams) error {
-	err := h.canUserAccessComposeId(ctx, composeId)
+	composeEntry, err := h.getComposeByIdAndOrgId(ctx, composeId)
 	if err != nil {
 		return err
 	}
@@ -772,19 +1302,35 @@ func (h *Handlers) GetComposeClones(ctx echo.Context, composeId uuid.UUID, param
 	}
 
 	data := []ClonesResponseItem{}
-	for _, c := range cloneEntries {
+	for i, c := range cloneEntries {
 		var cr CloneRequest
 		err = json.Unmarshal(c.Request, &cr)
 		if err != nil {
 			return echo.NewHTTPError(
 				http.StatusInternalServerError, "Something went wrong querying clones for this compose")
 		}
-		data = append(data, ClonesResponseItem{
+		item := ClonesResponseItem{
 			Id:        c.Id,
 			ComposeId: composeId,
+			ParentId:  c.ParentId,
 			Request:   cr,
 			CreatedAt: c.CreatedAt.Format(time.RFC3339),
-		})
+		}
+		// a clone composer can't tell about doesn't fail the listing
+		status, err := h.cloneStatus(ctx, &cloneEntries[i], composeEntry)
+		if err != nil {
+			ctx.Logger().Warnf("Unable to get the status of clone %v: %v", c.Id, err)
+		} else {
+			item.Status = &status
+		}
+		data = append(data, item)
+	}
+
+	// counted after the statuses of the listed clones were recorded
+	counts, err := h.server.db.CountClonesByStatus(ctx.Request().Context(), composeId, userID.OrgID())
+	if err != nil {
+		ctx.Logger().Errorf("Error counting clones for compose %v: %v", composeId, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Something went wrong querying clones for this compose")
 	}
 
 	lastOffset := count - 1
@@ -800,12 +1346,17 @@ func (h *Handlers) GetComposeClones(ctx echo.Context, composeId uuid.UUID, param
 	return ctx.JSON(http.StatusOK, ClonesResponse{
 		Meta: ListResponseMeta{count},
 		Links: ListResponseLinks{
-			fmt.Sprintf("%v/v%v/composes/%v/clones?offset=%v&limit=%v",
+			First: fmt.Sprintf("%v/v%v/composes/%v/clones?offset=%v&limit=%v",
 				RoutePrefix(), spec.Info.Version, composeId, 0, limit),
-			fmt.Sprintf("%v/v%v/composes/%v/clones?offset=%v&limit=%v",
+			Last: fmt.Sprintf("%v/v%v/composes/%v/clones?offset=%v&limit=%v",
 				RoutePrefix(), spec.Info.Version, composeId, lastOffset, limit),
 		},
 		Data: data,
+		Summary: ClonesSummary{
+			Success:    counts.Success,
+			Failure:    counts.Failure,
+			Unfinished: counts.Unfinished,
+		},
 	})
 }
 
@@ -0,0 +1,189 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/labstack/echo/v4"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/distribution"
+)
+
+// majorVersionPart matches the parts of repository URLs naming the major
+// version of a distribution, like 8 in epel/8/Everything, el8, rhel-8 or
+// RPM-GPG-KEY-EPEL-8.
+var majorVersionPart = regexp.MustCompile(`^(.*-|(?i:el|rhel|epel|centos|fc))?(\d+)$`)
+
+// DuplicateBlueprint copies the latest version of a blueprint, building
+// another distribution if asked to. Unlike retargeting, any distribution
+// available to the org can be picked, older releases included.
+func (h *Handlers) DuplicateBlueprint(ctx echo.Context, blueprintId openapi_types.UUID) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	var request DuplicateBlueprintJSONRequestBody
+	err = ctx.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	blueprintEntry, err := h.getBlueprintToChange(ctx, userID, blueprintId, nil)
+	if err != nil {
+		if errors.Is(err, db.BlueprintNotFoundError) {
+			return echo.NewHTTPError(http.StatusNotFound, err)
+		}
+		return err
+	}
+	blueprint, err := BlueprintFromEntry(blueprintEntry)
+	if err != nil {
+		return err
+	}
+
+	distro := blueprint.Distribution
+	if request.Distribution != nil {
+		distro = *request.Distribution
+	}
+	target, err := h.server.getDistro(ctx, distro)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s (copy)", blueprintEntry.Name)
+	if distro != blueprint.Distribution {
+		name = fmt.Sprintf("%s (%s)", blueprintEntry.Name, distro)
+	}
+	if request.Name != nil {
+		name = *request.Name
+	}
+	if !blueprintNameRegex.MatchString(name) {
+		return ctx.JSON(http.StatusUnprocessableEntity, HTTPErrorList{
+			Errors: []HTTPError{{
+				Title:  "Invalid blueprint name",
+				Detail: blueprintInvalidNameDetail,
+			}},
+		})
+	}
+
+	report := blueprintCompatibility(blueprint, target)
+	remapped := []RepositoryRemapping{}
+	if request.RemapRepositories == nil || *request.RemapRepositories {
+		remapped = remapRepositories(&blueprint.Customizations, blueprint.Distribution, distro)
+	}
+	blueprint.Distribution = distro
+	ctx.Logger().Infof("Duplicating blueprint %s for %s as %s, for orgID: %s", blueprintId, distro, name, userID.OrgID())
+	id, err := h.insertBlueprintCopy(ctx, userID, blueprintEntry, name, blueprint)
+	if err != nil {
+		var e *pgconn.PgError
+		if errors.As(err, &e) && e.Code == pgerrcode.UniqueViolation {
+			return ctx.JSON(http.StatusUnprocessableEntity, HTTPErrorList{
+				Errors: []HTTPError{{
+					Title:  "Name not unique",
+					Detail: "A blueprint with the same name already exists.",
+				}},
+			})
+		}
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, DuplicateBlueprintResponse{
+		Id:                   id,
+		Report:               report,
+		RemappedRepositories: remapped,
+	})
+}
+
+// remapRepositories points the custom and payload repositories of the
+// customizations at the major version of the new distribution, when it
+// changes. The URLs which changed are returned.
+func remapRepositories(cust *Customizations, from, to Distributions) []RepositoryRemapping {
+	remapped := []RepositoryRemapping{}
+	fromMajor, ok := distribution.MajorVersion(string(from))
+	if !ok {
+		return remapped
+	}
+	toMajor, ok := distribution.MajorVersion(string(to))
+	if !ok || fromMajor == toMajor {
+		return remapped
+	}
+
+	remap := func(u *string) {
+		if u == nil {
+			return
+		}
+		r := remapURL(*u, fromMajor, toMajor)
+		if r != *u {
+			remapped = append(remapped, RepositoryRemapping{From: *u, To: r})
+			*u = r
+		}
+	}
+	remapAll := func(urls *[]string) {
+		if urls == nil {
+			return
+		}
+		for i := range *urls {
+			remap(&(*urls)[i])
+		}
+	}
+	if cust.CustomRepositories != nil {
+		for i := range *cust.CustomRepositories {
+			repo := &(*cust.CustomRepositories)[i]
+			remapAll(repo.Baseurl)
+			remapAll(repo.Gpgkey)
+			remap(repo.Metalink)
+			remap(repo.Mirrorlist)
+		}
+	}
+	if cust.PayloadRepositories != nil {
+		for i := range *cust.PayloadRepositories {
+			repo := &(*cust.PayloadRepositories)[i]
+			remap(repo.Baseurl)
+			remap(repo.Gpgkey)
+			remap(repo.Metalink)
+			remap(repo.Mirrorlist)
+		}
+	}
+	return remapped
+}
+
+// remapURL replaces the major version in the path and the query parameters
+// of the URL, the scheme and the host stay. GPG keys given as the keys
+// themselves aren't URLs and stay too.
+func remapURL(raw string, from, to int) string {
+	if !strings.Contains(raw, "://") {
+		return raw
+	}
+	location, query, hasQuery := strings.Cut(raw, "?")
+	parts := strings.Split(location, "/")
+	for i := 3; i < len(parts); i++ {
+		parts[i] = remapMajorVersion(parts[i], from, to)
+	}
+	remapped := strings.Join(parts, "/")
+	if hasQuery {
+		params := strings.Split(query, "&")
+		for i, p := range params {
+			if k, v, ok := strings.Cut(p, "="); ok {
+				params[i] = k + "=" + remapMajorVersion(v, from, to)
+			}
+		}
+		remapped += "?" + strings.Join(params, "&")
+	}
+	return remapped
+}
+
+func remapMajorVersion(part string, from, to int) string {
+	m := majorVersionPart.FindStringSubmatch(part)
+	if m == nil || m[2] != strconv.Itoa(from) {
+		return part
+	}
+	return m[1] + strconv.Itoa(to)
+}
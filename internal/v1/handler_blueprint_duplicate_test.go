@@ -0,0 +1,102 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestRemapRepositories(t *testing.T) {
+	cust := Customizations{
+		CustomRepositories: &[]CustomRepository{
+			{
+				Id:       "epel",
+				Baseurl:  &[]string{"https://dl.fedoraproject.org/pub/epel/8/Everything/x86_64/"},
+				Gpgkey:   &[]string{"https://dl.fedoraproject.org/pub/epel/RPM-GPG-KEY-EPEL-8", "-----BEGIN PGP PUBLIC KEY BLOCK-----"},
+				Metalink: common.ToPtr("https://mirrors.fedoraproject.org/metalink?repo=epel-8&arch=$basearch"),
+			},
+		},
+		PayloadRepositories: &[]Repository{
+			{Baseurl: common.ToPtr("https://repo.example.com/el8/x86_64/")},
+			// the host and other numbers stay
+			{Baseurl: common.ToPtr("https://rhel8.example.com/releases/2024-08/x86_64/")},
+		},
+	}
+
+	remapped := remapRepositories(&cust, Rhel89, Rhel94)
+	require.Equal(t, []RepositoryRemapping{
+		{From: "https://dl.fedoraproject.org/pub/epel/8/Everything/x86_64/", To: "https://dl.fedoraproject.org/pub/epel/9/Everything/x86_64/"},
+		{From: "https://dl.fedoraproject.org/pub/epel/RPM-GPG-KEY-EPEL-8", To: "https://dl.fedoraproject.org/pub/epel/RPM-GPG-KEY-EPEL-9"},
+		{From: "https://mirrors.fedoraproject.org/metalink?repo=epel-8&arch=$basearch", To: "https://mirrors.fedoraproject.org/metalink?repo=epel-9&arch=$basearch"},
+		{From: "https://repo.example.com/el8/x86_64/", To: "https://repo.example.com/el9/x86_64/"},
+	}, remapped)
+	require.Equal(t, "-----BEGIN PGP PUBLIC KEY BLOCK-----", (*(*cust.CustomRepositories)[0].Gpgkey)[1])
+	require.Equal(t, "https://rhel8.example.com/releases/2024-08/x86_64/", *(*cust.PayloadRepositories)[1].Baseurl)
+
+	// nothing to remap within a major version or without one
+	require.Empty(t, remapRepositories(&cust, Rhel93, Rhel94))
+	require.Empty(t, remapRepositories(&cust, Rhel9Nightly, Rhel810))
+}
+
+func TestDuplicateBlueprint(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	startTestServer(t, &testServerClientsConf{}, &ServerConfig{
+		DBase:            dbase,
+		DistributionsDir: "../../distributions",
+	})
+
+	blueprintId := uuid.New()
+	err = dbase.InsertBlueprint(ctx, blueprintId, uuid.New(), "000000", "500000", "golden", "golden image",
+		json.RawMessage(`{"distribution": "rhel-89", "image_requests": [{"architecture": "x86_64", "image_type": "aws"}], "customizations": {"packages": ["vim-enhanced"], "payload_repositories": [{"baseurl": "https://dl.fedoraproject.org/pub/epel/8/Everything/x86_64/", "rhsm": false}]}}`), nil, db.BlueprintOptions{})
+	require.NoError(t, err)
+	url := fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s/duplicate", blueprintId)
+
+	respStatusCode, body := tutils.PostResponseBody(t, url, DuplicateBlueprintRequest{Distribution: common.ToPtr(Rhel94)})
+	require.Equal(t, http.StatusCreated, respStatusCode, body)
+	var result DuplicateBlueprintResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Equal(t, []RepositoryRemapping{
+		{From: "https://dl.fedoraproject.org/pub/epel/8/Everything/x86_64/", To: "https://dl.fedoraproject.org/pub/epel/9/Everything/x86_64/"},
+	}, result.RemappedRepositories)
+	require.Empty(t, result.Report.MissingPackages)
+
+	respStatusCode, body = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s", result.Id), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var blueprint BlueprintResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &blueprint))
+	require.Equal(t, "golden (rhel-94)", blueprint.Name)
+	require.Equal(t, "golden image", blueprint.Description)
+	require.Equal(t, Rhel94, blueprint.Distribution)
+	require.Equal(t, "https://dl.fedoraproject.org/pub/epel/9/Everything/x86_64/", *(*blueprint.Customizations.PayloadRepositories)[0].Baseurl)
+
+	// a plain copy, or one keeping the repositories
+	respStatusCode, body = tutils.PostResponseBody(t, url, DuplicateBlueprintRequest{})
+	require.Equal(t, http.StatusCreated, respStatusCode, body)
+	respStatusCode, body = tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/blueprints?name=golden%20(copy)", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var copies BlueprintsResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &copies))
+	require.Equal(t, 1, copies.Meta.Count)
+	respStatusCode, body = tutils.PostResponseBody(t, url, DuplicateBlueprintRequest{Distribution: common.ToPtr(Centos9), RemapRepositories: common.ToPtr(false)})
+	require.Equal(t, http.StatusCreated, respStatusCode, body)
+	result = DuplicateBlueprintResponse{}
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Empty(t, result.RemappedRepositories)
+
+	// the name is taken now
+	respStatusCode, _ = tutils.PostResponseBody(t, url, DuplicateBlueprintRequest{})
+	require.Equal(t, http.StatusUnprocessableEntity, respStatusCode)
+	respStatusCode, _ = tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s/duplicate", uuid.New()), DuplicateBlueprintRequest{})
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+}
@@ -0,0 +1,155 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+const (
+	// how far back the last compose of an image is looked for
+	outdatedLookback = 90 * 24 * time.Hour
+	outdatedComposes = 100
+)
+
+// GetBlueprintOutdated depsolves the images of the latest blueprint version
+// and compares the packages with the ones of their last successful composes.
+// Images without one are reported as not outdated, there is nothing to
+// compare with.
+func (h *Handlers) GetBlueprintOutdated(ctx echo.Context, id openapi_types.UUID) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	blueprintEntry, err := h.getBlueprint(ctx, userID, id, nil)
+	if err != nil {
+		if errors.Is(err, db.BlueprintNotFoundError) {
+			return echo.NewHTTPError(http.StatusNotFound, err)
+		}
+		return err
+	}
+	blueprint, err := BlueprintFromEntry(blueprintEntry)
+	if err != nil {
+		return err
+	}
+	lastComposes, err := h.lastSuccessfulComposes(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	response := BlueprintOutdatedResponse{
+		Images: []BlueprintOutdatedImage{},
+	}
+	for _, imageRequest := range blueprint.ImageRequests {
+		for _, split := range splitImageRequest(imageRequest) {
+			image, err := h.outdatedImage(ctx, blueprintEntry, blueprint, split, lastComposes)
+			if err != nil {
+				return err
+			}
+			response.Outdated = response.Outdated || image.Outdated
+			response.Images = append(response.Images, image)
+		}
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// outdatedImage compares the image request with its last compose, if it has
+// one with packages.
+func (h *Handlers) outdatedImage(ctx echo.Context, blueprintEntry *db.BlueprintEntry, blueprint BlueprintBody, imageRequest ImageRequest, lastComposes map[string]*db.ComposeEntry) (BlueprintOutdatedImage, error) {
+	image := BlueprintOutdatedImage{
+		ImageType:    imageRequest.ImageType,
+		Architecture: string(imageRequest.Architecture),
+	}
+	composeEntry, ok := lastComposes[outdatedKey(imageRequest.ImageType, string(imageRequest.Architecture))]
+	if !ok {
+		return image, nil
+	}
+	image.ComposeId = &composeEntry.Id
+	built, err := h.builtPackages(ctx, composeEntry)
+	if err != nil || built == nil {
+		return image, err
+	}
+	rebuilt, err := h.rebuiltPackages(ctx, blueprintEntry, blueprint, imageRequest)
+	if err != nil {
+		return image, err
+	}
+	diff := packageDiff(built, rebuilt)
+	image.Packages = &diff
+	image.Outdated = len(diff.Added) > 0 || len(diff.Removed) > 0 || len(diff.Changed) > 0
+	return image, nil
+}
+
+func outdatedKey(imageType ImageTypes, arch string) string {
+	return string(canonicalImageType(imageType)) + "/" + arch
+}
+
+// lastSuccessfulComposes returns the newest successful compose of every image
+// type and architecture the blueprint was built for, of any of its versions.
+func (h *Handlers) lastSuccessfulComposes(ctx echo.Context, userID *Identity, blueprintId openapi_types.UUID) (map[string]*db.ComposeEntry, error) {
+	composes, err := h.server.db.GetBlueprintComposes(ctx.Request().Context(), userID.OrgID(), blueprintId, nil, outdatedLookback, outdatedComposes, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	last := map[string]*db.ComposeEntry{}
+	for _, c := range composes {
+		var composeRequest ComposeRequest
+		err = h.server.openComposeRequest(c.Request, &composeRequest)
+		if err != nil {
+			return nil, err
+		}
+		imageRequest := composeRequest.ImageRequests[0]
+		key := outdatedKey(imageRequest.ImageType, string(imageRequest.Architecture))
+		if _, ok := last[key]; ok {
+			continue
+		}
+		// the list of the blueprint composes comes without their status
+		composeEntry, err := h.server.db.GetCompose(ctx.Request().Context(), c.Id, userID.OrgID())
+		if err != nil {
+			return nil, err
+		}
+		if common.FromPtr(composeEntry.Status) == string(ImageStatusStatusSuccess) {
+			last[key] = composeEntry
+		}
+	}
+	return last, nil
+}
+
+// rebuiltPackages depsolves the image request of the blueprint the way it is
+// composed.
+func (h *Handlers) rebuiltPackages(ctx echo.Context, blueprintEntry *db.BlueprintEntry, blueprint BlueprintBody, imageRequest ImageRequest) ([]PackageMetadata, error) {
+	composeRequest := ComposeRequest{
+		Customizations:   &blueprint.Customizations,
+		Distribution:     blueprint.Distribution,
+		ImageRequests:    []ImageRequest{imageRequest},
+		ImageName:        &blueprintEntry.Name,
+		ImageDescription: &blueprintEntry.Description,
+	}
+	prepared, err := h.prepareCompose(ctx, &composeRequest)
+	if err != nil {
+		return nil, err
+	}
+	depsolved, err := h.depsolvePackages(ctx, prepared.composerRequest)
+	if err != nil {
+		return nil, err
+	}
+	packages := make([]PackageMetadata, 0, len(depsolved))
+	for _, pkg := range depsolved {
+		packages = append(packages, PackageMetadata{
+			Name:    pkg.Name,
+			Epoch:   pkg.Epoch,
+			Version: pkg.Version,
+			Release: pkg.Release,
+			Arch:    pkg.Arch,
+			Type:    pkg.Type,
+		})
+	}
+	return packages, nil
+}
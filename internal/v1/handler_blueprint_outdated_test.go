@@ -0,0 +1,138 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestHandlers_BlueprintOutdated(t *testing.T) {
+	ctx := context.Background()
+
+	var depsolveRequest composer.DepsolveRequest
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.Equal(t, "/api/image-builder-composer/v2/depsolve/blueprint", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&depsolveRequest))
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(`{"packages": [
+			{"type": "rpm", "name": "bash", "version": "5.1.8", "release": "6.el9", "arch": "x86_64"},
+			{"type": "rpm", "name": "openssl", "version": "3.0.7", "release": "27.el9", "epoch": "1", "arch": "x86_64"}
+		]}`))
+		require.NoError(t, err)
+	}))
+	defer apiSrv.Close()
+
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	startTestServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
+		DBase:            dbase,
+		DistributionsDir: "../../distributions",
+	})
+
+	uploadOptions := UploadRequest_Options{}
+	require.NoError(t, uploadOptions.FromAWSUploadRequestOptions(AWSUploadRequestOptions{
+		ShareWithAccounts: common.ToPtr([]string{"test-account"}),
+	}))
+	body, err := json.Marshal(BlueprintBody{
+		Customizations: Customizations{
+			Packages: common.ToPtr([]string{"openssl"}),
+		},
+		Distribution: "centos-9",
+		ImageRequests: []ImageRequest{
+			{
+				Architecture: ImageRequestArchitectureX8664,
+				ImageType:    ImageTypesAws,
+				UploadRequest: UploadRequest{
+					Type:    UploadTypesAws,
+					Options: uploadOptions,
+				},
+			},
+			{
+				Architecture: ImageRequestArchitectureAarch64,
+				ImageType:    ImageTypesGuestImage,
+				UploadRequest: UploadRequest{
+					Type:    UploadTypesAwsS3,
+					Options: uploadOptions,
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	blueprintId := uuid.New()
+	versionId := uuid.New()
+	require.NoError(t, dbase.InsertBlueprint(ctx, blueprintId, versionId, "000000", "500000", "outdated", "", body, nil, db.BlueprintOptions{}))
+
+	insertCompose := func(status ImageStatusStatus, packages []PackageMetadata) uuid.UUID {
+		id := uuid.New()
+		request := `{"distribution": "centos-9", "image_requests": [{"architecture": "x86_64", "image_type": "aws", "upload_request": {"type": "aws", "options": {}}}]}`
+		require.NoError(t, dbase.InsertCompose(ctx, id, "500000", "user@user.user", "000000", nil, json.RawMessage(request), nil, &versionId, nil, nil))
+		require.NoError(t, dbase.SetComposeStatus(ctx, id, string(status), nil))
+		metadata, err := json.Marshal(ComposeMetadata{Packages: &packages})
+		require.NoError(t, err)
+		require.NoError(t, dbase.InsertComposeMetadata(ctx, id, metadata))
+		return id
+	}
+	bash := PackageMetadata{Name: "bash", Arch: "x86_64", Version: "5.1.8", Release: "6.el9"}
+	openssl := PackageMetadata{Name: "openssl", Arch: "x86_64", Version: "3.0.7", Release: "24.el9", Epoch: common.ToPtr("1")}
+	succeeded := insertCompose(ImageStatusStatusSuccess, []PackageMetadata{bash, openssl})
+	// newer composes which failed don't count
+	insertCompose(ImageStatusStatusFailure, nil)
+
+	respStatusCode, respBody := tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s/outdated", blueprintId), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode, respBody)
+	var result BlueprintOutdatedResponse
+	require.NoError(t, json.Unmarshal([]byte(respBody), &result))
+	require.True(t, result.Outdated)
+	require.Equal(t, []BlueprintOutdatedImage{
+		{
+			ImageType:    ImageTypesAws,
+			Architecture: "x86_64",
+			ComposeId:    &succeeded,
+			Outdated:     true,
+			Packages: &ComposePackageDiff{
+				Added:   []string{},
+				Removed: []string{},
+				Changed: []ComposePackageChange{
+					{Name: "openssl", Arch: "x86_64", From: "1:3.0.7-24.el9", To: "1:3.0.7-27.el9"},
+				},
+			},
+		},
+		{
+			// never built, nothing to compare with
+			ImageType:    ImageTypesGuestImage,
+			Architecture: "aarch64",
+		},
+	}, result.Images)
+	require.Equal(t, []composer.Package{{Name: "openssl"}}, *depsolveRequest.Blueprint.Packages)
+	require.Equal(t, "x86_64", *depsolveRequest.Architecture)
+
+	// up to date once the last compose has the packages a rebuild installs
+	openssl.Release = "27.el9"
+	latest := insertCompose(ImageStatusStatusSuccess, []PackageMetadata{bash, openssl})
+	respStatusCode, respBody = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s/outdated", blueprintId), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode, respBody)
+	result = BlueprintOutdatedResponse{}
+	require.NoError(t, json.Unmarshal([]byte(respBody), &result))
+	require.False(t, result.Outdated)
+	require.Equal(t, latest, *result.Images[0].ComposeId)
+	require.Empty(t, result.Images[0].Packages.Changed)
+
+	// blueprints of other orgs are not found
+	respStatusCode, _ = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s/outdated", blueprintId), &tutils.AuthString1)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+}
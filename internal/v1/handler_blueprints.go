@@ -31,13 +31,18 @@ type BlueprintBody struct {
 	Customizations Customizations `json:"customizations"`
 	Distribution   Distributions  `json:"distribution"`
 	ImageRequests  []ImageRequest `json:"image_requests"`
+	// the lifecycle collector queries the policy by its json name
+	Lifecycle          *BlueprintLifecycle `json:"lifecycle,omitempty"`
+	LaunchRequirements *LaunchRequirements `json:"launch_requirements,omitempty"`
 }
 
 func BlueprintFromAPI(cbr CreateBlueprintRequest) BlueprintBody {
 	return BlueprintBody{
-		Customizations: cbr.Customizations,
-		Distribution:   cbr.Distribution,
-		ImageRequests:  cbr.ImageRequests,
+		Customizations:     cbr.Customizations,
+		Distribution:       cbr.Distribution,
+		ImageRequests:      cbr.ImageRequests,
+		Lifecycle:          cbr.Lifecycle,
+		LaunchRequirements: cbr.LaunchRequirements,
 	}
 }
 
@@ -51,6 +56,76 @@ func BlueprintFromEntry(be *db.BlueprintEntry) (BlueprintBody, error) {
 	return result, nil
 }
 
+// getBlueprint returns the blueprint when the user sees it, the private
+// blueprints of others and the ones of workspaces they aren't a member of
+// aren't found like the ones of other orgs.
+func (h *Handlers) getBlueprint(ctx echo.Context, userID *Identity, id uuid.UUID, version *int) (*db.BlueprintEntry, error) {
+	blueprintEntry, err := h.server.db.GetBlueprint(ctx.Request().Context(), id, userID.OrgID(), version)
+	if err != nil {
+		return nil, err
+	}
+	if blueprintEntry.Private && blueprintEntry.CreatedBy != userID.Email() {
+		return nil, db.BlueprintNotFoundError
+	}
+	role, err := h.workspaceRole(ctx, userID, blueprintEntry.WorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, db.BlueprintNotFoundError
+	}
+	return blueprintEntry, nil
+}
+
+// getBlueprintToChange returns the blueprint when the user changes or
+// composes it, only the builders of its workspace do.
+func (h *Handlers) getBlueprintToChange(ctx echo.Context, userID *Identity, id uuid.UUID, version *int) (*db.BlueprintEntry, error) {
+	blueprintEntry, err := h.getBlueprint(ctx, userID, id, version)
+	if err != nil {
+		return nil, err
+	}
+	role, err := h.workspaceRole(ctx, userID, blueprintEntry.WorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+	if role != db.WorkspaceBuilder {
+		return nil, echo.NewHTTPError(http.StatusForbidden, "Only the builders of the workspace can change and compose its blueprints")
+	}
+	return blueprintEntry, nil
+}
+
+// blueprintWorkspaceKept rejects requests moving the blueprint to another
+// workspace, omitting the workspace keeps it.
+func blueprintWorkspaceKept(blueprintEntry *db.BlueprintEntry, workspaceId *uuid.UUID) error {
+	if workspaceId == nil || (blueprintEntry.WorkspaceId != nil && *blueprintEntry.WorkspaceId == *workspaceId) {
+		return nil
+	}
+	return echo.NewHTTPError(http.StatusBadRequest, "Blueprints can't be moved to another workspace")
+}
+
+// blueprintPrivate returns whether the blueprint is private after a request
+// asking for the visibility, nil keeps it as it is. Only the creator changes
+// the visibility, blueprints without one stay shared with the org.
+func blueprintPrivate(userID *Identity, blueprintEntry *db.BlueprintEntry, visibility *BlueprintVisibility) (bool, error) {
+	if visibility == nil || (*visibility == Private) == blueprintEntry.Private {
+		return blueprintEntry.Private, nil
+	}
+	if blueprintEntry.CreatedBy == "" {
+		return false, echo.NewHTTPError(http.StatusForbidden, "The blueprint has no known creator, it can't be made private")
+	}
+	if blueprintEntry.CreatedBy != userID.Email() {
+		return false, echo.NewHTTPError(http.StatusForbidden, "Only the creator of the blueprint can change its visibility")
+	}
+	return *visibility == Private, nil
+}
+
+func blueprintVisibility(private bool) BlueprintVisibility {
+	if private {
+		return Private
+	}
+	return Org
+}
+
 func (h *Handlers) CreateBlueprint(ctx echo.Context) error {
 	userID, err := h.server.getIdentity(ctx)
 	if err != nil {
@@ -86,6 +161,22 @@ func (h *Handlers) CreateBlueprint(ctx echo.Context) error {
 		})
 	}
 
+	private := common.FromPtr(blueprintRequest.Visibility) == Private
+	if private && userID.Email() == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Private blueprints need a creator, the identity has no email")
+	}
+
+	role, err := h.workspaceRole(ctx, userID, blueprintRequest.WorkspaceId)
+	if errors.Is(err, db.WorkspaceNotFoundError) {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err)
+	}
+	if err != nil {
+		return err
+	}
+	if role != db.WorkspaceBuilder {
+		return echo.NewHTTPError(http.StatusForbidden, "Only the builders of the workspace can add blueprints to it")
+	}
+
 	id := uuid.New()
 	versionId := uuid.New()
 	ctx.Logger().Infof("Inserting blueprint: %s (%s), for orgID: %s and account: %s", blueprintRequest.Name, id, userID.OrgID(), userID.AccountNumber())
@@ -94,7 +185,7 @@ func (h *Handlers) CreateBlueprint(ctx echo.Context) error {
 		desc = *blueprintRequest.Description
 	}
 
-	err = h.server.db.InsertBlueprint(ctx.Request().Context(), id, versionId, userID.OrgID(), userID.AccountNumber(), blueprintRequest.Name, desc, body, metadata)
+	err = h.server.db.InsertBlueprint(ctx.Request().Context(), id, versionId, userID.OrgID(), userID.AccountNumber(), blueprintRequest.Name, desc, body, metadata, db.BlueprintOptions{CreatedBy: userID.Email(), Private: private, WorkspaceId: blueprintRequest.WorkspaceId})
 	if err != nil {
 		ctx.Logger().Errorf("Error inserting id into db: %s", err.Error())
 
@@ -129,7 +220,7 @@ func (h *Handlers) GetBlueprint(ctx echo.Context, id openapi_types.UUID, params
 		params.Version = nil
 	}
 
-	blueprintEntry, err := h.server.db.GetBlueprint(ctx.Request().Context(), id, userID.OrgID(), params.Version)
+	blueprintEntry, err := h.getBlueprint(ctx, userID, id, params.Version)
 	if err != nil {
 		if errors.Is(err, db.BlueprintNotFoundError) {
 			return echo.NewHTTPError(http.StatusNotFound, err)
@@ -143,17 +234,59 @@ func (h *Handlers) GetBlueprint(ctx echo.Context, id openapi_types.UUID, params
 	}
 
 	blueprintResponse := BlueprintResponse{
-		Id:             id,
-		Name:           blueprintEntry.Name,
-		Description:    blueprintEntry.Description,
-		ImageRequests:  blueprint.ImageRequests,
-		Distribution:   blueprint.Distribution,
-		Customizations: blueprint.Customizations,
+		Id:                 id,
+		Name:               blueprintEntry.Name,
+		Description:        blueprintEntry.Description,
+		ImageRequests:      blueprint.ImageRequests,
+		Distribution:       blueprint.Distribution,
+		Customizations:     blueprint.Customizations,
+		Lifecycle:          blueprint.Lifecycle,
+		LaunchRequirements: blueprint.LaunchRequirements,
+		Visibility:         blueprintVisibility(blueprintEntry.Private),
+		WorkspaceId:        blueprintEntry.WorkspaceId,
+	}
+	if blueprintEntry.CreatedBy != "" {
+		blueprintResponse.CreatedBy = &blueprintEntry.CreatedBy
+	}
+	if blueprintEntry.UpdatedBy != "" {
+		blueprintResponse.UpdatedBy = &blueprintEntry.UpdatedBy
 	}
 
+	ctx.Response().Header().Set("ETag", versionETag(blueprintEntry.Version))
 	return ctx.JSON(http.StatusOK, blueprintResponse)
 }
 
+func (h *Handlers) GetBlueprintVersions(ctx echo.Context, id openapi_types.UUID) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	var versions []db.BlueprintWithNoBody
+
+	_, err = h.getBlueprint(ctx, userID, id, nil)
+	if err == nil {
+		versions, err = h.server.db.GetBlueprintVersions(ctx.Request().Context(), id, userID.OrgID())
+	}
+	if err != nil {
+		if errors.Is(err, db.BlueprintNotFoundError) {
+			return echo.NewHTTPError(http.StatusNotFound, err)
+		}
+		return err
+	}
+
+	data := make([]BlueprintVersion, 0, len(versions))
+	for _, version := range versions {
+		data = append(data, BlueprintVersion{
+			Version:   version.Version,
+			CreatedAt: version.LastModifiedAt.Format(time.RFC3339),
+		})
+	}
+	return ctx.JSON(http.StatusOK, BlueprintVersionsResponse{
+		Data: data,
+	})
+}
+
 func (h *Handlers) ExportBlueprint(ctx echo.Context, id openapi_types.UUID) error {
 	userID, err := h.server.getIdentity(ctx)
 	if err != nil {
@@ -161,7 +294,7 @@ func (h *Handlers) ExportBlueprint(ctx echo.Context, id openapi_types.UUID) erro
 	}
 
 	ctx.Logger().Infof("Fetching blueprint %s", id)
-	blueprintEntry, err := h.server.db.GetBlueprint(ctx.Request().Context(), id, userID.OrgID(), nil)
+	blueprintEntry, err := h.getBlueprint(ctx, userID, id, nil)
 	if err != nil {
 		if errors.Is(err, db.BlueprintNotFoundError) {
 			return echo.NewHTTPError(http.StatusNotFound, err)
@@ -188,7 +321,7 @@ func (h *Handlers) ExportBlueprint(ctx echo.Context, id openapi_types.UUID) erro
 	return ctx.JSON(http.StatusOK, blueprintExportResponse)
 }
 
-func (h *Handlers) UpdateBlueprint(ctx echo.Context, blueprintId uuid.UUID) error {
+func (h *Handlers) UpdateBlueprint(ctx echo.Context, blueprintId uuid.UUID, params UpdateBlueprintParams) error {
 	userID, err := h.server.getIdentity(ctx)
 	if err != nil {
 		return err
@@ -215,25 +348,110 @@ func (h *Handlers) UpdateBlueprint(ctx echo.Context, blueprintId uuid.UUID) erro
 		})
 	}
 
+	blueprintEntry, err := h.getBlueprintToChange(ctx, userID, blueprintId, nil)
+	if errors.Is(err, db.BlueprintNotFoundError) {
+		return echo.NewHTTPError(http.StatusNotFound, err)
+	}
+	if err != nil {
+		return err
+	}
+	private, err := blueprintPrivate(userID, blueprintEntry, blueprintRequest.Visibility)
+	if err != nil {
+		return err
+	}
+	err = blueprintWorkspaceKept(blueprintEntry, blueprintRequest.WorkspaceId)
+	if err != nil {
+		return err
+	}
+
+	// the update only applies to the version of the ETag
+	var version *int
+	if params.IfMatch != nil {
+		if !etagMatches(*params.IfMatch, versionETag(blueprintEntry.Version)) {
+			return echo.NewHTTPError(http.StatusPreconditionFailed, "The blueprint changed since the version of the ETag")
+		}
+		version = &blueprintEntry.Version
+	}
+
 	versionId := uuid.New()
 	desc := ""
 	if blueprintRequest.Description != nil {
 		desc = *blueprintRequest.Description
 	}
-	err = h.server.db.UpdateBlueprint(ctx.Request().Context(), versionId, blueprintId, userID.OrgID(), blueprintRequest.Name, desc, body)
+	if version != nil {
+		err = h.server.db.UpdateBlueprintIfVersion(ctx.Request().Context(), versionId, blueprintId, userID.OrgID(), blueprintRequest.Name, desc, body, *version, userID.Email())
+	} else {
+		err = h.server.db.UpdateBlueprint(ctx.Request().Context(), versionId, blueprintId, userID.OrgID(), blueprintRequest.Name, desc, body, userID.Email())
+	}
+	if err == nil && private != blueprintEntry.Private {
+		err = h.server.db.SetBlueprintPrivate(ctx.Request().Context(), blueprintId, userID.OrgID(), private)
+	}
 	if err != nil {
 		ctx.Logger().Errorf("Error updating blueprint in db: %v", err)
 		if errors.Is(err, db.BlueprintNotFoundError) {
 			return echo.NewHTTPError(http.StatusNotFound, err)
 		}
+		if errors.Is(err, db.BlueprintVersionConflictError) {
+			return echo.NewHTTPError(http.StatusPreconditionFailed, "The blueprint changed while updating it")
+		}
 		return err
 	}
 	ctx.Logger().Infof("Updated blueprint %s", blueprintId)
+	if version != nil {
+		ctx.Response().Header().Set("ETag", versionETag(*version+1))
+	}
 	return ctx.JSON(http.StatusCreated, ComposeResponse{
 		Id: blueprintId,
 	})
 }
 
+// RollbackBlueprint adds a version to the blueprint with the body of an
+// earlier one, the history itself isn't changed.
+func (h *Handlers) RollbackBlueprint(ctx echo.Context, blueprintId openapi_types.UUID) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	var request RollbackBlueprintJSONRequestBody
+	err = ctx.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	latest, err := h.getBlueprintToChange(ctx, userID, blueprintId, nil)
+	if errors.Is(err, db.BlueprintNotFoundError) {
+		return echo.NewHTTPError(http.StatusNotFound, err)
+	}
+	if err != nil {
+		return err
+	}
+	target, err := h.server.db.GetBlueprint(ctx.Request().Context(), blueprintId, userID.OrgID(), &request.Version)
+	if errors.Is(err, db.BlueprintNotFoundError) {
+		return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Blueprint %s has no version %d", blueprintId, request.Version))
+	}
+	if err != nil {
+		return err
+	}
+
+	// the name and description aren't versioned, they stay as they are
+	err = h.server.db.UpdateBlueprintIfVersion(ctx.Request().Context(), uuid.New(), blueprintId, userID.OrgID(), latest.Name, latest.Description, target.Body, latest.Version, userID.Email())
+	if err != nil {
+		if errors.Is(err, db.BlueprintNotFoundError) {
+			return echo.NewHTTPError(http.StatusNotFound, err)
+		}
+		if errors.Is(err, db.BlueprintVersionConflictError) {
+			return echo.NewHTTPError(http.StatusPreconditionFailed, "The blueprint changed while rolling it back")
+		}
+		return err
+	}
+	ctx.Logger().Infof("Rolled blueprint %s back to version %d", blueprintId, request.Version)
+	ctx.Response().Header().Set("ETag", versionETag(latest.Version+1))
+	return ctx.JSON(http.StatusCreated, CreateBlueprintResponse{
+		Id: blueprintId,
+	})
+}
+
 func (h *Handlers) ComposeBlueprint(ctx echo.Context, id openapi_types.UUID) error {
 	var requestBody ComposeBlueprintJSONBody
 	err := ctx.Bind(&requestBody)
@@ -246,39 +464,68 @@ func (h *Handlers) ComposeBlueprint(ctx echo.Context, id openapi_types.UUID) err
 		return err
 	}
 
-	blueprintEntry, err := h.server.db.GetBlueprint(ctx.Request().Context(), id, userID.OrgID(), nil)
+	blueprintEntry, err := h.getBlueprintToChange(ctx, userID, id, requestBody.Version)
+	if errors.Is(err, db.BlueprintNotFoundError) {
+		return echo.NewHTTPError(http.StatusNotFound, err)
+	}
 	if err != nil {
 		return err
 	}
-	blueprint, err := BlueprintFromEntry(blueprintEntry)
+	composeResponses, err := h.composeBlueprint(ctx, blueprintEntry, requestBody.ImageTypes)
 	if err != nil {
 		return err
 	}
-	composeResponses := make([]ComposeResponse, 0, len(blueprint.ImageRequests))
+
+	return ctx.JSON(http.StatusCreated, composeResponses)
+}
+
+// composeBlueprint builds the image requests of the blueprint, all of them if
+// imageTypes is nil.
+func (h *Handlers) composeBlueprint(ctx echo.Context, blueprintEntry *db.BlueprintEntry, imageTypes *[]ImageTypes) ([]ComposeResponse, error) {
+	blueprint, err := BlueprintFromEntry(blueprintEntry)
+	if err != nil {
+		return nil, err
+	}
+	// every architecture of an image request is an image request of its own
+	var imageRequests []ImageRequest
+	for _, imageRequest := range blueprint.ImageRequests {
+		imageRequests = append(imageRequests, splitImageRequest(imageRequest)...)
+	}
 	clientId := ClientId("api")
 	if ctx.Request().Header.Get("X-ImageBuilder-ui") != "" {
 		clientId = "ui"
 	}
-	for _, imageRequest := range blueprint.ImageRequests {
-		if requestBody.ImageTypes != nil && !slices.Contains(*requestBody.ImageTypes, imageRequest.ImageType) {
+	var composeRequests []ComposeRequest
+	for _, imageRequest := range imageRequests {
+		if imageTypes != nil && !slices.Contains(*imageTypes, imageRequest.ImageType) {
 			continue
 		}
-		composeRequest := ComposeRequest{
+		composeRequests = append(composeRequests, ComposeRequest{
 			Customizations:   &blueprint.Customizations,
 			Distribution:     blueprint.Distribution,
 			ImageRequests:    []ImageRequest{imageRequest},
 			ImageName:        &blueprintEntry.Name,
 			ImageDescription: &blueprintEntry.Description,
 			ClientId:         &clientId,
+		})
+	}
+	if blueprintEntry.WorkspaceId != nil {
+		err = h.checkWorkspaceComposes(ctx, *blueprintEntry.WorkspaceId, composeRequests)
+		if err != nil {
+			return nil, err
 		}
-		composesResponse, err := h.handleCommonCompose(ctx, composeRequest, &blueprintEntry.VersionId)
+	}
+
+	composeResponses := make([]ComposeResponse, 0, len(composeRequests))
+	for _, composeRequest := range composeRequests {
+		composesResponse, err := h.handleCommonCompose(ctx, composeRequest, &blueprintEntry.VersionId, nil)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		composeResponses = append(composeResponses, composesResponse)
 	}
 
-	return ctx.JSON(http.StatusCreated, composeResponses)
+	return composeResponses, nil
 }
 
 func (h *Handlers) GetBlueprints(ctx echo.Context, params GetBlueprintsParams) error {
@@ -302,6 +549,12 @@ func (h *Handlers) GetBlueprints(ctx echo.Context, params GetBlueprintsParams) e
 	if params.Offset != nil {
 		offset = *params.Offset
 	}
+	filter := db.BlueprintFilter{
+		Viewer:        userID.Email(),
+		Owner:         string(common.FromPtr(params.Owner)),
+		Workspace:     params.WorkspaceId,
+		AllWorkspaces: userID.IsOrgAdmin(),
+	}
 	var blueprints []db.BlueprintWithNoBody
 	var count int
 
@@ -310,18 +563,26 @@ func (h *Handlers) GetBlueprints(ctx echo.Context, params GetBlueprintsParams) e
 		if err != nil {
 			return err
 		}
-		if blueprint != nil {
+		listed := blueprint != nil && blueprintListed(filter, blueprint)
+		if listed && !filter.AllWorkspaces && blueprint.WorkspaceId != nil {
+			role, err := h.workspaceRole(ctx, userID, blueprint.WorkspaceId)
+			if err != nil {
+				return err
+			}
+			listed = role != ""
+		}
+		if listed {
 			blueprints = []db.BlueprintWithNoBody{*blueprint}
 			count = 1
 		}
 		// Else no blueprint found - return empty list and count = 0
 	} else if params.Search != nil && common.FromPtr(params.Search) != "" {
-		blueprints, count, err = h.server.db.FindBlueprints(ctx.Request().Context(), userID.OrgID(), *params.Search, limit, offset)
+		blueprints, count, err = h.server.db.FindBlueprints(ctx.Request().Context(), userID.OrgID(), *params.Search, filter, limit, offset)
 		if err != nil {
 			return err
 		}
 	} else {
-		blueprints, count, err = h.server.db.GetBlueprints(ctx.Request().Context(), userID.OrgID(), limit, offset)
+		blueprints, count, err = h.server.db.GetBlueprints(ctx.Request().Context(), userID.OrgID(), filter, limit, offset)
 		if err != nil {
 			return err
 		}
@@ -331,13 +592,19 @@ func (h *Handlers) GetBlueprints(ctx echo.Context, params GetBlueprintsParams) e
 
 	data := make([]BlueprintItem, 0, len(blueprints))
 	for _, blueprint := range blueprints {
-		data = append(data, BlueprintItem{
+		item := BlueprintItem{
 			Id:             blueprint.Id,
 			Name:           blueprint.Name,
 			Description:    blueprint.Description,
 			Version:        blueprint.Version,
 			LastModifiedAt: blueprint.LastModifiedAt.Format(time.RFC3339),
-		})
+			Visibility:     blueprintVisibility(blueprint.Private),
+			WorkspaceId:    blueprint.WorkspaceId,
+		}
+		if blueprint.CreatedBy != "" {
+			item.CreatedBy = &blueprint.CreatedBy
+		}
+		data = append(data, item)
 	}
 	lastOffset := count - 1
 	if lastOffset < 0 {
@@ -346,15 +613,35 @@ func (h *Handlers) GetBlueprints(ctx echo.Context, params GetBlueprintsParams) e
 	return ctx.JSON(http.StatusOK, BlueprintsResponse{
 		Meta: ListResponseMeta{count},
 		Links: ListResponseLinks{
-			fmt.Sprintf("%v/v%v/composes?offset=0&limit=%v",
+			First: fmt.Sprintf("%v/v%v/composes?offset=0&limit=%v",
 				RoutePrefix(), spec.Info.Version, limit),
-			fmt.Sprintf("%v/v%v/composes?offset=%v&limit=%v",
+			Last: fmt.Sprintf("%v/v%v/composes?offset=%v&limit=%v",
 				RoutePrefix(), spec.Info.Version, lastOffset, limit),
 		},
 		Data: data,
 	})
 }
 
+// blueprintListed tells whether the filter of the blueprint list keeps the
+// blueprint, like the database does for the other lists. The membership of
+// the viewer in the workspace of the blueprint is checked separately.
+func blueprintListed(filter db.BlueprintFilter, blueprint *db.BlueprintWithNoBody) bool {
+	mine := blueprint.CreatedBy != "" && blueprint.CreatedBy == filter.Viewer
+	if blueprint.Private && !mine {
+		return false
+	}
+	if filter.Workspace != nil && (blueprint.WorkspaceId == nil || *blueprint.WorkspaceId != *filter.Workspace) {
+		return false
+	}
+	switch filter.Owner {
+	case db.BlueprintsMine:
+		return mine
+	case db.BlueprintsShared:
+		return !blueprint.Private && !mine
+	}
+	return true
+}
+
 func (h *Handlers) GetBlueprintComposes(ctx echo.Context, blueprintId openapi_types.UUID, params GetBlueprintComposesParams) error {
 	userID, err := h.server.getIdentity(ctx)
 	if err != nil {
@@ -374,6 +661,14 @@ func (h *Handlers) GetBlueprintComposes(ctx echo.Context, blueprintId openapi_ty
 	}
 	ignoreImageTypeStrings := convertIgnoreImageTypeToSlice(params.IgnoreImageTypes)
 
+	_, err = h.getBlueprint(ctx, userID, blueprintId, nil)
+	if err != nil {
+		if errors.Is(err, db.BlueprintNotFoundError) {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return err
+	}
+
 	since := time.Hour * 24 * 14
 
 	if params.BlueprintVersion != nil && *params.BlueprintVersion < 0 {
@@ -400,7 +695,7 @@ func (h *Handlers) GetBlueprintComposes(ctx echo.Context, blueprintId openapi_ty
 		bId := c.BlueprintId
 		version := c.BlueprintVersion
 		var cmpr ComposeRequest
-		err = json.Unmarshal(c.Request, &cmpr)
+		err = h.server.openComposeRequest(c.Request, &cmpr)
 		if err != nil {
 			return err
 		}
@@ -427,13 +722,69 @@ func (h *Handlers) GetBlueprintComposes(ctx echo.Context, blueprintId openapi_ty
 	})
 }
 
+// PreviewBlueprintLifecycle lists the composes the lifecycle collector would
+// delete, without deleting them.
+func (h *Handlers) PreviewBlueprintLifecycle(ctx echo.Context, blueprintId openapi_types.UUID) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	blueprintEntry, err := h.getBlueprint(ctx, userID, blueprintId, nil)
+	if err != nil {
+		if errors.Is(err, db.BlueprintNotFoundError) {
+			return echo.NewHTTPError(http.StatusNotFound, err)
+		}
+		return err
+	}
+	blueprint, err := BlueprintFromEntry(blueprintEntry)
+	if err != nil {
+		return err
+	}
+
+	preview := BlueprintLifecyclePreview{
+		Data:      []ComposesResponseItem{},
+		Lifecycle: blueprint.Lifecycle,
+	}
+	if blueprint.Lifecycle == nil {
+		return ctx.JSON(http.StatusOK, preview)
+	}
+
+	composes, err := h.server.db.GetExpiredBlueprintComposes(ctx.Request().Context(), userID.OrgID(), blueprintId, blueprint.Lifecycle.KeepLast)
+	if err != nil {
+		return err
+	}
+	for _, c := range composes {
+		bId := c.BlueprintId
+		version := c.BlueprintVersion
+		var cmpr ComposeRequest
+		err = h.server.openComposeRequest(c.Request, &cmpr)
+		if err != nil {
+			return err
+		}
+		preview.Data = append(preview.Data, ComposesResponseItem{
+			BlueprintId:      &bId,
+			BlueprintVersion: &version,
+			CreatedAt:        c.CreatedAt.Format(time.RFC3339),
+			Id:               c.Id,
+			ImageName:        c.ImageName,
+			Request:          cmpr,
+			ClientId:         (*ClientId)(c.ClientId),
+		})
+	}
+	return ctx.JSON(http.StatusOK, preview)
+}
+
 func (h *Handlers) DeleteBlueprint(ctx echo.Context, blueprintId openapi_types.UUID) error {
 	userID, err := h.server.getIdentity(ctx)
 	if err != nil {
 		return err
 	}
 
-	err = h.server.db.DeleteBlueprint(ctx.Request().Context(), blueprintId, userID.OrgID(), userID.AccountNumber())
+	_, err = h.getBlueprintToChange(ctx, userID, blueprintId, nil)
+	if err == nil {
+		err = h.server.db.DeleteBlueprint(ctx.Request().Context(), blueprintId, userID.OrgID(), userID.AccountNumber())
+	}
 	if err != nil {
 		if errors.Is(err, db.BlueprintNotFoundError) {
 			return echo.NewHTTPError(http.StatusNotFound)
@@ -75,6 +75,59 @@ func TestHandlers_CreateBlueprint(t *testing.T) {
 	require.Equal(t, "Invalid blueprint name", jsonResp.Errors[0].Title)
 }
 
+func TestHandlers_CreateBlueprintYAML(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+
+	db_srv, tokenSrv := startServer(t, &testServerClientsConf{}, &ServerConfig{
+		DBase:            dbase,
+		DistributionsDir: "../../distributions",
+	})
+	defer func() {
+		err := db_srv.Shutdown(ctx)
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	body := `name: Blueprint YAML
+description: desc
+distribution: centos-9
+customizations:
+  packages:
+    - nginx
+image_requests:
+  - architecture: x86_64
+    image_type: guest-image
+    upload_request:
+      type: aws.s3
+      options: {}
+`
+	statusCode, resp := tutils.RawResponse(t, http.MethodPost, "http://localhost:8086/api/image-builder/v1/blueprints", "application/yaml", body)
+	require.Equal(t, http.StatusCreated, statusCode, resp)
+	var result CreateBlueprintResponse
+	require.NoError(t, json.Unmarshal([]byte(resp), &result))
+
+	statusCode, resp = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s", result.Id), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, statusCode)
+	var blueprint BlueprintResponse
+	require.NoError(t, json.Unmarshal([]byte(resp), &blueprint))
+	require.Equal(t, "Blueprint YAML", blueprint.Name)
+	require.Equal(t, []string{"nginx"}, *blueprint.Customizations.Packages)
+
+	// yaml is validated against the same schema
+	statusCode, _ = tutils.RawResponse(t, http.MethodPut, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s", result.Id), "application/yaml", strings.Replace(body, "centos-9", "nope", 1))
+	require.Equal(t, http.StatusBadRequest, statusCode)
+	statusCode, _ = tutils.RawResponse(t, http.MethodPost, "http://localhost:8086/api/image-builder/v1/blueprints", "application/yaml", "name: [")
+	require.Equal(t, http.StatusBadRequest, statusCode)
+	statusCode, resp = tutils.RawResponse(t, http.MethodPut, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s", result.Id), "application/yaml", strings.Replace(body, "desc", "updated", 1))
+	require.Equal(t, http.StatusCreated, statusCode, resp)
+
+	// only the operations declaring it accept yaml
+	statusCode, _ = tutils.RawResponse(t, http.MethodPost, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s/retarget", result.Id), "application/yaml", "distribution: centos-10\n")
+	require.Equal(t, http.StatusBadRequest, statusCode)
+}
+
 func TestHandlers_UpdateBlueprint(t *testing.T) {
 	var jsonResp HTTPErrorList
 	ctx := context.Background()
@@ -203,7 +256,7 @@ func TestHandlers_ComposeBlueprint(t *testing.T) {
 	var message []byte
 	message, err = json.Marshal(blueprint)
 	require.NoError(t, err)
-	err = dbase.InsertBlueprint(ctx, id, versionId, "000000", "000000", name, description, message, nil)
+	err = dbase.InsertBlueprint(ctx, id, versionId, "000000", "000000", name, description, message, nil, db.BlueprintOptions{})
 	require.NoError(t, err)
 
 	tests := map[string]struct {
@@ -253,22 +306,22 @@ func TestHandlers_GetBlueprintComposes(t *testing.T) {
 
 	var result ComposesResponse
 
-	err = dbase.InsertBlueprint(ctx, blueprintId, versionId, "000000", "500000", "blueprint", "blueprint desc", json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), nil)
+	err = dbase.InsertBlueprint(ctx, blueprintId, versionId, "000000", "500000", "blueprint", "blueprint desc", json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), nil, db.BlueprintOptions{})
 	require.NoError(t, err)
 	id1 := uuid.New()
-	err = dbase.InsertCompose(ctx, id1, "500000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "edge-installer"}]}`), &clientId, &versionId)
+	err = dbase.InsertCompose(ctx, id1, "500000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "edge-installer"}]}`), &clientId, &versionId, nil, nil)
 	require.NoError(t, err)
 	id2 := uuid.New()
-	err = dbase.InsertCompose(ctx, id2, "500000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), &clientId, &versionId)
+	err = dbase.InsertCompose(ctx, id2, "500000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), &clientId, &versionId, nil, nil)
 	require.NoError(t, err)
 
-	err = dbase.UpdateBlueprint(ctx, version2Id, blueprintId, "000000", "blueprint", "desc2", json.RawMessage(`{"image_requests": [{"image_type": "aws"}, {"image_type": "gcp"}]}`))
+	err = dbase.UpdateBlueprint(ctx, version2Id, blueprintId, "000000", "blueprint", "desc2", json.RawMessage(`{"image_requests": [{"image_type": "aws"}, {"image_type": "gcp"}]}`), "")
 	require.NoError(t, err)
 	id3 := uuid.New()
-	err = dbase.InsertCompose(ctx, id3, "500000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), &clientId, &version2Id)
+	err = dbase.InsertCompose(ctx, id3, "500000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), &clientId, &version2Id, nil, nil)
 	require.NoError(t, err)
 	id4 := uuid.New()
-	err = dbase.InsertCompose(ctx, id4, "500000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "gcp"}]}`), &clientId, &version2Id)
+	err = dbase.InsertCompose(ctx, id4, "500000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "gcp"}]}`), &clientId, &version2Id, nil, nil)
 	require.NoError(t, err)
 
 	respStatusCode, body := tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s/composes", blueprintId.String()), &tutils.AuthString0)
@@ -315,7 +368,7 @@ func TestHandlers_GetBlueprintComposes(t *testing.T) {
 	// get composes for a blueprint that does not have any composes
 	id5 := uuid.New()
 	versionId2 := uuid.New()
-	err = dbase.InsertBlueprint(ctx, id5, versionId2, "000000", "500000", "newBlueprint", "blueprint desc", json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), nil)
+	err = dbase.InsertBlueprint(ctx, id5, versionId2, "000000", "500000", "newBlueprint", "blueprint desc", json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), nil, db.BlueprintOptions{})
 	require.NoError(t, err)
 	respStatusCode, body = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s/composes?blueprint_version=1", id5), &tutils.AuthString0)
 	require.Equal(t, 200, respStatusCode)
@@ -325,6 +378,64 @@ func TestHandlers_GetBlueprintComposes(t *testing.T) {
 	require.Equal(t, 0, result.Meta.Count)
 }
 
+func TestHandlers_PreviewBlueprintLifecycle(t *testing.T) {
+	ctx := context.Background()
+	blueprintId := uuid.New()
+	versionId := uuid.New()
+
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{}, &ServerConfig{
+		DBase:            dbase,
+		DistributionsDir: "../../distributions",
+	})
+	defer func() {
+		err := srv.Shutdown(ctx)
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	err = dbase.InsertBlueprint(ctx, blueprintId, versionId, "000000", "500000", "blueprint", "blueprint desc", json.RawMessage(`{"image_requests": [{"image_type": "aws"}], "lifecycle": {"keep_last": 1}}`), nil, db.BlueprintOptions{})
+	require.NoError(t, err)
+	var ids []uuid.UUID
+	for i := 0; i < 3; i++ {
+		id := uuid.New()
+		err = dbase.InsertCompose(ctx, id, "500000", "user100000@test.test", "000000", nil, json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), nil, &versionId, nil, nil)
+		require.NoError(t, err)
+		require.NoError(t, dbase.SetComposeStatus(ctx, id, "success", nil))
+		ids = append(ids, id)
+	}
+
+	respStatusCode, body := tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s/lifecycle/preview", blueprintId), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var result BlueprintLifecyclePreview
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Equal(t, &BlueprintLifecycle{KeepLast: 1}, result.Lifecycle)
+	require.Len(t, result.Data, 2)
+	require.Equal(t, ids[1], result.Data[0].Id)
+	require.Equal(t, ids[0], result.Data[1].Id)
+
+	// the preview doesn't delete anything
+	respStatusCode, body = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s/lifecycle/preview", blueprintId), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Len(t, result.Data, 2)
+
+	// without a policy nothing is deleted
+	err = dbase.UpdateBlueprint(ctx, uuid.New(), blueprintId, "000000", "blueprint", "desc2", json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), "")
+	require.NoError(t, err)
+	respStatusCode, body = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s/lifecycle/preview", blueprintId), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	result = BlueprintLifecyclePreview{}
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Nil(t, result.Lifecycle)
+	require.Empty(t, result.Data)
+
+	respStatusCode, _ = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s/lifecycle/preview", uuid.New()), &tutils.AuthString0)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+}
+
 func TestHandlers_GetBlueprint(t *testing.T) {
 	ctx := context.Background()
 	dbase, err := dbc.NewDB()
@@ -378,7 +489,7 @@ func TestHandlers_GetBlueprint(t *testing.T) {
 	var message []byte
 	message, err = json.Marshal(blueprint)
 	require.NoError(t, err)
-	err = dbase.InsertBlueprint(ctx, id, versionId, "000000", "000000", name, description, message, nil)
+	err = dbase.InsertBlueprint(ctx, id, versionId, "000000", "000000", name, description, message, nil, db.BlueprintOptions{})
 	require.NoError(t, err)
 
 	be, err := dbase.GetBlueprint(ctx, id, "000000", nil)
@@ -411,7 +522,7 @@ func TestHandlers_GetBlueprint(t *testing.T) {
 	var message2 []byte
 	message2, err = json.Marshal(version2Body)
 	require.NoError(t, err)
-	err = dbase.UpdateBlueprint(ctx, version2Id, id, "000000", name, description, message2)
+	err = dbase.UpdateBlueprint(ctx, version2Id, id, "000000", name, description, message2, "")
 	require.NoError(t, err)
 
 	respStatusCode, body = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s?version=%d", id.String(), -1), &tutils.AuthString0)
@@ -500,7 +611,7 @@ func TestHandlers_ExportBlueprint(t *testing.T) {
 	metadataMessage, err = json.Marshal(metadata)
 	require.NoError(t, err)
 
-	err = dbase.InsertBlueprint(ctx, id, versionId, "000000", "000000", name, description, message, metadataMessage)
+	err = dbase.InsertBlueprint(ctx, id, versionId, "000000", "000000", name, description, message, metadataMessage, db.BlueprintOptions{})
 	require.NoError(t, err)
 
 	respStatusCode, body := tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s/export", id.String()), &tutils.AuthString0)
@@ -574,11 +685,11 @@ func TestHandlers_GetBlueprints(t *testing.T) {
 
 	blueprintId := uuid.New()
 	versionId := uuid.New()
-	err = dbase.InsertBlueprint(ctx, blueprintId, versionId, "000000", "000000", "blueprint", "blueprint desc", json.RawMessage(`{}`), nil)
+	err = dbase.InsertBlueprint(ctx, blueprintId, versionId, "000000", "000000", "blueprint", "blueprint desc", json.RawMessage(`{}`), nil, db.BlueprintOptions{})
 	require.NoError(t, err)
 	blueprintId2 := uuid.New()
 	versionId2 := uuid.New()
-	err = dbase.InsertBlueprint(ctx, blueprintId2, versionId2, "000000", "000000", "Blueprint2", "blueprint desc", json.RawMessage(`{}`), nil)
+	err = dbase.InsertBlueprint(ctx, blueprintId2, versionId2, "000000", "000000", "Blueprint2", "blueprint desc", json.RawMessage(`{}`), nil, db.BlueprintOptions{})
 	require.NoError(t, err)
 
 	var result BlueprintsResponse
@@ -618,23 +729,23 @@ func TestHandlers_DeleteBlueprint(t *testing.T) {
 	defer tokenSrv.Close()
 
 	blueprintName := "blueprint"
-	err = dbase.InsertBlueprint(ctx, blueprintId, versionId, "000000", "000000", blueprintName, "blueprint desc", json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), nil)
+	err = dbase.InsertBlueprint(ctx, blueprintId, versionId, "000000", "000000", blueprintName, "blueprint desc", json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), nil, db.BlueprintOptions{})
 	require.NoError(t, err)
 	id1 := uuid.New()
-	err = dbase.InsertCompose(ctx, id1, "000000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "edge-installer"}]}`), &clientId, &versionId)
+	err = dbase.InsertCompose(ctx, id1, "000000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "edge-installer"}]}`), &clientId, &versionId, nil, nil)
 	require.NoError(t, err)
 
 	id2 := uuid.New()
-	err = dbase.InsertCompose(ctx, id2, "000000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), &clientId, &versionId)
+	err = dbase.InsertCompose(ctx, id2, "000000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), &clientId, &versionId, nil, nil)
 	require.NoError(t, err)
 
-	err = dbase.UpdateBlueprint(ctx, version2Id, blueprintId, "000000", "blueprint", "desc2", json.RawMessage(`{"image_requests": [{"image_type": "aws"}, {"image_type": "gcp"}]}`))
+	err = dbase.UpdateBlueprint(ctx, version2Id, blueprintId, "000000", "blueprint", "desc2", json.RawMessage(`{"image_requests": [{"image_type": "aws"}, {"image_type": "gcp"}]}`), "")
 	require.NoError(t, err)
 	id3 := uuid.New()
-	err = dbase.InsertCompose(ctx, id3, "000000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), &clientId, &version2Id)
+	err = dbase.InsertCompose(ctx, id3, "000000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), &clientId, &version2Id, nil, nil)
 	require.NoError(t, err)
 	id4 := uuid.New()
-	err = dbase.InsertCompose(ctx, id4, "000000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "gcp"}]}`), &clientId, &version2Id)
+	err = dbase.InsertCompose(ctx, id4, "000000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "gcp"}]}`), &clientId, &version2Id, nil, nil)
 	require.NoError(t, err)
 
 	respStatusCode, body := tutils.DeleteResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s", blueprintId.String()))
@@ -661,7 +772,7 @@ func TestHandlers_DeleteBlueprint(t *testing.T) {
 
 	// We should not be able to update deleted blueprint
 	id5 := uuid.New()
-	err = dbase.UpdateBlueprint(ctx, id5, blueprintId, "000000", "newName", "desc2", json.RawMessage(`{"image_requests": [{"image_type": "aws"}, {"image_type": "gcp"}]}`))
+	err = dbase.UpdateBlueprint(ctx, id5, blueprintId, "000000", "newName", "desc2", json.RawMessage(`{"image_requests": [{"image_type": "aws"}, {"image_type": "gcp"}]}`), "")
 	require.ErrorIs(t, err, db.BlueprintNotFoundError)
 
 	// Composes should not be assigned to the blueprint anymore
@@ -671,10 +782,160 @@ func TestHandlers_DeleteBlueprint(t *testing.T) {
 	// We should be able to create a Blueprint with same name
 	blueprintId2 := uuid.New()
 	versionId2 := uuid.New()
-	err = dbase.InsertBlueprint(ctx, blueprintId2, versionId2, "000000", "000000", blueprintName, "blueprint desc", json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), nil)
+	err = dbase.InsertBlueprint(ctx, blueprintId2, versionId2, "000000", "000000", blueprintName, "blueprint desc", json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), nil, db.BlueprintOptions{})
 	require.NoError(t, err)
 
 	bpComposes, err := dbase.GetBlueprintComposes(ctx, "000000", blueprintId2, nil, (time.Hour * 24 * 14), 10, 0, nil)
 	require.Len(t, bpComposes, 0)
 	require.NoError(t, err)
 }
+
+func TestHandlers_BlueprintVersions(t *testing.T) {
+	startTestServer(t, &testServerClientsConf{}, nil)
+
+	blueprint := func(packages ...string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":           "versioned",
+			"customizations": map[string]interface{}{"packages": packages},
+			"distribution":   "centos-9",
+			"image_requests": []map[string]interface{}{
+				{
+					"architecture":   "x86_64",
+					"image_type":     "aws",
+					"upload_request": map[string]interface{}{"type": "aws", "options": map[string]interface{}{"share_with_accounts": []string{"test-account"}}},
+				},
+			},
+		}
+	}
+	respStatusCode, body := tutils.PostResponseBody(t, apiURL("/blueprints"), blueprint("nginx"))
+	require.Equal(t, http.StatusCreated, respStatusCode)
+	var created CreateBlueprintResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &created))
+	respStatusCode, _ = tutils.PutResponseBody(t, apiURL("/blueprints/%s", created.Id), blueprint("httpd"))
+	require.Equal(t, http.StatusCreated, respStatusCode)
+
+	versions := func() []int {
+		respStatusCode, body := tutils.GetResponseBody(t, apiURL("/blueprints/%s/versions", created.Id), &tutils.AuthString0)
+		require.Equal(t, http.StatusOK, respStatusCode)
+		var result BlueprintVersionsResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &result))
+		var numbers []int
+		for _, v := range result.Data {
+			require.NotEmpty(t, v.CreatedAt)
+			numbers = append(numbers, v.Version)
+		}
+		return numbers
+	}
+	require.Equal(t, []int{2, 1}, versions())
+
+	respStatusCode, _ = tutils.PostResponseBody(t, apiURL("/blueprints/%s/rollback", created.Id), RollbackBlueprintRequest{Version: 1})
+	require.Equal(t, http.StatusCreated, respStatusCode)
+	require.Equal(t, []int{3, 2, 1}, versions())
+	respStatusCode, body = tutils.GetResponseBody(t, apiURL("/blueprints/%s", created.Id), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var result BlueprintResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Equal(t, []string{"nginx"}, *result.Customizations.Packages)
+	require.Equal(t, "versioned", result.Name)
+
+	respStatusCode, _ = tutils.PostResponseBody(t, apiURL("/blueprints/%s/rollback", created.Id), RollbackBlueprintRequest{Version: 9})
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+	respStatusCode, _ = tutils.PostResponseBody(t, apiURL("/blueprints/%s/compose", created.Id), ComposeBlueprintJSONBody{Version: common.ToPtr(9)})
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+	respStatusCode, _ = tutils.GetResponseBody(t, apiURL("/blueprints/%s/versions", created.Id), &tutils.AuthString1)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+	respStatusCode, _ = tutils.GetResponseBody(t, apiURL("/blueprints/%s/versions", uuid.New()), &tutils.AuthString0)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+}
+
+func TestHandlers_PrivateBlueprints(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	startTestServer(t, &testServerClientsConf{}, &ServerConfig{
+		DBase:            dbase,
+		DistributionsDir: "../../distributions",
+	})
+
+	legacyId := uuid.New()
+	err = dbase.InsertBlueprint(ctx, legacyId, uuid.New(), "000000", "000000", "legacy", "desc", json.RawMessage(`{}`), nil, db.BlueprintOptions{})
+	require.NoError(t, err)
+
+	body := map[string]interface{}{
+		"name":           "private",
+		"customizations": map[string]interface{}{},
+		"distribution":   "centos-9",
+		"visibility":     "private",
+		"image_requests": []map[string]interface{}{
+			{
+				"architecture":   "x86_64",
+				"image_type":     "guest-image",
+				"upload_request": map[string]interface{}{"type": "aws.s3", "options": map[string]interface{}{}},
+			},
+		},
+	}
+	respStatusCode, resp := tutils.ResponseBody(t, http.MethodPost, apiURL("/blueprints"), tutils.AuthString0, body)
+	require.Equal(t, http.StatusCreated, respStatusCode, resp)
+	var created CreateBlueprintResponse
+	require.NoError(t, json.Unmarshal([]byte(resp), &created))
+
+	respStatusCode, resp = tutils.ResponseBody(t, http.MethodGet, apiURL("/blueprints/%s", created.Id), tutils.AuthString0, nil)
+	require.Equal(t, http.StatusOK, respStatusCode, resp)
+	var blueprint BlueprintResponse
+	require.NoError(t, json.Unmarshal([]byte(resp), &blueprint))
+	require.Equal(t, Private, blueprint.Visibility)
+	require.Equal(t, "user@user.user", *blueprint.CreatedBy)
+	require.Equal(t, "user@user.user", *blueprint.UpdatedBy)
+
+	// others in the org don't see private blueprints at all
+	other := tutils.NewIdentity("000000").Email("other@user.user").Base64()
+	respStatusCode, _ = tutils.ResponseBody(t, http.MethodGet, apiURL("/blueprints/%s", created.Id), other, nil)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+	respStatusCode, _ = tutils.ResponseBody(t, http.MethodPost, apiURL("/blueprints/%s/compose", created.Id), other, map[string]interface{}{})
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+	respStatusCode, _ = tutils.ResponseBody(t, http.MethodDelete, apiURL("/blueprints/%s", created.Id), other, nil)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+
+	listed := func(auth, query string) []BlueprintItem {
+		respStatusCode, resp := tutils.ResponseBody(t, http.MethodGet, apiURL("/blueprints%s", query), auth, nil)
+		require.Equal(t, http.StatusOK, respStatusCode, resp)
+		var result BlueprintsResponse
+		require.NoError(t, json.Unmarshal([]byte(resp), &result))
+		require.Equal(t, len(result.Data), result.Meta.Count)
+		return result.Data
+	}
+	require.Len(t, listed(tutils.AuthString0, ""), 2)
+	mine := listed(tutils.AuthString0, "?owner=mine")
+	require.Len(t, mine, 1)
+	require.Equal(t, created.Id, mine[0].Id)
+	require.Equal(t, Private, mine[0].Visibility)
+	shared := listed(tutils.AuthString0, "?owner=shared")
+	require.Len(t, shared, 1)
+	require.Equal(t, legacyId, shared[0].Id)
+	require.Nil(t, shared[0].CreatedBy)
+	require.Len(t, listed(other, ""), 1)
+	require.Len(t, listed(other, "?owner=shared"), 1)
+	require.Len(t, listed(other, "?owner=mine"), 0)
+	require.Len(t, listed(other, "?name=private"), 0)
+	require.Len(t, listed(other, "?search=priv"), 0)
+
+	// only the creator changes the visibility, blueprints without one stay shared
+	respStatusCode, _ = tutils.ResponseBody(t, http.MethodPut, apiURL("/blueprints/%s", legacyId), tutils.AuthString0, body)
+	require.Equal(t, http.StatusForbidden, respStatusCode)
+	body["visibility"] = "org"
+	respStatusCode, resp = tutils.ResponseBody(t, http.MethodPut, apiURL("/blueprints/%s", created.Id), tutils.AuthString0, body)
+	require.Equal(t, http.StatusCreated, respStatusCode, resp)
+	require.Len(t, listed(other, "?owner=shared"), 2)
+
+	body["visibility"] = "private"
+	respStatusCode, _ = tutils.ResponseBody(t, http.MethodPut, apiURL("/blueprints/%s", created.Id), other, body)
+	require.Equal(t, http.StatusForbidden, respStatusCode)
+	delete(body, "visibility")
+	respStatusCode, resp = tutils.ResponseBody(t, http.MethodPut, apiURL("/blueprints/%s", created.Id), other, body)
+	require.Equal(t, http.StatusCreated, respStatusCode, resp)
+	entry, err := dbase.GetBlueprint(ctx, created.Id, "000000", nil)
+	require.NoError(t, err)
+	require.Equal(t, "user@user.user", entry.CreatedBy)
+	require.Equal(t, "other@user.user", entry.UpdatedBy)
+	require.False(t, entry.Private)
+}
@@ -0,0 +1,69 @@
+package v1
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+)
+
+// ErrorCodeCancelled is stored as the error code of cancelled composes.
+const ErrorCodeCancelled = "COMPOSE_CANCELLED"
+
+// CancelCompose stops a compose which is still building, it is recorded as
+// failed and its status doesn't change anymore.
+func (h *Handlers) CancelCompose(ctx echo.Context, composeId openapi_types.UUID) error {
+	composeEntry, err := h.getComposeByIdAndOrgId(ctx, composeId)
+	if err != nil {
+		return err
+	}
+	status := common.FromPtr(composeEntry.Status)
+	if status == string(ImageStatusStatusSuccess) || status == string(ImageStatusStatusFailure) {
+		return echo.NewHTTPError(http.StatusConflict, "Compose already finished")
+	}
+
+	cClient, err := h.server.composerFor(composeEntry.Region)
+	if err != nil {
+		return err
+	}
+	resp, err := cClient.CancelCompose(composeId)
+	if err != nil {
+		return err
+	}
+	defer closeBody(ctx, resp.Body)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode/100 == 2:
+	case resp.StatusCode == http.StatusNotFound:
+		return echo.NewHTTPError(http.StatusNotFound, string(body))
+	case resp.StatusCode == http.StatusConflict:
+		return echo.NewHTTPError(http.StatusConflict, "Compose already finished")
+	default:
+		httpError := echo.NewHTTPError(http.StatusInternalServerError, "Failed cancelling compose in osbuild-composer")
+		_ = httpError.SetInternal(fmt.Errorf("%s", body))
+		return httpError
+	}
+
+	err = h.server.db.SetComposeStatus(ctx.Request().Context(), composeId, string(composer.ComposeStatusValueFailure), common.ToPtr(ErrorCodeCancelled))
+	if err != nil {
+		return err
+	}
+	ctx.Logger().Infof("Cancelled compose %s", composeId)
+
+	composeEntry.Status = common.ToPtr(string(composer.ComposeStatusValueFailure))
+	composeEntry.ErrorCode = common.ToPtr(ErrorCodeCancelled)
+	composeStatus, err := h.composeStatus(ctx, composeEntry)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, composeStatus)
+}
@@ -0,0 +1,134 @@
+package v1
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+// GetComposeBundle exports what's needed to rebuild the image with osbuild
+// on premises: the manifests, the request they were generated from and the
+// packages they resolved to, with checksums.
+func (h *Handlers) GetComposeBundle(ctx echo.Context, composeId uuid.UUID) error {
+	composeEntry, err := h.getComposeByIdAndOrgId(ctx, composeId)
+	if err != nil {
+		return err
+	}
+
+	manifests, err := h.composeManifests(ctx, composeEntry)
+	if err != nil {
+		return err
+	}
+	metadata, err := h.composeMetadata(ctx, composeEntry)
+	if err != nil {
+		return err
+	}
+	var composeRequest ComposeRequest
+	err = h.server.openComposeRequest(composeEntry.Request, &composeRequest)
+	if err != nil {
+		return err
+	}
+	request, err := json.MarshalIndent(composeRequest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	type file struct {
+		name string
+		data []byte
+	}
+	var files []file
+	for i, m := range manifests {
+		files = append(files, file{fmt.Sprintf("manifest-%d.json", i), m})
+	}
+	files = append(files,
+		file{"compose-request.json", request},
+		file{"metadata.json", metadata},
+	)
+	var sums bytes.Buffer
+	for _, f := range files {
+		fmt.Fprintf(&sums, "%x  %s\n", sha256.Sum256(f.data), f.name)
+	}
+	files = append(files, file{"SHA256SUMS", sums.Bytes()})
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	now := time.Now().UTC()
+	prefix := fmt.Sprintf("compose-%s/", composeId)
+	for _, f := range files {
+		err = tw.WriteHeader(&tar.Header{
+			Name:    prefix + f.name,
+			Mode:    0644,
+			Size:    int64(len(f.data)),
+			ModTime: now,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tw.Write(f.data)
+		if err != nil {
+			return err
+		}
+	}
+	err = tw.Close()
+	if err != nil {
+		return err
+	}
+	err = gz.Close()
+	if err != nil {
+		return err
+	}
+
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"compose-%s.tar.gz\"", composeId))
+	return ctx.Blob(http.StatusOK, "application/gzip", buf.Bytes())
+}
+
+// composeManifests returns the osbuild manifests of the compose exactly as
+// composer generated them, one per image request.
+func (h *Handlers) composeManifests(ctx echo.Context, composeEntry *db.ComposeEntry) ([]json.RawMessage, error) {
+	if err := h.server.readOnlyError(); err != nil {
+		return nil, err
+	}
+	cClient, err := h.server.composerFor(composeEntry.Region)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := cClient.ComposeManifests(composeEntry.Id)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(ctx, resp.Body)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, echo.NewHTTPError(http.StatusNotFound, string(body))
+	} else if resp.StatusCode != http.StatusOK {
+		httpError := echo.NewHTTPError(http.StatusInternalServerError, "Failed querying compose manifests")
+		_ = httpError.SetInternal(fmt.Errorf("%s", body))
+		return nil, httpError
+	}
+
+	var cloudManifests struct {
+		Manifests []json.RawMessage `json:"manifests"`
+	}
+	err = json.Unmarshal(body, &cloudManifests)
+	if err != nil {
+		return nil, err
+	}
+	return cloudManifests.Manifests, nil
+}
@@ -0,0 +1,92 @@
+package v1
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestGetComposeBundle(t *testing.T) {
+	ctx := context.Background()
+	composeId := uuid.New()
+	manifest := `{"version":"2","pipelines":[{"name":"os","stages":[]}],"sources":{}}`
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/manifests"):
+			_, err := fmt.Fprintf(w, `{"href": "", "id": "%s", "kind": "ComposeManifests", "manifests": [%s]}`, composeId, manifest)
+			require.NoError(t, err)
+		case strings.HasSuffix(r.URL.Path, "/metadata"):
+			_, err := w.Write([]byte(`{"href": "", "id": "", "kind": "ComposeMetadata", "packages": [{"arch": "x86_64", "name": "bash", "release": "1.el9", "sigmd5": "abc", "type": "rpm", "version": "5.1"}]}`))
+			require.NoError(t, err)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer apiSrv.Close()
+
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	cr := ComposeRequest{
+		Distribution: "rhel-9",
+		Customizations: &Customizations{
+			Packages: &[]string{"bash"},
+		},
+	}
+	crRaw, err := json.Marshal(cr)
+	require.NoError(t, err)
+	err = dbase.InsertCompose(ctx, composeId, "000000", "user000000@test.test", "000000", cr.ImageName, crRaw, (*string)(cr.ClientId), nil, nil, nil)
+	require.NoError(t, err)
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
+		DBase: dbase,
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	statusCode, _ := tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/bundle", uuid.New()), &tutils.AuthString0)
+	require.Equal(t, http.StatusNotFound, statusCode)
+
+	statusCode, body := tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/bundle", composeId), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, statusCode)
+
+	gz, err := gzip.NewReader(bytes.NewReader([]byte(body)))
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		files[strings.TrimPrefix(hdr.Name, fmt.Sprintf("compose-%s/", composeId))] = string(data)
+	}
+
+	require.Len(t, files, 4)
+	require.Equal(t, manifest, files["manifest-0.json"])
+	require.Contains(t, files["compose-request.json"], "rhel-9")
+	require.Contains(t, files["metadata.json"], "bash")
+	for _, name := range []string{"manifest-0.json", "compose-request.json", "metadata.json"} {
+		require.Contains(t, files["SHA256SUMS"], fmt.Sprintf("%x  %s\n", sha256.Sum256([]byte(files[name])), name))
+	}
+}
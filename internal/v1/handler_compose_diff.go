@@ -0,0 +1,224 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+// GetComposeDiff compares the compose with another compose of the org, the
+// changes lead from the compose to the other one.
+func (h *Handlers) GetComposeDiff(ctx echo.Context, composeId uuid.UUID, otherComposeId uuid.UUID) error {
+	composeEntry, err := h.getComposeByIdAndOrgId(ctx, composeId)
+	if err != nil {
+		return err
+	}
+	otherEntry, err := h.getComposeByIdAndOrgId(ctx, otherComposeId)
+	if err != nil {
+		return err
+	}
+
+	var composeRequest, otherRequest ComposeRequest
+	err = h.server.openComposeRequest(composeEntry.Request, &composeRequest)
+	if err != nil {
+		return err
+	}
+	err = h.server.openComposeRequest(otherEntry.Request, &otherRequest)
+	if err != nil {
+		return err
+	}
+	changes, err := requestChanges(composeRequest, otherRequest)
+	if err != nil {
+		return err
+	}
+	diff := ComposeDiff{
+		RequestChanges: changes,
+	}
+
+	packages, err := h.builtPackages(ctx, composeEntry)
+	if err != nil {
+		return err
+	}
+	otherPackages, err := h.builtPackages(ctx, otherEntry)
+	if err != nil {
+		return err
+	}
+	if packages != nil && otherPackages != nil {
+		diff.Packages = common.ToPtr(packageDiff(packages, otherPackages))
+	}
+	return ctx.JSON(http.StatusOK, diff)
+}
+
+// builtPackages returns the packages of the image, nil when the compose
+// failed or hasn't finished yet.
+func (h *Handlers) builtPackages(ctx echo.Context, composeEntry *db.ComposeEntry) ([]PackageMetadata, error) {
+	if common.FromPtr(composeEntry.Status) == string(ImageStatusStatusFailure) {
+		return nil, nil
+	}
+	metadata, err := h.loadComposeMetadata(ctx, composeEntry)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.Packages == nil || len(*metadata.Packages) == 0 {
+		return nil, nil
+	}
+	return *metadata.Packages, nil
+}
+
+// requestChanges lists the fields which differ between the requests, in the
+// order of the field names. A changed package list is a single change.
+func requestChanges(from, to ComposeRequest) ([]ComposeRequestChange, error) {
+	fromDoc, err := jsonDocument(from)
+	if err != nil {
+		return nil, err
+	}
+	toDoc, err := jsonDocument(to)
+	if err != nil {
+		return nil, err
+	}
+	changes := []ComposeRequestChange{}
+	diffDocuments("", fromDoc, toDoc, &changes)
+	return changes, nil
+}
+
+// jsonDocument keeps the numbers as they were written, large image sizes
+// don't turn into floats.
+func jsonDocument(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc interface{}
+	err = decoder.Decode(&doc)
+	return doc, err
+}
+
+func diffDocuments(path string, from, to interface{}, changes *[]ComposeRequestChange) {
+	fromObject, fromIsObject := from.(map[string]interface{})
+	toObject, toIsObject := to.(map[string]interface{})
+	if fromIsObject && toIsObject {
+		keys := make([]string, 0, len(fromObject)+len(toObject))
+		for k := range fromObject {
+			keys = append(keys, k)
+		}
+		for k := range toObject {
+			if _, ok := fromObject[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fieldPath := path + "/" + escapePointer(k)
+			fromValue, inFrom := fromObject[k]
+			toValue, inTo := toObject[k]
+			switch {
+			case !inFrom:
+				*changes = append(*changes, ComposeRequestChange{Path: fieldPath, To: &toValue})
+			case !inTo:
+				*changes = append(*changes, ComposeRequestChange{Path: fieldPath, From: &fromValue})
+			default:
+				diffDocuments(fieldPath, fromValue, toValue, changes)
+			}
+		}
+		return
+	}
+
+	fromArray, fromIsArray := from.([]interface{})
+	toArray, toIsArray := to.([]interface{})
+	if fromIsArray && toIsArray && len(fromArray) == len(toArray) && objects(fromArray) && objects(toArray) {
+		for i := range fromArray {
+			diffDocuments(fmt.Sprintf("%s/%d", path, i), fromArray[i], toArray[i], changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(from, to) {
+		*changes = append(*changes, ComposeRequestChange{Path: path, From: &from, To: &to})
+	}
+}
+
+func objects(values []interface{}) bool {
+	for _, v := range values {
+		if _, ok := v.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// escapePointer escapes a key for a JSON pointer (RFC 6901).
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// packageDiff compares the packages by name and architecture, all lists are
+// sorted by the package name.
+func packageDiff(from, to []PackageMetadata) ComposePackageDiff {
+	packageKey := func(pkg PackageMetadata) string {
+		return pkg.Name + "." + pkg.Arch
+	}
+	fromPackages := make(map[string]PackageMetadata, len(from))
+	for _, pkg := range from {
+		fromPackages[packageKey(pkg)] = pkg
+	}
+	toPackages := make(map[string]PackageMetadata, len(to))
+	for _, pkg := range to {
+		toPackages[packageKey(pkg)] = pkg
+	}
+
+	diff := ComposePackageDiff{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []ComposePackageChange{},
+	}
+	for _, pkg := range to {
+		old, ok := fromPackages[packageKey(pkg)]
+		if !ok {
+			diff.Added = append(diff.Added, nevra(pkg.Name, pkg.Epoch, pkg.Version, pkg.Release, pkg.Arch))
+			continue
+		}
+		if evr(old) != evr(pkg) {
+			diff.Changed = append(diff.Changed, ComposePackageChange{
+				Name: pkg.Name,
+				Arch: pkg.Arch,
+				From: evr(old),
+				To:   evr(pkg),
+			})
+		}
+	}
+	for _, pkg := range from {
+		if _, ok := toPackages[packageKey(pkg)]; !ok {
+			diff.Removed = append(diff.Removed, nevra(pkg.Name, pkg.Epoch, pkg.Version, pkg.Release, pkg.Arch))
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		if diff.Changed[i].Name != diff.Changed[j].Name {
+			return diff.Changed[i].Name < diff.Changed[j].Name
+		}
+		return diff.Changed[i].Arch < diff.Changed[j].Arch
+	})
+	return diff
+}
+
+// evr leaves out the epoch when it is 0, like nevra
+func evr(pkg PackageMetadata) string {
+	if pkg.Epoch != nil && *pkg.Epoch != "" && *pkg.Epoch != "0" {
+		return fmt.Sprintf("%s:%s-%s", *pkg.Epoch, pkg.Version, pkg.Release)
+	}
+	return fmt.Sprintf("%s-%s", pkg.Version, pkg.Release)
+}
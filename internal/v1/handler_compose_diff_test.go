@@ -0,0 +1,182 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestRequestChanges(t *testing.T) {
+	from := ComposeRequest{
+		Distribution: "rhel-9",
+		ImageName:    common.ToPtr("web"),
+		Customizations: &Customizations{
+			Packages: &[]string{"nginx"},
+			Users: &[]User{
+				{Name: "admin", SshKey: "ssh-rsa one"},
+			},
+		},
+		ImageRequests: []ImageRequest{
+			{
+				Architecture: "x86_64",
+				ImageType:    ImageTypesGuestImage,
+				Size:         common.ToPtr(uint64(10737418240)),
+			},
+		},
+	}
+	changes, err := requestChanges(from, from)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	to := from
+	to.Distribution = "rhel-10"
+	to.ImageName = nil
+	to.ImageDescription = common.ToPtr("web server")
+	to.Customizations = &Customizations{
+		Packages: &[]string{"nginx", "vim"},
+		Users: &[]User{
+			{Name: "admin", SshKey: "ssh-rsa two"},
+		},
+	}
+	to.ImageRequests = []ImageRequest{from.ImageRequests[0]}
+	to.ImageRequests[0].Size = common.ToPtr(uint64(21474836480))
+
+	changes, err = requestChanges(from, to)
+	require.NoError(t, err)
+	data, err := json.Marshal(changes)
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{"path": "/customizations/packages", "from": ["nginx"], "to": ["nginx", "vim"]},
+		{"path": "/customizations/users/0/ssh_key", "from": "ssh-rsa one", "to": "ssh-rsa two"},
+		{"path": "/distribution", "from": "rhel-9", "to": "rhel-10"},
+		{"path": "/image_description", "to": "web server"},
+		{"path": "/image_name", "from": "web"},
+		{"path": "/image_requests/0/size", "from": 10737418240, "to": 21474836480}
+	]`, string(data))
+
+	// arrays of objects with a different length change as a whole
+	to = from
+	to.Customizations = &Customizations{
+		Packages: from.Customizations.Packages,
+		Users:    &[]User{{Name: "admin"}, {Name: "guest"}},
+	}
+	changes, err = requestChanges(from, to)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, "/customizations/users", changes[0].Path)
+
+	require.Equal(t, "/a~1b~0c", "/"+escapePointer("a/b~c"))
+}
+
+func TestPackageDiff(t *testing.T) {
+	pkg := func(name, epoch, version, release string) PackageMetadata {
+		p := PackageMetadata{
+			Name:    name,
+			Arch:    "x86_64",
+			Version: version,
+			Release: release,
+		}
+		if epoch != "" {
+			p.Epoch = &epoch
+		}
+		return p
+	}
+	from := []PackageMetadata{
+		pkg("openssl", "1", "3.0.7", "24.el9"),
+		pkg("bash", "", "5.1.8", "6.el9"),
+		pkg("telnet", "", "0.17", "85.el9"),
+		pkg("vim", "2", "8.2.2637", "20.el9"),
+	}
+	to := []PackageMetadata{
+		pkg("vim", "2", "8.2.2637", "20.el9"),
+		pkg("openssl", "1", "3.0.7", "27.el9"),
+		pkg("bash", "0", "5.1.8", "6.el9"),
+		pkg("nginx", "1", "1.20.1", "14.el9"),
+		pkg("curl", "", "7.76.1", "26.el9"),
+	}
+	require.Equal(t, ComposePackageDiff{
+		Added:   []string{"curl-7.76.1-26.el9.x86_64", "nginx-1:1.20.1-14.el9.x86_64"},
+		Removed: []string{"telnet-0.17-85.el9.x86_64"},
+		Changed: []ComposePackageChange{
+			{Name: "openssl", Arch: "x86_64", From: "1:3.0.7-24.el9", To: "1:3.0.7-27.el9"},
+		},
+	}, packageDiff(from, to))
+
+	require.Equal(t, ComposePackageDiff{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []ComposePackageChange{},
+	}, packageDiff(from, from))
+}
+
+func TestGetComposeDiff(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	srv, tokenSrv := startServer(t, &testServerClientsConf{}, &ServerConfig{
+		DBase:            dbase,
+		DistributionsDir: "../../distributions",
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	insertCompose := func(distribution string, packages []PackageMetadata) uuid.UUID {
+		id := uuid.New()
+		request := fmt.Sprintf(`{"distribution": %q, "image_requests": [{"architecture": "x86_64", "image_type": "guest-image", "upload_request": {"type": "aws.s3", "options": {}}}]}`, distribution)
+		err := dbase.InsertCompose(ctx, id, "500000", "user100000@test.test", "000000", nil, json.RawMessage(request), nil, nil, nil, nil)
+		require.NoError(t, err)
+		if packages != nil {
+			metadata, err := json.Marshal(ComposeMetadata{Packages: &packages})
+			require.NoError(t, err)
+			require.NoError(t, dbase.InsertComposeMetadata(ctx, id, metadata))
+		}
+		return id
+	}
+	bash := PackageMetadata{Name: "bash", Arch: "x86_64", Version: "5.1.8", Release: "6.el9"}
+	curl := PackageMetadata{Name: "curl", Arch: "x86_64", Version: "7.76.1", Release: "26.el9"}
+	id1 := insertCompose("rhel-9", []PackageMetadata{bash})
+	id2 := insertCompose("rhel-10", []PackageMetadata{bash, curl})
+
+	getDiff := func(id, otherId uuid.UUID) (int, ComposeDiff) {
+		respStatusCode, body := tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/diff/%s", id, otherId), &tutils.AuthString0)
+		var diff ComposeDiff
+		if respStatusCode == http.StatusOK {
+			require.NoError(t, json.Unmarshal([]byte(body), &diff))
+		}
+		return respStatusCode, diff
+	}
+
+	respStatusCode, diff := getDiff(id1, id2)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.Len(t, diff.RequestChanges, 1)
+	require.Equal(t, "/distribution", diff.RequestChanges[0].Path)
+	require.Equal(t, "rhel-9", *diff.RequestChanges[0].From)
+	require.Equal(t, "rhel-10", *diff.RequestChanges[0].To)
+	require.NotNil(t, diff.Packages)
+	require.Equal(t, []string{"curl-7.76.1-26.el9.x86_64"}, diff.Packages.Added)
+	require.Empty(t, diff.Packages.Removed)
+
+	// the packages of a failed compose are unknown
+	require.NoError(t, dbase.SetComposeStatus(ctx, id2, string(ImageStatusStatusFailure), nil))
+	respStatusCode, diff = getDiff(id1, id2)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.Len(t, diff.RequestChanges, 1)
+	require.Nil(t, diff.Packages)
+
+	// composes of other orgs are not found
+	respStatusCode, _ = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/diff/%s", id1, id2), &tutils.AuthString1)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+	respStatusCode, _ = getDiff(id1, uuid.New())
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+}
@@ -0,0 +1,87 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+const defaultDownloadLinkLifetime = time.Hour
+
+// GetComposeDownload hands out a link to the image of a compose which was
+// uploaded to object storage, valid for the configured lifetime only.
+func (h *Handlers) GetComposeDownload(ctx echo.Context, composeId uuid.UUID) error {
+	if h.server.downloadSigner == nil {
+		return echo.NewHTTPError(http.StatusForbidden, "Download links are not available")
+	}
+	composeEntry, err := h.getComposeByIdAndOrgId(ctx, composeId)
+	if err != nil {
+		return err
+	}
+	return h.composeDownload(ctx, composeEntry)
+}
+
+func (h *Handlers) composeDownload(ctx echo.Context, composeEntry *db.ComposeEntry) error {
+	composeId := composeEntry.Id
+	status, err := h.composeStatus(ctx, composeEntry)
+	if err != nil {
+		return err
+	}
+	if status.ImageStatus.Status != ImageStatusStatusSuccess {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Compose %s didn't finish successfully, there is nothing to download", composeId))
+	}
+	us := status.ImageStatus.UploadStatus
+	if us == nil || us.Type != UploadTypesAwsS3 {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("The image of compose %s wasn't uploaded to object storage, it can't be downloaded", composeId))
+	}
+	upload, err := us.Options.AsAWSS3UploadStatus()
+	if err != nil {
+		return err
+	}
+
+	// the link in the status is signed already, but when it expires isn't
+	// known anymore
+	url, expiresAt, err := h.server.signDownloadURL(upload.Url)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, ComposeDownload{
+		Url:       url,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// signDownloadURL signs the URL of an object for the lifetime of the
+// download links, the signature the URL had is dropped.
+func (s *Server) signDownloadURL(rawURL string) (string, time.Time, error) {
+	expiresAt := time.Now().UTC().Add(s.downloadLifetime).Truncate(time.Second)
+	url, err := s.downloadSigner.SignURL(rawURL, s.downloadLifetime)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return url, expiresAt, nil
+}
+
+// signUploadStatus replaces the URL composer returned for an image uploaded
+// to object storage with a link signed by image-builder, so the status never
+// hands out the bucket of composer. Other upload statuses are left as they
+// are.
+func (s *Server) signUploadStatus(us *UploadStatus) error {
+	if s.downloadSigner == nil || us == nil || us.Type != UploadTypesAwsS3 {
+		return nil
+	}
+	upload, err := us.Options.AsAWSS3UploadStatus()
+	if err != nil {
+		return err
+	}
+	upload.Url, _, err = s.signDownloadURL(upload.Url)
+	if err != nil {
+		return err
+	}
+	return us.Options.FromAWSS3UploadStatus(upload)
+}
@@ -0,0 +1,95 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+type fakeSigner struct{}
+
+func (fakeSigner) SignURL(rawURL string, lifetime time.Duration) (string, error) {
+	return fmt.Sprintf("https://signed.test/%s?expires=%s", strings.TrimPrefix(rawURL, "https://bucket.test/"), lifetime), nil
+}
+
+func TestGetComposeDownload(t *testing.T) {
+	ctx := context.Background()
+	uploaded := uuid.New()
+	ami := uuid.New()
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		var us composer.UploadStatus
+		if strings.HasSuffix(r.URL.Path, uploaded.String()) {
+			require.NoError(t, us.Options.FromAWSS3UploadStatus(composer.AWSS3UploadStatus{
+				Url: "https://bucket.test/image.qcow2",
+			}))
+			us.Type = composer.UploadTypesAwsS3
+		} else {
+			require.NoError(t, us.Options.FromAWSEC2UploadStatus(composer.AWSEC2UploadStatus{
+				Ami:    "ami-0c830793775595d4b",
+				Region: "eu-west-1",
+			}))
+			us.Type = composer.UploadTypesAws
+		}
+		us.Status = composer.Success
+		w.WriteHeader(http.StatusOK)
+		require.NoError(t, json.NewEncoder(w).Encode(composer.ComposeStatus{
+			ImageStatus: composer.ImageStatus{
+				Status:       composer.ImageStatusValueSuccess,
+				UploadStatus: &us,
+			},
+		}))
+	}))
+	defer apiSrv.Close()
+
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	err = dbase.InsertCompose(ctx, uploaded, "500000", "user500000@test.test", "000000", nil, json.RawMessage(`{"image_requests": [{"image_type": "guest-image"}]}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+	err = dbase.InsertCompose(ctx, ami, "500000", "user500000@test.test", "000000", nil, json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
+		DBase:                dbase,
+		DownloadSigner:       fakeSigner{},
+		DownloadLinkLifetime: 15 * time.Minute,
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	before := time.Now()
+	respStatusCode, body := tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/download", uploaded), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode, body)
+	var download ComposeDownload
+	require.NoError(t, json.Unmarshal([]byte(body), &download))
+	require.Equal(t, "https://signed.test/image.qcow2?expires=15m0s", download.Url)
+	require.WithinDuration(t, before.Add(15*time.Minute), download.ExpiresAt, time.Minute)
+
+	// the status doesn't hand out the bucket either
+	respStatusCode, body = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s", uploaded), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode, body)
+	require.Contains(t, body, "https://signed.test/image.qcow2")
+	require.NotContains(t, body, "bucket.test")
+
+	respStatusCode, _ = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/download", ami), &tutils.AuthString0)
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+	respStatusCode, _ = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/download", uuid.New()), &tutils.AuthString0)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+}
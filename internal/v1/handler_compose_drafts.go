@@ -0,0 +1,189 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+const (
+	defaultDraftLimit = 20
+	// drafts hold what the wizard was filled in with, not whole files
+	maxDraftSize = 256 * 1024
+)
+
+func (h *Handlers) composeDraftFromEntry(e *db.ComposeDraftEntry) (ComposeDraft, error) {
+	draft := ComposeDraft{
+		Id:        e.Id,
+		Name:      e.Name,
+		CreatedAt: e.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: e.UpdatedAt.Format(time.RFC3339),
+	}
+	raw, err := h.server.decryptCustomizations(e.Request)
+	if err != nil {
+		return ComposeDraft{}, err
+	}
+	err = json.Unmarshal(raw, &draft.Request)
+	return draft, err
+}
+
+// sealComposeDraft marshals the request of the draft for storage. Like the
+// templates, drafts keep their secrets even with redaction enabled, they're
+// submitted once completed. The customizations are encrypted instead.
+func (h *Handlers) sealComposeDraft(request map[string]interface{}) (json.RawMessage, error) {
+	raw, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > maxDraftSize {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("The draft is larger than %d KiB", maxDraftSize/1024))
+	}
+	return h.server.encryptCustomizations(raw)
+}
+
+func (h *Handlers) GetComposeDrafts(ctx echo.Context) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.server.db.GetComposeDrafts(ctx.Request().Context(), userID.OrgID(), userID.Email())
+	if err != nil {
+		return err
+	}
+
+	data := make([]ComposeDraft, 0, len(entries))
+	for i := range entries {
+		draft, err := h.composeDraftFromEntry(&entries[i])
+		if err != nil {
+			return err
+		}
+		data = append(data, draft)
+	}
+	return ctx.JSON(http.StatusOK, ComposeDraftsResponse{
+		Data: data,
+	})
+}
+
+func (h *Handlers) CreateComposeDraft(ctx echo.Context) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	var request CreateComposeDraftJSONRequestBody
+	err = ctx.Bind(&request)
+	if err != nil {
+		return err
+	}
+	sealed, err := h.sealComposeDraft(request.Request)
+	if err != nil {
+		return err
+	}
+
+	entry := db.ComposeDraftEntry{
+		Id:      uuid.New(),
+		OrgId:   userID.OrgID(),
+		Email:   userID.Email(),
+		Name:    common.FromPtr(request.Name),
+		Request: sealed,
+	}
+	err = h.server.db.InsertComposeDraft(ctx.Request().Context(), h.server.draftLimit, &entry)
+	if err != nil {
+		if errors.Is(err, db.ComposeDraftLimitError) {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, fmt.Sprintf("No more than %d drafts can be kept, delete one of them first", h.server.draftLimit))
+		}
+		return err
+	}
+	ctx.Logger().Infof("Saved compose draft %s", entry.Id)
+
+	draft, err := h.composeDraftFromEntry(&entry)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusCreated, draft)
+}
+
+func (h *Handlers) GetComposeDraft(ctx echo.Context, id openapi_types.UUID) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	entry, err := h.server.db.GetComposeDraft(ctx.Request().Context(), id, userID.OrgID(), userID.Email())
+	if err != nil {
+		if errors.Is(err, db.ComposeDraftNotFoundError) {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Compose draft %s not found", id))
+		}
+		return err
+	}
+	draft, err := h.composeDraftFromEntry(entry)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, draft)
+}
+
+func (h *Handlers) UpdateComposeDraft(ctx echo.Context, id openapi_types.UUID) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	var request UpdateComposeDraftJSONRequestBody
+	err = ctx.Bind(&request)
+	if err != nil {
+		return err
+	}
+	sealed, err := h.sealComposeDraft(request.Request)
+	if err != nil {
+		return err
+	}
+
+	entry := db.ComposeDraftEntry{
+		Id:      id,
+		OrgId:   userID.OrgID(),
+		Email:   userID.Email(),
+		Name:    common.FromPtr(request.Name),
+		Request: sealed,
+	}
+	err = h.server.db.UpdateComposeDraft(ctx.Request().Context(), &entry)
+	if err != nil {
+		if errors.Is(err, db.ComposeDraftNotFoundError) {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Compose draft %s not found", id))
+		}
+		return err
+	}
+
+	draft, err := h.composeDraftFromEntry(&entry)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, draft)
+}
+
+func (h *Handlers) DeleteComposeDraft(ctx echo.Context, id openapi_types.UUID) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = h.server.db.DeleteComposeDraft(ctx.Request().Context(), id, userID.OrgID(), userID.Email())
+	if err != nil {
+		if errors.Is(err, db.ComposeDraftNotFoundError) {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Compose draft %s not found", id))
+		}
+		return err
+	}
+	ctx.Logger().Infof("Deleted compose draft %s", id)
+	return ctx.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,89 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestComposeDrafts(t *testing.T) {
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	startTestServer(t, &testServerClientsConf{}, &ServerConfig{
+		DBase:      dbase,
+		DraftLimit: 2,
+	})
+
+	respStatusCode, body := tutils.PostResponseBody(t, apiURL("/drafts"), ComposeDraftRequest{
+		Name: common.ToPtr("web server"),
+		Request: map[string]interface{}{
+			"distribution": "rhel-9",
+			"customizations": map[string]interface{}{
+				"users": []interface{}{map[string]interface{}{"name": "admin", "password": "secret"}},
+			},
+		},
+	})
+	require.Equal(t, http.StatusCreated, respStatusCode)
+	var draft ComposeDraft
+	require.NoError(t, json.Unmarshal([]byte(body), &draft))
+	require.Equal(t, "web server", draft.Name)
+	require.Equal(t, "rhel-9", draft.Request["distribution"])
+
+	respStatusCode, _ = tutils.PutResponseBody(t, apiURL("/drafts/%s", draft.Id), ComposeDraftRequest{
+		Name: common.ToPtr("web server"),
+		Request: map[string]interface{}{
+			"distribution": "rhel-10",
+		},
+	})
+	require.Equal(t, http.StatusOK, respStatusCode)
+
+	respStatusCode, body = tutils.GetResponseBody(t, apiURL("/drafts/%s", draft.Id), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.NoError(t, json.Unmarshal([]byte(body), &draft))
+	require.Equal(t, map[string]interface{}{"distribution": "rhel-10"}, draft.Request)
+	// drafts of other users are not found, not even within the org
+	respStatusCode, _ = tutils.GetResponseBody(t, apiURL("/drafts/%s", draft.Id), &tutils.AuthString1)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+	colleague := tutils.NewIdentity("000000").Email("colleague@user.user").Base64()
+	respStatusCode, _ = tutils.GetResponseBody(t, apiURL("/drafts/%s", draft.Id), &colleague)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+	respStatusCode, _ = tutils.ResponseBody(t, http.MethodDelete, apiURL("/drafts/%s", draft.Id), colleague, nil)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+
+	respStatusCode, _ = tutils.PostResponseBody(t, apiURL("/drafts"), ComposeDraftRequest{
+		Request: map[string]interface{}{},
+	})
+	require.Equal(t, http.StatusCreated, respStatusCode)
+	// the limit is reached
+	respStatusCode, _ = tutils.PostResponseBody(t, apiURL("/drafts"), ComposeDraftRequest{
+		Request: map[string]interface{}{},
+	})
+	require.Equal(t, http.StatusUnprocessableEntity, respStatusCode)
+
+	respStatusCode, body = tutils.GetResponseBody(t, apiURL("/drafts"), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var drafts ComposeDraftsResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &drafts))
+	require.Len(t, drafts.Data, 2)
+	// the most recently updated one first
+	require.Equal(t, "", drafts.Data[0].Name)
+	require.Equal(t, draft.Id, drafts.Data[1].Id)
+
+	respStatusCode, _ = tutils.PutResponseBody(t, apiURL("/drafts/%s", draft.Id), ComposeDraftRequest{
+		Request: map[string]interface{}{
+			"image_description": strings.Repeat("a", maxDraftSize),
+		},
+	})
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+
+	respStatusCode, _ = tutils.DeleteResponseBody(t, apiURL("/drafts/%s", draft.Id))
+	require.Equal(t, http.StatusNoContent, respStatusCode)
+	respStatusCode, _ = tutils.DeleteResponseBody(t, apiURL("/drafts/%s", draft.Id))
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+}
@@ -0,0 +1,90 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+
+	"github.com/osbuild/image-builder/internal/watcher"
+)
+
+// GetComposeEvents streams the status of the compose as server-sent events
+// until it succeeded or failed, polling it for the client.
+func (h *Handlers) GetComposeEvents(ctx echo.Context, composeId openapi_types.UUID) error {
+	// the compose is looked up every time, it may get cancelled or deleted
+	updates := watcher.Watch(ctx.Request().Context(), h.server.statusInterval, func(context.Context) (ImageStatus, bool, error) {
+		composeEntry, err := h.getComposeByIdAndOrgId(ctx, composeId)
+		if err != nil {
+			return ImageStatus{}, false, err
+		}
+		status, err := h.composeStatus(ctx, composeEntry)
+		if err != nil {
+			return ImageStatus{}, false, err
+		}
+		s := status.ImageStatus.Status
+		return status.ImageStatus, s == ImageStatusStatusSuccess || s == ImageStatusStatusFailure, nil
+	})
+
+	// the first status fails the request like the compose status would
+	first, ok := <-updates
+	if !ok {
+		return nil
+	}
+	if first.Err != nil {
+		return first.Err
+	}
+
+	resp := ctx.Response()
+	resp.Header().Set(echo.HeaderContentType, "text/event-stream")
+	resp.Header().Set(echo.HeaderCacheControl, "no-cache")
+	resp.WriteHeader(http.StatusOK)
+	err := writeStatusEvent(resp, first.Status)
+	for update := range updates {
+		if err != nil {
+			// the client is gone, the watch ends with the request
+			continue
+		}
+		if update.Err != nil {
+			err = writeErrorEvent(ctx, update.Err)
+			continue
+		}
+		err = writeStatusEvent(resp, update.Status)
+	}
+	return nil
+}
+
+func writeStatusEvent(resp *echo.Response, status ImageStatus) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(resp, "event: status\ndata: %s\n\n", data)
+	resp.Flush()
+	return err
+}
+
+// writeErrorEvent reports the error the way the error handler would, the
+// response is already underway.
+func writeErrorEvent(ctx echo.Context, err error) error {
+	he, ok := err.(*echo.HTTPError)
+	if !ok {
+		ctx.Logger().Errorf("Failed to get the status of the streamed compose: %v", err)
+		he = echo.NewHTTPError(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+	}
+	data, err := json.Marshal(HTTPError{
+		Code:   errorCode(he),
+		Title:  strconv.Itoa(he.Code),
+		Detail: fmt.Sprintf("%v", he.Message),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(ctx.Response(), "event: error\ndata: %s\n\n", data)
+	ctx.Response().Flush()
+	return err
+}
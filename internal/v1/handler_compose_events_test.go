@@ -0,0 +1,90 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestGetComposeEvents(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+
+	var statuses []string
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.Equal(t, "Bearer accesstoken", r.Header.Get("Authorization"))
+		if len(statuses) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		status := statuses[0]
+		statuses = statuses[1:]
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err := fmt.Fprintf(w, `{"href": "/status", "id": "id", "kind": "ComposeStatus", "status": "pending", "image_status": {"status": "%s"}}`, status)
+		require.NoError(t, err)
+	}))
+	defer apiSrv.Close()
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
+		DBase:          dbase,
+		StatusInterval: time.Millisecond,
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	id := uuid.New()
+	err = dbase.InsertCompose(ctx, id, "500000", "user000000@test.test", "000000", nil, json.RawMessage(`{}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+	url := fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/events", id)
+
+	// only the transitions are pushed, the stream ends with the compose
+	statuses = []string{"building", "building", "uploading", "uploading", "success"}
+	respStatusCode, body := tutils.GetResponseBody(t, url, &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.Equal(t, `event: status
+data: {"status":"building"}
+
+event: status
+data: {"status":"uploading"}
+
+event: status
+data: {"status":"success"}
+
+`, body)
+	require.Empty(t, statuses)
+
+	statuses = []string{"building"}
+	respStatusCode, body = tutils.GetResponseBody(t, url, &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.Equal(t, `event: status
+data: {"status":"building"}
+
+event: error
+data: {"detail":"Failed querying compose status","title":"500"}
+
+`, body)
+
+	respStatusCode, _ = tutils.GetResponseBody(t, url, &tutils.AuthString0)
+	require.Equal(t, http.StatusInternalServerError, respStatusCode)
+
+	respStatusCode, _ = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/events", uuid.New()), &tutils.AuthString0)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+}
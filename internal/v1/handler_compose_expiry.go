@@ -0,0 +1,52 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+// ExtendComposeExpiry postpones the deletion of the artifacts of the compose,
+// it can't bring it forward nor make composes which are kept expire.
+func (h *Handlers) ExtendComposeExpiry(ctx echo.Context, composeId uuid.UUID) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	composeEntry, err := h.getComposeByIdAndOrgId(ctx, composeId)
+	if err != nil {
+		return err
+	}
+
+	var request ComposeExpiry
+	err = ctx.Bind(&request)
+	if err != nil {
+		return err
+	}
+	if composeEntry.ExpiresAt == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Compose %s does not expire", composeId))
+	}
+	expiresAt := request.ExpiresAt.UTC()
+	if !expiresAt.After(*composeEntry.ExpiresAt) {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Compose %s expires at %s already, it can only be extended", composeId, composeEntry.ExpiresAt.UTC().Format(time.RFC3339)))
+	}
+
+	err = h.server.db.SetComposeExpiry(ctx.Request().Context(), composeId, userID.OrgID(), expiresAt)
+	if errors.Is(err, db.ComposeNotFoundError) {
+		return echo.NewHTTPError(http.StatusNotFound, err)
+	}
+	if err != nil {
+		return err
+	}
+	ctx.Logger().Infof("Extended the expiry of compose %s of org %s to %s", composeId, userID.OrgID(), expiresAt)
+
+	return ctx.JSON(http.StatusOK, ComposeExpiry{
+		ExpiresAt: expiresAt,
+	})
+}
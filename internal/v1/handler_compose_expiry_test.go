@@ -0,0 +1,72 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestComposeTTL(t *testing.T) {
+	srv := &Server{composeExpiry: 48 * time.Hour}
+	require.Equal(t, 48*time.Hour, srv.composeTTL(nil))
+	require.Equal(t, 48*time.Hour, srv.composeTTL(&OrgPolicy{}))
+	require.Equal(t, 7*24*time.Hour, srv.composeTTL(&OrgPolicy{ComposeExpiryDays: common.ToPtr(7)}))
+	require.Equal(t, time.Duration(0), (&Server{}).composeTTL(nil))
+}
+
+func TestExtendComposeExpiry(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{}, &ServerConfig{
+		DBase: dbase,
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	kept := uuid.New()
+	err = dbase.InsertCompose(ctx, kept, "500000", "user000000@test.test", "000000", nil, json.RawMessage(`{}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+	expiring := uuid.New()
+	err = dbase.InsertCompose(ctx, expiring, "500000", "user000000@test.test", "000000", nil, json.RawMessage(`{}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+	expiresAt := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+	require.NoError(t, dbase.SetComposeExpiry(ctx, expiring, "000000", expiresAt))
+
+	url := fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/expiry", expiring)
+	extended := expiresAt.Add(24 * time.Hour)
+	respStatusCode, body := tutils.PutResponseBody(t, url, ComposeExpiry{ExpiresAt: extended})
+	require.Equal(t, http.StatusOK, respStatusCode, body)
+	var result ComposeExpiry
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.True(t, extended.Equal(result.ExpiresAt))
+
+	// the expiry is listed with the compose
+	respStatusCode, body = tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/composes?fields=id&fields=expires_at", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.Contains(t, body, extended.Format(time.RFC3339))
+
+	// expiry can't be brought forward
+	respStatusCode, _ = tutils.PutResponseBody(t, url, ComposeExpiry{ExpiresAt: expiresAt})
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+
+	// composes which are kept don't start expiring
+	respStatusCode, _ = tutils.PutResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/expiry", kept), ComposeExpiry{ExpiresAt: extended})
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+
+	respStatusCode, _ = tutils.PutResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/expiry", uuid.New()), ComposeExpiry{ExpiresAt: extended})
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+}
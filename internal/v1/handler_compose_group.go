@@ -0,0 +1,138 @@
+package v1
+
+import (
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// splitImageRequest returns an image request for each architecture of the
+// image request, its own architecture first.
+func splitImageRequest(imageRequest ImageRequest) []ImageRequest {
+	archs := []ImageRequestArchitecture{imageRequest.Architecture}
+	if imageRequest.AdditionalArchitectures != nil {
+		for _, arch := range *imageRequest.AdditionalArchitectures {
+			if !slices.Contains(archs, ImageRequestArchitecture(arch)) {
+				archs = append(archs, ImageRequestArchitecture(arch))
+			}
+		}
+	}
+	imageRequests := make([]ImageRequest, 0, len(archs))
+	for _, arch := range archs {
+		split := imageRequest
+		split.Architecture = arch
+		split.AdditionalArchitectures = nil
+		imageRequests = append(imageRequests, split)
+	}
+	return imageRequests
+}
+
+// handleComposeGroup submits a compose for each architecture of the request
+// and tracks them as a group, the compose of the first architecture answers
+// for it. Composes submitted before one of them failed stay in the group.
+func (h *Handlers) handleComposeGroup(ctx echo.Context, composeRequest ComposeRequest) (ComposeResponse, error) {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return ComposeResponse{}, err
+	}
+	groupId := uuid.New()
+	err = h.server.db.InsertComposeGroup(ctx.Request().Context(), groupId, userID.OrgID())
+	if err != nil {
+		return ComposeResponse{}, err
+	}
+
+	var groupResponse ComposeResponse
+	var warnings []string
+	for i, imageRequest := range splitImageRequest(composeRequest.ImageRequests[0]) {
+		archRequest := composeRequest
+		archRequest.ImageRequests = []ImageRequest{imageRequest}
+		composeResponse, err := h.handleCommonCompose(ctx, archRequest, nil, nil)
+		if err != nil {
+			return ComposeResponse{}, err
+		}
+		err = h.server.db.AddComposeToGroup(ctx.Request().Context(), groupId, composeResponse.Id)
+		if err != nil {
+			return ComposeResponse{}, err
+		}
+		if i == 0 {
+			groupResponse = composeResponse
+		}
+		if composeResponse.Warnings != nil {
+			for _, w := range *composeResponse.Warnings {
+				if !slices.Contains(warnings, w) {
+					warnings = append(warnings, w)
+				}
+			}
+		}
+	}
+	ctx.Logger().Infof("Submitted compose group %s", groupId)
+
+	groupResponse.GroupId = &groupId
+	groupResponse.Warnings = nil
+	if len(warnings) > 0 {
+		groupResponse.Warnings = &warnings
+	}
+	return groupResponse, nil
+}
+
+// composeGroupStatus asks about the status of every compose of the group, the
+// compose the status was asked for is already known.
+func (h *Handlers) composeGroupStatus(ctx echo.Context, groupId uuid.UUID, known ComposeStatus, knownId uuid.UUID) (*ComposeGroupStatus, error) {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	composeEntries, err := h.server.db.GetComposeGroup(ctx.Request().Context(), groupId, userID.OrgID())
+	if err != nil {
+		return nil, err
+	}
+
+	group := ComposeGroupStatus{
+		Id:       groupId,
+		Composes: make([]ComposeGroupMember, 0, len(composeEntries)),
+	}
+	for i := range composeEntries {
+		status := known
+		if composeEntries[i].Id != knownId {
+			status, err = h.composeStatus(ctx, &composeEntries[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+		group.Composes = append(group.Composes, ComposeGroupMember{
+			Id:           composeEntries[i].Id,
+			Architecture: string(status.Request.ImageRequests[0].Architecture),
+			ImageStatus:  status.ImageStatus,
+		})
+	}
+	group.ImageStatus = groupImageStatus(group.Composes)
+	return &group, nil
+}
+
+// groupImageStatus sums up the statuses of the composes of a group. A single
+// failure fails the group, which carries its error, and the group only
+// succeeds once all of them did.
+func groupImageStatus(composes []ComposeGroupMember) ImageStatus {
+	succeeded, pending := 0, 0
+	for _, c := range composes {
+		switch c.ImageStatus.Status {
+		case ImageStatusStatusFailure:
+			return ImageStatus{
+				Status: ImageStatusStatusFailure,
+				Error:  c.ImageStatus.Error,
+			}
+		case ImageStatusStatusSuccess:
+			succeeded++
+		case ImageStatusStatusPending:
+			pending++
+		}
+	}
+	switch {
+	case succeeded == len(composes):
+		return ImageStatus{Status: ImageStatusStatusSuccess}
+	case pending == len(composes):
+		return ImageStatus{Status: ImageStatusStatusPending}
+	}
+	return ImageStatus{Status: ImageStatusStatusBuilding}
+}
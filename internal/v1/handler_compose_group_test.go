@@ -0,0 +1,166 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestSplitImageRequest(t *testing.T) {
+	imageRequest := ImageRequest{
+		Architecture: ImageRequestArchitectureX8664,
+		ImageType:    ImageTypesGuestImage,
+	}
+	require.Equal(t, []ImageRequest{imageRequest}, splitImageRequest(imageRequest))
+
+	imageRequest.AdditionalArchitectures = &[]ImageRequestAdditionalArchitectures{
+		ImageRequestAdditionalArchitecturesX8664,
+		ImageRequestAdditionalArchitecturesAarch64,
+	}
+	split := splitImageRequest(imageRequest)
+	require.Len(t, split, 2)
+	require.Equal(t, ImageRequestArchitectureX8664, split[0].Architecture)
+	require.Equal(t, ImageRequestArchitectureAarch64, split[1].Architecture)
+	for _, ir := range split {
+		require.Nil(t, ir.AdditionalArchitectures)
+		require.Equal(t, ImageTypesGuestImage, ir.ImageType)
+	}
+}
+
+func TestGroupImageStatus(t *testing.T) {
+	members := func(statuses ...ImageStatusStatus) []ComposeGroupMember {
+		var composes []ComposeGroupMember
+		for _, s := range statuses {
+			composes = append(composes, ComposeGroupMember{ImageStatus: ImageStatus{Status: s}})
+		}
+		return composes
+	}
+	require.Equal(t, ImageStatusStatusSuccess, groupImageStatus(members(ImageStatusStatusSuccess, ImageStatusStatusSuccess)).Status)
+	require.Equal(t, ImageStatusStatusPending, groupImageStatus(members(ImageStatusStatusPending, ImageStatusStatusPending)).Status)
+	require.Equal(t, ImageStatusStatusBuilding, groupImageStatus(members(ImageStatusStatusSuccess, ImageStatusStatusPending)).Status)
+	require.Equal(t, ImageStatusStatusBuilding, groupImageStatus(members(ImageStatusStatusUploading, ImageStatusStatusSuccess)).Status)
+
+	failed := members(ImageStatusStatusSuccess, ImageStatusStatusFailure)
+	failed[1].ImageStatus.Error = &ComposeStatusError{Reason: "osbuild failed"}
+	status := groupImageStatus(failed)
+	require.Equal(t, ImageStatusStatusFailure, status.Status)
+	require.Equal(t, "osbuild failed", status.Error.Reason)
+}
+
+func TestComposeGroup(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var composed []composer.ComposeRequest
+	statuses := map[string]composer.ImageStatusValue{}
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.Equal(t, "Bearer accesstoken", r.Header.Get("Authorization"))
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			var cr composer.ComposeRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&cr))
+			composed = append(composed, cr)
+			id := uuid.New()
+			statuses[id.String()] = composer.ImageStatusValueSuccess
+			if cr.ImageRequest.Architecture == "aarch64" {
+				statuses[id.String()] = composer.ImageStatusValueBuilding
+			}
+			w.WriteHeader(http.StatusCreated)
+			require.NoError(t, json.NewEncoder(w).Encode(composer.ComposeId{Id: id}))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		require.NoError(t, json.NewEncoder(w).Encode(composer.ComposeStatus{
+			ImageStatus: composer.ImageStatus{
+				Status: statuses[path.Base(r.URL.Path)],
+			},
+		}))
+	}))
+	defer apiSrv.Close()
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
+		DBase:            dbase,
+		DistributionsDir: "../../distributions",
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	var uo UploadRequest_Options
+	require.NoError(t, uo.FromAWSS3UploadRequestOptions(AWSS3UploadRequestOptions{}))
+	payload := ComposeRequest{
+		Distribution: "centos-9",
+		ImageRequests: []ImageRequest{
+			{
+				Architecture: ImageRequestArchitectureX8664,
+				AdditionalArchitectures: &[]ImageRequestAdditionalArchitectures{
+					ImageRequestAdditionalArchitecturesAarch64,
+				},
+				ImageType: ImageTypesGuestImage,
+				UploadRequest: UploadRequest{
+					Type:    UploadTypesAwsS3,
+					Options: uo,
+				},
+			},
+		},
+	}
+	respStatusCode, body := tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/compose", payload)
+	require.Equal(t, http.StatusCreated, respStatusCode, body)
+	var result ComposeResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.NotNil(t, result.GroupId)
+	require.Len(t, composed, 2)
+	require.Equal(t, "x86_64", composed[0].ImageRequest.Architecture)
+	require.Equal(t, "aarch64", composed[1].ImageRequest.Architecture)
+
+	group, err := dbase.GetComposeGroup(ctx, *result.GroupId, "000000")
+	require.NoError(t, err)
+	require.Len(t, group, 2)
+	require.Equal(t, result.Id, group[0].Id)
+
+	for _, entry := range group {
+		respStatusCode, body = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s", entry.Id), &tutils.AuthString0)
+		require.Equal(t, http.StatusOK, respStatusCode)
+		var status ComposeStatus
+		require.NoError(t, json.Unmarshal([]byte(body), &status))
+		// every compose is stored for its architecture alone
+		require.Nil(t, status.Request.ImageRequests[0].AdditionalArchitectures)
+		require.Equal(t, *result.GroupId, status.Group.Id)
+		require.Equal(t, ImageStatusStatusBuilding, status.Group.ImageStatus.Status)
+		require.Len(t, status.Group.Composes, 2)
+		require.Equal(t, "x86_64", status.Group.Composes[0].Architecture)
+		require.Equal(t, ImageStatusStatusSuccess, status.Group.Composes[0].ImageStatus.Status)
+		require.Equal(t, "aarch64", status.Group.Composes[1].Architecture)
+		require.Equal(t, ImageStatusStatusBuilding, status.Group.Composes[1].ImageStatus.Status)
+	}
+
+	mu.Lock()
+	statuses[group[1].Id.String()] = composer.ImageStatusValueSuccess
+	mu.Unlock()
+	respStatusCode, body = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s", result.Id), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var status ComposeStatus
+	require.NoError(t, json.Unmarshal([]byte(body), &status))
+	require.Equal(t, ImageStatusStatusSuccess, status.Group.ImageStatus.Status)
+}
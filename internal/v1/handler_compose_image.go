@@ -1,20 +1,25 @@
 package v1
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
 	"slices"
 	"strings"
 	"time"
 
 	"github.com/osbuild/image-builder/internal/clients/composer"
 	"github.com/osbuild/image-builder/internal/clients/content_sources"
-	"github.com/osbuild/image-builder/internal/clients/provisioning"
 	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
 	"github.com/osbuild/image-builder/internal/distribution"
+	"github.com/osbuild/image-builder/internal/redact"
+	iberrors "github.com/osbuild/image-builder/pkg/errors"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -26,7 +31,7 @@ func (h *Handlers) ComposeImage(ctx echo.Context) error {
 	if err != nil {
 		return err
 	}
-	composeResponse, err := h.handleCommonCompose(ctx, composeRequest, nil)
+	composeResponse, err := h.submitComposeRequest(ctx, composeRequest)
 	if err != nil {
 		ctx.Logger().Errorf("Failed to compose image: %v", err)
 		return err
@@ -35,73 +40,142 @@ func (h *Handlers) ComposeImage(ctx echo.Context) error {
 	return ctx.JSON(http.StatusCreated, composeResponse)
 }
 
-func (h *Handlers) handleCommonCompose(ctx echo.Context, composeRequest ComposeRequest, blueprintVersionId *uuid.UUID) (ComposeResponse, error) {
+// submitComposeRequest submits the compose request as a pipeline, a group
+// of composes when it's built for several architectures, or a single compose.
+func (h *Handlers) submitComposeRequest(ctx echo.Context, composeRequest ComposeRequest) (ComposeResponse, error) {
+	if composeRequest.Pipeline != nil {
+		return h.handleComposePipeline(ctx, composeRequest)
+	}
+	if len(composeRequest.ImageRequests) == 1 && len(splitImageRequest(composeRequest.ImageRequests[0])) > 1 {
+		return h.handleComposeGroup(ctx, composeRequest)
+	}
+	return h.handleCommonCompose(ctx, composeRequest, nil, nil)
+}
+
+// preparedCompose is a compose request which passed all checks, translated
+// for composer.
+type preparedCompose struct {
+	userID          *Identity
+	distro          *distribution.DistributionFile
+	arch            *distribution.Architecture
+	secrets         []string
+	policy          *OrgPolicy
+	priorityLane    bool
+	injected        *InjectedDefaults
+	composerRequest composer.ComposeRequest
+}
+
+// prepareCompose runs all checks of the compose request and translates it
+// for composer, without submitting it.
+func (h *Handlers) prepareCompose(ctx echo.Context, composeRequest *ComposeRequest) (*preparedCompose, error) {
 	userID, err := h.server.getIdentity(ctx)
 	if err != nil {
-		return ComposeResponse{}, err
+		return nil, err
 	}
+	ctx.Logger().Debugf("Compose request for org %s: %s", userID.OrgID(), redact.Value(*composeRequest, redact.ComposeRequest))
 
-	quotaOk, err := common.CheckQuota(ctx.Request().Context(), userID.OrgID(), h.server.db, h.server.quotaFile)
+	priorityLane, err := h.server.priorityLaneOpen(ctx, userID, composeRequest)
 	if err != nil {
-		return ComposeResponse{}, err
+		return nil, err
 	}
-	if !quotaOk {
-		return ComposeResponse{}, echo.NewHTTPError(http.StatusForbidden, "Quota exceeded for user")
+
+	// spares composer the requests which are over the quota already, the
+	// reservation of the slot settles it for concurrent ones
+	quota, err := h.server.quota(ctx, userID.OrgID())
+	if err != nil {
+		return nil, err
+	}
+	if quota != nil && !priorityLane {
+		remaining, err := common.RemainingQuotaOf(ctx.Request().Context(), userID.OrgID(), h.server.db, *quota)
+		if err != nil {
+			return nil, err
+		}
+		if remaining == 0 {
+			return nil, apiError(iberrors.CodeQuotaExceeded, http.StatusForbidden, "Quota exceeded for user")
+		}
 	}
 
 	if string(composeRequest.ImageRequests[0].UploadRequest.Type) == "" {
-		return ComposeResponse{}, echo.NewHTTPError(http.StatusBadRequest, "Exactly one upload request should be included")
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Exactly one upload request should be included")
 	}
 
 	d, err := h.server.getDistro(ctx, composeRequest.Distribution)
 	if err != nil {
-		return ComposeResponse{}, err
+		return nil, err
 	}
 
 	arch, err := d.Architecture(string(composeRequest.ImageRequests[0].Architecture))
 	if err != nil {
-		return ComposeResponse{}, err
+		return nil, err
+	}
+
+	// the defaults count towards the policy like the customizations of the
+	// request itself
+	defaults, err := h.orgDefaults(ctx, userID.OrgID())
+	if err != nil {
+		return nil, err
+	}
+	var injected *InjectedDefaults
+	if defaults != nil {
+		injected = injectOrgDefaults(composeRequest, *defaults)
 	}
 
 	var customizations *composer.Customizations
 	customizations, err = h.buildCustomizations(ctx, composeRequest.Customizations, composeRequest.ImageRequests[0].SnapshotDate)
 	if err != nil {
 		ctx.Logger().Errorf("Failed building customizations: %v", err)
-		return ComposeResponse{}, echo.NewHTTPError(http.StatusInternalServerError, "Unable to build customizations")
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Unable to build customizations")
 	}
 
 	var repositories []composer.Repository
 	if composeRequest.ImageRequests[0].SnapshotDate != nil {
 		repoURLs := []string{}
 		for _, r := range arch.Repositories {
-			if len(r.ImageTypeTags) == 0 || slices.Contains(r.ImageTypeTags, string(composeRequest.ImageRequests[0].ImageType)) {
+			if len(r.ImageTypeTags) == 0 || slices.Contains(r.ImageTypeTags, string(canonicalImageType(composeRequest.ImageRequests[0].ImageType))) {
 				repoURLs = append(repoURLs, *r.Baseurl)
 			}
 		}
 
 		repositories, _, err = h.buildRepositorySnapshots(ctx, repoURLs, false, *composeRequest.ImageRequests[0].SnapshotDate)
 		if err != nil {
-			return ComposeResponse{}, err
+			return nil, err
 		}
 
 		// A sanity check to make sure there's a snapshot for each repo
 		expected := len(buildRepositories(arch, composeRequest.ImageRequests[0].ImageType))
 		if len(repositories) != expected {
-			return ComposeResponse{}, fmt.Errorf("No snapshots found for all repositories (found %d, expected %d)", len(repositories), expected)
+			return nil, fmt.Errorf("No snapshots found for all repositories (found %d, expected %d)", len(repositories), expected)
 		}
 
 	} else {
 		repositories = buildRepositories(arch, composeRequest.ImageRequests[0].ImageType)
 	}
 
-	uploadOptions, imageType, err := h.buildUploadOptions(ctx, composeRequest.ImageRequests[0].UploadRequest, composeRequest.ImageRequests[0].ImageType)
+	uploadOptions, imageType, err := h.buildUploadOptions(ctx, composeRequest.ImageRequests[0].UploadRequest, composeRequest.ImageRequests[0].ImageType, composeRequest.ImageRequests[0].Architecture)
 	if err != nil {
-		return ComposeResponse{}, err
+		return nil, err
 	}
 
-	err = validateComposeRequest(&composeRequest)
+	err = validateComposeRequest(composeRequest)
 	if err != nil {
-		return ComposeResponse{}, err
+		return nil, err
+	}
+	err = validatePackageGroups(arch, composeRequest.Customizations)
+	if err != nil {
+		return nil, err
+	}
+	err = validatePackageConflicts(d, composeRequest.Customizations)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := scanComposeRequest(composeRequest)
+	policy, err := h.checkOrgPolicy(ctx, userID.OrgID(), composeRequest, secrets)
+	if err != nil {
+		return nil, err
+	}
+	if len(secrets) > 0 {
+		ctx.Logger().Warnf("Compose request for org %s embeds secrets: %s", userID.OrgID(), strings.Join(secrets, "; "))
 	}
 
 	distro := d.Distribution.Name
@@ -109,84 +183,246 @@ func (h *Handlers) handleCommonCompose(ctx echo.Context, composeRequest ComposeR
 		distro = *d.Distribution.ComposerName
 	}
 
-	cloudCR := composer.ComposeRequest{
-		Distribution:   distro,
-		Customizations: customizations,
-		ImageRequest: &composer.ImageRequest{
-			Architecture:  string(composeRequest.ImageRequests[0].Architecture),
-			ImageType:     imageType,
-			Size:          composeRequest.ImageRequests[0].Size,
-			Ostree:        buildOSTreeOptions(composeRequest.ImageRequests[0].Ostree),
-			Repositories:  repositories,
-			UploadOptions: &uploadOptions,
+	return &preparedCompose{
+		userID:       userID,
+		distro:       d,
+		arch:         arch,
+		secrets:      secrets,
+		policy:       policy,
+		priorityLane: priorityLane,
+		injected:     injected,
+		composerRequest: composer.ComposeRequest{
+			Distribution:   distro,
+			Customizations: customizations,
+			ImageRequest: &composer.ImageRequest{
+				Architecture:  string(composeRequest.ImageRequests[0].Architecture),
+				ImageType:     imageType,
+				Size:          alignImageSize(composeRequest.ImageRequests[0]),
+				Ostree:        buildOSTreeOptions(composeRequest.ImageRequests[0].Ostree),
+				Repositories:  repositories,
+				UploadOptions: &uploadOptions,
+			},
+			Tags: composerJobTags(userID, policy, priorityLane, h.server.workerPool(userID.OrgID())),
 		},
+	}, nil
+}
+
+// handleCommonCompose submits the compose request to composer and records
+// it, parentComposeId is set when it retries a failed compose.
+func (h *Handlers) handleCommonCompose(ctx echo.Context, composeRequest ComposeRequest, blueprintVersionId *uuid.UUID, parentComposeId *uuid.UUID) (ComposeResponse, error) {
+	prepared, err := h.prepareCompose(ctx, &composeRequest)
+	if err != nil {
+		return ComposeResponse{}, err
+	}
+	userID := prepared.userID
+	cloudCR := prepared.composerRequest
+
+	// only submitted composes are stamped, a validated request has no build
+	if composeRequest.Customizations != nil && composeRequest.Customizations.BuildMetadata != nil {
+		stamp, err := h.newBuildStamp(ctx, composeRequest, blueprintVersionId)
+		if err != nil {
+			return ComposeResponse{}, err
+		}
+		cloudCR.Customizations, err = stampBuildMetadata(cloudCR.Customizations, stamp, common.FromPtr(composeRequest.Customizations.BuildMetadata.Motd))
+		if err != nil {
+			return ComposeResponse{}, err
+		}
+		// a retried compose is a build of its own
+		labels := ComposeLabels{}
+		for k, v := range common.FromPtr(composeRequest.Labels) {
+			labels[k] = v
+		}
+		labels[buildIdLabel] = stamp.BuildId.String()
+		composeRequest.Labels = &labels
+	}
+
+	// flagged content must not end up in the database
+	rawCR, err := h.server.sealComposeRequest(composeRequest, len(prepared.secrets) > 0)
+	if err != nil {
+		return ComposeResponse{}, err
+	}
+	var injected json.RawMessage
+	if prepared.injected != nil {
+		injected, err = json.Marshal(prepared.injected)
+		if err != nil {
+			return ComposeResponse{}, err
+		}
+	}
+
+	// the slot is taken before composer builds anything, composes which
+	// don't fit are never built
+	quota, err := h.server.quota(ctx, userID.OrgID())
+	if err != nil {
+		return ComposeResponse{}, err
+	}
+	reservation := db.ComposeReservation{
+		Id:                 uuid.New(),
+		OrgId:              userID.OrgID(),
+		Window:             common.DefaultSlidingWindow,
+		BlueprintVersionId: blueprintVersionId,
+	}
+	if quota != nil {
+		reservation.Quota = &quota.Quota
+		reservation.Window = quota.SlidingWindow
+	}
+	if prepared.priorityLane {
+		reservation.LaneLimit = common.ToPtr(h.server.priorityLane)
+		reservation.LaneWindow = laneWindow(quota)
+	}
+	err = h.server.db.ReserveCompose(ctx.Request().Context(), &reservation)
+	if errors.Is(err, db.QuotaExceededError) {
+		return ComposeResponse{}, apiError(iberrors.CodeQuotaExceeded, http.StatusForbidden, "Quota exceeded for user")
+	}
+	if errors.Is(err, db.WorkspaceQuotaExceededError) {
+		return ComposeResponse{}, apiError(iberrors.CodeQuotaExceeded, http.StatusForbidden, "Quota exceeded for workspace")
+	}
+	if err != nil {
+		return ComposeResponse{}, err
+	}
+	if prepared.priorityLane && !reservation.PriorityLane {
+		ctx.Logger().Warnf("Priority lane of org %s filled up meanwhile, the security rebuild counts against the quota", userID.OrgID())
+	}
+	// composer prioritizes the jobs the way they're recorded
+	cloudCR.Tags = composerJobTags(userID, prepared.policy, reservation.PriorityLane, h.server.workerPool(userID.OrgID()))
+
+	ctx.Logger().Debugf("Composer compose request: %s", redact.Value(cloudCR, redact.ComposerRequest))
+	cClient, region := h.server.composerForOrg(userID.OrgID())
+	composeId, err := submitCompose(ctx, cClient, cloudCR)
+	if err != nil {
+		h.releaseReservation(ctx, reservation.Id)
+		return ComposeResponse{}, err
 	}
 
-	resp, err := h.server.cClient.Compose(cloudCR)
+	clientIdString := string(*composeRequest.ClientId)
+	var expiresAt *time.Time
+	if ttl := h.server.composeTTL(prepared.policy); ttl > 0 {
+		expiresAt = common.ToPtr(time.Now().UTC().Add(ttl))
+	}
+	err = h.server.db.InsertReservedCompose(ctx.Request().Context(), &reservation, db.ReservedCompose{
+		JobId:              composeId,
+		AccountNumber:      userID.AccountNumber(),
+		Email:              userID.Email(),
+		ImageName:          composeRequest.ImageName,
+		Request:            rawCR,
+		ClientId:           &clientIdString,
+		BlueprintVersionId: blueprintVersionId,
+		Region:             region,
+		ParentComposeId:    parentComposeId,
+		ExpiresAt:          expiresAt,
+		Labels:             common.FromPtr(composeRequest.Labels),
+		InjectedDefaults:   injected,
+	})
 	if err != nil {
+		ctx.Logger().Errorf("Error recording compose %s: %v", composeId, err)
+		// nobody could reach the build nor account for it
+		cancelUnrecordedCompose(ctx, cClient, composeId)
+		h.releaseReservation(ctx, reservation.Id)
 		return ComposeResponse{}, err
 	}
+	h.server.countPriorityLane(composeRequest, reservation.PriorityLane)
+
+	ctx.Logger().Infof("Compose result: %s", composeId)
+	countCustomizations(composeRequest.Customizations)
+	h.server.countArchitecture(composeRequest.ImageRequests[0].Architecture)
+
+	composeResponse := ComposeResponse{
+		Id:               composeId,
+		InjectedDefaults: prepared.injected,
+	}
+	warnings := prepared.secrets
+	if w := h.server.emulationWarning(composeRequest.ImageRequests[0].Architecture); w != "" {
+		warnings = append(warnings, w)
+	}
+	warnings = append(warnings, deprecationWarnings(ctx, prepared.distro, prepared.arch, composeRequest.ImageRequests[0].ImageType)...)
+	warnings = append(warnings, lintComposeRequest(&composeRequest)...)
+	if len(warnings) > 0 {
+		composeResponse.Warnings = &warnings
+	}
+	return composeResponse, nil
+}
+
+// submitCompose posts the compose request to composer and returns the id of
+// the compose.
+func submitCompose(ctx echo.Context, cClient *composer.ComposerClient, cloudCR composer.ComposeRequest) (uuid.UUID, error) {
+	resp, err := cClient.Compose(cloudCR)
+	if err != nil {
+		return uuid.Nil, withInternal(apiError(iberrors.CodeUpstreamUnavailable, http.StatusInternalServerError, "Failed posting compose request to osbuild-composer"), err)
+	}
 	defer closeBody(ctx, resp.Body)
 	if resp.StatusCode != http.StatusCreated {
-		httpError := echo.NewHTTPError(http.StatusInternalServerError, "Failed posting compose request to osbuild-composer")
+		httpError := apiError(iberrors.CodeUpstreamUnavailable, http.StatusInternalServerError, "Failed posting compose request to osbuild-composer")
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
 			ctx.Logger().Errorf("Unable to parse composer's compose response: %v", err)
-		} else {
-			_ = httpError.SetInternal(fmt.Errorf("%s", body))
-			var serviceStat composer.Error
-			if err := json.Unmarshal(body, &serviceStat); err != nil {
-				return ComposeResponse{}, httpError
-			}
-			if serviceStat.Id == "10" {
-				httpError.Message = "Error resolving OSTree repo"
-				httpError.Code = http.StatusBadRequest
-			}
-			// missing baseurl in payload repository
-			if serviceStat.Id == "24" {
-				httpError.Message = serviceStat.Reason
-				httpError.Code = http.StatusBadRequest
-			}
-			// gpg key not set when check_gpg is true
-			if serviceStat.Id == "29" {
-				httpError.Message = serviceStat.Reason
-				httpError.Code = http.StatusBadRequest
-			}
+			return uuid.Nil, httpError
+		}
+		var serviceStat composer.Error
+		if err := json.Unmarshal(body, &serviceStat); err == nil {
+			httpError = composerRequestError(resp.StatusCode, serviceStat)
 		}
-		return ComposeResponse{}, httpError
+		return uuid.Nil, withInternal(httpError, fmt.Errorf("%s", body))
 	}
 
 	var composeResult composer.ComposeId
 	err = json.NewDecoder(resp.Body).Decode(&composeResult)
 	if err != nil {
-		return ComposeResponse{}, err
+		return uuid.Nil, err
 	}
+	return composeResult.Id, nil
+}
 
-	rawCR, err := json.Marshal(composeRequest)
+// releaseReservation frees the slot of a compose which isn't recorded, even
+// when the client went away meanwhile. Reservations which can't be released
+// time out.
+func (h *Handlers) releaseReservation(ctx echo.Context, id uuid.UUID) {
+	err := h.server.db.ReleaseComposeReservation(context.WithoutCancel(ctx.Request().Context()), id)
 	if err != nil {
-		return ComposeResponse{}, err
+		ctx.Logger().Errorf("Unable to release compose reservation %s: %v", id, err)
 	}
+}
 
-	clientIdString := string(*composeRequest.ClientId)
-
-	err = h.server.db.InsertCompose(ctx.Request().Context(), composeResult.Id, userID.AccountNumber(), userID.Email(), userID.OrgID(), composeRequest.ImageName, rawCR, &clientIdString, blueprintVersionId)
+// cancelUnrecordedCompose stops the build of a compose which couldn't be
+// recorded, it would go unbilled and unwatched otherwise.
+func cancelUnrecordedCompose(ctx echo.Context, cClient *composer.ComposerClient, composeId uuid.UUID) {
+	resp, err := cClient.CancelCompose(composeId)
 	if err != nil {
-		ctx.Logger().Error("Error inserting id into db", err)
-		return ComposeResponse{}, err
+		ctx.Logger().Errorf("Unable to cancel unrecorded compose %s: %v", composeId, err)
+		return
 	}
+	defer closeBody(ctx, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		ctx.Logger().Errorf("Unable to cancel unrecorded compose %s, composer responded %d", composeId, resp.StatusCode)
+	}
+}
 
-	ctx.Logger().Info("Compose result", composeResult)
-
-	return ComposeResponse{
-		Id: composeResult.Id,
-	}, nil
+// composerJobTags attributes the build jobs to the org, the workers account
+// for the resources they used by these tags. Jobs of the priority lane are
+// tagged for composer to schedule them first, the ones of orgs with a worker
+// pool of their own for the workers of the pool.
+func composerJobTags(userID *Identity, policy *OrgPolicy, priorityLane bool, workerPool string) *composer.JobTags {
+	tags := composer.JobTags{
+		"org_id": userID.OrgID(),
+	}
+	if userID.AccountNumber() != "" {
+		tags["account_number"] = userID.AccountNumber()
+	}
+	if policy != nil && policy.CostCenter != nil && *policy.CostCenter != "" {
+		tags["cost_center"] = *policy.CostCenter
+	}
+	if priorityLane {
+		tags["priority"] = "security"
+	}
+	if workerPool != "" {
+		tags["worker_pool"] = workerPool
+	}
+	return &tags
 }
 
 func buildRepositories(arch *distribution.Architecture, imageType ImageTypes) []composer.Repository {
 	var repositories []composer.Repository
 	for _, r := range arch.Repositories {
 		// If no image type tags are defined for the repo, add the repo
-		if len(r.ImageTypeTags) == 0 || slices.Contains(r.ImageTypeTags, string(imageType)) {
+		if len(r.ImageTypeTags) == 0 || slices.Contains(r.ImageTypeTags, string(canonicalImageType(imageType))) {
 			repositories = append(repositories, composer.Repository{
 				Baseurl:  r.Baseurl,
 				Metalink: r.Metalink,
@@ -300,15 +536,19 @@ func (h *Handlers) buildRepositorySnapshots(ctx echo.Context, repoURLs []string,
 	return repositories, customRepositories, nil
 }
 
-func (h *Handlers) buildUploadOptions(ctx echo.Context, ur UploadRequest, it ImageTypes) (composer.UploadOptions, composer.ImageTypes, error) {
+// canonicalImageType resolves image type aliases, see distribution.ImageType.
+func canonicalImageType(it ImageTypes) ImageTypes {
+	return ImageTypes(distribution.CanonicalImageType(string(it)))
+}
+
+func (h *Handlers) buildUploadOptions(ctx echo.Context, ur UploadRequest, it ImageTypes, arch ImageRequestArchitecture) (composer.UploadOptions, composer.ImageTypes, error) {
 	var uploadOptions composer.UploadOptions
+	it = canonicalImageType(it)
 	switch ur.Type {
 	case UploadTypesAws:
 		var composerImageType composer.ImageTypes
 		switch it {
 		case ImageTypesAws:
-			fallthrough
-		case ImageTypesAmi:
 			composerImageType = composer.ImageTypesAws
 		default:
 			return uploadOptions, "", echo.NewHTTPError(http.StatusBadRequest, "Invalid image type for upload target")
@@ -317,8 +557,14 @@ func (h *Handlers) buildUploadOptions(ctx echo.Context, ur UploadRequest, it Ima
 		if err != nil {
 			return uploadOptions, "", echo.NewHTTPError(http.StatusBadRequest, "Unable to parse upload request options as aws options")
 		}
+		err = validateAWSRegistration(uo, arch)
+		if err != nil {
+			return uploadOptions, "", err
+		}
 
-		if (uo.ShareWithAccounts == nil || len(*uo.ShareWithAccounts) == 0) && (uo.ShareWithSources == nil || len(*uo.ShareWithSources) == 0) {
+		if (uo.ShareWithAccounts == nil || len(*uo.ShareWithAccounts) == 0) &&
+			(uo.ShareWithSources == nil || len(*uo.ShareWithSources) == 0) &&
+			(uo.ShareWithGrants == nil || len(*uo.ShareWithGrants) == 0) {
 			return uploadOptions, "", echo.NewHTTPError(http.StatusBadRequest, "Expected at least one source or account to share the image with")
 		}
 
@@ -327,19 +573,21 @@ func (h *Handlers) buildUploadOptions(ctx echo.Context, ur UploadRequest, it Ima
 			shareWithAccounts = append(shareWithAccounts, *uo.ShareWithAccounts...)
 		}
 
-		if uo.ShareWithSources != nil {
-			for _, source := range *uo.ShareWithSources {
-				resp, err := h.server.pClient.GetUploadInfo(ctx.Request().Context(), source)
+		if uo.ShareWithGrants != nil {
+			for _, alias := range *uo.ShareWithGrants {
+				grant, err := h.uploadGrant(ctx, alias, UploadGrantProviderAws)
 				if err != nil {
-					ctx.Logger().Error(err)
-					return uploadOptions, "", echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unable to request source: %s", source))
+					return uploadOptions, "", err
 				}
-				defer closeBody(ctx, resp.Body)
+				shareWithAccounts = append(shareWithAccounts, *grant.AccountId)
+			}
+		}
 
-				var uploadInfo provisioning.V1SourceUploadInfoResponse
-				err = json.NewDecoder(resp.Body).Decode(&uploadInfo)
+		if uo.ShareWithSources != nil {
+			for _, source := range *uo.ShareWithSources {
+				uploadInfo, err := h.sourceUploadInfo(ctx, source)
 				if err != nil {
-					return uploadOptions, "", echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Unable to resolve source: %s", source))
+					return uploadOptions, "", err
 				}
 
 				if uploadInfo.Aws == nil || uploadInfo.Aws.AccountId == nil || len(*uploadInfo.Aws.AccountId) != 12 {
@@ -350,9 +598,19 @@ func (h *Handlers) buildUploadOptions(ctx echo.Context, ur UploadRequest, it Ima
 				shareWithAccounts = append(shareWithAccounts, *uploadInfo.Aws.AccountId)
 			}
 		}
+		var bootMode *composer.AWSEC2UploadOptionsBootMode
+		if uo.BootMode != nil {
+			bootMode = common.ToPtr(composer.AWSEC2UploadOptionsBootMode(*uo.BootMode))
+		}
 		err = uploadOptions.FromAWSEC2UploadOptions(composer.AWSEC2UploadOptions{
-			Region:            h.server.aws.Region,
-			ShareWithAccounts: shareWithAccounts,
+			Region:             h.server.aws.Region,
+			ShareWithAccounts:  shareWithAccounts,
+			BootMode:           bootMode,
+			EnaSupport:         uo.EnaSupport,
+			SriovNetSupport:    uo.SriovNetSupport,
+			HibernationCapable: uo.HibernationCapable,
+			ImdsV2Required:     uo.ImdsV2Required,
+			TpmSupport:         uo.TpmSupport,
 		})
 		if err != nil {
 			return uploadOptions, "", err
@@ -362,12 +620,8 @@ func (h *Handlers) buildUploadOptions(ctx echo.Context, ur UploadRequest, it Ima
 		var composerImageType composer.ImageTypes
 		switch it {
 		case ImageTypesEdgeCommit:
-			fallthrough
-		case ImageTypesRhelEdgeCommit:
 			composerImageType = composer.ImageTypesEdgeCommit
 		case ImageTypesEdgeInstaller:
-			fallthrough
-		case ImageTypesRhelEdgeInstaller:
 			composerImageType = composer.ImageTypesEdgeInstaller
 		case ImageTypesGuestImage:
 			composerImageType = composer.ImageTypesGuestImage
@@ -401,10 +655,15 @@ func (h *Handlers) buildUploadOptions(ctx echo.Context, ur UploadRequest, it Ima
 		if err != nil {
 			return uploadOptions, "", echo.NewHTTPError(http.StatusBadRequest, "Unable to parse upload request options as GCP options")
 		}
+		err = h.validateGCPKmsKey(ctx, uo)
+		if err != nil {
+			return uploadOptions, "", err
+		}
 		err = uploadOptions.FromGCPUploadOptions(composer.GCPUploadOptions{
 			Bucket:            &h.server.gcp.Bucket,
 			Region:            h.server.gcp.Region,
 			ShareWithAccounts: uo.ShareWithAccounts,
+			KmsKeyName:        uo.KmsKeyName,
 		})
 		if err != nil {
 			return uploadOptions, "", err
@@ -414,8 +673,6 @@ func (h *Handlers) buildUploadOptions(ctx echo.Context, ur UploadRequest, it Ima
 		var composerImageType composer.ImageTypes
 		switch it {
 		case ImageTypesAzure:
-			fallthrough
-		case ImageTypesVhd:
 			composerImageType = composer.ImageTypesAzure
 		default:
 			return uploadOptions, "", echo.NewHTTPError(http.StatusBadRequest, "Invalid image type for upload target")
@@ -426,32 +683,37 @@ func (h *Handlers) buildUploadOptions(ctx echo.Context, ur UploadRequest, it Ima
 			return uploadOptions, "", echo.NewHTTPError(http.StatusBadRequest, "Unable to parse upload request options as Azure options")
 		}
 
-		if (uo.SourceId == nil && (uo.TenantId == nil || uo.SubscriptionId == nil)) ||
-			(uo.SourceId != nil && (uo.TenantId != nil || uo.SubscriptionId != nil)) {
+		if uo.Grant != nil && (uo.SourceId != nil || uo.TenantId != nil || uo.SubscriptionId != nil) {
+			return uploadOptions, "", echo.NewHTTPError(http.StatusBadRequest, "Request must contain either a grant or a source id or tenant and subscription ids, not both.")
+		}
+		if uo.Grant == nil && ((uo.SourceId == nil && (uo.TenantId == nil || uo.SubscriptionId == nil)) ||
+			(uo.SourceId != nil && (uo.TenantId != nil || uo.SubscriptionId != nil))) {
 			return uploadOptions, "", echo.NewHTTPError(http.StatusBadRequest, "Request must contain either (1) a source id, and no tenant or subscription ids or (2) tenant and subscription ids, and no source id.")
 		}
 
+		generation, err := azureHyperVGeneration(uo.HyperVGeneration, arch)
+		if err != nil {
+			return uploadOptions, "", err
+		}
+
 		var tenantId string
 		var subscriptionId string
 
-		if uo.SourceId == nil {
-			tenantId = *uo.TenantId
-			subscriptionId = *uo.SubscriptionId
-		}
-
-		if uo.SourceId != nil {
-			resp, err := h.server.pClient.GetUploadInfo(ctx.Request().Context(), *uo.SourceId)
+		switch {
+		case uo.Grant != nil:
+			grant, err := h.uploadGrant(ctx, *uo.Grant, UploadGrantProviderAzure)
 			if err != nil {
-				ctx.Logger().Error(err)
-				return uploadOptions, "", echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unable to request source: %s", *uo.SourceId))
+				return uploadOptions, "", err
 			}
-			defer closeBody(ctx, resp.Body)
-
-			var uploadInfo provisioning.V1SourceUploadInfoResponse
-			err = json.NewDecoder(resp.Body).Decode(&uploadInfo)
-
+			tenantId = *grant.TenantId
+			subscriptionId = *grant.SubscriptionId
+		case uo.SourceId == nil:
+			tenantId = *uo.TenantId
+			subscriptionId = *uo.SubscriptionId
+		default:
+			uploadInfo, err := h.sourceUploadInfo(ctx, *uo.SourceId)
 			if err != nil {
-				return uploadOptions, "", echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Unable to resolve source: %s", *uo.SourceId))
+				return uploadOptions, "", err
 			}
 
 			if uploadInfo.Azure == nil || uploadInfo.Azure.TenantId == nil || uploadInfo.Azure.SubscriptionId == nil {
@@ -464,10 +726,11 @@ func (h *Handlers) buildUploadOptions(ctx echo.Context, ur UploadRequest, it Ima
 		}
 
 		err = uploadOptions.FromAzureUploadOptions(composer.AzureUploadOptions{
-			TenantId:       tenantId,
-			SubscriptionId: subscriptionId,
-			ResourceGroup:  uo.ResourceGroup,
-			ImageName:      uo.ImageName,
+			TenantId:         tenantId,
+			SubscriptionId:   subscriptionId,
+			ResourceGroup:    uo.ResourceGroup,
+			ImageName:        uo.ImageName,
+			HyperVGeneration: generation,
 		})
 		if err != nil {
 			return uploadOptions, "", err
@@ -487,6 +750,89 @@ func (h *Handlers) buildUploadOptions(ctx echo.Context, ur UploadRequest, it Ima
 	}
 }
 
+var gcpKmsKeyName = regexp.MustCompile(`^projects/([^/]+)/locations/[^/]+/keyRings/[^/]+/cryptoKeys/[^/]+$`)
+
+// validateGCPKmsKey checks the key encrypting the image belongs to the
+// project of the source, so a typo doesn't only fail the import once the
+// image was built.
+func (h *Handlers) validateGCPKmsKey(ctx echo.Context, uo GCPUploadRequestOptions) error {
+	if uo.KmsKeyName == nil {
+		if uo.SourceId != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "A source is only used to check the project of a kms key")
+		}
+		return nil
+	}
+	match := gcpKmsKeyName.FindStringSubmatch(*uo.KmsKeyName)
+	if match == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid kms key name %s, expected projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>", *uo.KmsKeyName))
+	}
+	if uo.SourceId == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "A kms key requires the source of its project")
+	}
+
+	uploadInfo, err := h.sourceUploadInfo(ctx, *uo.SourceId)
+	if err != nil {
+		return err
+	}
+	if uploadInfo.Gcp == nil || uploadInfo.Gcp.ProjectId == nil || *uploadInfo.Gcp.ProjectId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unable to resolve source %s to a gcp project", *uo.SourceId))
+	}
+	if match[1] != *uploadInfo.Gcp.ProjectId {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("The kms key %s doesn't belong to project %s of source %s", *uo.KmsKeyName, *uploadInfo.Gcp.ProjectId, *uo.SourceId))
+	}
+	return nil
+}
+
+// validateAWSRegistration checks the AMI can be registered with the requested
+// flags, AWS would only reject the registration once the image was built.
+func validateAWSRegistration(uo AWSUploadRequestOptions, arch ImageRequestArchitecture) error {
+	if uo.BootMode != nil {
+		switch *uo.BootMode {
+		case LegacyBios:
+			if arch != ImageRequestArchitectureX8664 {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Boot mode legacy-bios is not available for %s, use uefi", arch))
+			}
+		case Uefi, UefiPreferred:
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unknown boot mode %s", *uo.BootMode))
+		}
+	}
+	if common.FromPtr(uo.TpmSupport) {
+		// only uefi guarantees the TPM, uefi-preferred falls back to
+		// legacy-bios on instance types without UEFI
+		uefi := arch == ImageRequestArchitectureAarch64 && uo.BootMode == nil
+		if !uefi && common.FromPtr(uo.BootMode) != Uefi {
+			return echo.NewHTTPError(http.StatusBadRequest, "NitroTPM support requires the uefi boot mode")
+		}
+	}
+	if common.FromPtr(uo.SriovNetSupport) && arch != ImageRequestArchitectureX8664 {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("SR-IOV networking support is not available for %s", arch))
+	}
+	return nil
+}
+
+// azureHyperVGeneration picks the generation if none was requested, composer
+// would default to V1 which doesn't exist for aarch64.
+func azureHyperVGeneration(requested *AzureUploadRequestOptionsHyperVGeneration, arch ImageRequestArchitecture) (*composer.AzureUploadOptionsHyperVGeneration, error) {
+	if requested == nil {
+		if arch == ImageRequestArchitectureAarch64 {
+			return common.ToPtr(composer.AzureUploadOptionsHyperVGenerationV2), nil
+		}
+		return nil, nil
+	}
+
+	switch *requested {
+	case V1:
+		if arch != ImageRequestArchitectureX8664 {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Hyper-V generation V1 is not available for %s, use V2", arch))
+		}
+		return common.ToPtr(composer.AzureUploadOptionsHyperVGenerationV1), nil
+	case V2:
+		return common.ToPtr(composer.AzureUploadOptionsHyperVGenerationV2), nil
+	}
+	return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unknown Hyper-V generation %s", *requested))
+}
+
 func buildOSTreeOptions(ostreeOptions *OSTree) *composer.OSTree {
 	if ostreeOptions == nil {
 		return nil
@@ -506,7 +852,14 @@ func buildOSTreeOptions(ostreeOptions *OSTree) *composer.OSTree {
 // validateComposeRequest makes sure the image size is not too large for AWS or Azure
 // It takes into account the requested image size, and the total size of requested
 // filesystem customizations.
+// It also checks the filesystems are large enough for the contents of the image,
+// see validateImageSize, and the labels of the compose.
 func validateComposeRequest(cr *ComposeRequest) error {
+	err := validateComposeLabels(cr.Labels)
+	if err != nil {
+		return err
+	}
+
 	var totalSize uint64
 	cust := cr.Customizations
 	if cust != nil && cust.Filesystem != nil {
@@ -521,15 +874,35 @@ func validateComposeRequest(cr *ComposeRequest) error {
 	}
 
 	if totalSize > FSMaxSize {
-		it := cr.ImageRequests[0].ImageType
+		it := canonicalImageType(cr.ImageRequests[0].ImageType)
 		switch it {
-		case ImageTypesAmi, ImageTypesAws:
+		case ImageTypesAws:
 			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Total AWS image size cannot exceed %d bytes", FSMaxSize))
-		case ImageTypesAzure, ImageTypesVhd:
+		case ImageTypesAzure:
 			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Total Azure image size cannot exceed %d bytes", FSMaxSize))
 		}
 	}
 
+	return validateImageSize(cr)
+}
+
+// validatePackageGroups checks the @groups among the packages against the
+// comps groups of the distribution. Architectures without group metadata
+// leave that to composer, which installs them like dnf does.
+func validatePackageGroups(arch *distribution.Architecture, cust *Customizations) error {
+	if cust == nil || cust.Packages == nil || !arch.HasGroups() {
+		return nil
+	}
+	var unknown []string
+	for _, p := range *cust.Packages {
+		group, ok := strings.CutPrefix(p, "@")
+		if ok && arch.FindGroup(group) == nil {
+			unknown = append(unknown, p)
+		}
+	}
+	if len(unknown) > 0 {
+		return apiError(iberrors.CodeInvalidCustomization, http.StatusBadRequest, fmt.Sprintf("Unknown package groups: %s", strings.Join(unknown, ", ")))
+	}
 	return nil
 }
 
@@ -0,0 +1,169 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+const (
+	// maxComposeLogSize is how much of the log is returned, the end of it
+	// where builds fail is kept.
+	maxComposeLogSize = 1 << 20
+	// maxComposerLogsSize bounds what is read of composer's response, the
+	// stage outputs are embedded in JSON.
+	maxComposerLogsSize = 64 << 20
+)
+
+var errComposerLogsTooLarge = errors.New("logs of the compose are too large")
+
+// osbuildStage is the part of a stage result of osbuild which makes the log.
+type osbuildStage struct {
+	Type   string `json:"type"`
+	Output string `json:"output"`
+}
+
+// imageBuildLog is the part of the job result composer reports for each image
+// build which makes the log.
+type imageBuildLog struct {
+	OSBuildOutput *struct {
+		Log map[string][]osbuildStage `json:"log"`
+	} `json:"osbuild_output"`
+	JobError *struct {
+		Reason string `json:"reason"`
+	} `json:"job_error"`
+}
+
+// renderComposeLogs turns the logs composer reports into text, the stages of
+// each pipeline in the order they ran.
+func renderComposeLogs(logs composer.ComposeLogs) string {
+	var b strings.Builder
+	for _, build := range logs.ImageBuilds {
+		raw, err := json.Marshal(build)
+		if err != nil {
+			continue
+		}
+		var l imageBuildLog
+		if err := json.Unmarshal(raw, &l); err != nil {
+			// not a job result, keep it as composer reported it
+			b.Write(raw)
+			b.WriteString("\n")
+			continue
+		}
+		if l.OSBuildOutput != nil {
+			pipelines := make([]string, 0, len(l.OSBuildOutput.Log))
+			for p := range l.OSBuildOutput.Log {
+				pipelines = append(pipelines, p)
+			}
+			slices.Sort(pipelines)
+			for _, p := range pipelines {
+				for _, stage := range l.OSBuildOutput.Log[p] {
+					fmt.Fprintf(&b, "==> Pipeline %s: Stage %s\n", p, stage.Type)
+					b.WriteString(stage.Output)
+					if !strings.HasSuffix(stage.Output, "\n") {
+						b.WriteString("\n")
+					}
+				}
+			}
+		}
+		if l.JobError != nil {
+			fmt.Fprintf(&b, "==> Build failed: %s\n", l.JobError.Reason)
+		}
+	}
+	return b.String()
+}
+
+// truncateComposeLog keeps the end of logs longer than the limit, starting at
+// a line.
+func truncateComposeLog(log string, limit int) string {
+	if len(log) <= limit {
+		return log
+	}
+	tail := log[len(log)-limit:]
+	if i := strings.IndexByte(tail, '\n'); i >= 0 {
+		tail = tail[i+1:]
+	}
+	return fmt.Sprintf("==> Log truncated, only its last %d bytes are shown\n%s", len(tail), tail)
+}
+
+// GetComposeLogs returns the build log of a compose as text.
+func (h *Handlers) GetComposeLogs(ctx echo.Context, composeId openapi_types.UUID) error {
+	composeEntry, err := h.getComposeByIdAndOrgId(ctx, composeId)
+	if err != nil {
+		return err
+	}
+	log, err := h.composeLogs(ctx, composeEntry)
+	if err != nil {
+		return err
+	}
+	return ctx.Blob(http.StatusOK, echo.MIMETextPlainCharsetUTF8, log)
+}
+
+// composeLogs returns the log of the compose as text, from object storage if
+// it was kept there or else from composer. Logs of finished composes don't
+// change anymore and are kept.
+func (h *Handlers) composeLogs(ctx echo.Context, composeEntry *db.ComposeEntry) ([]byte, error) {
+	composeId := composeEntry.Id
+	stored, err := h.loadComposeBlob(ctx, composeId, blobKindLogs)
+	if err != nil {
+		return nil, err
+	}
+	if stored != nil {
+		return stored, nil
+	}
+	if err := h.server.readOnlyError(); err != nil {
+		return nil, err
+	}
+
+	cClient, err := h.server.composerFor(composeEntry.Region)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := cClient.ComposeLogs(composeId)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(ctx, resp.Body)
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxComposerLogsSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, echo.NewHTTPError(http.StatusNotFound, string(body))
+	} else if resp.StatusCode != http.StatusOK {
+		httpError := echo.NewHTTPError(http.StatusInternalServerError, "Failed querying compose logs")
+		_ = httpError.SetInternal(fmt.Errorf("%s", body))
+		return nil, httpError
+	}
+	if len(body) > maxComposerLogsSize {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, errComposerLogsTooLarge)
+	}
+
+	var logs composer.ComposeLogs
+	err = json.Unmarshal(body, &logs)
+	if err != nil {
+		return nil, err
+	}
+	log := []byte(truncateComposeLog(renderComposeLogs(logs), maxComposeLogSize))
+
+	status := common.FromPtr(composeEntry.Status)
+	if status == string(ImageStatusStatusSuccess) || status == string(ImageStatusStatusFailure) {
+		err = h.storeComposeBlob(ctx, composeId, blobKindLogs, log)
+		if err != nil {
+			ctx.Logger().Errorf("Unable to store logs of compose %v: %v", composeId, err)
+		}
+	}
+	return log, nil
+}
@@ -0,0 +1,125 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/storage"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+const testComposerLogs = `{
+	"href": "/logs",
+	"id": "log",
+	"kind": "ComposeLogs",
+	"image_builds": [{
+		"osbuild_output": {
+			"success": false,
+			"log": {
+				"os": [
+					{"type": "org.osbuild.rpm", "output": "Installing vim\n", "success": true},
+					{"type": "org.osbuild.selinux", "output": "relabel failed", "success": false}
+				],
+				"build": [
+					{"type": "org.osbuild.rpm", "output": "Installing dnf\n", "success": true}
+				]
+			}
+		},
+		"job_error": {"id": 10, "reason": "osbuild build failed"}
+	}]
+}`
+
+func TestRenderComposeLogs(t *testing.T) {
+	var logs composer.ComposeLogs
+	require.NoError(t, json.Unmarshal([]byte(testComposerLogs), &logs))
+	require.Equal(t, `==> Pipeline build: Stage org.osbuild.rpm
+Installing dnf
+==> Pipeline os: Stage org.osbuild.rpm
+Installing vim
+==> Pipeline os: Stage org.osbuild.selinux
+relabel failed
+==> Build failed: osbuild build failed
+`, renderComposeLogs(logs))
+
+	// whatever isn't a job result is kept
+	logs.ImageBuilds = []interface{}{"plain"}
+	require.Equal(t, "\"plain\"\n", renderComposeLogs(logs))
+}
+
+func TestTruncateComposeLog(t *testing.T) {
+	require.Equal(t, "short\n", truncateComposeLog("short\n", 10))
+	log := strings.Repeat("line\n", 10)
+	require.Equal(t, "==> Log truncated, only its last 10 bytes are shown\nline\nline\n", truncateComposeLog(log, 12))
+}
+
+func TestGetComposeLogs(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+
+	requests := 0
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.Equal(t, "Bearer accesstoken", r.Header.Get("Authorization"))
+		require.True(t, strings.HasSuffix(r.URL.Path, "/logs"))
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write([]byte(testComposerLogs))
+		require.NoError(t, err)
+	}))
+	defer apiSrv.Close()
+
+	store, err := storage.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
+		DBase:   dbase,
+		Storage: store,
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	building := uuid.New()
+	err = dbase.InsertCompose(ctx, building, "500000", "user000000@test.test", "000000", nil, json.RawMessage(`{}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+	failed := uuid.New()
+	err = dbase.InsertCompose(ctx, failed, "500000", "user000000@test.test", "000000", nil, json.RawMessage(`{}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, dbase.SetComposeStatus(ctx, failed, "failure", nil))
+
+	// logs of composes still building change, they're asked for every time
+	for i := 1; i <= 2; i++ {
+		respStatusCode, body := tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/logs", building), &tutils.AuthString0)
+		require.Equal(t, http.StatusOK, respStatusCode)
+		require.Contains(t, body, "==> Build failed: osbuild build failed")
+		require.Equal(t, i, requests)
+	}
+
+	for i := 0; i < 2; i++ {
+		respStatusCode, body := tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/logs", failed), &tutils.AuthString0)
+		require.Equal(t, http.StatusOK, respStatusCode)
+		require.Contains(t, body, "relabel failed")
+	}
+	require.Equal(t, 3, requests)
+	_, err = dbase.GetComposeBlob(ctx, failed, "000000", blobKindLogs)
+	require.NoError(t, err)
+
+	respStatusCode, _ := tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/logs", failed), common.ToPtr(tutils.AuthString1))
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+}
@@ -0,0 +1,27 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// GetComposeManifest returns the osbuild manifest of the compose exactly as
+// composer generated it.
+func (h *Handlers) GetComposeManifest(ctx echo.Context, composeId uuid.UUID) error {
+	composeEntry, err := h.getComposeByIdAndOrgId(ctx, composeId)
+	if err != nil {
+		return err
+	}
+
+	manifests, err := h.composeManifests(ctx, composeEntry)
+	if err != nil {
+		return err
+	}
+	// composes have a single image request
+	if len(manifests) == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "The manifest of the compose wasn't generated yet")
+	}
+	return ctx.JSONBlob(http.StatusOK, manifests[0])
+}
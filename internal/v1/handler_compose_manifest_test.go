@@ -0,0 +1,68 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestGetComposeManifest(t *testing.T) {
+	ctx := context.Background()
+	composeId := uuid.New()
+	pending := uuid.New()
+	manifest := `{"version":"2","pipelines":[{"name":"os","stages":[]}],"sources":{}}`
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.True(t, strings.HasSuffix(r.URL.Path, "/manifests"))
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(r.URL.Path, composeId.String()):
+			_, err := fmt.Fprintf(w, `{"href": "", "id": "%s", "kind": "ComposeManifests", "manifests": [%s]}`, composeId, manifest)
+			require.NoError(t, err)
+		case strings.Contains(r.URL.Path, pending.String()):
+			_, err := fmt.Fprintf(w, `{"href": "", "id": "%s", "kind": "ComposeManifests", "manifests": []}`, pending)
+			require.NoError(t, err)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer apiSrv.Close()
+
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	for _, id := range []uuid.UUID{composeId, pending} {
+		err = dbase.InsertCompose(ctx, id, "000000", "user000000@test.test", "000000", nil, json.RawMessage(`{}`), nil, nil, nil, nil)
+		require.NoError(t, err)
+	}
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
+		DBase: dbase,
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	statusCode, body := tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/manifest", composeId), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, statusCode)
+	require.JSONEq(t, manifest, body)
+
+	statusCode, _ = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/manifest", pending), &tutils.AuthString0)
+	require.Equal(t, http.StatusNotFound, statusCode)
+
+	// composes of other orgs aren't found
+	statusCode, _ = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/manifest", composeId), common.ToPtr(tutils.AuthString1))
+	require.Equal(t, http.StatusNotFound, statusCode)
+	statusCode, _ = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/manifest", uuid.New()), &tutils.AuthString0)
+	require.Equal(t, http.StatusNotFound, statusCode)
+}
@@ -0,0 +1,273 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	fedora_identity "github.com/osbuild/community-gateway/oidc-authorizer/pkg/identity"
+	rh_identity "github.com/redhatinsights/identity"
+
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+// pipelineCommitLinkLifetime is how long the installer compose can fetch the
+// commit when the deployment signs the links to objects itself, that covers
+// the watchdog's threshold of installers.
+const pipelineCommitLinkLifetime = 24 * time.Hour
+
+var (
+	pipelineCommitTypes    = []ImageTypes{ImageTypesEdgeCommit, ImageTypesRhelEdgeCommit}
+	pipelineInstallerTypes = []ImageTypes{ImageTypesEdgeInstaller, ImageTypesRhelEdgeInstaller}
+)
+
+// validateComposePipeline checks the compose request builds a commit the
+// installer can be composed from once it's uploaded.
+func validateComposePipeline(composeRequest ComposeRequest) error {
+	commit := composeRequest.ImageRequests[0]
+	installer := composeRequest.Pipeline.Installer
+	if !slices.Contains(pipelineCommitTypes, commit.ImageType) {
+		return echo.NewHTTPError(http.StatusBadRequest, "A pipeline has to start with an edge-commit compose")
+	}
+	if commit.UploadRequest.Type != UploadTypesAwsS3 {
+		return echo.NewHTTPError(http.StatusBadRequest, "The commit of a pipeline has to be uploaded to aws.s3")
+	}
+	if !slices.Contains(pipelineInstallerTypes, installer.ImageType) {
+		return echo.NewHTTPError(http.StatusBadRequest, "A pipeline can only chain an edge-installer to the commit")
+	}
+	if len(splitImageRequest(commit)) > 1 || len(splitImageRequest(installer)) > 1 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Pipelines are built for a single architecture")
+	}
+	if installer.Architecture != commit.Architecture {
+		return echo.NewHTTPError(http.StatusBadRequest, "The installer has to be built for the architecture of the commit")
+	}
+	return nil
+}
+
+// handleComposePipeline submits the commit compose of the request and records
+// the pipeline waiting for it, the installer is submitted once the commit
+// was built.
+func (h *Handlers) handleComposePipeline(ctx echo.Context, composeRequest ComposeRequest) (ComposeResponse, error) {
+	err := validateComposePipeline(composeRequest)
+	if err != nil {
+		return ComposeResponse{}, err
+	}
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return ComposeResponse{}, err
+	}
+	installerRequest, err := json.Marshal(composeRequest.Pipeline.Installer)
+	if err != nil {
+		return ComposeResponse{}, err
+	}
+
+	// the pipeline is recorded on its own, the commit request stays one
+	// which can be retried
+	commitRequest := composeRequest
+	commitRequest.Pipeline = nil
+	composeResponse, err := h.handleCommonCompose(ctx, commitRequest, nil, nil)
+	if err != nil {
+		return ComposeResponse{}, err
+	}
+	pipelineId := uuid.New()
+	err = h.server.db.InsertComposePipeline(ctx.Request().Context(), pipelineId, userID.OrgID(), composeResponse.Id, installerRequest)
+	if err != nil {
+		return ComposeResponse{}, err
+	}
+	ctx.Logger().Infof("Submitted commit compose %s of pipeline %s", composeResponse.Id, pipelineId)
+	return composeResponse, nil
+}
+
+// composePipelineStatus returns the pipeline of the compose. The pipeline is
+// moved on when the status of its commit compose is read, the same way the
+// status of finished composes gets recorded: once the commit was built the
+// installer compose is submitted, a failed commit fails the pipeline. status
+// has to be the unsigned status of the compose, the installer is built from
+// the URL composer uploaded the commit to.
+func (h *Handlers) composePipelineStatus(ctx echo.Context, composeEntry *db.ComposeEntry, status ComposeStatus) (*ComposePipeline, error) {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pipeline, err := h.server.db.GetComposePipeline(ctx.Request().Context(), *composeEntry.PipelineId, userID.OrgID())
+	if err != nil {
+		return nil, err
+	}
+
+	if pipeline.State == string(ComposePipelineStateWaiting) && pipeline.CommitComposeId == composeEntry.Id {
+		switch status.ImageStatus.Status {
+		case ImageStatusStatusSuccess:
+			pipeline, err = h.triggerComposePipeline(ctx, pipeline, composeEntry, status)
+		case ImageStatusStatusFailure:
+			pipeline, _, err = h.moveComposePipeline(ctx, pipeline, ComposePipelineStateFailed, nil, "The commit compose failed")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &ComposePipeline{
+		Id:                 pipeline.Id,
+		State:              ComposePipelineState(pipeline.State),
+		CommitComposeId:    pipeline.CommitComposeId,
+		InstallerComposeId: pipeline.InstallerComposeId,
+		Error:              pipeline.Error,
+	}, nil
+}
+
+// AdvanceComposePipeline moves the waiting pipeline on the way reading the
+// status of its commit compose does, so the pipelines nobody polls get their
+// installer too. It's submitted on behalf of whoever submitted the commit.
+func (s *Server) AdvanceComposePipeline(ctx context.Context, pipeline db.WaitingComposePipeline) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/", nil)
+	if err != nil {
+		return err
+	}
+	ectx := s.echo.NewContext(req, nil)
+	cacheOf(ectx).identity = pipelineIdentity(s.fedoraAuth, pipeline)
+
+	h := &Handlers{server: s}
+	commitEntry, err := s.db.GetCompose(ctx, pipeline.CommitComposeId, pipeline.OrgId)
+	if err != nil {
+		return err
+	}
+	status, err := h.unsignedComposeStatus(ectx, commitEntry)
+	if err != nil {
+		return err
+	}
+	_, err = h.composePipelineStatus(ectx, commitEntry, status)
+	return err
+}
+
+// pipelineIdentity stands in for the user who submitted the commit of the
+// pipeline. The org was entitled to the distribution of the commit, the
+// installer is composed of the same one.
+func pipelineIdentity(fedoraAuth bool, pipeline db.WaitingComposePipeline) *Identity {
+	if fedoraAuth {
+		return &Identity{
+			fid: &fedora_identity.Identity{User: pipeline.OrgId},
+		}
+	}
+	return &Identity{
+		rhid: &rh_identity.XRHID{
+			Identity: rh_identity.Identity{
+				OrgID:         pipeline.OrgId,
+				AccountNumber: pipeline.AccountNumber,
+				User:          rh_identity.User{Email: pipeline.Email},
+			},
+			Entitlements: map[string]rh_identity.ServiceDetails{
+				"rhel": {IsEntitled: true},
+			},
+		},
+	}
+}
+
+// triggerComposePipeline submits the installer compose of the pipeline. It is
+// claimed first, concurrent status requests leave it to the one which
+// claimed it. A failing submission fails the pipeline, not the request.
+func (h *Handlers) triggerComposePipeline(ctx echo.Context, pipeline *db.ComposePipelineEntry, commitEntry *db.ComposeEntry, commit ComposeStatus) (*db.ComposePipelineEntry, error) {
+	pipeline, claimed, err := h.moveComposePipeline(ctx, pipeline, ComposePipelineStateTriggering, nil, "")
+	if err != nil || !claimed {
+		return pipeline, err
+	}
+
+	installerRequest, err := h.server.pipelineInstallerRequest(pipeline, commit)
+	if err != nil {
+		pipeline, _, err = h.moveComposePipeline(ctx, pipeline, ComposePipelineStateFailed, nil, err.Error())
+		return pipeline, err
+	}
+	composeResponse, err := h.handleCommonCompose(ctx, installerRequest, commitEntry.BlueprintVersionId, nil)
+	if err != nil {
+		ctx.Logger().Errorf("Unable to submit the installer compose of pipeline %s: %v", pipeline.Id, err)
+		reason := "The installer compose couldn't be submitted"
+		var httpError *echo.HTTPError
+		if errors.As(err, &httpError) {
+			reason = fmt.Sprintf("%s: %v", reason, httpError.Message)
+		}
+		pipeline, _, err = h.moveComposePipeline(ctx, pipeline, ComposePipelineStateFailed, nil, reason)
+		return pipeline, err
+	}
+	ctx.Logger().Infof("Submitted installer compose %s of pipeline %s", composeResponse.Id, pipeline.Id)
+	pipeline, _, err = h.moveComposePipeline(ctx, pipeline, ComposePipelineStateTriggered, &composeResponse.Id, "")
+	return pipeline, err
+}
+
+// moveComposePipeline moves the pipeline from its state to the next. When it
+// was moved by someone else in the meantime it's returned as it is now,
+// along with false.
+func (h *Handlers) moveComposePipeline(ctx echo.Context, pipeline *db.ComposePipelineEntry, to ComposePipelineState, installerComposeId *uuid.UUID, reason string) (*db.ComposePipelineEntry, bool, error) {
+	var errorMessage *string
+	if reason != "" {
+		errorMessage = &reason
+	}
+	err := h.server.db.SetComposePipelineState(ctx.Request().Context(), pipeline.Id, pipeline.State, string(to), installerComposeId, errorMessage)
+	if errors.Is(err, db.ComposePipelineStateError) {
+		userID, err := h.server.getIdentity(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+		pipeline, err = h.server.db.GetComposePipeline(ctx.Request().Context(), pipeline.Id, userID.OrgID())
+		return pipeline, false, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	moved := *pipeline
+	moved.State = string(to)
+	if installerComposeId != nil {
+		moved.InstallerComposeId = installerComposeId
+	}
+	moved.Error = errorMessage
+	return &moved, true, nil
+}
+
+// pipelineInstallerRequest is the compose request of the commit with the
+// image request of the installer, whose ostree options default to the URL
+// the commit was uploaded to and its ref. The URL is signed like the one in
+// the status, so the installer request doesn't hand out the bucket of
+// composer either.
+func (s *Server) pipelineInstallerRequest(pipeline *db.ComposePipelineEntry, commit ComposeStatus) (ComposeRequest, error) {
+	var installer ImageRequest
+	err := json.Unmarshal(pipeline.InstallerRequest, &installer)
+	if err != nil {
+		return ComposeRequest{}, err
+	}
+
+	us := commit.ImageStatus.UploadStatus
+	if us == nil || us.Type != UploadTypesAwsS3 {
+		return ComposeRequest{}, fmt.Errorf("The commit compose wasn't uploaded to aws.s3")
+	}
+	upload, err := us.Options.AsAWSS3UploadStatus()
+	if err != nil {
+		return ComposeRequest{}, err
+	}
+
+	if installer.Ostree == nil {
+		installer.Ostree = &OSTree{}
+	}
+	if installer.Ostree.Url == nil {
+		url := upload.Url
+		if s.downloadSigner != nil {
+			url, err = s.downloadSigner.SignURL(url, pipelineCommitLinkLifetime)
+			if err != nil {
+				return ComposeRequest{}, err
+			}
+		}
+		installer.Ostree.Url = &url
+	}
+	if commitOstree := commit.Request.ImageRequests[0].Ostree; installer.Ostree.Ref == nil && commitOstree != nil {
+		installer.Ostree.Ref = commitOstree.Ref
+	}
+
+	installerRequest := commit.Request
+	installerRequest.ImageRequests = []ImageRequest{installer}
+	installerRequest.Pipeline = nil
+	return installerRequest, nil
+}
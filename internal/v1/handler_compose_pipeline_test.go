@@ -0,0 +1,236 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func pipelineRequest(t *testing.T) ComposeRequest {
+	var uo UploadRequest_Options
+	require.NoError(t, uo.FromAWSS3UploadRequestOptions(AWSS3UploadRequestOptions{}))
+	return ComposeRequest{
+		Distribution: "rhel-8",
+		ImageRequests: []ImageRequest{
+			{
+				Architecture: "x86_64",
+				ImageType:    ImageTypesEdgeCommit,
+				Ostree: &OSTree{
+					Ref: common.ToPtr("edge/ref"),
+				},
+				UploadRequest: UploadRequest{
+					Type:    UploadTypesAwsS3,
+					Options: uo,
+				},
+			},
+		},
+		Pipeline: &ComposePipelineRequest{
+			Installer: ImageRequest{
+				Architecture: "x86_64",
+				ImageType:    ImageTypesEdgeInstaller,
+				UploadRequest: UploadRequest{
+					Type:    UploadTypesAwsS3,
+					Options: uo,
+				},
+			},
+		},
+	}
+}
+
+func TestValidateComposePipeline(t *testing.T) {
+	require.NoError(t, validateComposePipeline(pipelineRequest(t)))
+
+	cr := pipelineRequest(t)
+	cr.ImageRequests[0].ImageType = ImageTypesGuestImage
+	require.ErrorContains(t, validateComposePipeline(cr), "edge-commit")
+
+	cr = pipelineRequest(t)
+	cr.ImageRequests[0].UploadRequest.Type = UploadTypesAws
+	require.ErrorContains(t, validateComposePipeline(cr), "aws.s3")
+
+	cr = pipelineRequest(t)
+	cr.Pipeline.Installer.ImageType = ImageTypesImageInstaller
+	require.ErrorContains(t, validateComposePipeline(cr), "edge-installer")
+
+	cr = pipelineRequest(t)
+	cr.ImageRequests[0].AdditionalArchitectures = &[]ImageRequestAdditionalArchitectures{ImageRequestAdditionalArchitecturesAarch64}
+	require.ErrorContains(t, validateComposePipeline(cr), "single architecture")
+
+	cr = pipelineRequest(t)
+	cr.Pipeline.Installer.Architecture = ImageRequestArchitectureAarch64
+	require.ErrorContains(t, validateComposePipeline(cr), "architecture of the commit")
+}
+
+func TestPipelineInstallerRequest(t *testing.T) {
+	cr := pipelineRequest(t)
+	installer, err := json.Marshal(cr.Pipeline.Installer)
+	require.NoError(t, err)
+	pipeline := &db.ComposePipelineEntry{InstallerRequest: installer}
+	commitRequest := cr
+	commitRequest.Pipeline = nil
+	us := &UploadStatus{Type: UploadTypesAwsS3}
+	require.NoError(t, us.Options.FromAWSS3UploadStatus(AWSS3UploadStatus{Url: "https://bucket.test/commit.tar"}))
+	commit := ComposeStatus{
+		ImageStatus: ImageStatus{
+			Status:       ImageStatusStatusSuccess,
+			UploadStatus: us,
+		},
+		Request: commitRequest,
+	}
+
+	installerRequest, err := (&Server{}).pipelineInstallerRequest(pipeline, commit)
+	require.NoError(t, err)
+	require.Equal(t, "rhel-8", string(installerRequest.Distribution))
+	require.Nil(t, installerRequest.Pipeline)
+	require.Len(t, installerRequest.ImageRequests, 1)
+	require.Equal(t, ImageTypesEdgeInstaller, installerRequest.ImageRequests[0].ImageType)
+	require.Equal(t, "https://bucket.test/commit.tar", *installerRequest.ImageRequests[0].Ostree.Url)
+	require.Equal(t, "edge/ref", *installerRequest.ImageRequests[0].Ostree.Ref)
+	// the commit isn't touched
+	require.Nil(t, commit.Request.ImageRequests[0].Ostree.Url)
+
+	installerRequest, err = (&Server{downloadSigner: fakeSigner{}}).pipelineInstallerRequest(pipeline, commit)
+	require.NoError(t, err)
+	require.Equal(t, "https://signed.test/commit.tar?expires=24h0m0s", *installerRequest.ImageRequests[0].Ostree.Url)
+
+	// an URL of the request wins
+	cr.Pipeline.Installer.Ostree = &OSTree{Url: common.ToPtr("https://repo.test/ostree")}
+	pipeline.InstallerRequest, err = json.Marshal(cr.Pipeline.Installer)
+	require.NoError(t, err)
+	installerRequest, err = (&Server{}).pipelineInstallerRequest(pipeline, commit)
+	require.NoError(t, err)
+	require.Equal(t, "https://repo.test/ostree", *installerRequest.ImageRequests[0].Ostree.Url)
+
+	commit.ImageStatus.UploadStatus = nil
+	_, err = (&Server{}).pipelineInstallerRequest(pipeline, commit)
+	require.ErrorContains(t, err, "aws.s3")
+}
+
+func TestComposePipeline(t *testing.T) {
+	var mu sync.Mutex
+	var composeIds []uuid.UUID
+	var composeReqs []composer.ComposeRequest
+	commitBuilt := false
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			var composeReq composer.ComposeRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&composeReq))
+			composeReqs = append(composeReqs, composeReq)
+			id := uuid.New()
+			composeIds = append(composeIds, id)
+			w.WriteHeader(http.StatusCreated)
+			require.NoError(t, json.NewEncoder(w).Encode(composer.ComposeId{
+				Href: "/api/image-builder-composer/v2/compose",
+				Id:   id,
+				Kind: "ComposeId",
+			}))
+			return
+		}
+
+		status := composer.ComposeStatus{
+			ImageStatus: composer.ImageStatus{
+				Status: composer.ImageStatusValueBuilding,
+			},
+		}
+		if commitBuilt && strings.HasSuffix(r.URL.Path, composeIds[0].String()) {
+			var us composer.UploadStatus
+			require.NoError(t, us.Options.FromAWSS3UploadStatus(composer.AWSS3UploadStatus{
+				Url: "https://bucket.test/commit.tar",
+			}))
+			us.Type = composer.UploadTypesAwsS3
+			us.Status = composer.Success
+			status.ImageStatus = composer.ImageStatus{
+				Status:       composer.ImageStatusValueSuccess,
+				UploadStatus: &us,
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+		require.NoError(t, json.NewEncoder(w).Encode(status))
+	}))
+	defer apiSrv.Close()
+
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
+		DBase:            dbase,
+		DistributionsDir: "../../distributions",
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	respStatusCode, body := tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/compose", pipelineRequest(t))
+	require.Equal(t, http.StatusCreated, respStatusCode, body)
+	var result ComposeResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Len(t, composeReqs, 1)
+	require.Equal(t, composer.ImageTypesEdgeCommit, composeReqs[0].ImageRequest.ImageType)
+
+	getStatus := func(id uuid.UUID) ComposeStatus {
+		respStatusCode, body := tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s", id), &tutils.AuthString0)
+		require.Equal(t, http.StatusOK, respStatusCode, body)
+		var status ComposeStatus
+		require.NoError(t, json.Unmarshal([]byte(body), &status))
+		return status
+	}
+
+	status := getStatus(result.Id)
+	require.Nil(t, status.Request.Pipeline)
+	require.NotNil(t, status.Pipeline)
+	require.Equal(t, ComposePipelineStateWaiting, status.Pipeline.State)
+	require.Equal(t, result.Id, status.Pipeline.CommitComposeId)
+	require.Nil(t, status.Pipeline.InstallerComposeId)
+	require.Len(t, composeReqs, 1)
+
+	// once the commit was built, reading its status submits the installer
+	mu.Lock()
+	commitBuilt = true
+	mu.Unlock()
+	status = getStatus(result.Id)
+	require.Equal(t, ComposePipelineStateTriggered, status.Pipeline.State)
+	require.NotNil(t, status.Pipeline.InstallerComposeId)
+	require.Len(t, composeReqs, 2)
+	require.Equal(t, composeIds[1], *status.Pipeline.InstallerComposeId)
+	installerReq := composeReqs[1].ImageRequest
+	require.Equal(t, composer.ImageTypesEdgeInstaller, installerReq.ImageType)
+	require.Equal(t, "https://bucket.test/commit.tar", *installerReq.Ostree.Url)
+	require.Equal(t, "edge/ref", *installerReq.Ostree.Ref)
+
+	// the installer is submitted once only
+	status = getStatus(result.Id)
+	require.Equal(t, ComposePipelineStateTriggered, status.Pipeline.State)
+	require.Len(t, composeReqs, 2)
+
+	status = getStatus(composeIds[1])
+	require.Equal(t, ComposePipelineStateTriggered, status.Pipeline.State)
+	require.Equal(t, result.Id, status.Pipeline.CommitComposeId)
+	require.Equal(t, ImageTypesEdgeInstaller, status.Request.ImageRequests[0].ImageType)
+
+	cr := pipelineRequest(t)
+	cr.Pipeline.Installer.ImageType = ImageTypesGuestImage
+	respStatusCode, _ = tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/compose", cr)
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+	require.Len(t, composeReqs, 2)
+}
@@ -0,0 +1,61 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/common"
+)
+
+// ShareCompose shares the AMI of a finished compose with more accounts.
+// Composer can't change the launch permissions of an AMI it built, so the
+// AMI gets cloned within its region and the clone is shared instead.
+func (h *Handlers) ShareCompose(ctx echo.Context, composeId uuid.UUID) error {
+	composeEntry, err := h.getComposeByIdAndOrgId(ctx, composeId)
+	if err != nil {
+		return err
+	}
+	err = h.checkAWSCompose(ctx, composeId, "Sharing a compose is only available for AWS composes")
+	if err != nil {
+		return err
+	}
+
+	var request ComposeShareRequest
+	err = ctx.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	status, err := h.composeStatus(ctx, composeEntry)
+	if err != nil {
+		return err
+	}
+	if status.ImageStatus.Status != ImageStatusStatusSuccess || status.ImageStatus.UploadStatus == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Compose %s didn't finish successfully, only built images can be shared", composeId))
+	}
+	upload, err := status.ImageStatus.UploadStatus.Options.AsAWSUploadStatus()
+	if err != nil {
+		return err
+	}
+
+	cClient, err := h.server.composerFor(composeEntry.Region)
+	if err != nil {
+		return err
+	}
+	cloneId, err := h.cloneComposeToRegion(ctx, cClient, composeId, AWSEC2Clone{
+		Region:            common.ToPtr(upload.Region),
+		ShareWithAccounts: common.ToPtr(request.ShareWithAccounts),
+	}, request.ShareWithAccounts, nil)
+	if err != nil {
+		return err
+	}
+	ctx.Logger().Infof("Sharing compose %s in %s with %d accounts by clone %s", composeId, upload.Region, len(request.ShareWithAccounts), cloneId)
+
+	return ctx.JSON(http.StatusCreated, CloneResponse{
+		Id:  cloneId,
+		Ids: []uuid.UUID{cloneId},
+	})
+}
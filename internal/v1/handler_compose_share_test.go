@@ -0,0 +1,115 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestShareCompose(t *testing.T) {
+	ctx := context.Background()
+	built := uuid.New()
+	building := uuid.New()
+	var cloneReqs []composer.AWSEC2CloneCompose
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			status := composer.ComposeStatus{
+				ImageStatus: composer.ImageStatus{
+					Status: composer.ImageStatusValueBuilding,
+				},
+			}
+			if strings.HasSuffix(r.URL.Path, built.String()) {
+				var uo composer.UploadStatus_Options
+				require.NoError(t, uo.FromAWSEC2UploadStatus(composer.AWSEC2UploadStatus{
+					Ami:    "ami-0c830793775595d4b",
+					Region: "eu-west-1",
+				}))
+				status.ImageStatus = composer.ImageStatus{
+					Status: composer.ImageStatusValueSuccess,
+					UploadStatus: &composer.UploadStatus{
+						Options: uo,
+						Status:  composer.Success,
+						Type:    composer.UploadTypesAws,
+					},
+				}
+			}
+			w.WriteHeader(http.StatusOK)
+			require.NoError(t, json.NewEncoder(w).Encode(status))
+			return
+		}
+		require.True(t, strings.HasSuffix(r.URL.Path, fmt.Sprintf("%v/clone", built)))
+		var cloneReq composer.AWSEC2CloneCompose
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&cloneReq))
+		cloneReqs = append(cloneReqs, cloneReq)
+
+		w.WriteHeader(http.StatusCreated)
+		require.NoError(t, json.NewEncoder(w).Encode(composer.CloneComposeResponse{
+			Id: uuid.New(),
+		}))
+	}))
+	defer apiSrv.Close()
+
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	awsRequest := json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`)
+	err = dbase.InsertCompose(ctx, built, "500000", "user500000@test.test", "000000", nil, awsRequest, nil, nil, nil, nil)
+	require.NoError(t, err)
+	err = dbase.InsertCompose(ctx, building, "500000", "user500000@test.test", "000000", nil, awsRequest, nil, nil, nil, nil)
+	require.NoError(t, err)
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
+		DBase:            dbase,
+		DistributionsDir: "../../distributions",
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	accounts := []string{"123456789012", "210987654321"}
+	respStatusCode, body := tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/share", built), ComposeShareRequest{
+		ShareWithAccounts: accounts,
+	})
+	require.Equal(t, http.StatusCreated, respStatusCode, body)
+	var cResp CloneResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &cResp))
+	require.Equal(t, []uuid.UUID{cResp.Id}, cResp.Ids)
+
+	// the AMI is cloned within its own region
+	require.Len(t, cloneReqs, 1)
+	require.Equal(t, "eu-west-1", cloneReqs[0].Region)
+	require.Equal(t, accounts, *cloneReqs[0].ShareWithAccounts)
+
+	// the share is listed with the clones of the compose
+	respStatusCode, body = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/clones", built), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var csResp ClonesResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &csResp))
+	require.Len(t, csResp.Data, 1)
+	require.Equal(t, cResp.Id, csResp.Data[0].Id)
+
+	respStatusCode, _ = tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/share", building), ComposeShareRequest{
+		ShareWithAccounts: accounts,
+	})
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+	respStatusCode, _ = tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/share", built), ComposeShareRequest{
+		ShareWithAccounts: []string{"not-an-account"},
+	})
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+	require.Len(t, cloneReqs, 1)
+}
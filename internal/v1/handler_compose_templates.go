@@ -0,0 +1,195 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+func (h *Handlers) composeTemplateFromEntry(e *db.ComposeTemplateEntry) (ComposeTemplate, error) {
+	template := ComposeTemplate{
+		Name:        e.Name,
+		Version:     e.Version,
+		Description: e.Description,
+		CreatedBy:   e.CreatedBy,
+		CreatedAt:   e.CreatedAt.Format(time.RFC3339),
+	}
+	err := h.server.openComposeRequest(e.Request, &template.Request)
+	return template, err
+}
+
+// getComposeTemplate returns the version of the template, the latest one if
+// version is nil.
+func (h *Handlers) getComposeTemplate(ctx echo.Context, name string, version *int) (*db.ComposeTemplateEntry, error) {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := h.server.db.GetComposeTemplate(ctx.Request().Context(), userID.OrgID(), name, version)
+	if errors.Is(err, db.ComposeTemplateNotFoundError) {
+		if version != nil {
+			return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Version %d of compose template %s not found", *version, name))
+		}
+		return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Compose template %s not found", name))
+	}
+	return entry, err
+}
+
+func (h *Handlers) GetComposeTemplates(ctx echo.Context) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.server.db.GetComposeTemplates(ctx.Request().Context(), userID.OrgID())
+	if err != nil {
+		return err
+	}
+
+	data := make([]ComposeTemplate, 0, len(entries))
+	for i := range entries {
+		template, err := h.composeTemplateFromEntry(&entries[i])
+		if err != nil {
+			return err
+		}
+		data = append(data, template)
+	}
+	return ctx.JSON(http.StatusOK, ComposeTemplatesResponse{
+		Data: data,
+	})
+}
+
+// PublishComposeTemplate stores the request as the next version of the
+// template. The request is only checked once a compose is built from it,
+// the overrides can complete it.
+func (h *Handlers) PublishComposeTemplate(ctx echo.Context) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !userID.IsOrgAdmin() {
+		return echo.NewHTTPError(http.StatusForbidden, "Compose templates can only be published by organization administrators")
+	}
+
+	var request PublishComposeTemplateJSONRequestBody
+	err = ctx.Bind(&request)
+	if err != nil {
+		return err
+	}
+	err = validateComposeLabels(request.Request.Labels)
+	if err != nil {
+		return err
+	}
+	// unlike the requests of composes the template keeps its secrets even
+	// with redaction enabled, the composes built from it need them
+	raw, err := json.Marshal(request.Request)
+	if err != nil {
+		return err
+	}
+	sealed, err := h.server.encryptCustomizations(raw)
+	if err != nil {
+		return err
+	}
+
+	entry := db.ComposeTemplateEntry{
+		Id:          uuid.New(),
+		OrgId:       userID.OrgID(),
+		Name:        request.Name,
+		Description: common.FromPtr(request.Description),
+		Request:     sealed,
+		CreatedBy:   userID.Email(),
+	}
+	err = h.server.db.InsertComposeTemplate(ctx.Request().Context(), &entry)
+	if err != nil {
+		var e *pgconn.PgError
+		if errors.As(err, &e) && e.Code == pgerrcode.UniqueViolation {
+			return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("Another version of compose template %s was published at the same time", request.Name))
+		}
+		return err
+	}
+	ctx.Logger().Infof("Published version %d of compose template %s for org %s", entry.Version, entry.Name, entry.OrgId)
+
+	template, err := h.composeTemplateFromEntry(&entry)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusCreated, template)
+}
+
+func (h *Handlers) GetComposeTemplate(ctx echo.Context, name string, params GetComposeTemplateParams) error {
+	entry, err := h.getComposeTemplate(ctx, name, params.Version)
+	if err != nil {
+		return err
+	}
+	template, err := h.composeTemplateFromEntry(entry)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, template)
+}
+
+func (h *Handlers) DeleteComposeTemplate(ctx echo.Context, name string) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !userID.IsOrgAdmin() {
+		return echo.NewHTTPError(http.StatusForbidden, "Compose templates can only be deleted by organization administrators")
+	}
+
+	err = h.server.db.DeleteComposeTemplate(ctx.Request().Context(), userID.OrgID(), name)
+	if err != nil {
+		if errors.Is(err, db.ComposeTemplateNotFoundError) {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Compose template %s not found", name))
+		}
+		return err
+	}
+	ctx.Logger().Infof("Deleted compose template %s of org %s", name, userID.OrgID())
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// ComposeFromTemplate submits the request of the template with the
+// overrides applied, like a compose request of its own.
+func (h *Handlers) ComposeFromTemplate(ctx echo.Context, name string) error {
+	var overrides ComposeFromTemplateJSONRequestBody
+	err := ctx.Bind(&overrides)
+	if err != nil {
+		return err
+	}
+
+	entry, err := h.getComposeTemplate(ctx, name, overrides.Version)
+	if err != nil {
+		return err
+	}
+	var template ComposeRequest
+	err = h.server.openComposeRequest(entry.Request, &template)
+	if err != nil {
+		return err
+	}
+	composeRequest, err := mergeComposeTemplate(template, entry.Name, entry.Version, overrides)
+	if err != nil {
+		return err
+	}
+	clientId := ClientId("api")
+	if ctx.Request().Header.Get("X-ImageBuilder-ui") != "" {
+		clientId = "ui"
+	}
+	composeRequest.ClientId = &clientId
+
+	composeResponse, err := h.submitComposeRequest(ctx, composeRequest)
+	if err != nil {
+		ctx.Logger().Errorf("Failed to compose version %d of template %s: %v", entry.Version, entry.Name, err)
+		return err
+	}
+	return ctx.JSON(http.StatusCreated, composeResponse)
+}
@@ -0,0 +1,129 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestComposeTemplates(t *testing.T) {
+	id := uuid.New()
+	var composerRequest composer.ComposeRequest
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&composerRequest))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		require.NoError(t, json.NewEncoder(w).Encode(composer.ComposeId{
+			Id: id,
+		}))
+	}))
+	defer apiSrv.Close()
+
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
+		DBase:            dbase,
+		DistributionsDir: "../../distributions",
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	var uo UploadRequest_Options
+	require.NoError(t, uo.FromAWSS3UploadRequestOptions(AWSS3UploadRequestOptions{}))
+	request := ComposeRequest{
+		Distribution: "centos-9",
+		ImageRequests: []ImageRequest{
+			{
+				Architecture: "x86_64",
+				ImageType:    ImageTypesGuestImage,
+				UploadRequest: UploadRequest{
+					Type:    UploadTypesAwsS3,
+					Options: uo,
+				},
+			},
+		},
+		Customizations: &Customizations{
+			Packages: &[]string{"nginx"},
+		},
+	}
+	publish := func() ComposeTemplate {
+		respStatusCode, body := tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/templates", PublishComposeTemplateRequest{
+			Name:        "web",
+			Description: common.ToPtr("web servers"),
+			Request:     request,
+		})
+		require.Equal(t, http.StatusCreated, respStatusCode)
+		var template ComposeTemplate
+		require.NoError(t, json.Unmarshal([]byte(body), &template))
+		return template
+	}
+	template := publish()
+	require.Equal(t, 1, template.Version)
+	require.Equal(t, "user100000@test.test", template.CreatedBy)
+	request.Distribution = "rhel-9"
+	template = publish()
+	require.Equal(t, 2, template.Version)
+	require.Equal(t, Distributions("rhel-9"), template.Request.Distribution)
+
+	respStatusCode, body := tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/templates", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var templates ComposeTemplatesResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &templates))
+	require.Len(t, templates.Data, 1)
+	require.Equal(t, 2, templates.Data[0].Version)
+
+	respStatusCode, body = tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/templates/web?version=1", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.NoError(t, json.Unmarshal([]byte(body), &template))
+	require.Equal(t, 1, template.Version)
+	require.Equal(t, Distributions("centos-9"), template.Request.Distribution)
+	respStatusCode, _ = tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/templates/web?version=3", &tutils.AuthString0)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+	// templates of other orgs are not found
+	respStatusCode, _ = tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/templates/web", &tutils.AuthString1)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+
+	respStatusCode, body = tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/templates/web/compose", ComposeTemplateOverrides{
+		Version:  common.ToPtr(1),
+		Packages: &[]string{"vim"},
+		Labels:   &ComposeLabels{"env": "staging"},
+	})
+	require.Equal(t, http.StatusCreated, respStatusCode)
+	var result ComposeResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Equal(t, id, result.Id)
+	require.Equal(t, "centos-9", composerRequest.Distribution)
+	require.Equal(t, []string{"nginx", "vim"}, *composerRequest.Customizations.Packages)
+
+	compose, err := dbase.GetCompose(context.Background(), id, "000000")
+	require.NoError(t, err)
+	var stored ComposeRequest
+	require.NoError(t, json.Unmarshal(compose.Request, &stored))
+	require.Equal(t, ComposeLabels{
+		"env":                "staging",
+		templateLabel:        "web",
+		templateVersionLabel: "1",
+	}, *stored.Labels)
+
+	respStatusCode, _ = tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/templates/db/compose", ComposeTemplateOverrides{})
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+
+	respStatusCode, _ = tutils.DeleteResponseBody(t, "http://localhost:8086/api/image-builder/v1/templates/web")
+	require.Equal(t, http.StatusNoContent, respStatusCode)
+	respStatusCode, _ = tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/templates/web", &tutils.AuthString0)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+	respStatusCode, _ = tutils.DeleteResponseBody(t, "http://localhost:8086/api/image-builder/v1/templates/web")
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+}
@@ -0,0 +1,224 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+func orgDefaultsResponseFromEntry(e *db.OrgDefaultsEntry) (*OrgDefaultsResponse, error) {
+	var defaults OrgDefaults
+	err := json.Unmarshal(e.Defaults, &defaults)
+	if err != nil {
+		return nil, err
+	}
+	return &OrgDefaultsResponse{
+		Defaults:  defaults,
+		UpdatedBy: e.UpdatedBy,
+		UpdatedAt: e.UpdatedAt.Format(time.RFC3339),
+	}, nil
+}
+
+func (h *Handlers) GetOrgDefaults(ctx echo.Context) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	entry, err := h.server.db.GetOrgDefaults(ctx.Request().Context(), userID.OrgID())
+	if errors.Is(err, db.OrgDefaultsNotFoundError) {
+		return echo.NewHTTPError(http.StatusNotFound, err)
+	}
+	if err != nil {
+		return err
+	}
+	resp, err := orgDefaultsResponseFromEntry(entry)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *Handlers) SetOrgDefaults(ctx echo.Context) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !userID.IsOrgAdmin() {
+		return echo.NewHTTPError(http.StatusForbidden, "The defaults can only be changed by organization administrators")
+	}
+
+	var defaults SetOrgDefaultsJSONRequestBody
+	err = ctx.Bind(&defaults)
+	if err != nil {
+		return err
+	}
+	err = checkOrgDefaults(defaults)
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(defaults)
+	if err != nil {
+		return err
+	}
+	err = h.server.db.SetOrgDefaults(ctx.Request().Context(), userID.OrgID(), userID.Email(), raw)
+	if err != nil {
+		return err
+	}
+	ctx.Logger().Infof("Set the defaults of orgID: %s", userID.OrgID())
+
+	entry, err := h.server.db.GetOrgDefaults(ctx.Request().Context(), userID.OrgID())
+	if err != nil {
+		return err
+	}
+	resp, err := orgDefaultsResponseFromEntry(entry)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *Handlers) DeleteOrgDefaults(ctx echo.Context) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !userID.IsOrgAdmin() {
+		return echo.NewHTTPError(http.StatusForbidden, "The defaults can only be changed by organization administrators")
+	}
+
+	err = h.server.db.DeleteOrgDefaults(ctx.Request().Context(), userID.OrgID())
+	if errors.Is(err, db.OrgDefaultsNotFoundError) {
+		return echo.NewHTTPError(http.StatusNotFound, err)
+	}
+	if err != nil {
+		return err
+	}
+	ctx.Logger().Infof("Deleted the defaults of orgID: %s", userID.OrgID())
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// checkOrgDefaults rejects defaults which would break every compose of the
+// org, like blank packages or two files at the same path.
+func checkOrgDefaults(defaults OrgDefaults) error {
+	for _, p := range common.FromPtr(defaults.Packages) {
+		if strings.TrimSpace(p) == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "The default packages can't be blank")
+		}
+	}
+	paths := map[string]bool{}
+	for _, f := range common.FromPtr(defaults.Files) {
+		if !path.IsAbs(f.Path) || path.Clean(f.Path) != f.Path {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("The default file %s needs a clean absolute path", f.Path))
+		}
+		if paths[f.Path] {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("The default file %s is listed more than once", f.Path))
+		}
+		paths[f.Path] = true
+	}
+	return nil
+}
+
+// orgDefaults returns the defaults of the org, nil if it has none.
+func (h *Handlers) orgDefaults(ctx echo.Context, orgID string) (*OrgDefaults, error) {
+	entry, err := h.server.db.GetOrgDefaults(ctx.Request().Context(), orgID)
+	if errors.Is(err, db.OrgDefaultsNotFoundError) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var defaults OrgDefaults
+	err = json.Unmarshal(entry.Defaults, &defaults)
+	if err != nil {
+		return nil, err
+	}
+	return &defaults, nil
+}
+
+// injectOrgDefaults merges the defaults into the customizations of the compose
+// request. The packages are added, the files replace the ones of the request
+// at the same path, the timezone and the locale are only set when the request
+// has none. The customizations are copied before they are changed, the ones of
+// a blueprint are shared by the composes of its image requests. Returns what
+// was injected, nil if nothing was.
+func injectOrgDefaults(composeRequest *ComposeRequest, defaults OrgDefaults) *InjectedDefaults {
+	var cust Customizations
+	if composeRequest.Customizations != nil {
+		cust = *composeRequest.Customizations
+	}
+	var injected InjectedDefaults
+
+	packages := slices.Clone(common.FromPtr(cust.Packages))
+	var added []string
+	for _, p := range common.FromPtr(defaults.Packages) {
+		if !slices.Contains(packages, p) {
+			packages = append(packages, p)
+			added = append(added, p)
+		}
+	}
+	if len(added) > 0 {
+		cust.Packages = &packages
+		injected.Packages = &added
+	}
+
+	if len(common.FromPtr(defaults.Files)) > 0 {
+		files := slices.Clone(common.FromPtr(cust.Files))
+		var paths []string
+		for _, f := range *defaults.Files {
+			i := slices.IndexFunc(files, func(r File) bool {
+				return r.Path == f.Path
+			})
+			if i >= 0 {
+				files[i] = f
+			} else {
+				files = append(files, f)
+			}
+			paths = append(paths, f.Path)
+		}
+		cust.Files = &files
+		injected.Files = &paths
+	}
+
+	if cust.Timezone == nil && defaults.Timezone != nil {
+		cust.Timezone = defaults.Timezone
+		injected.Timezone = common.ToPtr(true)
+	}
+	if cust.Locale == nil && defaults.Locale != nil {
+		cust.Locale = defaults.Locale
+		injected.Locale = common.ToPtr(true)
+	}
+
+	if injected == (InjectedDefaults{}) {
+		return nil
+	}
+	composeRequest.Customizations = &cust
+	return &injected
+}
+
+// composeInjectedDefaults returns what the defaults of the org merged into the
+// compose, nil if nothing was.
+func (h *Handlers) composeInjectedDefaults(ctx echo.Context, composeId uuid.UUID, orgID string) (*InjectedDefaults, error) {
+	raw, err := h.server.db.GetComposeInjectedDefaults(ctx.Request().Context(), composeId, orgID)
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var injected InjectedDefaults
+	err = json.Unmarshal(raw, &injected)
+	if err != nil {
+		return nil, err
+	}
+	return &injected, nil
+}
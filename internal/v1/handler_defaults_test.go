@@ -0,0 +1,164 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestInjectOrgDefaults(t *testing.T) {
+	defaults := OrgDefaults{
+		Packages: &[]string{"falcon-sensor", "vim"},
+		Files: &[]File{
+			{Path: "/etc/pki/ca-trust/source/anchors/corp.pem", Data: common.ToPtr("corp CA")},
+		},
+		Timezone: &Timezone{Timezone: common.ToPtr("Europe/Prague")},
+		Locale:   &Locale{Languages: &[]string{"cs_CZ.UTF-8"}},
+	}
+	cust := &Customizations{
+		Packages: &[]string{"vim"},
+		Files: &[]File{
+			{Path: "/etc/pki/ca-trust/source/anchors/corp.pem", Data: common.ToPtr("somebody else's CA")},
+			{Path: "/etc/motd", Data: common.ToPtr("hello")},
+		},
+		Timezone: &Timezone{Timezone: common.ToPtr("UTC")},
+	}
+	cr := ComposeRequest{Customizations: cust}
+
+	injected := injectOrgDefaults(&cr, defaults)
+	require.Equal(t, &InjectedDefaults{
+		Packages: &[]string{"falcon-sensor"},
+		Files:    &[]string{"/etc/pki/ca-trust/source/anchors/corp.pem"},
+		Locale:   common.ToPtr(true),
+	}, injected)
+	require.Equal(t, []string{"vim", "falcon-sensor"}, *cr.Customizations.Packages)
+	require.Equal(t, "corp CA", *(*cr.Customizations.Files)[0].Data)
+	require.Equal(t, "/etc/motd", (*cr.Customizations.Files)[1].Path)
+	// the timezone of the request wins
+	require.Equal(t, "UTC", *cr.Customizations.Timezone.Timezone)
+	require.Equal(t, defaults.Locale, cr.Customizations.Locale)
+
+	// the customizations of the request are left alone
+	require.Equal(t, []string{"vim"}, *cust.Packages)
+	require.Equal(t, "somebody else's CA", *(*cust.Files)[0].Data)
+	require.Nil(t, cust.Locale)
+
+	// requests without customizations get them
+	cr = ComposeRequest{}
+	injected = injectOrgDefaults(&cr, OrgDefaults{Packages: &[]string{"falcon-sensor"}})
+	require.Equal(t, &InjectedDefaults{Packages: &[]string{"falcon-sensor"}}, injected)
+	require.Equal(t, []string{"falcon-sensor"}, *cr.Customizations.Packages)
+
+	// nothing to inject
+	require.Nil(t, injectOrgDefaults(&cr, OrgDefaults{Packages: &[]string{"falcon-sensor"}}))
+	require.Nil(t, injectOrgDefaults(&cr, OrgDefaults{}))
+}
+
+func TestOrgDefaults(t *testing.T) {
+	id := uuid.New()
+	var composerRequest composer.ComposeRequest
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			err := json.NewEncoder(w).Encode(composer.ComposeStatus{
+				ImageStatus: composer.ImageStatus{
+					Status: composer.ImageStatusValueBuilding,
+				},
+				Status: composer.ComposeStatusValuePending,
+			})
+			require.NoError(t, err)
+			return
+		}
+		err := json.NewDecoder(r.Body).Decode(&composerRequest)
+		require.NoError(t, err)
+		w.WriteHeader(http.StatusCreated)
+		err = json.NewEncoder(w).Encode(composer.ComposeId{Id: id})
+		require.NoError(t, err)
+	}))
+	defer apiSrv.Close()
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, nil)
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	respStatusCode, _ := tutils.GetResponseBody(t, apiURL("/defaults"), &tutils.AuthString0)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+
+	respStatusCode, body := tutils.PutResponseBody(t, apiURL("/defaults"), OrgDefaults{
+		Files: &[]File{{Path: "etc/motd"}},
+	})
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+	require.Contains(t, body, "needs a clean absolute path")
+
+	defaults := OrgDefaults{
+		Packages: &[]string{"falcon-sensor"},
+		Timezone: &Timezone{Timezone: common.ToPtr("Europe/Prague")},
+	}
+	respStatusCode, _ = tutils.ResponseBody(t, http.MethodPut, apiURL("/defaults"), tutils.NewIdentity("000000").OrgAdmin(false).Base64(), defaults)
+	require.Equal(t, http.StatusForbidden, respStatusCode)
+	respStatusCode, body = tutils.PutResponseBody(t, apiURL("/defaults"), defaults)
+	require.Equal(t, http.StatusOK, respStatusCode, body)
+	var result OrgDefaultsResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Equal(t, defaults, result.Defaults)
+
+	var uo UploadRequest_Options
+	require.NoError(t, uo.FromAWSUploadRequestOptions(AWSUploadRequestOptions{
+		ShareWithAccounts: &[]string{"test-account"},
+	}))
+	payload := ComposeRequest{
+		Customizations: &Customizations{
+			Packages: &[]string{"vim"},
+		},
+		Distribution: "centos-9",
+		ImageRequests: []ImageRequest{
+			{
+				Architecture: "x86_64",
+				ImageType:    ImageTypesAws,
+				UploadRequest: UploadRequest{
+					Type:    UploadTypesAws,
+					Options: uo,
+				},
+			},
+		},
+	}
+	respStatusCode, body = tutils.PostResponseBody(t, apiURL("/compose"), payload)
+	require.Equal(t, http.StatusCreated, respStatusCode, body)
+	var composeResponse ComposeResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &composeResponse))
+	injected := &InjectedDefaults{
+		Packages: &[]string{"falcon-sensor"},
+		Timezone: common.ToPtr(true),
+	}
+	require.Equal(t, injected, composeResponse.InjectedDefaults)
+	require.Equal(t, []string{"vim", "falcon-sensor"}, *composerRequest.Customizations.Packages)
+	require.Equal(t, "Europe/Prague", *composerRequest.Customizations.Timezone.Timezone)
+
+	respStatusCode, body = tutils.GetResponseBody(t, apiURL("/composes/%s", id), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode, body)
+	var status ComposeStatus
+	require.NoError(t, json.Unmarshal([]byte(body), &status))
+	require.Equal(t, injected, status.InjectedDefaults)
+	require.Equal(t, []string{"vim", "falcon-sensor"}, *status.Request.Customizations.Packages)
+
+	respStatusCode, _ = tutils.DeleteResponseBody(t, apiURL("/defaults"))
+	require.Equal(t, http.StatusNoContent, respStatusCode)
+	respStatusCode, _ = tutils.DeleteResponseBody(t, apiURL("/defaults"))
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+}
@@ -0,0 +1,141 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/downloadtoken"
+)
+
+func downloadTokenFromEntry(e *db.DownloadTokenEntry) DownloadToken {
+	token := DownloadToken{
+		Id:        e.Id,
+		Name:      e.Name,
+		CreatedBy: e.CreatedBy,
+		CreatedAt: e.CreatedAt.Format(time.RFC3339),
+	}
+	if e.LastUsedAt != nil {
+		lastUsedAt := e.LastUsedAt.Format(time.RFC3339)
+		token.LastUsedAt = &lastUsedAt
+	}
+	return token
+}
+
+// orgAdminOfDownloadTokens returns the org of the user, who has to be an
+// administrator of it, tokens grant access to all images of the org.
+func (h *Handlers) orgAdminOfDownloadTokens(ctx echo.Context) (string, string, error) {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	if !userID.IsOrgAdmin() {
+		return "", "", echo.NewHTTPError(http.StatusForbidden, "Download tokens can only be managed by organization administrators")
+	}
+	return userID.OrgID(), userID.Email(), nil
+}
+
+func (h *Handlers) GetDownloadTokens(ctx echo.Context) error {
+	orgId, _, err := h.orgAdminOfDownloadTokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.server.db.GetDownloadTokens(ctx.Request().Context(), orgId)
+	if err != nil {
+		return err
+	}
+	data := make([]DownloadToken, 0, len(entries))
+	for i := range entries {
+		data = append(data, downloadTokenFromEntry(&entries[i]))
+	}
+	return ctx.JSON(http.StatusOK, DownloadTokensResponse{
+		Data: data,
+	})
+}
+
+// CreateDownloadToken returns the token once, only its hash is kept.
+func (h *Handlers) CreateDownloadToken(ctx echo.Context) error {
+	orgId, email, err := h.orgAdminOfDownloadTokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	var request CreateDownloadTokenJSONRequestBody
+	err = ctx.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	token, hash, err := downloadtoken.New()
+	if err != nil {
+		return err
+	}
+	entry := db.DownloadTokenEntry{
+		Id:        uuid.New(),
+		OrgId:     orgId,
+		Name:      request.Name,
+		CreatedBy: email,
+	}
+	err = h.server.db.InsertDownloadToken(ctx.Request().Context(), &entry, hash)
+	if err != nil {
+		return err
+	}
+	ctx.Logger().Infof("Created download token %s of org %s", entry.Id, orgId)
+
+	resp := downloadTokenFromEntry(&entry)
+	resp.Token = &token
+	return ctx.JSON(http.StatusCreated, resp)
+}
+
+func (h *Handlers) DeleteDownloadToken(ctx echo.Context, id openapi_types.UUID) error {
+	orgId, _, err := h.orgAdminOfDownloadTokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = h.server.db.DeleteDownloadToken(ctx.Request().Context(), id, orgId)
+	if err != nil {
+		if errors.Is(err, db.DownloadTokenNotFoundError) {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Download token %s not found", id))
+		}
+		return err
+	}
+	ctx.Logger().Infof("Revoked download token %s of org %s", id, orgId)
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// GetArtifactDownload is reachable without authentication, the download
+// token is what grants access, and only to the images of its org.
+func (h *Handlers) GetArtifactDownload(ctx echo.Context, composeId openapi_types.UUID) error {
+	if h.server.downloadSigner == nil {
+		return echo.NewHTTPError(http.StatusForbidden, "Download links are not available")
+	}
+	token, err := downloadtoken.FromHeader(ctx.Request().Header.Get(echo.HeaderAuthorization))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, err)
+	}
+	entry, err := h.server.db.UseDownloadToken(ctx.Request().Context(), downloadtoken.Hash(token))
+	if err != nil {
+		if errors.Is(err, db.DownloadTokenNotFoundError) {
+			return echo.NewHTTPError(http.StatusUnauthorized, downloadtoken.ErrInvalidToken)
+		}
+		return err
+	}
+
+	composeEntry, err := h.server.db.GetCompose(ctx.Request().Context(), composeId, entry.OrgId)
+	if err != nil {
+		if errors.Is(err, db.ComposeNotFoundError) {
+			return echo.NewHTTPError(http.StatusNotFound, err)
+		}
+		return err
+	}
+	ctx.Logger().Infof("Download token %s of org %s fetches compose %s", entry.Id, entry.OrgId, composeId)
+	return h.composeDownload(ctx, composeEntry)
+}
@@ -0,0 +1,123 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+// getArtifactDownload sends the request the way a build farm would, with the
+// download token and no identity.
+func getArtifactDownload(t *testing.T, composeId uuid.UUID, authorization string) (int, string) {
+	request, err := http.NewRequest(http.MethodGet, apiURL("/artifacts/%s/download", composeId), nil)
+	require.NoError(t, err)
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	/* #nosec G307 */
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	return response.StatusCode, string(body)
+}
+
+func TestDownloadTokens(t *testing.T) {
+	ctx := context.Background()
+	composerURL := mockService(t, func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var us composer.UploadStatus
+		require.NoError(t, us.Options.FromAWSS3UploadStatus(composer.AWSS3UploadStatus{
+			Url: "https://bucket.test/image.qcow2",
+		}))
+		us.Type = composer.UploadTypesAwsS3
+		us.Status = composer.Success
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(composer.ComposeStatus{
+			ImageStatus: composer.ImageStatus{
+				Status:       composer.ImageStatusValueSuccess,
+				UploadStatus: &us,
+			},
+		}))
+	})
+
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	composeId := uuid.New()
+	otherOrg := uuid.New()
+	err = dbase.InsertCompose(ctx, composeId, "500000", "user500000@test.test", "000000", nil, json.RawMessage(`{"image_requests": [{"image_type": "guest-image"}]}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+	err = dbase.InsertCompose(ctx, otherOrg, "500000", "user500000@test.test", "000001", nil, json.RawMessage(`{"image_requests": [{"image_type": "guest-image"}]}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+	startTestServer(t, &testServerClientsConf{ComposerURL: composerURL}, &ServerConfig{
+		DBase:                dbase,
+		DownloadSigner:       fakeSigner{},
+		DownloadLinkLifetime: 15 * time.Minute,
+	})
+
+	// only org admins manage tokens
+	member := tutils.NewIdentity("000000").OrgAdmin(false).Base64()
+	respStatusCode, body := tutils.ResponseBody(t, http.MethodPost, apiURL("/download-tokens"), member, DownloadTokenRequest{Name: "build farm"})
+	require.Equal(t, http.StatusForbidden, respStatusCode, body)
+	respStatusCode, body = tutils.ResponseBody(t, http.MethodGet, apiURL("/download-tokens"), member, nil)
+	require.Equal(t, http.StatusForbidden, respStatusCode, body)
+
+	respStatusCode, body = tutils.ResponseBody(t, http.MethodPost, apiURL("/download-tokens"), tutils.AuthString0, DownloadTokenRequest{Name: "build farm"})
+	require.Equal(t, http.StatusCreated, respStatusCode, body)
+	var created DownloadToken
+	require.NoError(t, json.Unmarshal([]byte(body), &created))
+	require.Equal(t, "build farm", created.Name)
+	require.NotNil(t, created.Token)
+	require.Nil(t, created.LastUsedAt)
+
+	// the token is only ever returned on creation
+	respStatusCode, body = tutils.ResponseBody(t, http.MethodGet, apiURL("/download-tokens"), tutils.AuthString0, nil)
+	require.Equal(t, http.StatusOK, respStatusCode, body)
+	require.NotContains(t, body, *created.Token)
+	var tokens DownloadTokensResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &tokens))
+	require.Len(t, tokens.Data, 1)
+	require.Equal(t, created.Id, tokens.Data[0].Id)
+
+	respStatusCode, body = getArtifactDownload(t, composeId, "Bearer "+*created.Token)
+	require.Equal(t, http.StatusOK, respStatusCode, body)
+	var download ComposeDownload
+	require.NoError(t, json.Unmarshal([]byte(body), &download))
+	require.Equal(t, "https://signed.test/image.qcow2?expires=15m0s", download.Url)
+
+	respStatusCode, body = tutils.ResponseBody(t, http.MethodGet, apiURL("/download-tokens"), tutils.AuthString0, nil)
+	require.Equal(t, http.StatusOK, respStatusCode, body)
+	require.NoError(t, json.Unmarshal([]byte(body), &tokens))
+	require.NotNil(t, tokens.Data[0].LastUsedAt)
+
+	// the token grants nothing beyond the images of its org
+	respStatusCode, _ = getArtifactDownload(t, otherOrg, "Bearer "+*created.Token)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+	respStatusCode, _ = tutils.GetResponseBody(t, apiURL("/composes/%s/download", composeId), created.Token)
+	require.NotEqual(t, http.StatusOK, respStatusCode)
+	for _, authorization := range []string{"", *created.Token, "Bearer " + strings.TrimPrefix(*created.Token, "ibdt_")} {
+		respStatusCode, _ = getArtifactDownload(t, composeId, authorization)
+		require.Equal(t, http.StatusUnauthorized, respStatusCode)
+	}
+
+	respStatusCode, body = tutils.ResponseBody(t, http.MethodDelete, apiURL("/download-tokens/%s", created.Id), tutils.AuthString1, nil)
+	require.Equal(t, http.StatusNotFound, respStatusCode, body)
+	respStatusCode, body = tutils.ResponseBody(t, http.MethodDelete, apiURL("/download-tokens/%s", created.Id), tutils.AuthString0, nil)
+	require.Equal(t, http.StatusNoContent, respStatusCode, body)
+	respStatusCode, _ = getArtifactDownload(t, composeId, "Bearer "+*created.Token)
+	require.Equal(t, http.StatusUnauthorized, respStatusCode)
+}
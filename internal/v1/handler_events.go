@@ -0,0 +1,54 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handlers) GetEvents(ctx echo.Context, params GetEventsParams) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	limit := 100
+	if params.Limit != nil && *params.Limit > 0 {
+		limit = *params.Limit
+	}
+
+	// cursors are event ids, but consumers shouldn't rely on that
+	var after int64
+	if params.Since != nil && *params.Since != "" {
+		after, err = strconv.ParseInt(*params.Since, 10, 64)
+		if err != nil || after < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid cursor")
+		}
+	}
+
+	entries, err := h.server.db.GetComposeEvents(ctx.Request().Context(), userID.OrgID(), after, limit)
+	if err != nil {
+		return err
+	}
+
+	data := make([]ComposeEvent, 0, len(entries))
+	for _, e := range entries {
+		data = append(data, ComposeEvent{
+			Id:        strconv.FormatInt(e.Id, 10),
+			Type:      ComposeEventType(e.Type),
+			ComposeId: e.ComposeId,
+			ImageType: e.ImageType,
+			Status:    e.Status,
+			ErrorCode: e.ErrorCode,
+			CreatedAt: e.CreatedAt.Format(time.RFC3339),
+		})
+		after = e.Id
+	}
+
+	return ctx.JSON(http.StatusOK, ComposeEventsResponse{
+		Data:       data,
+		NextCursor: strconv.FormatInt(after, 10),
+	})
+}
@@ -0,0 +1,39 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestGetEvents(t *testing.T) {
+	srv, tokenSrv := startServer(t, &testServerClientsConf{}, nil)
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	statusCode, _ := tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/events?since=abc", &tutils.AuthString0)
+	require.Equal(t, http.StatusBadRequest, statusCode)
+	statusCode, _ = tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/events?since=-1", &tutils.AuthString0)
+	require.Equal(t, http.StatusBadRequest, statusCode)
+
+	// nothing new, the cursor stays where it was
+	statusCode, body := tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/events?since=42", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, statusCode)
+	var result ComposeEventsResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Len(t, result.Data, 0)
+	require.Equal(t, "42", result.NextCursor)
+
+	statusCode, body = tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/events", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, statusCode)
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Equal(t, "0", result.NextCursor)
+}
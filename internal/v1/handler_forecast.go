@@ -0,0 +1,129 @@
+package v1
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+const defaultForecastHistoryMonths = 6
+
+func (h *Handlers) GetUsageForecast(ctx echo.Context, params GetUsageForecastParams) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !userID.IsOrgAdmin() {
+		return echo.NewHTTPError(http.StatusForbidden, "Forecasts can only be viewed by organization administrators")
+	}
+
+	months := defaultForecastHistoryMonths
+	if params.HistoryMonths != nil {
+		months = *params.HistoryMonths
+	}
+
+	// the current month is still running, it would drag the trend down, the
+	// forecast skips over it to the next month
+	now := time.Now().UTC()
+	end := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	start := end.AddDate(0, -months, 0)
+	usage, err := h.server.db.GetMonthlyComposeUsage(ctx.Request().Context(), userID.OrgID(), start, end)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, buildUsageForecast(usage, start, months))
+}
+
+// buildUsageForecast extrapolates the monthly usage starting at start to the
+// month after the current one, which follows the history.
+func buildUsageForecast(usage []db.MonthlyComposeUsage, start time.Time, months int) UsageForecast {
+	type series struct {
+		composes []float64
+		bytes    []float64
+	}
+	type key struct {
+		distribution string
+		imageType    string
+	}
+	all := map[key]*series{}
+	for _, u := range usage {
+		k := key{u.Distribution, u.ImageType}
+		s, ok := all[k]
+		if !ok {
+			s = &series{
+				composes: make([]float64, months),
+				bytes:    make([]float64, months),
+			}
+			all[k] = s
+		}
+		m := monthsBetween(start, u.Month)
+		if m < 0 || m >= months {
+			continue
+		}
+		s.composes[m] += float64(u.Composes)
+		s.bytes[m] += float64(u.BlobBytes)
+	}
+
+	forecast := UsageForecast{
+		Month:         start.AddDate(0, months+1, 0).Format("2006-01"),
+		HistoryMonths: months,
+		Data:          []UsageForecastItem{},
+	}
+	for k, s := range all {
+		item := UsageForecastItem{
+			Distribution: k.distribution,
+			ImageType:    k.imageType,
+			Composes:     int(math.Round(linearForecast(s.composes, months+1))),
+			StorageBytes: int64(math.Round(linearForecast(s.bytes, months+1))),
+			History:      make([]int, months),
+		}
+		for i, c := range s.composes {
+			item.History[i] = int(c)
+		}
+		forecast.Composes += item.Composes
+		forecast.StorageBytes += item.StorageBytes
+		forecast.Data = append(forecast.Data, item)
+	}
+	sort.Slice(forecast.Data, func(i, j int) bool {
+		if forecast.Data[i].Distribution != forecast.Data[j].Distribution {
+			return forecast.Data[i].Distribution < forecast.Data[j].Distribution
+		}
+		return forecast.Data[i].ImageType < forecast.Data[j].ImageType
+	})
+	return forecast
+}
+
+func monthsBetween(from, to time.Time) int {
+	to = to.UTC()
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
+
+// linearForecast fits a least squares line through the values, one per
+// month, and returns its value for month x. Usage can't shrink below zero.
+func linearForecast(ys []float64, x int) float64 {
+	n := float64(len(ys))
+	if n == 0 {
+		return 0
+	}
+	if n == 1 {
+		return ys[0]
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for i, y := range ys {
+		xi := float64(i)
+		sumX += xi
+		sumY += y
+		sumXY += xi * y
+		sumXX += xi * xi
+	}
+	slope := (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+	intercept := (sumY - slope*sumX) / n
+	return math.Max(0, intercept+slope*float64(x))
+}
@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestLinearForecast(t *testing.T) {
+	require.Equal(t, 0.0, linearForecast(nil, 1))
+	require.Equal(t, 3.0, linearForecast([]float64{3}, 2))
+	require.InDelta(t, 5.0, linearForecast([]float64{1, 2, 3, 4}, 4), 0.0001)
+	require.InDelta(t, 6.0, linearForecast([]float64{1, 2, 3, 4}, 5), 0.0001)
+	require.InDelta(t, 2.0, linearForecast([]float64{2, 2, 2}, 4), 0.0001)
+	require.Equal(t, 0.0, linearForecast([]float64{10, 5, 0}, 4))
+}
+
+func TestBuildUsageForecast(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	usage := []db.MonthlyComposeUsage{
+		{Month: start, Distribution: "rhel-9", ImageType: "aws", Composes: 1, BlobBytes: 100},
+		{Month: start.AddDate(0, 1, 0), Distribution: "rhel-9", ImageType: "aws", Composes: 2, BlobBytes: 200},
+		{Month: start.AddDate(0, 2, 0), Distribution: "rhel-9", ImageType: "aws", Composes: 3, BlobBytes: 300},
+		{Month: start.AddDate(0, 2, 0), Distribution: "centos-9", ImageType: "guest-image", Composes: 3},
+	}
+
+	forecast := buildUsageForecast(usage, start, 3)
+	require.Equal(t, "2024-05", forecast.Month)
+	require.Equal(t, 3, forecast.HistoryMonths)
+	require.Equal(t, []UsageForecastItem{
+		{Distribution: "centos-9", ImageType: "guest-image", Composes: 6, StorageBytes: 0, History: []int{0, 0, 3}},
+		{Distribution: "rhel-9", ImageType: "aws", Composes: 5, StorageBytes: 500, History: []int{1, 2, 3}},
+	}, forecast.Data)
+	require.Equal(t, 11, forecast.Composes)
+	require.Equal(t, int64(500), forecast.StorageBytes)
+
+	empty := buildUsageForecast(nil, start, 3)
+	require.Empty(t, empty.Data)
+	require.Equal(t, 0, empty.Composes)
+}
+
+func TestGetUsageForecast(t *testing.T) {
+	srv, tokenSrv := startServer(t, &testServerClientsConf{}, nil)
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	respStatusCode, body := tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/admin/forecast?history_months=3", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var forecast UsageForecast
+	require.NoError(t, json.Unmarshal([]byte(body), &forecast))
+	require.Equal(t, 3, forecast.HistoryMonths)
+	now := time.Now().UTC()
+	require.Equal(t, time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC).Format("2006-01"), forecast.Month)
+
+	respStatusCode, _ = tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/admin/forecast?history_months=1", &tutils.AuthString0)
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+}
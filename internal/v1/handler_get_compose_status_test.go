@@ -12,6 +12,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/oauth2"
 	"github.com/osbuild/image-builder/internal/tutils"
 )
 
@@ -40,7 +43,7 @@ func TestComposeStatus(t *testing.T) {
 	}
 	crRaw, err := json.Marshal(cr)
 	require.NoError(t, err)
-	err = dbase.InsertCompose(ctx, composeId, "000000", "user000000@test.test", "000000", cr.ImageName, crRaw, (*string)(cr.ClientId), nil)
+	err = dbase.InsertCompose(ctx, composeId, "000000", "user000000@test.test", "000000", cr.ImageName, crRaw, (*string)(cr.ClientId), nil, nil, nil)
 	require.NoError(t, err)
 	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
 		DBase:            dbase,
@@ -210,6 +213,35 @@ func TestComposeStatus(t *testing.T) {
 				},
 			},
 		},
+		{
+			composerStatus: composer.ComposeStatus{
+				ImageStatus: composer.ImageStatus{
+					Status: composer.ImageStatusValueUploading,
+					UploadStatus: &composer.UploadStatus{
+						Status:  composer.UploadStatusValue("running"),
+						Type:    composer.UploadTypesAws,
+						Options: awsUS,
+						Progress: &composer.UploadProgress{
+							BytesTransferred: 1024,
+							BytesTotal:       common.ToPtr(int64(4096)),
+						},
+					},
+				},
+				Status: composer.ComposeStatusValuePending,
+			},
+			imageStatus: ImageStatus{
+				Status: ImageStatusStatusUploading,
+				UploadStatus: &UploadStatus{
+					Status:  Running,
+					Type:    UploadTypesAws,
+					Options: ibAwsUS,
+					Progress: &UploadProgress{
+						BytesTransferred: 1024,
+						BytesTotal:       common.ToPtr(int64(4096)),
+					},
+				},
+			},
+		},
 	}
 
 	for idx, payload := range payloads {
@@ -225,3 +257,129 @@ func TestComposeStatus(t *testing.T) {
 		require.Equal(t, cr, result.Request)
 	}
 }
+
+func TestComposeStatusRegion(t *testing.T) {
+	ctx := context.Background()
+	statusFrom := func(status composer.ImageStatusValue) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			err := json.NewEncoder(w).Encode(composer.ComposeStatus{
+				ImageStatus: composer.ImageStatus{
+					Status: status,
+				},
+				Status: composer.ComposeStatusValuePending,
+			})
+			require.NoError(t, err)
+		}))
+	}
+	localSrv := statusFrom(composer.ImageStatusValueBuilding)
+	defer localSrv.Close()
+	remoteSrv := statusFrom(composer.ImageStatusValueUploading)
+	defer remoteSrv.Close()
+
+	remoteClient, err := composer.NewClient(composer.ComposerClientConfig{
+		URL:     remoteSrv.URL,
+		Tokener: &oauth2.DummyToken{},
+	})
+	require.NoError(t, err)
+
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	crRaw, err := json.Marshal(ComposeRequest{
+		Distribution: "rhel-9",
+	})
+	require.NoError(t, err)
+	legacyId := uuid.New()
+	err = dbase.InsertCompose(ctx, legacyId, "000000", "user000000@test.test", "000000", nil, crRaw, nil, nil, nil, nil)
+	require.NoError(t, err)
+	localId := uuid.New()
+	err = dbase.InsertCompose(ctx, localId, "000000", "user000000@test.test", "000000", nil, crRaw, nil, nil, common.ToPtr("us-east-1"), nil)
+	require.NoError(t, err)
+	remoteId := uuid.New()
+	err = dbase.InsertCompose(ctx, remoteId, "000000", "user000000@test.test", "000000", nil, crRaw, nil, nil, common.ToPtr("eu-west-1"), nil)
+	require.NoError(t, err)
+	unknownId := uuid.New()
+	err = dbase.InsertCompose(ctx, unknownId, "000000", "user000000@test.test", "000000", nil, crRaw, nil, nil, common.ToPtr("ap-south-1"), nil)
+	require.NoError(t, err)
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: localSrv.URL}, &ServerConfig{
+		DBase:  dbase,
+		Region: "us-east-1",
+		RegionalCompClients: map[string]*composer.ComposerClient{
+			"eu-west-1": remoteClient,
+		},
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	tests := []struct {
+		id     uuid.UUID
+		status ImageStatusStatus
+	}{
+		{legacyId, ImageStatusStatusBuilding},
+		{localId, ImageStatusStatusBuilding},
+		{remoteId, ImageStatusStatusUploading},
+	}
+	for _, tt := range tests {
+		respStatusCode, body := tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s", tt.id), &tutils.AuthString0)
+		require.Equal(t, http.StatusOK, respStatusCode)
+		var result ComposeStatus
+		err := json.Unmarshal([]byte(body), &result)
+		require.NoError(t, err)
+		require.Equal(t, tt.status, result.ImageStatus.Status)
+	}
+
+	respStatusCode, _ := tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s", unknownId), &tutils.AuthString0)
+	require.Equal(t, http.StatusInternalServerError, respStatusCode)
+}
+
+func TestComposeStatusPostProcessing(t *testing.T) {
+	ctx := context.Background()
+	composerURL := mockService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(composer.ComposeStatus{
+			ImageStatus: composer.ImageStatus{
+				Status: composer.ImageStatusValueSuccess,
+			},
+			Status: composer.ComposeStatusValueSuccess,
+		}))
+	})
+
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	composeId := uuid.New()
+	err = dbase.InsertCompose(ctx, composeId, "000000", "user000000@test.test", "000000", nil, json.RawMessage(`{"distribution": "rhel-9"}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+	startTestServer(t, &testServerClientsConf{ComposerURL: composerURL}, &ServerConfig{
+		DBase: dbase,
+	})
+
+	// no hook ran yet
+	respStatusCode, body := tutils.GetResponseBody(t, apiURL("/composes/%s", composeId), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode, body)
+	var result ComposeStatus
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Nil(t, result.PostProcessing)
+
+	err = dbase.SetComposeHookRun(ctx, db.ComposeHookRunEntry{ComposeId: composeId, Hook: "cmdb", Status: db.ComposeHookSucceeded, Attempts: 1})
+	require.NoError(t, err)
+	err = dbase.SetComposeHookRun(ctx, db.ComposeHookRunEntry{ComposeId: composeId, Hook: "ansible", Status: db.ComposeHookPending, Attempts: 1, Error: common.ToPtr("tower unreachable")})
+	require.NoError(t, err)
+
+	respStatusCode, body = tutils.GetResponseBody(t, apiURL("/composes/%s", composeId), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode, body)
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.NotNil(t, result.PostProcessing)
+	runs := *result.PostProcessing
+	require.Len(t, runs, 2)
+	require.Equal(t, "ansible", runs[0].Hook)
+	require.Equal(t, ComposeHookRunStatusPending, runs[0].Status)
+	require.Equal(t, "tower unreachable", *runs[0].Error)
+	require.Equal(t, "cmdb", runs[1].Hook)
+	require.Equal(t, ComposeHookRunStatusSucceeded, runs[1].Status)
+	require.Equal(t, 1, runs[1].Attempts)
+}
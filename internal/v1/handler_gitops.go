@@ -0,0 +1,417 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/gitops"
+)
+
+const gitOpsDefaultBranch = "main"
+
+// gitOpsBlueprint is a blueprint as found in the git repository.
+type gitOpsBlueprint struct {
+	file    string
+	request CreateBlueprintRequest
+	body    json.RawMessage
+}
+
+func gitOpsRepositoryFromEntry(e *db.GitOpsRepositoryEntry) GitOpsRepository {
+	repo := GitOpsRepository{
+		Id:         e.Id,
+		Url:        e.Url,
+		Branch:     e.Branch,
+		Path:       e.Path,
+		AutoBuild:  e.AutoBuild,
+		CreatedAt:  e.CreatedAt.Format(time.RFC3339),
+		LastCommit: e.LastCommit,
+	}
+	if e.LastSyncedAt != nil {
+		repo.LastSyncedAt = common.ToPtr(e.LastSyncedAt.Format(time.RFC3339))
+	}
+	return repo
+}
+
+func (h *Handlers) GetGitOpsRepositories(ctx echo.Context) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.server.db.GetGitOpsRepositories(ctx.Request().Context(), userID.OrgID())
+	if err != nil {
+		return err
+	}
+
+	data := make([]GitOpsRepository, 0, len(entries))
+	for i := range entries {
+		data = append(data, gitOpsRepositoryFromEntry(&entries[i]))
+	}
+	return ctx.JSON(http.StatusOK, GitOpsRepositoriesResponse{
+		Data: data,
+	})
+}
+
+func (h *Handlers) CreateGitOpsRepository(ctx echo.Context) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	var request CreateGitOpsRepositoryJSONRequestBody
+	err = ctx.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	err = gitops.ValidateURL(request.Url)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	path := common.FromPtr(request.Path)
+	err = gitops.ValidateDir(path)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	branch := gitOpsDefaultBranch
+	if request.Branch != nil && *request.Branch != "" {
+		branch = *request.Branch
+	}
+
+	id := uuid.New()
+	err = h.server.db.InsertGitOpsRepository(ctx.Request().Context(), id, userID.OrgID(), request.Url, branch, path, common.FromPtr(request.AutoBuild))
+	if err != nil {
+		return err
+	}
+	ctx.Logger().Infof("Registered gitops repository %s (%s) for org %s", id, request.Url, userID.OrgID())
+
+	entry, err := h.server.db.GetGitOpsRepository(ctx.Request().Context(), id, userID.OrgID())
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusCreated, gitOpsRepositoryFromEntry(entry))
+}
+
+func (h *Handlers) getGitOpsRepository(ctx echo.Context, id uuid.UUID) (*db.GitOpsRepositoryEntry, error) {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := h.server.db.GetGitOpsRepository(ctx.Request().Context(), id, userID.OrgID())
+	if errors.Is(err, db.GitOpsRepositoryNotFoundError) {
+		return nil, echo.NewHTTPError(http.StatusNotFound, err)
+	}
+	return entry, err
+}
+
+func (h *Handlers) GetGitOpsRepository(ctx echo.Context, id uuid.UUID) error {
+	entry, err := h.getGitOpsRepository(ctx, id)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, gitOpsRepositoryFromEntry(entry))
+}
+
+func (h *Handlers) DeleteGitOpsRepository(ctx echo.Context, id uuid.UUID) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = h.server.db.DeleteGitOpsRepository(ctx.Request().Context(), id, userID.OrgID())
+	if err != nil {
+		if errors.Is(err, db.GitOpsRepositoryNotFoundError) {
+			return echo.NewHTTPError(http.StatusNotFound, err)
+		}
+		return err
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func gitOpsError(ctx echo.Context, title, detail string) error {
+	return ctx.JSON(http.StatusUnprocessableEntity, HTTPErrorList{
+		Errors: []HTTPError{{
+			Title:  title,
+			Detail: detail,
+		}},
+	})
+}
+
+// fetchGitOpsBlueprints returns the blueprints of the repository sorted by
+// file name, or an error message for the user if the repository can't be
+// used as it is.
+func (h *Handlers) fetchGitOpsBlueprints(ctx echo.Context, repo *db.GitOpsRepositoryEntry) (string, []gitOpsBlueprint, *HTTPError) {
+	snapshot, err := h.server.gitFetcher.Fetch(ctx.Request().Context(), repo.Url, repo.Branch, repo.Path)
+	if err != nil {
+		ctx.Logger().Warnf("Unable to fetch gitops repository %s: %v", repo.Id, err)
+		return "", nil, &HTTPError{
+			Title:  "Unable to fetch repository",
+			Detail: fmt.Sprintf("Branch %s of %s could not be fetched: %v", repo.Branch, repo.Url, err),
+		}
+	}
+
+	files := make([]string, 0, len(snapshot.Files))
+	for f := range snapshot.Files {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+
+	schema := h.server.spec.Components.Schemas["CreateBlueprintRequest"].Value
+	names := map[string]string{}
+	blueprints := make([]gitOpsBlueprint, 0, len(files))
+	for _, f := range files {
+		invalid := func(err error) *HTTPError {
+			return &HTTPError{
+				Title:  "Invalid blueprint",
+				Detail: fmt.Sprintf("%s: %v", f, err),
+			}
+		}
+
+		raw, err := gitops.ParseBlueprint(snapshot.Files[f])
+		if err != nil {
+			return "", nil, invalid(err)
+		}
+		var doc interface{}
+		err = json.Unmarshal(raw, &doc)
+		if err != nil {
+			return "", nil, invalid(err)
+		}
+		err = schema.VisitJSON(doc)
+		if err != nil {
+			// the full error includes the whole schema
+			var schemaErr *openapi3.SchemaError
+			if errors.As(err, &schemaErr) {
+				err = fmt.Errorf("%s: %s", strings.Join(schemaErr.JSONPointer(), "."), schemaErr.Reason)
+			}
+			return "", nil, invalid(err)
+		}
+
+		var request CreateBlueprintRequest
+		err = json.Unmarshal(raw, &request)
+		if err != nil {
+			return "", nil, invalid(err)
+		}
+		if !blueprintNameRegex.MatchString(request.Name) {
+			return "", nil, invalid(errors.New(blueprintInvalidNameDetail))
+		}
+		if other, ok := names[request.Name]; ok {
+			return "", nil, invalid(fmt.Errorf("blueprint name %q is already used by %s", request.Name, other))
+		}
+		names[request.Name] = f
+
+		body, err := json.Marshal(BlueprintFromAPI(request))
+		if err != nil {
+			return "", nil, invalid(err)
+		}
+		blueprints = append(blueprints, gitOpsBlueprint{
+			file:    f,
+			request: request,
+			body:    body,
+		})
+	}
+	return snapshot.Commit, blueprints, nil
+}
+
+// gitOpsBlueprintState compares a blueprint from git with the stored one,
+// which is nil if there is none yet.
+func gitOpsBlueprintState(bp gitOpsBlueprint, stored *db.BlueprintEntry) (GitOpsBlueprintStateState, error) {
+	if stored == nil {
+		return Missing, nil
+	}
+	if stored.Description != common.FromPtr(bp.request.Description) {
+		return Modified, nil
+	}
+	// normalize the stored body, it may have been written by an older version
+	body, err := BlueprintFromEntry(stored)
+	if err != nil {
+		return "", err
+	}
+	storedBody, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	if !bytes.Equal(storedBody, bp.body) {
+		return Modified, nil
+	}
+	return InSync, nil
+}
+
+// findStoredBlueprint returns the blueprint of the org with the name, nil if
+// there is none. It conflicts when the name is taken by a blueprint the user
+// can't see, which is left alone. A sync also leaves the blueprints the user
+// can't change alone.
+func (h *Handlers) findStoredBlueprint(ctx echo.Context, userID *Identity, name string) (*db.BlueprintEntry, bool, error) {
+	found, err := h.server.db.FindBlueprintByName(ctx.Request().Context(), userID.OrgID(), name)
+	if err != nil || found == nil {
+		return nil, false, err
+	}
+	stored, err := h.getBlueprint(ctx, userID, found.Id, nil)
+	if errors.Is(err, db.BlueprintNotFoundError) {
+		return nil, true, nil
+	}
+	return stored, false, err
+}
+
+func (h *Handlers) GetGitOpsDrift(ctx echo.Context, id uuid.UUID) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	repo, err := h.getGitOpsRepository(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	commit, blueprints, httpErr := h.fetchGitOpsBlueprints(ctx, repo)
+	if httpErr != nil {
+		return gitOpsError(ctx, httpErr.Title, httpErr.Detail)
+	}
+
+	data := make([]GitOpsBlueprintState, 0, len(blueprints))
+	for _, bp := range blueprints {
+		stored, conflict, err := h.findStoredBlueprint(ctx, userID, bp.request.Name)
+		if err != nil {
+			return err
+		}
+		if conflict {
+			data = append(data, GitOpsBlueprintState{
+				File:  bp.file,
+				Name:  bp.request.Name,
+				State: Conflict,
+			})
+			continue
+		}
+		state, err := gitOpsBlueprintState(bp, stored)
+		if err != nil {
+			return err
+		}
+		item := GitOpsBlueprintState{
+			File:  bp.file,
+			Name:  bp.request.Name,
+			State: state,
+		}
+		if stored != nil {
+			item.BlueprintId = &stored.Id
+		}
+		data = append(data, item)
+	}
+
+	return ctx.JSON(http.StatusOK, GitOpsDriftResponse{
+		Commit: commit,
+		Data:   data,
+	})
+}
+
+func (h *Handlers) SyncGitOpsRepository(ctx echo.Context, id uuid.UUID) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	repo, err := h.getGitOpsRepository(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	commit, blueprints, httpErr := h.fetchGitOpsBlueprints(ctx, repo)
+	if httpErr != nil {
+		return gitOpsError(ctx, httpErr.Title, httpErr.Detail)
+	}
+
+	data := make([]GitOpsBlueprintState, 0, len(blueprints))
+	for _, bp := range blueprints {
+		stored, conflict, err := h.findStoredBlueprint(ctx, userID, bp.request.Name)
+		if err != nil {
+			return err
+		}
+		if conflict {
+			data = append(data, GitOpsBlueprintState{
+				File:  bp.file,
+				Name:  bp.request.Name,
+				State: Conflict,
+			})
+			continue
+		}
+		state, err := gitOpsBlueprintState(bp, stored)
+		if err != nil {
+			return err
+		}
+
+		// only the builders of the workspace of the blueprint change it
+		if state == Modified {
+			_, err = h.getBlueprintToChange(ctx, userID, stored.Id, nil)
+			var httpErr *echo.HTTPError
+			if errors.As(err, &httpErr) && httpErr.Code == http.StatusForbidden {
+				data = append(data, GitOpsBlueprintState{
+					File:  bp.file,
+					Name:  bp.request.Name,
+					State: Conflict,
+				})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		desc := common.FromPtr(bp.request.Description)
+		var blueprintId uuid.UUID
+		switch state {
+		case Missing:
+			blueprintId = uuid.New()
+			err = h.server.db.InsertBlueprint(ctx.Request().Context(), blueprintId, uuid.New(), userID.OrgID(), userID.AccountNumber(), bp.request.Name, desc, bp.body, nil, db.BlueprintOptions{CreatedBy: userID.Email()})
+			state = Created
+		case Modified:
+			blueprintId = stored.Id
+			err = h.server.db.UpdateBlueprint(ctx.Request().Context(), uuid.New(), blueprintId, userID.OrgID(), bp.request.Name, desc, bp.body, userID.Email())
+			state = Updated
+		default:
+			blueprintId = stored.Id
+		}
+		if err != nil {
+			return err
+		}
+
+		item := GitOpsBlueprintState{
+			BlueprintId: &blueprintId,
+			File:        bp.file,
+			Name:        bp.request.Name,
+			State:       state,
+		}
+		if repo.AutoBuild && state != InSync {
+			entry, err := h.getBlueprintToChange(ctx, userID, blueprintId, nil)
+			if err != nil {
+				return err
+			}
+			composes, err := h.composeBlueprint(ctx, entry, nil)
+			if err != nil {
+				return err
+			}
+			item.Composes = &composes
+		}
+		data = append(data, item)
+	}
+
+	err = h.server.db.SetGitOpsRepositorySynced(ctx.Request().Context(), repo.Id, userID.OrgID(), commit)
+	if err != nil {
+		return err
+	}
+	ctx.Logger().Infof("Synced gitops repository %s at %s", repo.Id, commit)
+
+	return ctx.JSON(http.StatusOK, GitOpsSyncResponse{
+		Commit: commit,
+		Data:   data,
+	})
+}
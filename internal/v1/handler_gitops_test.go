@@ -0,0 +1,248 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/gitops"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+type fakeGitFetcher struct {
+	snapshot gitops.Snapshot
+}
+
+func (f *fakeGitFetcher) Fetch(ctx context.Context, repoURL, branch, dir string) (*gitops.Snapshot, error) {
+	return &f.snapshot, nil
+}
+
+const gitOpsBlueprintYAML = `name: from-git
+description: %s
+distribution: centos-9
+customizations:
+  packages:
+    - nginx
+image_requests:
+  - architecture: x86_64
+    image_type: guest-image
+    upload_request:
+      type: aws.s3
+      options: {}
+`
+
+func TestGitOpsSync(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+
+	fetcher := &fakeGitFetcher{
+		snapshot: gitops.Snapshot{
+			Commit: "c1",
+			Files: map[string][]byte{
+				"blueprints/bp.yaml": []byte(fmt.Sprintf(gitOpsBlueprintYAML, "first")),
+			},
+		},
+	}
+	srv, tokenSrv := startServer(t, &testServerClientsConf{}, &ServerConfig{
+		DBase:      dbase,
+		GitFetcher: fetcher,
+	})
+	defer func() {
+		err := srv.Shutdown(ctx)
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	statusCode, _ := tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/gitops/repositories", map[string]interface{}{
+		"url": "file:///etc",
+	})
+	require.Equal(t, http.StatusBadRequest, statusCode)
+
+	statusCode, body := tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/gitops/repositories", map[string]interface{}{
+		"url":  "https://example.com/blueprints.git",
+		"path": "blueprints",
+	})
+	require.Equal(t, http.StatusCreated, statusCode)
+	var repo GitOpsRepository
+	require.NoError(t, json.Unmarshal([]byte(body), &repo))
+	require.Equal(t, "main", repo.Branch)
+	require.False(t, repo.AutoBuild)
+	require.Nil(t, repo.LastCommit)
+	repoURL := fmt.Sprintf("http://localhost:8086/api/image-builder/v1/gitops/repositories/%s", repo.Id)
+
+	statusCode, body = tutils.GetResponseBody(t, repoURL+"/drift", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, statusCode)
+	var drift GitOpsDriftResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &drift))
+	require.Equal(t, "c1", drift.Commit)
+	require.Len(t, drift.Data, 1)
+	require.Equal(t, Missing, drift.Data[0].State)
+	require.Nil(t, drift.Data[0].BlueprintId)
+
+	statusCode, body = tutils.PostResponseBody(t, repoURL+"/sync", nil)
+	require.Equal(t, http.StatusOK, statusCode)
+	var sync GitOpsSyncResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &sync))
+	require.Len(t, sync.Data, 1)
+	require.Equal(t, Created, sync.Data[0].State)
+	require.Equal(t, "from-git", sync.Data[0].Name)
+	blueprintId := *sync.Data[0].BlueprintId
+
+	statusCode, body = tutils.GetResponseBody(t, repoURL+"/drift", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, statusCode)
+	require.NoError(t, json.Unmarshal([]byte(body), &drift))
+	require.Equal(t, InSync, drift.Data[0].State)
+	require.Equal(t, blueprintId, *drift.Data[0].BlueprintId)
+
+	fetcher.snapshot.Commit = "c2"
+	fetcher.snapshot.Files["blueprints/bp.yaml"] = []byte(fmt.Sprintf(gitOpsBlueprintYAML, "second"))
+	statusCode, body = tutils.GetResponseBody(t, repoURL+"/drift", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, statusCode)
+	require.NoError(t, json.Unmarshal([]byte(body), &drift))
+	require.Equal(t, Modified, drift.Data[0].State)
+
+	statusCode, body = tutils.PostResponseBody(t, repoURL+"/sync", nil)
+	require.Equal(t, http.StatusOK, statusCode)
+	require.NoError(t, json.Unmarshal([]byte(body), &sync))
+	require.Equal(t, Updated, sync.Data[0].State)
+	require.Equal(t, blueprintId, *sync.Data[0].BlueprintId)
+
+	be, err := dbase.GetBlueprint(ctx, blueprintId, "000000", nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, be.Version)
+	require.Equal(t, "second", be.Description)
+
+	statusCode, body = tutils.GetResponseBody(t, repoURL, &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, statusCode)
+	require.NoError(t, json.Unmarshal([]byte(body), &repo))
+	require.Equal(t, "c2", *repo.LastCommit)
+
+	// invalid blueprints fail the whole sync
+	fetcher.snapshot.Files["blueprints/broken.yaml"] = []byte("name: broken\n")
+	statusCode, body = tutils.PostResponseBody(t, repoURL+"/sync", nil)
+	require.Equal(t, http.StatusUnprocessableEntity, statusCode)
+	require.Contains(t, body, "blueprints/broken.yaml")
+
+	statusCode, _ = tutils.DeleteResponseBody(t, repoURL)
+	require.Equal(t, http.StatusNoContent, statusCode)
+	statusCode, _ = tutils.GetResponseBody(t, repoURL, &tutils.AuthString0)
+	require.Equal(t, http.StatusNotFound, statusCode)
+}
+
+func TestGitOpsSyncPrivateConflict(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+
+	// another user's private blueprint takes the name of the one in git
+	privateId := uuid.New()
+	err = dbase.InsertBlueprint(ctx, privateId, uuid.New(), "000000", "500000", "from-git", "private", json.RawMessage(`{}`), nil, db.BlueprintOptions{CreatedBy: "other@user.user", Private: true})
+	require.NoError(t, err)
+
+	fetcher := &fakeGitFetcher{
+		snapshot: gitops.Snapshot{
+			Commit: "c1",
+			Files: map[string][]byte{
+				"bp.yaml": []byte(fmt.Sprintf(gitOpsBlueprintYAML, "first")),
+			},
+		},
+	}
+	srv, tokenSrv := startServer(t, &testServerClientsConf{}, &ServerConfig{
+		DBase:      dbase,
+		GitFetcher: fetcher,
+	})
+	defer func() {
+		err := srv.Shutdown(ctx)
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	statusCode, body := tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/gitops/repositories", map[string]interface{}{
+		"url": "https://example.com/blueprints.git",
+	})
+	require.Equal(t, http.StatusCreated, statusCode)
+	var repo GitOpsRepository
+	require.NoError(t, json.Unmarshal([]byte(body), &repo))
+	repoURL := fmt.Sprintf("http://localhost:8086/api/image-builder/v1/gitops/repositories/%s", repo.Id)
+
+	statusCode, body = tutils.GetResponseBody(t, repoURL+"/drift", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, statusCode)
+	var drift GitOpsDriftResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &drift))
+	require.Equal(t, Conflict, drift.Data[0].State)
+	require.Nil(t, drift.Data[0].BlueprintId)
+
+	statusCode, body = tutils.PostResponseBody(t, repoURL+"/sync", nil)
+	require.Equal(t, http.StatusOK, statusCode)
+	var sync GitOpsSyncResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &sync))
+	require.Equal(t, Conflict, sync.Data[0].State)
+	require.Nil(t, sync.Data[0].BlueprintId)
+
+	be, err := dbase.GetBlueprint(ctx, privateId, "000000", nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, be.Version)
+	require.Equal(t, "private", be.Description)
+}
+
+func TestGitOpsSyncWorkspaceViewer(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+
+	workspace := db.WorkspaceEntry{
+		Id:        uuid.New(),
+		OrgId:     "000000",
+		Name:      "finance",
+		Members:   []db.WorkspaceMember{{Email: "viewer@user.user", Role: db.WorkspaceViewer}},
+		CreatedBy: "admin@user.user",
+	}
+	require.NoError(t, dbase.InsertWorkspace(ctx, &workspace))
+	blueprintId := uuid.New()
+	err = dbase.InsertBlueprint(ctx, blueprintId, uuid.New(), "000000", "500000", "from-git", "workspace", json.RawMessage(`{}`), nil, db.BlueprintOptions{CreatedBy: "admin@user.user", WorkspaceId: &workspace.Id})
+	require.NoError(t, err)
+
+	startTestServer(t, &testServerClientsConf{}, &ServerConfig{
+		DBase: dbase,
+		GitFetcher: &fakeGitFetcher{
+			snapshot: gitops.Snapshot{
+				Commit: "c1",
+				Files: map[string][]byte{
+					"bp.yaml": []byte(fmt.Sprintf(gitOpsBlueprintYAML, "first")),
+				},
+			},
+		},
+	})
+
+	// neither viewers nor non-members of the workspace rewrite its blueprints
+	viewer := tutils.NewIdentity("000000").Email("viewer@user.user").OrgAdmin(false).Base64()
+	outsider := tutils.NewIdentity("000000").Email("outsider@user.user").OrgAdmin(false).Base64()
+	for _, auth := range []string{viewer, outsider} {
+		statusCode, body := tutils.ResponseBody(t, http.MethodPost, apiURL("/gitops/repositories"), auth, map[string]interface{}{
+			"url":        "https://example.com/blueprints.git",
+			"auto_build": true,
+		})
+		require.Equal(t, http.StatusCreated, statusCode, body)
+		var repo GitOpsRepository
+		require.NoError(t, json.Unmarshal([]byte(body), &repo))
+
+		statusCode, body = tutils.ResponseBody(t, http.MethodPost, apiURL("/gitops/repositories/%s/sync", repo.Id), auth, nil)
+		require.Equal(t, http.StatusOK, statusCode, body)
+		var sync GitOpsSyncResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &sync))
+		require.Equal(t, Conflict, sync.Data[0].State)
+		require.Nil(t, sync.Data[0].Composes)
+	}
+
+	be, err := dbase.GetBlueprint(ctx, blueprintId, "000000", nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, be.Version)
+	require.Equal(t, "workspace", be.Description)
+}
@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+)
+
+// composes older than this aren't listed anymore, they don't hold up anything
+const pollerLagLookback = 14 * 24 * time.Hour
+
+// GetHealthSummary checks every dependency, a failing one is reported in the
+// summary instead of failing the request.
+func (h *Handlers) GetHealthSummary(ctx echo.Context) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !userID.IsOrgAdmin() {
+		return echo.NewHTTPError(http.StatusForbidden, "The health summary can only be viewed by organization administrators")
+	}
+
+	summary := HealthSummary{
+		CheckedAt: time.Now().UTC().Format(time.RFC3339),
+		Composer:  []DependencyHealth{composerHealth(h.server.cClient)},
+	}
+	regions := make([]string, 0, len(h.server.regionalCClients))
+	for region := range h.server.regionalCClients {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	for _, region := range regions {
+		health := composerHealth(h.server.regionalCClients[region])
+		health.Region = common.ToPtr(region)
+		summary.Composer = append(summary.Composer, health)
+	}
+	if expiry, ok := h.server.cClient.TokenExpiry(); ok && !expiry.IsZero() {
+		summary.ComposerTokenExpiresAt = common.ToPtr(expiry.UTC().Format(time.RFC3339))
+	}
+
+	start := time.Now()
+	err = h.server.db.Ping(ctx.Request().Context())
+	summary.Database = DependencyHealth{
+		Reachable: err == nil,
+		LatencyMs: int(time.Since(start).Milliseconds()),
+	}
+	if err != nil {
+		summary.Database.Error = common.ToPtr(err.Error())
+		return ctx.JSON(http.StatusOK, summary)
+	}
+
+	unfinished, err := h.server.db.GetUnfinishedComposes(ctx.Request().Context(), h.server.regionPtr(), 0, pollerLagLookback, nil, 1)
+	if err != nil {
+		return err
+	}
+	if len(unfinished) > 0 {
+		summary.PollerLagSeconds = common.ToPtr(int(time.Since(unfinished[0].CreatedAt).Seconds()))
+	}
+	eventLag, err := h.server.db.ComposeEventLag(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+	if eventLag != nil {
+		summary.EventLagSeconds = common.ToPtr(int(eventLag.Seconds()))
+	}
+	return ctx.JSON(http.StatusOK, summary)
+}
+
+// composerHealth requests the API description of composer, like the
+// readiness probe does.
+func composerHealth(client *composer.ComposerClient) DependencyHealth {
+	start := time.Now()
+	resp, err := client.OpenAPI()
+	health := DependencyHealth{
+		LatencyMs: int(time.Since(start).Milliseconds()),
+	}
+	if err != nil {
+		health.Error = common.ToPtr(err.Error())
+		return health
+	}
+	defer resp.Body.Close()
+	health.StatusCode = common.ToPtr(resp.StatusCode)
+	health.Reachable = resp.StatusCode == http.StatusOK
+	if !health.Reachable {
+		health.Error = common.ToPtr(http.StatusText(resp.StatusCode))
+	}
+	return health
+}
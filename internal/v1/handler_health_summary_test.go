@@ -0,0 +1,71 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/oauth2"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestGetHealthSummary(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.True(t, strings.HasSuffix(r.URL.Path, "/openapi"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer apiSrv.Close()
+	regionalSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer regionalSrv.Close()
+	regionalClient, err := composer.NewClient(composer.ComposerClientConfig{
+		URL:     regionalSrv.URL,
+		Tokener: &oauth2.DummyToken{},
+	})
+	require.NoError(t, err)
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
+		DBase:               dbase,
+		RegionalCompClients: map[string]*composer.ComposerClient{"eu": regionalClient},
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	err = dbase.InsertCompose(ctx, uuid.New(), "500000", "user000000@test.test", "000000", nil, json.RawMessage(`{}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	statusCode, body := tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/admin/health/summary", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, statusCode, body)
+	var summary HealthSummary
+	require.NoError(t, json.Unmarshal([]byte(body), &summary))
+
+	require.Len(t, summary.Composer, 2)
+	require.Nil(t, summary.Composer[0].Region)
+	require.True(t, summary.Composer[0].Reachable)
+	require.Equal(t, http.StatusOK, *summary.Composer[0].StatusCode)
+	require.Equal(t, "eu", *summary.Composer[1].Region)
+	require.False(t, summary.Composer[1].Reachable)
+	require.Equal(t, http.StatusServiceUnavailable, *summary.Composer[1].StatusCode)
+	require.NotNil(t, summary.Composer[1].Error)
+
+	require.True(t, summary.Database.Reachable)
+	require.NotNil(t, summary.PollerLagSeconds)
+	require.NotNil(t, summary.EventLagSeconds)
+	// the dummy tokener doesn't know when its token expires
+	require.Nil(t, summary.ComposerTokenExpiresAt)
+}
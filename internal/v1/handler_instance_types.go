@@ -0,0 +1,136 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/labstack/echo/v4"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+
+	"github.com/osbuild/image-builder/internal/clients/provisioning"
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+// GetBlueprintInstanceTypes filters the instance types provisioning offers by
+// the launch requirements and the images of the latest blueprint version.
+func (h *Handlers) GetBlueprintInstanceTypes(ctx echo.Context, id openapi_types.UUID, params GetBlueprintInstanceTypesParams) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	blueprintEntry, err := h.getBlueprint(ctx, userID, id, nil)
+	if err != nil {
+		if errors.Is(err, db.BlueprintNotFoundError) {
+			return echo.NewHTTPError(http.StatusNotFound, err)
+		}
+		return err
+	}
+	blueprint, err := BlueprintFromEntry(blueprintEntry)
+	if err != nil {
+		return err
+	}
+	images, err := blueprintLaunchImages(blueprint, params.Provider)
+	if err != nil {
+		return err
+	}
+	if len(images) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("The blueprint has no images for %s", params.Provider))
+	}
+
+	offered, err := h.offeredInstanceTypes(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	response := BlueprintInstanceTypesResponse{
+		Data:               []InstanceType{},
+		LaunchRequirements: blueprint.LaunchRequirements,
+	}
+	if params.InstanceType != nil {
+		i := slices.IndexFunc(offered, func(it offeredInstanceType) bool {
+			return it.Name == *params.InstanceType
+		})
+		if i < 0 {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Instance type %s is not offered in %s", *params.InstanceType, params.Region))
+		}
+		unmet := unmetLaunchRequirements(blueprint.LaunchRequirements, images, offered[i])
+		if len(unmet) > 0 {
+			errs := HTTPErrorList{}
+			for _, reason := range unmet {
+				errs.Errors = append(errs.Errors, HTTPError{
+					Title:  "Launch requirement not met",
+					Detail: reason,
+				})
+			}
+			return ctx.JSON(http.StatusUnprocessableEntity, errs)
+		}
+		response.Data = append(response.Data, offered[i].InstanceType)
+		return ctx.JSON(http.StatusOK, response)
+	}
+
+	for _, it := range offered {
+		if len(unmetLaunchRequirements(blueprint.LaunchRequirements, images, it)) == 0 {
+			response.Data = append(response.Data, it.InstanceType)
+		}
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// offeredInstanceTypes lists the instance types of the provider in the region.
+func (h *Handlers) offeredInstanceTypes(ctx echo.Context, params GetBlueprintInstanceTypesParams) ([]offeredInstanceType, error) {
+	resp, err := h.server.pClient.GetInstanceTypes(ctx.Request().Context(), string(params.Provider), provisioning.GetInstanceTypeListAllParams{
+		Region: params.Region,
+		Zone:   params.Zone,
+	})
+	if err != nil {
+		ctx.Logger().Error(err)
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unable to list the instance types of %s in %s", params.Provider, params.Region))
+	}
+	defer closeBody(ctx, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unable to list the instance types of %s in %s", params.Provider, params.Region))
+	}
+
+	var instanceTypes provisioning.V1ListInstaceTypeResponse
+	err = json.NewDecoder(resp.Body).Decode(&instanceTypes)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Unable to list the instance types of %s in %s", params.Provider, params.Region))
+	}
+
+	var offered []offeredInstanceType
+	for _, it := range common.FromPtr(instanceTypes.Data) {
+		name := common.FromPtr(it.Name)
+		storageGb := int(common.FromPtr(it.StorageGb))
+		architecture := common.FromPtr(it.Architecture)
+		// provisioning names the architectures like the providers do
+		if architecture == "arm64" {
+			architecture = string(ImageRequestArchitectureAarch64)
+		}
+		o := offeredInstanceType{
+			InstanceType: InstanceType{
+				Name:         name,
+				Architecture: architecture,
+				Vcpus:        int(common.FromPtr(it.Vcpus)),
+				MemoryMib:    int(common.FromPtr(it.MemoryMib)),
+				StorageGb:    storageGb,
+				Features:     instanceTypeFeatures(params.Provider, name, storageGb),
+			},
+			supported: common.FromPtr(it.Supported),
+		}
+		if it.Azure != nil {
+			if common.FromPtr(it.Azure.GenV1) {
+				o.generations = append(o.generations, V1)
+			}
+			if common.FromPtr(it.Azure.GenV2) {
+				o.generations = append(o.generations, V2)
+			}
+		}
+		offered = append(offered, o)
+	}
+	return offered, nil
+}
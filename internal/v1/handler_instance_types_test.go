@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestGetBlueprintInstanceTypes(t *testing.T) {
+	provURL := mockService(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/instance_types/aws", r.URL.Path)
+		require.Equal(t, "us-east-1", r.URL.Query().Get("region"))
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(`{"data": [
+			{"name": "t3.micro", "architecture": "x86_64", "vcpus": 2, "memory_mib": 1024, "storage_gb": 0, "supported": false},
+			{"name": "t3.large", "architecture": "x86_64", "vcpus": 2, "memory_mib": 8192, "storage_gb": 0, "supported": true},
+			{"name": "c5d.2xlarge", "architecture": "x86_64", "vcpus": 8, "memory_mib": 16384, "storage_gb": 200, "supported": true},
+			{"name": "c5.metal", "architecture": "x86_64", "vcpus": 96, "memory_mib": 196608, "storage_gb": 0, "supported": true},
+			{"name": "c6g.2xlarge", "architecture": "arm64", "vcpus": 8, "memory_mib": 16384, "storage_gb": 0, "supported": true}
+		]}`))
+		require.NoError(t, err)
+	})
+	startTestServer(t, &testServerClientsConf{ProvURL: provURL}, nil)
+
+	body := map[string]interface{}{
+		"name":           "launchable",
+		"customizations": map[string]interface{}{},
+		"distribution":   "centos-9",
+		"image_requests": []map[string]interface{}{
+			{
+				"architecture":   "x86_64",
+				"image_type":     "aws",
+				"upload_request": map[string]interface{}{"type": "aws", "options": map[string]interface{}{"share_with_accounts": []string{"test-account"}}},
+			},
+		},
+		"launch_requirements": map[string]interface{}{
+			"min_vcpus":      4,
+			"min_memory_mib": 8192,
+		},
+	}
+	respStatusCode, resp := tutils.PostResponseBody(t, apiURL("/blueprints"), body)
+	require.Equal(t, http.StatusCreated, respStatusCode)
+	var result CreateBlueprintResponse
+	require.NoError(t, json.Unmarshal([]byte(resp), &result))
+
+	respStatusCode, resp = tutils.GetResponseBody(t, apiURL("/blueprints/%s", result.Id), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var blueprint BlueprintResponse
+	require.NoError(t, json.Unmarshal([]byte(resp), &blueprint))
+	require.Equal(t, 4, *blueprint.LaunchRequirements.MinVcpus)
+
+	instanceTypes := func(query string) (int, string) {
+		return tutils.GetResponseBody(t, apiURL("/blueprints/%s/instance_types?%s", result.Id, query), &tutils.AuthString0)
+	}
+	respStatusCode, resp = instanceTypes("provider=aws&region=us-east-1")
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var response BlueprintInstanceTypesResponse
+	require.NoError(t, json.Unmarshal([]byte(resp), &response))
+	require.Len(t, response.Data, 2)
+	require.Equal(t, "c5d.2xlarge", response.Data[0].Name)
+	require.Equal(t, []LaunchFeature{LocalStorage}, response.Data[0].Features)
+	require.Equal(t, "c5.metal", response.Data[1].Name)
+	require.Equal(t, []LaunchFeature{NestedVirtualization}, response.Data[1].Features)
+
+	respStatusCode, resp = instanceTypes("provider=aws&region=us-east-1&instance_type=t3.large")
+	require.Equal(t, http.StatusUnprocessableEntity, respStatusCode)
+	var errs HTTPErrorList
+	require.NoError(t, json.Unmarshal([]byte(resp), &errs))
+	require.Len(t, errs.Errors, 1)
+	require.Equal(t, "The blueprint needs at least 4 vCPUs, t3.large has 2", errs.Errors[0].Detail)
+	respStatusCode, _ = instanceTypes("provider=aws&region=us-east-1&instance_type=c5.metal")
+	require.Equal(t, http.StatusOK, respStatusCode)
+	respStatusCode, _ = instanceTypes("provider=aws&region=us-east-1&instance_type=x1.huge")
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+
+	// the blueprint has no gcp image
+	respStatusCode, _ = instanceTypes("provider=gcp&region=us-east1")
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+}
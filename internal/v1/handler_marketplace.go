@@ -0,0 +1,84 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+// CreateMarketplaceSubmission prepares the AMI of a compose for AWS
+// Marketplace, nothing is submitted, the admin does that with the manifest.
+func (h *Handlers) CreateMarketplaceSubmission(ctx echo.Context, composeId openapi_types.UUID) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !userID.IsOrgAdmin() {
+		return echo.NewHTTPError(http.StatusForbidden, "Images can only be prepared for AWS Marketplace by organization administrators")
+	}
+	composeEntry, err := h.getComposeByIdAndOrgId(ctx, composeId)
+	if err != nil {
+		return err
+	}
+
+	var request CreateMarketplaceSubmissionJSONRequestBody
+	err = ctx.Bind(&request)
+	if err != nil {
+		return err
+	}
+	var composeRequest ComposeRequest
+	err = h.server.openComposeRequest(composeEntry.Request, &composeRequest)
+	if err != nil {
+		return err
+	}
+
+	ami, problem, err := h.composeAMI(ctx, composeEntry)
+	if err != nil {
+		return err
+	}
+	amiCheck := MarketplaceCheck{
+		Name:   MarketplaceCheckNameAmi,
+		Passed: problem == "",
+	}
+	if problem != "" {
+		amiCheck.Details = &[]string{problem}
+	}
+	submission := MarketplaceSubmission{
+		ComposeId: composeId,
+		Checks:    append([]MarketplaceCheck{amiCheck}, marketplaceChecks(&composeRequest)...),
+		Ready:     true,
+	}
+	for _, c := range submission.Checks {
+		submission.Ready = submission.Ready && c.Passed
+	}
+	if submission.Ready {
+		manifest := marketplaceManifest(ami, composeRequest.Distribution, &request)
+		submission.Manifest = &manifest
+	}
+	ctx.Logger().Infof("Prepared compose %s for AWS Marketplace, ready: %t", composeId, submission.Ready)
+	return ctx.JSON(http.StatusOK, submission)
+}
+
+// composeAMI returns the AMI the compose produced, or why there's none.
+func (h *Handlers) composeAMI(ctx echo.Context, composeEntry *db.ComposeEntry) (string, string, error) {
+	status, err := h.composeStatus(ctx, composeEntry)
+	if err != nil {
+		return "", "", err
+	}
+	if status.ImageStatus.Status != ImageStatusStatusSuccess {
+		return "", fmt.Sprintf("Compose %s didn't finish successfully", composeEntry.Id), nil
+	}
+	us := status.ImageStatus.UploadStatus
+	if us == nil || us.Type != UploadTypesAws {
+		return "", fmt.Sprintf("The image of compose %s isn't an AMI", composeEntry.Id), nil
+	}
+	upload, err := us.Options.AsAWSUploadStatus()
+	if err != nil {
+		return "", "", err
+	}
+	return upload.Ami, "", nil
+}
@@ -0,0 +1,161 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/jsonpatch"
+)
+
+const (
+	jsonPatchContentType  = "application/json-patch+json"
+	mergePatchContentType = "application/merge-patch+json"
+)
+
+// PatchBlueprint applies a patch to the blueprint in the form of the requests
+// updating it, so it can be checked and stored the same way.
+func (h *Handlers) PatchBlueprint(ctx echo.Context, blueprintId uuid.UUID, params PatchBlueprintParams) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	blueprintEntry, err := h.getBlueprintToChange(ctx, userID, blueprintId, nil)
+	if errors.Is(err, db.BlueprintNotFoundError) {
+		return echo.NewHTTPError(http.StatusNotFound, err)
+	}
+	if err != nil {
+		return err
+	}
+	if params.IfMatch != nil && !etagMatches(*params.IfMatch, versionETag(blueprintEntry.Version)) {
+		return echo.NewHTTPError(http.StatusPreconditionFailed, "The blueprint changed since the version of the ETag")
+	}
+
+	blueprint, err := BlueprintFromEntry(blueprintEntry)
+	if err != nil {
+		return err
+	}
+	document, err := json.Marshal(CreateBlueprintRequest{
+		Name:               blueprintEntry.Name,
+		Description:        &blueprintEntry.Description,
+		Distribution:       blueprint.Distribution,
+		ImageRequests:      blueprint.ImageRequests,
+		Customizations:     blueprint.Customizations,
+		Lifecycle:          blueprint.Lifecycle,
+		LaunchRequirements: blueprint.LaunchRequirements,
+		Visibility:         common.ToPtr(blueprintVisibility(blueprintEntry.Private)),
+		WorkspaceId:        blueprintEntry.WorkspaceId,
+	})
+	if err != nil {
+		return err
+	}
+
+	patch, err := io.ReadAll(ctx.Request().Body)
+	if err != nil {
+		return err
+	}
+	contentType, _, _ := mime.ParseMediaType(ctx.Request().Header.Get(echo.HeaderContentType))
+	var patched []byte
+	switch contentType {
+	case jsonPatchContentType:
+		patched, err = jsonpatch.Apply(document, patch)
+	case mergePatchContentType:
+		patched, err = jsonpatch.MergePatch(document, patch)
+	default:
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType, fmt.Sprintf("Blueprints are patched with %s or %s", jsonPatchContentType, mergePatchContentType))
+	}
+	if errors.Is(err, jsonpatch.ErrTestFailed) {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// the patched blueprint didn't pass the request validation
+	var value interface{}
+	err = json.Unmarshal(patched, &value)
+	if err != nil {
+		return err
+	}
+	err = h.server.spec.Components.Schemas["CreateBlueprintRequest"].Value.VisitJSON(value)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("The patched blueprint is invalid: %s", err))
+	}
+	var blueprintRequest CreateBlueprintRequest
+	err = json.Unmarshal(patched, &blueprintRequest)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("The patched blueprint is invalid: %s", err))
+	}
+	if !blueprintNameRegex.MatchString(blueprintRequest.Name) {
+		return ctx.JSON(http.StatusUnprocessableEntity, HTTPErrorList{
+			Errors: []HTTPError{{
+				Title:  "Invalid blueprint name",
+				Detail: blueprintInvalidNameDetail,
+			}},
+		})
+	}
+
+	private, err := blueprintPrivate(userID, blueprintEntry, blueprintRequest.Visibility)
+	if err != nil {
+		return err
+	}
+	err = blueprintWorkspaceKept(blueprintEntry, blueprintRequest.WorkspaceId)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(BlueprintFromAPI(blueprintRequest))
+	if err != nil {
+		return err
+	}
+	desc := ""
+	if blueprintRequest.Description != nil {
+		desc = *blueprintRequest.Description
+	}
+	err = h.server.db.UpdateBlueprintIfVersion(ctx.Request().Context(), uuid.New(), blueprintId, userID.OrgID(), blueprintRequest.Name, desc, body, blueprintEntry.Version, userID.Email())
+	if err == nil && private != blueprintEntry.Private {
+		err = h.server.db.SetBlueprintPrivate(ctx.Request().Context(), blueprintId, userID.OrgID(), private)
+	}
+	if err != nil {
+		ctx.Logger().Errorf("Error patching blueprint in db: %v", err)
+		var e *pgconn.PgError
+		switch {
+		case errors.Is(err, db.BlueprintNotFoundError):
+			return echo.NewHTTPError(http.StatusNotFound, err)
+		case errors.Is(err, db.BlueprintVersionConflictError):
+			return echo.NewHTTPError(http.StatusPreconditionFailed, "The blueprint changed while patching it")
+		case errors.As(err, &e) && e.Code == pgerrcode.UniqueViolation:
+			return ctx.JSON(http.StatusUnprocessableEntity, HTTPErrorList{
+				Errors: []HTTPError{{
+					Title:  "Name not unique",
+					Detail: "A blueprint with the same name already exists.",
+				}},
+			})
+		}
+		return err
+	}
+	ctx.Logger().Infof("Patched blueprint %s", blueprintId)
+
+	ctx.Response().Header().Set("ETag", versionETag(blueprintEntry.Version+1))
+	return ctx.JSON(http.StatusOK, BlueprintResponse{
+		Id:                 blueprintId,
+		Name:               blueprintRequest.Name,
+		Description:        desc,
+		ImageRequests:      blueprintRequest.ImageRequests,
+		Distribution:       blueprintRequest.Distribution,
+		Customizations:     blueprintRequest.Customizations,
+		Lifecycle:          blueprintRequest.Lifecycle,
+		LaunchRequirements: blueprintRequest.LaunchRequirements,
+	})
+}
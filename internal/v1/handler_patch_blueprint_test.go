@@ -0,0 +1,108 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestHandlers_PatchBlueprint(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{}, &ServerConfig{
+		DBase:            dbase,
+		DistributionsDir: "../../distributions",
+	})
+	defer func() {
+		err := srv.Shutdown(ctx)
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	body := map[string]interface{}{
+		"name":           "Patched blueprint",
+		"description":    "desc",
+		"customizations": map[string]interface{}{"packages": []string{"nginx"}},
+		"distribution":   "centos-9",
+		"image_requests": []map[string]interface{}{
+			{
+				"architecture":   "x86_64",
+				"image_type":     "aws",
+				"upload_request": map[string]interface{}{"type": "aws", "options": map[string]interface{}{"share_with_accounts": []string{"test-account"}}},
+			},
+		},
+	}
+	statusCode, resp := tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/blueprints", body)
+	require.Equal(t, http.StatusCreated, statusCode)
+	var created ComposeResponse
+	require.NoError(t, json.Unmarshal([]byte(resp), &created))
+	url := fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s", created.Id)
+
+	getResp, err := tutils.GetResponseError(url)
+	require.NoError(t, err)
+	require.NoError(t, getResp.Body.Close())
+	require.Equal(t, `"1"`, getResp.Header.Get("ETag"))
+
+	statusCode, headers, resp := tutils.PatchResponse(t, url, "application/json-patch+json", `[
+		{"op": "test", "path": "/customizations/packages/0", "value": "nginx"},
+		{"op": "add", "path": "/customizations/packages/-", "value": "vim"}
+	]`, `"1"`)
+	require.Equal(t, http.StatusOK, statusCode, resp)
+	require.Equal(t, `"2"`, headers.Get("ETag"))
+	var patched BlueprintResponse
+	require.NoError(t, json.Unmarshal([]byte(resp), &patched))
+	require.Equal(t, []string{"nginx", "vim"}, *patched.Customizations.Packages)
+	require.Equal(t, "desc", patched.Description)
+
+	// the ETag of the first version is stale
+	statusCode, _, _ = tutils.PatchResponse(t, url, "application/merge-patch+json", `{"description": "stale"}`, `"1"`)
+	require.Equal(t, http.StatusPreconditionFailed, statusCode)
+
+	statusCode, headers, resp = tutils.PatchResponse(t, url, "application/merge-patch+json", `{"description": "patched", "customizations": {"packages": null}}`, `"2"`)
+	require.Equal(t, http.StatusOK, statusCode, resp)
+	require.Equal(t, `"3"`, headers.Get("ETag"))
+	require.NoError(t, json.Unmarshal([]byte(resp), &patched))
+	require.Nil(t, patched.Customizations.Packages)
+	require.Equal(t, "patched", patched.Description)
+
+	statusCode, resp = tutils.GetResponseBody(t, url, &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, statusCode)
+	var stored BlueprintResponse
+	require.NoError(t, json.Unmarshal([]byte(resp), &stored))
+	require.Equal(t, patched, stored)
+
+	// updates honor If-Match like patches
+	statusCode, _, _ = tutils.ConditionalResponse(t, http.MethodPut, url, body, `"2"`)
+	require.Equal(t, http.StatusPreconditionFailed, statusCode)
+	statusCode, headers, resp = tutils.ConditionalResponse(t, http.MethodPut, url, body, `"3"`)
+	require.Equal(t, http.StatusCreated, statusCode, resp)
+	require.Equal(t, `"4"`, headers.Get("ETag"))
+	statusCode, _, _ = tutils.PatchResponse(t, url, "application/merge-patch+json", `{"description": "patched", "customizations": {"packages": null}}`, `"4"`)
+	require.Equal(t, http.StatusOK, statusCode)
+
+	// the patch is applied without If-Match too
+	statusCode, _, _ = tutils.PatchResponse(t, url, "application/merge-patch+json", `{"lifecycle": {"keep_last": 3}}`, "")
+	require.Equal(t, http.StatusOK, statusCode)
+
+	statusCode, _, _ = tutils.PatchResponse(t, url, "application/json-patch+json", `[{"op": "test", "path": "/name", "value": "other"}]`, "")
+	require.Equal(t, http.StatusConflict, statusCode)
+	statusCode, _, _ = tutils.PatchResponse(t, url, "application/json-patch+json", `[{"op": "remove", "path": "/missing"}]`, "")
+	require.Equal(t, http.StatusBadRequest, statusCode)
+	statusCode, _, _ = tutils.PatchResponse(t, url, "application/json-patch+json", `[{"op": "remove", "path": "/distribution"}]`, "")
+	require.Equal(t, http.StatusBadRequest, statusCode)
+	statusCode, _, _ = tutils.PatchResponse(t, url, "application/merge-patch+json", `{"distribution": "nope"}`, "")
+	require.Equal(t, http.StatusBadRequest, statusCode)
+	statusCode, _, _ = tutils.PatchResponse(t, url, "application/merge-patch+json", `{"name": " "}`, "")
+	require.Equal(t, http.StatusUnprocessableEntity, statusCode)
+	statusCode, _, _ = tutils.PatchResponse(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s", uuid.New()), "application/merge-patch+json", `{}`, "")
+	require.Equal(t, http.StatusNotFound, statusCode)
+}
@@ -0,0 +1,255 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+// policyViolations is the message of the error rejecting a compose, each
+// violation is reported as its own error.
+type policyViolations []string
+
+func (v policyViolations) String() string {
+	return strings.Join(v, "; ")
+}
+
+func orgPolicyResponseFromEntry(e *db.OrgPolicyEntry) (*OrgPolicyResponse, error) {
+	var policy OrgPolicy
+	err := json.Unmarshal(e.Policy, &policy)
+	if err != nil {
+		return nil, err
+	}
+	return &OrgPolicyResponse{
+		Policy:    policy,
+		UpdatedBy: e.UpdatedBy,
+		UpdatedAt: e.UpdatedAt.Format(time.RFC3339),
+	}, nil
+}
+
+func (h *Handlers) GetOrgPolicy(ctx echo.Context) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	entry, err := h.server.db.GetOrgPolicy(ctx.Request().Context(), userID.OrgID())
+	if err != nil {
+		if errors.Is(err, db.OrgPolicyNotFoundError) {
+			return echo.NewHTTPError(http.StatusNotFound, err)
+		}
+		return err
+	}
+	resp, err := orgPolicyResponseFromEntry(entry)
+	if err != nil {
+		return err
+	}
+	ctx.Response().Header().Set("ETag", versionETag(entry.Version))
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// currentOrgPolicyVersion returns the version of the policy an If-Match header
+// refers to, a missing policy never matches.
+func (h *Handlers) currentOrgPolicyVersion(ctx echo.Context, orgId, ifMatch string) (int, error) {
+	entry, err := h.server.db.GetOrgPolicy(ctx.Request().Context(), orgId)
+	if errors.Is(err, db.OrgPolicyNotFoundError) {
+		return 0, echo.NewHTTPError(http.StatusPreconditionFailed, "The organization has no policy")
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !etagMatches(ifMatch, versionETag(entry.Version)) {
+		return 0, echo.NewHTTPError(http.StatusPreconditionFailed, "The policy changed since the version of the ETag")
+	}
+	return entry.Version, nil
+}
+
+func (h *Handlers) SetOrgPolicy(ctx echo.Context, params SetOrgPolicyParams) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !userID.IsOrgAdmin() {
+		return echo.NewHTTPError(http.StatusForbidden, "The policy can only be changed by organization administrators")
+	}
+
+	var policy SetOrgPolicyJSONRequestBody
+	err = ctx.Bind(&policy)
+	if err != nil {
+		return err
+	}
+
+	err = checkPolicy(policy)
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	if params.IfMatch != nil {
+		version, err := h.currentOrgPolicyVersion(ctx, userID.OrgID(), *params.IfMatch)
+		if err != nil {
+			return err
+		}
+		err = h.server.db.SetOrgPolicyIfVersion(ctx.Request().Context(), userID.OrgID(), userID.Email(), raw, version)
+		if errors.Is(err, db.OrgPolicyVersionConflictError) {
+			return echo.NewHTTPError(http.StatusPreconditionFailed, "The policy changed while storing it")
+		}
+		if err != nil {
+			return err
+		}
+	} else {
+		err = h.server.db.SetOrgPolicy(ctx.Request().Context(), userID.OrgID(), userID.Email(), raw)
+		if err != nil {
+			return err
+		}
+	}
+
+	entry, err := h.server.db.GetOrgPolicy(ctx.Request().Context(), userID.OrgID())
+	if err != nil {
+		return err
+	}
+	resp, err := orgPolicyResponseFromEntry(entry)
+	if err != nil {
+		return err
+	}
+	ctx.Response().Header().Set("ETag", versionETag(entry.Version))
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *Handlers) DeleteOrgPolicy(ctx echo.Context, params DeleteOrgPolicyParams) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !userID.IsOrgAdmin() {
+		return echo.NewHTTPError(http.StatusForbidden, "The policy can only be changed by organization administrators")
+	}
+
+	if params.IfMatch != nil {
+		version, err := h.currentOrgPolicyVersion(ctx, userID.OrgID(), *params.IfMatch)
+		if err != nil {
+			return err
+		}
+		err = h.server.db.DeleteOrgPolicyIfVersion(ctx.Request().Context(), userID.OrgID(), version)
+		if errors.Is(err, db.OrgPolicyVersionConflictError) {
+			return echo.NewHTTPError(http.StatusPreconditionFailed, "The policy changed while removing it")
+		}
+		if err != nil {
+			return err
+		}
+		return ctx.NoContent(http.StatusNoContent)
+	}
+
+	err = h.server.db.DeleteOrgPolicy(ctx.Request().Context(), userID.OrgID())
+	if err != nil {
+		if errors.Is(err, db.OrgPolicyNotFoundError) {
+			return echo.NewHTTPError(http.StatusNotFound, err)
+		}
+		return err
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// checkPolicy rejects policies contradicting themselves.
+func checkPolicy(policy OrgPolicy) error {
+	if policy.RequiredPackages != nil && policy.BannedPackages != nil {
+		for _, p := range *policy.RequiredPackages {
+			if slices.Contains(*policy.BannedPackages, p) {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Package %s is both required and banned", p))
+			}
+		}
+	}
+	if policy.AllowedUploadTypes != nil && len(*policy.AllowedUploadTypes) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "At least one upload type has to be allowed")
+	}
+	return nil
+}
+
+// checkOrgPolicy rejects composes which don't satisfy the policy of the org,
+// listing everything that needs to change. The secrets found in the request
+// only reject it if the policy says so. The policy is returned for the rest of
+// the compose, nil if the org has none.
+func (h *Handlers) checkOrgPolicy(ctx echo.Context, orgId string, cr *ComposeRequest, secrets []string) (*OrgPolicy, error) {
+	entry, err := h.server.db.GetOrgPolicy(ctx.Request().Context(), orgId)
+	if errors.Is(err, db.OrgPolicyNotFoundError) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var policy OrgPolicy
+	err = json.Unmarshal(entry.Policy, &policy)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := orgPolicyViolations(policy, cr, secrets)
+	if len(violations) > 0 {
+		return nil, echo.NewHTTPError(http.StatusForbidden, violations)
+	}
+	return &policy, nil
+}
+
+func orgPolicyViolations(policy OrgPolicy, cr *ComposeRequest, secrets []string) policyViolations {
+	return scopedPolicyViolations("Organization", policy, cr, secrets)
+}
+
+// scopedPolicyViolations lists what the compose request needs to change to
+// satisfy the policy of the scope, the org or a workspace.
+func scopedPolicyViolations(scope string, policy OrgPolicy, cr *ComposeRequest, secrets []string) policyViolations {
+	var packages []string
+	cust := cr.Customizations
+	if cust != nil && cust.Packages != nil {
+		packages = *cust.Packages
+	}
+
+	var violations policyViolations
+	if policy.RequiredPackages != nil {
+		for _, p := range *policy.RequiredPackages {
+			if !slices.Contains(packages, p) {
+				violations = append(violations, fmt.Sprintf("%s policy requires package %s", scope, p))
+			}
+		}
+	}
+	if policy.BannedPackages != nil {
+		for _, p := range *policy.BannedPackages {
+			if slices.Contains(packages, p) {
+				violations = append(violations, fmt.Sprintf("%s policy bans package %s", scope, p))
+			}
+		}
+	}
+	if policy.OpenscapProfileId != nil {
+		if cust == nil || cust.Openscap == nil || cust.Openscap.ProfileId != *policy.OpenscapProfileId {
+			violations = append(violations, fmt.Sprintf("%s policy requires OpenSCAP profile %s", scope, *policy.OpenscapProfileId))
+		}
+	}
+	if policy.Fips != nil && *policy.Fips {
+		if cust == nil || cust.Fips == nil || cust.Fips.Enabled == nil || !*cust.Fips.Enabled {
+			violations = append(violations, fmt.Sprintf("%s policy requires FIPS mode", scope))
+		}
+	}
+	if policy.AllowedUploadTypes != nil {
+		ut := cr.ImageRequests[0].UploadRequest.Type
+		if !slices.Contains(*policy.AllowedUploadTypes, ut) {
+			violations = append(violations, fmt.Sprintf("%s policy doesn't allow uploading to %s", scope, ut))
+		}
+	}
+	if policy.SecretScanning != nil && *policy.SecretScanning == Block {
+		for _, s := range secrets {
+			violations = append(violations, fmt.Sprintf("%s policy doesn't allow secrets: %s", scope, s))
+		}
+	}
+	return violations
+}
@@ -0,0 +1,208 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestOrgPolicy(t *testing.T) {
+	srv, tokenSrv := startServer(t, &testServerClientsConf{}, nil)
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	respStatusCode, _ := tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/policy", &tutils.AuthString0)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+
+	respStatusCode, body := tutils.PutResponseBody(t, "http://localhost:8086/api/image-builder/v1/policy", OrgPolicy{
+		RequiredPackages: &[]string{"telnet"},
+		BannedPackages:   &[]string{"telnet"},
+	})
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+	require.Contains(t, body, "Package telnet is both required and banned")
+
+	policy := OrgPolicy{
+		RequiredPackages:   &[]string{"falcon-sensor"},
+		BannedPackages:     &[]string{"telnet-server"},
+		OpenscapProfileId:  common.ToPtr("xccdf_org.ssgproject.content_profile_cis"),
+		Fips:               common.ToPtr(true),
+		AllowedUploadTypes: &[]UploadTypes{UploadTypesGcp},
+	}
+	respStatusCode, body = tutils.PutResponseBody(t, "http://localhost:8086/api/image-builder/v1/policy", policy)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var result OrgPolicyResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Equal(t, policy, result.Policy)
+
+	respStatusCode, body = tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/policy", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Equal(t, policy, result.Policy)
+
+	getResp, err := tutils.GetResponseError("http://localhost:8086/api/image-builder/v1/policy")
+	require.NoError(t, err)
+	require.NoError(t, getResp.Body.Close())
+	etag := getResp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	respStatusCode, headers, _ := tutils.ConditionalResponse(t, http.MethodPut, "http://localhost:8086/api/image-builder/v1/policy", policy, etag)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.NotEqual(t, etag, headers.Get("ETag"))
+
+	// the ETag is stale after the update
+	respStatusCode, _, _ = tutils.ConditionalResponse(t, http.MethodPut, "http://localhost:8086/api/image-builder/v1/policy", OrgPolicy{}, etag)
+	require.Equal(t, http.StatusPreconditionFailed, respStatusCode)
+	respStatusCode, _, _ = tutils.ConditionalResponse(t, http.MethodDelete, "http://localhost:8086/api/image-builder/v1/policy", nil, etag)
+	require.Equal(t, http.StatusPreconditionFailed, respStatusCode)
+	etag = headers.Get("ETag")
+
+	var uo UploadRequest_Options
+	require.NoError(t, uo.FromAWSUploadRequestOptions(AWSUploadRequestOptions{
+		ShareWithAccounts: &[]string{"test-account"},
+	}))
+	payload := ComposeRequest{
+		Customizations: &Customizations{
+			Packages: &[]string{"telnet-server"},
+		},
+		Distribution: "rhel-9",
+		ImageRequests: []ImageRequest{
+			{
+				Architecture: "x86_64",
+				ImageType:    ImageTypesAws,
+				UploadRequest: UploadRequest{
+					Type:    UploadTypesAws,
+					Options: uo,
+				},
+			},
+		},
+	}
+	respStatusCode, body = tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/compose", payload)
+	require.Equal(t, http.StatusForbidden, respStatusCode)
+	var errs HTTPErrorList
+	require.NoError(t, json.Unmarshal([]byte(body), &errs))
+	require.Equal(t, []HTTPError{
+		{Title: "403", Detail: "Organization policy requires package falcon-sensor"},
+		{Title: "403", Detail: "Organization policy bans package telnet-server"},
+		{Title: "403", Detail: "Organization policy requires OpenSCAP profile xccdf_org.ssgproject.content_profile_cis"},
+		{Title: "403", Detail: "Organization policy requires FIPS mode"},
+		{Title: "403", Detail: "Organization policy doesn't allow uploading to aws"},
+	}, errs.Errors)
+
+	respStatusCode, _, _ = tutils.ConditionalResponse(t, http.MethodDelete, "http://localhost:8086/api/image-builder/v1/policy", nil, etag)
+	require.Equal(t, http.StatusNoContent, respStatusCode)
+	respStatusCode, _ = tutils.DeleteResponseBody(t, "http://localhost:8086/api/image-builder/v1/policy")
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+	// there is no policy to match
+	respStatusCode, _, _ = tutils.ConditionalResponse(t, http.MethodPut, "http://localhost:8086/api/image-builder/v1/policy", policy, "*")
+	require.Equal(t, http.StatusPreconditionFailed, respStatusCode)
+}
+
+func TestOrgPolicyCostCenter(t *testing.T) {
+	id := uuid.New()
+	var composerRequest composer.ComposeRequest
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.Equal(t, "Bearer accesstoken", r.Header.Get("Authorization"))
+		err := json.NewDecoder(r.Body).Decode(&composerRequest)
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		err = json.NewEncoder(w).Encode(composer.ComposeId{Id: id})
+		require.NoError(t, err)
+	}))
+	defer apiSrv.Close()
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, nil)
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	respStatusCode, _ := tutils.PutResponseBody(t, "http://localhost:8086/api/image-builder/v1/policy", OrgPolicy{
+		CostCenter: common.ToPtr("cc-1234"),
+	})
+	require.Equal(t, http.StatusOK, respStatusCode)
+	defer func() {
+		respStatusCode, _ := tutils.DeleteResponseBody(t, "http://localhost:8086/api/image-builder/v1/policy")
+		require.Equal(t, http.StatusNoContent, respStatusCode)
+	}()
+
+	var uo UploadRequest_Options
+	require.NoError(t, uo.FromAWSUploadRequestOptions(AWSUploadRequestOptions{
+		ShareWithAccounts: &[]string{"test-account"},
+	}))
+	payload := ComposeRequest{
+		Distribution: "centos-9",
+		ImageRequests: []ImageRequest{
+			{
+				Architecture: "x86_64",
+				ImageType:    ImageTypesAws,
+				UploadRequest: UploadRequest{
+					Type:    UploadTypesAws,
+					Options: uo,
+				},
+			},
+		},
+	}
+	respStatusCode, body := tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/compose", payload)
+	require.Equal(t, http.StatusCreated, respStatusCode, body)
+	require.Equal(t, &composer.JobTags{
+		"org_id":         "000000",
+		"account_number": "000000",
+		"cost_center":    "cc-1234",
+	}, composerRequest.Tags)
+}
+
+func TestOrgPolicyViolations(t *testing.T) {
+	policy := OrgPolicy{
+		RequiredPackages:  &[]string{"falcon-sensor"},
+		OpenscapProfileId: common.ToPtr("xccdf_org.ssgproject.content_profile_cis"),
+		Fips:              common.ToPtr(true),
+	}
+	cr := ComposeRequest{
+		Customizations: &Customizations{
+			Packages: &[]string{"falcon-sensor", "vim"},
+			Openscap: &OpenSCAP{
+				ProfileId: "xccdf_org.ssgproject.content_profile_cis",
+			},
+			Fips: &FIPS{
+				Enabled: common.ToPtr(true),
+			},
+		},
+		ImageRequests: []ImageRequest{
+			{
+				UploadRequest: UploadRequest{
+					Type: UploadTypesAws,
+				},
+			},
+		},
+	}
+	require.Empty(t, orgPolicyViolations(policy, &cr, nil))
+	require.Empty(t, orgPolicyViolations(OrgPolicy{}, &ComposeRequest{ImageRequests: cr.ImageRequests}, nil))
+
+	cr.Customizations.Fips = nil
+	require.Equal(t, policyViolations{"Organization policy requires FIPS mode"}, orgPolicyViolations(policy, &cr, nil))
+
+	secrets := []string{"File /root/.aws/credentials contains an AWS access key"}
+	require.Empty(t, orgPolicyViolations(OrgPolicy{}, &cr, secrets))
+	require.Empty(t, orgPolicyViolations(OrgPolicy{SecretScanning: common.ToPtr(Warn)}, &cr, secrets))
+	require.Equal(t, policyViolations{
+		"Organization policy doesn't allow secrets: File /root/.aws/credentials contains an AWS access key",
+	}, orgPolicyViolations(OrgPolicy{SecretScanning: common.ToPtr(Block)}, &cr, secrets))
+}
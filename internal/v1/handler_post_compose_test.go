@@ -2,6 +2,7 @@ package v1
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -18,7 +19,11 @@ import (
 	"github.com/osbuild/image-builder/internal/clients/content_sources"
 	"github.com/osbuild/image-builder/internal/clients/provisioning"
 	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/distribution"
+	"github.com/osbuild/image-builder/internal/encryption"
+	"github.com/osbuild/image-builder/internal/redact"
 	"github.com/osbuild/image-builder/internal/tutils"
+	iberrors "github.com/osbuild/image-builder/pkg/errors"
 )
 
 const (
@@ -356,6 +361,138 @@ func TestValidateComposeRequest(t *testing.T) {
 	})
 }
 
+func TestImageTypeAliases(t *testing.T) {
+	h := Handlers{server: &Server{}}
+	var uo UploadRequest_Options
+	require.NoError(t, uo.FromAWSS3UploadRequestOptions(AWSS3UploadRequestOptions{}))
+	ur := UploadRequest{
+		Type:    UploadTypesAwsS3,
+		Options: uo,
+	}
+
+	for it, expected := range map[ImageTypes]composer.ImageTypes{
+		ImageTypesGuestImage:     composer.ImageTypesGuestImage,
+		ImageTypesQcow2:          composer.ImageTypesGuestImage,
+		ImageTypesVirtualization: composer.ImageTypesGuestImage,
+		ImageTypesVmdk:           composer.ImageTypesVsphere,
+		ImageTypesRhelEdgeCommit: composer.ImageTypesEdgeCommit,
+	} {
+		_, composerImageType, err := h.buildUploadOptions(nil, ur, it, ImageRequestArchitectureX8664)
+		require.NoError(t, err, it)
+		require.Equal(t, expected, composerImageType, it)
+	}
+
+	_, _, err := h.buildUploadOptions(nil, ur, ImageTypesAmi, ImageRequestArchitectureX8664)
+	require.Error(t, err)
+}
+
+func TestAzureHyperVGeneration(t *testing.T) {
+	h := Handlers{server: &Server{}}
+	uploadRequest := func(generation *AzureUploadRequestOptionsHyperVGeneration) UploadRequest {
+		var uo UploadRequest_Options
+		require.NoError(t, uo.FromAzureUploadRequestOptions(AzureUploadRequestOptions{
+			ResourceGroup:    "group",
+			SubscriptionId:   common.ToPtr("id"),
+			TenantId:         common.ToPtr("tenant"),
+			HyperVGeneration: generation,
+		}))
+		return UploadRequest{
+			Type:    UploadTypesAzure,
+			Options: uo,
+		}
+	}
+
+	testData := []struct {
+		requested *AzureUploadRequestOptionsHyperVGeneration
+		arch      ImageRequestArchitecture
+		expected  *composer.AzureUploadOptionsHyperVGeneration
+	}{
+		{nil, ImageRequestArchitectureX8664, nil},
+		{nil, ImageRequestArchitectureAarch64, common.ToPtr(composer.AzureUploadOptionsHyperVGenerationV2)},
+		{common.ToPtr(V1), ImageRequestArchitectureX8664, common.ToPtr(composer.AzureUploadOptionsHyperVGenerationV1)},
+		{common.ToPtr(V2), ImageRequestArchitectureX8664, common.ToPtr(composer.AzureUploadOptionsHyperVGenerationV2)},
+		{common.ToPtr(V2), ImageRequestArchitectureAarch64, common.ToPtr(composer.AzureUploadOptionsHyperVGenerationV2)},
+	}
+	for idx, td := range testData {
+		uploadOptions, _, err := h.buildUploadOptions(nil, uploadRequest(td.requested), ImageTypesAzure, td.arch)
+		require.NoError(t, err, idx)
+		azureOptions, err := uploadOptions.AsAzureUploadOptions()
+		require.NoError(t, err, idx)
+		require.Equal(t, td.expected, azureOptions.HyperVGeneration, idx)
+	}
+
+	_, _, err := h.buildUploadOptions(nil, uploadRequest(common.ToPtr(V1)), ImageTypesAzure, ImageRequestArchitectureAarch64)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Hyper-V generation V1 is not available for aarch64")
+}
+
+func TestAWSRegistration(t *testing.T) {
+	h := Handlers{server: &Server{aws: AWSConfig{Region: "us-east-1"}}}
+	uploadRequest := func(uo AWSUploadRequestOptions) UploadRequest {
+		uo.ShareWithAccounts = &[]string{"123456789012"}
+		var options UploadRequest_Options
+		require.NoError(t, options.FromAWSUploadRequestOptions(uo))
+		return UploadRequest{
+			Type:    UploadTypesAws,
+			Options: options,
+		}
+	}
+
+	uploadOptions, _, err := h.buildUploadOptions(nil, uploadRequest(AWSUploadRequestOptions{
+		BootMode:           common.ToPtr(Uefi),
+		EnaSupport:         common.ToPtr(false),
+		SriovNetSupport:    common.ToPtr(true),
+		HibernationCapable: common.ToPtr(true),
+		ImdsV2Required:     common.ToPtr(true),
+		TpmSupport:         common.ToPtr(true),
+	}), ImageTypesAws, ImageRequestArchitectureX8664)
+	require.NoError(t, err)
+	awsOptions, err := uploadOptions.AsAWSEC2UploadOptions()
+	require.NoError(t, err)
+	require.Equal(t, composer.AWSEC2UploadOptions{
+		Region:             "us-east-1",
+		ShareWithAccounts:  []string{"123456789012"},
+		BootMode:           common.ToPtr(composer.AWSEC2UploadOptionsBootModeUefi),
+		EnaSupport:         common.ToPtr(false),
+		SriovNetSupport:    common.ToPtr(true),
+		HibernationCapable: common.ToPtr(true),
+		ImdsV2Required:     common.ToPtr(true),
+		TpmSupport:         common.ToPtr(true),
+	}, awsOptions)
+
+	// the flags are left to composer unless requested
+	uploadOptions, _, err = h.buildUploadOptions(nil, uploadRequest(AWSUploadRequestOptions{}), ImageTypesAws, ImageRequestArchitectureX8664)
+	require.NoError(t, err)
+	awsOptions, err = uploadOptions.AsAWSEC2UploadOptions()
+	require.NoError(t, err)
+	require.Nil(t, awsOptions.BootMode)
+	require.Nil(t, awsOptions.EnaSupport)
+	require.Nil(t, awsOptions.TpmSupport)
+
+	// aarch64 boots with uefi
+	_, _, err = h.buildUploadOptions(nil, uploadRequest(AWSUploadRequestOptions{
+		TpmSupport: common.ToPtr(true),
+	}), ImageTypesAws, ImageRequestArchitectureAarch64)
+	require.NoError(t, err)
+
+	testData := []struct {
+		options AWSUploadRequestOptions
+		arch    ImageRequestArchitecture
+		err     string
+	}{
+		{AWSUploadRequestOptions{BootMode: common.ToPtr(LegacyBios)}, ImageRequestArchitectureAarch64, "Boot mode legacy-bios is not available for aarch64"},
+		{AWSUploadRequestOptions{BootMode: common.ToPtr(AWSUploadRequestOptionsBootMode("bios"))}, ImageRequestArchitectureX8664, "Unknown boot mode bios"},
+		{AWSUploadRequestOptions{TpmSupport: common.ToPtr(true)}, ImageRequestArchitectureX8664, "NitroTPM support requires the uefi boot mode"},
+		{AWSUploadRequestOptions{TpmSupport: common.ToPtr(true), BootMode: common.ToPtr(UefiPreferred)}, ImageRequestArchitectureX8664, "NitroTPM support requires the uefi boot mode"},
+		{AWSUploadRequestOptions{SriovNetSupport: common.ToPtr(true)}, ImageRequestArchitectureAarch64, "SR-IOV networking support is not available for aarch64"},
+	}
+	for idx, td := range testData {
+		_, _, err := h.buildUploadOptions(nil, uploadRequest(td.options), ImageTypesAws, td.arch)
+		require.Error(t, err, idx)
+		require.Contains(t, err.Error(), td.err, idx)
+	}
+}
+
 func TestComposeStatusError(t *testing.T) {
 	ctx := context.Background()
 	id := uuid.New()
@@ -406,7 +543,7 @@ func TestComposeStatusError(t *testing.T) {
 	require.NoError(t, err)
 	imageName := "MyImageName"
 	clientId := "ui"
-	err = dbase.InsertCompose(ctx, id, "600000", "user@test.test", "000001", &imageName, json.RawMessage("{}"), &clientId, nil)
+	err = dbase.InsertCompose(ctx, id, "600000", "user@test.test", "000001", &imageName, json.RawMessage("{}"), &clientId, nil, nil, nil)
 	require.NoError(t, err)
 
 	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
@@ -537,6 +674,46 @@ func TestComposeImageErrorResolvingOSTree(t *testing.T) {
 	require.Contains(t, body, "Error resolving OSTree repo")
 }
 
+func TestComposeImageComposerRejection(t *testing.T) {
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		err := json.NewEncoder(w).Encode(composer.Error{
+			Id:     "8",
+			Reason: "DNF error occurred: package nonexistent-package not available from rpmrepo.composer.svc",
+		})
+		require.NoError(t, err)
+	}))
+	defer apiSrv.Close()
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, nil)
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	var uo UploadRequest_Options
+	require.NoError(t, uo.FromAWSS3UploadRequestOptions(AWSS3UploadRequestOptions{}))
+	payload := ComposeRequest{
+		Distribution: "centos-9",
+		ImageRequests: []ImageRequest{
+			{
+				Architecture: "x86_64",
+				ImageType:    ImageTypesGuestImage,
+				UploadRequest: UploadRequest{
+					Type:    UploadTypesAwsS3,
+					Options: uo,
+				},
+			},
+		},
+	}
+	respStatusCode, body := tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/compose", payload)
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+	require.Contains(t, body, "osbuild-composer rejected the compose request: DNF error occurred: package nonexistent-package not available from [internal host]")
+	require.NotContains(t, body, "rpmrepo.composer.svc")
+}
+
 func TestComposeImageErrorsWhenCannotParseResponse(t *testing.T) {
 	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if "Bearer" == r.Header.Get("Authorization") {
@@ -666,6 +843,98 @@ func TestComposeImageReturnsIdWhenNoErrors(t *testing.T) {
 	require.Equal(t, id, result.Id)
 }
 
+func TestComposeGCPKmsKey(t *testing.T) {
+	var composerRequest composer.ComposeRequest
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&composerRequest))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		err := json.NewEncoder(w).Encode(composer.ComposeId{
+			Id: uuid.New(),
+		})
+		require.NoError(t, err)
+	}))
+	defer apiSrv.Close()
+
+	provSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var result provisioning.V1SourceUploadInfoResponse
+		switch r.URL.Path {
+		case "/sources/1/upload_info":
+			result.Gcp = &struct {
+				ProjectId *string `json:"project_id,omitempty"`
+			}{
+				ProjectId: common.ToPtr("my-project"),
+			}
+		case "/sources/2/upload_info":
+			result.Aws = &struct {
+				AccountId *string `json:"account_id,omitempty"`
+			}{
+				AccountId: common.ToPtr("123456123456"),
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err := json.NewEncoder(w).Encode(result)
+		require.NoError(t, err)
+	}))
+	defer provSrv.Close()
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL, ProvURL: provSrv.URL}, nil)
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	compose := func(options GCPUploadRequestOptions) (int, string) {
+		var uo UploadRequest_Options
+		require.NoError(t, uo.FromGCPUploadRequestOptions(options))
+		payload := ComposeRequest{
+			Distribution: "centos-9",
+			ImageRequests: []ImageRequest{
+				{
+					Architecture: "x86_64",
+					ImageType:    ImageTypesGcp,
+					UploadRequest: UploadRequest{
+						Type:    UploadTypesGcp,
+						Options: uo,
+					},
+				},
+			},
+		}
+		return tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/compose", payload)
+	}
+
+	key := "projects/my-project/locations/global/keyRings/ring/cryptoKeys/key"
+	respStatusCode, body := compose(GCPUploadRequestOptions{
+		KmsKeyName: &key,
+		SourceId:   common.ToPtr("1"),
+	})
+	require.Equal(t, http.StatusCreated, respStatusCode, body)
+	gcpOptions, err := composerRequest.ImageRequest.UploadOptions.AsGCPUploadOptions()
+	require.NoError(t, err)
+	require.Equal(t, key, *gcpOptions.KmsKeyName)
+
+	testData := []struct {
+		options GCPUploadRequestOptions
+		err     string
+	}{
+		{GCPUploadRequestOptions{KmsKeyName: &key}, "A kms key requires the source of its project"},
+		{GCPUploadRequestOptions{SourceId: common.ToPtr("1")}, "A source is only used to check the project of a kms key"},
+		{GCPUploadRequestOptions{KmsKeyName: common.ToPtr("projects/other-project/locations/global/keyRings/ring/cryptoKeys/key"), SourceId: common.ToPtr("1")}, "doesn't belong to project my-project of source 1"},
+		{GCPUploadRequestOptions{KmsKeyName: &key, SourceId: common.ToPtr("2")}, "Unable to resolve source 2 to a gcp project"},
+	}
+	for idx, td := range testData {
+		respStatusCode, body := compose(td.options)
+		require.Equal(t, http.StatusBadRequest, respStatusCode, idx)
+		require.Contains(t, body, td.err, idx)
+	}
+}
+
 func TestComposeImageAllowList(t *testing.T) {
 	distsDir := "../distribution/testdata/distributions"
 	allowFile := "../common/testdata/allow.json"
@@ -752,6 +1021,7 @@ func TestComposeImageAllowList(t *testing.T) {
 
 		respStatusCode, body := tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/compose", payload)
 		require.Equal(t, http.StatusForbidden, respStatusCode)
+		require.ErrorIs(t, iberrors.FromResponse(respStatusCode, []byte(body)), iberrors.ErrForbiddenDistribution)
 
 		var result ComposeResponse
 		err := json.Unmarshal([]byte(body), &result)
@@ -981,6 +1251,7 @@ func TestComposeWithSnapshots(t *testing.T) {
 				},
 			},
 			composerRequest: composer.ComposeRequest{
+				Tags:         &composer.JobTags{"org_id": "000000", "account_number": "000000"},
 				Distribution: "rhel-94",
 				ImageRequest: &composer.ImageRequest{
 					Architecture: "x86_64",
@@ -1054,6 +1325,7 @@ func TestComposeWithSnapshots(t *testing.T) {
 				},
 			},
 			composerRequest: composer.ComposeRequest{
+				Tags:         &composer.JobTags{"org_id": "000000", "account_number": "000000"},
 				Distribution: "rhel-94",
 				ImageRequest: &composer.ImageRequest{
 					Architecture: "x86_64",
@@ -1150,6 +1422,7 @@ func TestComposeWithSnapshots(t *testing.T) {
 				},
 			},
 			composerRequest: composer.ComposeRequest{
+				Tags:         &composer.JobTags{"org_id": "000000", "account_number": "000000"},
 				Distribution: "rhel-94",
 				ImageRequest: &composer.ImageRequest{
 					Architecture: "x86_64",
@@ -1400,6 +1673,7 @@ func TestComposeCustomizations(t *testing.T) {
 				},
 			},
 			composerRequest: composer.ComposeRequest{
+				Tags:         &composer.JobTags{"org_id": "000000", "account_number": "000000"},
 				Distribution: "centos-9",
 				Customizations: &composer.Customizations{
 					Packages: &[]string{
@@ -1516,6 +1790,7 @@ func TestComposeCustomizations(t *testing.T) {
 				},
 			},
 			composerRequest: composer.ComposeRequest{
+				Tags:         &composer.JobTags{"org_id": "000000", "account_number": "000000"},
 				Distribution: "rhel-8.10",
 				Customizations: &composer.Customizations{
 					Packages: nil,
@@ -1591,6 +1866,7 @@ func TestComposeCustomizations(t *testing.T) {
 				},
 			},
 			composerRequest: composer.ComposeRequest{
+				Tags:         &composer.JobTags{"org_id": "000000", "account_number": "000000"},
 				Distribution: "centos-9",
 				Customizations: &composer.Customizations{
 					Packages: &[]string{
@@ -1673,6 +1949,7 @@ func TestComposeCustomizations(t *testing.T) {
 				},
 			},
 			composerRequest: composer.ComposeRequest{
+				Tags:           &composer.JobTags{"org_id": "000000", "account_number": "000000"},
 				Distribution:   "centos-9",
 				Customizations: nil,
 				ImageRequest: &composer.ImageRequest{
@@ -1736,6 +2013,7 @@ func TestComposeCustomizations(t *testing.T) {
 				},
 			},
 			composerRequest: composer.ComposeRequest{
+				Tags:           &composer.JobTags{"org_id": "000000", "account_number": "000000"},
 				Distribution:   "centos-9",
 				Customizations: nil,
 				ImageRequest: &composer.ImageRequest{
@@ -1793,6 +2071,7 @@ func TestComposeCustomizations(t *testing.T) {
 				},
 			},
 			composerRequest: composer.ComposeRequest{
+				Tags:           &composer.JobTags{"org_id": "000000", "account_number": "000000"},
 				Distribution:   "centos-9",
 				Customizations: nil,
 				ImageRequest: &composer.ImageRequest{
@@ -1843,6 +2122,7 @@ func TestComposeCustomizations(t *testing.T) {
 				},
 			},
 			composerRequest: composer.ComposeRequest{
+				Tags:           &composer.JobTags{"org_id": "000000", "account_number": "000000"},
 				Distribution:   "centos-9",
 				Customizations: nil,
 				ImageRequest: &composer.ImageRequest{
@@ -1902,6 +2182,7 @@ func TestComposeCustomizations(t *testing.T) {
 				},
 			},
 			composerRequest: composer.ComposeRequest{
+				Tags:         &composer.JobTags{"org_id": "000000", "account_number": "000000"},
 				Distribution: "rhel-8.10",
 				Customizations: &composer.Customizations{
 					Filesystem: &[]composer.Filesystem{
@@ -1957,6 +2238,7 @@ func TestComposeCustomizations(t *testing.T) {
 				},
 			},
 			composerRequest: composer.ComposeRequest{
+				Tags:         &composer.JobTags{"org_id": "000000", "account_number": "000000"},
 				Distribution: "rhel-8.10",
 				ImageRequest: &composer.ImageRequest{
 					Architecture: "x86_64",
@@ -2012,6 +2294,7 @@ func TestComposeCustomizations(t *testing.T) {
 				},
 			},
 			composerRequest: composer.ComposeRequest{
+				Tags:         &composer.JobTags{"org_id": "000000", "account_number": "000000"},
 				Distribution: "rhel-8.10",
 				Customizations: &composer.Customizations{
 					Filesystem: &[]composer.Filesystem{
@@ -2086,6 +2369,7 @@ func TestComposeCustomizations(t *testing.T) {
 				},
 			},
 			composerRequest: composer.ComposeRequest{
+				Tags:         &composer.JobTags{"org_id": "000000", "account_number": "000000"},
 				Distribution: "rhel-8.10",
 				Customizations: &composer.Customizations{
 					Files: &[]composer.File{
@@ -2171,6 +2455,7 @@ func TestComposeCustomizations(t *testing.T) {
 				},
 			},
 			composerRequest: composer.ComposeRequest{
+				Tags:         &composer.JobTags{"org_id": "000000", "account_number": "000000"},
 				Distribution: "rhel-8.10",
 				Customizations: &composer.Customizations{
 					Firewall: &composer.FirewallCustomization{
@@ -2243,6 +2528,7 @@ func TestComposeCustomizations(t *testing.T) {
 				},
 			},
 			composerRequest: composer.ComposeRequest{
+				Tags:         &composer.JobTags{"org_id": "000000", "account_number": "000000"},
 				Distribution: "rhel-8.10",
 				Customizations: &composer.Customizations{
 					Subscription: &composer.Subscription{
@@ -2308,6 +2594,7 @@ func TestComposeCustomizations(t *testing.T) {
 				},
 			},
 			composerRequest: composer.ComposeRequest{
+				Tags:         &composer.JobTags{"org_id": "000000", "account_number": "000000"},
 				Distribution: "rhel-8.10",
 				Customizations: &composer.Customizations{
 					Subscription: &composer.Subscription{
@@ -2365,3 +2652,104 @@ func TestComposeCustomizations(t *testing.T) {
 		composerRequest = composer.ComposeRequest{}
 	}
 }
+
+func TestComposeRequestEncryption(t *testing.T) {
+	keyring, err := encryption.ParseKeyring("a:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 32))))
+	require.NoError(t, err)
+
+	cr := ComposeRequest{
+		Distribution: "rhel-9",
+		Customizations: &Customizations{
+			Subscription: &Subscription{
+				ActivationKey: "secret-activation-key",
+				Organization:  1,
+			},
+		},
+		ImageRequests: []ImageRequest{
+			{
+				Architecture: "x86_64",
+				ImageType:    ImageTypesGuestImage,
+			},
+		},
+	}
+
+	plain := Server{}
+	raw, err := plain.sealComposeRequest(cr, false)
+	require.NoError(t, err)
+	require.Contains(t, string(raw), "secret-activation-key")
+
+	encrypted := Server{keyring: keyring}
+	raw, err = encrypted.sealComposeRequest(cr, false)
+	require.NoError(t, err)
+	require.NotContains(t, string(raw), "secret-activation-key")
+	// the database filters on the image type
+	require.Contains(t, string(raw), string(ImageTypesGuestImage))
+
+	var opened ComposeRequest
+	require.NoError(t, encrypted.openComposeRequest(raw, &opened))
+	require.Equal(t, cr.Customizations, opened.Customizations)
+	require.Equal(t, cr.ImageRequests[0].ImageType, opened.ImageRequests[0].ImageType)
+
+	require.Error(t, plain.openComposeRequest(raw, &opened))
+}
+
+func TestComposeRequestRedaction(t *testing.T) {
+	cr := ComposeRequest{
+		Distribution: "rhel-9",
+		Customizations: &Customizations{
+			Files: &[]File{
+				{
+					Path: "/etc/secret",
+					Data: common.ToPtr("file contents"),
+				},
+			},
+			Subscription: &Subscription{
+				ActivationKey: "secret-activation-key",
+			},
+		},
+		ImageRequests: []ImageRequest{
+			{
+				Architecture: "x86_64",
+				ImageType:    ImageTypesGuestImage,
+			},
+		},
+	}
+
+	s := Server{redactRequests: true}
+	raw, err := s.sealComposeRequest(cr, false)
+	require.NoError(t, err)
+	require.NotContains(t, string(raw), "secret-activation-key")
+	require.NotContains(t, string(raw), "file contents")
+
+	var stored ComposeRequest
+	require.NoError(t, s.openComposeRequest(raw, &stored))
+	require.Equal(t, redact.Digest("secret-activation-key"), stored.Customizations.Subscription.ActivationKey)
+	require.Equal(t, redact.Digest("file contents"), *(*stored.Customizations.Files)[0].Data)
+	require.Equal(t, "/etc/secret", (*stored.Customizations.Files)[0].Path)
+
+	// content flagged by the secret scan is redacted regardless
+	plain := Server{}
+	raw, err = plain.sealComposeRequest(cr, true)
+	require.NoError(t, err)
+	require.NotContains(t, string(raw), "file contents")
+}
+
+func TestValidatePackageGroups(t *testing.T) {
+	arch := &distribution.Architecture{
+		Repositories: []distribution.Repository{{Id: "appstream"}},
+		Groups: map[string][]distribution.PackageGroup{
+			"appstream": {{Id: "development", Name: "Development Tools"}},
+		},
+	}
+	cust := &Customizations{
+		Packages: &[]string{"vim", "@development", "@Development Tools"},
+	}
+	require.NoError(t, validatePackageGroups(arch, cust))
+
+	cust.Packages = &[]string{"vim", "@core", "@Server"}
+	err := validatePackageGroups(arch, cust)
+	require.ErrorContains(t, err, "@core, @Server")
+
+	// without metadata composer decides
+	require.NoError(t, validatePackageGroups(&distribution.Architecture{}, cust))
+}
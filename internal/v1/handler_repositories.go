@@ -0,0 +1,47 @@
+package v1
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/common"
+)
+
+func (h *Handlers) GetRepositoriesHealth(ctx echo.Context) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !userID.IsOrgAdmin() {
+		return echo.NewHTTPError(http.StatusForbidden, "Repository health can only be viewed by organization administrators")
+	}
+	if h.server.repoChecker == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Repository checks are not enabled")
+	}
+
+	health := RepositoriesHealth{
+		Data: []RepositoryHealth{},
+	}
+	for _, r := range h.server.repoChecker.Results() {
+		rh := RepositoryHealth{
+			Url:           r.URL,
+			Distributions: r.Distributions,
+			Architecture:  r.Architecture,
+			Reachable:     r.Reachable,
+			CheckedAt:     r.CheckedAt.Format(time.RFC3339),
+		}
+		if r.StatusCode != 0 {
+			rh.StatusCode = common.ToPtr(r.StatusCode)
+		}
+		if r.LastModified != nil {
+			rh.LastModified = common.ToPtr(r.LastModified.UTC().Format(time.RFC3339))
+		}
+		if r.Error != "" {
+			rh.Error = common.ToPtr(r.Error)
+		}
+		health.Data = append(health.Data, rh)
+	}
+	return ctx.JSON(http.StatusOK, health)
+}
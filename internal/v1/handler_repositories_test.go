@@ -0,0 +1,81 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/distribution"
+	"github.com/osbuild/image-builder/internal/repocheck"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestGetRepositoriesHealth(t *testing.T) {
+	repoSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/baseos/repodata/repomd.xml" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer repoSrv.Close()
+
+	distsDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(distsDir, "test-distro"), 0700))
+	distro := fmt.Sprintf(`{
+  "distribution": {"name": "test-distro", "no_package_list": true},
+  "x86_64": {
+    "image_types": ["guest-image"],
+    "repositories": [
+      {"id": "baseos", "baseurl": "%[1]s/baseos"},
+      {"id": "appstream", "baseurl": "%[1]s/appstream"}
+    ]
+  }
+}`, repoSrv.URL)
+	require.NoError(t, os.WriteFile(filepath.Join(distsDir, "test-distro", "test-distro.json"), []byte(distro), 0600))
+	adr, err := distribution.LoadDistroRegistry(distsDir)
+	require.NoError(t, err)
+	checker := repocheck.New(adr, repoSrv.Client())
+	checker.Check(context.Background())
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{}, &ServerConfig{
+		RepoChecker: checker,
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	statusCode, body := tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/admin/repositories", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, statusCode)
+
+	var result RepositoriesHealth
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Len(t, result.Data, 2)
+	require.Equal(t, repoSrv.URL+"/appstream/repodata/repomd.xml", result.Data[0].Url)
+	require.False(t, result.Data[0].Reachable)
+	require.Equal(t, http.StatusNotFound, *result.Data[0].StatusCode)
+	require.NotNil(t, result.Data[0].Error)
+	require.Equal(t, repoSrv.URL+"/baseos/repodata/repomd.xml", result.Data[1].Url)
+	require.True(t, result.Data[1].Reachable)
+	require.Equal(t, []string{"test-distro"}, result.Data[1].Distributions)
+	require.Nil(t, result.Data[1].Error)
+}
+
+func TestGetRepositoriesHealthDisabled(t *testing.T) {
+	srv, tokenSrv := startServer(t, &testServerClientsConf{}, &ServerConfig{})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	statusCode, _ := tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/admin/repositories", &tutils.AuthString0)
+	require.Equal(t, http.StatusNotFound, statusCode)
+}
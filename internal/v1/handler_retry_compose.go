@@ -0,0 +1,53 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/redact"
+)
+
+// RetryCompose resubmits the request of a failed compose, the new compose
+// links to the failed one and belongs to the same blueprint version.
+func (h *Handlers) RetryCompose(ctx echo.Context, composeId openapi_types.UUID) error {
+	composeEntry, err := h.getComposeByIdAndOrgId(ctx, composeId)
+	if err != nil {
+		return err
+	}
+	status, err := h.composeStatus(ctx, composeEntry)
+	if err != nil {
+		return err
+	}
+	if status.ImageStatus.Status != ImageStatusStatusFailure {
+		return echo.NewHTTPError(http.StatusConflict, "Only failed composes can be retried")
+	}
+
+	// redacted secrets would end up in the image as their digests
+	composeRequest := status.Request
+	raw, err := json.Marshal(composeRequest)
+	if err != nil {
+		return err
+	}
+	redacted, err := redact.Redacted(raw, redact.ComposeRequest)
+	if err != nil {
+		return err
+	}
+	if redacted {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "The request of the compose was stored redacted, submit it again instead")
+	}
+	if composeRequest.ClientId == nil {
+		composeRequest.ClientId = common.ToPtr(Api)
+	}
+
+	composeResponse, err := h.handleCommonCompose(ctx, composeRequest, composeEntry.BlueprintVersionId, &composeId)
+	if err != nil {
+		ctx.Logger().Errorf("Failed to retry compose %s: %v", composeId, err)
+		return err
+	}
+	ctx.Logger().Infof("Retried compose %s as %s", composeId, composeResponse.Id)
+	return ctx.JSON(http.StatusCreated, composeResponse)
+}
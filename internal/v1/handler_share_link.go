@@ -0,0 +1,139 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/sharelink"
+)
+
+const (
+	defaultShareLinkLifetime = 24 * time.Hour
+	minShareLinkLifetime     = 5 * time.Minute
+	maxShareLinkLifetime     = 7 * 24 * time.Hour
+)
+
+// CreateComposeShareLink signs a link to the status and logs of the compose,
+// for users to hand to whoever helps them with it.
+func (h *Handlers) CreateComposeShareLink(ctx echo.Context, composeId uuid.UUID) error {
+	if h.server.shareLinks == nil {
+		return echo.NewHTTPError(http.StatusForbidden, "Share links are not available")
+	}
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = h.getComposeByIdAndOrgId(ctx, composeId)
+	if err != nil {
+		return err
+	}
+
+	var request ShareLinkRequest
+	err = ctx.Bind(&request)
+	if err != nil {
+		return err
+	}
+	lifetime := defaultShareLinkLifetime
+	if request.ExpiresIn != nil {
+		lifetime = time.Duration(*request.ExpiresIn) * time.Second
+		if lifetime < minShareLinkLifetime || lifetime > maxShareLinkLifetime {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Share links expire after %s to %s", minShareLinkLifetime, maxShareLinkLifetime))
+		}
+	}
+
+	expiresAt := time.Now().UTC().Add(lifetime).Truncate(time.Second)
+	token, err := h.server.shareLinks.Sign(sharelink.Link{
+		ComposeId: composeId,
+		OrgId:     userID.OrgID(),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+	ctx.Logger().Infof("Sharing compose %s of org %s until %s", composeId, userID.OrgID(), expiresAt)
+
+	majorVersion := strings.Split(h.server.spec.Info.Version, ".")[0]
+	return ctx.JSON(http.StatusCreated, ShareLink{
+		Token:     token,
+		Url:       fmt.Sprintf("%s://%s%s/v%s/shared/%s", ctx.Scheme(), ctx.Request().Host, RoutePrefix(), majorVersion, token),
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+	})
+}
+
+// GetSharedCompose is reachable without authentication, the token is what
+// grants access. The compose request isn't shared, it may hold secrets.
+func (h *Handlers) GetSharedCompose(ctx echo.Context, token string) error {
+	composeEntry, link, err := h.sharedCompose(ctx, token)
+	if err != nil {
+		return err
+	}
+	status, err := h.composeStatus(ctx, composeEntry)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, SharedCompose{
+		Id:          composeEntry.Id,
+		ImageStatus: status.ImageStatus,
+		ExpiresAt:   link.ExpiresAt.Format(time.RFC3339),
+	})
+}
+
+func (h *Handlers) GetSharedComposeLogs(ctx echo.Context, token string) error {
+	composeEntry, _, err := h.sharedCompose(ctx, token)
+	if err != nil {
+		return err
+	}
+	if err := h.server.readOnlyError(); err != nil {
+		return err
+	}
+	cClient, err := h.server.composerFor(composeEntry.Region)
+	if err != nil {
+		return err
+	}
+	resp, err := cClient.ComposeLogs(composeEntry.Id)
+	if err != nil {
+		return err
+	}
+	defer closeBody(ctx, resp.Body)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return echo.NewHTTPError(http.StatusNotFound, string(body))
+	} else if resp.StatusCode != http.StatusOK {
+		httpError := echo.NewHTTPError(http.StatusInternalServerError, "Failed querying compose logs")
+		_ = httpError.SetInternal(fmt.Errorf("%s", body))
+		return httpError
+	}
+	return ctx.JSONBlob(http.StatusOK, body)
+}
+
+// sharedCompose returns the compose a valid share link grants access to,
+// links to deleted composes are as invalid as forged ones.
+func (h *Handlers) sharedCompose(ctx echo.Context, token string) (*db.ComposeEntry, sharelink.Link, error) {
+	if h.server.shareLinks == nil {
+		return nil, sharelink.Link{}, echo.NewHTTPError(http.StatusNotFound, sharelink.ErrInvalidToken)
+	}
+	link, err := h.server.shareLinks.Verify(token, time.Now())
+	if err != nil {
+		return nil, sharelink.Link{}, echo.NewHTTPError(http.StatusNotFound, err)
+	}
+	composeEntry, err := h.server.db.GetCompose(ctx.Request().Context(), link.ComposeId, link.OrgId)
+	if errors.Is(err, db.ComposeNotFoundError) {
+		return nil, sharelink.Link{}, echo.NewHTTPError(http.StatusNotFound, sharelink.ErrInvalidToken)
+	}
+	if err != nil {
+		return nil, sharelink.Link{}, err
+	}
+	return composeEntry, link, nil
+}
@@ -0,0 +1,110 @@
+package v1
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/sharelink"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestShareLink(t *testing.T) {
+	ctx := context.Background()
+	composeId := uuid.New()
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.Equal(t, "Bearer accesstoken", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if strings.HasSuffix(r.URL.Path, "/logs") {
+			_, err := w.Write([]byte(`{"href": "/logs", "id": "log", "kind": "ComposeLogs", "image_builds": ["build log"]}`))
+			require.NoError(t, err)
+			return
+		}
+		err := json.NewEncoder(w).Encode(composer.ComposeStatus{
+			ImageStatus: composer.ImageStatus{
+				Status: composer.ImageStatusValueSuccess,
+			},
+		})
+		require.NoError(t, err)
+	}))
+	defer apiSrv.Close()
+
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	err = dbase.InsertCompose(ctx, composeId, "500000", "user500000@test.test", "000000", nil, json.RawMessage(`{"distribution": "rhel-9", "customizations": {"users": [{"name": "admin", "password": "secret"}]}}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", sharelink.MinKeyLength)))
+	signer, err := sharelink.ParseSigner("test:" + key)
+	require.NoError(t, err)
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
+		DBase:      dbase,
+		ShareLinks: signer,
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	t.Run("lifetime out of bounds", func(t *testing.T) {
+		respStatusCode, _ := tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/share-link", composeId), ShareLinkRequest{
+			ExpiresIn: common.ToPtr(60),
+		})
+		require.Equal(t, http.StatusBadRequest, respStatusCode)
+	})
+
+	t.Run("other org", func(t *testing.T) {
+		other := uuid.New()
+		err = dbase.InsertCompose(ctx, other, "500001", "user500001@test.test", "000001", nil, json.RawMessage(`{"distribution": "rhel-9"}`), nil, nil, nil, nil)
+		require.NoError(t, err)
+		respStatusCode, _ := tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/share-link", other), ShareLinkRequest{})
+		require.Equal(t, http.StatusNotFound, respStatusCode)
+	})
+
+	respStatusCode, body := tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/share-link", composeId), ShareLinkRequest{
+		ExpiresIn: common.ToPtr(3600),
+	})
+	require.Equal(t, http.StatusCreated, respStatusCode)
+	var link ShareLink
+	require.NoError(t, json.Unmarshal([]byte(body), &link))
+	require.Equal(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/shared/%s", link.Token), link.Url)
+
+	// no identity header needed
+	respStatusCode, body = tutils.GetResponseBody(t, link.Url, nil)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var shared SharedCompose
+	require.NoError(t, json.Unmarshal([]byte(body), &shared))
+	require.Equal(t, composeId, shared.Id)
+	require.Equal(t, ImageStatusStatusSuccess, shared.ImageStatus.Status)
+	require.Equal(t, link.ExpiresAt, shared.ExpiresAt)
+	require.NotContains(t, body, "secret")
+
+	respStatusCode, body = tutils.GetResponseBody(t, link.Url+"/logs", nil)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.Contains(t, body, "build log")
+
+	respStatusCode, _ = tutils.GetResponseBody(t, link.Url+"x", nil)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+
+	// deleting the compose revokes its links
+	respStatusCode, _ = tutils.DeleteResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s", composeId))
+	require.Equal(t, http.StatusOK, respStatusCode)
+	respStatusCode, _ = tutils.GetResponseBody(t, link.Url, nil)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+}
@@ -0,0 +1,239 @@
+package v1
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/redact"
+)
+
+// supportBundleCompose is the compose record as it ends up in the bundle.
+type supportBundleCompose struct {
+	Id        uuid.UUID       `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	ImageName *string         `json:"image_name,omitempty"`
+	ClientId  *string         `json:"client_id,omitempty"`
+	Status    *string         `json:"status,omitempty"`
+	ErrorCode *string         `json:"error_code,omitempty"`
+	Region    *string         `json:"region,omitempty"`
+	Request   json.RawMessage `json:"request"`
+}
+
+// supportBundleConfig is the part of the configuration which can help
+// debugging, nothing secret belongs in here.
+type supportBundleConfig struct {
+	Version             string   `json:"version"`
+	BuildCommit         string   `json:"build_commit"`
+	BuildTime           string   `json:"build_time"`
+	AWSRegion           string   `json:"aws_region"`
+	GCPRegion           string   `json:"gcp_region"`
+	GCPBucket           string   `json:"gcp_bucket"`
+	FedoraAuth          bool     `json:"fedora_auth"`
+	RequestEncryption   bool     `json:"request_encryption"`
+	RedactRequests      bool     `json:"redact_requests"`
+	ObjectStorage       bool     `json:"object_storage"`
+	AvailableDistros    []string `json:"available_distributions"`
+	RestrictedDistros   []string `json:"restricted_distributions"`
+	ContentSourcesURL   string   `json:"content_sources_url"`
+	QuotaFileConfigured bool     `json:"quota_file_configured"`
+	Region              string   `json:"region,omitempty"`
+}
+
+func (h *Handlers) CreateSupportBundle(ctx echo.Context, composeId uuid.UUID) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !userID.IsOrgAdmin() {
+		return echo.NewHTTPError(http.StatusForbidden, "Support bundles can only be generated by organization administrators")
+	}
+
+	composeEntry, err := h.getComposeByIdAndOrgId(ctx, composeId)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	now := time.Now().UTC()
+	prefix := fmt.Sprintf("support-bundle-%s/", composeId)
+	add := func(name string, data []byte) error {
+		err := tw.WriteHeader(&tar.Header{
+			Name:    prefix + name,
+			Mode:    0600,
+			Size:    int64(len(data)),
+			ModTime: now,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	}
+
+	compose, err := h.supportBundleCompose(composeEntry)
+	if err != nil {
+		return err
+	}
+	history, err := h.server.db.GetComposeHistory(ctx.Request().Context(), composeId, userID.OrgID())
+	if err != nil {
+		return err
+	}
+	if history == nil {
+		history = []db.ComposeEventEntry{}
+	}
+
+	files := []struct {
+		name string
+		v    interface{}
+	}{
+		{"compose.json", compose},
+		{"history.json", history},
+		{"config.json", h.supportBundleConfig(ctx)},
+	}
+	for _, f := range files {
+		data, err := json.MarshalIndent(f.v, "", "  ")
+		if err != nil {
+			return err
+		}
+		err = add(f.name, data)
+		if err != nil {
+			return err
+		}
+	}
+
+	cClient, err := h.server.composerFor(composeEntry.Region)
+	if err != nil {
+		return err
+	}
+	// the status holds the unsigned links to the uploaded images
+	composerFiles := []struct {
+		name  string
+		fetch func(uuid.UUID) (*http.Response, error)
+		rules []redact.Rule
+	}{
+		{"composer/status.json", cClient.ComposeStatus, redact.ComposerResponse},
+		{"composer/metadata.json", cClient.ComposeMetadata, nil},
+		{"composer/logs.json", cClient.ComposeLogs, nil},
+	}
+	for _, f := range composerFiles {
+		name, data := h.supportBundleComposerFile(ctx, composeId, f.name, f.fetch, f.rules)
+		err = add(name, data)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return err
+	}
+	err = gz.Close()
+	if err != nil {
+		return err
+	}
+
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"support-bundle-%s.tar.gz\"", composeId))
+	return ctx.Blob(http.StatusOK, "application/gzip", buf.Bytes())
+}
+
+// supportBundleCompose decrypts the stored request and redacts its secrets,
+// bundles get passed around and must never contain them.
+func (h *Handlers) supportBundleCompose(composeEntry *db.ComposeEntry) (*supportBundleCompose, error) {
+	var composeRequest ComposeRequest
+	err := h.server.openComposeRequest(composeEntry.Request, &composeRequest)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(composeRequest)
+	if err != nil {
+		return nil, err
+	}
+	raw, err = redact.JSON(raw, redact.ComposeRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &supportBundleCompose{
+		Id:        composeEntry.Id,
+		CreatedAt: composeEntry.CreatedAt,
+		ImageName: composeEntry.ImageName,
+		ClientId:  composeEntry.ClientId,
+		Status:    composeEntry.Status,
+		ErrorCode: composeEntry.ErrorCode,
+		Region:    composeEntry.Region,
+		Request:   raw,
+	}, nil
+}
+
+func (h *Handlers) supportBundleConfig(ctx echo.Context) supportBundleConfig {
+	conf := supportBundleConfig{
+		Version:             h.server.spec.Info.Version,
+		BuildCommit:         common.BuildCommit,
+		BuildTime:           common.BuildTime,
+		AWSRegion:           h.server.aws.Region,
+		GCPRegion:           h.server.gcp.Region,
+		GCPBucket:           h.server.gcp.Bucket,
+		FedoraAuth:          h.server.fedoraAuth,
+		RequestEncryption:   h.server.keyring != nil,
+		RedactRequests:      h.server.redactRequests,
+		ObjectStorage:       h.server.storage != nil,
+		AvailableDistros:    []string{},
+		RestrictedDistros:   []string{},
+		ContentSourcesURL:   h.server.csReposURL.String(),
+		QuotaFileConfigured: h.server.quotaFile != "",
+		Region:              h.server.region,
+	}
+	if h.server.allDistros != nil {
+		for _, d := range h.server.distroRegistry(ctx).List() {
+			conf.AvailableDistros = append(conf.AvailableDistros, d.Distribution.Name)
+			if d.IsRestricted() {
+				conf.RestrictedDistros = append(conf.RestrictedDistros, d.Distribution.Name)
+			}
+		}
+	}
+	return conf
+}
+
+// supportBundleComposerFile fetches what composer knows about the compose
+// with the values the rules select redacted, if that fails the bundle
+// contains the reason instead, it's still useful without.
+func (h *Handlers) supportBundleComposerFile(ctx echo.Context, composeId uuid.UUID, name string, fetch func(uuid.UUID) (*http.Response, error), rules []redact.Rule) (string, []byte) {
+	if err := h.server.readOnlyError(); err != nil {
+		return name + ".error", []byte(err.Error())
+	}
+	resp, err := fetch(composeId)
+	if err != nil {
+		return name + ".error", []byte(err.Error())
+	}
+	defer closeBody(ctx, resp.Body)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return name + ".error", []byte(err.Error())
+	}
+	if resp.StatusCode != http.StatusOK {
+		return name + ".error", []byte(fmt.Sprintf("composer returned %d: %s", resp.StatusCode, body))
+	}
+	if len(rules) == 0 {
+		return name, body
+	}
+	redacted, err := redact.JSON(body, rules)
+	if err != nil {
+		// don't risk bundling what couldn't be redacted
+		return name + ".error", []byte(fmt.Sprintf("unable to redact: %v", err))
+	}
+	return name, redacted
+}
@@ -0,0 +1,97 @@
+package v1
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestCreateSupportBundle(t *testing.T) {
+	ctx := context.Background()
+	composeId := uuid.New()
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/logs") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write([]byte(`{"image_status": {"status": "failure", "upload_status": {"status": "success", "type": "aws.s3", "options": {"url": "https://bucket.example.com/unsigned-image"}}}}`))
+		require.NoError(t, err)
+	}))
+	defer apiSrv.Close()
+
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	cr := ComposeRequest{
+		Distribution: "rhel-9",
+		Customizations: &Customizations{
+			Subscription: &Subscription{
+				ActivationKey: "secret-activation-key",
+			},
+		},
+	}
+	crRaw, err := json.Marshal(cr)
+	require.NoError(t, err)
+	err = dbase.InsertCompose(ctx, composeId, "000000", "user000000@test.test", "000000", cr.ImageName, crRaw, (*string)(cr.ClientId), nil, nil, nil)
+	require.NoError(t, err)
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
+		DBase:            dbase,
+		DistributionsDir: "../../distributions",
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	statusCode, _ := tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/admin/support-bundle/%s", uuid.New()), nil)
+	require.Equal(t, http.StatusNotFound, statusCode)
+
+	statusCode, body := tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/admin/support-bundle/%s", composeId), nil)
+	require.Equal(t, http.StatusOK, statusCode)
+
+	gz, err := gzip.NewReader(bytes.NewReader([]byte(body)))
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		files[strings.TrimPrefix(hdr.Name, fmt.Sprintf("support-bundle-%s/", composeId))] = string(data)
+	}
+
+	require.Contains(t, files, "compose.json")
+	require.Contains(t, files["compose.json"], composeId.String())
+	require.NotContains(t, files["compose.json"], "secret-activation-key")
+	require.Contains(t, files, "history.json")
+	require.Contains(t, files["history.json"], "compose.created")
+	require.Contains(t, files, "config.json")
+	require.Contains(t, files["composer/status.json"], "failure")
+	require.NotContains(t, files["composer/status.json"], "unsigned-image")
+	require.Contains(t, files, "composer/metadata.json")
+	require.Contains(t, files["composer/logs.json.error"], "404")
+}
@@ -21,6 +21,9 @@ import (
 	"github.com/osbuild/image-builder/internal/clients/composer"
 	"github.com/osbuild/image-builder/internal/clients/provisioning"
 	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/readonly"
+	"github.com/osbuild/image-builder/internal/storage"
 	"github.com/osbuild/image-builder/internal/tutils"
 )
 
@@ -178,7 +181,7 @@ func TestGetComposeMetadata(t *testing.T) {
 	require.NoError(t, err)
 	imageName := "MyImageName"
 	clientId := "ui"
-	err = dbase.InsertCompose(ctx, id, "500000", "user500000@test.test", "000000", &imageName, json.RawMessage("{}"), &clientId, nil)
+	err = dbase.InsertCompose(ctx, id, "500000", "user500000@test.test", "000000", &imageName, json.RawMessage("{}"), &clientId, nil, nil, nil)
 	require.NoError(t, err)
 
 	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
@@ -200,6 +203,108 @@ func TestGetComposeMetadata(t *testing.T) {
 	err = json.Unmarshal([]byte(body), &result)
 	require.NoError(t, err)
 	require.Equal(t, *result.Packages, testPackages)
+
+	var pinned ComposeMetadata
+	require.NoError(t, json.Unmarshal([]byte(body), &pinned))
+	require.Equal(t, []string{
+		"NameTest2-EpochTest2:VersionTest2-ReleaseTest2.ArchTest2",
+		"NameTest1-EpochTest1:VersionTest1-ReleaseTest1.ArchTest1",
+	}, *pinned.PinnedPackages)
+}
+
+func TestPinnedPackages(t *testing.T) {
+	require.Equal(t, []string{
+		"bash-5.1.8-6.el9.x86_64",
+		"dbus-1:1.12.20-8.el9.x86_64",
+		"tzdata-2024a-1.el9.noarch",
+	}, pinnedPackages([]PackageMetadata{
+		{Name: "bash", Version: "5.1.8", Release: "6.el9", Arch: "x86_64"},
+		{Name: "dbus", Epoch: common.ToPtr("1"), Version: "1.12.20", Release: "8.el9", Arch: "x86_64"},
+		{Name: "tzdata", Epoch: common.ToPtr("0"), Version: "2024a", Release: "1.el9", Arch: "noarch"},
+	}))
+}
+
+func TestGetComposeMetadataStored(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+	testPackages := []composer.PackageMetadata{
+		{
+			Arch:    "ArchTest1",
+			Name:    "NameTest1",
+			Release: "ReleaseTest1",
+			Sigmd5:  "Sigmd5Test1",
+			Type:    "TypeTest1",
+			Version: "VersionTest1",
+		},
+	}
+	composerCalls := 0
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		composerCalls += 1
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(composer.ComposeMetadata{
+			Packages: &testPackages,
+		})
+		require.NoError(t, err)
+	}))
+	defer apiSrv.Close()
+
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	err = dbase.InsertCompose(ctx, id, "500000", "user500000@test.test", "000000", nil, json.RawMessage("{}"), nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	blobStorage, err := storage.NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
+		DBase:            dbase,
+		DistributionsDir: "../../distributions",
+		Storage:          blobStorage,
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	for i := 0; i < 2; i++ {
+		respStatusCode, body := tutils.GetResponseBody(t,
+			fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/metadata", id), &tutils.AuthString0)
+		require.Equal(t, http.StatusOK, respStatusCode)
+		var result ComposeMetadata
+		require.NoError(t, json.Unmarshal([]byte(body), &result))
+		require.Len(t, *result.Packages, 1)
+		require.Equal(t, "NameTest1", (*result.Packages)[0].Name)
+	}
+	// the second request was served from the snapshot
+	require.Equal(t, 1, composerCalls)
+
+	snapshot, err := dbase.GetComposeMetadata(ctx, id, "000000")
+	require.NoError(t, err)
+	require.Contains(t, string(snapshot), "NameTest1")
+
+	// other orgs can't see it
+	_, err = dbase.GetComposeMetadata(ctx, id, "000001")
+	require.ErrorIs(t, err, db.ComposeMetadataNotFoundError)
+
+	// metadata kept in storage before the snapshots is still served from there
+	kept := uuid.New()
+	err = dbase.InsertCompose(ctx, kept, "500000", "user500000@test.test", "000000", nil, json.RawMessage("{}"), nil, nil, nil, nil)
+	require.NoError(t, err)
+	data := []byte(`{"packages": [{"arch": "noarch", "name": "kept", "release": "1", "sigmd5": "", "type": "rpm", "version": "1"}]}`)
+	require.NoError(t, blobStorage.Put(ctx, storage.ComposeKey(kept, "metadata"), data))
+	require.NoError(t, dbase.InsertComposeBlob(ctx, kept, "metadata", storage.ComposeKey(kept, "metadata"), int64(len(data))))
+	respStatusCode, body := tutils.GetResponseBody(t,
+		fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/metadata", kept), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var result ComposeMetadata
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Equal(t, []string{"kept-1-1.noarch"}, *result.PinnedPackages)
+	require.Equal(t, 1, composerCalls)
 }
 
 func TestGetComposeMetadata404(t *testing.T) {
@@ -262,11 +367,11 @@ func TestGetComposes(t *testing.T) {
 
 	imageName := "MyImageName"
 	clientId := "ui"
-	err = dbase.InsertCompose(ctx, id, "500000", "user500000@test.test", "000000", &imageName, json.RawMessage("{}"), &clientId, nil)
+	err = dbase.InsertCompose(ctx, id, "500000", "user500000@test.test", "000000", &imageName, json.RawMessage("{}"), &clientId, nil, nil, nil)
 	require.NoError(t, err)
-	err = dbase.InsertCompose(ctx, id2, "500000", "user500000@test.test", "000000", &imageName, json.RawMessage("{}"), &clientId, nil)
+	err = dbase.InsertCompose(ctx, id2, "500000", "user500000@test.test", "000000", &imageName, json.RawMessage("{}"), &clientId, nil, nil, nil)
 	require.NoError(t, err)
-	err = dbase.InsertCompose(ctx, id3, "500000", "user500000@test.test", "000000", &imageName, json.RawMessage("{}"), &clientId, nil)
+	err = dbase.InsertCompose(ctx, id3, "500000", "user500000@test.test", "000000", &imageName, json.RawMessage("{}"), &clientId, nil, nil, nil)
 	require.NoError(t, err)
 
 	composeEntry, err := dbase.GetCompose(ctx, id, "000000")
@@ -287,14 +392,14 @@ func TestGetComposes(t *testing.T) {
 
 	bpId := uuid.New()
 	versionId := uuid.New()
-	err = dbase.InsertBlueprint(ctx, bpId, versionId, "000000", "500000", "bpName", "desc", json.RawMessage("{}"), json.RawMessage("{}"))
+	err = dbase.InsertBlueprint(ctx, bpId, versionId, "000000", "500000", "bpName", "desc", json.RawMessage("{}"), json.RawMessage("{}"), db.BlueprintOptions{})
 	require.NoError(t, err)
 
-	err = dbase.InsertCompose(ctx, id4, "500000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "edge-installer"}]}`), &clientId, &versionId)
+	err = dbase.InsertCompose(ctx, id4, "500000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "edge-installer"}]}`), &clientId, &versionId, nil, nil)
 	require.NoError(t, err)
-	err = dbase.InsertCompose(ctx, id5, "500000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), &clientId, &versionId)
+	err = dbase.InsertCompose(ctx, id5, "500000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), &clientId, &versionId, nil, nil)
 	require.NoError(t, err)
-	err = dbase.InsertCompose(ctx, id6, "500000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "edge-commit"}]}`), &clientId, &versionId)
+	err = dbase.InsertCompose(ctx, id6, "500000", "user100000@test.test", "000000", &imageName, json.RawMessage(`{"image_requests": [{"image_type": "edge-commit"}]}`), &clientId, &versionId, nil, nil)
 	require.NoError(t, err)
 
 	respStatusCode, body = tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/composes?ignoreImageTypes=edge-installer&ignoreImageTypes=aws", &tutils.AuthString0)
@@ -307,6 +412,80 @@ func TestGetComposes(t *testing.T) {
 	require.Equal(t, 1, result.Meta.Count)
 	require.Equal(t, bpId, *result.Data[0].BlueprintId)
 	require.Equal(t, 1, *result.Data[0].BlueprintVersion)
+
+	// iterating with cursors neither skips nor repeats composes created meanwhile
+	seen := map[uuid.UUID]bool{}
+	next := common.ToPtr("/api/image-builder/v1/composes?limit=2")
+	for next != nil {
+		var page ComposesResponse
+		respStatusCode, body = tutils.GetResponseBody(t, "http://localhost:8086"+*next, &tutils.AuthString0)
+		require.Equal(t, http.StatusOK, respStatusCode)
+		err = json.Unmarshal([]byte(body), &page)
+		require.NoError(t, err)
+		for _, c := range page.Data {
+			require.False(t, seen[c.Id])
+			seen[c.Id] = true
+		}
+		if len(seen) == 2 {
+			err = dbase.InsertCompose(ctx, uuid.New(), "500000", "user500000@test.test", "000000", &imageName, json.RawMessage("{}"), &clientId, nil, nil, nil)
+			require.NoError(t, err)
+		}
+		next = page.Links.Next
+	}
+	require.Len(t, seen, 6)
+
+	respStatusCode, _ = tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/composes?cursor=garbage", &tutils.AuthString0)
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+
+	respStatusCode, body = tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/composes?fields=id,image_name&limit=1", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var projected struct {
+		Data []map[string]interface{} `json:"data"`
+		Meta ListResponseMeta         `json:"meta"`
+	}
+	err = json.Unmarshal([]byte(body), &projected)
+	require.NoError(t, err)
+	require.Equal(t, 7, projected.Meta.Count)
+	require.Len(t, projected.Data, 1)
+	require.Len(t, projected.Data[0], 2)
+	require.Equal(t, imageName, projected.Data[0]["image_name"])
+	require.Contains(t, projected.Data[0], "id")
+
+	// composes without a recorded status leave it out
+	respStatusCode, body = tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/composes?fields=id,status&limit=1", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	projected.Data = nil
+	require.NoError(t, json.Unmarshal([]byte(body), &projected))
+	require.Len(t, projected.Data, 1)
+	require.Len(t, projected.Data[0], 1)
+	require.Contains(t, projected.Data[0], "id")
+
+	respStatusCode, _ = tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/composes?fields=id,error_code", &tutils.AuthString0)
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+}
+
+func TestProjectComposesResponseItem(t *testing.T) {
+	item := ComposesResponseItem{
+		Id:        uuid.New(),
+		CreatedAt: "2024-05-01T10:20:30Z",
+		ImageName: common.ToPtr("name"),
+		Request: ComposeRequest{
+			Distribution: "rhel-9",
+		},
+		Status: common.ToPtr("success"),
+	}
+	require.Equal(t, ComposesProjectedItem{
+		Id:        &item.Id,
+		CreatedAt: &item.CreatedAt,
+		ImageName: item.ImageName,
+		Status:    item.Status,
+	}, projectComposesResponseItem(item, []GetComposesParamsFields{
+		GetComposesParamsFieldsId,
+		GetComposesParamsFieldsCreatedAt,
+		GetComposesParamsFieldsImageName,
+		GetComposesParamsFieldsStatus,
+	}))
+	require.Empty(t, projectComposesResponseItem(item, []GetComposesParamsFields{}))
 }
 
 // TestBuildOSTreeOptions checks if the buildOSTreeOptions utility function
@@ -377,6 +556,337 @@ func TestReadinessProbeReady(t *testing.T) {
 	require.Contains(t, body, "{\"readiness\":\"ready\"}")
 }
 
+func TestReadOnlyMode(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	crRaw, err := json.Marshal(ComposeRequest{
+		Distribution: "rhel-9",
+	})
+	require.NoError(t, err)
+	finishedId := uuid.New()
+	err = dbase.InsertCompose(ctx, finishedId, "000000", "user000000@test.test", "000000", nil, crRaw, nil, nil, nil, nil)
+	require.NoError(t, err)
+	err = dbase.SetComposeStatus(ctx, finishedId, string(ImageStatusStatusSuccess), nil)
+	require.NoError(t, err)
+	runningId := uuid.New()
+	err = dbase.InsertCompose(ctx, runningId, "000000", "user000000@test.test", "000000", nil, crRaw, nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	// composer is unreachable
+	srv, tokenSrv := startServer(t, &testServerClientsConf{}, &ServerConfig{
+		DBase:    dbase,
+		ReadOnly: readonly.New(true, "composer failover"),
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	respStatusCode, body := tutils.GetResponseBody(t, "http://localhost:8086/ready", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.Contains(t, body, "{\"readiness\":\"read-only\",\"reason\":\"composer failover\"}")
+
+	for id, status := range map[uuid.UUID]ImageStatusStatus{
+		finishedId: ImageStatusStatusSuccess,
+		runningId:  ImageStatusStatusPending,
+	} {
+		respStatusCode, body = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s", id), &tutils.AuthString0)
+		require.Equal(t, http.StatusOK, respStatusCode)
+		var result ComposeStatus
+		require.NoError(t, json.Unmarshal([]byte(body), &result))
+		require.Equal(t, status, result.ImageStatus.Status)
+	}
+
+	respStatusCode, body = tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/compose", ComposeRequest{
+		Distribution: "rhel-9",
+	})
+	require.Equal(t, http.StatusServiceUnavailable, respStatusCode)
+	require.Contains(t, body, "image-builder is in read-only mode: composer failover")
+
+	respStatusCode, _ = tutils.DeleteResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s", finishedId))
+	require.Equal(t, http.StatusServiceUnavailable, respStatusCode)
+}
+
+func TestDeleteCompose(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+
+	var deleted []string
+	composerStatus := http.StatusOK
+	composerBody := ""
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.Equal(t, "Bearer accesstoken", r.Header.Get("Authorization"))
+		require.Equal(t, http.MethodDelete, r.Method)
+		deleted = append(deleted, r.URL.Path)
+		w.WriteHeader(composerStatus)
+		_, err := w.Write([]byte(composerBody))
+		require.NoError(t, err)
+	}))
+	defer apiSrv.Close()
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
+		DBase: dbase,
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	var ids []uuid.UUID
+	for i := 0; i < 2; i++ {
+		id := uuid.New()
+		err = dbase.InsertCompose(ctx, id, "500000", "user000000@test.test", "000000", nil, json.RawMessage(`{"distribution": "rhel-9"}`), nil, nil, nil, nil)
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	respStatusCode, _ := tutils.DeleteResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s", ids[0]))
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.Empty(t, deleted)
+	respStatusCode, _ = tutils.DeleteResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s", ids[0]))
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+
+	// composer failing keeps the compose
+	composerStatus = http.StatusInternalServerError
+	respStatusCode, _ = tutils.DeleteResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s?delete_artifacts=true", ids[1]))
+	require.Equal(t, http.StatusInternalServerError, respStatusCode)
+	respStatusCode, body := tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/composes", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var result ComposesResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Len(t, result.Data, 1)
+	require.Equal(t, ids[1], result.Data[0].Id)
+
+	// so does a composer which can't delete composes
+	composerStatus = http.StatusNotFound
+	composerBody = "404 page not found"
+	respStatusCode, _ = tutils.DeleteResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s?delete_artifacts=true", ids[1]))
+	require.Equal(t, http.StatusInternalServerError, respStatusCode)
+
+	// a compose composer doesn't know anymore is gone already
+	composerBody = `{"code": "IMAGE-BUILDER-COMPOSER-15", "reason": "Compose with given id not found"}`
+	respStatusCode, _ = tutils.DeleteResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s?delete_artifacts=true", ids[1]))
+	require.Equal(t, http.StatusOK, respStatusCode)
+	path := fmt.Sprintf("/api/image-builder-composer/v2/composes/%s", ids[1])
+	require.Equal(t, []string{path, path, path}, deleted)
+	respStatusCode, body = tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/composes", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Empty(t, result.Data)
+}
+
+func TestUpdateCompose(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{}, &ServerConfig{
+		DBase: dbase,
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	id := uuid.New()
+	err = dbase.InsertCompose(ctx, id, "500000", "user000000@test.test", "000000", common.ToPtr("original"), json.RawMessage(`{"distribution": "rhel-9", "image_name": "original"}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+	url := fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s", id)
+
+	listed := func() ComposesResponseItem {
+		respStatusCode, body := tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/composes", &tutils.AuthString0)
+		require.Equal(t, http.StatusOK, respStatusCode)
+		var result ComposesResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &result))
+		require.Len(t, result.Data, 1)
+		return result.Data[0]
+	}
+
+	respStatusCode, _, _ := tutils.PatchResponse(t, url, "application/json", `{"image_name": "renamed", "description": "web servers"}`, "")
+	require.Equal(t, http.StatusNoContent, respStatusCode)
+	item := listed()
+	require.Equal(t, "renamed", *item.ImageName)
+	require.Equal(t, "web servers", *item.Description)
+	// the request keeps the name the image was built with
+	require.Equal(t, "original", *item.Request.ImageName)
+
+	// left out fields are kept, empty ones cleared
+	respStatusCode, _, _ = tutils.PatchResponse(t, url, "application/json", `{"description": ""}`, "")
+	require.Equal(t, http.StatusNoContent, respStatusCode)
+	item = listed()
+	require.Equal(t, "renamed", *item.ImageName)
+	require.Nil(t, item.Description)
+
+	respStatusCode, _, _ = tutils.PatchResponse(t, url, "application/json", `{"name": "unknown field"}`, "")
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+	respStatusCode, _, _ = tutils.PatchResponse(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s", uuid.New()), "application/json", `{"image_name": "renamed"}`, "")
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+}
+
+func TestCancelCompose(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+
+	var cancelled []string
+	composerStatus := http.StatusOK
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.Equal(t, "Bearer accesstoken", r.Header.Get("Authorization"))
+		require.Equal(t, http.MethodPost, r.Method)
+		cancelled = append(cancelled, r.URL.Path)
+		w.WriteHeader(composerStatus)
+	}))
+	defer apiSrv.Close()
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
+		DBase: dbase,
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	var ids []uuid.UUID
+	for i := 0; i < 3; i++ {
+		id := uuid.New()
+		err = dbase.InsertCompose(ctx, id, "500000", "user000000@test.test", "000000", nil, json.RawMessage(`{"distribution": "rhel-9", "image_requests": [{"architecture": "x86_64", "image_type": "guest-image", "upload_request": {"type": "aws.s3", "options": {}}}]}`), nil, nil, nil, nil)
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	err = dbase.SetComposeStatus(ctx, ids[2], "success", nil)
+	require.NoError(t, err)
+
+	respStatusCode, body := tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/cancel", ids[0]), nil)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var result ComposeStatus
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Equal(t, ImageStatusStatusFailure, result.ImageStatus.Status)
+	require.Equal(t, ErrorCodeCancelled, *result.ImageStatus.Error.Code)
+	require.Equal(t, []string{fmt.Sprintf("/api/image-builder-composer/v2/composes/%s/cancel", ids[0])}, cancelled)
+
+	// the status sticks without asking composer
+	respStatusCode, body = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s", ids[0]), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Equal(t, ErrorCodeCancelled, *result.ImageStatus.Error.Code)
+	require.Len(t, cancelled, 1)
+
+	respStatusCode, _ = tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/cancel", ids[0]), nil)
+	require.Equal(t, http.StatusConflict, respStatusCode)
+	respStatusCode, _ = tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/cancel", ids[2]), nil)
+	require.Equal(t, http.StatusConflict, respStatusCode)
+	require.Len(t, cancelled, 1)
+
+	// composer failing leaves the compose be
+	composerStatus = http.StatusInternalServerError
+	respStatusCode, _ = tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/cancel", ids[1]), nil)
+	require.Equal(t, http.StatusInternalServerError, respStatusCode)
+	entry, err := dbase.GetCompose(ctx, ids[1], "000000")
+	require.NoError(t, err)
+	require.Nil(t, entry.ErrorCode)
+
+	respStatusCode, _ = tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/cancel", uuid.New()), nil)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+}
+
+func TestRetryCompose(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+
+	retryId := uuid.New()
+	var composed []composer.ComposeRequest
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.Equal(t, "Bearer accesstoken", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			var cr composer.ComposeRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&cr))
+			composed = append(composed, cr)
+			w.WriteHeader(http.StatusCreated)
+			require.NoError(t, json.NewEncoder(w).Encode(composer.ComposeId{Id: retryId}))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		require.NoError(t, json.NewEncoder(w).Encode(composer.ComposeStatus{
+			ImageStatus: composer.ImageStatus{
+				Status: composer.ImageStatusValueBuilding,
+			},
+		}))
+	}))
+	defer apiSrv.Close()
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
+		DBase:            dbase,
+		DistributionsDir: "../../distributions",
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	failed := uuid.New()
+	err = dbase.InsertCompose(ctx, failed, "500000", "user000000@test.test", "000000", common.ToPtr("retried"), json.RawMessage(`{"distribution": "rhel-9", "image_name": "retried", "client_id": "ui", "image_requests": [{"architecture": "x86_64", "image_type": "guest-image", "upload_request": {"type": "aws.s3", "options": {}}}]}`), common.ToPtr("ui"), nil, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, dbase.SetComposeStatus(ctx, failed, "failure", common.ToPtr(ErrorCodeCancelled)))
+
+	building := uuid.New()
+	err = dbase.InsertCompose(ctx, building, "500000", "user000000@test.test", "000000", nil, json.RawMessage(`{"distribution": "rhel-9", "image_requests": [{"architecture": "x86_64", "image_type": "guest-image", "upload_request": {"type": "aws.s3", "options": {}}}]}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	redacted := uuid.New()
+	err = dbase.InsertCompose(ctx, redacted, "500000", "user000000@test.test", "000000", nil, json.RawMessage(`{"distribution": "rhel-9", "customizations": {"users": [{"name": "admin", "password": "redacted:sha256:abc"}]}, "image_requests": [{"architecture": "x86_64", "image_type": "guest-image", "upload_request": {"type": "aws.s3", "options": {}}}]}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, dbase.SetComposeStatus(ctx, redacted, "failure", common.ToPtr(ErrorCodeCancelled)))
+
+	respStatusCode, _ := tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/retry", building), nil)
+	require.Equal(t, http.StatusConflict, respStatusCode)
+	respStatusCode, _ = tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/retry", redacted), nil)
+	require.Equal(t, http.StatusUnprocessableEntity, respStatusCode)
+	respStatusCode, _ = tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/retry", uuid.New()), nil)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+	require.Empty(t, composed)
+
+	respStatusCode, body := tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/retry", failed), nil)
+	require.Equal(t, http.StatusCreated, respStatusCode)
+	var result ComposeResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Equal(t, retryId, result.Id)
+	require.Len(t, composed, 1)
+	require.Equal(t, "rhel-9", composed[0].Distribution)
+
+	entry, err := dbase.GetCompose(ctx, retryId, "000000")
+	require.NoError(t, err)
+	require.Equal(t, failed, *entry.ParentComposeId)
+	require.Equal(t, "retried", *entry.ImageName)
+	require.Equal(t, "ui", *entry.ClientId)
+
+	respStatusCode, body = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s", retryId), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var status ComposeStatus
+	require.NoError(t, json.Unmarshal([]byte(body), &status))
+	require.Equal(t, failed, *status.ParentComposeId)
+}
+
 func TestMetrics(t *testing.T) {
 	srv, tokenSrv := startServer(t, &testServerClientsConf{}, nil)
 	defer func() {
@@ -391,11 +901,54 @@ func TestMetrics(t *testing.T) {
 	require.Contains(t, body, "image_builder_crc_compose_errors")
 }
 
+func TestMetricsAuth(t *testing.T) {
+	srv, tokenSrv := startServer(t, &testServerClientsConf{}, &ServerConfig{
+		MetricsToken: "scraper-token",
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	scrape := func(path, token string) (int, string) {
+		req, err := http.NewRequest(http.MethodGet, "http://localhost:8086"+path, nil)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	for _, path := range []string{"/metrics", "/metrics/runtime"} {
+		respStatusCode, _ := scrape(path, "")
+		require.Equal(t, http.StatusUnauthorized, respStatusCode)
+		respStatusCode, _ = scrape(path, "wrong-token")
+		require.Equal(t, http.StatusUnauthorized, respStatusCode)
+	}
+
+	respStatusCode, body := scrape("/metrics", "scraper-token")
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.Contains(t, body, "image_builder_crc_compose_requests_total")
+	require.NotContains(t, body, "go_goroutines")
+
+	respStatusCode, body = scrape("/metrics/runtime", "scraper-token")
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.Contains(t, body, "go_goroutines")
+	require.NotContains(t, body, "image_builder_crc")
+}
+
 func TestGetClones(t *testing.T) {
 	ctx := context.Background()
 	id := uuid.New()
 	cloneId := uuid.New()
 	awsAccountId := "123456123456"
+	statusRequests := 0
 
 	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if "Bearer" == r.Header.Get("Authorization") {
@@ -403,6 +956,23 @@ func TestGetClones(t *testing.T) {
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			require.True(t, strings.HasSuffix(r.URL.Path, fmt.Sprintf("/clones/%v", cloneId)))
+			statusRequests++
+			var uo composer.CloneStatus_Options
+			require.NoError(t, uo.FromAWSEC2UploadStatus(composer.AWSEC2UploadStatus{
+				Ami:    "ami-1",
+				Region: "us-east-2",
+			}))
+			w.WriteHeader(http.StatusOK)
+			err := json.NewEncoder(w).Encode(composer.CloneStatus{
+				Options: uo,
+				Status:  composer.Success,
+				Type:    composer.UploadTypesAws,
+			})
+			require.NoError(t, err)
+			return
+		}
 		w.WriteHeader(http.StatusCreated)
 
 		var cloneReq composer.AWSEC2CloneCompose
@@ -445,7 +1015,7 @@ func TestGetClones(t *testing.T) {
       "image_type": "aws"
     }
   ]
-}`), nil, nil)
+}`), nil, nil, nil, nil)
 	require.NoError(t, err)
 	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL, ProvURL: provSrv.URL}, &ServerConfig{
 		DBase:            dbase,
@@ -464,9 +1034,10 @@ func TestGetClones(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 0, len(csResp.Data))
 	require.Contains(t, body, "\"data\":[]")
+	require.Equal(t, ClonesSummary{}, csResp.Summary)
 
 	cloneReq := AWSEC2Clone{
-		Region:           "us-east-2",
+		Region:           common.ToPtr("us-east-2"),
 		ShareWithSources: &[]string{"1"},
 	}
 	respStatusCode, body = tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/clone", id), cloneReq)
@@ -483,12 +1054,27 @@ func TestGetClones(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 1, len(csResp.Data))
 	require.Equal(t, cloneId, csResp.Data[0].Id)
+	require.Equal(t, CloneStatusResponseStatusSuccess, csResp.Data[0].Status.Status)
+	require.Equal(t, ClonesSummary{Success: 1}, csResp.Summary)
 
 	cloneReqExp, err := json.Marshal(cloneReq)
 	require.NoError(t, err)
 	cloneReqRecv, err := json.Marshal(csResp.Data[0].Request)
 	require.NoError(t, err)
 	require.Equal(t, cloneReqExp, cloneReqRecv)
+
+	// the final status was recorded, composer isn't asked again
+	respStatusCode, body = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/clones", id), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var cached ClonesResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &cached))
+	require.Equal(t, csResp.Data[0].Status, cached.Data[0].Status)
+	respStatusCode, body = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/clones/%s", cloneId), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var cloneStatus CloneStatusResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &cloneStatus))
+	require.Equal(t, *csResp.Data[0].Status, cloneStatus)
+	require.Equal(t, 1, statusRequests)
 }
 
 func TestGetCloneStatus(t *testing.T) {
@@ -538,7 +1124,7 @@ func TestGetCloneStatus(t *testing.T) {
       "image_type": "aws"
     }
   ]
-}`), nil, nil)
+}`), nil, nil, nil, nil)
 	require.NoError(t, err)
 	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
 		DBase:            dbase,
@@ -551,7 +1137,7 @@ func TestGetCloneStatus(t *testing.T) {
 	defer tokenSrv.Close()
 
 	cloneReq := AWSEC2Clone{
-		Region: "us-east-2",
+		Region: common.ToPtr("us-east-2"),
 	}
 	respStatusCode, body := tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/clone", id), cloneReq)
 	require.Equal(t, http.StatusCreated, respStatusCode)
@@ -580,6 +1166,128 @@ func TestGetCloneStatus(t *testing.T) {
 	require.Equal(t, "us-east-2", awsUS.Region)
 }
 
+func TestCloneComposeRegions(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+	var regions []string
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			var uo composer.CloneStatus_Options
+			require.NoError(t, uo.FromAWSEC2UploadStatus(composer.AWSEC2UploadStatus{}))
+			w.WriteHeader(http.StatusOK)
+			err := json.NewEncoder(w).Encode(composer.CloneStatus{
+				Options: uo,
+				Status:  composer.Running,
+				Type:    composer.UploadTypesAws,
+			})
+			require.NoError(t, err)
+			return
+		}
+		require.True(t, strings.HasSuffix(r.URL.Path, fmt.Sprintf("%v/clone", id)))
+		var cloneReq composer.AWSEC2CloneCompose
+		err := json.NewDecoder(r.Body).Decode(&cloneReq)
+		require.NoError(t, err)
+		regions = append(regions, cloneReq.Region)
+		if cloneReq.Region == "eu-central-1" {
+			w.WriteHeader(http.StatusInternalServerError)
+			err = json.NewEncoder(w).Encode(composer.Error{Reason: "cloning failed"})
+			require.NoError(t, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		err = json.NewEncoder(w).Encode(composer.CloneComposeResponse{
+			Id: uuid.New(),
+		})
+		require.NoError(t, err)
+	}))
+	defer apiSrv.Close()
+
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	err = dbase.InsertCompose(ctx, id, "500000", "user500000@test.test", "000000", nil, json.RawMessage(`
+{
+  "image_requests": [
+    {
+      "image_type": "aws"
+    }
+  ]
+}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
+		DBase:            dbase,
+		DistributionsDir: "../../distributions",
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	url := fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/clone", id)
+	respStatusCode, body := tutils.PostResponseBody(t, url, AWSEC2Clone{
+		Regions: &[]string{"us-east-2", "eu-west-1", "ap-south-1"},
+	})
+	require.Equal(t, http.StatusCreated, respStatusCode, body)
+	var cResp CloneResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &cResp))
+	require.Len(t, cResp.Ids, 3)
+	require.Equal(t, cResp.Ids[0], cResp.Id)
+	require.Equal(t, []string{"us-east-2", "eu-west-1", "ap-south-1"}, regions)
+
+	// the clones of a request are listed together, each with its region
+	var csResp ClonesResponse
+	respStatusCode, body = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/clones", id), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.NoError(t, json.Unmarshal([]byte(body), &csResp))
+	require.Len(t, csResp.Data, 3)
+	require.Equal(t, ClonesSummary{Unfinished: 3}, csResp.Summary)
+	for i, c := range csResp.Data {
+		require.Equal(t, cResp.Ids[i], c.Id)
+		awsReq, err := c.Request.AsAWSEC2Clone()
+		require.NoError(t, err)
+		require.Equal(t, regions[i], *awsReq.Region)
+		require.Nil(t, awsReq.Regions)
+		if i == 0 {
+			require.Nil(t, c.ParentId)
+		} else {
+			require.Equal(t, cResp.Id, *c.ParentId)
+		}
+	}
+
+	respStatusCode, _ = tutils.PostResponseBody(t, url, AWSEC2Clone{
+		Region:  common.ToPtr("us-east-2"),
+		Regions: &[]string{"eu-west-1"},
+	})
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+	respStatusCode, _ = tutils.PostResponseBody(t, url, AWSEC2Clone{})
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+	// none of the regions is cloned to when one of them is invalid
+	respStatusCode, _ = tutils.PostResponseBody(t, url, AWSEC2Clone{
+		Regions: &[]string{"us-west-1", "moon-base"},
+	})
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+	require.Len(t, regions, 3)
+
+	// the clones created before a failure are returned
+	respStatusCode, body = tutils.PostResponseBody(t, url, AWSEC2Clone{
+		Regions: &[]string{"us-west-1", "eu-central-1", "ap-south-1"},
+	})
+	require.Equal(t, http.StatusMultiStatus, respStatusCode, body)
+	cResp = CloneResponse{}
+	require.NoError(t, json.Unmarshal([]byte(body), &cResp))
+	require.Len(t, cResp.Ids, 1)
+	require.Equal(t, cResp.Ids[0], cResp.Id)
+	require.Equal(t, "eu-central-1", *cResp.FailedRegion)
+	require.Contains(t, *cResp.Error, "cloning failed")
+	require.Equal(t, []string{"us-east-2", "eu-west-1", "ap-south-1", "us-west-1", "eu-central-1"}, regions)
+}
+
 func TestValidateSpec(t *testing.T) {
 	spec, err := GetSwagger()
 	require.NoError(t, err)
@@ -607,6 +1315,19 @@ func TestGetArchitectures(t *testing.T) {
 		var result Architectures
 		err := json.Unmarshal([]byte(body), &result)
 		require.NoError(t, err)
+
+		for _, arch := range result {
+			require.NotNil(t, arch.ImageTypeDetails)
+			require.Len(t, *arch.ImageTypeDetails, len(arch.ImageTypes))
+			for i, it := range arch.ImageTypes {
+				require.Equal(t, it, (*arch.ImageTypeDetails)[i].Name)
+			}
+		}
+		require.Equal(t, "Amazon Web Services (AMI)", (*result[0].ImageTypeDetails)[0].DisplayName)
+		require.Equal(t, "Microsoft Azure (VHD)", (*result[0].ImageTypeDetails)[1].DisplayName)
+		result[0].ImageTypeDetails = nil
+		result[1].ImageTypeDetails = nil
+
 		require.Equal(t, Architectures{
 			ArchitectureItem{
 				Arch:       "x86_64",
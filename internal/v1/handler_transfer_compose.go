@@ -0,0 +1,79 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+// TransferCompose records another user of the org as the creator of the
+// compose, users who don't administer the org can only hand over their own.
+func (h *Handlers) TransferCompose(ctx echo.Context, composeId openapi_types.UUID) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	var transfer TransferComposeJSONRequestBody
+	err = ctx.Bind(&transfer)
+	if err != nil {
+		return err
+	}
+	creator := strings.TrimSpace(transfer.Creator)
+	if creator == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "The creator can't be empty")
+	}
+
+	_, err = h.getComposeByIdAndOrgId(ctx, composeId)
+	if err != nil {
+		return err
+	}
+	var from *string
+	if !userID.IsOrgAdmin() {
+		email := userID.Email()
+		from = &email
+	}
+	err = h.server.db.TransferCompose(ctx.Request().Context(), composeId, userID.OrgID(), from, creator)
+	if errors.Is(err, db.ComposeNotFoundError) {
+		return echo.NewHTTPError(http.StatusForbidden, "Only the creator of the compose and organization administrators can transfer it")
+	}
+	if err != nil {
+		return err
+	}
+	ctx.Logger().Infof("Transferred compose %s to %s", composeId, creator)
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// TransferComposes records another user of the org as the creator of all the
+// composes of a user, which keeps them attributed once the user left.
+func (h *Handlers) TransferComposes(ctx echo.Context) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !userID.IsOrgAdmin() {
+		return echo.NewHTTPError(http.StatusForbidden, "Composes can only be transferred in bulk by organization administrators")
+	}
+	var transfer TransferComposesJSONRequestBody
+	err = ctx.Bind(&transfer)
+	if err != nil {
+		return err
+	}
+	from, to := strings.TrimSpace(transfer.From), strings.TrimSpace(transfer.To)
+	if from == "" || to == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "The users composes are transferred between can't be empty")
+	}
+
+	transferred, err := h.server.db.TransferComposes(ctx.Request().Context(), userID.OrgID(), from, to)
+	if err != nil {
+		return err
+	}
+	ctx.Logger().Infof("Transferred %d composes of org %s from %s to %s", transferred, userID.OrgID(), from, to)
+	return ctx.JSON(http.StatusOK, ComposesTransferResponse{
+		Transferred: int(transferred),
+	})
+}
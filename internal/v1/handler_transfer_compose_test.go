@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestTransferCompose(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{}, &ServerConfig{
+		DBase: dbase,
+	})
+	defer func() {
+		err := srv.Shutdown(ctx)
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	var ids []uuid.UUID
+	for _, email := range []string{"user@user.user", "former@user.user", "former@user.user"} {
+		id := uuid.New()
+		err = dbase.InsertCompose(ctx, id, "000000", email, "000000", nil, json.RawMessage(`{"distribution": "rhel-9", "image_requests": [{"image_type": "aws"}]}`), nil, nil, nil, nil)
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	listCreator := func(creator string) []uuid.UUID {
+		respStatusCode, body := tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes?creator=%s", creator), &tutils.AuthString0)
+		require.Equal(t, http.StatusOK, respStatusCode)
+		var result ComposesResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &result))
+		var composes []uuid.UUID
+		for _, c := range result.Data {
+			composes = append(composes, c.Id)
+		}
+		return composes
+	}
+	require.Equal(t, []uuid.UUID{ids[2], ids[1]}, listCreator("former@user.user"))
+
+	respStatusCode, _ := tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/transfer", ids[1]), ComposeTransferRequest{Creator: "other@user.user"})
+	require.Equal(t, http.StatusNoContent, respStatusCode)
+	require.Equal(t, []uuid.UUID{ids[1]}, listCreator("other@user.user"))
+
+	respStatusCode, body := tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/composes/transfer", ComposesTransferRequest{From: "former@user.user", To: "user@user.user"})
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var transfer ComposesTransferResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &transfer))
+	require.Equal(t, 1, transfer.Transferred)
+	require.Equal(t, []uuid.UUID{ids[2], ids[0]}, listCreator("user@user.user"))
+	require.Empty(t, listCreator("former@user.user"))
+
+	respStatusCode, _ = tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/transfer", ids[0]), ComposeTransferRequest{Creator: " "})
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+	respStatusCode, _ = tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/composes/%s/transfer", uuid.New()), ComposeTransferRequest{Creator: "other@user.user"})
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+}
@@ -0,0 +1,198 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/labstack/echo/v4"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/distribution"
+)
+
+func (h *Handlers) GetUpgradeTargets(ctx echo.Context, distro Distributions) error {
+	_, err := h.server.getDistro(ctx, distro)
+	if err != nil {
+		return err
+	}
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	dr := h.server.distroRegistry(ctx)
+	targets, err := dr.UpgradeTargets(string(distro))
+	if err != nil {
+		return err
+	}
+	distributions := DistributionsResponse{}
+	for _, name := range targets {
+		d, err := dr.Get(name)
+		if err != nil {
+			return err
+		}
+		if d.IsRestricted() {
+			allowOk, err := h.server.isAllowed(ctx, userID.OrgID(), d.Distribution.Name)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			}
+			if !allowOk {
+				continue
+			}
+		}
+		distributions = append(distributions, DistributionItem{
+			Description: d.Distribution.Description,
+			Name:        name,
+		})
+	}
+	return ctx.JSON(http.StatusOK, distributions)
+}
+
+// RetargetBlueprint clones the latest version of a blueprint to a newer
+// release of its distribution. The original blueprint stays untouched.
+func (h *Handlers) RetargetBlueprint(ctx echo.Context, blueprintId openapi_types.UUID) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	var request RetargetBlueprintRequest
+	err = ctx.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	blueprintEntry, err := h.getBlueprintToChange(ctx, userID, blueprintId, nil)
+	if err != nil {
+		if errors.Is(err, db.BlueprintNotFoundError) {
+			return echo.NewHTTPError(http.StatusNotFound, err)
+		}
+		return err
+	}
+	blueprint, err := BlueprintFromEntry(blueprintEntry)
+	if err != nil {
+		return err
+	}
+
+	targets, err := h.server.distroRegistry(ctx).UpgradeTargets(string(blueprint.Distribution))
+	if errors.Is(err, distribution.DistributionNotFound) {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(targets, string(request.Distribution)) {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("%s is not an upgrade of %s", request.Distribution, blueprint.Distribution))
+	}
+	target, err := h.server.getDistro(ctx, request.Distribution)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s (%s)", blueprintEntry.Name, request.Distribution)
+	if request.Name != nil {
+		name = *request.Name
+	}
+	if !blueprintNameRegex.MatchString(name) {
+		return ctx.JSON(http.StatusUnprocessableEntity, HTTPErrorList{
+			Errors: []HTTPError{{
+				Title:  "Invalid blueprint name",
+				Detail: blueprintInvalidNameDetail,
+			}},
+		})
+	}
+
+	report := blueprintCompatibility(blueprint, target)
+	blueprint.Distribution = request.Distribution
+	ctx.Logger().Infof("Retargeting blueprint %s to %s as %s, for orgID: %s", blueprintId, request.Distribution, name, userID.OrgID())
+	id, err := h.insertBlueprintCopy(ctx, userID, blueprintEntry, name, blueprint)
+	if err != nil {
+		var e *pgconn.PgError
+		if errors.As(err, &e) && e.Code == pgerrcode.UniqueViolation {
+			return ctx.JSON(http.StatusUnprocessableEntity, HTTPErrorList{
+				Errors: []HTTPError{{
+					Title:  "Name not unique",
+					Detail: "A blueprint with the same name already exists.",
+				}},
+			})
+		}
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, RetargetBlueprintResponse{
+		Id:     id,
+		Report: report,
+	})
+}
+
+// insertBlueprintCopy stores the blueprint body as a new blueprint of the user
+// descending from the parent, in the workspace of the parent and as visible
+// as it is.
+func (h *Handlers) insertBlueprintCopy(ctx echo.Context, userID *Identity, parent *db.BlueprintEntry, name string, blueprint BlueprintBody) (uuid.UUID, error) {
+	body, err := json.Marshal(blueprint)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	metadata, err := json.Marshal(BlueprintMetadata{
+		ExportedAt: time.Now().UTC().String(),
+		ParentId:   &parent.Id,
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	id := uuid.New()
+	err = h.server.db.InsertBlueprint(ctx.Request().Context(), id, uuid.New(), userID.OrgID(), userID.AccountNumber(), name, parent.Description, body, metadata, db.BlueprintOptions{CreatedBy: userID.Email(), Private: parent.Private, WorkspaceId: parent.WorkspaceId})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	ctx.Logger().Infof("Inserted blueprint %s as a copy of %s", id, parent.Id)
+	return id, nil
+}
+
+// blueprintCompatibility reports what the blueprint uses which isn't
+// available in the target release, for any of the architectures of its image
+// requests.
+func blueprintCompatibility(blueprint BlueprintBody, target *distribution.DistributionFile) BlueprintCompatibilityReport {
+	report := BlueprintCompatibilityReport{
+		MissingPackages:       []string{},
+		UnsupportedImageTypes: []ImageTypes{},
+		PackagesChecked:       !target.Distribution.NoPackageList,
+	}
+
+	var archs []*distribution.Architecture
+	for _, ir := range blueprint.ImageRequests {
+		arch, err := target.Architecture(string(ir.Architecture))
+		supported := err == nil && arch != nil &&
+			(slices.Contains(arch.ImageTypes, string(ir.ImageType)) || slices.Contains(arch.ImageTypes, string(canonicalImageType(ir.ImageType))))
+		if !supported && !slices.Contains(report.UnsupportedImageTypes, ir.ImageType) {
+			report.UnsupportedImageTypes = append(report.UnsupportedImageTypes, ir.ImageType)
+		}
+		if arch != nil && !slices.Contains(archs, arch) {
+			archs = append(archs, arch)
+		}
+	}
+
+	if !report.PackagesChecked || blueprint.Customizations.Packages == nil {
+		return report
+	}
+	for _, p := range *blueprint.Customizations.Packages {
+		for _, arch := range archs {
+			group, isGroup := strings.CutPrefix(p, "@")
+			if isGroup && arch.HasGroups() && arch.FindGroup(group) == nil || !isGroup && !arch.HasPackage(p) {
+				report.MissingPackages = append(report.MissingPackages, p)
+				break
+			}
+		}
+	}
+	return report
+}
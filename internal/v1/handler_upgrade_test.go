@@ -0,0 +1,137 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/distribution"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestGetUpgradeTargets(t *testing.T) {
+	srv, tokenSrv := startServer(t, &testServerClientsConf{}, &ServerConfig{
+		DistributionsDir: "../../distributions",
+		AllowFile:        "../common/testdata/allow.json",
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	respStatusCode, body := tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/distributions/rhel-89/upgrade-targets", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var result DistributionsResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	var names []string
+	for _, d := range result {
+		names = append(names, d.Name)
+	}
+	require.Equal(t, []string{"rhel-8.10", "rhel-90", "rhel-91", "rhel-92", "rhel-93", "rhel-94"}, names)
+
+	// restricted releases are only listed to allowed organizations
+	respStatusCode, body = tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/distributions/centos-9/upgrade-targets", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Len(t, result, 1)
+	require.Equal(t, "centos-10", result[0].Name)
+
+	respStatusCode, body = tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/distributions/centos-9/upgrade-targets", &tutils.AuthString1)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Empty(t, result)
+}
+
+func TestRetargetBlueprint(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{}, &ServerConfig{
+		DBase:            dbase,
+		DistributionsDir: "../../distributions",
+	})
+	defer func() {
+		err := srv.Shutdown(ctx)
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	blueprintId := uuid.New()
+	err = dbase.InsertBlueprint(ctx, blueprintId, uuid.New(), "000000", "500000", "blueprint", "blueprint desc",
+		json.RawMessage(`{"distribution": "rhel-89", "image_requests": [{"architecture": "x86_64", "image_type": "aws"}], "customizations": {"packages": ["vim-enhanced", "not-a-package"]}}`), nil, db.BlueprintOptions{})
+	require.NoError(t, err)
+
+	url := fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s/retarget", blueprintId)
+	respStatusCode, body := tutils.PostResponseBody(t, url, RetargetBlueprintRequest{Distribution: Rhel94})
+	require.Equal(t, http.StatusCreated, respStatusCode, body)
+	var result RetargetBlueprintResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Equal(t, BlueprintCompatibilityReport{
+		MissingPackages:       []string{"not-a-package"},
+		PackagesChecked:       true,
+		UnsupportedImageTypes: []ImageTypes{},
+	}, result.Report)
+
+	respStatusCode, body = tutils.GetResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s", result.Id), &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	var blueprint BlueprintResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &blueprint))
+	require.Equal(t, "blueprint (rhel-94)", blueprint.Name)
+	require.Equal(t, Rhel94, blueprint.Distribution)
+	require.Equal(t, []string{"vim-enhanced", "not-a-package"}, *blueprint.Customizations.Packages)
+
+	// the name is taken now
+	respStatusCode, _ = tutils.PostResponseBody(t, url, RetargetBlueprintRequest{Distribution: Rhel94})
+	require.Equal(t, http.StatusUnprocessableEntity, respStatusCode)
+	respStatusCode, _ = tutils.PostResponseBody(t, url, RetargetBlueprintRequest{Distribution: Rhel94, Name: common.ToPtr("blueprint 9.4")})
+	require.Equal(t, http.StatusCreated, respStatusCode)
+
+	// only newer releases
+	respStatusCode, _ = tutils.PostResponseBody(t, url, RetargetBlueprintRequest{Distribution: Rhel88})
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+	respStatusCode, _ = tutils.PostResponseBody(t, url, RetargetBlueprintRequest{Distribution: Centos9})
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+
+	respStatusCode, _ = tutils.PostResponseBody(t, fmt.Sprintf("http://localhost:8086/api/image-builder/v1/blueprints/%s/retarget", uuid.New()), RetargetBlueprintRequest{Distribution: Rhel94})
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+}
+
+func TestBlueprintCompatibility(t *testing.T) {
+	adr, err := distribution.LoadDistroRegistry("../../distributions")
+	require.NoError(t, err)
+	target, err := adr.Available(true).Get("rhel-94")
+	require.NoError(t, err)
+
+	blueprint := BlueprintBody{
+		Distribution: Rhel89,
+		ImageRequests: []ImageRequest{
+			{Architecture: ImageRequestArchitectureX8664, ImageType: ImageTypesAzure},
+			{Architecture: ImageRequestArchitectureAarch64, ImageType: ImageTypesAzure},
+			{Architecture: ImageRequestArchitectureAarch64, ImageType: ImageTypesGuestImage},
+		},
+		Customizations: Customizations{
+			Packages: &[]string{"bash", "python2", "@anything"},
+		},
+	}
+	require.Equal(t, BlueprintCompatibilityReport{
+		MissingPackages:       []string{"python2"},
+		PackagesChecked:       true,
+		UnsupportedImageTypes: []ImageTypes{ImageTypesAzure},
+	}, blueprintCompatibility(blueprint, target))
+
+	// fedora doesn't publish package lists
+	target, err = adr.Available(true).Get("fedora-41")
+	require.NoError(t, err)
+	report := blueprintCompatibility(blueprint, target)
+	require.False(t, report.PackagesChecked)
+	require.Empty(t, report.MissingPackages)
+}
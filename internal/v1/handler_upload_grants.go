@@ -0,0 +1,161 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/clients/provisioning"
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+func uploadGrantFromEntry(e *db.UploadGrantEntry) UploadGrant {
+	return UploadGrant{
+		Alias:          e.Alias,
+		Provider:       UploadGrantProvider(e.Provider),
+		SourceId:       e.SourceId,
+		AccountId:      e.AccountId,
+		TenantId:       e.TenantId,
+		SubscriptionId: e.SubscriptionId,
+		CreatedBy:      &e.CreatedBy,
+		CreatedAt:      e.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func (h *Handlers) GetUploadGrants(ctx echo.Context) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.server.db.GetUploadGrants(ctx.Request().Context(), userID.OrgID())
+	if err != nil {
+		return err
+	}
+
+	data := make([]UploadGrant, 0, len(entries))
+	for i := range entries {
+		data = append(data, uploadGrantFromEntry(&entries[i]))
+	}
+	return ctx.JSON(http.StatusOK, UploadGrantsResponse{
+		Data: data,
+	})
+}
+
+// CreateUploadGrant resolves the source once, upload requests referencing
+// the grant share with the stored account even if the source changes or is
+// removed later on.
+func (h *Handlers) CreateUploadGrant(ctx echo.Context) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	var request CreateUploadGrantJSONRequestBody
+	err = ctx.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	grant := db.UploadGrantEntry{
+		OrgId:     userID.OrgID(),
+		Alias:     request.Alias,
+		SourceId:  request.SourceId,
+		CreatedBy: userID.Email(),
+	}
+	uploadInfo, err := h.sourceUploadInfo(ctx, request.SourceId)
+	if err != nil {
+		return err
+	}
+	switch {
+	case uploadInfo.Aws != nil && uploadInfo.Aws.AccountId != nil && len(*uploadInfo.Aws.AccountId) == 12:
+		grant.Provider = string(UploadGrantProviderAws)
+		grant.AccountId = uploadInfo.Aws.AccountId
+	case uploadInfo.Azure != nil && uploadInfo.Azure.TenantId != nil && uploadInfo.Azure.SubscriptionId != nil:
+		grant.Provider = string(UploadGrantProviderAzure)
+		grant.TenantId = uploadInfo.Azure.TenantId
+		grant.SubscriptionId = uploadInfo.Azure.SubscriptionId
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unable to resolve source %s to an aws account or an Azure subscription", request.SourceId))
+	}
+
+	err = h.server.db.InsertUploadGrant(ctx.Request().Context(), grant)
+	if err != nil {
+		var e *pgconn.PgError
+		if errors.As(err, &e) && e.Code == pgerrcode.UniqueViolation {
+			return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("An upload grant with the alias %s already exists", request.Alias))
+		}
+		return err
+	}
+	ctx.Logger().Infof("Created %s upload grant %s from source %s for org %s", grant.Provider, grant.Alias, grant.SourceId, grant.OrgId)
+
+	entry, err := h.server.db.GetUploadGrant(ctx.Request().Context(), userID.OrgID(), request.Alias)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusCreated, uploadGrantFromEntry(entry))
+}
+
+func (h *Handlers) DeleteUploadGrant(ctx echo.Context, alias string) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = h.server.db.DeleteUploadGrant(ctx.Request().Context(), userID.OrgID(), alias)
+	if err != nil {
+		if errors.Is(err, db.UploadGrantNotFoundError) {
+			return echo.NewHTTPError(http.StatusNotFound, err)
+		}
+		return err
+	}
+	ctx.Logger().Infof("Revoked upload grant %s of org %s", alias, userID.OrgID())
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (h *Handlers) sourceUploadInfo(ctx echo.Context, source string) (*provisioning.V1SourceUploadInfoResponse, error) {
+	resp, err := h.server.pClient.GetUploadInfo(ctx.Request().Context(), source)
+	if err != nil {
+		ctx.Logger().Error(err)
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unable to request source: %s", source))
+	}
+	defer closeBody(ctx, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unable to request source: %s", source))
+	}
+
+	var uploadInfo provisioning.V1SourceUploadInfoResponse
+	err = json.NewDecoder(resp.Body).Decode(&uploadInfo)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Unable to resolve source: %s", source))
+	}
+	return &uploadInfo, nil
+}
+
+// uploadGrant looks up a grant referenced by the upload options, it has to
+// be one of the provider of the upload target.
+func (h *Handlers) uploadGrant(ctx echo.Context, alias string, provider UploadGrantProvider) (*db.UploadGrantEntry, error) {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	grant, err := h.server.db.GetUploadGrant(ctx.Request().Context(), userID.OrgID(), alias)
+	if errors.Is(err, db.UploadGrantNotFoundError) {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unknown upload grant: %s", alias))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if grant.Provider != string(provider) {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Upload grant %s is for %s, not %s", alias, grant.Provider, provider))
+	}
+	ctx.Logger().Infof("Resolved upload grant %s from source %s", alias, grant.SourceId)
+	return grant, nil
+}
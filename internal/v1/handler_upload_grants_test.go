@@ -0,0 +1,163 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/clients/provisioning"
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestUploadGrants(t *testing.T) {
+	ctx := context.Background()
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+
+	var composerRequest composer.ComposeRequest
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&composerRequest))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		require.NoError(t, json.NewEncoder(w).Encode(composer.ComposeId{Id: uuid.New()}))
+	}))
+	defer apiSrv.Close()
+
+	provSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var result provisioning.V1SourceUploadInfoResponse
+		switch r.URL.Path {
+		case "/sources/1/upload_info":
+			result.Aws = &struct {
+				AccountId *string `json:"account_id,omitempty"`
+			}{
+				AccountId: common.ToPtr("123456123456"),
+			}
+		case "/sources/2/upload_info":
+			result.Azure = &struct {
+				ResourceGroups *[]string `json:"resource_groups,omitempty"`
+				SubscriptionId *string   `json:"subscription_id,omitempty"`
+				TenantId       *string   `json:"tenant_id,omitempty"`
+			}{
+				SubscriptionId: common.ToPtr("subscription"),
+				TenantId:       common.ToPtr("tenant"),
+			}
+		case "/sources/3/upload_info":
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		require.NoError(t, json.NewEncoder(w).Encode(result))
+	}))
+	defer provSrv.Close()
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL, ProvURL: provSrv.URL}, &ServerConfig{
+		DBase:            dbase,
+		DistributionsDir: "../../distributions",
+	})
+	defer func() {
+		err := srv.Shutdown(ctx)
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	grantsURL := "http://localhost:8086/api/image-builder/v1/upload-grants"
+	statusCode, body := tutils.PostResponseBody(t, grantsURL, CreateUploadGrantRequest{Alias: "prod", SourceId: "1"})
+	require.Equal(t, http.StatusCreated, statusCode, body)
+	var grant UploadGrant
+	require.NoError(t, json.Unmarshal([]byte(body), &grant))
+	require.Equal(t, UploadGrantProviderAws, grant.Provider)
+	require.Equal(t, "123456123456", *grant.AccountId)
+
+	statusCode, body = tutils.PostResponseBody(t, grantsURL, CreateUploadGrantRequest{Alias: "prod", SourceId: "2"})
+	require.Equal(t, http.StatusConflict, statusCode, body)
+	statusCode, body = tutils.PostResponseBody(t, grantsURL, CreateUploadGrantRequest{Alias: "azure", SourceId: "2"})
+	require.Equal(t, http.StatusCreated, statusCode, body)
+	// sources of neither provider, or unknown ones, aren't granted
+	statusCode, _ = tutils.PostResponseBody(t, grantsURL, CreateUploadGrantRequest{Alias: "other", SourceId: "3"})
+	require.Equal(t, http.StatusBadRequest, statusCode)
+	statusCode, _ = tutils.PostResponseBody(t, grantsURL, CreateUploadGrantRequest{Alias: "other", SourceId: "4"})
+	require.Equal(t, http.StatusBadRequest, statusCode)
+	statusCode, _ = tutils.PostResponseBody(t, grantsURL, CreateUploadGrantRequest{Alias: "Not Valid", SourceId: "1"})
+	require.Equal(t, http.StatusBadRequest, statusCode)
+
+	statusCode, body = tutils.GetResponseBody(t, grantsURL, &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, statusCode)
+	var grants UploadGrantsResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &grants))
+	require.Len(t, grants.Data, 2)
+	statusCode, body = tutils.GetResponseBody(t, grantsURL, common.ToPtr(tutils.AuthString1))
+	require.Equal(t, http.StatusOK, statusCode)
+	require.NoError(t, json.Unmarshal([]byte(body), &grants))
+	require.Len(t, grants.Data, 0)
+
+	compose := func(options UploadRequest_Options, uploadType UploadTypes, imageType ImageTypes) (int, string) {
+		return tutils.PostResponseBody(t, "http://localhost:8086/api/image-builder/v1/compose", ComposeRequest{
+			Distribution: "centos-9",
+			ImageRequests: []ImageRequest{
+				{
+					Architecture:  "x86_64",
+					ImageType:     imageType,
+					UploadRequest: UploadRequest{Type: uploadType, Options: options},
+				},
+			},
+		})
+	}
+
+	var awsOptions UploadRequest_Options
+	require.NoError(t, awsOptions.FromAWSUploadRequestOptions(AWSUploadRequestOptions{
+		ShareWithAccounts: &[]string{"000000000000"},
+		ShareWithGrants:   &[]string{"prod"},
+	}))
+	statusCode, body = compose(awsOptions, UploadTypesAws, ImageTypesAws)
+	require.Equal(t, http.StatusCreated, statusCode, body)
+	ec2Options, err := (*composerRequest.ImageRequest.UploadOptions).AsAWSEC2UploadOptions()
+	require.NoError(t, err)
+	require.Equal(t, []string{"000000000000", "123456123456"}, ec2Options.ShareWithAccounts)
+
+	var azureOptions UploadRequest_Options
+	require.NoError(t, azureOptions.FromAzureUploadRequestOptions(AzureUploadRequestOptions{
+		Grant:         common.ToPtr("azure"),
+		ResourceGroup: "group",
+	}))
+	statusCode, body = compose(azureOptions, UploadTypesAzure, ImageTypesAzure)
+	require.Equal(t, http.StatusCreated, statusCode, body)
+	azureUploadOptions, err := (*composerRequest.ImageRequest.UploadOptions).AsAzureUploadOptions()
+	require.NoError(t, err)
+	require.Equal(t, "tenant", azureUploadOptions.TenantId)
+	require.Equal(t, "subscription", azureUploadOptions.SubscriptionId)
+
+	// grants of the other provider are rejected, and so is mixing a grant
+	// with a source
+	require.NoError(t, azureOptions.FromAzureUploadRequestOptions(AzureUploadRequestOptions{
+		Grant:         common.ToPtr("prod"),
+		ResourceGroup: "group",
+	}))
+	statusCode, body = compose(azureOptions, UploadTypesAzure, ImageTypesAzure)
+	require.Equal(t, http.StatusBadRequest, statusCode)
+	require.Contains(t, body, "Upload grant prod is for aws, not azure")
+	require.NoError(t, azureOptions.FromAzureUploadRequestOptions(AzureUploadRequestOptions{
+		Grant:         common.ToPtr("azure"),
+		SourceId:      common.ToPtr("2"),
+		ResourceGroup: "group",
+	}))
+	statusCode, _ = compose(azureOptions, UploadTypesAzure, ImageTypesAzure)
+	require.Equal(t, http.StatusBadRequest, statusCode)
+
+	// revoked grants can't be used anymore
+	statusCode, _ = tutils.DeleteResponseBody(t, grantsURL+"/prod")
+	require.Equal(t, http.StatusNoContent, statusCode)
+	statusCode, _ = tutils.DeleteResponseBody(t, grantsURL+"/prod")
+	require.Equal(t, http.StatusNotFound, statusCode)
+	statusCode, body = compose(awsOptions, UploadTypesAws, ImageTypesAws)
+	require.Equal(t, http.StatusBadRequest, statusCode)
+	require.Contains(t, body, "Unknown upload grant: prod")
+}
@@ -0,0 +1,138 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// only composes this recent are listed, so only these can be running
+	usageWindow = 14 * 24 * time.Hour
+
+	// how many composes composer is asked about at once
+	usageQueries = 8
+)
+
+func (h *Handlers) GetCurrentUsage(ctx echo.Context) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	composes, err := h.server.db.GetOrgUnfinishedComposes(ctx.Request().Context(), userID.OrgID(), usageWindow)
+	if err != nil {
+		return err
+	}
+
+	var usage CurrentUsage
+	var unstored []db.UnfinishedCompose
+	readOnly, _ := h.server.readOnly.Enabled()
+	for _, c := range composes {
+		switch {
+		case c.Status != nil:
+			countImageStatus(&usage, composer.ImageStatusValue(*c.Status))
+		case readOnly:
+			// composer can't tell, they count against the quota either way
+			usage.Unknown += 1
+		default:
+			unstored = append(unstored, c)
+		}
+	}
+
+	var mu sync.Mutex
+	queries := make(chan db.UnfinishedCompose)
+	var wg sync.WaitGroup
+	for i := 0; i < usageQueries; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range queries {
+				status, err := h.composerImageStatus(ctx, c.Id, c.Region)
+				if err != nil {
+					ctx.Logger().Warnf("Unable to query status of compose %v: %v", c.Id, err)
+					mu.Lock()
+					usage.Unknown += 1
+					mu.Unlock()
+					continue
+				}
+				if status == composer.ImageStatusValueSuccess || status == composer.ImageStatusValueFailure {
+					// remember it finished so it's not queried again
+					err = h.server.db.SetComposeStatus(ctx.Request().Context(), c.Id, string(status), nil)
+					if err != nil {
+						ctx.Logger().Errorf("Unable to store status of compose %v: %v", c.Id, err)
+					}
+				}
+				mu.Lock()
+				countImageStatus(&usage, status)
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, c := range unstored {
+		queries <- c
+	}
+	close(queries)
+	wg.Wait()
+
+	usage.RemainingSlots, err = common.RemainingQuota(ctx.Request().Context(), userID.OrgID(), h.server.db, h.server.quotaFile)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, usage)
+}
+
+// countImageStatus adds a compose in the status to the usage, finished
+// composes and the ones composer doesn't know about anymore don't count.
+func countImageStatus(usage *CurrentUsage, status composer.ImageStatusValue) {
+	switch status {
+	case "", composer.ImageStatusValueSuccess, composer.ImageStatusValueFailure:
+	case composer.ImageStatusValuePending:
+		usage.Queued += 1
+	default:
+		usage.Running += 1
+	}
+}
+
+func (h *Handlers) composerImageStatus(ctx echo.Context, id uuid.UUID, region *string) (composer.ImageStatusValue, error) {
+	cClient, err := h.server.composerFor(region)
+	if err != nil {
+		return "", err
+	}
+	resp, err := cClient.ComposeStatus(id)
+	if err != nil {
+		return "", err
+	}
+	defer closeBody(ctx, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", err
+		}
+		// the 404s of proxies in front of composer count as unknown
+		if composer.IsComposeNotFound(resp.StatusCode, body) {
+			return "", nil
+		}
+		return "", fmt.Errorf("composer returned %d: %s", resp.StatusCode, body)
+	}
+
+	var cloudStat composer.ComposeStatus
+	err = json.NewDecoder(resp.Body).Decode(&cloudStat)
+	if err != nil {
+		return "", err
+	}
+	return cloudStat.ImageStatus.Status, nil
+}
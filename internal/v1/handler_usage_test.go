@@ -0,0 +1,104 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestGetCurrentUsage(t *testing.T) {
+	ctx := context.Background()
+	queued := uuid.New()
+	building := uuid.New()
+	uploading := uuid.New()
+	finished := uuid.New()
+	broken := uuid.New()
+	forgotten := uuid.New()
+	proxied := uuid.New()
+
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.Equal(t, "Bearer accesstoken", r.Header.Get("Authorization"))
+
+		status := composer.ComposeStatus{
+			Status: composer.ComposeStatusValuePending,
+		}
+		switch r.URL.Path {
+		case "/api/image-builder-composer/v2/composes/" + queued.String():
+			status.ImageStatus.Status = composer.ImageStatusValuePending
+		case "/api/image-builder-composer/v2/composes/" + building.String():
+			status.ImageStatus.Status = composer.ImageStatusValueBuilding
+		case "/api/image-builder-composer/v2/composes/" + uploading.String():
+			status.ImageStatus.Status = composer.ImageStatusValueUploading
+		case "/api/image-builder-composer/v2/composes/" + finished.String():
+			status.Status = composer.ComposeStatusValueSuccess
+			status.ImageStatus.Status = composer.ImageStatusValueSuccess
+		case "/api/image-builder-composer/v2/composes/" + broken.String():
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		case "/api/image-builder-composer/v2/composes/" + forgotten.String():
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, err := w.Write([]byte(`{"code": "IMAGE-BUILDER-COMPOSER-15", "reason": "Compose with given id not found"}`))
+			require.NoError(t, err)
+			return
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		require.NoError(t, json.NewEncoder(w).Encode(status))
+	}))
+	defer apiSrv.Close()
+
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+
+	for _, id := range []uuid.UUID{queued, building, uploading, finished, broken, forgotten, proxied} {
+		err = dbase.InsertCompose(ctx, id, "500000", "user500000@test.test", "000000", nil, json.RawMessage(`{"image_requests": [{"image_type": "aws"}]}`), nil, nil, nil, nil)
+		require.NoError(t, err)
+	}
+	// other orgs don't count
+	err = dbase.InsertCompose(ctx, uuid.New(), "500001", "user500001@test.test", "000001", nil, json.RawMessage("{}"), nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	srv, tokenSrv := startServer(t, &testServerClientsConf{ComposerURL: apiSrv.URL}, &ServerConfig{
+		DBase:            dbase,
+		DistributionsDir: "../../distributions",
+	})
+	defer func() {
+		err := srv.Shutdown(context.Background())
+		require.NoError(t, err)
+	}()
+	defer tokenSrv.Close()
+
+	respStatusCode, body := tutils.GetResponseBody(t, "http://localhost:8086/api/image-builder/v1/usage/current", &tutils.AuthString0)
+	require.Equal(t, http.StatusOK, respStatusCode)
+
+	var result CurrentUsage
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	require.Equal(t, 2, result.Running)
+	require.Equal(t, 1, result.Queued)
+	// composer failing for one compose doesn't fail the others, a 404 not
+	// coming from composer can't tell the compose is gone either
+	require.Equal(t, 2, result.Unknown)
+	require.NotNil(t, result.RemainingSlots)
+	require.Equal(t, 93, *result.RemainingSlots)
+
+	// the finished compose got its status recorded
+	compose, err := dbase.GetCompose(ctx, finished, "000000")
+	require.NoError(t, err)
+	require.Equal(t, "success", *compose.Status)
+}
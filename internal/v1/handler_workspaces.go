@@ -0,0 +1,336 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/labstack/echo/v4"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+	iberrors "github.com/osbuild/image-builder/pkg/errors"
+)
+
+func workspaceFromEntry(e *db.WorkspaceEntry) (Workspace, error) {
+	workspace := Workspace{
+		Id:           e.Id,
+		Name:         e.Name,
+		Description:  e.Description,
+		ComposeQuota: e.ComposeQuota,
+		Members:      make([]WorkspaceMember, 0, len(e.Members)),
+		CreatedBy:    e.CreatedBy,
+		CreatedAt:    e.CreatedAt.Format(time.RFC3339),
+	}
+	if e.Policy != nil {
+		var policy OrgPolicy
+		err := json.Unmarshal(e.Policy, &policy)
+		if err != nil {
+			return Workspace{}, err
+		}
+		workspace.Policy = &policy
+	}
+	for _, m := range e.Members {
+		workspace.Members = append(workspace.Members, WorkspaceMember{
+			Email: m.Email,
+			Role:  WorkspaceRole(m.Role),
+		})
+	}
+	return workspace, nil
+}
+
+// workspaceEntryFromRequest checks the workspace of the request, its members
+// are listed once each.
+func workspaceEntryFromRequest(userID *Identity, id uuid.UUID, request WorkspaceRequest) (*db.WorkspaceEntry, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "The workspace needs a name")
+	}
+	entry := &db.WorkspaceEntry{
+		Id:           id,
+		OrgId:        userID.OrgID(),
+		Name:         name,
+		Description:  common.FromPtr(request.Description),
+		ComposeQuota: request.ComposeQuota,
+		CreatedBy:    userID.Email(),
+	}
+	if request.Policy != nil {
+		err := checkPolicy(*request.Policy)
+		if err != nil {
+			return nil, err
+		}
+		entry.Policy, err = json.Marshal(request.Policy)
+		if err != nil {
+			return nil, err
+		}
+	}
+	listed := map[string]bool{}
+	for _, m := range common.FromPtr(request.Members) {
+		email := strings.TrimSpace(m.Email)
+		if email == "" {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "Workspace members need an email")
+		}
+		if m.Role != Viewer && m.Role != Builder {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unknown workspace role %s", m.Role))
+		}
+		if listed[email] {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("%s is listed more than once", email))
+		}
+		listed[email] = true
+		entry.Members = append(entry.Members, db.WorkspaceMember{
+			Email: email,
+			Role:  string(m.Role),
+		})
+	}
+	return entry, nil
+}
+
+func (h *Handlers) GetWorkspaces(ctx echo.Context) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	response := WorkspacesResponse{
+		Data: []Workspace{},
+	}
+	// identities without an email are members of none
+	if !userID.IsOrgAdmin() && userID.Email() == "" {
+		return ctx.JSON(http.StatusOK, response)
+	}
+	member := userID.Email()
+	if userID.IsOrgAdmin() {
+		member = ""
+	}
+	entries, err := h.server.db.GetWorkspaces(ctx.Request().Context(), userID.OrgID(), member)
+	if err != nil {
+		return err
+	}
+	for i := range entries {
+		workspace, err := workspaceFromEntry(&entries[i])
+		if err != nil {
+			return err
+		}
+		response.Data = append(response.Data, workspace)
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (h *Handlers) CreateWorkspace(ctx echo.Context) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !userID.IsOrgAdmin() {
+		return echo.NewHTTPError(http.StatusForbidden, "Workspaces can only be changed by organization administrators")
+	}
+
+	var request CreateWorkspaceJSONRequestBody
+	err = ctx.Bind(&request)
+	if err != nil {
+		return err
+	}
+	entry, err := workspaceEntryFromRequest(userID, uuid.New(), request)
+	if err != nil {
+		return err
+	}
+
+	err = h.server.db.InsertWorkspace(ctx.Request().Context(), entry)
+	var e *pgconn.PgError
+	if errors.As(err, &e) && e.Code == pgerrcode.UniqueViolation {
+		return ctx.JSON(http.StatusUnprocessableEntity, HTTPErrorList{
+			Errors: []HTTPError{{
+				Title:  "Name not unique",
+				Detail: "A workspace with the same name already exists.",
+			}},
+		})
+	}
+	if err != nil {
+		return err
+	}
+	ctx.Logger().Infof("Created workspace %s (%s) for orgID: %s", entry.Name, entry.Id, userID.OrgID())
+	workspace, err := workspaceFromEntry(entry)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusCreated, workspace)
+}
+
+func (h *Handlers) GetWorkspace(ctx echo.Context, id openapi_types.UUID) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+
+	entry, err := h.server.db.GetWorkspace(ctx.Request().Context(), id, userID.OrgID())
+	if errors.Is(err, db.WorkspaceNotFoundError) {
+		return echo.NewHTTPError(http.StatusNotFound, err)
+	}
+	if err != nil {
+		return err
+	}
+	// the workspaces of others aren't found like the ones of other orgs
+	if !userID.IsOrgAdmin() && !workspaceMember(entry, userID.Email()) {
+		return echo.NewHTTPError(http.StatusNotFound, db.WorkspaceNotFoundError)
+	}
+	workspace, err := workspaceFromEntry(entry)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, workspace)
+}
+
+func workspaceMember(entry *db.WorkspaceEntry, email string) bool {
+	for _, m := range entry.Members {
+		if email != "" && m.Email == email {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handlers) UpdateWorkspace(ctx echo.Context, id openapi_types.UUID) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !userID.IsOrgAdmin() {
+		return echo.NewHTTPError(http.StatusForbidden, "Workspaces can only be changed by organization administrators")
+	}
+
+	var request UpdateWorkspaceJSONRequestBody
+	err = ctx.Bind(&request)
+	if err != nil {
+		return err
+	}
+	entry, err := workspaceEntryFromRequest(userID, id, request)
+	if err != nil {
+		return err
+	}
+
+	err = h.server.db.UpdateWorkspace(ctx.Request().Context(), entry)
+	if errors.Is(err, db.WorkspaceNotFoundError) {
+		return echo.NewHTTPError(http.StatusNotFound, err)
+	}
+	var e *pgconn.PgError
+	if errors.As(err, &e) && e.Code == pgerrcode.UniqueViolation {
+		return ctx.JSON(http.StatusUnprocessableEntity, HTTPErrorList{
+			Errors: []HTTPError{{
+				Title:  "Name not unique",
+				Detail: "A workspace with the same name already exists.",
+			}},
+		})
+	}
+	if err != nil {
+		return err
+	}
+	ctx.Logger().Infof("Updated workspace %s", id)
+
+	// the creation stays the one of the stored workspace
+	entry, err = h.server.db.GetWorkspace(ctx.Request().Context(), id, userID.OrgID())
+	if err != nil {
+		return err
+	}
+	workspace, err := workspaceFromEntry(entry)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, workspace)
+}
+
+func (h *Handlers) DeleteWorkspace(ctx echo.Context, id openapi_types.UUID) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !userID.IsOrgAdmin() {
+		return echo.NewHTTPError(http.StatusForbidden, "Workspaces can only be changed by organization administrators")
+	}
+
+	err = h.server.db.DeleteWorkspace(ctx.Request().Context(), id, userID.OrgID())
+	if errors.Is(err, db.WorkspaceNotFoundError) {
+		return echo.NewHTTPError(http.StatusNotFound, err)
+	}
+	if errors.Is(err, db.WorkspaceNotEmptyError) {
+		return echo.NewHTTPError(http.StatusConflict, "The blueprints of the workspace have to be deleted first")
+	}
+	if err != nil {
+		return err
+	}
+	ctx.Logger().Infof("Deleted workspace %s", id)
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// workspaceRole returns the role of the user in the workspace, empty if they
+// aren't a member. Org admins are builders of every workspace, everyone is a
+// builder of the blueprints without one.
+func (h *Handlers) workspaceRole(ctx echo.Context, userID *Identity, workspaceId *uuid.UUID) (string, error) {
+	if workspaceId == nil {
+		return db.WorkspaceBuilder, nil
+	}
+	role, err := h.server.db.GetWorkspaceRole(ctx.Request().Context(), *workspaceId, userID.OrgID(), userID.Email())
+	if err != nil {
+		return "", err
+	}
+	if userID.IsOrgAdmin() {
+		return db.WorkspaceBuilder, nil
+	}
+	return role, nil
+}
+
+// checkWorkspaceComposes rejects the composes of the blueprints of a workspace
+// exceeding its quota or violating its policy, on top of the ones of the org
+// every compose is checked against.
+func (h *Handlers) checkWorkspaceComposes(ctx echo.Context, workspaceId uuid.UUID, composeRequests []ComposeRequest) error {
+	userID, err := h.server.getIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	entry, err := h.server.db.GetWorkspace(ctx.Request().Context(), workspaceId, userID.OrgID())
+	if err != nil {
+		return err
+	}
+
+	// spares composer the batches which are over the quota already, the
+	// reservations of the composes settle it for concurrent ones
+	if entry.ComposeQuota != nil {
+		window := common.DefaultSlidingWindow
+		quota, err := h.server.quota(ctx, userID.OrgID())
+		if err != nil {
+			return err
+		}
+		if quota != nil {
+			window = quota.SlidingWindow
+		}
+		count, err := h.server.db.CountWorkspaceComposesSince(ctx.Request().Context(), userID.OrgID(), workspaceId, window)
+		if err != nil {
+			return err
+		}
+		if count+len(composeRequests) > *entry.ComposeQuota {
+			return apiError(iberrors.CodeQuotaExceeded, http.StatusForbidden, "Quota exceeded for workspace")
+		}
+	}
+
+	if entry.Policy != nil {
+		var policy OrgPolicy
+		err = json.Unmarshal(entry.Policy, &policy)
+		if err != nil {
+			return err
+		}
+		var violations policyViolations
+		for i := range composeRequests {
+			violations = append(violations, scopedPolicyViolations("Workspace", policy, &composeRequests[i], scanComposeRequest(&composeRequests[i]))...)
+		}
+		if len(violations) > 0 {
+			return echo.NewHTTPError(http.StatusForbidden, violations)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestHandlers_Workspaces(t *testing.T) {
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	startTestServer(t, &testServerClientsConf{}, &ServerConfig{
+		DBase:            dbase,
+		DistributionsDir: "../../distributions",
+	})
+
+	admin := tutils.NewIdentity("000000").Email("admin@user.user").Base64()
+	builder := tutils.NewIdentity("000000").Email("builder@user.user").OrgAdmin(false).Base64()
+	viewer := tutils.NewIdentity("000000").Email("viewer@user.user").OrgAdmin(false).Base64()
+	outsider := tutils.NewIdentity("000000").Email("outsider@user.user").OrgAdmin(false).Base64()
+
+	request := WorkspaceRequest{
+		Name:         "finance",
+		ComposeQuota: common.ToPtr(0),
+		Policy:       &OrgPolicy{RequiredPackages: &[]string{"falcon-sensor"}},
+		Members: &[]WorkspaceMember{
+			{Email: "builder@user.user", Role: Builder},
+			{Email: "viewer@user.user", Role: Viewer},
+		},
+	}
+	respStatusCode, resp := tutils.ResponseBody(t, http.MethodPost, apiURL("/workspaces"), builder, request)
+	require.Equal(t, http.StatusForbidden, respStatusCode, resp)
+	respStatusCode, resp = tutils.ResponseBody(t, http.MethodPost, apiURL("/workspaces"), admin, request)
+	require.Equal(t, http.StatusCreated, respStatusCode, resp)
+	var workspace Workspace
+	require.NoError(t, json.Unmarshal([]byte(resp), &workspace))
+	require.Equal(t, "admin@user.user", workspace.CreatedBy)
+	require.Len(t, workspace.Members, 2)
+	respStatusCode, _ = tutils.ResponseBody(t, http.MethodPost, apiURL("/workspaces"), admin, request)
+	require.Equal(t, http.StatusUnprocessableEntity, respStatusCode)
+
+	listed := func(auth string) []Workspace {
+		respStatusCode, resp := tutils.ResponseBody(t, http.MethodGet, apiURL("/workspaces"), auth, nil)
+		require.Equal(t, http.StatusOK, respStatusCode, resp)
+		var result WorkspacesResponse
+		require.NoError(t, json.Unmarshal([]byte(resp), &result))
+		return result.Data
+	}
+	require.Len(t, listed(admin), 1)
+	require.Len(t, listed(viewer), 1)
+	require.Empty(t, listed(outsider))
+	respStatusCode, _ = tutils.ResponseBody(t, http.MethodGet, apiURL("/workspaces/%s", workspace.Id), viewer, nil)
+	require.Equal(t, http.StatusOK, respStatusCode)
+	respStatusCode, _ = tutils.ResponseBody(t, http.MethodGet, apiURL("/workspaces/%s", workspace.Id), outsider, nil)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+
+	body := map[string]interface{}{
+		"name":           "ledger",
+		"customizations": map[string]interface{}{},
+		"distribution":   "centos-9",
+		"workspace_id":   workspace.Id,
+		"image_requests": []map[string]interface{}{
+			{
+				"architecture":   "x86_64",
+				"image_type":     "guest-image",
+				"upload_request": map[string]interface{}{"type": "aws.s3", "options": map[string]interface{}{}},
+			},
+		},
+	}
+	// only builders add blueprints to the workspace
+	respStatusCode, _ = tutils.ResponseBody(t, http.MethodPost, apiURL("/blueprints"), viewer, body)
+	require.Equal(t, http.StatusForbidden, respStatusCode)
+	respStatusCode, _ = tutils.ResponseBody(t, http.MethodPost, apiURL("/blueprints"), outsider, body)
+	require.Equal(t, http.StatusForbidden, respStatusCode)
+	respStatusCode, resp = tutils.ResponseBody(t, http.MethodPost, apiURL("/blueprints"), builder, body)
+	require.Equal(t, http.StatusCreated, respStatusCode, resp)
+	var created CreateBlueprintResponse
+	require.NoError(t, json.Unmarshal([]byte(resp), &created))
+
+	respStatusCode, resp = tutils.ResponseBody(t, http.MethodGet, apiURL("/blueprints/%s", created.Id), viewer, nil)
+	require.Equal(t, http.StatusOK, respStatusCode, resp)
+	var blueprint BlueprintResponse
+	require.NoError(t, json.Unmarshal([]byte(resp), &blueprint))
+	require.Equal(t, workspace.Id, *blueprint.WorkspaceId)
+	respStatusCode, _ = tutils.ResponseBody(t, http.MethodGet, apiURL("/blueprints/%s", created.Id), outsider, nil)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+
+	blueprints := func(auth, query string) []BlueprintItem {
+		respStatusCode, resp := tutils.ResponseBody(t, http.MethodGet, apiURL("/blueprints%s", query), auth, nil)
+		require.Equal(t, http.StatusOK, respStatusCode, resp)
+		var result BlueprintsResponse
+		require.NoError(t, json.Unmarshal([]byte(resp), &result))
+		return result.Data
+	}
+	require.Len(t, blueprints(viewer, "?workspace_id="+workspace.Id.String()), 1)
+	require.Len(t, blueprints(admin, ""), 1)
+	require.Empty(t, blueprints(outsider, ""))
+	require.Empty(t, blueprints(outsider, "?name=ledger"))
+	require.Len(t, blueprints(viewer, "?name=ledger"), 1)
+
+	// viewers don't compose, the builders are held to the quota and the
+	// policy of the workspace
+	respStatusCode, _ = tutils.ResponseBody(t, http.MethodPost, apiURL("/blueprints/%s/compose", created.Id), viewer, map[string]interface{}{})
+	require.Equal(t, http.StatusForbidden, respStatusCode)
+	respStatusCode, resp = tutils.ResponseBody(t, http.MethodPost, apiURL("/blueprints/%s/compose", created.Id), builder, map[string]interface{}{})
+	require.Equal(t, http.StatusForbidden, respStatusCode)
+	require.Contains(t, resp, "Quota exceeded for workspace")
+	request.ComposeQuota = nil
+	respStatusCode, resp = tutils.ResponseBody(t, http.MethodPut, apiURL("/workspaces/%s", workspace.Id), admin, request)
+	require.Equal(t, http.StatusOK, respStatusCode, resp)
+	respStatusCode, resp = tutils.ResponseBody(t, http.MethodPost, apiURL("/blueprints/%s/compose", created.Id), builder, map[string]interface{}{})
+	require.Equal(t, http.StatusForbidden, respStatusCode)
+	require.Contains(t, resp, "Workspace policy requires package falcon-sensor")
+
+	// blueprints stay in their workspace
+	body["workspace_id"] = uuid.New()
+	respStatusCode, _ = tutils.ResponseBody(t, http.MethodPut, apiURL("/blueprints/%s", created.Id), builder, body)
+	require.Equal(t, http.StatusBadRequest, respStatusCode)
+
+	respStatusCode, _ = tutils.ResponseBody(t, http.MethodDelete, apiURL("/workspaces/%s", workspace.Id), admin, nil)
+	require.Equal(t, http.StatusConflict, respStatusCode)
+	respStatusCode, _ = tutils.ResponseBody(t, http.MethodDelete, apiURL("/blueprints/%s", created.Id), viewer, nil)
+	require.Equal(t, http.StatusForbidden, respStatusCode)
+	respStatusCode, _ = tutils.ResponseBody(t, http.MethodDelete, apiURL("/blueprints/%s", created.Id), builder, nil)
+	require.Equal(t, http.StatusNoContent, respStatusCode)
+	respStatusCode, _ = tutils.ResponseBody(t, http.MethodDelete, apiURL("/workspaces/%s", workspace.Id), admin, nil)
+	require.Equal(t, http.StatusNoContent, respStatusCode)
+}
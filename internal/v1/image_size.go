@@ -0,0 +1,117 @@
+package v1
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/common"
+)
+
+const (
+	mib = 1024 * 1024
+	gib = 1024 * mib
+
+	// What a package adds to an image on average, package lists don't carry
+	// sizes so this has to do.
+	averagePackageSize = 5 * mib
+
+	// Fallback for image types missing in baseImageSizes
+	defaultBaseImageSize = 1 * gib
+)
+
+// Space used by a default installation of each image type, before any
+// customizations. The cloud images come with their agents preinstalled.
+var baseImageSizes = map[ImageTypes]uint64{
+	ImageTypesAws:            1536 * mib,
+	ImageTypesAzure:          1536 * mib,
+	ImageTypesGcp:            1536 * mib,
+	ImageTypesGuestImage:     1 * gib,
+	ImageTypesImageInstaller: 1 * gib,
+	ImageTypesOci:            1 * gib,
+	ImageTypesVsphere:        1 * gib,
+	ImageTypesVsphereOva:     1 * gib,
+}
+
+// imageSizePrediction breaks down the space the contents of an image will
+// need, so errors can tell where it went.
+type imageSizePrediction struct {
+	Base     uint64
+	Packages uint64
+	Files    uint64
+}
+
+func (p imageSizePrediction) Total() uint64 {
+	return p.Base + p.Packages + p.Files
+}
+
+func (p imageSizePrediction) String() string {
+	return fmt.Sprintf("%d bytes (base image %d, packages %d, files %d)", p.Total(), p.Base, p.Packages, p.Files)
+}
+
+func predictImageSize(cr *ComposeRequest) imageSizePrediction {
+	base, ok := baseImageSizes[canonicalImageType(cr.ImageRequests[0].ImageType)]
+	if !ok {
+		base = defaultBaseImageSize
+	}
+	prediction := imageSizePrediction{
+		Base: base,
+	}
+
+	cust := cr.Customizations
+	if cust == nil {
+		return prediction
+	}
+	if cust.Packages != nil {
+		prediction.Packages = uint64(len(*cust.Packages)) * averagePackageSize
+	}
+	if cust.Files != nil {
+		for _, f := range *cust.Files {
+			if f.Data == nil {
+				continue
+			}
+			if f.DataEncoding != nil && *f.DataEncoding == Base64 {
+				prediction.Files += uint64(base64.StdEncoding.DecodedLen(len(*f.Data)))
+			} else {
+				prediction.Files += uint64(len(*f.Data))
+			}
+		}
+	}
+	return prediction
+}
+
+// validateImageSize rejects filesystem customizations which can't hold the
+// contents of the image.
+func validateImageSize(cr *ComposeRequest) error {
+	cust := cr.Customizations
+	if cust != nil && cust.Filesystem != nil {
+		var total uint64
+		hasRoot := false
+		for _, fs := range *cust.Filesystem {
+			total += fs.MinSize
+			if fs.Mountpoint == "/" {
+				hasRoot = true
+			}
+		}
+
+		// without a root filesystem its default size applies, which grows
+		// as needed
+		prediction := predictImageSize(cr)
+		if hasRoot && total < prediction.Total() {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Filesystems total %d bytes, but the image is predicted to need %s", total, prediction))
+		}
+	}
+
+	return nil
+}
+
+// alignImageSize rounds the size up to what the upload target accepts, Azure
+// only takes VHDs with a virtual size aligned to 1 MiB.
+func alignImageSize(ir ImageRequest) *uint64 {
+	if ir.Size == nil || canonicalImageType(ir.ImageType) != ImageTypesAzure || *ir.Size%mib == 0 {
+		return ir.Size
+	}
+	return common.ToPtr((*ir.Size/mib + 1) * mib)
+}
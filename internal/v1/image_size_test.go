@@ -0,0 +1,71 @@
+package v1
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/common"
+)
+
+func TestPredictImageSize(t *testing.T) {
+	cr := &ComposeRequest{
+		ImageRequests: []ImageRequest{
+			{ImageType: ImageTypesAmi},
+		},
+		Customizations: &Customizations{
+			Packages: &[]string{"vim", "nginx"},
+			Files: &[]File{
+				{Path: "/etc/plain", Data: common.ToPtr("hello")},
+				{Path: "/etc/encoded", Data: common.ToPtr(base64.StdEncoding.EncodeToString([]byte("hello world!"))), DataEncoding: common.ToPtr(Base64)},
+				{Path: "/etc/empty"},
+			},
+		},
+	}
+	require.Equal(t, imageSizePrediction{
+		Base:     1536 * mib,
+		Packages: 2 * averagePackageSize,
+		Files:    17,
+	}, predictImageSize(cr))
+
+	cr.ImageRequests[0].ImageType = ImageTypesWsl
+	cr.Customizations = nil
+	require.Equal(t, uint64(defaultBaseImageSize), predictImageSize(cr).Total())
+}
+
+func TestValidateImageSize(t *testing.T) {
+	packages := make([]string, 200)
+	for i := range packages {
+		packages[i] = fmt.Sprintf("package-%d", i)
+	}
+	cr := func(it ImageTypes, size *uint64, fs ...Filesystem) *ComposeRequest {
+		return &ComposeRequest{
+			ImageRequests: []ImageRequest{
+				{ImageType: it, Size: size},
+			},
+			Customizations: &Customizations{
+				Packages:   &packages,
+				Filesystem: &fs,
+			},
+		}
+	}
+
+	// 1 GiB base and 1000 MiB of packages
+	require.NoError(t, validateImageSize(cr(ImageTypesGuestImage, nil, Filesystem{Mountpoint: "/", MinSize: 3 * gib})))
+	require.NoError(t, validateImageSize(cr(ImageTypesGuestImage, nil, Filesystem{Mountpoint: "/", MinSize: 1 * gib}, Filesystem{Mountpoint: "/var", MinSize: 1 * gib})))
+	err := validateImageSize(cr(ImageTypesGuestImage, nil, Filesystem{Mountpoint: "/", MinSize: 1 * gib}))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), fmt.Sprintf("Filesystems total %d bytes, but the image is predicted to need %d bytes (base image %d, packages %d, files 0)", gib, gib+200*averagePackageSize, gib, 200*averagePackageSize))
+	// the root filesystem isn't limited
+	require.NoError(t, validateImageSize(cr(ImageTypesGuestImage, nil, Filesystem{Mountpoint: "/var", MinSize: 1 * gib})))
+
+}
+
+func TestAlignImageSize(t *testing.T) {
+	require.Nil(t, alignImageSize(ImageRequest{ImageType: ImageTypesAzure}))
+	require.Equal(t, uint64(10*gib), *alignImageSize(ImageRequest{ImageType: ImageTypesAzure, Size: common.ToPtr(uint64(10 * gib))}))
+	require.Equal(t, uint64(10*gib+mib), *alignImageSize(ImageRequest{ImageType: ImageTypesVhd, Size: common.ToPtr(uint64(10*gib + 1))}))
+	require.Equal(t, uint64(10*gib+1), *alignImageSize(ImageRequest{ImageType: ImageTypesGuestImage, Size: common.ToPtr(uint64(10*gib + 1))}))
+}
@@ -0,0 +1,136 @@
+package v1
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/osbuild/image-builder/internal/common"
+)
+
+var (
+	launchUploadTypes = map[GetBlueprintInstanceTypesParamsProvider]UploadTypes{
+		GetBlueprintInstanceTypesParamsProviderAws:   UploadTypesAws,
+		GetBlueprintInstanceTypesParamsProviderAzure: UploadTypesAzure,
+		GetBlueprintInstanceTypesParamsProviderGcp:   UploadTypesGcp,
+	}
+
+	// Provisioning doesn't know which instance types can run virtual
+	// machines, the families documented by the providers are recognized by
+	// their names instead: bare metal on aws, the intel based families on gcp
+	// and the v3 and newer D, E, F and M series on azure.
+	nestedVirtualizationFamilies = map[GetBlueprintInstanceTypesParamsProvider]*regexp.Regexp{
+		GetBlueprintInstanceTypesParamsProviderAws:   regexp.MustCompile(`\.metal(-\d+xl)?$`),
+		GetBlueprintInstanceTypesParamsProviderAzure: regexp.MustCompile(`^Standard_[DEFM]\d+[a-oq-z]*_v([3-9]|\d{2,})$`),
+		GetBlueprintInstanceTypesParamsProviderGcp:   regexp.MustCompile(`^(n1|n2|n4|c2|c3|c4|m1|m2|m3)-`),
+	}
+)
+
+// launchImage is an image of the blueprint instances can be launched from.
+type launchImage struct {
+	architecture string
+	// Hyper-V generation of azure images
+	generation AzureUploadRequestOptionsHyperVGeneration
+}
+
+// blueprintLaunchImages returns the images the blueprint builds for the
+// provider, one per architecture of the image requests.
+func blueprintLaunchImages(blueprint BlueprintBody, provider GetBlueprintInstanceTypesParamsProvider) ([]launchImage, error) {
+	var images []launchImage
+	for _, ir := range blueprint.ImageRequests {
+		if ir.UploadRequest.Type != launchUploadTypes[provider] {
+			continue
+		}
+		architectures := []ImageRequestArchitecture{ir.Architecture}
+		for _, arch := range common.FromPtr(ir.AdditionalArchitectures) {
+			architectures = append(architectures, ImageRequestArchitecture(arch))
+		}
+		for _, arch := range architectures {
+			image := launchImage{
+				architecture: string(arch),
+			}
+			if provider == GetBlueprintInstanceTypesParamsProviderAzure {
+				uo, err := ir.UploadRequest.Options.AsAzureUploadRequestOptions()
+				if err != nil {
+					return nil, err
+				}
+				// composer builds V1 images unless told otherwise
+				image.generation = V1
+				generation, err := azureHyperVGeneration(uo.HyperVGeneration, arch)
+				if err != nil {
+					return nil, err
+				}
+				if generation != nil {
+					image.generation = AzureUploadRequestOptionsHyperVGeneration(*generation)
+				}
+			}
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
+// offeredInstanceType is an instance type as provisioning lists it.
+type offeredInstanceType struct {
+	InstanceType
+	supported bool
+	// Hyper-V generations of the images azure instance types can boot
+	generations []AzureUploadRequestOptionsHyperVGeneration
+}
+
+// instanceTypeFeatures returns the launch features of the instance type.
+func instanceTypeFeatures(provider GetBlueprintInstanceTypesParamsProvider, name string, storageGb int) []LaunchFeature {
+	features := []LaunchFeature{}
+	if storageGb > 0 {
+		features = append(features, LocalStorage)
+	}
+	if nestedVirtualizationFamilies[provider].MatchString(name) {
+		features = append(features, NestedVirtualization)
+	}
+	return features
+}
+
+// unmetLaunchRequirements explains why instances of the type can't be
+// launched from the images, it's empty if they can.
+func unmetLaunchRequirements(requirements *LaunchRequirements, images []launchImage, it offeredInstanceType) []string {
+	var unmet []string
+	if !it.supported {
+		unmet = append(unmet, fmt.Sprintf("%s is not supported by Red Hat", it.Name))
+	}
+
+	var generations []AzureUploadRequestOptionsHyperVGeneration
+	bootable := false
+	for _, image := range images {
+		if image.architecture != it.Architecture {
+			continue
+		}
+		if image.generation == "" || slices.Contains(it.generations, image.generation) {
+			bootable = true
+			break
+		}
+		generations = append(generations, image.generation)
+	}
+	if !bootable {
+		if len(generations) == 0 {
+			unmet = append(unmet, fmt.Sprintf("The blueprint has no %s image for %s", it.Architecture, it.Name))
+		} else {
+			unmet = append(unmet, fmt.Sprintf("%s doesn't support Hyper-V generation %s", it.Name, generations[0]))
+		}
+	}
+
+	if requirements == nil {
+		return unmet
+	}
+	if requirements.MinVcpus != nil && it.Vcpus < *requirements.MinVcpus {
+		unmet = append(unmet, fmt.Sprintf("The blueprint needs at least %d vCPUs, %s has %d", *requirements.MinVcpus, it.Name, it.Vcpus))
+	}
+	if requirements.MinMemoryMib != nil && it.MemoryMib < *requirements.MinMemoryMib {
+		unmet = append(unmet, fmt.Sprintf("The blueprint needs at least %d MiB of memory, %s has %d MiB", *requirements.MinMemoryMib, it.Name, it.MemoryMib))
+	}
+	for _, feature := range common.FromPtr(requirements.Features) {
+		if !slices.Contains(it.Features, feature) {
+			unmet = append(unmet, fmt.Sprintf("%s doesn't support %s", it.Name, feature))
+		}
+	}
+	return unmet
+}
@@ -0,0 +1,101 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/common"
+)
+
+func TestInstanceTypeFeatures(t *testing.T) {
+	aws := GetBlueprintInstanceTypesParamsProviderAws
+	azure := GetBlueprintInstanceTypesParamsProviderAzure
+	gcp := GetBlueprintInstanceTypesParamsProviderGcp
+
+	require.Equal(t, []LaunchFeature{}, instanceTypeFeatures(aws, "t3.small", 0))
+	require.Equal(t, []LaunchFeature{LocalStorage}, instanceTypeFeatures(aws, "c5d.large", 50))
+	require.Equal(t, []LaunchFeature{NestedVirtualization}, instanceTypeFeatures(aws, "c5.metal", 0))
+	require.Equal(t, []LaunchFeature{NestedVirtualization}, instanceTypeFeatures(aws, "m7i.metal-24xl", 0))
+	require.Equal(t, []LaunchFeature{NestedVirtualization}, instanceTypeFeatures(azure, "Standard_D4s_v3", 0))
+	require.Equal(t, []LaunchFeature{}, instanceTypeFeatures(azure, "Standard_D2_v2", 0))
+	require.Equal(t, []LaunchFeature{}, instanceTypeFeatures(azure, "Standard_D4ps_v5", 0))
+	require.Equal(t, []LaunchFeature{NestedVirtualization}, instanceTypeFeatures(gcp, "n2-standard-4", 0))
+	require.Equal(t, []LaunchFeature{}, instanceTypeFeatures(gcp, "e2-medium", 0))
+}
+
+func TestBlueprintLaunchImages(t *testing.T) {
+	var aws, azure UploadRequest_Options
+	require.NoError(t, aws.FromAWSUploadRequestOptions(AWSUploadRequestOptions{}))
+	require.NoError(t, azure.FromAzureUploadRequestOptions(AzureUploadRequestOptions{}))
+	blueprint := BlueprintBody{
+		ImageRequests: []ImageRequest{
+			{
+				Architecture:            ImageRequestArchitectureX8664,
+				AdditionalArchitectures: &[]ImageRequestAdditionalArchitectures{ImageRequestAdditionalArchitecturesAarch64},
+				ImageType:               ImageTypesAws,
+				UploadRequest:           UploadRequest{Type: UploadTypesAws, Options: aws},
+			},
+			{
+				Architecture:            ImageRequestArchitectureX8664,
+				AdditionalArchitectures: &[]ImageRequestAdditionalArchitectures{ImageRequestAdditionalArchitecturesAarch64},
+				ImageType:               ImageTypesAzure,
+				UploadRequest:           UploadRequest{Type: UploadTypesAzure, Options: azure},
+			},
+		},
+	}
+
+	images, err := blueprintLaunchImages(blueprint, GetBlueprintInstanceTypesParamsProviderAws)
+	require.NoError(t, err)
+	require.Equal(t, []launchImage{{architecture: "x86_64"}, {architecture: "aarch64"}}, images)
+	images, err = blueprintLaunchImages(blueprint, GetBlueprintInstanceTypesParamsProviderAzure)
+	require.NoError(t, err)
+	require.Equal(t, []launchImage{{architecture: "x86_64", generation: V1}, {architecture: "aarch64", generation: V2}}, images)
+	images, err = blueprintLaunchImages(blueprint, GetBlueprintInstanceTypesParamsProviderGcp)
+	require.NoError(t, err)
+	require.Empty(t, images)
+}
+
+func TestUnmetLaunchRequirements(t *testing.T) {
+	images := []launchImage{{architecture: "x86_64", generation: V2}}
+	it := offeredInstanceType{
+		InstanceType: InstanceType{
+			Name:         "Standard_D2s_v3",
+			Architecture: "x86_64",
+			Vcpus:        2,
+			MemoryMib:    8192,
+			Features:     []LaunchFeature{NestedVirtualization},
+		},
+		supported:   true,
+		generations: []AzureUploadRequestOptionsHyperVGeneration{V1, V2},
+	}
+	require.Empty(t, unmetLaunchRequirements(nil, images, it))
+	require.Empty(t, unmetLaunchRequirements(&LaunchRequirements{
+		MinVcpus:     common.ToPtr(2),
+		MinMemoryMib: common.ToPtr(8192),
+		Features:     &[]LaunchFeature{NestedVirtualization},
+	}, images, it))
+
+	require.Equal(t, []string{
+		"The blueprint needs at least 4 vCPUs, Standard_D2s_v3 has 2",
+		"The blueprint needs at least 16384 MiB of memory, Standard_D2s_v3 has 8192 MiB",
+		"Standard_D2s_v3 doesn't support local-storage",
+	}, unmetLaunchRequirements(&LaunchRequirements{
+		MinVcpus:     common.ToPtr(4),
+		MinMemoryMib: common.ToPtr(16384),
+		Features:     &[]LaunchFeature{NestedVirtualization, LocalStorage},
+	}, images, it))
+
+	it.supported = false
+	it.generations = []AzureUploadRequestOptionsHyperVGeneration{V1}
+	require.Equal(t, []string{
+		"Standard_D2s_v3 is not supported by Red Hat",
+		"Standard_D2s_v3 doesn't support Hyper-V generation V2",
+	}, unmetLaunchRequirements(nil, images, it))
+
+	it.supported = true
+	it.Architecture = "aarch64"
+	require.Equal(t, []string{
+		"The blueprint has no aarch64 image for Standard_D2s_v3",
+	}, unmetLaunchRequirements(nil, images, it))
+}
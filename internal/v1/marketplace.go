@@ -0,0 +1,195 @@
+package v1
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/osbuild/image-builder/internal/common"
+)
+
+const (
+	defaultMarketplaceInstanceType = "t3.medium"
+	defaultMarketplaceSSHPort      = 22
+)
+
+// fileContent returns the content of a file customization as it ends up in
+// the image.
+func fileContent(f File) string {
+	data := common.FromPtr(f.Data)
+	if f.DataEncoding != nil && *f.DataEncoding == Base64 {
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err == nil {
+			return string(decoded)
+		}
+	}
+	return data
+}
+
+// cloudInitPassword returns the key of a cloud-init config which sets or
+// unlocks passwords, if there's one.
+func cloudInitPassword(config string) (string, bool) {
+	for _, line := range strings.Split(config, "\n") {
+		key, value, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), "- "), ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.ToLower(strings.TrimSpace(value))
+		switch key {
+		case "chpasswd", "passwd", "plain_text_passwd", "hashed_passwd":
+			return key, true
+		case "lock_passwd":
+			if value == "false" {
+				return key, true
+			}
+		case "ssh_pwauth":
+			if value == "true" || value == "yes" {
+				return key, true
+			}
+		}
+	}
+	return "", false
+}
+
+// sshdDirectives returns the lowercased directives of an sshd config, by
+// keyword, the first one of a keyword wins like in sshd.
+func sshdDirectives(config string) map[string]string {
+	directives := map[string]string{}
+	for _, line := range strings.Split(config, "\n") {
+		fields := strings.Fields(strings.ToLower(line))
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if _, ok := directives[fields[0]]; !ok {
+			directives[fields[0]] = fields[1]
+		}
+	}
+	return directives
+}
+
+// marketplaceChecks runs the self-service scans of AWS Marketplace on what
+// the compose request puts into the image, the image itself is left as the
+// distribution ships it, which passes them.
+func marketplaceChecks(cr *ComposeRequest) []MarketplaceCheck {
+	var passwords, keys, sshd []string
+	if cust := cr.Customizations; cust != nil {
+		if cust.Users != nil {
+			for _, u := range *cust.Users {
+				if u.SshKey != "" {
+					keys = append(keys, fmt.Sprintf("User %s has an SSH key embedded", u.Name))
+				}
+			}
+		}
+		if cust.Files != nil {
+			for _, f := range *cust.Files {
+				content := fileContent(f)
+				switch {
+				case f.Path == "/etc/shadow" || f.Path == "/etc/gshadow":
+					passwords = append(passwords, fmt.Sprintf("File %s sets passwords", f.Path))
+				case strings.HasPrefix(f.Path, "/etc/cloud/"):
+					if key, ok := cloudInitPassword(content); ok {
+						passwords = append(passwords, fmt.Sprintf("File %s sets passwords through cloud-init (%s)", f.Path, key))
+					}
+				case path.Base(f.Path) == "authorized_keys" || path.Base(f.Path) == "authorized_keys2":
+					keys = append(keys, fmt.Sprintf("File %s embeds SSH keys", f.Path))
+				case f.Path == "/etc/ssh/sshd_config" || strings.HasPrefix(f.Path, "/etc/ssh/sshd_config.d/"):
+					directives := sshdDirectives(content)
+					for _, keyword := range []string{"passwordauthentication", "permitemptypasswords", "permitrootlogin"} {
+						if directives[keyword] == "yes" {
+							sshd = append(sshd, fmt.Sprintf("File %s enables %s", f.Path, keyword))
+						}
+					}
+				}
+			}
+		}
+		// credentials embedded anywhere are as good as default passwords
+		passwords = append(passwords, scanComposeRequest(cr)...)
+		if cust.Services != nil {
+			for _, services := range []*[]string{cust.Services.Disabled, cust.Services.Masked} {
+				if services != nil && slices.Contains(*services, "sshd") {
+					sshd = append(sshd, "The sshd service is disabled, instances can't be logged into")
+				}
+			}
+		}
+	}
+
+	check := func(name MarketplaceCheckName, details []string) MarketplaceCheck {
+		c := MarketplaceCheck{
+			Name:   name,
+			Passed: len(details) == 0,
+		}
+		if len(details) > 0 {
+			c.Details = &details
+		}
+		return c
+	}
+	return []MarketplaceCheck{
+		check(MarketplaceCheckNameDefaultPasswords, passwords),
+		check(MarketplaceCheckNameSshKeys, keys),
+		check(MarketplaceCheckNameSshdConfig, sshd),
+	}
+}
+
+// marketplaceOperatingSystem returns the operating system of the
+// distribution in the terms of AWS Marketplace.
+func marketplaceOperatingSystem(distribution Distributions) (string, string) {
+	name, version, _ := strings.Cut(strings.TrimSuffix(string(distribution), "-nightly"), "-")
+	switch name {
+	case "rhel":
+		// rhel-94 is 9.4, minor versions of rhel 10 have a dot
+		if len(version) == 2 && (version[0] == '8' || version[0] == '9') {
+			version = version[:1] + "." + version[1:]
+		}
+		return "REDHAT", version
+	default:
+		return strings.ToUpper(name), version
+	}
+}
+
+// marketplaceManifest returns the details of the AddDeliveryOptions change
+// of the AWS Marketplace Catalog API which adds the AMI as a new version.
+func marketplaceManifest(ami string, distribution Distributions, request *MarketplaceSubmissionRequest) map[string]interface{} {
+	osName, osVersion := marketplaceOperatingSystem(distribution)
+	port := common.FromPtr(request.SshPort)
+	if port == 0 {
+		port = defaultMarketplaceSSHPort
+	}
+	instanceType := common.FromPtr(request.RecommendedInstanceType)
+	if instanceType == "" {
+		instanceType = defaultMarketplaceInstanceType
+	}
+	return map[string]interface{}{
+		"Version": map[string]interface{}{
+			"VersionTitle": request.VersionTitle,
+			"ReleaseNotes": request.ReleaseNotes,
+		},
+		"DeliveryOptions": []interface{}{
+			map[string]interface{}{
+				"Details": map[string]interface{}{
+					"AmiDeliveryOptionDetails": map[string]interface{}{
+						"AmiSource": map[string]interface{}{
+							"AmiId":                  ami,
+							"AccessRoleArn":          request.AccessRoleArn,
+							"UserName":               request.Username,
+							"OperatingSystemName":    osName,
+							"OperatingSystemVersion": osVersion,
+						},
+						"UsageInstructions":       request.UsageInstructions,
+						"RecommendedInstanceType": instanceType,
+						"SecurityGroups": []interface{}{
+							map[string]interface{}{
+								"IpProtocol": "tcp",
+								"FromPort":   port,
+								"ToPort":     port,
+								"IpRanges":   []string{"0.0.0.0/0"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,157 @@
+package v1
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func TestMarketplaceChecks(t *testing.T) {
+	passed := func(checks []MarketplaceCheck) map[MarketplaceCheckName]bool {
+		result := map[MarketplaceCheckName]bool{}
+		for _, c := range checks {
+			result[c.Name] = c.Passed
+		}
+		return result
+	}
+
+	// what the distribution ships passes
+	cr := ComposeRequest{
+		Customizations: &Customizations{
+			Files: &[]File{
+				{Path: "/etc/ssh/sshd_config.d/50-hardening.conf", Data: common.ToPtr("PasswordAuthentication no\nPermitRootLogin no\n")},
+				{Path: "/etc/cloud/cloud.cfg.d/10-users.cfg", Data: common.ToPtr("users:\n  - name: cloud-user\n    lock_passwd: true\nssh_pwauth: false\n")},
+			},
+			Services: &Services{Enabled: &[]string{"sshd"}},
+		},
+	}
+	for name, ok := range passed(marketplaceChecks(&cr)) {
+		require.True(t, ok, name)
+	}
+	require.Len(t, marketplaceChecks(&ComposeRequest{}), 3)
+
+	cr.Customizations = &Customizations{
+		Users: &[]User{{Name: "admin", SshKey: "ssh-ed25519 AAAA"}},
+		Files: &[]File{
+			{Path: "/etc/shadow", Data: common.ToPtr("root:$6$salt$hash:19000::::::\n")},
+			{Path: "/etc/cloud/cloud.cfg.d/99-pw.cfg", Data: common.ToPtr(base64.StdEncoding.EncodeToString([]byte("chpasswd:\n  expire: false\n"))), DataEncoding: common.ToPtr(Base64)},
+			{Path: "/root/.ssh/authorized_keys", Data: common.ToPtr("ssh-ed25519 AAAA")},
+			// the first directive of a keyword wins
+			{Path: "/etc/ssh/sshd_config.d/01-pw.conf", Data: common.ToPtr("# PasswordAuthentication no\nPasswordAuthentication yes\nPasswordAuthentication no\n")},
+		},
+		Services: &Services{Masked: &[]string{"sshd"}},
+	}
+	checks := marketplaceChecks(&cr)
+	for name, ok := range passed(checks) {
+		require.False(t, ok, name)
+	}
+	require.Equal(t, []string{"File /etc/shadow sets passwords", "File /etc/cloud/cloud.cfg.d/99-pw.cfg sets passwords through cloud-init (chpasswd)"}, *checks[0].Details)
+	require.Equal(t, []string{"User admin has an SSH key embedded", "File /root/.ssh/authorized_keys embeds SSH keys"}, *checks[1].Details)
+	require.Equal(t, []string{"File /etc/ssh/sshd_config.d/01-pw.conf enables passwordauthentication", "The sshd service is disabled, instances can't be logged into"}, *checks[2].Details)
+}
+
+func TestMarketplaceOperatingSystem(t *testing.T) {
+	for distribution, expected := range map[Distributions][2]string{
+		Rhel94:        {"REDHAT", "9.4"},
+		Rhel810:       {"REDHAT", "8.10"},
+		Rhel9:         {"REDHAT", "9"},
+		Rhel10Nightly: {"REDHAT", "10"},
+		Centos9:       {"CENTOS", "9"},
+		Fedora41:      {"FEDORA", "41"},
+	} {
+		name, version := marketplaceOperatingSystem(distribution)
+		require.Equal(t, expected, [2]string{name, version}, distribution)
+	}
+}
+
+func TestCreateMarketplaceSubmission(t *testing.T) {
+	ctx := context.Background()
+	composerURL := mockService(t, func(w http.ResponseWriter, r *http.Request) {
+		if "Bearer" == r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var us composer.UploadStatus
+		require.NoError(t, us.Options.FromAWSEC2UploadStatus(composer.AWSEC2UploadStatus{
+			Ami:    "ami-0c830793775595d4b",
+			Region: "us-east-1",
+		}))
+		us.Type = composer.UploadTypesAws
+		us.Status = composer.Success
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(composer.ComposeStatus{
+			ImageStatus: composer.ImageStatus{
+				Status:       composer.ImageStatusValueSuccess,
+				UploadStatus: &us,
+			},
+		}))
+	})
+
+	dbase, err := dbc.NewDB()
+	require.NoError(t, err)
+	clean := uuid.New()
+	withKey := uuid.New()
+	err = dbase.InsertCompose(ctx, clean, "500000", "user500000@test.test", "000000", nil, json.RawMessage(`{"distribution": "rhel-94", "image_requests": [{"architecture": "x86_64", "image_type": "aws"}]}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+	err = dbase.InsertCompose(ctx, withKey, "500000", "user500000@test.test", "000000", nil, json.RawMessage(`{"distribution": "rhel-94", "image_requests": [{"architecture": "x86_64", "image_type": "aws"}], "customizations": {"users": [{"name": "admin", "ssh_key": "ssh-ed25519 AAAA"}]}}`), nil, nil, nil, nil)
+	require.NoError(t, err)
+	startTestServer(t, &testServerClientsConf{ComposerURL: composerURL}, &ServerConfig{DBase: dbase})
+
+	request := MarketplaceSubmissionRequest{
+		VersionTitle:      "1.0.0",
+		ReleaseNotes:      "first release",
+		AccessRoleArn:     "arn:aws:iam::123456789012:role/MarketplaceAmiIngestion",
+		Username:          "ec2-user",
+		UsageInstructions: "ssh in as ec2-user",
+	}
+	member := tutils.NewIdentity("000000").OrgAdmin(false).Base64()
+	respStatusCode, body := tutils.ResponseBody(t, http.MethodPost, apiURL("/composes/%s/marketplace-submission", clean), member, request)
+	require.Equal(t, http.StatusForbidden, respStatusCode, body)
+
+	respStatusCode, body = tutils.ResponseBody(t, http.MethodPost, apiURL("/composes/%s/marketplace-submission", clean), tutils.AuthString0, request)
+	require.Equal(t, http.StatusOK, respStatusCode, body)
+	var submission MarketplaceSubmission
+	require.NoError(t, json.Unmarshal([]byte(body), &submission))
+	require.True(t, submission.Ready)
+	require.Len(t, submission.Checks, 4)
+	require.NotNil(t, submission.Manifest)
+	manifest, err := json.Marshal(submission.Manifest)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"Version": {"VersionTitle": "1.0.0", "ReleaseNotes": "first release"},
+		"DeliveryOptions": [{"Details": {"AmiDeliveryOptionDetails": {
+			"AmiSource": {
+				"AmiId": "ami-0c830793775595d4b",
+				"AccessRoleArn": "arn:aws:iam::123456789012:role/MarketplaceAmiIngestion",
+				"UserName": "ec2-user",
+				"OperatingSystemName": "REDHAT",
+				"OperatingSystemVersion": "9.4"
+			},
+			"UsageInstructions": "ssh in as ec2-user",
+			"RecommendedInstanceType": "t3.medium",
+			"SecurityGroups": [{"IpProtocol": "tcp", "FromPort": 22, "ToPort": 22, "IpRanges": ["0.0.0.0/0"]}]
+		}}}]
+	}`, string(manifest))
+
+	// the manifest is only handed out for AMIs which pass
+	respStatusCode, body = tutils.ResponseBody(t, http.MethodPost, apiURL("/composes/%s/marketplace-submission", withKey), tutils.AuthString0, request)
+	require.Equal(t, http.StatusOK, respStatusCode, body)
+	submission = MarketplaceSubmission{}
+	require.NoError(t, json.Unmarshal([]byte(body), &submission))
+	require.False(t, submission.Ready)
+	require.Nil(t, submission.Manifest)
+	require.Equal(t, MarketplaceCheckNameSshKeys, submission.Checks[2].Name)
+	require.False(t, submission.Checks[2].Passed)
+
+	respStatusCode, _ = tutils.ResponseBody(t, http.MethodPost, apiURL("/composes/%s/marketplace-submission", uuid.New()), tutils.AuthString0, request)
+	require.Equal(t, http.StatusNotFound, respStatusCode)
+}
@@ -1,17 +1,61 @@
 package v1
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"sync"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/getkin/kin-openapi/openapi3filter"
 	"github.com/getkin/kin-openapi/routers"
+	legacyrouter "github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/invopop/yaml"
 	"github.com/labstack/echo/v4"
 )
 
+const yamlContentType = "application/yaml"
+
+func init() {
+	// blueprints are patched with merge patches
+	openapi3filter.RegisterBodyDecoder("application/merge-patch+json", openapi3filter.JSONBodyDecoder)
+}
+
+// lazyRouter builds the router of the spec when a request is validated first,
+// which validates the whole spec. Attach builds it in the background, the
+// service isn't ready before it's built.
+type lazyRouter struct {
+	spec   *openapi3.T
+	once   sync.Once
+	router routers.Router
+	err    error
+}
+
+func (r *lazyRouter) build() error {
+	r.once.Do(func() {
+		r.router, r.err = legacyrouter.NewRouter(r.spec)
+	})
+	return r.err
+}
+
+func (r *lazyRouter) FindRoute(req *http.Request) (*routers.Route, map[string]string, error) {
+	if err := r.build(); err != nil {
+		return nil, nil, err
+	}
+	return r.router.FindRoute(req)
+}
+
 func (s *Server) ValidateRequest(nextHandler echo.HandlerFunc) echo.HandlerFunc {
 	return func(ctx echo.Context) error {
 		request := ctx.Request()
 
+		// a spec the router can't be built of is no fault of the request
+		if err := s.router.build(); err != nil {
+			httpErr := echo.NewHTTPError(http.StatusServiceUnavailable, "Unable to validate requests")
+			return httpErr.SetInternal(err)
+		}
 		route, params, err := s.router.FindRoute(request)
 		if err == routers.ErrMethodNotAllowed {
 			return echo.NewHTTPError(http.StatusMethodNotAllowed, err)
@@ -21,6 +65,11 @@ func (s *Server) ValidateRequest(nextHandler echo.HandlerFunc) echo.HandlerFunc
 			return echo.NewHTTPError(http.StatusBadRequest, err)
 		}
 
+		err = yamlRequestToJSON(request, route)
+		if err != nil {
+			return err
+		}
+
 		requestValidationInput := &openapi3filter.RequestValidationInput{
 			Request:    request,
 			PathParams: params,
@@ -35,6 +84,32 @@ func (s *Server) ValidateRequest(nextHandler echo.HandlerFunc) echo.HandlerFunc
 	}
 }
 
+// yamlRequestToJSON converts the yaml request bodies of the operations which
+// accept them, they're validated and bound like json ones afterwards. The
+// others are rejected by the validation.
+func yamlRequestToJSON(request *http.Request, route *routers.Route) error {
+	if request.Header.Get(echo.HeaderContentType) != yamlContentType {
+		return nil
+	}
+	body := route.Operation.RequestBody
+	if body == nil || body.Value.GetMediaType(yamlContentType) == nil {
+		return nil
+	}
+
+	data, err := io.ReadAll(request.Body)
+	if err != nil {
+		return err
+	}
+	data, err = yaml.YAMLToJSON(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("cannot parse request body: %v", err))
+	}
+	request.Body = io.NopCloser(bytes.NewReader(data))
+	request.ContentLength = int64(len(data))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	return nil
+}
+
 func (s *Server) noAssociateAccounts(nextHandler echo.HandlerFunc) echo.HandlerFunc {
 	return func(ctx echo.Context) error {
 		id, err := s.getIdentity(ctx)
@@ -53,3 +128,43 @@ func (s *Server) noAssociateAccounts(nextHandler echo.HandlerFunc) echo.HandlerF
 		return nextHandler(ctx)
 	}
 }
+
+// routes which only read despite their method
+var readOnlySafeRoutes = []string{
+	"/admin/support-bundle/:composeId",
+	"/experimental/recommendations",
+}
+
+// rejectWrites fails mutations in read-only mode, the database they'd write to
+// or composer they'd talk to are failing over.
+func (s *Server) rejectWrites(nextHandler echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		method := ctx.Request().Method
+		if method == http.MethodGet || method == http.MethodHead {
+			return nextHandler(ctx)
+		}
+		for _, route := range readOnlySafeRoutes {
+			if strings.HasSuffix(ctx.Path(), route) {
+				return nextHandler(ctx)
+			}
+		}
+		if err := s.readOnlyError(); err != nil {
+			return err
+		}
+		return nextHandler(ctx)
+	}
+}
+
+// readOnlyError is returned in read-only mode in place of what needs
+// composer or writes to the database, nil otherwise.
+func (s *Server) readOnlyError() error {
+	enabled, reason := s.readOnly.Enabled()
+	if !enabled {
+		return nil
+	}
+	message := "image-builder is in read-only mode"
+	if reason != "" {
+		message = fmt.Sprintf("%s: %s", message, reason)
+	}
+	return echo.NewHTTPError(http.StatusServiceUnavailable, message)
+}
@@ -0,0 +1,39 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazyRouter(t *testing.T) {
+	spec, err := GetSwagger()
+	require.NoError(t, err)
+	router := &lazyRouter{spec: spec}
+
+	// the first route found builds the router
+	req, err := http.NewRequest(http.MethodGet, "/api/image-builder/v1/distributions", nil)
+	require.NoError(t, err)
+	route, _, err := router.FindRoute(req)
+	require.NoError(t, err)
+	require.Equal(t, "/distributions", route.Path)
+	require.NoError(t, router.build())
+}
+
+func TestValidateRequestUnbuiltRouter(t *testing.T) {
+	// the spec lacks everything, the router can't be built of it
+	s := &Server{router: &lazyRouter{spec: &openapi3.T{}}}
+	req := httptest.NewRequest(http.MethodGet, "/api/image-builder/v1/distributions", nil)
+	ctx := echo.New().NewContext(req, httptest.NewRecorder())
+	err := s.ValidateRequest(func(echo.Context) error {
+		t.Fatal("the request was passed on")
+		return nil
+	})(ctx)
+	var httpErr *echo.HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	require.Equal(t, http.StatusServiceUnavailable, httpErr.Code)
+}
@@ -0,0 +1,75 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/osbuild/image-builder/internal/distribution"
+	iberrors "github.com/osbuild/image-builder/pkg/errors"
+)
+
+// packageVersion matches the [epoch:]version[-release] dnf accepts after the
+// name of a package. The version has to start with dotted numbers, names like
+// java-17-openjdk or xorg-x11-fonts-75dpi aren't taken for versions.
+var packageVersion = regexp.MustCompile(`^(\d+:)?\d+(\.\d+)+[\w.+~^]*(-\d[\w.+~^]*)?$`)
+
+// splitPackageVersion returns the name and the version of a package spec
+// like nodejs-18.19.0, the version is empty when none is given.
+func splitPackageVersion(spec string) (string, string) {
+	for i := 0; i < len(spec); i++ {
+		if spec[i] == '-' && i > 0 && packageVersion.MatchString(spec[i+1:]) {
+			return spec[:i], spec[i+1:]
+		}
+	}
+	return spec, ""
+}
+
+// packageConflicts returns the selections among the packages which can't be
+// installed together: packages of a conflict of the distribution and the same
+// package at different versions. dnf would fail the depsolve on either.
+func packageConflicts(d *distribution.DistributionFile, packages []string) []string {
+	names := make([]string, 0, len(packages))
+	versions := map[string][]string{}
+	for _, p := range packages {
+		if strings.HasPrefix(p, "@") {
+			continue
+		}
+		name, version := splitPackageVersion(p)
+		names = append(names, name)
+		if version != "" && !slices.Contains(versions[name], version) {
+			versions[name] = append(versions[name], version)
+		}
+	}
+
+	var conflicts []string
+	for _, c := range d.PackageConflicts {
+		if found := c.Conflicts(names); found != nil {
+			conflicts = append(conflicts, fmt.Sprintf("Packages %s can't be installed together, %s", strings.Join(found, ", "), c.Reason))
+		}
+	}
+	var pinned []string
+	for name, vs := range versions {
+		if len(vs) > 1 {
+			pinned = append(pinned, fmt.Sprintf("Package %s is requested at versions %s, only one of them can be installed", name, strings.Join(vs, ", ")))
+		}
+	}
+	sort.Strings(pinned)
+	return append(conflicts, pinned...)
+}
+
+// validatePackageConflicts rejects conflicting packages before the compose
+// is submitted, saving the round trip to composer for the common mistakes.
+func validatePackageConflicts(d *distribution.DistributionFile, cust *Customizations) error {
+	if cust == nil || cust.Packages == nil {
+		return nil
+	}
+	conflicts := packageConflicts(d, *cust.Packages)
+	if len(conflicts) > 0 {
+		return apiError(iberrors.CodeInvalidCustomization, http.StatusBadRequest, strings.Join(conflicts, "; "))
+	}
+	return nil
+}
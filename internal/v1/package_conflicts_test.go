@@ -0,0 +1,50 @@
+package v1
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/distribution"
+	iberrors "github.com/osbuild/image-builder/pkg/errors"
+)
+
+func TestSplitPackageVersion(t *testing.T) {
+	for spec, expected := range map[string][2]string{
+		"vim":                  {"vim", ""},
+		"nodejs-18.19.0":       {"nodejs", "18.19.0"},
+		"nodejs-18.19.0-1.el9": {"nodejs", "18.19.0-1.el9"},
+		"bash-1:5.1.8":         {"bash", "1:5.1.8"},
+		"java-17-openjdk":      {"java-17-openjdk", ""},
+		"python3.11-pip-22.3":  {"python3.11-pip", "22.3"},
+		"kernel-rt":            {"kernel-rt", ""},
+		"xorg-x11-fonts-75dpi": {"xorg-x11-fonts-75dpi", ""},
+		"nodejs-18":            {"nodejs-18", ""},
+	} {
+		name, version := splitPackageVersion(spec)
+		require.Equal(t, expected, [2]string{name, version}, spec)
+	}
+}
+
+func TestValidatePackageConflicts(t *testing.T) {
+	d := &distribution.DistributionFile{
+		PackageConflicts: []distribution.PackageConflict{
+			{Packages: []string{"curl", "curl-minimal"}, Reason: "curl-minimal only supports the common protocols"},
+		},
+	}
+	require.NoError(t, validatePackageConflicts(d, nil))
+	require.NoError(t, validatePackageConflicts(d, &Customizations{Packages: &[]string{"curl", "vim", "vim", "nodejs-18.19.0", "nodejs-18.19.0", "@core"}}))
+	require.NoError(t, validatePackageConflicts(d, &Customizations{Packages: &[]string{"xorg-x11-fonts-75dpi", "xorg-x11-fonts-100dpi"}}))
+
+	err := validatePackageConflicts(d, &Customizations{Packages: &[]string{"curl-minimal-8.0", "vim", "curl", "nodejs-20.1", "nodejs-18.19.0", "nodejs"}})
+	require.ErrorIs(t, err, iberrors.ErrInvalidCustomization)
+	var he *echo.HTTPError
+	require.ErrorAs(t, err, &he)
+	require.Equal(t, http.StatusBadRequest, he.Code)
+	require.Equal(t, []string{
+		"Packages curl, curl-minimal can't be installed together, curl-minimal only supports the common protocols",
+		"Package nodejs is requested at versions 20.1, 18.19.0, only one of them can be installed",
+	}, packageConflicts(d, []string{"curl-minimal-8.0", "vim", "curl", "nodejs-20.1", "nodejs-18.19.0", "nodejs"}))
+}
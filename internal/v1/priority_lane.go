@@ -0,0 +1,72 @@
+package v1
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/prometheus"
+)
+
+// the values of the lane label of the priority lane metrics
+const (
+	lanePriority = "priority"
+	laneOverflow = "overflow"
+)
+
+var laneNames = []string{lanePriority, laneOverflow}
+
+// laneWindow is the window the limit of the priority lane applies to, the
+// one of the quota so the lane can't be used to build more than the quota
+// permits twice over.
+func laneWindow(quota *common.Quota) time.Duration {
+	if quota == nil {
+		return common.DefaultSlidingWindow
+	}
+	return quota.SlidingWindow
+}
+
+// priorityLaneOpen tells if the compose gets into the priority lane, only
+// composes flagged as security rebuilds by org admins do while the org has
+// room in the lane. The reservation of the compose settles it for concurrent
+// ones.
+func (s *Server) priorityLaneOpen(ctx echo.Context, userID *Identity, composeRequest *ComposeRequest) (bool, error) {
+	if !common.FromPtr(composeRequest.Security) {
+		return false, nil
+	}
+	if !userID.IsOrgAdmin() {
+		return false, echo.NewHTTPError(http.StatusForbidden, "Only organization administrators can flag composes as security rebuilds")
+	}
+	if s.priorityLane == 0 {
+		return false, nil
+	}
+
+	quota, err := s.quota(ctx, userID.OrgID())
+	if err != nil {
+		return false, err
+	}
+	count, err := s.db.CountPriorityLaneComposesSince(ctx.Request().Context(), userID.OrgID(), laneWindow(quota))
+	if err != nil {
+		return false, err
+	}
+	if count >= s.priorityLane {
+		ctx.Logger().Warnf("Priority lane of org %s is full, the security rebuild counts against the quota", userID.OrgID())
+		return false, nil
+	}
+	return true, nil
+}
+
+// countPriorityLane records whether a recorded security rebuild got into the
+// priority lane.
+func (s *Server) countPriorityLane(composeRequest ComposeRequest, priorityLane bool) {
+	if !common.FromPtr(composeRequest.Security) || s.priorityLane == 0 {
+		return
+	}
+	lane := laneOverflow
+	if priorityLane {
+		lane = lanePriority
+	}
+	prometheus.PriorityLaneComposes.WithLabelValues(lane).Inc()
+}
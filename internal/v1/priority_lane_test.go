@@ -0,0 +1,68 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	rh_identity "github.com/redhatinsights/identity"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+type laneDB struct {
+	db.DB
+	inLane int
+	window time.Duration
+}
+
+func (l *laneDB) CountPriorityLaneComposesSince(ctx context.Context, orgId string, duration time.Duration) (int, error) {
+	l.window = duration
+	return l.inLane, nil
+}
+
+func TestPriorityLaneOpen(t *testing.T) {
+	dbase := &laneDB{}
+	srv := &Server{db: dbase, priorityLane: 2}
+	ctx := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/api/image-builder/v1/compose", nil), nil)
+	admin := &Identity{rhid: &rh_identity.XRHID{Identity: rh_identity.Identity{OrgID: "000000", User: rh_identity.User{OrgAdmin: true}}}}
+	user := &Identity{rhid: &rh_identity.XRHID{Identity: rh_identity.Identity{OrgID: "000000"}}}
+	security := &ComposeRequest{Security: common.ToPtr(true)}
+
+	open, err := srv.priorityLaneOpen(ctx, admin, &ComposeRequest{})
+	require.NoError(t, err)
+	require.False(t, open)
+
+	_, err = srv.priorityLaneOpen(ctx, user, security)
+	var httpErr *echo.HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	require.Equal(t, http.StatusForbidden, httpErr.Code)
+
+	open, err = srv.priorityLaneOpen(ctx, admin, security)
+	require.NoError(t, err)
+	require.True(t, open)
+	// without a quota the lane is limited within the default window
+	require.Equal(t, common.DefaultSlidingWindow, dbase.window)
+
+	dbase.inLane = 2
+	open, err = srv.priorityLaneOpen(ctx, admin, security)
+	require.NoError(t, err)
+	require.False(t, open)
+
+	// flagged composes are accepted without the lane too
+	dbase.inLane = 0
+	open, err = (&Server{db: dbase}).priorityLaneOpen(ctx, admin, security)
+	require.NoError(t, err)
+	require.False(t, open)
+}
+
+func TestComposerJobTagsPriority(t *testing.T) {
+	admin := &Identity{rhid: &rh_identity.XRHID{Identity: rh_identity.Identity{OrgID: "000000"}}}
+	require.NotContains(t, *composerJobTags(admin, nil, false, ""), "priority")
+	require.Equal(t, "security", (*composerJobTags(admin, nil, true, ""))["priority"])
+}
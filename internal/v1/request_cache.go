@@ -0,0 +1,68 @@
+package v1
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/distribution"
+)
+
+const requestCacheKey = "image-builder.request-cache"
+
+// requestCache holds what the middlewares and handlers look up repeatedly
+// while serving a single request: the identity, the distributions it has
+// access to, the allow list decisions and the quota of its org. It lives in
+// the echo context, so it's gone along with the request and never serves a
+// stale quota file or allow list to the next one.
+type requestCache struct {
+	identity *Identity
+	distros  *distribution.DistroRegistry
+	// allowed is keyed by the distribution name
+	allowed map[string]bool
+
+	quotaLoaded bool
+	quota       *common.Quota
+}
+
+func cacheOf(ctx echo.Context) *requestCache {
+	if cache, ok := ctx.Get(requestCacheKey).(*requestCache); ok {
+		return cache
+	}
+	cache := &requestCache{}
+	ctx.Set(requestCacheKey, cache)
+	return cache
+}
+
+// isAllowed wraps AllowList.IsAllowed, its patterns are compiled on every
+// call.
+func (s *Server) isAllowed(ctx echo.Context, orgID, distro string) (bool, error) {
+	cache := cacheOf(ctx)
+	if allowed, ok := cache.allowed[distro]; ok {
+		return allowed, nil
+	}
+	allowed, err := s.allowList.IsAllowed(orgID, distro)
+	if err != nil {
+		return false, err
+	}
+	if cache.allowed == nil {
+		cache.allowed = map[string]bool{}
+	}
+	cache.allowed[distro] = allowed
+	return allowed, nil
+}
+
+// quota is the quota of the org from the quota file, nil if the quota check
+// is disabled.
+func (s *Server) quota(ctx echo.Context, orgID string) (*common.Quota, error) {
+	cache := cacheOf(ctx)
+	if cache.quotaLoaded {
+		return cache.quota, nil
+	}
+	quota, err := common.LoadQuota(orgID, s.quotaFile)
+	if err != nil {
+		return nil, err
+	}
+	cache.quota = quota
+	cache.quotaLoaded = true
+	return quota, nil
+}
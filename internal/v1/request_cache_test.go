@@ -0,0 +1,110 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	rh_identity "github.com/redhatinsights/identity"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/distribution"
+	"github.com/osbuild/image-builder/internal/tutils"
+)
+
+func newRequestCacheServer(tb testing.TB) *Server {
+	allDistros, err := distribution.LoadDistroRegistry("../../distributions")
+	require.NoError(tb, err)
+	quotaFile, err := initQuotaFile(tb)
+	require.NoError(tb, err)
+	return &Server{
+		allDistros: allDistros,
+		allowList:  common.AllowList{"000000": []string{"rhel-.*", "centos-.*"}},
+		quotaFile:  quotaFile,
+	}
+}
+
+// newIdentityRequest returns a request the way the identity middleware
+// hands it over to the handlers.
+func newIdentityRequest(tb testing.TB) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/image-builder/v1/compose", nil)
+	req.Header.Add("x-rh-identity", tutils.AuthString0)
+	var extracted *http.Request
+	rh_identity.Extractor(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		extracted = r
+	})).ServeHTTP(httptest.NewRecorder(), req)
+	require.NotNil(tb, extracted)
+	return extracted
+}
+
+// composeLookups are the lookups the middlewares and the compose handler
+// each make while serving a single compose request.
+func composeLookups(tb testing.TB, srv *Server, ctx echo.Context) {
+	id, err := srv.getIdentity(ctx)
+	require.NoError(tb, err)
+	_, err = srv.distroRegistry(ctx).Get("rhel-94")
+	require.NoError(tb, err)
+	allowed, err := srv.isAllowed(ctx, id.OrgID(), "rhel-94")
+	require.NoError(tb, err)
+	require.True(tb, allowed)
+	quota, err := srv.quota(ctx, id.OrgID())
+	require.NoError(tb, err)
+	require.NotNil(tb, quota)
+}
+
+func TestRequestCache(t *testing.T) {
+	srv := newRequestCacheServer(t)
+	e := echo.New()
+	ctx := e.NewContext(newIdentityRequest(t), httptest.NewRecorder())
+
+	id, err := srv.getIdentity(ctx)
+	require.NoError(t, err)
+	require.Same(t, id, cacheOf(ctx).identity)
+	distros := srv.distroRegistry(ctx)
+	require.Same(t, distros, srv.distroRegistry(ctx))
+
+	// changes to the server state aren't seen by the request being served
+	allowed, err := srv.isAllowed(ctx, id.OrgID(), "rhel-94")
+	require.NoError(t, err)
+	require.True(t, allowed)
+	srv.allowList = common.AllowList{"000000": []string{}}
+	allowed, err = srv.isAllowed(ctx, id.OrgID(), "rhel-94")
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// but they are by the next one
+	next := e.NewContext(newIdentityRequest(t), httptest.NewRecorder())
+	nextID, err := srv.getIdentity(next)
+	require.NoError(t, err)
+	require.NotSame(t, id, nextID)
+	allowed, err = srv.isAllowed(next, nextID.OrgID(), "rhel-94")
+	require.NoError(t, err)
+	require.False(t, allowed)
+}
+
+func BenchmarkRequestCache(b *testing.B) {
+	srv := newRequestCacheServer(b)
+	e := echo.New()
+	req := newIdentityRequest(b)
+
+	// every lookup in a context of its own, as without the cache
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < 3; j++ {
+				composeLookups(b, srv, e.NewContext(req, nil))
+			}
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ctx := e.NewContext(req, nil)
+			for j := 0; j < 3; j++ {
+				composeLookups(b, srv, ctx)
+			}
+		}
+	})
+}
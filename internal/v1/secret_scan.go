@@ -0,0 +1,39 @@
+package v1
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/osbuild/image-builder/internal/secretscan"
+)
+
+// scanComposeRequest looks for credentials in the content embedded into the
+// image, the findings name where a secret was found but never the secret.
+func scanComposeRequest(cr *ComposeRequest) []string {
+	cust := cr.Customizations
+	if cust == nil {
+		return nil
+	}
+
+	var findings []string
+	if cust.Files != nil {
+		for _, f := range *cust.Files {
+			if f.Data == nil {
+				continue
+			}
+			for _, r := range secretscan.Scan(fileContent(f)) {
+				findings = append(findings, fmt.Sprintf("File %s contains %s", f.Path, r.Description))
+			}
+		}
+	}
+	if cust.Ignition != nil && cust.Ignition.Embedded != nil {
+		config := cust.Ignition.Embedded.Config
+		if decoded, err := base64.StdEncoding.DecodeString(config); err == nil {
+			config = string(decoded)
+		}
+		for _, r := range secretscan.Scan(config) {
+			findings = append(findings, fmt.Sprintf("Ignition config contains %s", r.Description))
+		}
+	}
+	return findings
+}
@@ -9,6 +9,8 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/osbuild/image-builder/internal/clients/recommendations"
 
@@ -18,14 +20,19 @@ import (
 	"github.com/osbuild/image-builder/internal/common"
 	"github.com/osbuild/image-builder/internal/db"
 	"github.com/osbuild/image-builder/internal/distribution"
+	"github.com/osbuild/image-builder/internal/encryption"
+	"github.com/osbuild/image-builder/internal/gitops"
 	"github.com/osbuild/image-builder/internal/prometheus"
+	"github.com/osbuild/image-builder/internal/readonly"
+	"github.com/osbuild/image-builder/internal/repocheck"
+	"github.com/osbuild/image-builder/internal/sharelink"
+	"github.com/osbuild/image-builder/internal/storage"
+	"github.com/osbuild/image-builder/internal/watcher"
+	iberrors "github.com/osbuild/image-builder/pkg/errors"
 
 	"github.com/getkin/kin-openapi/openapi3"
-	"github.com/getkin/kin-openapi/routers"
-	legacyrouter "github.com/getkin/kin-openapi/routers/legacy"
 	"github.com/labstack/echo/v4"
 	fedora_identity "github.com/osbuild/community-gateway/oidc-authorizer/pkg/identity"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/redhatinsights/identity"
 )
 
@@ -37,7 +44,7 @@ type Server struct {
 	csReposURL       *url.URL
 	rClient          *recommendations.RecommendationsClient
 	spec             *openapi3.T
-	router           routers.Router
+	router           *lazyRouter
 	db               db.DB
 	aws              AWSConfig
 	gcp              GCPConfig
@@ -46,6 +53,24 @@ type Server struct {
 	allDistros       *distribution.AllDistroRegistry
 	distributionsDir string
 	fedoraAuth       bool
+	storage          storage.Storage
+	keyring          *encryption.Keyring
+	redactRequests   bool
+	gitFetcher       gitops.Fetcher
+	repoChecker      *repocheck.Checker
+	region           string
+	regionalCClients map[string]*composer.ComposerClient
+	tenants          map[string]composer.Tenant
+	readOnly         *readonly.Mode
+	metricsToken     string
+	emulatedArchs    map[string]time.Duration
+	shareLinks       *sharelink.Signer
+	statusInterval   time.Duration
+	composeExpiry    time.Duration
+	downloadSigner   storage.URLSigner
+	downloadLifetime time.Duration
+	priorityLane     int
+	draftLimit       int
 }
 
 type ServerConfig struct {
@@ -63,10 +88,64 @@ type ServerConfig struct {
 	AllDistros       *distribution.AllDistroRegistry
 	DistributionsDir string
 	FedoraAuth       bool
+	Storage          storage.Storage
+	// Keyring encrypts the sensitive parts of stored compose requests, nil
+	// stores them in plain text.
+	Keyring *encryption.Keyring
+	// RedactRequests drops secrets from compose requests before storing them,
+	// keeping only their digests.
+	RedactRequests bool
+	// GitFetcher defaults to cloning with the git binary
+	GitFetcher gitops.Fetcher
+	// RepoChecker reports the reachability of the distribution repositories,
+	// nil when the checks are disabled.
+	RepoChecker *repocheck.Checker
+	// Region is recorded on the composes created by this deployment, empty
+	// when there is only one.
+	Region string
+	// RegionalCompClients reach the composers of the other regions sharing
+	// the database, keyed by region.
+	RegionalCompClients map[string]*composer.ComposerClient
+	// TenantComposers are the composers and worker pools orgs brought along
+	// for their composes, keyed by org id.
+	TenantComposers map[string]composer.Tenant
+	// ReadOnly rejects mutations while enabled, nil never does.
+	ReadOnly *readonly.Mode
+	// MetricsToken has to be presented as bearer token to scrape the metrics,
+	// empty leaves them open to whoever can reach the service.
+	MetricsToken string
+	// EmulatedArchitectures are built on workers emulating them, with the
+	// typical duration of such a build.
+	EmulatedArchitectures map[string]time.Duration
+	// ShareLinks signs the links sharing composes, nil disables them.
+	ShareLinks *sharelink.Signer
+	// StatusInterval is how often the status of composes streamed to clients
+	// is polled, zero polls every watcher.DefaultInterval.
+	StatusInterval time.Duration
+	// ComposeExpiry is how long the artifacts of composes are kept unless the
+	// org policy says otherwise, zero keeps them.
+	ComposeExpiry time.Duration
+	// DownloadSigner signs the links to the images uploaded to object
+	// storage, nil disables the download links and hands out the URLs
+	// composer returned.
+	DownloadSigner storage.URLSigner
+	// DownloadLinkLifetime is how long the download links are valid, zero
+	// defaults to defaultDownloadLinkLifetime.
+	DownloadLinkLifetime time.Duration
+	// PriorityLaneLimit is how many composes flagged as security rebuilds
+	// an org can have in the priority lane within the window of its quota,
+	// zero disables the lane.
+	PriorityLaneLimit int
+	// DraftLimit is how many compose drafts a user can keep, zero defaults
+	// to defaultDraftLimit.
+	DraftLimit int
 }
 
 type AWSConfig struct {
 	Region string
+	// GovCloudDistros are the distributions which may be cloned into the
+	// GovCloud partitions, none disables cloning there.
+	GovCloudDistros []string
 }
 
 type GCPConfig struct {
@@ -78,27 +157,31 @@ type Handlers struct {
 	server *Server
 }
 
-func Attach(conf *ServerConfig) error {
-	spec, err := GetSwagger()
-	if err != nil {
-		return err
+func Attach(conf *ServerConfig) (*Server, error) {
+	// parsing the spec takes the longest, the allow list is loaded meanwhile
+	var spec *openapi3.T
+	var specErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		spec, specErr = GetSwagger()
+	}()
+	allowList, err := common.LoadAllowList(conf.AllowFile)
+	wg.Wait()
+	if specErr != nil {
+		return nil, specErr
 	}
-
-	router, err := legacyrouter.NewRouter(spec)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	router := &lazyRouter{spec: spec}
 	majorVersion := strings.Split(spec.Info.Version, ".")[0]
 
-	allowList, err := common.LoadAllowList(conf.AllowFile)
-	if err != nil {
-		return err
-	}
-
 	csReposURL, err := url.Parse(conf.CSReposURL)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	s := Server{
@@ -118,7 +201,49 @@ func Attach(conf *ServerConfig) error {
 		conf.AllDistros,
 		conf.DistributionsDir,
 		conf.FedoraAuth,
+		conf.Storage,
+		conf.Keyring,
+		conf.RedactRequests,
+		conf.GitFetcher,
+		conf.RepoChecker,
+		conf.Region,
+		conf.RegionalCompClients,
+		conf.TenantComposers,
+		conf.ReadOnly,
+		conf.MetricsToken,
+		conf.EmulatedArchitectures,
+		conf.ShareLinks,
+		conf.StatusInterval,
+		conf.ComposeExpiry,
+		conf.DownloadSigner,
+		conf.DownloadLinkLifetime,
+		conf.PriorityLaneLimit,
+		conf.DraftLimit,
 	}
+	if s.gitFetcher == nil {
+		s.gitFetcher = gitops.NewGit("")
+	}
+	if s.statusInterval == 0 {
+		s.statusInterval = watcher.DefaultInterval
+	}
+	if s.downloadLifetime == 0 {
+		s.downloadLifetime = defaultDownloadLinkLifetime
+	}
+	if s.draftLimit == 0 {
+		s.draftLimit = defaultDraftLimit
+	}
+	// a failed build keeps the service from becoming ready
+	go func() {
+		if err := router.build(); err != nil {
+			s.echo.Logger.Errorf("Unable to build the router of the spec: %v", err)
+		}
+	}()
+	// metric labels only take known values
+	prometheus.SetLabelValues("customization", customizationNames()...)
+	prometheus.SetLabelValues("architecture", architectureNames()...)
+	prometheus.SetLabelValues("lane", laneNames...)
+	prometheus.SetLabelValues("emulated", "true", "false")
+
 	var h Handlers
 	h.server = &s
 	s.echo.Binder = binder{}
@@ -137,7 +262,7 @@ func Attach(conf *ServerConfig) error {
 	}
 
 	middlewaresNoAuth = append(middlewaresNoAuth, prometheus.PrometheusMW)
-	middlewares = append(middlewares, s.noAssociateAccounts, s.ValidateRequest, prometheus.PrometheusMW)
+	middlewares = append(middlewares, s.noAssociateAccounts, s.rejectWrites, s.ValidateRequest, prometheus.PrometheusMW)
 
 	RegisterHandlers(s.echo.Group(fmt.Sprintf("%s/v%s", RoutePrefix(), majorVersion), middlewares...), &h)
 	RegisterHandlers(s.echo.Group(fmt.Sprintf("%s/v%s", RoutePrefix(), spec.Info.Version), middlewares...), &h)
@@ -147,6 +272,13 @@ func Attach(conf *ServerConfig) error {
 	s.echo.GET(fmt.Sprintf("%s/v%s/openapi.json", RoutePrefix(), majorVersion), h.GetOpenapiJson, middlewaresNoAuth...)
 	s.echo.GET(fmt.Sprintf("%s/v%s/openapi.json", RoutePrefix(), spec.Info.Version), h.GetOpenapiJson, middlewaresNoAuth...)
 	s.echo.GET("/openapi.json", h.GetOpenapiJson, middlewaresNoAuth...)
+	// share links and download tokens are the authorization
+	wrapper := ServerInterfaceWrapper{Handler: &h}
+	for _, version := range []string{majorVersion, spec.Info.Version} {
+		s.echo.GET(fmt.Sprintf("%s/v%s/shared/:token", RoutePrefix(), version), wrapper.GetSharedCompose, middlewaresNoAuth...)
+		s.echo.GET(fmt.Sprintf("%s/v%s/shared/:token/logs", RoutePrefix(), version), wrapper.GetSharedComposeLogs, middlewaresNoAuth...)
+		s.echo.GET(fmt.Sprintf("%s/v%s/artifacts/:composeId/download", RoutePrefix(), version), wrapper.GetArtifactDownload, middlewaresNoAuth...)
+	}
 
 	/* Used for the livenessProbe */
 	s.echo.GET("/status", func(c echo.Context) error {
@@ -158,8 +290,8 @@ func Attach(conf *ServerConfig) error {
 		return h.GetReadiness(c)
 	})
 
-	h.server.echo.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
-	return nil
+	prometheus.AddMetricsRoutes(s.echo, s.metricsToken)
+	return &s, nil
 }
 
 func RoutePrefix() string {
@@ -207,6 +339,11 @@ func (s *Server) HTTPErrorHandler(err error, c echo.Context) {
 				he = herr
 			}
 		}
+	} else if apiErr, ok := err.(*iberrors.Error); ok {
+		he = &echo.HTTPError{
+			Code:    apiErr.Status,
+			Message: apiErr.Message,
+		}
 	} else {
 		he = &echo.HTTPError{
 			Code:    http.StatusInternalServerError,
@@ -225,10 +362,22 @@ func (s *Server) HTTPErrorHandler(err error, c echo.Context) {
 		c.Logger().Warnf("HTTP error: %s", err)
 	}
 
-	errors = append(errors, HTTPError{
-		Title:  strconv.Itoa(he.Code),
-		Detail: fmt.Sprintf("%v", he.Message),
-	})
+	code := errorCode(err)
+	if violations, ok := he.Message.(policyViolations); ok {
+		for _, v := range violations {
+			errors = append(errors, HTTPError{
+				Code:   code,
+				Title:  strconv.Itoa(he.Code),
+				Detail: v,
+			})
+		}
+	} else {
+		errors = append(errors, HTTPError{
+			Code:   code,
+			Title:  strconv.Itoa(he.Code),
+			Detail: fmt.Sprintf("%v", he.Message),
+		})
+	}
 
 	// Send response
 	if !c.Response().Committed {
@@ -246,6 +395,11 @@ func (s *Server) HTTPErrorHandler(err error, c echo.Context) {
 }
 
 func (s *Server) distroRegistry(ctx echo.Context) *distribution.DistroRegistry {
+	cache := cacheOf(ctx)
+	if cache.distros != nil {
+		return cache.distros
+	}
+
 	entitled := false
 	id, err := s.getIdentity(ctx)
 	if err != nil {
@@ -253,7 +407,8 @@ func (s *Server) distroRegistry(ctx echo.Context) *distribution.DistroRegistry {
 	}
 
 	entitled = id.IsEntitled(ctx, "rhel")
-	return s.allDistros.Available(entitled)
+	cache.distros = s.allDistros.Available(entitled)
+	return cache.distros
 }
 
 // wraps DistroRegistry.Get and verifies the user has access
@@ -272,14 +427,49 @@ func (s *Server) getDistro(ctx echo.Context, distro Distributions) (*distributio
 	}
 
 	if d.IsRestricted() {
-		allowOk, err := s.allowList.IsAllowed(id.OrgID(), d.Distribution.Name)
+		allowOk, err := s.isAllowed(ctx, id.OrgID(), d.Distribution.Name)
 		if err != nil {
 			return nil, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
 		if !allowOk {
 			message := fmt.Sprintf("This account's organization is not authorized to build %s images", string(d.Distribution.Name))
-			return nil, echo.NewHTTPError(http.StatusForbidden, message)
+			return nil, apiError(iberrors.CodeForbiddenDistribution, http.StatusForbidden, message)
 		}
 	}
 	return d, nil
 }
+
+// composerFor returns the client of the composer which created the composes of
+// region, composes without a region predate the multi-region setup and belong
+// to the local one.
+func (s *Server) composerFor(region *string) (*composer.ComposerClient, error) {
+	if region == nil || *region == s.region {
+		return s.cClient, nil
+	}
+	if client, ok := s.regionalCClients[*region]; ok {
+		return client, nil
+	}
+	if orgID, ok := composer.TenantOrg(*region); ok {
+		if tenant := s.tenants[orgID]; tenant.Client != nil {
+			return tenant.Client, nil
+		}
+	}
+	return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("No composer configured for region %s", *region))
+}
+
+// regionPtr is the region recorded on new composes
+func (s *Server) regionPtr() *string {
+	if s.region == "" {
+		return nil
+	}
+	return common.ToPtr(s.region)
+}
+
+// composeTTL is how long the artifacts of new composes of an org are kept,
+// zero keeps them
+func (s *Server) composeTTL(policy *OrgPolicy) time.Duration {
+	if policy != nil && policy.ComposeExpiryDays != nil {
+		return time.Duration(*policy.ComposeExpiryDays) * 24 * time.Hour
+	}
+	return s.composeExpiry
+}
@@ -15,6 +15,19 @@ type Identity struct {
 
 // return the Identity Header if there is a valid one in the request
 func (s *Server) getIdentity(ctx echo.Context) (*Identity, error) {
+	cache := cacheOf(ctx)
+	if cache.identity != nil {
+		return cache.identity, nil
+	}
+	id, err := s.extractIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cache.identity = id
+	return id, nil
+}
+
+func (s *Server) extractIdentity(ctx echo.Context) (*Identity, error) {
 	if s.fedoraAuth {
 		fid, ok := ctx.Request().Context().Value(fedora_identity.IDHeaderKey).(*fedora_identity.Identity)
 		if !ok {
@@ -66,6 +79,15 @@ func (i *Identity) Type() string {
 	return ""
 }
 
+// IsOrgAdmin tells if the user administers its organization, in Fedora every
+// user is an organization of its own.
+func (i *Identity) IsOrgAdmin() bool {
+	if i.rhid != nil {
+		return i.rhid.Identity.User.OrgAdmin
+	}
+	return i.fid != nil
+}
+
 func (i *Identity) IsEntitled(ctx echo.Context, ask string) bool {
 	if i.rhid != nil {
 		entitled, ok := i.rhid.Entitlements[ask]
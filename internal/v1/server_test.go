@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -50,7 +51,7 @@ func runTests(m *testing.M) int {
 }
 
 // Create a temporary file containing quotas, returns the file name as a string
-func initQuotaFile(t *testing.T) (string, error) {
+func initQuotaFile(t testing.TB) (string, error) {
 	// create quotas with only the default values
 	quotas := map[string]common.Quota{
 		"default": {Quota: common.DefaultQuota, SlidingWindow: common.DefaultSlidingWindow},
@@ -187,7 +188,7 @@ func startServer(t *testing.T, tscc *testServerClientsConf, conf *ServerConfig)
 		serverConfig.AllDistros = adr
 	}
 
-	err = Attach(serverConfig)
+	_, err = Attach(serverConfig)
 	require.NoError(t, err)
 	// execute in parallel b/c .Run() will block execution
 	go func() {
@@ -212,3 +213,26 @@ func startServer(t *testing.T, tscc *testServerClientsConf, conf *ServerConfig)
 
 	return echoServer, tokenServer
 }
+
+// startTestServer is startServer for tests which don't need to stop the
+// servers themselves, they're stopped when the test is done.
+func startTestServer(t *testing.T, tscc *testServerClientsConf, conf *ServerConfig) {
+	srv, tokenSrv := startServer(t, tscc, conf)
+	t.Cleanup(func() {
+		tokenSrv.Close()
+		require.NoError(t, srv.Shutdown(context.Background()))
+	})
+}
+
+// mockService serves handler in place of a service the server calls, like
+// composer or provisioning, until the test is done. Its URL is returned.
+func mockService(t *testing.T, handler http.HandlerFunc) string {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+// apiURL returns the URL of path in the API of the test server.
+func apiURL(path string, args ...interface{}) string {
+	return "http://localhost:8086/api/image-builder/v1" + fmt.Sprintf(path, args...)
+}
@@ -0,0 +1,119 @@
+package v1
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/labstack/echo/v4"
+)
+
+// SpecMismatch is a route the handlers and the spec disagree on.
+type SpecMismatch struct {
+	Method string
+	Path   string
+	// Reason tells which side lacks the route
+	Reason string
+}
+
+func (m SpecMismatch) String() string {
+	return fmt.Sprintf("%s %s: %s", m.Method, m.Path, m.Reason)
+}
+
+// undocumentedRoutes are served outside of the versioned API on purpose: the
+// spec itself, the probes and the metrics.
+var undocumentedRoutes = map[string]bool{
+	"GET /openapi.json":    true,
+	"GET /status":          true,
+	"GET /ready":           true,
+	"GET /metrics":         true,
+	"GET /metrics/runtime": true,
+}
+
+// VerifySpec compares the routes the server is attached on with the
+// operations of the spec. Operations without a handler and routes the spec
+// doesn't document are returned, sorted by path and method.
+func VerifySpec() ([]SpecMismatch, error) {
+	spec, err := GetSwagger()
+	if err != nil {
+		return nil, err
+	}
+	// the handlers aren't called, the routes they're attached on are all
+	// that's looked at
+	s, err := Attach(&ServerConfig{EchoServer: echo.New()})
+	if err != nil {
+		return nil, err
+	}
+	versions := []string{"/v" + strings.Split(spec.Info.Version, ".")[0], "/v" + spec.Info.Version}
+	return verifyRoutes(spec, RoutePrefix(), versions, s.echo.Routes()), nil
+}
+
+// verifyRoutes checks every version under the prefix serves the operations of
+// the spec, and nothing else is served besides the undocumented routes.
+func verifyRoutes(spec *openapi3.T, prefix string, versions []string, routes []*echo.Route) []SpecMismatch {
+	documented := map[string]bool{}
+	for path, item := range spec.Paths.Map() {
+		for method := range item.Operations() {
+			documented[method+" "+path] = true
+		}
+	}
+
+	// routes can be registered more than once, the last one is served
+	served := map[string]bool{}
+	for _, r := range routes {
+		// groups catch the requests no route matches
+		if r.Method != echo.RouteNotFound {
+			served[r.Method+" "+specPath(r.Path)] = true
+		}
+	}
+
+	var mismatches []SpecMismatch
+	registered := map[string]map[string]bool{}
+	for _, version := range versions {
+		registered[version] = map[string]bool{}
+	}
+	for route := range served {
+		method, path, _ := strings.Cut(route, " ")
+		versioned := false
+		for version := range registered {
+			if rest, ok := strings.CutPrefix(path, prefix+version+"/"); ok {
+				versioned = true
+				registered[version][method+" /"+rest] = true
+				if !documented[method+" /"+rest] {
+					mismatches = append(mismatches, SpecMismatch{Method: method, Path: version + "/" + rest, Reason: "undocumented route"})
+				}
+			}
+		}
+		if !versioned && !undocumentedRoutes[route] {
+			mismatches = append(mismatches, SpecMismatch{Method: method, Path: path, Reason: "undocumented route"})
+		}
+	}
+	for version, routes := range registered {
+		for route := range documented {
+			if !routes[route] {
+				method, path, _ := strings.Cut(route, " ")
+				mismatches = append(mismatches, SpecMismatch{Method: method, Path: version + path, Reason: "missing handler"})
+			}
+		}
+	}
+	sort.Slice(mismatches, func(i, j int) bool {
+		if mismatches[i].Path != mismatches[j].Path {
+			return mismatches[i].Path < mismatches[j].Path
+		}
+		return mismatches[i].Method < mismatches[j].Method
+	})
+	return mismatches
+}
+
+// specPath turns the parameters of an echo path, like :composeId, into the
+// ones of the spec, like {composeId}.
+func specPath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, p := range parts {
+		if strings.HasPrefix(p, ":") {
+			parts[i] = "{" + p[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
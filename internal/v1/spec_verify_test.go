@@ -0,0 +1,43 @@
+package v1
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySpec(t *testing.T) {
+	mismatches, err := VerifySpec()
+	require.NoError(t, err)
+	require.Empty(t, mismatches)
+}
+
+func TestVerifyRoutes(t *testing.T) {
+	paths := openapi3.NewPaths()
+	paths.Set("/composes/{composeId}", &openapi3.PathItem{Get: &openapi3.Operation{}, Delete: &openapi3.Operation{}})
+	spec := &openapi3.T{Paths: paths}
+
+	e := echo.New()
+	g := e.Group("/api/image-builder/v1", func(next echo.HandlerFunc) echo.HandlerFunc { return next })
+	g.GET("/composes/:composeId", nil)
+	g.DELETE("/composes/:composeId", nil)
+	g.GET("/composes/:composeId/secret", nil)
+	e.GET("/api/image-builder/v1.0/composes/:composeId", nil)
+	e.GET("/status", nil)
+	e.GET("/debug", nil)
+
+	mismatches := verifyRoutes(spec, "/api/image-builder", []string{"/v1", "/v1.0"}, e.Routes())
+	require.Equal(t, []SpecMismatch{
+		{Method: http.MethodGet, Path: "/debug", Reason: "undocumented route"},
+		{Method: http.MethodDelete, Path: "/v1.0/composes/{composeId}", Reason: "missing handler"},
+		{Method: http.MethodGet, Path: "/v1/composes/{composeId}/secret", Reason: "undocumented route"},
+	}, mismatches)
+}
+
+func TestSpecPath(t *testing.T) {
+	require.Equal(t, "/composes/{composeId}/download", specPath("/composes/:composeId/download"))
+	require.Equal(t, "/openapi.json", specPath("/openapi.json"))
+}
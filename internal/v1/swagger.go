@@ -8,6 +8,7 @@ package v1
 
 import (
 	_ "embed"
+	"sync"
 
 	"github.com/getkin/kin-openapi/openapi3"
 )
@@ -15,16 +16,25 @@ import (
 //go:embed api.yaml
 var oapiYAML []byte
 
+// the spec is parsed once, it's the slowest part of starting up and some
+// handlers ask for it on every request
+var (
+	swaggerOnce sync.Once
+	swagger     *openapi3.T
+	swaggerErr  error
+)
+
 // GetSwagger returns the Swagger specification corresponding to the generated code
 // in this file. The external references of Swagger specification are resolved.
 // The logic of resolving external references is tightly connected to "import-mapping" feature.
 // Externally referenced files must be embedded in the corresponding golang packages.
 // Urls can be supported but this task was out of the scope.
-func GetSwagger() (swagger *openapi3.T, err error) {
-	loader := openapi3.NewLoader()
-	swagger, err = loader.LoadFromData(oapiYAML)
-	if err != nil {
-		return
-	}
-	return
+//
+// The specification is shared by all callers, it must not be changed.
+func GetSwagger() (*openapi3.T, error) {
+	swaggerOnce.Do(func() {
+		loader := openapi3.NewLoader()
+		swagger, swaggerErr = loader.LoadFromData(oapiYAML)
+	})
+	return swagger, swaggerErr
 }
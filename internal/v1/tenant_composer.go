@@ -0,0 +1,21 @@
+package v1
+
+import (
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+)
+
+// composerForOrg returns the composer new composes of the org are submitted
+// to, along with the region recorded on them.
+func (s *Server) composerForOrg(orgID string) (*composer.ComposerClient, *string) {
+	if tenant := s.tenants[orgID]; tenant.Client != nil {
+		return tenant.Client, common.ToPtr(composer.TenantRegion(orgID))
+	}
+	return s.cClient, s.regionPtr()
+}
+
+// workerPool is the pool of workers building the jobs of the org, empty for
+// the shared one.
+func (s *Server) workerPool(orgID string) string {
+	return s.tenants[orgID].WorkerPool
+}
@@ -0,0 +1,57 @@
+package v1
+
+import (
+	"testing"
+
+	rh_identity "github.com/redhatinsights/identity"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/oauth2"
+)
+
+func TestTenantComposers(t *testing.T) {
+	newClient := func(url string) *composer.ComposerClient {
+		client, err := composer.NewClient(composer.ComposerClientConfig{
+			URL:     url,
+			Tokener: &oauth2.DummyToken{},
+		})
+		require.NoError(t, err)
+		return client
+	}
+	shared := newClient("https://shared.example.com")
+	dedicated := newClient("https://dedicated.example.com")
+	srv := &Server{
+		cClient: shared,
+		region:  "us-east-1",
+		tenants: map[string]composer.Tenant{
+			"000001": {Client: dedicated, WorkerPool: "acme"},
+			"000002": {WorkerPool: "dedicated"},
+		},
+	}
+
+	// the composes of the org are recorded for its composer to be found again
+	client, region := srv.composerForOrg("000001")
+	require.Same(t, dedicated, client)
+	require.Equal(t, "tenant-000001", *region)
+	client, err := srv.composerFor(region)
+	require.NoError(t, err)
+	require.Same(t, dedicated, client)
+
+	// a worker pool alone builds on the shared composer
+	client, region = srv.composerForOrg("000002")
+	require.Same(t, shared, client)
+	require.Equal(t, "us-east-1", *region)
+	client, region = srv.composerForOrg("000000")
+	require.Same(t, shared, client)
+	require.Equal(t, "us-east-1", *region)
+
+	// composes of orgs which dropped their composer can't be reached anymore
+	_, err = srv.composerFor(common.ToPtr("tenant-000002"))
+	require.Error(t, err)
+
+	user := &Identity{rhid: &rh_identity.XRHID{Identity: rh_identity.Identity{OrgID: "000002"}}}
+	require.Equal(t, "dedicated", (*composerJobTags(user, nil, false, srv.workerPool("000002")))["worker_pool"])
+	require.NotContains(t, *composerJobTags(user, nil, false, srv.workerPool("000000")), "worker_pool")
+}
@@ -0,0 +1,242 @@
+// Package watchdog finds composes which are stuck in a non-terminal state and
+// fails them, so they don't linger in the compose list or count against the
+// org's concurrency forever.
+package watchdog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/prometheus"
+	"github.com/osbuild/image-builder/internal/readonly"
+)
+
+// ErrorCodeTimeout is stored as the error code of composes failed by the watchdog.
+const ErrorCodeTimeout = "WATCHDOG_TIMEOUT"
+
+const (
+	// DefaultInterval is how often the watchdog looks for stuck composes.
+	DefaultInterval = 10 * time.Minute
+
+	// DefaultThreshold applies to image types not listed in DefaultThresholds.
+	DefaultThreshold = 4 * time.Hour
+
+	// composes older than this aren't listed anymore, no need to look at them
+	lookback = 14 * 24 * time.Hour
+
+	batchSize = 100
+)
+
+// DefaultThresholds lists image types which legitimately take longer than
+// DefaultThreshold to build and upload.
+var DefaultThresholds = map[string]time.Duration{
+	"edge-installer":            6 * time.Hour,
+	"edge-commit":               6 * time.Hour,
+	"rhel-edge-installer":       6 * time.Hour,
+	"rhel-edge-commit":          6 * time.Hour,
+	"image-installer":           6 * time.Hour,
+	"edge-simplified-installer": 6 * time.Hour,
+}
+
+// ComposeStatuser is the part of the composer client the watchdog needs.
+type ComposeStatuser interface {
+	ComposeStatus(id uuid.UUID) (*http.Response, error)
+	CancelCompose(id uuid.UUID) (*http.Response, error)
+}
+
+type Watchdog struct {
+	db               db.DB
+	client           ComposeStatuser
+	region           *string
+	readOnly         *readonly.Mode
+	thresholds       map[string]time.Duration
+	defaultThreshold time.Duration
+}
+
+// New creates a watchdog for the composes created in region, which client
+// has to be the composer of. Composes of other regions are watched by the
+// deployment there, the ones of tenants with a composer of their own by a
+// watchdog per tenant region.
+func New(dbase db.DB, client ComposeStatuser, region *string) *Watchdog {
+	return &Watchdog{
+		db:               dbase,
+		client:           client,
+		region:           region,
+		thresholds:       DefaultThresholds,
+		defaultThreshold: DefaultThreshold,
+	}
+}
+
+// PauseWhileReadOnly skips the checks in read-only mode, composes would time
+// out only because composer is failing over.
+func (w *Watchdog) PauseWhileReadOnly(m *readonly.Mode) *Watchdog {
+	w.readOnly = m
+	return w
+}
+
+func (w *Watchdog) threshold(imageType string) time.Duration {
+	if t, ok := w.thresholds[imageType]; ok {
+		return t
+	}
+	return w.defaultThreshold
+}
+
+func (w *Watchdog) minThreshold() time.Duration {
+	min := w.defaultThreshold
+	for _, t := range w.thresholds {
+		if t < min {
+			min = t
+		}
+	}
+	return min
+}
+
+// Run calls Check every interval until the context is cancelled.
+func (w *Watchdog) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if enabled, _ := w.readOnly.Enabled(); !enabled {
+			err := w.Check(ctx)
+			if err != nil {
+				logrus.Errorf("Watchdog check failed: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check looks at all unfinished composes which are older than their image
+// type's threshold. Composer is asked for their status first, composes which
+// finished in the meantime get their status recorded, the rest is cancelled
+// and marked as failed with ErrorCodeTimeout. The composes are paged through,
+// the ones which are skipped don't hold up the ones after them.
+func (w *Watchdog) Check(ctx context.Context) error {
+	var after *db.UnfinishedCompose
+	for {
+		composes, err := w.db.GetUnfinishedComposes(ctx, w.region, w.minThreshold(), lookback, after, batchSize)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, c := range composes {
+			if now.Sub(c.CreatedAt) < w.threshold(c.ImageType) {
+				continue
+			}
+			err = w.checkStuck(ctx, c)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(composes) < batchSize {
+			return nil
+		}
+		after = &composes[len(composes)-1]
+	}
+}
+
+// checkStuck settles a compose past its threshold, errors of composer only
+// leave it for the next check.
+func (w *Watchdog) checkStuck(ctx context.Context, c db.UnfinishedCompose) error {
+	status, err := w.composerStatus(c.Id)
+	if err != nil {
+		logrus.Warnf("Watchdog unable to query status of compose %v: %v", c.Id, err)
+		return nil
+	}
+
+	if status == composer.ComposeStatusValueSuccess || status == composer.ComposeStatusValueFailure {
+		err = w.db.SetComposeStatus(ctx, c.Id, string(status), nil)
+		if err != nil {
+			return err
+		}
+		prometheus.WatchdogRecovered.Inc()
+		return nil
+	}
+
+	// a compose composer doesn't know about anymore has nothing to cancel,
+	// the others would keep their workers busy
+	if status != "" {
+		err = w.cancel(c.Id)
+		if err != nil {
+			logrus.Warnf("Watchdog unable to cancel compose %v: %v", c.Id, err)
+			return nil
+		}
+	}
+
+	logrus.Infof("Watchdog failing compose %v (org %s, image type %s), stuck since %v", c.Id, c.OrgId, c.ImageType, c.CreatedAt)
+	err = w.db.SetComposeStatus(ctx, c.Id, string(composer.ComposeStatusValueFailure), common.ToPtr(ErrorCodeTimeout))
+	if err != nil {
+		return err
+	}
+	prometheus.WatchdogTimeouts.WithLabelValues(c.ImageType).Inc()
+	return nil
+}
+
+// cancel stops the jobs of the compose.
+func (w *Watchdog) cancel(id uuid.UUID) error {
+	resp, err := w.client.CancelCompose(id)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logrus.Errorf("Unable to close composer response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("composer returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// composerStatus returns an empty status for composes composer doesn't know
+// about anymore, those can't finish either.
+func (w *Watchdog) composerStatus(id uuid.UUID) (composer.ComposeStatusValue, error) {
+	resp, err := w.client.ComposeStatus(id)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logrus.Errorf("Unable to close composer response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", err
+		}
+		// only composer itself tells the compose is gone, the 404s of
+		// proxies in front of it don't
+		if composer.IsComposeNotFound(resp.StatusCode, body) {
+			return "", nil
+		}
+		return "", fmt.Errorf("composer returned %d: %s", resp.StatusCode, body)
+	}
+
+	var status composer.ComposeStatus
+	err = json.NewDecoder(resp.Body).Decode(&status)
+	if err != nil {
+		return "", err
+	}
+	return status.Status, nil
+}
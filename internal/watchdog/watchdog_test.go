@@ -0,0 +1,161 @@
+package watchdog
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/db"
+)
+
+type statusUpdate struct {
+	status    string
+	errorCode *string
+}
+
+type fakeDB struct {
+	db.DB
+	composes []db.UnfinishedCompose
+	updates  map[uuid.UUID]statusUpdate
+}
+
+// the composes are listed in order, as they'd be by age
+func (f *fakeDB) GetUnfinishedComposes(ctx context.Context, region *string, olderThan, newerThan time.Duration, after *db.UnfinishedCompose, limit int) ([]db.UnfinishedCompose, error) {
+	composes := f.composes
+	if after != nil {
+		for i, c := range composes {
+			if c.Id == after.Id {
+				composes = composes[i+1:]
+				break
+			}
+		}
+	}
+	if len(composes) > limit {
+		composes = composes[:limit]
+	}
+	return composes, nil
+}
+
+func (f *fakeDB) SetComposeStatus(ctx context.Context, jobId uuid.UUID, status string, errorCode *string) error {
+	f.updates[jobId] = statusUpdate{status, errorCode}
+	return nil
+}
+
+type fakeComposer struct {
+	url string
+}
+
+func (f *fakeComposer) ComposeStatus(id uuid.UUID) (*http.Response, error) {
+	return http.Get(f.url + "/" + id.String())
+}
+
+func (f *fakeComposer) CancelCompose(id uuid.UUID) (*http.Response, error) {
+	return http.Post(f.url+"/"+id.String()+"/cancel", "application/json", nil)
+}
+
+func TestCheck(t *testing.T) {
+	finished := uuid.New()
+	stuck := uuid.New()
+	gone := uuid.New()
+	slow := uuid.New()
+	uncancellable := uuid.New()
+	proxied := uuid.New()
+
+	var cancelled []string
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if r.URL.Path == "/"+uncancellable.String()+"/cancel" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			cancelled = append(cancelled, r.URL.Path)
+			return
+		}
+		status := composer.ComposeStatusValuePending
+		switch r.URL.Path {
+		case "/" + finished.String():
+			status = composer.ComposeStatusValueSuccess
+		case "/" + gone.String():
+			w.WriteHeader(http.StatusNotFound)
+			_, err := w.Write([]byte(`{"code": "IMAGE-BUILDER-COMPOSER-15", "reason": "Compose with given id not found"}`))
+			require.NoError(t, err)
+			return
+		case "/" + proxied.String():
+			// a proxy in front of composer doesn't know the route
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(composer.ComposeStatus{Status: status}))
+	}))
+	defer apiSrv.Close()
+
+	fdb := &fakeDB{
+		composes: []db.UnfinishedCompose{
+			{Id: finished, ImageType: "aws", CreatedAt: time.Now().Add(-5 * time.Hour)},
+			{Id: stuck, ImageType: "aws", CreatedAt: time.Now().Add(-5 * time.Hour)},
+			{Id: gone, ImageType: "guest-image", CreatedAt: time.Now().Add(-5 * time.Hour)},
+			// installers get more time
+			{Id: slow, ImageType: "image-installer", CreatedAt: time.Now().Add(-5 * time.Hour)},
+			{Id: uncancellable, ImageType: "aws", CreatedAt: time.Now().Add(-5 * time.Hour)},
+			{Id: proxied, ImageType: "aws", CreatedAt: time.Now().Add(-5 * time.Hour)},
+		},
+		updates: map[uuid.UUID]statusUpdate{},
+	}
+
+	wd := New(fdb, &fakeComposer{url: apiSrv.URL}, nil)
+	require.NoError(t, wd.Check(context.Background()))
+
+	require.Len(t, fdb.updates, 3)
+	require.Equal(t, statusUpdate{"success", nil}, fdb.updates[finished])
+	require.Equal(t, "failure", fdb.updates[stuck].status)
+	require.Equal(t, ErrorCodeTimeout, *fdb.updates[stuck].errorCode)
+	require.Equal(t, "failure", fdb.updates[gone].status)
+	require.Equal(t, ErrorCodeTimeout, *fdb.updates[gone].errorCode)
+	require.NotContains(t, fdb.updates, slow)
+	// the compose keeps running, it's retried on the next check
+	require.NotContains(t, fdb.updates, uncancellable)
+	// only composer tells the compose is gone
+	require.NotContains(t, fdb.updates, proxied)
+	require.Equal(t, []string{"/" + stuck.String() + "/cancel"}, cancelled)
+}
+
+func TestCheckPagesPastSkipped(t *testing.T) {
+	stuck := uuid.New()
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			return
+		}
+		if r.URL.Path != "/"+stuck.String() {
+			// composer fails for the ones in front
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(composer.ComposeStatus{Status: composer.ComposeStatusValuePending}))
+	}))
+	defer apiSrv.Close()
+
+	fdb := &fakeDB{
+		updates: map[uuid.UUID]statusUpdate{},
+	}
+	for i := 0; i < batchSize; i++ {
+		fdb.composes = append(fdb.composes,
+			db.UnfinishedCompose{Id: uuid.New(), ImageType: "image-installer", CreatedAt: time.Now().Add(-5 * time.Hour)},
+			db.UnfinishedCompose{Id: uuid.New(), ImageType: "aws", CreatedAt: time.Now().Add(-5 * time.Hour)},
+		)
+	}
+	fdb.composes = append(fdb.composes, db.UnfinishedCompose{Id: stuck, ImageType: "aws", CreatedAt: time.Now().Add(-5 * time.Hour)})
+
+	wd := New(fdb, &fakeComposer{url: apiSrv.URL}, nil)
+	require.NoError(t, wd.Check(context.Background()))
+	require.Len(t, fdb.updates, 1)
+	require.Equal(t, "failure", fdb.updates[stuck].status)
+}
@@ -0,0 +1,70 @@
+// Package watcher follows a status by polling it, passing on only its
+// transitions, so clients can be pushed updates instead of polling
+// themselves.
+package watcher
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// DefaultInterval is how often the status is polled.
+const DefaultInterval = 5 * time.Second
+
+// StatusFunc returns the current status, final once it won't change anymore.
+type StatusFunc[T any] func(ctx context.Context) (status T, final bool, err error)
+
+// Update is a new status, or the error which ended the watch.
+type Update[T any] struct {
+	Status T
+	Err    error
+}
+
+// Watch polls status every interval and sends it whenever it differs from the
+// previous one, starting with the current status. The channel is closed after
+// the final status, after an error, or once the context is done.
+func Watch[T any](ctx context.Context, interval time.Duration, status StatusFunc[T]) <-chan Update[T] {
+	updates := make(chan Update[T])
+	go func() {
+		defer close(updates)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var previous T
+		first := true
+		for {
+			current, final, err := status(ctx)
+			if err != nil {
+				send(ctx, updates, Update[T]{Err: err})
+				return
+			}
+			if first || !reflect.DeepEqual(previous, current) {
+				if !send(ctx, updates, Update[T]{Status: current}) {
+					return
+				}
+				previous = current
+				first = false
+			}
+			if final {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return updates
+}
+
+func send[T any](ctx context.Context, updates chan<- Update[T], u Update[T]) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case updates <- u:
+		return true
+	}
+}
@@ -0,0 +1,66 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func collect[T any](updates <-chan Update[T]) []Update[T] {
+	var result []Update[T]
+	for u := range updates {
+		result = append(result, u)
+	}
+	return result
+}
+
+func TestWatch(t *testing.T) {
+	statuses := []string{"pending", "building", "building", "uploading", "uploading", "success"}
+	polls := 0
+	updates := Watch(context.Background(), time.Millisecond, func(ctx context.Context) (string, bool, error) {
+		s := statuses[polls]
+		polls++
+		return s, s == "success", nil
+	})
+	require.Equal(t, []Update[string]{
+		{Status: "pending"},
+		{Status: "building"},
+		{Status: "uploading"},
+		{Status: "success"},
+	}, collect(updates))
+	require.Equal(t, len(statuses), polls)
+}
+
+func TestWatchFinal(t *testing.T) {
+	updates := Watch(context.Background(), time.Millisecond, func(ctx context.Context) (string, bool, error) {
+		return "failure", true, nil
+	})
+	require.Equal(t, []Update[string]{{Status: "failure"}}, collect(updates))
+}
+
+func TestWatchError(t *testing.T) {
+	failure := errors.New("composer is down")
+	polls := 0
+	updates := Watch(context.Background(), time.Millisecond, func(ctx context.Context) (string, bool, error) {
+		polls++
+		if polls > 1 {
+			return "", false, failure
+		}
+		return "building", false, nil
+	})
+	require.Equal(t, []Update[string]{{Status: "building"}, {Err: failure}}, collect(updates))
+}
+
+func TestWatchCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := Watch(ctx, time.Millisecond, func(ctx context.Context) (string, bool, error) {
+		return "building", false, nil
+	})
+	require.Equal(t, Update[string]{Status: "building"}, <-updates)
+	cancel()
+	// nothing changes anymore, the channel is closed once the watch notices
+	require.Empty(t, collect(updates))
+}
@@ -0,0 +1,124 @@
+// Package worker runs the background subsystems of image-builder: the
+// watchdog failing stuck composes, the lifecycle collector of blueprint
+// composes, the reaper of expired composes, the post-processing hooks of
+// succeeded composes, the pruning of compose events and drafts and the opt-in
+// usage telemetry.
+// They run in the API server, or in image-builder-worker when that is
+// deployed separately. The driver of the compose pipelines submits composes
+// through the API and always runs in the API server.
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/osbuild/image-builder/internal/clients/composer"
+	"github.com/osbuild/image-builder/internal/common"
+	"github.com/osbuild/image-builder/internal/config"
+	"github.com/osbuild/image-builder/internal/db"
+	"github.com/osbuild/image-builder/internal/drafts"
+	"github.com/osbuild/image-builder/internal/events"
+	"github.com/osbuild/image-builder/internal/gc"
+	"github.com/osbuild/image-builder/internal/hooks"
+	"github.com/osbuild/image-builder/internal/lifecycle"
+	"github.com/osbuild/image-builder/internal/pipelines"
+	"github.com/osbuild/image-builder/internal/readonly"
+	"github.com/osbuild/image-builder/internal/telemetry"
+	"github.com/osbuild/image-builder/internal/watchdog"
+)
+
+// Composer is the part of the composer client the subsystems need.
+type Composer interface {
+	watchdog.ComposeStatuser
+	gc.ComposeDeleter
+}
+
+// Start runs the enabled subsystems in the background until the context is
+// cancelled, client has to be the composer of the configured region. The
+// composes of tenants with a composer of their own are watched, collected and
+// post-processed in the region of the tenant, with its composer.
+func Start(ctx context.Context, conf *config.ImageBuilderConfig, dbase db.DB, client Composer, tenants map[string]composer.Tenant, readOnly *readonly.Mode) error {
+	watchdogInterval, err := parseInterval(conf.WatchdogInterval, watchdog.DefaultInterval)
+	if err != nil {
+		return err
+	}
+	lifecycleInterval, err := parseInterval(conf.LifecycleInterval, lifecycle.DefaultInterval)
+	if err != nil {
+		return err
+	}
+	gcInterval, err := parseInterval(conf.GCInterval, gc.DefaultInterval)
+	if err != nil {
+		return err
+	}
+	hooksInterval, err := parseInterval(conf.HooksInterval, hooks.DefaultInterval)
+	if err != nil {
+		return err
+	}
+	eventsRetention, err := parseInterval(conf.EventsRetention, events.DefaultRetention)
+	if err != nil {
+		return err
+	}
+	draftsRetention, err := parseInterval(conf.DraftsRetention, drafts.DefaultRetention)
+	if err != nil {
+		return err
+	}
+	telemetryInterval, err := parseInterval(conf.TelemetryInterval, telemetry.DefaultInterval)
+	if err != nil {
+		return err
+	}
+	if conf.TelemetryEnabled && conf.TelemetryURL == "" {
+		return errors.New("TELEMETRY_ENABLED needs the endpoint to send to in TELEMETRY_URL")
+	}
+
+	var region *string
+	if conf.ComposerRegion != "" {
+		region = &conf.ComposerRegion
+	}
+	startRegional := func(client Composer, region *string) {
+		if conf.WatchdogEnabled {
+			go watchdog.New(dbase, client, region).PauseWhileReadOnly(readOnly).Run(ctx, watchdogInterval)
+		}
+		if conf.GCEnabled {
+			go gc.New(dbase, client, region).PauseWhileReadOnly(readOnly).Run(ctx, gcInterval)
+		}
+		// the hooks are registered before the subsystems are started
+		if len(hooks.Registered()) > 0 {
+			go hooks.New(dbase, region).PauseWhileReadOnly(readOnly).Run(ctx, hooksInterval)
+		}
+	}
+	startRegional(client, region)
+	for orgID, tenant := range tenants {
+		if tenant.Client == nil {
+			continue
+		}
+		startRegional(tenant.Client, common.ToPtr(composer.TenantRegion(orgID)))
+	}
+	if conf.LifecycleEnabled {
+		go lifecycle.New(dbase).PauseWhileReadOnly(readOnly).Run(ctx, lifecycleInterval)
+	}
+	if conf.TelemetryEnabled {
+		go telemetry.New(dbase, conf.TelemetryURL, nil).Run(ctx, telemetryInterval)
+	}
+	go events.RunRetention(ctx, dbase, eventsRetention)
+	go drafts.RunRetention(ctx, dbase, draftsRetention)
+	return nil
+}
+
+// StartPipelines drives the waiting compose pipelines in the background until
+// the context is cancelled, advancer submits their next composes.
+func StartPipelines(ctx context.Context, conf *config.ImageBuilderConfig, dbase db.DB, advancer pipelines.Advancer, readOnly *readonly.Mode) error {
+	interval, err := parseInterval(conf.PipelinesInterval, pipelines.DefaultInterval)
+	if err != nil {
+		return err
+	}
+	go pipelines.New(dbase, advancer).PauseWhileReadOnly(readOnly).Run(ctx, interval)
+	return nil
+}
+
+func parseInterval(value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	return time.ParseDuration(value)
+}
@@ -0,0 +1,31 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/image-builder/internal/config"
+)
+
+func TestParseInterval(t *testing.T) {
+	interval, err := parseInterval("", time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, interval)
+
+	interval, err = parseInterval("90s", time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, 90*time.Second, interval)
+
+	_, err = parseInterval("often", time.Hour)
+	require.Error(t, err)
+}
+
+func TestStartTelemetryNeedsURL(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := Start(ctx, &config.ImageBuilderConfig{TelemetryEnabled: true}, nil, nil, nil, nil)
+	require.ErrorContains(t, err, "TELEMETRY_URL")
+}
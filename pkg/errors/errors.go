@@ -0,0 +1,92 @@
+// Package errors holds the errors image-builder reports to its clients. Each
+// one has a code which is part of the API, clients tell the errors apart by
+// their codes rather than by their messages, which can change.
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type Code string
+
+const (
+	// CodeQuotaExceeded is reported when the compose quota of the organization
+	// is used up.
+	CodeQuotaExceeded Code = "quota-exceeded"
+	// CodeForbiddenDistribution is reported when the organization isn't
+	// allowed to build images of the distribution.
+	CodeForbiddenDistribution Code = "forbidden-distribution"
+	// CodeInvalidCustomization is reported when the customizations of a
+	// request are rejected.
+	CodeInvalidCustomization Code = "invalid-customization"
+	// CodeUpstreamUnavailable is reported when a service image-builder relies
+	// on failed, retrying later can help.
+	CodeUpstreamUnavailable Code = "upstream-unavailable"
+)
+
+// The errors to compare with errors.Is, they match any error of their code.
+var (
+	ErrQuotaExceeded         = &Error{Code: CodeQuotaExceeded}
+	ErrForbiddenDistribution = &Error{Code: CodeForbiddenDistribution}
+	ErrInvalidCustomization  = &Error{Code: CodeInvalidCustomization}
+	ErrUpstreamUnavailable   = &Error{Code: CodeUpstreamUnavailable}
+)
+
+type Error struct {
+	Code    Code
+	Status  int
+	Message string
+}
+
+func New(code Code, status int, message string) *Error {
+	return &Error{
+		Code:    code,
+		Status:  status,
+		Message: message,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code != "" && t.Code == e.Code
+}
+
+// errorList is the body of the error responses of the API.
+type errorList struct {
+	Errors []struct {
+		Code   Code   `json:"code"`
+		Detail string `json:"detail"`
+	} `json:"errors"`
+}
+
+// FromResponse returns the error reported by a response of the API, nil for
+// successful ones. The first error with a code is returned, responses without
+// any have the code left empty.
+func FromResponse(status int, body []byte) *Error {
+	if status < http.StatusBadRequest {
+		return nil
+	}
+	e := New("", status, http.StatusText(status))
+	var list errorList
+	if json.Unmarshal(body, &list) != nil || len(list.Errors) == 0 {
+		return e
+	}
+	e.Message = list.Errors[0].Detail
+	for _, item := range list.Errors {
+		if item.Code != "" {
+			e.Code = item.Code
+			e.Message = item.Detail
+			break
+		}
+	}
+	return e
+}
@@ -0,0 +1,38 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIs(t *testing.T) {
+	err := fmt.Errorf("compose failed: %w", New(CodeQuotaExceeded, http.StatusForbidden, "Quota exceeded for user"))
+	require.ErrorIs(t, err, ErrQuotaExceeded)
+	require.NotErrorIs(t, err, ErrUpstreamUnavailable)
+	require.NotErrorIs(t, New("", http.StatusBadRequest, "bad"), &Error{})
+
+	var e *Error
+	require.True(t, errors.As(err, &e))
+	require.Equal(t, http.StatusForbidden, e.Status)
+	require.Equal(t, "quota-exceeded: Quota exceeded for user", e.Error())
+}
+
+func TestFromResponse(t *testing.T) {
+	require.Nil(t, FromResponse(http.StatusOK, []byte(`{"id": "1"}`)))
+
+	e := FromResponse(http.StatusForbidden, []byte(`{"errors": [{"title": "403", "detail": "Quota exceeded for user", "code": "quota-exceeded"}]}`))
+	require.Equal(t, New(CodeQuotaExceeded, http.StatusForbidden, "Quota exceeded for user"), e)
+
+	e = FromResponse(http.StatusBadRequest, []byte(`{"errors": [{"title": "400", "detail": "first"}, {"title": "400", "detail": "second", "code": "invalid-customization"}]}`))
+	require.Equal(t, New(CodeInvalidCustomization, http.StatusBadRequest, "second"), e)
+
+	e = FromResponse(http.StatusNotFound, []byte(`{"errors": [{"title": "404", "detail": "Compose not found"}]}`))
+	require.Equal(t, New("", http.StatusNotFound, "Compose not found"), e)
+
+	e = FromResponse(http.StatusBadGateway, []byte("<html>"))
+	require.Equal(t, New("", http.StatusBadGateway, "Bad Gateway"), e)
+}